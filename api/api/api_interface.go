@@ -145,6 +145,9 @@ type ServiceInterface interface {
 	GetDeployVersion(w http.ResponseWriter, r *http.Request)
 	AutoscalerRules(w http.ResponseWriter, r *http.Request)
 	ScalingRecords(w http.ResponseWriter, r *http.Request)
+	ScheduledScaling(w http.ResponseWriter, r *http.Request)
+	DeleteScheduledScaling(w http.ResponseWriter, r *http.Request)
+	IdlePolicy(w http.ResponseWriter, r *http.Request)
 	AddServiceMonitors(w http.ResponseWriter, r *http.Request)
 	DeleteServiceMonitors(w http.ResponseWriter, r *http.Request)
 	UpdateServiceMonitors(w http.ResponseWriter, r *http.Request)
@@ -167,6 +170,10 @@ type TenantInterfaceWithV1 interface {
 	CreateVMExport(w http.ResponseWriter, r *http.Request)
 	GetVMExport(w http.ResponseWriter, r *http.Request)
 	CreateVMSnapshot(w http.ResponseWriter, r *http.Request)
+	ListVMSnapshots(w http.ResponseWriter, r *http.Request)
+	GetVMSnapshot(w http.ResponseWriter, r *http.Request)
+	DeleteVMSnapshot(w http.ResponseWriter, r *http.Request)
+	RestoreVMSnapshot(w http.ResponseWriter, r *http.Request)
 	FileManageService(w http.ResponseWriter, r *http.Request)
 	DeployService(w http.ResponseWriter, r *http.Request)
 	UpgradeService(w http.ResponseWriter, r *http.Request)
@@ -186,6 +193,7 @@ type LogInterface interface {
 	Events(w http.ResponseWriter, r *http.Request)
 	EventLog(w http.ResponseWriter, r *http.Request)
 	MyTeamsEvents(w http.ResponseWriter, r *http.Request)
+	SearchEventLogs(w http.ResponseWriter, r *http.Request)
 }
 
 // PluginInterface plugin interface
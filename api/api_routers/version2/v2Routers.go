@@ -62,6 +62,7 @@ func (v2 *V2) Routes() chi.Router {
 	r.Get("/version", controller.GetManager().Version)
 	// deprecated use /gateway/ports
 	r.Mount("/port", v2.portRouter())
+	r.Get("/event-log/search", controller.GetManager().SearchEventLogs)
 	// deprecated, use /events/<event_id>/log
 	r.Get("/event-log", controller.GetManager().LogByAction)
 	r.Mount("/events", v2.eventsRouter())
@@ -344,6 +345,11 @@ func (v2 *V2) clusterRouter() chi.Router {
 	r.Get("/platform-resources/{name}", controller.GetClusterResourceController().GetResource)
 	r.Put("/platform-resources/{name}", controller.GetClusterResourceController().UpdateResource)
 	r.Delete("/platform-resources/{name}", controller.GetClusterResourceController().DeleteResource)
+	// MQ dead letters
+	r.Get("/mq/{topic}/dead-letters", controller.GetDeadLetterController().ListDeadLetters)
+	r.Get("/mq/{topic}/dead-letters/{message_id}", controller.GetDeadLetterController().GetDeadLetter)
+	r.Post("/mq/{topic}/dead-letters/replay", controller.GetDeadLetterController().ReplayDeadLetters)
+	r.Delete("/mq/{topic}/dead-letters", controller.GetDeadLetterController().PurgeDeadLetters)
 	return r
 }
 
@@ -505,6 +511,10 @@ func (v2 *V2) serviceRouter() chi.Router {
 	r.Post("/vm-exports", middleware.WrapEL(controller.GetManager().CreateVMExport, dbmodel.TargetTypeService, "export-vm", dbmodel.SYNEVENTTYPE, true))
 	r.Get("/vm-exports/{name}", controller.GetManager().GetVMExport)
 	r.Post("/vm-snapshots", middleware.WrapEL(controller.GetManager().CreateVMSnapshot, dbmodel.TargetTypeService, "snapshot-vm", dbmodel.SYNEVENTTYPE, true))
+	r.Get("/vm-snapshots", controller.GetManager().ListVMSnapshots)
+	r.Get("/vm-snapshots/{name}", controller.GetManager().GetVMSnapshot)
+	r.Delete("/vm-snapshots/{name}", middleware.WrapEL(controller.GetManager().DeleteVMSnapshot, dbmodel.TargetTypeService, "delete-vm-snapshot", dbmodel.SYNEVENTTYPE, true))
+	r.Post("/vm-snapshots/{name}/restore", middleware.WrapEL(controller.GetManager().RestoreVMSnapshot, dbmodel.TargetTypeService, "restore-vm-snapshot", dbmodel.ASYNEVENTTYPE, true))
 	r.Post("/start", middleware.WrapEL(controller.GetManager().StartService, dbmodel.TargetTypeService, "start-service", dbmodel.ASYNEVENTTYPE, true))
 	// component stop event set to synchronous event, not wait.
 	r.Post("/stop", middleware.WrapEL(controller.GetManager().StopService, dbmodel.TargetTypeService, "stop-service", dbmodel.SYNEVENTTYPE, true))
@@ -625,6 +635,12 @@ func (v2 *V2) serviceRouter() chi.Router {
 	r.Post("/xparules", middleware.WrapEL(controller.GetManager().AutoscalerRules, dbmodel.TargetTypeService, "add-app-autoscaler-rule", dbmodel.SYNEVENTTYPE, false))
 	r.Put("/xparules", middleware.WrapEL(controller.GetManager().AutoscalerRules, dbmodel.TargetTypeService, "update-app-autoscaler-rule", dbmodel.SYNEVENTTYPE, false))
 	r.Get("/xparecords", controller.GetManager().ScalingRecords)
+	r.Get("/scheduled-scaling", controller.GetManager().ScheduledScaling)
+	r.Post("/scheduled-scaling", middleware.WrapEL(controller.GetManager().ScheduledScaling, dbmodel.TargetTypeService, "add-app-scheduled-scaling", dbmodel.SYNEVENTTYPE, false))
+	r.Put("/scheduled-scaling", middleware.WrapEL(controller.GetManager().ScheduledScaling, dbmodel.TargetTypeService, "update-app-scheduled-scaling", dbmodel.SYNEVENTTYPE, false))
+	r.Delete("/scheduled-scaling/{schedule_id}", middleware.WrapEL(controller.GetManager().DeleteScheduledScaling, dbmodel.TargetTypeService, "delete-app-scheduled-scaling", dbmodel.SYNEVENTTYPE, false))
+	r.Get("/idle-policy", controller.GetManager().IdlePolicy)
+	r.Put("/idle-policy", middleware.WrapEL(controller.GetManager().IdlePolicy, dbmodel.TargetTypeService, "update-app-idle-policy", dbmodel.SYNEVENTTYPE, false))
 
 	//service monitor
 	r.Post("/service-monitors", middleware.WrapEL(controller.GetManager().AddServiceMonitors, dbmodel.TargetTypeService, "add-app-service-monitor", dbmodel.SYNEVENTTYPE, false))
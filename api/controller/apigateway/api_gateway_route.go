@@ -26,10 +26,14 @@ import (
 	"github.com/goodrain/rainbond/api/util"
 	"github.com/goodrain/rainbond/api/util/bcode"
 	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
+	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	"github.com/goodrain/rainbond/pkg/component/k8s"
+	"github.com/goodrain/rainbond/util/constants"
 	httputil "github.com/goodrain/rainbond/util/http"
+	"github.com/goodrain/rainbond/worker/activator"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -290,6 +294,12 @@ func (g Struct) CreateHTTPAPIRoute(w http.ResponseWriter, r *http.Request) {
 		//}
 	}
 
+	apisixRouteHTTP = useWeightedUpstreams(r.Context(), c, tenant.Namespace, apisixRouteHTTP)
+	if token, err := activator.GetToken(r.Context(), k8s.Default().Clientset.CoreV1().Secrets(configs.Default().PublicConfig.RbdNamespace)); err != nil {
+		logrus.Warningf("get activator token failure, route to the component services directly: %v", err)
+	} else {
+		apisixRouteHTTP = useActivator(r.Context(), c, k8s.Default().Clientset.CoreV1().Services(tenant.Namespace), tenant.Namespace, idlePolicyEnabled, token, apisixRouteHTTP)
+	}
 	apisixRouteHTTP.Name = uuid.New().String()[0:8] //每次都让他变化，让 apisix controller去更新
 
 	route, err := c.ApisixRoutes(tenant.Namespace).Create(r.Context(), &v2.ApisixRoute{
@@ -350,6 +360,119 @@ func (g Struct) CreateHTTPAPIRoute(w http.ResponseWriter, r *http.Request) {
 	httputil.ReturnSuccess(r, w, marshalApisixRoute(update))
 }
 
+// useWeightedUpstreams 第三方组件实例设置了权重时，路由改为引用控制器生成的带权重的上游
+func useWeightedUpstreams(ctx context.Context, c versionedApisixV2, namespace string, apisixRouteHTTP v2.ApisixRouteHTTP) v2.ApisixRouteHTTP {
+	var backends []v2.ApisixRouteHTTPBackend
+	for _, b := range apisixRouteHTTP.Backends {
+		name := v1alpha1.ThirdComponentUpstreamName(b.ServiceName, b.ServicePort.IntValue())
+		if _, err := c.ApisixUpstreams(namespace).Get(ctx, name, v1.GetOptions{}); err != nil {
+			backends = append(backends, b)
+			continue
+		}
+		apisixRouteHTTP.Upstreams = append(apisixRouteHTTP.Upstreams, v2.ApisixRouteUpstreamReference{
+			Name:   name,
+			Weight: b.Weight,
+		})
+	}
+	apisixRouteHTTP.Backends = backends
+	return apisixRouteHTTP
+}
+
+// idlePolicyEnabled 组件是否开启了闲置缩容到零
+func idlePolicyEnabled(serviceID string) bool {
+	policy, err := db.GetManager().TenantServiceIdlePolicyDao().GetByServiceID(serviceID)
+	return err == nil && policy.Enable
+}
+
+// activatorReadTimeout 网关等待激活器返回的时间，需要覆盖组件从零启动的时间
+const activatorReadTimeout = 5 * time.Minute
+
+// useActivator 开启了闲置缩容的组件，路由改为经由 rbd-worker 中的激活器转发，
+// 组件缩容到零后激活器会保持请求并唤醒组件。组件运行时请求同样经过激活器，多一跳转发，
+// 关闭闲置缩容时路由恢复为直接转发到组件。
+// 网关通过请求头携带激活器的 token，为避免 token 发送到其他后端，路由中的后端须全部开启闲置缩容
+func useActivator(ctx context.Context, c versionedApisixV2, services typedcorev1.ServiceInterface, namespace string, enabled func(serviceID string) bool, token string, apisixRouteHTTP v2.ApisixRouteHTTP) v2.ApisixRouteHTTP {
+	serviceIDs := make(map[string]string)
+	for _, b := range apisixRouteHTTP.Backends {
+		svc, err := services.Get(ctx, b.ServiceName, v1.GetOptions{})
+		if err != nil || svc.Labels["service_id"] == "" || !enabled(svc.Labels["service_id"]) {
+			continue
+		}
+		serviceIDs[b.ServiceName] = svc.Labels["service_id"]
+	}
+	if len(serviceIDs) == 0 {
+		return apisixRouteHTTP
+	}
+	if len(serviceIDs) != len(apisixRouteHTTP.Backends) || len(apisixRouteHTTP.Upstreams) > 0 {
+		logrus.Warningf("the route has backends without the idle policy, route to the component services directly")
+		return apisixRouteHTTP
+	}
+
+	var backends []v2.ApisixRouteHTTPBackend
+	var upstreams []v2.ApisixRouteUpstreamReference
+	for _, b := range apisixRouteHTTP.Backends {
+		port := b.ServicePort.IntValue()
+		name := fmt.Sprintf("%s-%d-activator", b.ServiceName, port)
+		activatorPort := constants.ActivatorPort
+		upstream := &v2.ApisixUpstream{
+			TypeMeta: v1.TypeMeta{
+				Kind:       "ApisixUpstream",
+				APIVersion: util.APIVersion,
+			},
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"creator":                        "Rainbond",
+					"service_id":                     serviceIDs[b.ServiceName],
+					constants.ActivatorUpstreamLabel: "true",
+				},
+			},
+			Spec: &v2.ApisixUpstreamSpec{
+				IngressClassName: "apisix",
+				ExternalNodes: []v2.ApisixUpstreamExternalNode{{
+					Name: fmt.Sprintf("rbd-worker.%s", configs.Default().PublicConfig.RbdNamespace),
+					Type: v2.ExternalTypeDomain,
+					Port: &activatorPort,
+				}},
+				ApisixUpstreamConfig: v2.ApisixUpstreamConfig{
+					// 激活器根据 Host 找到目标组件，原始 Host 通过 X-Forwarded-Host 传递
+					PassHost:     "rewrite",
+					UpstreamHost: fmt.Sprintf("%s.%s:%d", b.ServiceName, namespace, port),
+					Timeout: &v2.UpstreamTimeout{
+						Read: v1.Duration{Duration: activatorReadTimeout},
+					},
+				},
+			},
+		}
+		old, err := c.ApisixUpstreams(namespace).Get(ctx, name, v1.GetOptions{})
+		if err == nil {
+			old.Labels = upstream.Labels
+			old.Spec = upstream.Spec
+			_, err = c.ApisixUpstreams(namespace).Update(ctx, old, v1.UpdateOptions{})
+		} else if k8sErrors.IsNotFound(err) {
+			_, err = c.ApisixUpstreams(namespace).Create(ctx, upstream, v1.CreateOptions{})
+		}
+		if err != nil {
+			logrus.Errorf("apply activator upstream %s failure: %v", name, err)
+			backends = append(backends, b)
+			continue
+		}
+		upstreams = append(upstreams, v2.ApisixRouteUpstreamReference{
+			Name:   name,
+			Weight: b.Weight,
+		})
+	}
+	if len(backends) > 0 {
+		// 部分上游创建失败时整条路由直接转发，同样避免 token 发送到组件
+		return apisixRouteHTTP
+	}
+	apisixRouteHTTP.Backends = nil
+	apisixRouteHTTP.Upstreams = upstreams
+	apisixRouteHTTP.Plugins = activator.WithToken(apisixRouteHTTP.Plugins, token)
+	return apisixRouteHTTP
+}
+
 func marshalApisixRoute(r *v2.ApisixRoute) map[string]interface{} {
 	r.TypeMeta.Kind = util.ApisixRoute
 	r.TypeMeta.APIVersion = util.APIVersion
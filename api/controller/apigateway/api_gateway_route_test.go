@@ -10,12 +10,15 @@ import (
 	"testing"
 
 	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	apisixfake "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/fake"
 	"github.com/go-chi/chi"
 	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
 	"github.com/goodrain/rainbond/db"
 	dbdao "github.com/goodrain/rainbond/db/dao"
 	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	"github.com/goodrain/rainbond/pkg/component/k8s"
+	"github.com/goodrain/rainbond/util/constants"
 	"github.com/jinzhu/gorm"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -25,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 )
 
@@ -232,6 +236,92 @@ func TestRouteMatchesCertManagerDomains(t *testing.T) {
 	}
 }
 
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestUseWeightedUpstreams(t *testing.T) {
+	c := apisixfake.NewSimpleClientset(&v2.ApisixUpstream{
+		ObjectMeta: v1.ObjectMeta{Name: v1alpha1.ThirdComponentUpstreamName("legacy-80", 80), Namespace: "tenant"},
+	}).ApisixV2()
+	weight := 30
+	route := useWeightedUpstreams(context.Background(), c, "tenant", v2.ApisixRouteHTTP{
+		Backends: []v2.ApisixRouteHTTPBackend{
+			{ServiceName: "legacy-80", ServicePort: intstr.FromInt(80), Weight: &weight},
+			{ServiceName: "web-5000", ServicePort: intstr.FromInt(5000)},
+		},
+	})
+	if len(route.Backends) != 1 || route.Backends[0].ServiceName != "web-5000" {
+		t.Fatalf("unexpected backends %+v", route.Backends)
+	}
+	if len(route.Upstreams) != 1 || route.Upstreams[0].Name != "legacy-80-80-weighted" || *route.Upstreams[0].Weight != 30 {
+		t.Fatalf("unexpected upstreams %+v", route.Upstreams)
+	}
+
+	// 其他命名空间的上游不影响路由
+	route = useWeightedUpstreams(context.Background(), c, "other", v2.ApisixRouteHTTP{
+		Backends: []v2.ApisixRouteHTTPBackend{{ServiceName: "legacy-80", ServicePort: intstr.FromInt(80)}},
+	})
+	if len(route.Backends) != 1 || len(route.Upstreams) != 0 {
+		t.Fatalf("route should keep service backend, got %+v", route)
+	}
+}
+
+// capability_id: rainbond.gateway.route.activator-upstream
+func TestUseActivator(t *testing.T) {
+	services := kubefake.NewSimpleClientset(
+		&corev1.Service{ObjectMeta: v1.ObjectMeta{Name: "idle-5000", Namespace: "tenant", Labels: map[string]string{"service_id": "idle"}}},
+		&corev1.Service{ObjectMeta: v1.ObjectMeta{Name: "busy-8080", Namespace: "tenant", Labels: map[string]string{"service_id": "busy"}}},
+	).CoreV1().Services("tenant")
+	c := apisixfake.NewSimpleClientset().ApisixV2()
+	enabled := func(serviceID string) bool { return serviceID == "idle" }
+	weight := 40
+
+	// 路由中有未开启闲置缩容的后端时保持直接转发，token 不会发送到这些后端
+	mixed := v2.ApisixRouteHTTP{
+		Backends: []v2.ApisixRouteHTTPBackend{
+			{ServiceName: "idle-5000", ServicePort: intstr.FromInt(5000), Weight: &weight},
+			{ServiceName: "busy-8080", ServicePort: intstr.FromInt(8080)},
+		},
+	}
+	route := useActivator(context.Background(), c, services, "tenant", enabled, "secret", mixed)
+	if len(route.Backends) != 2 || len(route.Upstreams) != 0 || len(route.Plugins) != 0 {
+		t.Fatalf("mixed route should keep the service backends, got %+v", route)
+	}
+
+	route = useActivator(context.Background(), c, services, "tenant", enabled, "secret", v2.ApisixRouteHTTP{
+		Backends: []v2.ApisixRouteHTTPBackend{{ServiceName: "idle-5000", ServicePort: intstr.FromInt(5000), Weight: &weight}},
+		Plugins: []v2.ApisixRoutePlugin{{Name: "proxy-rewrite", Enable: true, Config: v2.ApisixRoutePluginConfig{
+			"headers": map[string]interface{}{"X-Custom": "1"},
+		}}},
+	})
+	if len(route.Backends) != 0 {
+		t.Fatalf("unexpected backends %+v", route.Backends)
+	}
+	if len(route.Upstreams) != 1 || route.Upstreams[0].Name != "idle-5000-5000-activator" || *route.Upstreams[0].Weight != 40 {
+		t.Fatalf("unexpected upstreams %+v", route.Upstreams)
+	}
+	headers := route.Plugins[0].Config["headers"].(map[string]interface{})
+	if len(route.Plugins) != 1 || headers[constants.ActivatorTokenHeader] != "secret" || headers["X-Custom"] != "1" {
+		t.Fatalf("the token header should be merged into proxy-rewrite, got %+v", route.Plugins)
+	}
+	upstream, err := c.ApisixUpstreams("tenant").Get(context.Background(), "idle-5000-5000-activator", v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("activator upstream not created: %v", err)
+	}
+	if upstream.Labels["service_id"] != "idle" || upstream.Labels[constants.ActivatorUpstreamLabel] != "true" {
+		t.Fatalf("unexpected labels %v", upstream.Labels)
+	}
+	if upstream.Spec.UpstreamHost != "idle-5000.tenant:5000" || *upstream.Spec.ExternalNodes[0].Port != constants.ActivatorPort {
+		t.Fatalf("unexpected spec %+v", upstream.Spec)
+	}
+
+	// 再次保存路由时更新已有的上游
+	route = useActivator(context.Background(), c, services, "tenant", enabled, "secret", v2.ApisixRouteHTTP{
+		Backends: []v2.ApisixRouteHTTPBackend{{ServiceName: "idle-5000", ServicePort: intstr.FromInt(5000)}},
+	})
+	if len(route.Backends) != 0 || len(route.Upstreams) != 1 || len(route.Plugins) != 1 {
+		t.Fatalf("route should reference the activator upstream, got %+v", route)
+	}
+}
+
 func TestCreateTCPRouteUsesRainbondServiceAliasFromBackendServiceLabels(t *testing.T) {
 	const (
 		namespace    = "default"
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/go-chi/chi"
 	"github.com/jinzhu/gorm"
 	"github.com/sirupsen/logrus"
 
@@ -48,6 +49,10 @@ func (t *TenantStruct) addAutoscalerRule(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
 
 	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
 	req.ServiceID = serviceID
@@ -70,6 +75,10 @@ func (t *TenantStruct) updAutoscalerRule(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
 
 	if err := handler.GetServiceManager().UpdAutoscalerRule(&req); err != nil {
 		if err == errors.ErrRecordAlreadyExist {
@@ -128,3 +137,125 @@ func (t *TenantStruct) listScalingRecords(w http.ResponseWriter, r *http.Request
 		"data":  records,
 	})
 }
+
+// ScheduledScaling -
+func (t *TenantStruct) ScheduledScaling(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		t.listScheduledScaling(w, r)
+	case "POST":
+		t.addScheduledScaling(w, r)
+	case "PUT":
+		t.updScheduledScaling(w, r)
+	}
+}
+
+func (t *TenantStruct) listScheduledScaling(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	schedules, err := handler.GetServiceManager().ListScheduledScaling(serviceID)
+	if err != nil {
+		logrus.Errorf("list scheduled scaling: %v", err)
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, schedules)
+}
+
+func (t *TenantStruct) addScheduledScaling(w http.ResponseWriter, r *http.Request) {
+	var req model.ScheduledScalingReq
+	ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil)
+	if !ok {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
+
+	req.ServiceID = r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	if err := handler.GetServiceManager().AddScheduledScaling(&req); err != nil {
+		if err == errors.ErrRecordAlreadyExist {
+			httputil.ReturnError(r, w, 400, err.Error())
+			return
+		}
+		logrus.Errorf("add scheduled scaling: %v", err)
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+
+	httputil.ReturnSuccess(r, w, nil)
+}
+
+func (t *TenantStruct) updScheduledScaling(w http.ResponseWriter, r *http.Request) {
+	var req model.ScheduledScalingReq
+	ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil)
+	if !ok {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
+
+	req.ServiceID = r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	if err := handler.GetServiceManager().UpdScheduledScaling(&req); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.ReturnError(r, w, 404, err.Error())
+			return
+		}
+		logrus.Errorf("update scheduled scaling: %v", err)
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+
+	httputil.ReturnSuccess(r, w, nil)
+}
+
+// DeleteScheduledScaling -
+func (t *TenantStruct) DeleteScheduledScaling(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	scheduleID := chi.URLParam(r, "schedule_id")
+	if err := handler.GetServiceManager().DelScheduledScaling(serviceID, scheduleID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.ReturnError(r, w, 404, err.Error())
+			return
+		}
+		logrus.Errorf("delete scheduled scaling: %v", err)
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+
+	httputil.ReturnSuccess(r, w, nil)
+}
+
+// IdlePolicy -
+func (t *TenantStruct) IdlePolicy(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	switch r.Method {
+	case "GET":
+		policy, err := handler.GetServiceManager().GetIdlePolicy(serviceID)
+		if err != nil {
+			logrus.Errorf("get idle policy: %v", err)
+			httputil.ReturnError(r, w, 500, err.Error())
+			return
+		}
+		httputil.ReturnSuccess(r, w, policy)
+	case "PUT":
+		var req model.IdlePolicyReq
+		ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil)
+		if !ok {
+			return
+		}
+		if err := req.Validate(); err != nil {
+			httputil.ReturnError(r, w, 400, err.Error())
+			return
+		}
+		policy, err := handler.GetServiceManager().SetIdlePolicy(serviceID, &req)
+		if err != nil {
+			logrus.Errorf("set idle policy: %v", err)
+			httputil.ReturnError(r, w, 500, err.Error())
+			return
+		}
+		httputil.ReturnSuccess(r, w, policy)
+	}
+}
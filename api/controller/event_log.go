@@ -25,6 +25,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	httputil "github.com/goodrain/rainbond/util/http"
 
@@ -35,6 +36,8 @@ import (
 	api_model "github.com/goodrain/rainbond/api/model"
 	"github.com/goodrain/rainbond/api/proxy"
 	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
+	"github.com/goodrain/rainbond/config/configs"
+	dbmodel "github.com/goodrain/rainbond/db/model"
 )
 
 //EventLogStruct eventlog struct
@@ -313,3 +316,65 @@ func (e *EventLogStruct) MyTeamsEvents(w http.ResponseWriter, r *http.Request) {
 	}
 	httputil.ReturnList(r, w, size, page, res)
 }
+
+//SearchEventLogs search event log messages by tenant, service, step, level, keyword and time range
+func (e *EventLogStruct) SearchEventLogs(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v2/event-log/search v2 searchEventLogs
+	//
+	// 跨事件检索操作日志，start_time 和 end_time 为 RFC3339 格式，结果按消息时间倒序分页返回
+	//
+	// search event logs
+	//
+	// ---
+	// produces:
+	// - application/json
+	//
+	// responses:
+	//   default:
+	//     schema:
+	//       "$ref": "#/responses/commandResponse"
+	//     description: 统一返回格式
+	if !configs.Default().EventLogConfig.Conf.EventStore.SearchIndex {
+		httputil.ReturnError(r, w, 503, "search index disabled, enable it with --eventlog.search.index")
+		return
+	}
+	query := &dbmodel.EventLogSearchQuery{
+		TenantID:  r.FormValue("tenant_id"),
+		ServiceID: r.FormValue("service_id"),
+		EventID:   r.FormValue("event_id"),
+		OptType:   r.FormValue("opt_type"),
+		Step:      r.FormValue("step"),
+		Level:     r.FormValue("level"),
+		Keyword:   strings.TrimSpace(r.FormValue("keyword")),
+	}
+	var err error
+	if startTime := r.FormValue("start_time"); startTime != "" {
+		if query.StartTime, err = time.Parse(time.RFC3339, startTime); err != nil {
+			httputil.ReturnError(r, w, 400, "start_time must be RFC3339 format")
+			return
+		}
+	}
+	if endTime := r.FormValue("end_time"); endTime != "" {
+		if query.EndTime, err = time.Parse(time.RFC3339, endTime); err != nil {
+			httputil.ReturnError(r, w, 400, "end_time must be RFC3339 format")
+			return
+		}
+	}
+	var page, size int
+	if page, err = strconv.Atoi(r.FormValue("page")); err != nil || page <= 0 {
+		page = 1
+	}
+	if size, err = strconv.Atoi(r.FormValue("size")); err != nil || size <= 0 {
+		size = 10
+	}
+	if size > 100 {
+		size = 100
+	}
+	list, total, err := handler.GetEventHandler().SearchEventLogs(query, page, size)
+	if err != nil {
+		logrus.Errorf("search event log error, %v", err)
+		httputil.ReturnError(r, w, 500, "search event log error")
+		return
+	}
+	httputil.ReturnList(r, w, total, page, list)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/goodrain/rainbond/api/handler"
+	apimodel "github.com/goodrain/rainbond/api/model"
+	httputil "github.com/goodrain/rainbond/util/http"
+)
+
+// DeadLetterController handles HTTP requests for MQ dead letters
+type DeadLetterController struct{}
+
+// ListDeadLetters returns the dead letters of a topic
+func (c *DeadLetterController) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	list, err := handler.GetDeadLetterHandler().ListDeadLetters(chi.URLParam(r, "topic"))
+	if err != nil {
+		httputil.ReturnBcodeError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, map[string]interface{}{"list": list, "total": len(list)})
+}
+
+// GetDeadLetter returns one dead letter of a topic
+func (c *DeadLetterController) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	deadLetter, err := handler.GetDeadLetterHandler().GetDeadLetter(chi.URLParam(r, "topic"), chi.URLParam(r, "message_id"))
+	if err != nil {
+		httputil.ReturnBcodeError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, deadLetter)
+}
+
+// ReplayDeadLetters redelivers dead letters to their origin topic
+func (c *DeadLetterController) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var req apimodel.DeadLetterReq
+	if ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil); !ok {
+		return
+	}
+	if len(req.MessageIDs) == 0 && !req.All {
+		httputil.ReturnError(r, w, 400, "message_ids is required unless all is true")
+		return
+	}
+	count, err := handler.GetDeadLetterHandler().ReplayDeadLetters(chi.URLParam(r, "topic"), req.MessageIDs)
+	if err != nil {
+		httputil.ReturnBcodeError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, map[string]interface{}{"count": count})
+}
+
+// PurgeDeadLetters deletes dead letters of a topic
+func (c *DeadLetterController) PurgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var req apimodel.DeadLetterReq
+	if ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil); !ok {
+		return
+	}
+	if len(req.MessageIDs) == 0 && !req.All {
+		httputil.ReturnError(r, w, 400, "message_ids is required unless all is true")
+		return
+	}
+	count, err := handler.GetDeadLetterHandler().PurgeDeadLetters(chi.URLParam(r, "topic"), req.MessageIDs)
+	if err != nil {
+		httputil.ReturnBcodeError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, map[string]interface{}{"count": count})
+}
+
+// GetDeadLetterController returns a new DeadLetterController
+func GetDeadLetterController() *DeadLetterController {
+	return &DeadLetterController{}
+}
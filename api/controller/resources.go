@@ -549,7 +549,7 @@ func (t *TenantStruct) DeleteTenant(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateTenant UpdateTenant
-// support update tenant limit memory
+// support update tenant limit memory and build quota
 func (t *TenantStruct) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 	var ts apimodel.UpdateTenantStruct
 	ok := httputil.ValidatorRequestStructAndErrorResponse(r, w, &ts.Body, nil)
@@ -557,7 +557,21 @@ func (t *TenantStruct) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tenant := r.Context().Value(ctxutil.ContextKey("tenant")).(*dbmodel.Tenants)
+	if ts.Body.BuildQuota != nil && *ts.Body.BuildQuota < 0 {
+		httputil.ReturnError(r, w, 400, "build_quota can not be negative")
+		return
+	}
+	if ts.Body.BuildWeight != nil && *ts.Body.BuildWeight < 1 {
+		httputil.ReturnError(r, w, 400, "build_weight must be greater than 0")
+		return
+	}
 	tenant.LimitMemory = ts.Body.LimitMemory
+	if ts.Body.BuildQuota != nil {
+		tenant.BuildQuota = *ts.Body.BuildQuota
+	}
+	if ts.Body.BuildWeight != nil {
+		tenant.BuildWeight = *ts.Body.BuildWeight
+	}
 	if err := handler.GetTenantManager().UpdateTenant(tenant); err != nil {
 		httputil.ReturnError(r, w, 500, "update tenant error")
 		return
@@ -1774,6 +1788,10 @@ func (t *TenantStruct) AddProbe(w http.ResponseWriter, r *http.Request) {
 	tspD.Scheme = tsp.Scheme
 	tspD.SuccessThreshold = tsp.SuccessThreshold
 	tspD.TimeoutSecond = tsp.TimeoutSecond
+	tspD.GRPCService = tsp.GRPCService
+	tspD.GRPCTLS = tsp.GRPCTLS
+	tspD.GRPCInsecureSkipVerify = tsp.GRPCInsecureSkipVerify
+	tspD.GRPCServerName = tsp.GRPCServerName
 	tspD.FailureAction = tsp.FailureAction
 	//注意端口问题
 	if err := handler.GetServiceManager().ServiceProbe(&tspD, "add"); err != nil {
@@ -1826,6 +1844,10 @@ func (t *TenantStruct) UpdateProbe(w http.ResponseWriter, r *http.Request) {
 	tspD.Scheme = tsp.Scheme
 	tspD.SuccessThreshold = tsp.SuccessThreshold
 	tspD.TimeoutSecond = tsp.TimeoutSecond
+	tspD.GRPCService = tsp.GRPCService
+	tspD.GRPCTLS = tsp.GRPCTLS
+	tspD.GRPCInsecureSkipVerify = tsp.GRPCInsecureSkipVerify
+	tspD.GRPCServerName = tsp.GRPCServerName
 	//注意端口问题
 	if err := handler.GetServiceManager().ServiceProbe(&tspD, "update"); err != nil {
 		if err.Error() == gorm.ErrRecordNotFound.Error() {
@@ -19,6 +19,7 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/goodrain/rainbond/api/handler"
@@ -31,6 +32,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// maxEndpointWeight 第三方组件实例权重的上限
+const maxEndpointWeight = 10000
+
 // ThirdPartyServiceController implements ThirdPartyServicer
 type ThirdPartyServiceController struct{}
 
@@ -53,6 +57,14 @@ func (t *ThirdPartyServiceController) addEndpoints(w http.ResponseWriter, r *htt
 	if !httputil.ValidatorRequestStructAndErrorResponse(r, w, &data, nil) {
 		return
 	}
+	if data.Weight != nil && (*data.Weight < 0 || *data.Weight > maxEndpointWeight) {
+		httputil.ReturnError(r, w, 400, fmt.Sprintf("weight must be between 0 and %d", maxEndpointWeight))
+		return
+	}
+	if len(data.Zone) > 64 {
+		httputil.ReturnError(r, w, 400, "zone is too long")
+		return
+	}
 	// if address is not ip, and then it is domain
 	address := validation.SplitEndpointAddress(data.Address)
 	sid := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
@@ -108,6 +120,14 @@ func (t *ThirdPartyServiceController) updEndpoints(w http.ResponseWriter, r *htt
 	if !httputil.ValidatorRequestStructAndErrorResponse(r, w, &data, nil) {
 		return
 	}
+	if data.Weight != nil && (*data.Weight < 0 || *data.Weight > maxEndpointWeight) {
+		httputil.ReturnError(r, w, 400, fmt.Sprintf("weight must be between 0 and %d", maxEndpointWeight))
+		return
+	}
+	if data.Zone != nil && len(*data.Zone) > 64 {
+		httputil.ReturnError(r, w, 400, "zone is too long")
+		return
+	}
 
 	if err := handler.Get3rdPartySvcHandler().UpdEndpoints(&data); err != nil {
 		httputil.ReturnError(r, w, 500, err.Error())
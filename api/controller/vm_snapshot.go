@@ -2,15 +2,22 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
+	"github.com/go-chi/chi"
 	"github.com/goodrain/rainbond/api/handler"
 	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
 	httputil "github.com/goodrain/rainbond/util/http"
 )
 
 type VMSnapshotController struct {
-	createSnapshot func(serviceID string, req *handler.VMSnapshotRequest) (*handler.VMSnapshotStatus, error)
+	createSnapshot  func(serviceID string, req *handler.VMSnapshotRequest) (*handler.VMSnapshotStatus, error)
+	listSnapshots   func(serviceID string) ([]*handler.VMSnapshotInfo, error)
+	getSnapshot     func(serviceID, snapshotName string) (*handler.VMSnapshotInfo, error)
+	deleteSnapshot  func(serviceID, snapshotName string) error
+	restoreSnapshot func(serviceID, snapshotName string, req *handler.VMSnapshotRestoreRequest) (*handler.VMSnapshotRestoreStatus, error)
 }
 
 var defaultVMSnapshotController = &VMSnapshotController{}
@@ -42,6 +49,117 @@ func (c *VMSnapshotController) CreateVMSnapshot(w http.ResponseWriter, r *http.R
 	httputil.ReturnSuccess(r, w, status)
 }
 
+func (c *VMSnapshotController) ListVMSnapshots(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	listSnapshots := c.listSnapshots
+	if listSnapshots == nil {
+		listSnapshots = handler.GetServiceManager().ListVMSnapshots
+	}
+	snapshots, err := listSnapshots(serviceID)
+	if err != nil {
+		httputil.ReturnError(r, w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, snapshots)
+}
+
+func (c *VMSnapshotController) GetVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	snapshotName := chi.URLParam(r, "name")
+	if snapshotName == "" {
+		httputil.ReturnError(r, w, http.StatusBadRequest, "snapshot name is required")
+		return
+	}
+	getSnapshot := c.getSnapshot
+	if getSnapshot == nil {
+		getSnapshot = handler.GetServiceManager().GetVMSnapshot
+	}
+	snapshot, err := getSnapshot(serviceID, snapshotName)
+	if err != nil {
+		returnVMSnapshotError(w, r, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, snapshot)
+}
+
+func (c *VMSnapshotController) DeleteVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	snapshotName := chi.URLParam(r, "name")
+	if snapshotName == "" {
+		httputil.ReturnError(r, w, http.StatusBadRequest, "snapshot name is required")
+		return
+	}
+	deleteSnapshot := c.deleteSnapshot
+	if deleteSnapshot == nil {
+		deleteSnapshot = handler.GetServiceManager().DeleteVMSnapshot
+	}
+	if err := deleteSnapshot(serviceID, snapshotName); err != nil {
+		returnVMSnapshotError(w, r, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, nil)
+}
+
+func (c *VMSnapshotController) RestoreVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
+	snapshotName := chi.URLParam(r, "name")
+	if snapshotName == "" {
+		httputil.ReturnError(r, w, http.StatusBadRequest, "snapshot name is required")
+		return
+	}
+	var reqBody handler.VMSnapshotRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && err != io.EOF {
+		httputil.ReturnError(r, w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	switch reqBody.Mode {
+	case "", handler.VMRestoreModeCurrent:
+	case handler.VMRestoreModeNew:
+		if reqBody.TargetServiceID == "" {
+			httputil.ReturnError(r, w, http.StatusBadRequest, "target_service_id is required when restoring as a new component")
+			return
+		}
+	default:
+		httputil.ReturnError(r, w, http.StatusBadRequest, "mode must be current or new")
+		return
+	}
+	reqBody.EventID, _ = r.Context().Value(ctxutil.ContextKey("event_id")).(string)
+	restoreSnapshot := c.restoreSnapshot
+	if restoreSnapshot == nil {
+		restoreSnapshot = handler.GetServiceManager().RestoreVMSnapshot
+	}
+	status, err := restoreSnapshot(serviceID, snapshotName, &reqBody)
+	if err != nil {
+		returnVMSnapshotError(w, r, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, status)
+}
+
+func returnVMSnapshotError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, handler.ErrVMSnapshotNotFound) {
+		httputil.ReturnError(r, w, http.StatusNotFound, err.Error())
+		return
+	}
+	httputil.ReturnError(r, w, http.StatusInternalServerError, err.Error())
+}
+
 func (t *TenantStruct) CreateVMSnapshot(w http.ResponseWriter, r *http.Request) {
 	GetVMSnapshotController().CreateVMSnapshot(w, r)
 }
+
+func (t *TenantStruct) ListVMSnapshots(w http.ResponseWriter, r *http.Request) {
+	GetVMSnapshotController().ListVMSnapshots(w, r)
+}
+
+func (t *TenantStruct) GetVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	GetVMSnapshotController().GetVMSnapshot(w, r)
+}
+
+func (t *TenantStruct) DeleteVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	GetVMSnapshotController().DeleteVMSnapshot(w, r)
+}
+
+func (t *TenantStruct) RestoreVMSnapshot(w http.ResponseWriter, r *http.Request) {
+	GetVMSnapshotController().RestoreVMSnapshot(w, r)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi"
 	"github.com/goodrain/rainbond/api/handler"
 	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
 )
@@ -48,3 +49,73 @@ func TestVMSnapshotControllerCreateVMSnapshotRequiresName(t *testing.T) {
 		t.Fatalf("expected status 400, got %d", recorder.Code)
 	}
 }
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestVMSnapshotControllerRestoreVMSnapshot(t *testing.T) {
+	controller := &VMSnapshotController{
+		restoreSnapshot: func(serviceID, snapshotName string, req *handler.VMSnapshotRestoreRequest) (*handler.VMSnapshotRestoreStatus, error) {
+			if serviceID != "service-1" || snapshotName != "snap-1" {
+				t.Fatalf("unexpected restore target %s/%s", serviceID, snapshotName)
+			}
+			if req.Mode != handler.VMRestoreModeNew || req.TargetServiceID != "service-2" || req.EventID != "event-1" {
+				t.Fatalf("unexpected request %#v", req)
+			}
+			return &handler.VMSnapshotRestoreStatus{RestoreName: "snap-1-restore", ServiceID: "service-2"}, nil
+		},
+	}
+
+	req := newVMSnapshotRequest(http.MethodPost, "/v2/tenants/demo/services/demo/vm-snapshots/snap-1/restore", `{"mode":"new","target_service_id":"service-2"}`, "snap-1")
+	req = req.WithContext(context.WithValue(req.Context(), ctxutil.ContextKey("event_id"), "event-1"))
+	recorder := httptest.NewRecorder()
+
+	controller.RestoreVMSnapshot(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+}
+
+func TestVMSnapshotControllerRestoreVMSnapshotRejectsInvalidMode(t *testing.T) {
+	controller := &VMSnapshotController{}
+	testCases := []string{
+		`{"mode":"clone"}`,
+		`{"mode":"new"}`,
+	}
+	for _, body := range testCases {
+		req := newVMSnapshotRequest(http.MethodPost, "/v2/tenants/demo/services/demo/vm-snapshots/snap-1/restore", body, "snap-1")
+		recorder := httptest.NewRecorder()
+
+		controller.RestoreVMSnapshot(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: expected status 400, got %d", body, recorder.Code)
+		}
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.delete
+func TestVMSnapshotControllerDeleteVMSnapshotNotFound(t *testing.T) {
+	controller := &VMSnapshotController{
+		deleteSnapshot: func(serviceID, snapshotName string) error {
+			return handler.ErrVMSnapshotNotFound
+		},
+	}
+
+	req := newVMSnapshotRequest(http.MethodDelete, "/v2/tenants/demo/services/demo/vm-snapshots/snap-1", "", "snap-1")
+	recorder := httptest.NewRecorder()
+
+	controller.DeleteVMSnapshot(recorder, req)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", recorder.Code)
+	}
+}
+
+func newVMSnapshotRequest(method, target, body, snapshotName string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", snapshotName)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	ctx = context.WithValue(ctx, ctxutil.ContextKey("service_id"), "service-1")
+	return req.WithContext(ctx)
+}
@@ -18,6 +18,8 @@
 
 package conf
 
+import "time"
+
 // Conf conf
 type Conf struct {
 	Entry      EntryConf
@@ -114,6 +116,24 @@ type EventStoreConf struct {
 	HandleSubMessageCoreNumber  int
 	HandleDockerLogCoreNumber   int
 	StorageHomePath             string
+	// FileStoreType 事件日志的存储后端：local、s3 或 sql，多副本部署时需要使用 s3 或 sql
+	FileStoreType string
+	// FileStorePath s3 存储时事件日志所在的路径，第一级目录为桶名
+	FileStorePath string
+	// FileCompression 本地存储时关闭的日志文件的压缩方式：none、gzip 或 zstd
+	FileCompression string
+	// FileMaxSizeMB 本地存储时单个日志文件的最大大小，超过后轮转，小于等于 0 时不轮转
+	FileMaxSizeMB int
+	// FileCompressAfter 本地日志文件超过该时间没有写入则视为已关闭并压缩
+	FileCompressAfter time.Duration
+	// Retention 本地存储时事件日志默认的保留时间，小于等于 0 表示永久保留
+	Retention time.Duration
+	// TenantRetention 按租户配置的保留时间，格式为 tenant_id=duration，多个租户使用逗号分隔
+	TenantRetention string
+	// SearchIndex 是否为事件日志消息建立检索索引
+	SearchIndex bool
+	// SearchIndexRetentionDays 检索索引保留的天数，小于等于 0 时不清理
+	SearchIndexRetentionDays int
 }
 
 // KubernetsConf kubernetes conf
@@ -241,16 +241,7 @@ func (m *EventFilePlugin) getJSONLinesMessages(eventID, level string, length int
 		if !checkStructuredLevel(eventMessage.Level, level) {
 			continue
 		}
-		unix := GetTimeUnix(eventMessage.Time)
-		eventTime := eventMessage.Time
-		if unix != 0 {
-			eventTime = time.Unix(unix, 0).Format(time.RFC3339)
-		}
-		messages = append(messages, MessageData{
-			Message:  eventMessage.Message,
-			Time:     eventTime,
-			Unixtime: unix,
-		})
+		messages = append(messages, structuredMessageData(&eventMessage))
 		if len(messages) > length && length != 0 {
 			break
 		}
@@ -258,6 +249,31 @@ func (m *EventFilePlugin) getJSONLinesMessages(eventID, level string, length int
 	return messages, nil
 }
 
+// StructuredMessages 将事件日志存储中的结构化消息按级别过滤，转换为历史日志
+func StructuredMessages(eventMessages []*EventLogMessage, level string) MessageDataList {
+	var messages MessageDataList
+	for _, eventMessage := range eventMessages {
+		if eventMessage == nil || !checkStructuredLevel(eventMessage.Level, level) {
+			continue
+		}
+		messages = append(messages, structuredMessageData(eventMessage))
+	}
+	return messages
+}
+
+func structuredMessageData(eventMessage *EventLogMessage) MessageData {
+	unix := GetTimeUnix(eventMessage.Time)
+	eventTime := eventMessage.Time
+	if unix != 0 {
+		eventTime = time.Unix(unix, 0).Format(time.RFC3339)
+	}
+	return MessageData{
+		Message:  eventMessage.Message,
+		Time:     eventTime,
+		Unixtime: unix,
+	}
+}
+
 func (m *EventFilePlugin) readStorageOrLocalFile(storagePath, localPath string) (storage.ReadCloser, error) {
 	if storage.Default() != nil && storage.Default().StorageCli != nil {
 		fileReader, err := storage.Default().StorageCli.ReadFile(storagePath)
@@ -21,12 +21,18 @@ package store
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/goodrain/rainbond/api/eventlog/conf"
 	"github.com/goodrain/rainbond/api/eventlog/db"
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/pkg/component/storage"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,24 +50,71 @@ type FileStore interface {
 	// Delete 删除事件的所有消息
 	Delete(eventID string) error
 
-	// Clean 清理过期文件
+	// Clean 清理最后一条消息早于 before 的事件
 	Clean(before time.Time) error
 
+	// EventIDs 返回存储中所有事件的 EventID
+	EventIDs() ([]string, error)
+
 	// Close 关闭文件存储
 	Close() error
 }
 
+// NewFileStore 根据配置创建事件日志的存储后端
+func NewFileStore(conf conf.EventStoreConf, log *logrus.Entry) (FileStore, error) {
+	switch conf.FileStoreType {
+	case "local", "":
+		tenants, err := ParseTenantRetention(conf.TenantRetention)
+		if err != nil {
+			return nil, err
+		}
+		options := FileStoreOptions{
+			Compression:   conf.FileCompression,
+			MaxFileSize:   int64(conf.FileMaxSizeMB) << 20,
+			CompressAfter: conf.FileCompressAfter,
+			Retention:     RetentionPolicy{Default: conf.Retention, Tenants: tenants},
+		}
+		return NewJSONLinesFileStoreWithOptions(filepath.Join(conf.StorageHomePath, "eventlog"), options, log)
+	case "s3":
+		s3Storage, err := storage.NewS3Storage(configs.Default().StorageConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3FileStore(s3Storage, conf.FileStorePath, log), nil
+	case "sql":
+		return NewSQLFileStore(log), nil
+	default:
+		return nil, fmt.Errorf("event log store type %s is not support", conf.FileStoreType)
+	}
+}
+
 // JSONLinesFileStore JSON Lines格式文件存储
-// 每个EventID对应一个.jsonl文件，每行一条JSON消息
+// 每个EventID对应一个正在写入的 <eventID>.jsonl 文件，每行一条JSON消息。
+// 文件超过大小上限时轮转为分段 <eventID>.<序号>.jsonl，分段和长时间没有写入的文件会被压缩为 .gz 或 .zst，
+// 读取时按序号依次读取所有分段，最后读取正在写入的文件。
 type JSONLinesFileStore struct {
 	basePath  string
+	options   FileStoreOptions
 	fileLocks map[string]*sync.Mutex // 每个文件一个锁，保证并发写入安全
 	lockMutex sync.RWMutex           // 保护fileLocks映射
+	stop      chan struct{}
+	done      chan struct{}
 	log       *logrus.Entry
 }
 
-// NewJSONLinesFileStore 创建JSON Lines文件存储
+// NewJSONLinesFileStore 创建JSON Lines文件存储，不压缩、不轮转也不清理
 func NewJSONLinesFileStore(basePath string, log *logrus.Entry) (*JSONLinesFileStore, error) {
+	return NewJSONLinesFileStoreWithOptions(basePath, FileStoreOptions{}, log)
+}
+
+// NewJSONLinesFileStoreWithOptions 创建带压缩、轮转和保留策略的JSON Lines文件存储
+func NewJSONLinesFileStoreWithOptions(basePath string, options FileStoreOptions, log *logrus.Entry) (*JSONLinesFileStore, error) {
+	if options.Compression == "" {
+		options.Compression = CompressionNone
+	}
+	if _, ok := compressionExts[options.Compression]; !ok {
+		return nil, fmt.Errorf("event log compression %s is not support", options.Compression)
+	}
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		return nil, err
 	}
@@ -72,11 +125,18 @@ func NewJSONLinesFileStore(basePath string, log *logrus.Entry) (*JSONLinesFileSt
 
 	log.Infof("Initialized JSON Lines file store at %s", basePath)
 
-	return &JSONLinesFileStore{
+	s := &JSONLinesFileStore{
 		basePath:  basePath,
+		options:   options,
 		fileLocks: make(map[string]*sync.Mutex),
 		log:       log,
-	}, nil
+	}
+	if options.Compression != CompressionNone || options.Retention.enabled() {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.maintainLoop()
+	}
+	return s, nil
 }
 
 // getFileLock 获取文件锁（每个文件独立锁）
@@ -104,6 +164,15 @@ func (s *JSONLinesFileStore) Append(eventID string, message *db.EventLogMessage)
 	defer lock.Unlock()
 
 	filePath := filepath.Join(s.basePath, eventID+".jsonl")
+	// 文件超过大小上限时先轮转，分段由后台压缩
+	if s.options.MaxFileSize > 0 {
+		if info, err := os.Stat(filePath); err == nil && info.Size() >= s.options.MaxFileSize {
+			if _, err := s.rotateLocked(eventID); err != nil {
+				s.log.Errorf("Failed to rotate file %s: %v", filePath, err)
+			}
+		}
+	}
+
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		s.log.Errorf("Failed to open file %s: %v", filePath, err)
@@ -111,17 +180,7 @@ func (s *JSONLinesFileStore) Append(eventID string, message *db.EventLogMessage)
 	}
 	defer file.Close()
 
-	// 序列化消息（只保存必要字段，避免Content字段的冗余）
-	data := map[string]interface{}{
-		"event_id": message.EventID,
-		"step":     message.Step,
-		"status":   message.Status,
-		"message":  message.Message,
-		"level":    message.Level,
-		"time":     message.Time,
-	}
-
-	jsonData, err := json.Marshal(data)
+	jsonData, err := encodeMessage(message)
 	if err != nil {
 		s.log.Errorf("Failed to marshal message: %v", err)
 		return err
@@ -136,115 +195,124 @@ func (s *JSONLinesFileStore) Append(eventID string, message *db.EventLogMessage)
 	return nil
 }
 
-// ReadAll 读取所有历史消息
+// ReadAll 读取所有历史消息，包括已轮转和已压缩的分段
 func (s *JSONLinesFileStore) ReadAll(eventID string) ([]*db.EventLogMessage, error) {
-	filePath := filepath.Join(s.basePath, eventID+".jsonl")
-	file, err := os.Open(filePath)
+	lock := s.getFileLock(eventID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	files, err := s.eventFiles(eventID)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // 文件不存在，返回空数组
-		}
-		s.log.Errorf("Failed to open file %s: %v", filePath, err)
 		return nil, err
 	}
-	defer file.Close()
-
 	var messages []*db.EventLogMessage
-	scanner := bufio.NewScanner(file)
-
-	// 设置更大的缓冲区，避免单行过长
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		var msg db.EventLogMessage
-		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
-			s.log.Debugf("Skip corrupted line in %s: %v", filePath, err)
-			continue // 跳过损坏的行
+	for _, file := range files {
+		part, err := s.readFile(file)
+		messages = append(messages, part...)
+		if err != nil {
+			return messages, err
 		}
-		messages = append(messages, &msg)
 	}
-
-	if err := scanner.Err(); err != nil {
-		s.log.Errorf("Error reading file %s: %v", filePath, err)
-		return messages, err
-	}
-
 	return messages, nil
 }
 
-// ReadLast 读取最后N条消息（用于限制历史推送量）
+// ReadLast 读取最后N条消息（用于限制历史推送量），从最新的分段向前读取，读够N条即停止
 func (s *JSONLinesFileStore) ReadLast(eventID string, n int) ([]*db.EventLogMessage, error) {
-	all, err := s.ReadAll(eventID)
+	lock := s.getFileLock(eventID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	files, err := s.eventFiles(eventID)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(all) <= n {
-		return all, nil
+	var messages []*db.EventLogMessage
+	for i := len(files) - 1; i >= 0 && len(messages) < n; i-- {
+		part, err := s.readFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(part, messages...)
 	}
-
-	return all[len(all)-n:], nil
+	return lastMessages(messages, n), nil
 }
 
-// Delete 删除事件文件
+// Delete 删除事件的所有文件
 func (s *JSONLinesFileStore) Delete(eventID string) error {
+	lock := s.getFileLock(eventID)
+	lock.Lock()
+	err := s.deleteLocked(eventID)
+	lock.Unlock()
+
 	s.lockMutex.Lock()
 	delete(s.fileLocks, eventID)
 	s.lockMutex.Unlock()
+	return err
+}
 
-	filePath := filepath.Join(s.basePath, eventID+".jsonl")
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		s.log.Errorf("Failed to delete file %s: %v", filePath, err)
+func (s *JSONLinesFileStore) deleteLocked(eventID string) error {
+	files, err := s.eventFiles(eventID)
+	if err != nil {
 		return err
 	}
-
-	s.log.Debugf("Deleted event log file: %s", filePath)
+	for _, file := range files {
+		filePath := filepath.Join(s.basePath, file.name)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			s.log.Errorf("Failed to delete file %s: %v", filePath, err)
+			return err
+		}
+		s.log.Debugf("Deleted event log file: %s", filePath)
+	}
 	return nil
 }
 
-// Clean 清理过期文件（用于定期清理）
+// Clean 清理最后一次写入早于 before 的事件（用于定期清理）
 func (s *JSONLinesFileStore) Clean(before time.Time) error {
-	entries, err := os.ReadDir(s.basePath)
+	events, err := s.listEvents()
 	if err != nil {
 		s.log.Errorf("Failed to read directory %s: %v", s.basePath, err)
 		return err
 	}
 
 	cleaned := 0
-	for _, entry := range entries {
-		if entry.IsDir() {
+	for eventID, files := range events {
+		if !lastModTime(files).Before(before) {
 			continue
 		}
-
-		if filepath.Ext(entry.Name()) != ".jsonl" {
-			continue
-		}
-
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
-
-		if info.ModTime().Before(before) {
-			filePath := filepath.Join(s.basePath, entry.Name())
-			if err := os.Remove(filePath); err != nil {
-				s.log.Errorf("Failed to remove old file %s: %v", filePath, err)
-			} else {
-				cleaned++
-			}
+		if err := s.Delete(eventID); err == nil {
+			cleaned++
 		}
 	}
 
 	if cleaned > 0 {
-		s.log.Infof("Cleaned %d old event log files", cleaned)
+		s.log.Infof("Cleaned %d old event logs", cleaned)
 	}
 
 	return nil
 }
 
+// EventIDs 返回目录中所有事件文件对应的EventID
+func (s *JSONLinesFileStore) EventIDs() ([]string, error) {
+	events, err := s.listEvents()
+	if err != nil {
+		return nil, err
+	}
+	eventIDs := make([]string, 0, len(events))
+	for eventID := range events {
+		eventIDs = append(eventIDs, eventID)
+	}
+	sort.Strings(eventIDs)
+	return eventIDs, nil
+}
+
 // Close 关闭文件存储（清理资源）
 func (s *JSONLinesFileStore) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+
 	s.lockMutex.Lock()
 	defer s.lockMutex.Unlock()
 
@@ -254,3 +322,44 @@ func (s *JSONLinesFileStore) Close() error {
 
 	return nil
 }
+
+// encodeMessage 将消息序列化为一行JSON（只保存必要字段，避免Content字段的冗余）
+func encodeMessage(message *db.EventLogMessage) ([]byte, error) {
+	data := map[string]interface{}{
+		"event_id": message.EventID,
+		"step":     message.Step,
+		"status":   message.Status,
+		"message":  message.Message,
+		"level":    message.Level,
+		"time":     message.Time,
+	}
+	return json.Marshal(data)
+}
+
+// decodeMessages 逐行解析JSON Lines格式的消息，跳过损坏的行
+func decodeMessages(r io.Reader, source string, log *logrus.Entry) ([]*db.EventLogMessage, error) {
+	var messages []*db.EventLogMessage
+	scanner := bufio.NewScanner(r)
+
+	// 设置更大的缓冲区，避免单行过长
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	for scanner.Scan() {
+		var msg db.EventLogMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Debugf("Skip corrupted line in %s: %v", source, err)
+			continue // 跳过损坏的行
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, scanner.Err()
+}
+
+// lastMessages 返回最后N条消息
+func lastMessages(all []*db.EventLogMessage, n int) []*db.EventLogMessage {
+	if len(all) <= n {
+		return all
+	}
+	return all[len(all)-n:]
+}
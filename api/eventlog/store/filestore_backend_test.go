@@ -0,0 +1,252 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	cdb "github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	mysqldao "github.com/goodrain/rainbond/db/mysql/dao"
+	"github.com/goodrain/rainbond/pkg/component/storage"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+// memoryObjectStorage 内存中的对象存储，路径格式与 S3Storage 一致
+type memoryObjectStorage struct {
+	lock    sync.Mutex
+	objects map[string]memoryObject
+}
+
+func newMemoryObjectStorage() *memoryObjectStorage {
+	return &memoryObjectStorage{objects: make(map[string]memoryObject)}
+}
+
+func (m *memoryObjectStorage) WriteFile(filePath string, data []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.objects[filePath] = memoryObject{data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+func (m *memoryObjectStorage) ReadFile(filePath string) (storage.ReadCloser, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	object, ok := m.objects[filePath]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", filePath)
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+func (m *memoryObjectStorage) ListFiles(dirPath string) ([]storage.FileInfo, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var files []storage.FileInfo
+	for filePath, object := range m.objects {
+		if strings.HasPrefix(filePath, strings.TrimSuffix(dirPath, "/")+"/") {
+			files = append(files, storage.FileInfo{Path: filePath, Size: int64(len(object.data)), ModTime: object.modTime})
+		}
+	}
+	return files, nil
+}
+
+func (m *memoryObjectStorage) RemoveDir(dirPath string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for filePath := range m.objects {
+		if strings.HasPrefix(filePath, strings.TrimSuffix(dirPath, "/")+"/") {
+			delete(m.objects, filePath)
+		}
+	}
+	return nil
+}
+
+type eventLogTestManager struct {
+	cdb.Manager
+	eventLogRecordDao dbdao.EventLogRecordDao
+}
+
+func (m eventLogTestManager) EventLogRecordDao() dbdao.EventLogRecordDao {
+	return m.eventLogRecordDao
+}
+
+func newSQLTestFileStore(t *testing.T) FileStore {
+	t.Helper()
+	gdb, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "eventlog.db"))
+	if err != nil {
+		t.Fatalf("open sqlite db: %v", err)
+	}
+	gdb.LogMode(false)
+	t.Cleanup(func() { gdb.Close() })
+	if err := gdb.AutoMigrate(&dbmodel.EventLogRecord{}).Error; err != nil {
+		t.Fatalf("auto migrate event log record: %v", err)
+	}
+	cdb.SetTestManager(eventLogTestManager{eventLogRecordDao: &mysqldao.EventLogRecordDaoImpl{DB: gdb}})
+	t.Cleanup(func() { cdb.SetTestManager(nil) })
+	return NewSQLFileStore(nil)
+}
+
+// flushStore 将对象存储缓存中的消息写入，使各个存储后端的状态一致
+func flushStore(t *testing.T, store FileStore, eventID string) {
+	t.Helper()
+	if flusher, ok := store.(interface{ Flush(eventID string) error }); ok {
+		if err := flusher.Flush(eventID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testFileStoreSemantics(t *testing.T, store FileStore) {
+	defer store.Close()
+	if messages, err := store.ReadAll("missing-event"); err != nil || len(messages) != 0 {
+		t.Fatalf("read missing event = %v, %v, want empty", messages, err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := store.Append("event-1", &db.EventLogMessage{EventID: "event-1", Step: "build", Level: "info", Message: fmt.Sprintf("message %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Append("event-2", &db.EventLogMessage{EventID: "event-2", Message: "other"})
+
+	all, err := store.ReadAll("event-1")
+	if err != nil || len(all) != 5 {
+		t.Fatalf("read all = %d messages, %v, want 5", len(all), err)
+	}
+	for i, message := range all {
+		if message.Message != fmt.Sprintf("message %d", i+1) || message.Step != "build" || message.EventID != "event-1" {
+			t.Fatalf("unexpected message %d %+v", i, message)
+		}
+	}
+	last, err := store.ReadLast("event-1", 2)
+	if err != nil || len(last) != 2 || last[0].Message != "message 4" || last[1].Message != "message 5" {
+		t.Fatalf("read last = %+v, %v", last, err)
+	}
+	if last, _ := store.ReadLast("event-1", 10); len(last) != 5 {
+		t.Fatalf("read last 10 = %d messages, want 5", len(last))
+	}
+
+	flushStore(t, store, "event-1")
+	flushStore(t, store, "event-2")
+	eventIDs, err := store.EventIDs()
+	sort.Strings(eventIDs)
+	if err != nil || strings.Join(eventIDs, ",") != "event-1,event-2" {
+		t.Fatalf("event ids = %v, %v", eventIDs, err)
+	}
+
+	if err := store.Delete("event-1"); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := store.ReadAll("event-1"); len(messages) != 0 {
+		t.Fatalf("deleted event still has %d messages", len(messages))
+	}
+
+	if err := store.Clean(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := store.ReadAll("event-2"); len(messages) != 1 {
+		t.Fatal("recent event should not be cleaned")
+	}
+	if err := store.Clean(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := store.ReadAll("event-2"); len(messages) != 0 {
+		t.Fatal("expired event should be cleaned")
+	}
+}
+
+// capability_id: rainbond.eventlog.file-store-backends
+func TestFileStoreBackendsSemantics(t *testing.T) {
+	log := logrus.WithField("test", "filestore-backends")
+	t.Run("local", func(t *testing.T) {
+		store, err := NewJSONLinesFileStore(t.TempDir(), log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testFileStoreSemantics(t, store)
+	})
+	t.Run("s3", func(t *testing.T) {
+		testFileStoreSemantics(t, NewS3FileStore(newMemoryObjectStorage(), "/grdata/logs/eventlog", log))
+	})
+	t.Run("sql", func(t *testing.T) {
+		testFileStoreSemantics(t, newSQLTestFileStore(t))
+	})
+}
+
+// capability_id: rainbond.eventlog.file-store-backends
+func TestS3FileStoreSharedAcrossReplicas(t *testing.T) {
+	objects := newMemoryObjectStorage()
+	first := NewS3FileStore(objects, "/grdata/logs/eventlog", nil)
+	second := NewS3FileStore(objects, "/grdata/logs/eventlog", nil)
+	defer second.Close()
+
+	for i := 0; i < s3FlushLines+1; i++ {
+		first.Append("event", &db.EventLogMessage{EventID: "event", Message: fmt.Sprintf("message %d", i)})
+	}
+	// 达到缓存上限的消息已经写入对象存储，其他副本可以读到
+	if messages, _ := second.ReadAll("event"); len(messages) != s3FlushLines {
+		t.Fatalf("other replica reads %d messages, want %d", len(messages), s3FlushLines)
+	}
+	first.Close()
+	messages, err := second.ReadAll("event")
+	if err != nil || len(messages) != s3FlushLines+1 {
+		t.Fatalf("other replica reads %d messages after close, %v", len(messages), err)
+	}
+	if messages[s3FlushLines].Message != fmt.Sprintf("message %d", s3FlushLines) {
+		t.Fatalf("unexpected last message %+v", messages[s3FlushLines])
+	}
+}
+
+// capability_id: rainbond.eventlog.file-store-backends
+func TestMigrateFileStore(t *testing.T) {
+	log := logrus.WithField("test", "filestore-migrate")
+	local, err := NewJSONLinesFileStore(t.TempDir(), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, eventID := range []string{"event-1", "event-2"} {
+		for i := 0; i < 3; i++ {
+			local.Append(eventID, &db.EventLogMessage{EventID: eventID, Message: fmt.Sprintf("%s %d", eventID, i)})
+		}
+	}
+	objects := newMemoryObjectStorage()
+	remote := NewS3FileStore(objects, "/grdata/logs/eventlog", log)
+	defer remote.Close()
+	// 上次迁移中断时已经复制了部分消息，重新迁移不能产生重复的消息
+	remote.Append("event-1", &db.EventLogMessage{EventID: "event-1", Message: "event-1 0"})
+	remote.Flush("event-1")
+
+	migrated, err := MigrateFileStore(local, remote, log)
+	if err != nil || migrated != 2 {
+		t.Fatalf("migrated = %d, %v, want 2", migrated, err)
+	}
+	if eventIDs, _ := local.EventIDs(); len(eventIDs) != 0 {
+		t.Fatalf("migrated events should be removed from source, left %v", eventIDs)
+	}
+	messages, _ := NewS3FileStore(objects, "/grdata/logs/eventlog", log).ReadAll("event-2")
+	if len(messages) != 3 || messages[2].Message != "event-2 2" {
+		t.Fatalf("unexpected migrated messages %+v", messages)
+	}
+	if messages, _ := remote.ReadAll("event-1"); len(messages) != 3 {
+		t.Fatalf("rerun migration should not duplicate messages, got %d", len(messages))
+	}
+	if migrated, err := MigrateFileStore(local, remote, log); err != nil || migrated != 0 {
+		t.Fatalf("migrate again = %d, %v, want 0", migrated, err)
+	}
+}
@@ -0,0 +1,439 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	cdb "github.com/goodrain/rainbond/db"
+	"github.com/klauspost/compress/zstd"
+)
+
+// 关闭的日志文件的压缩方式
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var compressionExts = map[string]string{
+	CompressionNone: "",
+	CompressionGzip: ".gz",
+	CompressionZstd: ".zst",
+}
+
+var (
+	fileMaintainInterval = time.Minute
+	// retentionQueryBatch 按保留策略清理时每次查询事件所属租户的数量
+	retentionQueryBatch = 500
+)
+
+// eventFileName 匹配 <eventID>.jsonl 以及分段 <eventID>.<序号>.jsonl[.gz|.zst]
+var eventFileName = regexp.MustCompile(`^(.+?)(?:\.(\d+))?\.jsonl(\.gz|\.zst)?$`)
+
+// FileStoreOptions 本地事件日志文件的压缩、轮转和保留策略，零值表示不压缩、不轮转、不清理
+type FileStoreOptions struct {
+	// Compression 关闭的日志文件的压缩方式：none、gzip 或 zstd
+	Compression string
+	// MaxFileSize 单个日志文件的最大字节数，超过后轮转为新的分段，小于等于 0 时不轮转
+	MaxFileSize int64
+	// CompressAfter 日志文件超过该时间没有写入则视为已关闭，之后被压缩
+	CompressAfter time.Duration
+	// Retention 事件日志的保留策略
+	Retention RetentionPolicy
+}
+
+// RetentionPolicy 事件日志的保留策略，租户没有单独配置时使用默认的保留时间，保留时间小于等于 0 表示永久保留
+type RetentionPolicy struct {
+	Default time.Duration
+	// Tenants 按租户 ID 配置的保留时间
+	Tenants map[string]time.Duration
+}
+
+// For 返回租户的事件日志保留时间
+func (p RetentionPolicy) For(tenantID string) time.Duration {
+	if retention, ok := p.Tenants[tenantID]; ok {
+		return retention
+	}
+	return p.Default
+}
+
+func (p RetentionPolicy) enabled() bool {
+	return p.shortest() > 0
+}
+
+// shortest 返回最短的有效保留时间，没有有效的保留时间时返回 0
+func (p RetentionPolicy) shortest() time.Duration {
+	shortest := p.Default
+	for _, retention := range p.Tenants {
+		if retention > 0 && (shortest <= 0 || retention < shortest) {
+			shortest = retention
+		}
+	}
+	if shortest < 0 {
+		return 0
+	}
+	return shortest
+}
+
+// ParseTenantRetention 解析租户的保留策略，格式为 tenant_id=duration，多个租户使用逗号分隔，例如 a1b2=168h,c3d4=2160h
+func ParseTenantRetention(value string) (map[string]time.Duration, error) {
+	tenants := make(map[string]time.Duration)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("tenant retention %s should be tenant_id=duration", item)
+		}
+		retention, err := time.ParseDuration(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("retention of tenant %s is invalid: %v", kv[0], err)
+		}
+		tenants[strings.TrimSpace(kv[0])] = retention
+	}
+	return tenants, nil
+}
+
+// eventFile 事件的一个日志文件，seq 为 0 表示正在写入的文件
+type eventFile struct {
+	name    string
+	seq     int
+	ext     string
+	modTime time.Time
+}
+
+func (f eventFile) active() bool {
+	return f.seq == 0
+}
+
+func parseEventFile(name string) (string, eventFile, bool) {
+	match := eventFileName.FindStringSubmatch(name)
+	if match == nil {
+		return "", eventFile{}, false
+	}
+	file := eventFile{name: name, ext: match[3]}
+	if match[2] != "" {
+		seq, err := strconv.Atoi(match[2])
+		if err != nil || seq <= 0 {
+			return "", eventFile{}, false
+		}
+		file.seq = seq
+	} else if file.ext != "" {
+		return "", eventFile{}, false
+	}
+	return match[1], file, true
+}
+
+// sortEventFiles 分段按序号排列，正在写入的文件排在最后
+func sortEventFiles(files []eventFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].active() != files[j].active() {
+			return files[j].active()
+		}
+		return files[i].seq < files[j].seq
+	})
+}
+
+func lastModTime(files []eventFile) time.Time {
+	var last time.Time
+	for _, file := range files {
+		if file.modTime.After(last) {
+			last = file.modTime
+		}
+	}
+	return last
+}
+
+// eventFiles 返回事件的所有日志文件，调用方需持有事件的文件锁
+func (s *JSONLinesFileStore) eventFiles(eventID string) ([]eventFile, error) {
+	names, err := filepath.Glob(filepath.Join(s.basePath, eventID+".*"))
+	if err != nil {
+		return nil, err
+	}
+	var files []eventFile
+	for _, name := range names {
+		id, file, ok := parseEventFile(filepath.Base(name))
+		if !ok || id != eventID {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		file.modTime = info.ModTime()
+		files = append(files, file)
+	}
+	sortEventFiles(files)
+	return files, nil
+}
+
+// listEvents 按事件分组返回目录中的所有日志文件
+func (s *JSONLinesFileStore) listEvents() (map[string][]eventFile, error) {
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return nil, err
+	}
+	events := make(map[string][]eventFile)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		eventID, file, ok := parseEventFile(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file.modTime = info.ModTime()
+		events[eventID] = append(events[eventID], file)
+	}
+	for _, files := range events {
+		sortEventFiles(files)
+	}
+	return events, nil
+}
+
+// readFile 读取一个日志文件，按扩展名解压
+func (s *JSONLinesFileStore) readFile(file eventFile) ([]*db.EventLogMessage, error) {
+	filePath := filepath.Join(s.basePath, file.name)
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		s.log.Errorf("Failed to open file %s: %v", filePath, err)
+		return nil, err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	switch file.ext {
+	case compressionExts[CompressionGzip]:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			s.log.Errorf("Failed to read gzip file %s: %v", filePath, err)
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	case compressionExts[CompressionZstd]:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			s.log.Errorf("Failed to read zstd file %s: %v", filePath, err)
+			return nil, err
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	messages, err := decodeMessages(reader, filePath, s.log)
+	if err != nil {
+		s.log.Errorf("Error reading file %s: %v", filePath, err)
+	}
+	return messages, err
+}
+
+// rotateLocked 将正在写入的文件轮转为下一个分段，返回分段的文件名，调用方需持有事件的文件锁
+func (s *JSONLinesFileStore) rotateLocked(eventID string) (string, error) {
+	files, err := s.eventFiles(eventID)
+	if err != nil {
+		return "", err
+	}
+	seq := 1
+	for _, file := range files {
+		if file.seq >= seq {
+			seq = file.seq + 1
+		}
+	}
+	name := fmt.Sprintf("%s.%06d.jsonl", eventID, seq)
+	if err := os.Rename(filepath.Join(s.basePath, eventID+".jsonl"), filepath.Join(s.basePath, name)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (s *JSONLinesFileStore) maintainLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(fileMaintainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.maintain(time.Now())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// maintain 按保留策略清理过期的事件，并压缩已轮转的分段和长时间没有写入的文件
+func (s *JSONLinesFileStore) maintain(now time.Time) {
+	events, err := s.listEvents()
+	if err != nil {
+		s.log.Errorf("Failed to read directory %s: %v", s.basePath, err)
+		return
+	}
+	if s.options.Retention.enabled() {
+		for _, eventID := range s.expiredEvents(events, now) {
+			if err := s.Delete(eventID); err == nil {
+				delete(events, eventID)
+			}
+		}
+	}
+	if s.options.Compression == CompressionNone {
+		return
+	}
+	for eventID, files := range events {
+		for _, file := range files {
+			if file.ext != "" {
+				continue
+			}
+			name := file.name
+			if file.active() {
+				if now.Sub(file.modTime) < s.options.CompressAfter {
+					continue
+				}
+				if name = s.closeIdle(eventID, now); name == "" {
+					continue
+				}
+			}
+			if err := s.compress(eventID, name); err != nil {
+				s.log.Errorf("Failed to compress event log file %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// closeIdle 将长时间没有写入的文件轮转为分段，文件在此期间有新的写入时返回空
+func (s *JSONLinesFileStore) closeIdle(eventID string, now time.Time) string {
+	lock := s.getFileLock(eventID)
+	lock.Lock()
+	defer lock.Unlock()
+	info, err := os.Stat(filepath.Join(s.basePath, eventID+".jsonl"))
+	if err != nil || now.Sub(info.ModTime()) < s.options.CompressAfter {
+		return ""
+	}
+	name, err := s.rotateLocked(eventID)
+	if err != nil {
+		s.log.Errorf("Failed to close idle event log of %s: %v", eventID, err)
+		return ""
+	}
+	return name
+}
+
+// compress 压缩一个已关闭的分段。分段不会再被写入，压缩在锁外进行，只在替换文件时持有锁，
+// 进程中途退出时未压缩的分段保留，下次重新压缩
+func (s *JSONLinesFileStore) compress(eventID, name string) error {
+	src := filepath.Join(s.basePath, name)
+	dst := src + compressionExts[s.options.Compression]
+	tmp := dst + ".tmp"
+	if err := s.compressFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	lock := s.getFileLock(eventID)
+	lock.Lock()
+	defer lock.Unlock()
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func (s *JSONLinesFileStore) compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	var writer io.WriteCloser
+	if s.options.Compression == CompressionZstd {
+		if writer, err = zstd.NewWriter(out); err != nil {
+			return err
+		}
+	} else {
+		writer = gzip.NewWriter(out)
+	}
+	if _, err := io.Copy(writer, in); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// expiredEvents 返回超过保留时间的事件。配置了租户的保留策略时按事件所属的租户计算，
+// 查询租户失败时本轮不清理，避免误删保留时间更长的租户的日志
+func (s *JSONLinesFileStore) expiredEvents(events map[string][]eventFile, now time.Time) []string {
+	policy := s.options.Retention
+	shortest := policy.shortest()
+	var candidates []string
+	for eventID, files := range events {
+		if now.Sub(lastModTime(files)) > shortest {
+			candidates = append(candidates, eventID)
+		}
+	}
+	tenants := make(map[string]string)
+	if len(policy.Tenants) > 0 {
+		for i := 0; i < len(candidates); i += retentionQueryBatch {
+			end := i + retentionQueryBatch
+			if end > len(candidates) {
+				end = len(candidates)
+			}
+			list, err := cdb.GetManager().ServiceEventDao().GetEventByEventIDs(candidates[i:end])
+			if err != nil {
+				s.log.Errorf("Failed to get tenants of event logs: %v", err)
+				return nil
+			}
+			for _, event := range list {
+				tenants[event.EventID] = event.TenantID
+			}
+		}
+	}
+	var expired []string
+	for _, eventID := range candidates {
+		retention := policy.For(tenants[eventID])
+		if retention > 0 && now.Sub(lastModTime(events[eventID])) > retention {
+			expired = append(expired, eventID)
+		}
+	}
+	return expired
+}
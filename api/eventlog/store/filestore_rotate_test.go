@@ -0,0 +1,163 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+)
+
+func appendMessages(t *testing.T, store FileStore, eventID string, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if err := store.Append(eventID, &db.EventLogMessage{EventID: eventID, Step: "build", Level: "info", Message: fmt.Sprintf("line-%03d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectMessages(t *testing.T, messages []*db.EventLogMessage, from, to int) {
+	t.Helper()
+	if len(messages) != to-from {
+		t.Fatalf("messages count = %d, want %d", len(messages), to-from)
+	}
+	for i, m := range messages {
+		if want := fmt.Sprintf("line-%03d", from+i); m.Message != want {
+			t.Fatalf("message %d = %s, want %s", i, m.Message, want)
+		}
+	}
+}
+
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// capability_id: rainbond.eventlog.file-rotation
+func TestJSONLinesFileStoreRotateAndCompress(t *testing.T) {
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := NewJSONLinesFileStoreWithOptions(dir, FileStoreOptions{Compression: compression, MaxFileSize: 512, CompressAfter: time.Minute}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			appendMessages(t, store, "build-1", 0, 40)
+			rotated := dirFiles(t, dir)
+			if len(rotated) < 3 || rotated[len(rotated)-1] != "build-1.jsonl" {
+				t.Fatalf("long event should be rotated into segments, got %v", rotated)
+			}
+			all, _ := store.ReadAll("build-1")
+			expectMessages(t, all, 0, 40)
+
+			// 分段和长时间没有写入的文件都被压缩，读取结果不变
+			store.maintain(time.Now().Add(time.Hour))
+			ext := compressionExts[compression]
+			for _, name := range dirFiles(t, dir) {
+				if filepath.Ext(name) != ext {
+					t.Fatalf("closed file %s should be compressed to %s", name, ext)
+				}
+			}
+			all, _ = store.ReadAll("build-1")
+			expectMessages(t, all, 0, 40)
+			last, _ := store.ReadLast("build-1", 3)
+			expectMessages(t, last, 37, 40)
+
+			// 压缩后继续写入的消息排在已压缩的分段之后
+			appendMessages(t, store, "build-1", 40, 42)
+			all, _ = store.ReadAll("build-1")
+			expectMessages(t, all, 0, 42)
+			last, _ = store.ReadLast("build-1", 5)
+			expectMessages(t, last, 37, 42)
+			if ids, _ := store.EventIDs(); len(ids) != 1 || ids[0] != "build-1" {
+				t.Fatalf("unexpected event ids %v", ids)
+			}
+			if err := store.Delete("build-1"); err != nil {
+				t.Fatal(err)
+			}
+			if names := dirFiles(t, dir); len(names) != 0 {
+				t.Fatalf("delete should remove all segments, left %v", names)
+			}
+		})
+	}
+}
+
+// capability_id: rainbond.eventlog.file-rotation
+func TestJSONLinesFileStoreReadsUncompressedHistory(t *testing.T) {
+	dir := t.TempDir()
+	// 升级前写入的未压缩文件
+	legacy, err := NewJSONLinesFileStore(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendMessages(t, legacy, "build-1", 0, 5)
+	legacy.Close()
+
+	store, err := NewJSONLinesFileStoreWithOptions(dir, FileStoreOptions{Compression: CompressionGzip, CompressAfter: time.Minute}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	all, _ := store.ReadAll("build-1")
+	expectMessages(t, all, 0, 5)
+	store.maintain(time.Now().Add(time.Hour))
+	if names := dirFiles(t, dir); len(names) != 1 || names[0] != "build-1.000001.jsonl.gz" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	all, _ = store.ReadAll("build-1")
+	expectMessages(t, all, 0, 5)
+
+	if _, err := NewJSONLinesFileStoreWithOptions(dir, FileStoreOptions{Compression: "lz4"}, nil); err == nil {
+		t.Fatal("unknown compression should be rejected")
+	}
+}
+
+// capability_id: rainbond.eventlog.file-rotation
+func TestJSONLinesFileStoreTenantRetention(t *testing.T) {
+	// build-1 属于 tenant-a，build-2 属于 tenant-b，orphan 不在数据库中
+	newSearchIndexTestDB(t)
+	tenants, err := ParseTenantRetention("tenant-a=1h, tenant-b=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	store, err := NewJSONLinesFileStoreWithOptions(dir, FileStoreOptions{Retention: RetentionPolicy{Default: 48 * time.Hour, Tenants: tenants}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for _, eventID := range []string{"build-1", "build-2", "orphan"} {
+		appendMessages(t, store, eventID, 0, 1)
+	}
+
+	now := time.Now()
+	store.maintain(now.Add(3 * time.Hour))
+	if ids, _ := store.EventIDs(); len(ids) != 2 || ids[0] != "build-2" || ids[1] != "orphan" {
+		t.Fatalf("only events of tenant-a should expire, left %v", ids)
+	}
+	store.maintain(now.Add(72 * time.Hour))
+	if ids, _ := store.EventIDs(); len(ids) != 1 || ids[0] != "build-2" {
+		t.Fatalf("events use the default retention except tenant-b which keeps forever, left %v", ids)
+	}
+
+	for _, value := range []string{"tenant-a", "=1h", "tenant-a=1x"} {
+		if _, err := ParseTenantRetention(value); err == nil {
+			t.Fatalf("parse %q should fail", value)
+		}
+	}
+}
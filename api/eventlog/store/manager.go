@@ -62,6 +62,8 @@ type Manager interface {
 	Scrape(ch chan<- prometheus.Metric, namespace, exporter, from string) error
 	Error() chan error
 	HealthCheck() map[string]string
+	// MessageFileStore 返回事件日志的存储后端，历史日志接口从这里读取
+	MessageFileStore() FileStore
 }
 
 // NewManager 存储管理器
@@ -75,11 +77,15 @@ func NewManager(conf conf.EventStoreConf, log *logrus.Entry) (Manager, error) {
 		return nil, err
 	}
 
-	// 创建消息文件存储（JSON Lines格式）
-	messageFileStore, err := NewJSONLinesFileStore(conf.StorageHomePath+"/eventlog", log.WithField("module", "messageFileStore"))
+	// 创建消息文件存储，默认为本地 JSON Lines 文件
+	messageFileStore, err := NewFileStore(conf, log.WithField("module", "messageFileStore"))
 	if err != nil {
 		return nil, err
 	}
+	if conf.SearchIndex {
+		retention := time.Duration(conf.SearchIndexRetentionDays) * 24 * time.Hour
+		messageFileStore = NewIndexedFileStore(messageFileStore, retention, log.WithField("module", "searchIndex"))
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	storeManager := &storeManager{
@@ -131,6 +137,10 @@ type storeManager struct {
 	errChan                chan error
 }
 
+func (s *storeManager) MessageFileStore() FileStore {
+	return s.messageFileStore
+}
+
 func (s *storeManager) HealthCheck() map[string]string {
 	receiveChan := len(s.receiveChan) == 300
 	pubChan := len(s.pubChan) == 300
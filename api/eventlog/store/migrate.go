@@ -0,0 +1,80 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/goodrain/rainbond/api/eventlog/conf"
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateFileStore 将 src 中的事件日志逐个复制到 dst，复制成功后删除 src 中的事件，返回迁移的事件数。
+// 复制前先删除 dst 中该事件已有的消息，迁移中断后可以重新执行，不会产生重复的消息。
+func MigrateFileStore(src, dst FileStore, log *logrus.Entry) (int, error) {
+	eventIDs, err := src.EventIDs()
+	if err != nil {
+		return 0, fmt.Errorf("list events failure: %s", err.Error())
+	}
+	var migrated int
+	for _, eventID := range eventIDs {
+		messages, err := src.ReadAll(eventID)
+		if err != nil {
+			return migrated, fmt.Errorf("read event %s failure: %s", eventID, err.Error())
+		}
+		if err := dst.Delete(eventID); err != nil {
+			return migrated, fmt.Errorf("clean event %s in destination failure: %s", eventID, err.Error())
+		}
+		for _, message := range messages {
+			if err := dst.Append(eventID, message); err != nil {
+				return migrated, fmt.Errorf("write event %s failure: %s", eventID, err.Error())
+			}
+		}
+		if flusher, ok := dst.(interface{ Flush(eventID string) error }); ok {
+			if err := flusher.Flush(eventID); err != nil {
+				return migrated, fmt.Errorf("write event %s failure: %s", eventID, err.Error())
+			}
+		}
+		if err := src.Delete(eventID); err != nil {
+			return migrated, fmt.Errorf("delete migrated event %s failure: %s", eventID, err.Error())
+		}
+		migrated++
+		log.Debugf("migrated %d messages of event %s", len(messages), eventID)
+	}
+	return migrated, nil
+}
+
+// MigrateLocalFileStore 将本地磁盘上的事件日志迁移到配置的存储后端
+func MigrateLocalFileStore(conf conf.EventStoreConf, log *logrus.Entry) (int, error) {
+	if conf.FileStoreType == "local" || conf.FileStoreType == "" {
+		return 0, fmt.Errorf("event log store type is local, nothing to migrate")
+	}
+	src, err := NewJSONLinesFileStore(filepath.Join(conf.StorageHomePath, "eventlog"), log.WithField("store", "local"))
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dst, err := NewFileStore(conf, log.WithField("store", conf.FileStoreType))
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+	return MigrateFileStore(src, dst, log)
+}
@@ -0,0 +1,310 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	"github.com/goodrain/rainbond/pkg/component/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// s3FlushLines 事件缓存的消息达到该数量时立即写入对象存储
+var s3FlushLines = 200
+
+// s3FlushInterval 缓存的消息写入对象存储的周期
+var s3FlushInterval = 3 * time.Second
+
+// ObjectStorage 事件日志使用的对象存储操作，由 storage.S3Storage 实现
+type ObjectStorage interface {
+	WriteFile(filePath string, data []byte) error
+	ReadFile(filePath string) (storage.ReadCloser, error)
+	ListFiles(dirPath string) ([]storage.FileInfo, error)
+	RemoveDir(dirPath string) error
+}
+
+// S3FileStore 对象存储的事件日志存储，多个副本可以共享同一份事件日志。
+// 对象不支持追加写，每个事件的消息先在内存中缓存，再按段写入 <basePath>/<eventID>/ 目录，
+// 段文件名以写入时间开头，读取时按文件名排序拼接。
+type S3FileStore struct {
+	storage  ObjectStorage
+	basePath string
+	host     string
+	buffers  map[string][]byte
+	lines    map[string]int
+	lock     sync.Mutex
+	// flushLock 保证同一时间只有一个写入过程，段文件的顺序与消息顺序一致
+	flushLock sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+	log       *logrus.Entry
+}
+
+// NewS3FileStore 创建对象存储的事件日志存储
+func NewS3FileStore(objectStorage ObjectStorage, basePath string, log *logrus.Entry) *S3FileStore {
+	if log == nil {
+		log = logrus.WithField("module", "s3filestore")
+	}
+	host, _ := os.Hostname()
+	s := &S3FileStore{
+		storage:  objectStorage,
+		basePath: "/" + strings.Trim(basePath, "/"),
+		host:     host,
+		buffers:  make(map[string][]byte),
+		lines:    make(map[string]int),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		log:      log,
+	}
+	go s.flushLoop()
+	log.Infof("Initialized s3 event log store at %s", basePath)
+	return s
+}
+
+func (s *S3FileStore) eventDir(eventID string) string {
+	return s.basePath + "/" + eventID
+}
+
+// eventOf 返回段文件所属的事件
+func (s *S3FileStore) eventOf(file storage.FileInfo) (string, bool) {
+	dir := path.Dir(file.Path)
+	if path.Dir(dir) != s.basePath {
+		return "", false
+	}
+	return path.Base(dir), true
+}
+
+// Append 追加消息到缓存，缓存的消息周期性地写入对象存储
+func (s *S3FileStore) Append(eventID string, message *db.EventLogMessage) error {
+	if eventID == "" || message == nil {
+		return nil
+	}
+	jsonData, err := encodeMessage(message)
+	if err != nil {
+		s.log.Errorf("Failed to marshal message: %v", err)
+		return err
+	}
+	s.lock.Lock()
+	s.buffers[eventID] = append(append(s.buffers[eventID], jsonData...), '\n')
+	s.lines[eventID]++
+	full := s.lines[eventID] >= s3FlushLines
+	s.lock.Unlock()
+	if full {
+		return s.Flush(eventID)
+	}
+	return nil
+}
+
+// Flush 将事件缓存的消息写入对象存储
+func (s *S3FileStore) Flush(eventID string) error {
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+	s.lock.Lock()
+	data := s.buffers[eventID]
+	lines := s.lines[eventID]
+	delete(s.buffers, eventID)
+	delete(s.lines, eventID)
+	s.lock.Unlock()
+	if len(data) == 0 {
+		return nil
+	}
+	filePath := fmt.Sprintf("%s/%019d-%s.jsonl", s.eventDir(eventID), time.Now().UnixNano(), s.host)
+	if err := s.storage.WriteFile(filePath, data); err != nil {
+		// 写入失败时放回缓存，等待下次写入
+		s.lock.Lock()
+		s.buffers[eventID] = append(data, s.buffers[eventID]...)
+		s.lines[eventID] += lines
+		s.lock.Unlock()
+		s.log.Errorf("Failed to write event log segment %s: %v", filePath, err)
+		return err
+	}
+	return nil
+}
+
+func (s *S3FileStore) flushAll() {
+	s.lock.Lock()
+	eventIDs := make([]string, 0, len(s.buffers))
+	for eventID := range s.buffers {
+		eventIDs = append(eventIDs, eventID)
+	}
+	s.lock.Unlock()
+	for _, eventID := range eventIDs {
+		s.Flush(eventID)
+	}
+}
+
+func (s *S3FileStore) flushLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s3FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.flushAll()
+		}
+	}
+}
+
+// segments 返回事件的所有段文件，按写入时间排序
+func (s *S3FileStore) segments(eventID string) ([]storage.FileInfo, error) {
+	files, err := s.storage.ListFiles(s.eventDir(eventID))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return path.Base(files[i].Path) < path.Base(files[j].Path)
+	})
+	return files, nil
+}
+
+// ReadAll 读取所有历史消息，包括尚未写入对象存储的消息
+func (s *S3FileStore) ReadAll(eventID string) ([]*db.EventLogMessage, error) {
+	files, err := s.segments(eventID)
+	if err != nil {
+		s.log.Errorf("Failed to list event log segments of %s: %v", eventID, err)
+		return nil, err
+	}
+	var messages []*db.EventLogMessage
+	for _, file := range files {
+		reader, err := s.storage.ReadFile(file.Path)
+		if err != nil {
+			s.log.Errorf("Failed to read event log segment %s: %v", file.Path, err)
+			return messages, err
+		}
+		segment, err := decodeMessages(reader, file.Path, s.log)
+		reader.Close()
+		messages = append(messages, segment...)
+		if err != nil {
+			s.log.Errorf("Error reading event log segment %s: %v", file.Path, err)
+			return messages, err
+		}
+	}
+	s.lock.Lock()
+	pending := s.buffers[eventID]
+	s.lock.Unlock()
+	if len(pending) > 0 {
+		buffered, _ := decodeMessages(bytes.NewReader(pending), eventID, s.log)
+		messages = append(messages, buffered...)
+	}
+	return messages, nil
+}
+
+// ReadLast 读取最后N条消息
+func (s *S3FileStore) ReadLast(eventID string, n int) ([]*db.EventLogMessage, error) {
+	all, err := s.ReadAll(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return lastMessages(all, n), nil
+}
+
+// Delete 删除事件的所有消息
+func (s *S3FileStore) Delete(eventID string) error {
+	s.lock.Lock()
+	delete(s.buffers, eventID)
+	delete(s.lines, eventID)
+	s.lock.Unlock()
+	if err := s.storage.RemoveDir(s.eventDir(eventID)); err != nil {
+		s.log.Errorf("Failed to delete event log %s: %v", eventID, err)
+		return err
+	}
+	return nil
+}
+
+// Clean 删除最后一个段文件早于 before 的事件
+func (s *S3FileStore) Clean(before time.Time) error {
+	files, err := s.storage.ListFiles(s.basePath)
+	if err != nil {
+		s.log.Errorf("Failed to list event log segments: %v", err)
+		return err
+	}
+	latest := make(map[string]time.Time)
+	for _, file := range files {
+		eventID, ok := s.eventOf(file)
+		if !ok {
+			continue
+		}
+		if file.ModTime.After(latest[eventID]) {
+			latest[eventID] = file.ModTime
+		}
+	}
+	cleaned := 0
+	for eventID, modTime := range latest {
+		if !modTime.Before(before) {
+			continue
+		}
+		s.lock.Lock()
+		_, pending := s.buffers[eventID]
+		s.lock.Unlock()
+		if pending {
+			continue
+		}
+		if err := s.storage.RemoveDir(s.eventDir(eventID)); err != nil {
+			s.log.Errorf("Failed to remove old event log %s: %v", eventID, err)
+			continue
+		}
+		cleaned++
+	}
+	if cleaned > 0 {
+		s.log.Infof("Cleaned %d old event logs", cleaned)
+	}
+	return nil
+}
+
+// EventIDs 返回对象存储中所有事件的EventID
+func (s *S3FileStore) EventIDs() ([]string, error) {
+	files, err := s.storage.ListFiles(s.basePath)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var eventIDs []string
+	for _, file := range files {
+		eventID, ok := s.eventOf(file)
+		if ok && !seen[eventID] {
+			seen[eventID] = true
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	return eventIDs, nil
+}
+
+// Close 将缓存的消息全部写入对象存储
+func (s *S3FileStore) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		close(s.stop)
+	}
+	<-s.done
+	s.flushAll()
+	s.log.Info("S3 file store closed")
+	return nil
+}
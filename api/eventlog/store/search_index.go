@@ -0,0 +1,253 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	cdb "github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	indexFlushLines    = 200
+	indexFlushInterval = 2 * time.Second
+	indexCleanInterval = time.Hour
+	// indexEventCacheSize 缓存的事件归属信息的上限，超过后整体清空
+	indexEventCacheSize = 10000
+	// indexResolveTimeout 事件入库前产生的索引最多等待的时间，超时后仍查询不到事件时丢弃索引
+	indexResolveTimeout = time.Minute
+)
+
+// IndexedFileStore 在写入事件日志的同时异步为消息建立检索索引。
+// 索引按批写入数据库，写入失败或积压时只丢弃索引，不影响事件日志本身的存储。
+type IndexedFileStore struct {
+	FileStore
+	retention time.Duration
+	pending   chan *dbmodel.EventLogIndex
+	// events 事件所属的租户、组件和操作类型，只在索引协程中访问
+	events map[string]*dbmodel.ServiceEvent
+	// deferred 事件尚未入库、还不知道所属租户的索引，以及事件第一次被查询的时间，只在索引协程中访问
+	deferred []*dbmodel.EventLogIndex
+	waiting  map[string]time.Time
+	stop     chan struct{}
+	done     chan struct{}
+	log      *logrus.Entry
+}
+
+// NewIndexedFileStore 为 store 增加检索索引，retention 大于 0 时定期清理过期的索引，数据库管理器需要已经初始化
+func NewIndexedFileStore(store FileStore, retention time.Duration, log *logrus.Entry) *IndexedFileStore {
+	if log == nil {
+		log = logrus.WithField("module", "searchIndex")
+	}
+	s := &IndexedFileStore{
+		FileStore: store,
+		retention: retention,
+		pending:   make(chan *dbmodel.EventLogIndex, indexFlushLines*20),
+		events:    make(map[string]*dbmodel.ServiceEvent),
+		waiting:   make(map[string]time.Time),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		log:       log,
+	}
+	go s.indexLoop()
+	return s
+}
+
+// Append 追加消息并为其建立索引
+func (s *IndexedFileStore) Append(eventID string, message *db.EventLogMessage) error {
+	if err := s.FileStore.Append(eventID, message); err != nil {
+		return err
+	}
+	if eventID == "" || message == nil {
+		return nil
+	}
+	index := &dbmodel.EventLogIndex{
+		EventID:     eventID,
+		Step:        message.Step,
+		Level:       message.Level,
+		Message:     message.Message,
+		MessageTime: parseMessageTime(message.Time),
+	}
+	select {
+	case s.pending <- index:
+	default:
+		s.log.Warningf("Search index of event log is busy, drop the index of event %s", eventID)
+	}
+	return nil
+}
+
+// Delete 删除事件的所有消息及其索引
+func (s *IndexedFileStore) Delete(eventID string) error {
+	if err := s.FileStore.Delete(eventID); err != nil {
+		return err
+	}
+	return cdb.GetManager().EventLogIndexDao().DeleteEventLogIndexes(eventID)
+}
+
+// Clean 清理过期的事件及早于 before 的索引
+func (s *IndexedFileStore) Clean(before time.Time) error {
+	if err := s.FileStore.Clean(before); err != nil {
+		return err
+	}
+	_, err := cdb.GetManager().EventLogIndexDao().DeleteEventLogIndexesBefore(before)
+	return err
+}
+
+// Close 写入剩余的索引后关闭存储
+func (s *IndexedFileStore) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.FileStore.Close()
+}
+
+func (s *IndexedFileStore) indexLoop() {
+	defer close(s.done)
+	flush := time.NewTicker(indexFlushInterval)
+	defer flush.Stop()
+	clean := time.NewTicker(indexCleanInterval)
+	defer clean.Stop()
+	var batch []*dbmodel.EventLogIndex
+	for {
+		select {
+		case index := <-s.pending:
+			batch = append(batch, index)
+			if len(batch) >= indexFlushLines {
+				s.flush(batch)
+				batch = nil
+			}
+		case <-flush.C:
+			s.flush(batch)
+			batch = nil
+		case <-clean.C:
+			s.cleanExpired()
+		case <-s.stop:
+			for {
+				select {
+				case index := <-s.pending:
+					batch = append(batch, index)
+				default:
+					s.flush(batch)
+					if len(s.deferred) > 0 {
+						s.log.Warningf("Drop %d search indexes of event log whose events are not found", len(s.deferred))
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 补全索引的事件归属信息后批量写入。事件尚未入库的索引留到下一批再写入，
+// 避免写入没有租户的索引；超过 indexResolveTimeout 仍查询不到事件的索引被丢弃。
+func (s *IndexedFileStore) flush(batch []*dbmodel.EventLogIndex) {
+	batch = append(s.deferred, batch...)
+	s.deferred = nil
+	if len(batch) == 0 {
+		return
+	}
+	s.resolveEvents(batch)
+	now := time.Now()
+	resolved := make([]*dbmodel.EventLogIndex, 0, len(batch))
+	for _, index := range batch {
+		event := s.events[index.EventID]
+		if event == nil {
+			first, ok := s.waiting[index.EventID]
+			if !ok {
+				first = now
+				s.waiting[index.EventID] = now
+			}
+			if now.Sub(first) < indexResolveTimeout && len(s.deferred) < cap(s.pending) {
+				s.deferred = append(s.deferred, index)
+			}
+			continue
+		}
+		delete(s.waiting, index.EventID)
+		index.TenantID = event.TenantID
+		index.ServiceID = event.ServiceID
+		if index.ServiceID == "" && event.Target == dbmodel.TargetTypeService {
+			index.ServiceID = event.TargetID
+		}
+		index.OptType = event.OptType
+		resolved = append(resolved, index)
+	}
+	for eventID, first := range s.waiting {
+		if now.Sub(first) >= indexResolveTimeout {
+			s.log.Warningf("Event %s is not found in %s, drop its search indexes", eventID, indexResolveTimeout)
+			delete(s.waiting, eventID)
+		}
+	}
+	if len(resolved) == 0 {
+		return
+	}
+	if err := cdb.GetManager().EventLogIndexDao().CreateEventLogIndexesInBatch(resolved); err != nil {
+		s.log.Errorf("Failed to save %d search indexes of event log: %v", len(resolved), err)
+	}
+}
+
+// resolveEvents 查询未缓存的事件，事件尚未入库时不缓存，下一批消息会再次查询
+func (s *IndexedFileStore) resolveEvents(batch []*dbmodel.EventLogIndex) {
+	var eventIDs []string
+	seen := make(map[string]bool)
+	for _, index := range batch {
+		if _, ok := s.events[index.EventID]; ok || seen[index.EventID] {
+			continue
+		}
+		seen[index.EventID] = true
+		eventIDs = append(eventIDs, index.EventID)
+	}
+	if len(eventIDs) == 0 {
+		return
+	}
+	events, err := cdb.GetManager().ServiceEventDao().GetEventByEventIDs(eventIDs)
+	if err != nil {
+		s.log.Errorf("Failed to get events of search index: %v", err)
+		return
+	}
+	if len(s.events)+len(events) > indexEventCacheSize {
+		s.events = make(map[string]*dbmodel.ServiceEvent)
+	}
+	for _, event := range events {
+		s.events[event.EventID] = event
+	}
+}
+
+func (s *IndexedFileStore) cleanExpired() {
+	if s.retention <= 0 {
+		return
+	}
+	count, err := cdb.GetManager().EventLogIndexDao().DeleteEventLogIndexesBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		s.log.Errorf("Failed to clean expired search indexes of event log: %v", err)
+		return
+	}
+	if count > 0 {
+		s.log.Infof("Cleaned %d expired search indexes of event log", count)
+	}
+}
+
+// parseMessageTime 解析消息中的时间，无法解析时使用当前时间
+func parseMessageTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	return time.Now()
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	cdb "github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	mysqldao "github.com/goodrain/rainbond/db/mysql/dao"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+)
+
+type searchIndexTestManager struct {
+	cdb.Manager
+	eventDao         dbdao.EventDao
+	eventLogIndexDao dbdao.EventLogIndexDao
+}
+
+func (m searchIndexTestManager) ServiceEventDao() dbdao.EventDao {
+	return m.eventDao
+}
+
+func (m searchIndexTestManager) EventLogIndexDao() dbdao.EventLogIndexDao {
+	return m.eventLogIndexDao
+}
+
+func newSearchIndexTestDB(t *testing.T) dbdao.EventLogIndexDao {
+	t.Helper()
+	gdb, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("open sqlite db: %v", err)
+	}
+	gdb.LogMode(false)
+	t.Cleanup(func() { gdb.Close() })
+	if err := gdb.AutoMigrate(&dbmodel.ServiceEvent{}, &dbmodel.EventLogIndex{}).Error; err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	events := []*dbmodel.ServiceEvent{
+		{EventID: "build-1", TenantID: "tenant-a", ServiceID: "service-a", Target: dbmodel.TargetTypeService, TargetID: "service-a", OptType: "build-service"},
+		{EventID: "build-2", TenantID: "tenant-b", Target: dbmodel.TargetTypeService, TargetID: "service-b", OptType: "build-service"},
+	}
+	for _, event := range events {
+		if err := gdb.Create(event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexDao := &mysqldao.EventLogIndexDaoImpl{DB: gdb}
+	cdb.SetTestManager(searchIndexTestManager{eventDao: &mysqldao.EventDaoImpl{DB: gdb}, eventLogIndexDao: indexDao})
+	t.Cleanup(func() { cdb.SetTestManager(nil) })
+	return indexDao
+}
+
+func searchTotal(t *testing.T, dao dbdao.EventLogIndexDao, query *dbmodel.EventLogSearchQuery) int {
+	t.Helper()
+	_, total, err := dao.SearchEventLogIndexes(query, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return total
+}
+
+// capability_id: rainbond.eventlog.search
+func TestIndexedFileStoreSearch(t *testing.T) {
+	indexDao := newSearchIndexTestDB(t)
+	local, err := NewJSONLinesFileStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewIndexedFileStore(local, 0, nil)
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	messages := []struct {
+		eventID, step, level, message string
+		offset                        time.Duration
+	}{
+		{"build-1", "build-code", "info", "start build", 0},
+		{"build-1", "build-code", "error", "java.lang.OutOfMemoryError: Java heap space", time.Minute},
+		{"build-1", "build-code", "debug", "gc overhead", 2 * time.Minute},
+		{"build-2", "build-code", "error", "java.lang.OutOfMemoryError: Metaspace", 3 * time.Minute},
+		{"build-2", "push-image", "info", "push image success", 48 * time.Hour},
+	}
+	for _, m := range messages {
+		err := store.Append(m.eventID, &db.EventLogMessage{EventID: m.eventID, Step: m.step, Level: m.level, Message: m.message, Time: base.Add(m.offset).Format(time.RFC3339)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if all, _ := store.ReadAll("build-1"); len(all) != 3 {
+		t.Fatalf("indexed store should keep messages in the wrapped store, got %d", len(all))
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, total, err := indexDao.SearchEventLogIndexes(&dbmodel.EventLogSearchQuery{TenantID: "tenant-a", Keyword: "OutOfMemoryError"}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(list) != 1 || list[0].EventID != "build-1" || list[0].ServiceID != "service-a" || list[0].OptType != "build-service" {
+		t.Fatalf("unexpected search result %d %+v", total, list)
+	}
+	// 事件没有 service_id 时使用组件类型的 target_id
+	if total := searchTotal(t, indexDao, &dbmodel.EventLogSearchQuery{ServiceID: "service-b"}); total != 2 {
+		t.Fatalf("search by service total = %d, want 2", total)
+	}
+	if total := searchTotal(t, indexDao, &dbmodel.EventLogSearchQuery{Step: "build-code", Level: "error"}); total != 2 {
+		t.Fatalf("search by step and level total = %d, want 2", total)
+	}
+	lastWeek := &dbmodel.EventLogSearchQuery{StartTime: base, EndTime: base.Add(24 * time.Hour)}
+	if total := searchTotal(t, indexDao, lastWeek); total != 4 {
+		t.Fatalf("search by time range total = %d, want 4", total)
+	}
+
+	// 分页按消息时间倒序返回
+	page, total, err := indexDao.SearchEventLogIndexes(&dbmodel.EventLogSearchQuery{}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 || len(page) != 2 || page[0].Message != "gc overhead" || page[1].Message != "java.lang.OutOfMemoryError: Java heap space" {
+		t.Fatalf("unexpected second page %d %+v", total, page)
+	}
+}
+
+// capability_id: rainbond.eventlog.search
+func TestIndexedFileStoreDeleteAndRetention(t *testing.T) {
+	indexDao := newSearchIndexTestDB(t)
+	local, err := NewJSONLinesFileStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewIndexedFileStore(local, time.Hour, nil)
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	store.Append("build-1", &db.EventLogMessage{EventID: "build-1", Level: "info", Message: "old", Time: old})
+	store.Append("build-1", &db.EventLogMessage{EventID: "build-1", Level: "info", Message: "new", Time: time.Now().Format(time.RFC3339)})
+	store.Append("build-2", &db.EventLogMessage{EventID: "build-2", Level: "info", Message: "other", Time: time.Now().Format(time.RFC3339)})
+	// 关闭时写入剩余的索引
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store.cleanExpired()
+	if total := searchTotal(t, indexDao, &dbmodel.EventLogSearchQuery{}); total != 2 {
+		t.Fatalf("total after retention clean = %d, want 2", total)
+	}
+	if err := store.Delete("build-2"); err != nil {
+		t.Fatal(err)
+	}
+	list, total, _ := indexDao.SearchEventLogIndexes(&dbmodel.EventLogSearchQuery{}, 0, 10)
+	if total != 1 || list[0].Message != "new" {
+		t.Fatalf("unexpected indexes after delete %+v", list)
+	}
+}
+
+// capability_id: rainbond.eventlog.search
+func TestIndexedFileStoreDefersUnresolvedEvents(t *testing.T) {
+	indexDao := newSearchIndexTestDB(t)
+	store := &IndexedFileStore{
+		pending: make(chan *dbmodel.EventLogIndex, 10),
+		events:  make(map[string]*dbmodel.ServiceEvent),
+		waiting: make(map[string]time.Time),
+		log:     logrus.WithField("module", "searchIndex"),
+	}
+	store.flush([]*dbmodel.EventLogIndex{{EventID: "build-3", Message: "start build", MessageTime: time.Now()}})
+	if total := searchTotal(t, indexDao, &dbmodel.EventLogSearchQuery{}); total != 0 {
+		t.Fatalf("index of an unknown event should not be written without tenant, total = %d", total)
+	}
+
+	// 事件入库后，之前的索引带上租户写入
+	event := &dbmodel.ServiceEvent{EventID: "build-3", TenantID: "tenant-c", Target: dbmodel.TargetTypeService, TargetID: "service-c"}
+	if err := indexDao.(*mysqldao.EventLogIndexDaoImpl).DB.Create(event).Error; err != nil {
+		t.Fatal(err)
+	}
+	store.flush(nil)
+	if total := searchTotal(t, indexDao, &dbmodel.EventLogSearchQuery{TenantID: "tenant-c"}); total != 1 {
+		t.Fatalf("deferred index should be written with its tenant, total = %d", total)
+	}
+
+	// 超时仍查询不到事件的索引被丢弃
+	store.flush([]*dbmodel.EventLogIndex{{EventID: "build-4", Message: "lost", MessageTime: time.Now()}})
+	store.waiting["build-4"] = time.Now().Add(-2 * indexResolveTimeout)
+	store.flush(nil)
+	if len(store.deferred) != 0 || len(store.waiting) != 0 {
+		t.Fatalf("index of a missing event should be dropped, deferred %d waiting %d", len(store.deferred), len(store.waiting))
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/db"
+	cdb "github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/sirupsen/logrus"
+)
+
+// SQLFileStore 使用数据库保存事件日志，每条消息一行记录，多个副本可以共享同一份事件日志
+type SQLFileStore struct {
+	log *logrus.Entry
+}
+
+// NewSQLFileStore 创建数据库的事件日志存储，数据库管理器需要已经初始化
+func NewSQLFileStore(log *logrus.Entry) *SQLFileStore {
+	if log == nil {
+		log = logrus.WithField("module", "sqlfilestore")
+	}
+	log.Info("Initialized sql event log store")
+	return &SQLFileStore{log: log}
+}
+
+// Append 追加消息
+func (s *SQLFileStore) Append(eventID string, message *db.EventLogMessage) error {
+	if eventID == "" || message == nil {
+		return nil
+	}
+	record := &dbmodel.EventLogRecord{
+		EventID: eventID,
+		Step:    message.Step,
+		Status:  message.Status,
+		Level:   message.Level,
+		Message: message.Message,
+		Time:    message.Time,
+	}
+	if err := cdb.GetManager().EventLogRecordDao().AddModel(record); err != nil {
+		s.log.Errorf("Failed to save event log message of %s: %v", eventID, err)
+		return err
+	}
+	return nil
+}
+
+// ReadAll 读取所有历史消息
+func (s *SQLFileStore) ReadAll(eventID string) ([]*db.EventLogMessage, error) {
+	records, err := cdb.GetManager().EventLogRecordDao().GetEventLogRecords(eventID)
+	if err != nil {
+		s.log.Errorf("Failed to read event log messages of %s: %v", eventID, err)
+		return nil, err
+	}
+	return recordsToMessages(records), nil
+}
+
+// ReadLast 读取最后N条消息
+func (s *SQLFileStore) ReadLast(eventID string, n int) ([]*db.EventLogMessage, error) {
+	records, err := cdb.GetManager().EventLogRecordDao().GetLastEventLogRecords(eventID, n)
+	if err != nil {
+		s.log.Errorf("Failed to read event log messages of %s: %v", eventID, err)
+		return nil, err
+	}
+	return recordsToMessages(records), nil
+}
+
+// Delete 删除事件的所有消息
+func (s *SQLFileStore) Delete(eventID string) error {
+	return cdb.GetManager().EventLogRecordDao().DeleteEventLogRecords(eventID)
+}
+
+// Clean 删除最后一条消息早于 before 的事件
+func (s *SQLFileStore) Clean(before time.Time) error {
+	cleaned, err := cdb.GetManager().EventLogRecordDao().DeleteEventLogRecordsBefore(before)
+	if err != nil {
+		s.log.Errorf("Failed to clean old event log messages: %v", err)
+		return err
+	}
+	if cleaned > 0 {
+		s.log.Infof("Cleaned %d old event log messages", cleaned)
+	}
+	return nil
+}
+
+// EventIDs 返回有消息的所有事件
+func (s *SQLFileStore) EventIDs() ([]string, error) {
+	return cdb.GetManager().EventLogRecordDao().GetEventIDs()
+}
+
+// Close 关闭存储，数据库连接由数据库管理器维护
+func (s *SQLFileStore) Close() error {
+	return nil
+}
+
+func recordsToMessages(records []*dbmodel.EventLogRecord) []*db.EventLogMessage {
+	if len(records) == 0 {
+		return nil
+	}
+	messages := make([]*db.EventLogMessage, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, &db.EventLogMessage{
+			EventID: record.EventID,
+			Step:    record.Step,
+			Status:  record.Status,
+			Message: record.Message,
+			Level:   record.Level,
+			Time:    record.Time,
+		})
+	}
+	return messages
+}
@@ -180,6 +180,10 @@ func (a *AppRestoreAction) RestoreProbe(serviceID string, req *apimodel.ServiceP
 		probe.Scheme = req.Scheme
 		probe.SuccessThreshold = req.SuccessThreshold
 		probe.TimeoutSecond = req.TimeoutSecond
+		probe.GRPCService = req.GRPCService
+		probe.GRPCTLS = req.GRPCTLS
+		probe.GRPCInsecureSkipVerify = req.GRPCInsecureSkipVerify
+		probe.GRPCServerName = req.GRPCServerName
 		if err := db.GetManager().ServiceProbeDaoTransactions(tx).AddModel(probe); err != nil {
 			tx.Rollback()
 			return err
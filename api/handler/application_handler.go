@@ -927,6 +927,12 @@ func (a *ApplicationAction) deleteByComponentIDs(tx *gorm.DB, app *dbmodel.Appli
 	if err = db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).DeleteByRuleIDs(autoScaleRuleIDs); err != nil {
 		return err
 	}
+	if err = db.GetManager().TenantServiceScheduledScalingDaoTransactions(tx).DeleteByComponentIDs(componentIDs); err != nil {
+		return err
+	}
+	if err = db.GetManager().TenantServiceIdlePolicyDaoTransactions(tx).DeleteByComponentIDs(componentIDs); err != nil {
+		return err
+	}
 	return db.GetManager().ComponentK8sAttributeDaoTransactions(tx).DeleteByComponentIDs(componentIDs)
 }
 
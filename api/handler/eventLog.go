@@ -23,6 +23,7 @@ import (
 	"compress/zlib"
 	"fmt"
 	eventdb "github.com/goodrain/rainbond/api/eventlog/db"
+	eventstore "github.com/goodrain/rainbond/api/eventlog/store"
 	"github.com/goodrain/rainbond/config/configs"
 	"io"
 	"io/ioutil"
@@ -33,12 +34,16 @@ import (
 	apimodel "github.com/goodrain/rainbond/api/model"
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/component/eventlog"
 	"github.com/goodrain/rainbond/util/constants"
+	"github.com/sirupsen/logrus"
 )
 
 // LogAction  log action struct
 type LogAction struct {
 	eventdb *eventdb.EventFilePlugin
+	// fileStore 返回 eventlog 服务配置的事件日志存储，服务未启动时返回 nil
+	fileStore func() eventstore.FileStore
 }
 
 // CreateLogManager get log manager
@@ -46,6 +51,12 @@ func CreateLogManager() *LogAction {
 	config := configs.Default()
 	return &LogAction{
 		eventdb: eventdb.NewEventFilePlugin(config.LogConfig.LogPath),
+		fileStore: func() eventstore.FileStore {
+			if eventlog.Default() == nil {
+				return nil
+			}
+			return eventlog.Default().FileStore()
+		},
 	}
 }
 
@@ -65,6 +76,11 @@ func (l *LogAction) GetMyTeamsEvents(target string, tenantIDs []string, page, si
 	return nil, nil
 }
 
+// SearchEventLogs search event log messages across events
+func (l *LogAction) SearchEventLogs(query *dbmodel.EventLogSearchQuery, page, size int) ([]*dbmodel.EventLogIndex, int, error) {
+	return db.GetManager().EventLogIndexDao().SearchEventLogIndexes(query, (page-1)*size, size)
+}
+
 // GetLogList get log list
 func (l *LogAction) GetLogList(serviceAlias string) ([]*model.HistoryLogFile, error) {
 	logDIR := path.Join(constants.GrdataLogPath, serviceAlias)
@@ -110,7 +126,19 @@ func (l *LogAction) GetLogInstance(serviceID string) (string, error) {
 }
 
 // GetLevelLog get event log
+// 优先从配置的事件日志存储读取，包括已轮转和压缩的分段，存储中没有该事件时读取旧版本的日志文件
 func (l *LogAction) GetLevelLog(eventID string, level string) (*apimodel.DataLog, error) {
+	if fileStore := l.getFileStore(); fileStore != nil {
+		messages, err := fileStore.ReadAll(eventID)
+		if err != nil {
+			logrus.Warningf("read event %s log from file store failure: %v", eventID, err)
+		} else if len(messages) > 0 {
+			return &apimodel.DataLog{
+				Status: "success",
+				Data:   eventdb.StructuredMessages(messages, level),
+			}, nil
+		}
+	}
 	re, err := l.eventdb.GetMessages(eventID, level, 0)
 	if err != nil {
 		return nil, err
@@ -130,6 +158,13 @@ func (l *LogAction) GetLevelLog(eventID string, level string) (*apimodel.DataLog
 	}, nil
 }
 
+func (l *LogAction) getFileStore() eventstore.FileStore {
+	if l.fileStore == nil {
+		return nil
+	}
+	return l.fileStore()
+}
+
 // Decompress zlib解码
 func decompress(zb []byte) ([]byte, error) {
 	b := bytes.NewReader(zb)
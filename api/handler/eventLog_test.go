@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	eventdb "github.com/goodrain/rainbond/api/eventlog/db"
+	eventstore "github.com/goodrain/rainbond/api/eventlog/store"
+)
+
+type memoryEventFileStore struct {
+	messages map[string][]*eventdb.EventLogMessage
+}
+
+func (m *memoryEventFileStore) Append(eventID string, message *eventdb.EventLogMessage) error {
+	m.messages[eventID] = append(m.messages[eventID], message)
+	return nil
+}
+
+func (m *memoryEventFileStore) ReadAll(eventID string) ([]*eventdb.EventLogMessage, error) {
+	return m.messages[eventID], nil
+}
+
+func (m *memoryEventFileStore) ReadLast(eventID string, n int) ([]*eventdb.EventLogMessage, error) {
+	return m.messages[eventID], nil
+}
+
+func (m *memoryEventFileStore) Delete(eventID string) error {
+	delete(m.messages, eventID)
+	return nil
+}
+
+func (m *memoryEventFileStore) Clean(before time.Time) error {
+	return nil
+}
+
+func (m *memoryEventFileStore) EventIDs() ([]string, error) {
+	var eventIDs []string
+	for eventID := range m.messages {
+		eventIDs = append(eventIDs, eventID)
+	}
+	return eventIDs, nil
+}
+
+func (m *memoryEventFileStore) Close() error {
+	return nil
+}
+
+// capability_id: rainbond.eventlog.file-store-backends
+func TestGetLevelLogReadsConfiguredFileStore(t *testing.T) {
+	fileStore := &memoryEventFileStore{messages: map[string][]*eventdb.EventLogMessage{
+		"event-1": {
+			{EventID: "event-1", Level: "info", Message: "build start", Time: "2024-05-01T10:00:00+08:00"},
+			{EventID: "event-1", Level: "debug", Message: "pull image"},
+			{EventID: "event-1", Level: "error", Message: "build failure"},
+		},
+	}}
+	action := &LogAction{
+		eventdb:   &eventdb.EventFilePlugin{HomePath: t.TempDir()},
+		fileStore: func() eventstore.FileStore { return fileStore },
+	}
+
+	dl, err := action.GetLevelLog("event-1", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := dl.Data
+	if len(messages) != 2 || messages[0].Message != "build start" || messages[1].Message != "build failure" {
+		t.Fatalf("unexpected info messages %+v", dl.Data)
+	}
+	if messages[0].Unixtime == 0 {
+		t.Fatalf("message time should be parsed, got %+v", messages[0])
+	}
+	dl, err = action.GetLevelLog("event-1", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dl.Data) != 3 {
+		t.Fatalf("debug level should return all messages, got %+v", dl.Data)
+	}
+}
+
+// capability_id: rainbond.eventlog.file-rotation
+func TestGetLevelLogReadsRotatedCompressedEvent(t *testing.T) {
+	dir := t.TempDir()
+	var rotated bytes.Buffer
+	gz := gzip.NewWriter(&rotated)
+	for _, message := range []*eventdb.EventLogMessage{
+		{EventID: "event-1", Level: "info", Message: "build start"},
+		{EventID: "event-1", Level: "debug", Message: "pull image"},
+	} {
+		line, _ := json.Marshal(message)
+		gz.Write(append(line, '\n'))
+	}
+	gz.Close()
+	if err := ioutil.WriteFile(filepath.Join(dir, "event-1.000001.jsonl.gz"), rotated.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	line, _ := json.Marshal(&eventdb.EventLogMessage{EventID: "event-1", Level: "info", Message: "build success"})
+	if err := ioutil.WriteFile(filepath.Join(dir, "event-1.jsonl"), append(line, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileStore, err := eventstore.NewJSONLinesFileStoreWithOptions(dir, eventstore.FileStoreOptions{Compression: eventstore.CompressionGzip, MaxFileSize: 512}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fileStore.Close()
+	action := &LogAction{
+		eventdb:   &eventdb.EventFilePlugin{HomePath: t.TempDir()},
+		fileStore: func() eventstore.FileStore { return fileStore },
+	}
+
+	dl, err := action.GetLevelLog("event-1", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dl.Data) != 2 || dl.Data[0].Message != "build start" || dl.Data[1].Message != "build success" {
+		t.Fatalf("rotated and compressed messages should be read, got %+v", dl.Data)
+	}
+}
@@ -32,4 +32,5 @@ type EventHandler interface {
 	GetLogFile(serviceAlias, fileName string) (string, string, error)
 	GetEvents(target, targetID string, page, size int) ([]*dbmodel.ServiceEvent, int, error)
 	GetMyTeamsEvents(target string, targetIDs []string, page, size int) ([]*dbmodel.EventAndBuild, error)
+	SearchEventLogs(query *dbmodel.EventLogSearchQuery, page, size int) ([]*dbmodel.EventLogIndex, int, error)
 }
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	apimodel "github.com/goodrain/rainbond/api/model"
+	"github.com/goodrain/rainbond/api/util/bcode"
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/goodrain/rainbond/mq/client"
+	"github.com/goodrain/rainbond/pkg/component/mq"
+)
+
+// DeadLetterHandler 管理消息队列中的死信任务
+type DeadLetterHandler struct {
+	mqClient client.MQClient
+}
+
+// ListDeadLetters 返回主题的全部死信任务
+func (h *DeadLetterHandler) ListDeadLetters(topic string) ([]*apimodel.DeadLetter, error) {
+	return h.listDeadLetters(topic, nil)
+}
+
+// GetDeadLetter 返回指定的死信任务
+func (h *DeadLetterHandler) GetDeadLetter(topic, messageID string) (*apimodel.DeadLetter, error) {
+	deadLetters, err := h.listDeadLetters(topic, []string{messageID})
+	if err != nil {
+		return nil, err
+	}
+	if len(deadLetters) == 0 {
+		return nil, bcode.NotFound
+	}
+	return deadLetters[0], nil
+}
+
+// ReplayDeadLetters 将死信任务重新投递到原主题，messageIDs 为空时重放全部，返回重放的数量
+func (h *DeadLetterHandler) ReplayDeadLetters(topic string, messageIDs []string) (int32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := h.mqClient.ReplayDeadLetters(ctx, &pb.DeadLetterRequest{Topic: topic, MessageIds: messageIDs})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Count, nil
+}
+
+// PurgeDeadLetters 删除死信任务，messageIDs 为空时删除全部，返回删除的数量
+func (h *DeadLetterHandler) PurgeDeadLetters(topic string, messageIDs []string) (int32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := h.mqClient.PurgeDeadLetters(ctx, &pb.DeadLetterRequest{Topic: topic, MessageIds: messageIDs})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Count, nil
+}
+
+func (h *DeadLetterHandler) listDeadLetters(topic string, messageIDs []string) ([]*apimodel.DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := h.mqClient.ListDeadLetters(ctx, &pb.DeadLetterRequest{Topic: topic, MessageIds: messageIDs})
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]*apimodel.DeadLetter, 0, len(reply.DeadLetters))
+	for _, dl := range reply.DeadLetters {
+		deadLetter := &apimodel.DeadLetter{
+			MessageID: dl.MessageId,
+			Topic:     dl.Topic,
+			Attempts:  dl.Attempts,
+			LastError: dl.LastError,
+			DeadTime:  dl.DeadTime,
+		}
+		if msg := dl.Message; msg != nil {
+			deadLetter.TaskID = msg.TaskId
+			deadLetter.TaskType = msg.TaskType
+			deadLetter.TaskBody = string(msg.TaskBody)
+			deadLetter.CreateTime = msg.CreateTime
+			deadLetter.User = msg.User
+			deadLetter.Arch = msg.Arch
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+var deadLetterHandler *DeadLetterHandler
+
+// GetDeadLetterHandler returns the singleton DeadLetterHandler
+func GetDeadLetterHandler() *DeadLetterHandler {
+	if deadLetterHandler == nil {
+		deadLetterHandler = &DeadLetterHandler{mqClient: mq.Default().MqClient}
+	}
+	return deadLetterHandler
+}
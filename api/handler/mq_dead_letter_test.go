@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goodrain/rainbond/api/util/bcode"
+	mqpb "github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type deadLetterMQClient struct {
+	noopMQClient
+	requests []*mqpb.DeadLetterRequest
+}
+
+func (m *deadLetterMQClient) ListDeadLetters(ctx context.Context, in *mqpb.DeadLetterRequest, opts ...grpc.CallOption) (*mqpb.DeadLetterReply, error) {
+	m.requests = append(m.requests, in)
+	if len(in.MessageIds) > 0 && in.MessageIds[0] == "missing" {
+		return &mqpb.DeadLetterReply{}, nil
+	}
+	return &mqpb.DeadLetterReply{DeadLetters: []*mqpb.DeadLetter{{
+		MessageId: "msg-1",
+		Topic:     in.Topic,
+		Message:   &mqpb.TaskMessage{TaskId: "task-1", TaskType: "build_from_image", TaskBody: []byte(`{"service_id":"s1"}`)},
+		Attempts:  2,
+		LastError: "pull image failure",
+	}}}, nil
+}
+
+func (m *deadLetterMQClient) ReplayDeadLetters(ctx context.Context, in *mqpb.DeadLetterRequest, opts ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	m.requests = append(m.requests, in)
+	return &mqpb.TaskReply{Status: "success", Count: int32(len(in.MessageIds))}, nil
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestDeadLetterHandlerListAndReplay(t *testing.T) {
+	client := &deadLetterMQClient{}
+	h := &DeadLetterHandler{mqClient: client}
+
+	list, err := h.ListDeadLetters("builder")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "task-1", list[0].TaskID)
+		assert.Equal(t, `{"service_id":"s1"}`, list[0].TaskBody)
+		assert.Equal(t, "pull image failure", list[0].LastError)
+	}
+
+	_, err = h.GetDeadLetter("builder", "missing")
+	assert.Equal(t, bcode.NotFound, err)
+
+	count, err := h.ReplayDeadLetters("builder", []string{"msg-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+	assert.Equal(t, []string{"msg-1"}, client.requests[len(client.requests)-1].MessageIds)
+}
@@ -402,6 +402,10 @@ func (c *clusterAction) createTelescopic(telescopic model.TelescopicManagement,
 			MetricsName:       metric.MetricsName,
 			MetricTargetType:  metric.MetricTargetType,
 			MetricTargetValue: metric.MetricTargetValue,
+			MetricQuery:       metric.MetricQuery,
+			ObjectKind:        metric.ObjectKind,
+			ObjectName:        metric.ObjectName,
+			ObjectAPIVersion:  metric.ObjectAPIVersion,
 		}
 		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDao().AddModel(m); err != nil {
 			logrus.Errorf("%v TenantServceAutoscalerRuleMetricsDao creation failed:%v", service.ServiceAlias, err)
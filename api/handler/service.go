@@ -39,6 +39,7 @@ import (
 	"github.com/goodrain/rainbond/pkg/component/mq"
 	"github.com/goodrain/rainbond/pkg/component/prom"
 	"github.com/goodrain/rainbond/util/constants"
+	"github.com/goodrain/rainbond/worker/activator"
 	appmvolume "github.com/goodrain/rainbond/worker/appm/volume"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -1314,6 +1315,7 @@ func (s *ServiceAction) ServiceCreate(sc *apimodel.ServiceStruct) error {
 					ServiceID:   sc.ServiceID,
 					VolumeName:  volumn.VolumeName,
 					FileContent: volumn.FileContent,
+					Secret:      volumn.Secret,
 				}
 				if err := db.GetManager().TenantServiceConfigFileDaoTransactions(tx).AddModel(cf); err != nil {
 					tx.Rollback()
@@ -1504,21 +1506,25 @@ func (s *ServiceAction) ServiceCreate(sc *apimodel.ServiceStruct) error {
 
 func (s *ServiceAction) convertProbeModel(req *apimodel.ServiceProbe, serviceID string) *dbmodel.TenantServiceProbe {
 	return &dbmodel.TenantServiceProbe{
-		ServiceID:          serviceID,
-		Cmd:                req.Cmd,
-		FailureThreshold:   req.FailureThreshold,
-		HTTPHeader:         req.HTTPHeader,
-		InitialDelaySecond: req.InitialDelaySecond,
-		IsUsed:             &req.IsUsed,
-		Mode:               req.Mode,
-		Path:               req.Path,
-		PeriodSecond:       req.PeriodSecond,
-		Port:               req.Port,
-		ProbeID:            req.ProbeID,
-		Scheme:             req.Scheme,
-		SuccessThreshold:   req.SuccessThreshold,
-		TimeoutSecond:      req.TimeoutSecond,
-		FailureAction:      req.FailureAction,
+		ServiceID:              serviceID,
+		Cmd:                    req.Cmd,
+		FailureThreshold:       req.FailureThreshold,
+		HTTPHeader:             req.HTTPHeader,
+		InitialDelaySecond:     req.InitialDelaySecond,
+		IsUsed:                 &req.IsUsed,
+		Mode:                   req.Mode,
+		Path:                   req.Path,
+		PeriodSecond:           req.PeriodSecond,
+		Port:                   req.Port,
+		ProbeID:                req.ProbeID,
+		Scheme:                 req.Scheme,
+		SuccessThreshold:       req.SuccessThreshold,
+		TimeoutSecond:          req.TimeoutSecond,
+		FailureAction:          req.FailureAction,
+		GRPCService:            req.GRPCService,
+		GRPCTLS:                req.GRPCTLS,
+		GRPCInsecureSkipVerify: req.GRPCInsecureSkipVerify,
+		GRPCServerName:         req.GRPCServerName,
 	}
 }
 
@@ -3294,19 +3300,17 @@ func (s *ServiceAction) AddAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 		MinReplicas: req.MinReplicas,
 		MaxReplicas: req.MaxReplicas,
 	}
+	if err := r.SetBehavior(req.Behavior); err != nil {
+		tx.Rollback()
+		return err
+	}
 	if err := db.GetManager().TenantServceAutoscalerRulesDaoTransactions(tx).AddModel(r); err != nil {
 		tx.Rollback()
 		return err
 	}
 
 	for _, metric := range req.Metrics {
-		m := &dbmodel.TenantServiceAutoscalerRuleMetrics{
-			RuleID:            req.RuleID,
-			MetricsType:       metric.MetricsType,
-			MetricsName:       metric.MetricsName,
-			MetricTargetType:  metric.MetricTargetType,
-			MetricTargetValue: metric.MetricTargetValue,
-		}
+		m := metric.DbModel(req.RuleID)
 		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(m); err != nil {
 			tx.Rollback()
 			return err
@@ -3341,6 +3345,9 @@ func (s *ServiceAction) UpdAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	rule.XPAType = req.XPAType
 	rule.MinReplicas = req.MinReplicas
 	rule.MaxReplicas = req.MaxReplicas
+	if err := rule.SetBehavior(req.Behavior); err != nil {
+		return err
+	}
 
 	tx := db.GetManager().Begin()
 	defer db.GetManager().EnsureEndTransactionFunc()
@@ -3357,13 +3364,7 @@ func (s *ServiceAction) UpdAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	}
 
 	for _, metric := range req.Metrics {
-		m := &dbmodel.TenantServiceAutoscalerRuleMetrics{
-			RuleID:            req.RuleID,
-			MetricsType:       metric.MetricsType,
-			MetricsName:       metric.MetricsName,
-			MetricTargetType:  metric.MetricTargetType,
-			MetricTargetValue: metric.MetricTargetValue,
-		}
+		m := metric.DbModel(req.RuleID)
 		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(m); err != nil {
 			tx.Rollback()
 			return err
@@ -3402,6 +3403,132 @@ func (s *ServiceAction) ListScalingRecords(serviceID string, page, pageSize int)
 	return records, count, nil
 }
 
+// AddScheduledScaling -
+func (s *ServiceAction) AddScheduledScaling(req *apimodel.ScheduledScalingReq) error {
+	return db.GetManager().TenantServiceScheduledScalingDao().AddModel(req.DbModel())
+}
+
+// UpdScheduledScaling -
+func (s *ServiceAction) UpdScheduledScaling(req *apimodel.ScheduledScalingReq) error {
+	schedule, err := db.GetManager().TenantServiceScheduledScalingDao().GetByScheduleID(req.ScheduleID)
+	if err != nil {
+		return err
+	}
+	if schedule.ServiceID != req.ServiceID {
+		return gorm.ErrRecordNotFound
+	}
+
+	schedule.Name = req.Name
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	schedule.MinReplicas = req.MinReplicas
+	schedule.MaxReplicas = req.MaxReplicas
+	schedule.Enable = req.Enable
+	if err := db.GetManager().TenantServiceScheduledScalingDao().UpdateModel(schedule); err != nil {
+		return err
+	}
+	return clearScheduledReplicas(req.ServiceID)
+}
+
+// ListScheduledScaling -
+func (s *ServiceAction) ListScheduledScaling(serviceID string) ([]*dbmodel.TenantServiceScheduledScaling, error) {
+	return db.GetManager().TenantServiceScheduledScalingDao().ListByServiceID(serviceID)
+}
+
+// DelScheduledScaling -
+func (s *ServiceAction) DelScheduledScaling(serviceID, scheduleID string) error {
+	schedule, err := db.GetManager().TenantServiceScheduledScalingDao().GetByScheduleID(scheduleID)
+	if err != nil {
+		return err
+	}
+	if schedule.ServiceID != serviceID {
+		return gorm.ErrRecordNotFound
+	}
+	if err := db.GetManager().TenantServiceScheduledScalingDao().DeleteByScheduleID(scheduleID); err != nil {
+		return err
+	}
+	return clearScheduledReplicas(serviceID)
+}
+
+// clearScheduledReplicas restores the replicas range of the autoscaler rules once the component has
+// no enabled schedule, the HPAs pick it up on the next refresh of the component
+func clearScheduledReplicas(serviceID string) error {
+	schedules, err := db.GetManager().TenantServiceScheduledScalingDao().ListByServiceID(serviceID)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if schedule.Enable {
+			return nil
+		}
+	}
+	rules, err := db.GetManager().TenantServceAutoscalerRulesDao().ListByServiceID(serviceID)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.ScheduledMinReplicas == nil && rule.ScheduledMaxReplicas == nil {
+			continue
+		}
+		rule.ScheduledMinReplicas, rule.ScheduledMaxReplicas = nil, nil
+		if err := db.GetManager().TenantServceAutoscalerRulesDao().UpdateModel(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIdlePolicy -
+func (s *ServiceAction) GetIdlePolicy(serviceID string) (*dbmodel.TenantServiceIdlePolicy, error) {
+	policy, err := db.GetManager().TenantServiceIdlePolicyDao().GetByServiceID(serviceID)
+	if err == gorm.ErrRecordNotFound {
+		return &dbmodel.TenantServiceIdlePolicy{ServiceID: serviceID}, nil
+	}
+	return policy, err
+}
+
+// SetIdlePolicy creates or updates the scale-to-zero policy of the component
+func (s *ServiceAction) SetIdlePolicy(serviceID string, req *apimodel.IdlePolicyReq) (*dbmodel.TenantServiceIdlePolicy, error) {
+	policy, err := db.GetManager().TenantServiceIdlePolicyDao().GetByServiceID(serviceID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		policy = &dbmodel.TenantServiceIdlePolicy{
+			ServiceID:   serviceID,
+			Enable:      req.Enable,
+			IdleMinutes: req.IdleMinutes,
+			UpdateTime:  time.Now(),
+		}
+		return policy, db.GetManager().TenantServiceIdlePolicyDao().AddModel(policy)
+	}
+	if policy.Enable && !req.Enable {
+		// the activator only serves the components that enable the policy, the routes are restored
+		// first so that the requests are not rejected in between
+		if err := s.restoreDirectRoutes(serviceID); err != nil {
+			return nil, fmt.Errorf("restore the routes of the component: %v", err)
+		}
+	}
+	policy.Enable = req.Enable
+	policy.IdleMinutes = req.IdleMinutes
+	// the idle time is counted from the update
+	policy.UpdateTime = time.Now()
+	return policy, db.GetManager().TenantServiceIdlePolicyDao().UpdateModel(policy)
+}
+
+// restoreDirectRoutes routes the requests of the component to its services instead of the activator
+func (s *ServiceAction) restoreDirectRoutes(serviceID string) error {
+	component, err := db.GetManager().TenantServiceDao().GetServiceByID(serviceID)
+	if err != nil {
+		return err
+	}
+	tenant, err := db.GetManager().TenantDao().GetTenantByUUID(component.TenantID)
+	if err != nil {
+		return err
+	}
+	return activator.RestoreDirectRoutes(context.Background(), s.apisixClient.ApisixV2(), tenant.Namespace, serviceID)
+}
+
 // SyncComponentBase -
 func (s *ServiceAction) SyncComponentBase(tx *gorm.DB, app *dbmodel.Application, components []*apimodel.Component) error {
 	var (
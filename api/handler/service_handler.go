@@ -45,6 +45,10 @@ type ServiceHandler interface {
 	CreateVMExport(serviceID string, req *VMExportRequest) (*VMExportStatus, error)
 	GetVMExport(serviceID, exportName string) (*VMExportStatus, error)
 	CreateVMSnapshot(serviceID string, req *VMSnapshotRequest) (*VMSnapshotStatus, error)
+	ListVMSnapshots(serviceID string) ([]*VMSnapshotInfo, error)
+	GetVMSnapshot(serviceID, snapshotName string) (*VMSnapshotInfo, error)
+	DeleteVMSnapshot(serviceID, snapshotName string) error
+	RestoreVMSnapshot(serviceID, snapshotName string, req *VMSnapshotRestoreRequest) (*VMSnapshotRestoreStatus, error)
 	GetVMLiveUpdateCapability(serviceID string) VMLiveUpdateCapability
 	SetVMFixedPodIP(ctx context.Context, serviceID string, enabled bool) (*VMFixedPodIPResult, error)
 	ServiceVertical(ctx context.Context, v *model.VerticalScalingTaskBody) error
@@ -97,6 +101,12 @@ type ServiceHandler interface {
 	AddAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	UpdAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	ListScalingRecords(serviceID string, page, pageSize int) ([]*dbmodel.TenantServiceScalingRecords, int, error)
+	AddScheduledScaling(req *apimodel.ScheduledScalingReq) error
+	UpdScheduledScaling(req *apimodel.ScheduledScalingReq) error
+	ListScheduledScaling(serviceID string) ([]*dbmodel.TenantServiceScheduledScaling, error)
+	DelScheduledScaling(serviceID, scheduleID string) error
+	GetIdlePolicy(serviceID string) (*dbmodel.TenantServiceIdlePolicy, error)
+	SetIdlePolicy(serviceID string, req *apimodel.IdlePolicyReq) (*dbmodel.TenantServiceIdlePolicy, error)
 
 	UpdateServiceMonitor(tenantID, serviceID, name string, update apimodel.UpdateServiceMonitorRequestStruct) (*dbmodel.TenantServiceMonitor, error)
 	DeleteServiceMonitor(tenantID, serviceID, name string) (*dbmodel.TenantServiceMonitor, error)
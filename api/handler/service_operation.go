@@ -335,20 +335,30 @@ func (o *OperationHandler) buildFromMarketSlug(r *model.ComponentBuildReq, servi
 	body["service_alias"] = service.ServiceAlias
 	body["slug_info"] = r.SlugInfo
 	body["configs"] = r.Configs
-	return o.sendBuildTopic(service.ServiceID, "build_from_market_slug", body, r.Arch)
+	return o.sendBuildTopic(r, service, "build_from_market_slug", body)
 }
-func (o *OperationHandler) sendBuildTopic(serviceID, taskType string, body map[string]interface{}, arch string) error {
-
+func (o *OperationHandler) sendBuildTopic(r *model.ComponentBuildReq, service *dbmodel.TenantServices, taskType string, body map[string]interface{}) error {
 	topic := gclient.BuilderTopic
-	if o.isWindowsService(serviceID) {
+	if o.isWindowsService(service.ServiceID) {
 		topic = gclient.WindowsBuilderTopic
 	}
-	return o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+	task := gclient.TaskStruct{
 		Topic:    topic,
 		TaskType: taskType,
 		TaskBody: body,
-		Arch:     arch,
-	})
+		Arch:     r.Arch,
+		Priority: gclient.ParsePriority(r.Priority),
+		TenantID: service.TenantID,
+	}
+	// 租户的构建配额与权重由消息队列用于公平调度
+	tenant, err := db.GetManager().TenantDao().GetTenantByUUID(service.TenantID)
+	if err != nil {
+		logrus.Warningf("get tenant %s of build task failure %s, schedule it without quota", service.TenantID, err.Error())
+	} else {
+		task.TenantQuota = int32(tenant.BuildQuota)
+		task.TenantWeight = int32(tenant.BuildWeight)
+	}
+	return o.mqCli.SendBuilderTopic(task)
 }
 
 func (o *OperationHandler) exportHelmChart(r *model.ComponentBuildReq, service *dbmodel.TenantServices) error {
@@ -394,7 +404,7 @@ func (o *OperationHandler) buildFromImage(r *model.ComponentBuildReq, service *d
 		body["password"] = r.ImageInfo.Password
 	}
 	body["configs"] = r.Configs
-	return o.sendBuildTopic(service.ServiceID, "build_from_image", body, r.Arch)
+	return o.sendBuildTopic(r, service, "build_from_image", body)
 }
 
 func (o *OperationHandler) buildFromSourceCode(r *model.ComponentBuildReq, service *dbmodel.TenantServices) error {
@@ -424,6 +434,23 @@ func (o *OperationHandler) buildFromSourceCode(r *model.ComponentBuildReq, servi
 	if r.CodeInfo.DockerfilePath != "" {
 		body["dockerfile_path"] = r.CodeInfo.DockerfilePath
 	}
+	if r.CodeInfo.SubDir != "" {
+		body["sub_dir"] = r.CodeInfo.SubDir
+	}
+	if len(r.CodeInfo.IncludePaths) > 0 {
+		body["include_paths"] = r.CodeInfo.IncludePaths
+	}
+	if len(r.CodeInfo.ExcludePaths) > 0 {
+		body["exclude_paths"] = r.CodeInfo.ExcludePaths
+	}
+	// 传递 webhook 推送的提交范围，由构建任务判断组件路径是否变更
+	if r.CodeInfo.CommitBefore != "" {
+		body["commit_before"] = r.CodeInfo.CommitBefore
+		body["commit_after"] = r.CodeInfo.CommitAfter
+	}
+	if r.CodeInfo.Checksum != "" {
+		body["checksum"] = r.CodeInfo.Checksum
+	}
 	buildStrategy := strings.TrimSpace(r.CodeInfo.BuildStrategy)
 	if buildStrategy == "" {
 		buildStrategy = strings.TrimSpace(r.CodeInfo.BuildType)
@@ -440,7 +467,7 @@ func (o *OperationHandler) buildFromSourceCode(r *model.ComponentBuildReq, servi
 	}
 	body["expire"] = 180
 	body["configs"] = r.Configs
-	return o.sendBuildTopic(service.ServiceID, "build_from_source_code", body, r.Arch)
+	return o.sendBuildTopic(r, service, "build_from_source_code", body)
 }
 
 func (o *OperationHandler) isWindowsService(serviceID string) bool {
@@ -469,7 +496,7 @@ func (o *OperationHandler) buildFromVM(r *model.ComponentBuildReq, service *dbmo
 	body["action"] = r.Action
 	body["event_id"] = r.EventID
 	body["image"] = r.ImageInfo.ImageURL
-	return o.sendBuildTopic(service.ServiceID, "build_from_vm", body, r.Arch)
+	return o.sendBuildTopic(r, service, "build_from_vm", body)
 }
 
 func (o *OperationHandler) buildFromKubeBlocks(r *model.ComponentBuildReq, service *dbmodel.TenantServices) error {
@@ -491,5 +518,5 @@ func (o *OperationHandler) buildFromKubeBlocks(r *model.ComponentBuildReq, servi
 	body["service_alias"] = service.ServiceAlias
 	body["action"] = r.Action
 	body["configs"] = r.Configs
-	return o.sendBuildTopic(service.ServiceID, "build_from_kubeblocks", body, r.Arch)
+	return o.sendBuildTopic(r, service, "build_from_kubeblocks", body)
 }
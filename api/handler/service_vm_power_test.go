@@ -39,6 +39,34 @@ func (m *recordingMQClient) Dequeue(context.Context, *mqpb.DequeueRequest, ...gr
 	return nil, nil
 }
 
+func (m *recordingMQClient) Ack(context.Context, *mqpb.AckRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) Nack(context.Context, *mqpb.AckRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) ListDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.DeadLetterReply, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) ReplayDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) PurgeDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) Subscribe(context.Context, *mqpb.SubscribeRequest, ...grpc.CallOption) (mqpb.TaskQueue_SubscribeClient, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) SubscribeTopic(context.Context, string, string, string, int32) <-chan *mqpb.TaskMessage {
+	return nil
+}
+
 func (m *recordingMQClient) Close() {}
 
 func (m *recordingMQClient) SendBuilderTopic(t mqclient.TaskStruct) error {
@@ -155,6 +155,34 @@ func (m *noopMQClient) Dequeue(context.Context, *mqpb.DequeueRequest, ...grpc.Ca
 	return nil, nil
 }
 
+func (m *noopMQClient) Ack(context.Context, *mqpb.AckRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) Nack(context.Context, *mqpb.AckRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) ListDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.DeadLetterReply, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) ReplayDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) PurgeDeadLetters(context.Context, *mqpb.DeadLetterRequest, ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) Subscribe(context.Context, *mqpb.SubscribeRequest, ...grpc.CallOption) (mqpb.TaskQueue_SubscribeClient, error) {
+	return nil, nil
+}
+
+func (m *noopMQClient) SubscribeTopic(context.Context, string, string, string, int32) <-chan *mqpb.TaskMessage {
+	return nil
+}
+
 func (m *noopMQClient) Close() {}
 
 func (m *noopMQClient) SendBuilderTopic(gclient.TaskStruct) error {
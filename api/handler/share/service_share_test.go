@@ -77,6 +77,34 @@ func (m *recordingMQClient) Dequeue(ctx context.Context, in *mqpb.DequeueRequest
 	return &mqpb.TaskMessage{}, nil
 }
 
+func (m *recordingMQClient) Ack(ctx context.Context, in *mqpb.AckRequest, opts ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return &mqpb.TaskReply{}, nil
+}
+
+func (m *recordingMQClient) Nack(ctx context.Context, in *mqpb.AckRequest, opts ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return &mqpb.TaskReply{}, nil
+}
+
+func (m *recordingMQClient) ListDeadLetters(ctx context.Context, in *mqpb.DeadLetterRequest, opts ...grpc.CallOption) (*mqpb.DeadLetterReply, error) {
+	return &mqpb.DeadLetterReply{}, nil
+}
+
+func (m *recordingMQClient) ReplayDeadLetters(ctx context.Context, in *mqpb.DeadLetterRequest, opts ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return &mqpb.TaskReply{}, nil
+}
+
+func (m *recordingMQClient) PurgeDeadLetters(ctx context.Context, in *mqpb.DeadLetterRequest, opts ...grpc.CallOption) (*mqpb.TaskReply, error) {
+	return &mqpb.TaskReply{}, nil
+}
+
+func (m *recordingMQClient) Subscribe(ctx context.Context, in *mqpb.SubscribeRequest, opts ...grpc.CallOption) (mqpb.TaskQueue_SubscribeClient, error) {
+	return nil, nil
+}
+
+func (m *recordingMQClient) SubscribeTopic(ctx context.Context, topic, group, host string, credits int32) <-chan *mqpb.TaskMessage {
+	return nil
+}
+
 func (m *recordingMQClient) Close() {}
 
 func (m *recordingMQClient) SendBuilderTopic(t mqclient.TaskStruct) error {
@@ -65,6 +65,8 @@ func (t *ThirdPartyServiceHanlder) AddEndpoints(sid string, d *model.AddEndpiont
 		ServiceID: sid,
 		IP:        address,
 		Port:      port,
+		Weight:    d.Weight,
+		Zone:      d.Zone,
 	}
 	if err := t.dbmanager.EndpointsDao().AddModel(ep); err != nil {
 		return err
@@ -87,6 +89,12 @@ func (t *ThirdPartyServiceHanlder) UpdEndpoints(d *model.UpdEndpiontsReq) error
 		ep.IP = address
 		ep.Port = port
 	}
+	if d.Weight != nil {
+		ep.Weight = d.Weight
+	}
+	if d.Zone != nil {
+		ep.Zone = *d.Zone
+	}
 	if err := t.dbmanager.EndpointsDao().UpdateModel(ep); err != nil {
 		return err
 	}
@@ -157,6 +165,8 @@ func (t *ThirdPartyServiceHanlder) ListEndpoints(componentID string) ([]*model.T
 		}
 		ep.IsStatic = sep.IsStatic
 		ep.Address = sep.Address
+		ep.Weight = sep.Weight
+		ep.Zone = sep.Zone
 		delete(staticEndpoints, ep.EpID)
 	}
 
@@ -205,6 +215,8 @@ func (t *ThirdPartyServiceHanlder) listStaticEndpoints(componentID string) (map[
 			Address:  address,
 			Status:   "-",
 			IsStatic: true,
+			Weight:   item.Weight,
+			Zone:     item.Zone,
 		}
 	}
 	return endpoints, nil
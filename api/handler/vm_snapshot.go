@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	gclient "github.com/goodrain/rainbond/mq/client"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/worker/discover/model"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,6 +18,9 @@ import (
 	snapshotv1 "kubevirt.io/api/snapshot/v1beta1"
 )
 
+// ErrVMSnapshotNotFound the snapshot does not exist or does not belong to the component
+var ErrVMSnapshotNotFound = errors.New("vm snapshot not found")
+
 type VMSnapshotRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -21,6 +30,38 @@ type VMSnapshotStatus struct {
 	SnapshotName string `json:"snapshot_name"`
 }
 
+// VMSnapshotInfo vm snapshot detail
+type VMSnapshotInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	VMName      string `json:"vm_name"`
+	Phase       string `json:"phase"`
+	ReadyToUse  bool   `json:"ready_to_use"`
+	CreateTime  string `json:"create_time"`
+	Error       string `json:"error,omitempty"`
+}
+
+// vm snapshot restore mode
+const (
+	// VMRestoreModeCurrent restore the snapshot to the vm it was taken from
+	VMRestoreModeCurrent = "current"
+	// VMRestoreModeNew restore the snapshot as the vm of another new component
+	VMRestoreModeNew = "new"
+)
+
+// VMSnapshotRestoreRequest vm snapshot restore request
+type VMSnapshotRestoreRequest struct {
+	Mode            string `json:"mode"`
+	TargetServiceID string `json:"target_service_id"`
+	EventID         string `json:"-"`
+}
+
+// VMSnapshotRestoreStatus vm snapshot restore result
+type VMSnapshotRestoreStatus struct {
+	RestoreName string `json:"restore_name"`
+	ServiceID   string `json:"service_id"`
+}
+
 func (s *ServiceAction) CreateVMSnapshot(serviceID string, req *VMSnapshotRequest) (*VMSnapshotStatus, error) {
 	if req == nil || strings.TrimSpace(req.Name) == "" {
 		return nil, fmt.Errorf("snapshot name is required")
@@ -69,3 +110,149 @@ func buildVMSnapshot(vm *kubevirtv1.VirtualMachine, snapshotName, description, s
 		},
 	}
 }
+
+// ListVMSnapshots list the snapshots of the component vm, newest first
+func (s *ServiceAction) ListVMSnapshots(serviceID string) ([]*VMSnapshotInfo, error) {
+	snapshots, err := s.listVMSnapshots(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[j].CreationTimestamp.Before(&snapshots[i].CreationTimestamp)
+	})
+	infos := make([]*VMSnapshotInfo, 0, len(snapshots))
+	for i := range snapshots {
+		infos = append(infos, vmSnapshotInfo(&snapshots[i]))
+	}
+	return infos, nil
+}
+
+// GetVMSnapshot get the status of a snapshot of the component vm
+func (s *ServiceAction) GetVMSnapshot(serviceID, snapshotName string) (*VMSnapshotInfo, error) {
+	snapshot, err := s.findVMSnapshot(serviceID, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	return vmSnapshotInfo(snapshot), nil
+}
+
+// DeleteVMSnapshot delete a snapshot of the component vm
+func (s *ServiceAction) DeleteVMSnapshot(serviceID, snapshotName string) error {
+	snapshot, err := s.findVMSnapshot(serviceID, snapshotName)
+	if err != nil {
+		return err
+	}
+	err = s.kubevirtClient.VirtualMachineSnapshot(snapshot.Namespace).Delete(context.Background(), snapshot.Name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// RestoreVMSnapshot restore a snapshot to the component vm or to the vm of a new component.
+// The restore runs in worker, which stops the vm, restores its disks and starts it again.
+func (s *ServiceAction) RestoreVMSnapshot(serviceID, snapshotName string, req *VMSnapshotRestoreRequest) (*VMSnapshotRestoreStatus, error) {
+	if req == nil {
+		req = &VMSnapshotRestoreRequest{}
+	}
+	targetServiceID := serviceID
+	switch req.Mode {
+	case "", VMRestoreModeCurrent:
+	case VMRestoreModeNew:
+		if req.TargetServiceID == "" || req.TargetServiceID == serviceID {
+			return nil, fmt.Errorf("target component is required when restoring as a new component")
+		}
+		targetServiceID = req.TargetServiceID
+	default:
+		return nil, fmt.Errorf("unsupported restore mode %s", req.Mode)
+	}
+	snapshot, err := s.findVMSnapshot(serviceID, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse {
+		return nil, fmt.Errorf("snapshot %s is not ready to use", snapshot.Name)
+	}
+	source, err := s.getDBManager().TenantServiceDao().GetServiceByID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	target := source
+	if targetServiceID != serviceID {
+		target, err = s.getDBManager().TenantServiceDao().GetServiceByID(targetServiceID)
+		if err != nil {
+			return nil, err
+		}
+		// restored disks can only be used in the namespace of the snapshot
+		if target.TenantID != source.TenantID {
+			return nil, fmt.Errorf("target component must be in the same team as the snapshot")
+		}
+		if !target.IsVM() {
+			return nil, fmt.Errorf("target component is not a vm")
+		}
+	}
+	restoreName := fmt.Sprintf("%s-restore-%s", snapshot.Name, util.NewUUID()[:8])
+	err = s.MQClient.SendBuilderTopic(gclient.TaskStruct{
+		TaskType: "vm_restore",
+		TaskBody: model.VMRestoreTaskBody{
+			TenantID:        target.TenantID,
+			ServiceID:       target.ServiceID,
+			SourceServiceID: serviceID,
+			SnapshotName:    snapshot.Name,
+			RestoreName:     restoreName,
+			EventID:         req.EventID,
+		},
+		Topic: gclient.WorkerTopic,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &VMSnapshotRestoreStatus{RestoreName: restoreName, ServiceID: target.ServiceID}, nil
+}
+
+func (s *ServiceAction) listVMSnapshots(serviceID string) ([]snapshotv1.VirtualMachineSnapshot, error) {
+	if s == nil || s.kubevirtClient == nil {
+		return nil, fmt.Errorf("kubevirt client is not initialized")
+	}
+	snapshots, err := s.kubevirtClient.VirtualMachineSnapshot("").List(context.Background(), metav1.ListOptions{
+		LabelSelector: "service_id=" + serviceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots.Items, nil
+}
+
+func (s *ServiceAction) findVMSnapshot(serviceID, snapshotName string) (*snapshotv1.VirtualMachineSnapshot, error) {
+	snapshotName = strings.TrimSpace(snapshotName)
+	if snapshotName == "" {
+		return nil, fmt.Errorf("snapshot name is required")
+	}
+	snapshots, err := s.listVMSnapshots(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if snapshots[i].Name == snapshotName {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, ErrVMSnapshotNotFound
+}
+
+func vmSnapshotInfo(snapshot *snapshotv1.VirtualMachineSnapshot) *VMSnapshotInfo {
+	info := &VMSnapshotInfo{
+		Name:        snapshot.Name,
+		Description: snapshot.Annotations["description"],
+		VMName:      snapshot.Spec.Source.Name,
+		CreateTime:  snapshot.CreationTimestamp.Format(time.RFC3339),
+	}
+	if status := snapshot.Status; status != nil {
+		info.Phase = string(status.Phase)
+		info.ReadyToUse = status.ReadyToUse != nil && *status.ReadyToUse
+		if status.Error != nil && status.Error.Message != nil {
+			info.Error = *status.Error.Message
+		}
+	}
+	return info
+}
@@ -2,11 +2,19 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/worker/discover/model"
 	kubecli "kubevirt.io/client-go/kubecli"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
@@ -16,6 +24,8 @@ import (
 
 type snapshotClientStub struct {
 	created *snapshotv1.VirtualMachineSnapshot
+	items   []snapshotv1.VirtualMachineSnapshot
+	deleted string
 }
 
 func (s *snapshotClientStub) Create(_ context.Context, snapshot *snapshotv1.VirtualMachineSnapshot, _ metav1.CreateOptions) (*snapshotv1.VirtualMachineSnapshot, error) {
@@ -31,7 +41,10 @@ func (s *snapshotClientStub) UpdateStatus(_ context.Context, snapshot *snapshotv
 	return snapshot, nil
 }
 
-func (s *snapshotClientStub) Delete(_ context.Context, _ string, _ metav1.DeleteOptions) error { return nil }
+func (s *snapshotClientStub) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
+	s.deleted = name
+	return nil
+}
 func (s *snapshotClientStub) DeleteCollection(_ context.Context, _ metav1.DeleteOptions, _ metav1.ListOptions) error {
 	return nil
 }
@@ -39,7 +52,7 @@ func (s *snapshotClientStub) Get(_ context.Context, _ string, _ metav1.GetOption
 	return nil, nil
 }
 func (s *snapshotClientStub) List(_ context.Context, _ metav1.ListOptions) (*snapshotv1.VirtualMachineSnapshotList, error) {
-	return &snapshotv1.VirtualMachineSnapshotList{}, nil
+	return &snapshotv1.VirtualMachineSnapshotList{Items: s.items}, nil
 }
 func (s *snapshotClientStub) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) { return nil, nil }
 func (s *snapshotClientStub) Patch(_ context.Context, _ string, _ types.PatchType, _ []byte, _ metav1.PatchOptions, _ ...string) (*snapshotv1.VirtualMachineSnapshot, error) {
@@ -109,3 +122,150 @@ func TestCreateVMSnapshot(t *testing.T) {
 		t.Fatalf("unexpected created snapshot source %#v", snapshotClient.created.Spec.Source)
 	}
 }
+
+type snapshotTestManager struct {
+	db.Manager
+	serviceDao dbdao.TenantServiceDao
+}
+
+func (m snapshotTestManager) TenantServiceDao() dbdao.TenantServiceDao {
+	return m.serviceDao
+}
+
+type snapshotTenantServiceDao struct {
+	dbdao.TenantServiceDao
+	services map[string]*dbmodel.TenantServices
+}
+
+func (d *snapshotTenantServiceDao) GetServiceByID(serviceID string) (*dbmodel.TenantServices, error) {
+	return d.services[serviceID], nil
+}
+
+func newTestVMSnapshot(name string, created time.Time, ready bool) snapshotv1.VirtualMachineSnapshot {
+	return snapshotv1.VirtualMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "demo-ns",
+			Labels:            map[string]string{"service_id": "service-1"},
+			Annotations:       map[string]string{"description": name + " desc"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: snapshotv1.VirtualMachineSnapshotSpec{
+			Source: corev1.TypedLocalObjectReference{Kind: "VirtualMachine", Name: "demo-vm"},
+		},
+		Status: &snapshotv1.VirtualMachineSnapshotStatus{
+			Phase:      snapshotv1.Succeeded,
+			ReadyToUse: &ready,
+		},
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.list
+func TestListVMSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	snapshotClient := &snapshotClientStub{items: []snapshotv1.VirtualMachineSnapshot{
+		newTestVMSnapshot("snap-old", now.Add(-time.Hour), true),
+		newTestVMSnapshot("snap-new", now, false),
+	}}
+	mockClient := kubecli.NewMockKubevirtClient(ctrl)
+	mockClient.EXPECT().VirtualMachineSnapshot("").Return(snapshotClient)
+
+	action := &ServiceAction{kubevirtClient: mockClient}
+	snapshots, err := action.ListVMSnapshots("service-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "snap-new" || snapshots[1].Name != "snap-old" {
+		t.Fatalf("expected snapshots newest first, got %#v", snapshots)
+	}
+	if snapshots[1].Description != "snap-old desc" || snapshots[1].VMName != "demo-vm" || !snapshots[1].ReadyToUse {
+		t.Fatalf("unexpected snapshot info %#v", snapshots[1])
+	}
+	if snapshots[0].ReadyToUse {
+		t.Fatalf("expected snap-new not ready, got %#v", snapshots[0])
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.delete
+func TestDeleteVMSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	listClient := &snapshotClientStub{items: []snapshotv1.VirtualMachineSnapshot{newTestVMSnapshot("snap-1", time.Now(), true)}}
+	deleteClient := &snapshotClientStub{}
+	mockClient := kubecli.NewMockKubevirtClient(ctrl)
+	mockClient.EXPECT().VirtualMachineSnapshot("").Return(listClient).Times(2)
+	mockClient.EXPECT().VirtualMachineSnapshot("demo-ns").Return(deleteClient)
+
+	action := &ServiceAction{kubevirtClient: mockClient}
+	if err := action.DeleteVMSnapshot("service-1", "snap-other"); !errors.Is(err, ErrVMSnapshotNotFound) {
+		t.Fatalf("expected snapshot not found, got %v", err)
+	}
+	if err := action.DeleteVMSnapshot("service-1", "snap-1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deleteClient.deleted != "snap-1" {
+		t.Fatalf("expected snap-1 deleted, got %q", deleteClient.deleted)
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestRestoreVMSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	snapshotClient := &snapshotClientStub{items: []snapshotv1.VirtualMachineSnapshot{
+		newTestVMSnapshot("snap-ready", time.Now(), true),
+		newTestVMSnapshot("snap-pending", time.Now(), false),
+	}}
+	mockClient := kubecli.NewMockKubevirtClient(ctrl)
+	mockClient.EXPECT().VirtualMachineSnapshot("").Return(snapshotClient).AnyTimes()
+	mq := &recordingMQClient{}
+	action := &ServiceAction{
+		MQClient:       mq,
+		kubevirtClient: mockClient,
+		dbmanager: snapshotTestManager{serviceDao: &snapshotTenantServiceDao{services: map[string]*dbmodel.TenantServices{
+			"service-1": {TenantID: "tenant-1", ServiceID: "service-1", ExtendMethod: "vm"},
+			"service-2": {TenantID: "tenant-1", ServiceID: "service-2", ExtendMethod: "vm"},
+			"service-3": {TenantID: "tenant-2", ServiceID: "service-3", ExtendMethod: "vm"},
+		}}},
+	}
+
+	if _, err := action.RestoreVMSnapshot("service-1", "snap-pending", &VMSnapshotRestoreRequest{}); err == nil {
+		t.Fatal("expected restoring a snapshot not ready to use to fail")
+	}
+	if _, err := action.RestoreVMSnapshot("service-1", "snap-ready", &VMSnapshotRestoreRequest{Mode: VMRestoreModeNew, TargetServiceID: "service-3"}); err == nil {
+		t.Fatal("expected restoring into a component of another team to fail")
+	}
+	if len(mq.tasks) != 0 {
+		t.Fatalf("expected no task for rejected restores, got %#v", mq.tasks)
+	}
+
+	status, err := action.RestoreVMSnapshot("service-1", "snap-ready", &VMSnapshotRestoreRequest{EventID: "event-1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if status.ServiceID != "service-1" || !strings.HasPrefix(status.RestoreName, "snap-ready-restore-") {
+		t.Fatalf("unexpected restore status %#v", status)
+	}
+	status, err = action.RestoreVMSnapshot("service-1", "snap-ready", &VMSnapshotRestoreRequest{Mode: VMRestoreModeNew, TargetServiceID: "service-2", EventID: "event-2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if status.ServiceID != "service-2" {
+		t.Fatalf("expected restore into service-2, got %#v", status)
+	}
+	if len(mq.tasks) != 2 {
+		t.Fatalf("expected two restore tasks, got %#v", mq.tasks)
+	}
+	body, ok := mq.tasks[1].TaskBody.(model.VMRestoreTaskBody)
+	if !ok || mq.tasks[1].TaskType != "vm_restore" {
+		t.Fatalf("unexpected restore task %#v", mq.tasks[1])
+	}
+	if body.ServiceID != "service-2" || body.SourceServiceID != "service-1" || body.SnapshotName != "snap-ready" || body.EventID != "event-2" {
+		t.Fatalf("unexpected restore task body %#v", body)
+	}
+}
@@ -18,53 +18,54 @@
 
 package model
 
-import dbmodel "github.com/goodrain/rainbond/db/model"
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util/cron"
+)
 
 // AutoscalerRuleReq -
 type AutoscalerRuleReq struct {
 	RuleID      string `json:"rule_id" validate:"rule_id|required"`
 	ServiceID   string
-	Enable      bool   `json:"enable" validate:"enable|required"`
-	XPAType     string `json:"xpa_type" validate:"xpa_type|required"`
-	MinReplicas int    `json:"min_replicas" validate:"min_replicas|required"`
-	MaxReplicas int    `json:"max_replicas" validate:"min_replicas|required"`
-	Metrics     []struct {
-		MetricsType       string `json:"metric_type"`
-		MetricsName       string `json:"metric_name"`
-		MetricTargetType  string `json:"metric_target_type"`
-		MetricTargetValue int    `json:"metric_target_value"`
-	} `json:"metrics"`
+	Enable      bool         `json:"enable" validate:"enable|required"`
+	XPAType     string       `json:"xpa_type" validate:"xpa_type|required"`
+	MinReplicas int          `json:"min_replicas" validate:"min_replicas|required"`
+	MaxReplicas int          `json:"max_replicas" validate:"min_replicas|required"`
+	Metrics     []RuleMetric `json:"metrics"`
+	// Behavior scale up and scale down policies of the HPA, nil means the default behavior
+	Behavior *dbmodel.AutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // AutoscalerRuleResp -
 type AutoscalerRuleResp struct {
-	RuleID      string `json:"rule_id"`
-	ServiceID   string `json:"service_id"`
-	Enable      bool   `json:"enable"`
-	XPAType     string `json:"xpa_type"`
-	MinReplicas int    `json:"min_replicas"`
-	MaxReplicas int    `json:"max_replicas"`
-	Metrics     []struct {
-		MetricsType       string `json:"metric_type"`
-		MetricsName       string `json:"metric_name"`
-		MetricTargetType  string `json:"metric_target_type"`
-		MetricTargetValue int    `json:"metric_target_value"`
-	} `json:"metrics"`
+	RuleID      string                      `json:"rule_id"`
+	ServiceID   string                      `json:"service_id"`
+	Enable      bool                        `json:"enable"`
+	XPAType     string                      `json:"xpa_type"`
+	MinReplicas int                         `json:"min_replicas"`
+	MaxReplicas int                         `json:"max_replicas"`
+	Metrics     []RuleMetric                `json:"metrics"`
+	Behavior    *dbmodel.AutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // AutoScalerRule -
 type AutoScalerRule struct {
-	RuleID      string       `json:"rule_id"`
-	Enable      bool         `json:"enable"`
-	XPAType     string       `json:"xpa_type"`
-	MinReplicas int          `json:"min_replicas"`
-	MaxReplicas int          `json:"max_replicas"`
-	RuleMetrics []RuleMetric `json:"metrics"`
+	RuleID      string                      `json:"rule_id"`
+	Enable      bool                        `json:"enable"`
+	XPAType     string                      `json:"xpa_type"`
+	MinReplicas int                         `json:"min_replicas"`
+	MaxReplicas int                         `json:"max_replicas"`
+	RuleMetrics []RuleMetric                `json:"metrics"`
+	Behavior    *dbmodel.AutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // DbModel return database model
 func (a AutoScalerRule) DbModel(componentID string) *dbmodel.TenantServiceAutoscalerRules {
-	return &dbmodel.TenantServiceAutoscalerRules{
+	rule := &dbmodel.TenantServiceAutoscalerRules{
 		RuleID:      a.RuleID,
 		ServiceID:   componentID,
 		MinReplicas: a.MinReplicas,
@@ -72,6 +73,9 @@ func (a AutoScalerRule) DbModel(componentID string) *dbmodel.TenantServiceAutosc
 		Enable:      a.Enable,
 		XPAType:     a.XPAType,
 	}
+	// the behavior only has plain fields, marshaling it never fails
+	_ = rule.SetBehavior(a.Behavior)
+	return rule
 }
 
 // RuleMetric -
@@ -80,6 +84,11 @@ type RuleMetric struct {
 	MetricsName       string `json:"metric_name"`
 	MetricTargetType  string `json:"metric_target_type"`
 	MetricTargetValue int    `json:"metric_target_value"`
+	// MetricQuery PromQL query of pods_metrics, object_metrics and external_metrics
+	MetricQuery      string `json:"metric_query,omitempty"`
+	ObjectKind       string `json:"object_kind,omitempty"`
+	ObjectName       string `json:"object_name,omitempty"`
+	ObjectAPIVersion string `json:"object_api_version,omitempty"`
 }
 
 // DbModel return database model
@@ -90,5 +99,167 @@ func (r RuleMetric) DbModel(ruleID string) *dbmodel.TenantServiceAutoscalerRuleM
 		MetricsName:       r.MetricsName,
 		MetricTargetType:  r.MetricTargetType,
 		MetricTargetValue: r.MetricTargetValue,
+		MetricQuery:       r.MetricQuery,
+		ObjectKind:        r.ObjectKind,
+		ObjectName:        r.ObjectName,
+		ObjectAPIVersion:  r.ObjectAPIVersion,
+	}
+}
+
+// customMetricName the name must be usable as a prometheus metric and a custom metrics api resource
+var customMetricName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Validate checks the metric definition against its metric type
+func (r RuleMetric) Validate() error {
+	switch r.MetricsType {
+	case dbmodel.ResourceMetricsType:
+		if r.MetricsName != "cpu" && r.MetricsName != "memory" {
+			return fmt.Errorf("unsupported resource metric name %q, must be cpu or memory", r.MetricsName)
+		}
+		if r.MetricTargetType != "utilization" && r.MetricTargetType != "average_value" {
+			return fmt.Errorf("unsupported target type %q of resource metric %s", r.MetricTargetType, r.MetricsName)
+		}
+		return nil
+	case dbmodel.PodsMetricsType, dbmodel.ObjectMetricsType, dbmodel.ExternalMetricsType:
+	default:
+		return fmt.Errorf("unsupported metric type %q", r.MetricsType)
+	}
+
+	if !customMetricName.MatchString(r.MetricsName) {
+		return fmt.Errorf("invalid metric name %q, must match %s", r.MetricsName, customMetricName.String())
+	}
+	if r.MetricQuery == "" {
+		return fmt.Errorf("metric %s: metric_query is required", r.MetricsName)
+	}
+	switch r.MetricTargetType {
+	case "average_value":
+	case "value":
+		// a pods metric is always averaged across the pods
+		if r.MetricsType == dbmodel.PodsMetricsType {
+			return fmt.Errorf("metric %s: pods metric only supports average_value target", r.MetricsName)
+		}
+	default:
+		return fmt.Errorf("metric %s: unsupported target type %q, must be value or average_value", r.MetricsName, r.MetricTargetType)
+	}
+	if r.MetricTargetValue <= 0 {
+		return fmt.Errorf("metric %s: metric_target_value must be greater than 0", r.MetricsName)
+	}
+	if r.MetricsType == dbmodel.ObjectMetricsType && (r.ObjectKind == "" || r.ObjectName == "") {
+		return fmt.Errorf("metric %s: object_kind and object_name are required for object metric", r.MetricsName)
+	}
+	return nil
+}
+
+// Validate checks the replicas range and every metric of the rule
+func (a *AutoscalerRuleReq) Validate() error {
+	if a.MinReplicas > a.MaxReplicas {
+		return fmt.Errorf("min_replicas %d is greater than max_replicas %d", a.MinReplicas, a.MaxReplicas)
+	}
+	seen := make(map[string]struct{}, len(a.Metrics))
+	for _, metric := range a.Metrics {
+		if err := metric.Validate(); err != nil {
+			return err
+		}
+		key := metric.MetricsType + "/" + metric.MetricsName
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate metric %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	if a.Behavior != nil {
+		if err := validateScalingRules("scale_up", a.Behavior.ScaleUp); err != nil {
+			return err
+		}
+		if err := validateScalingRules("scale_down", a.Behavior.ScaleDown); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateScalingRules checks the rules with the limits of the kubernetes HPA
+func validateScalingRules(direction string, rules *dbmodel.AutoscalerScalingRules) error {
+	if rules == nil {
+		return nil
+	}
+	if w := rules.StabilizationWindowSeconds; w != nil && (*w < 0 || *w > 3600) {
+		return fmt.Errorf("behavior %s: stabilization_window_seconds must be between 0 and 3600", direction)
+	}
+	switch rules.SelectPolicy {
+	case "", "Max", "Min", "Disabled":
+	default:
+		return fmt.Errorf("behavior %s: unsupported select_policy %q, must be Max, Min or Disabled", direction, rules.SelectPolicy)
+	}
+	for _, policy := range rules.Policies {
+		if policy.Type != "Pods" && policy.Type != "Percent" {
+			return fmt.Errorf("behavior %s: unsupported policy type %q, must be Pods or Percent", direction, policy.Type)
+		}
+		if policy.Value <= 0 {
+			return fmt.Errorf("behavior %s: policy value must be greater than 0", direction)
+		}
+		if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+			return fmt.Errorf("behavior %s: policy period_seconds must be between 1 and 1800", direction)
+		}
+	}
+	return nil
+}
+
+// ScheduledScalingReq cron based scaling rule of the component
+type ScheduledScalingReq struct {
+	ScheduleID  string `json:"schedule_id" validate:"schedule_id|required"`
+	ServiceID   string `json:"-"`
+	Name        string `json:"name"`
+	Cron        string `json:"cron" validate:"cron|required"`
+	Timezone    string `json:"timezone"`
+	MinReplicas int    `json:"min_replicas"`
+	MaxReplicas int    `json:"max_replicas" validate:"max_replicas|required"`
+	Enable      bool   `json:"enable"`
+}
+
+// Validate checks the cron expression, the timezone and the replicas range
+func (s *ScheduledScalingReq) Validate() error {
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return err
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+	}
+	if s.MinReplicas < 0 || s.MaxReplicas < 1 {
+		return fmt.Errorf("min_replicas must not be negative and max_replicas must be at least 1")
+	}
+	if s.MinReplicas > s.MaxReplicas {
+		return fmt.Errorf("min_replicas %d is greater than max_replicas %d", s.MinReplicas, s.MaxReplicas)
+	}
+	return nil
+}
+
+// DbModel return database model
+func (s *ScheduledScalingReq) DbModel() *dbmodel.TenantServiceScheduledScaling {
+	return &dbmodel.TenantServiceScheduledScaling{
+		ScheduleID:  s.ScheduleID,
+		ServiceID:   s.ServiceID,
+		Name:        s.Name,
+		Cron:        s.Cron,
+		Timezone:    s.Timezone,
+		MinReplicas: s.MinReplicas,
+		MaxReplicas: s.MaxReplicas,
+		Enable:      s.Enable,
+	}
+}
+
+// IdlePolicyReq scale-to-zero policy of the component, the component is scaled to zero
+// after idle_minutes without http traffic and woken up by the next request.
+type IdlePolicyReq struct {
+	Enable      bool `json:"enable"`
+	IdleMinutes int  `json:"idle_minutes"`
+}
+
+// Validate -
+func (i *IdlePolicyReq) Validate() error {
+	if i.Enable && i.IdleMinutes < 1 {
+		return fmt.Errorf("idle_minutes must be at least 1")
 	}
+	return nil
 }
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+)
+
+// capability_id: rainbond.component.autoscaler.validate-custom-metrics
+func TestAutoscalerRuleReqValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []RuleMetric
+		wantErr string
+	}{
+		{
+			name: "resource and custom metrics",
+			metrics: []RuleMetric{
+				{MetricsType: "resource_metrics", MetricsName: "cpu", MetricTargetType: "utilization", MetricTargetValue: 60},
+				{MetricsType: "pods_metrics", MetricsName: "http_requests", MetricTargetType: "average_value", MetricTargetValue: 10, MetricQuery: "sum(rate(http_requests_total[1m]))"},
+				{MetricsType: "object_metrics", MetricsName: "ingress_hits", MetricTargetType: "value", MetricTargetValue: 100, MetricQuery: "sum(hits)", ObjectKind: "Ingress", ObjectName: "web"},
+				{MetricsType: "external_metrics", MetricsName: "queue_depth", MetricTargetType: "value", MetricTargetValue: 30, MetricQuery: "sum(queue_messages_ready)"},
+			},
+		},
+		{
+			name:    "unknown metric type",
+			metrics: []RuleMetric{{MetricsType: "foo_metrics", MetricsName: "cpu"}},
+			wantErr: "unsupported metric type",
+		},
+		{
+			name:    "unknown resource name",
+			metrics: []RuleMetric{{MetricsType: "resource_metrics", MetricsName: "disk", MetricTargetType: "utilization"}},
+			wantErr: "unsupported resource metric name",
+		},
+		{
+			name:    "missing query",
+			metrics: []RuleMetric{{MetricsType: "external_metrics", MetricsName: "queue_depth", MetricTargetType: "value", MetricTargetValue: 1}},
+			wantErr: "metric_query is required",
+		},
+		{
+			name:    "invalid metric name",
+			metrics: []RuleMetric{{MetricsType: "external_metrics", MetricsName: "queue-depth", MetricTargetType: "value", MetricTargetValue: 1, MetricQuery: "up"}},
+			wantErr: "invalid metric name",
+		},
+		{
+			name:    "pods metric with value target",
+			metrics: []RuleMetric{{MetricsType: "pods_metrics", MetricsName: "rps", MetricTargetType: "value", MetricTargetValue: 1, MetricQuery: "up"}},
+			wantErr: "only supports average_value",
+		},
+		{
+			name:    "utilization target on custom metric",
+			metrics: []RuleMetric{{MetricsType: "external_metrics", MetricsName: "rps", MetricTargetType: "utilization", MetricTargetValue: 1, MetricQuery: "up"}},
+			wantErr: "unsupported target type",
+		},
+		{
+			name:    "zero target value",
+			metrics: []RuleMetric{{MetricsType: "external_metrics", MetricsName: "rps", MetricTargetType: "value", MetricQuery: "up"}},
+			wantErr: "must be greater than 0",
+		},
+		{
+			name:    "object metric without object",
+			metrics: []RuleMetric{{MetricsType: "object_metrics", MetricsName: "hits", MetricTargetType: "value", MetricTargetValue: 1, MetricQuery: "up"}},
+			wantErr: "object_kind and object_name are required",
+		},
+		{
+			name: "duplicate metric",
+			metrics: []RuleMetric{
+				{MetricsType: "resource_metrics", MetricsName: "cpu", MetricTargetType: "utilization", MetricTargetValue: 60},
+				{MetricsType: "resource_metrics", MetricsName: "cpu", MetricTargetType: "utilization", MetricTargetValue: 80},
+			},
+			wantErr: "duplicate metric",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &AutoscalerRuleReq{MinReplicas: 1, MaxReplicas: 5, Metrics: tc.metrics}
+			err := req.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	req := &AutoscalerRuleReq{MinReplicas: 5, MaxReplicas: 1}
+	if err := req.Validate(); err == nil {
+		t.Fatalf("expected min_replicas greater than max_replicas to be rejected")
+	}
+}
+
+// capability_id: rainbond.component.scheduled-scaling.validate
+func TestScheduledScalingReqValidate(t *testing.T) {
+	valid := ScheduledScalingReq{ScheduleID: "s1", Cron: "0 9 * * 1-5", Timezone: "Asia/Shanghai", MinReplicas: 4, MaxReplicas: 10}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nightly := ScheduledScalingReq{ScheduleID: "s2", Cron: "0 22 * * *", MinReplicas: 0, MaxReplicas: 1}
+	if err := nightly.Validate(); err != nil {
+		t.Fatalf("scale to zero must be allowed: %v", err)
+	}
+
+	for name, req := range map[string]ScheduledScalingReq{
+		"bad cron":     {Cron: "0 25 * * *", MaxReplicas: 1},
+		"bad timezone": {Cron: "0 9 * * *", Timezone: "Mars/Olympus", MaxReplicas: 1},
+		"min over max": {Cron: "0 9 * * *", MinReplicas: 3, MaxReplicas: 2},
+		"zero max":     {Cron: "0 9 * * *"},
+		"negative min": {Cron: "0 9 * * *", MinReplicas: -1, MaxReplicas: 2},
+	} {
+		if err := req.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+// capability_id: rainbond.component.autoscaler.validate-behavior
+func TestAutoscalerRuleReqValidateBehavior(t *testing.T) {
+	window := int32(120)
+	tooLong := int32(7200)
+	tests := []struct {
+		name     string
+		behavior *dbmodel.AutoscalerBehavior
+		wantErr  string
+	}{
+		{
+			name: "valid behavior",
+			behavior: &dbmodel.AutoscalerBehavior{
+				ScaleUp: &dbmodel.AutoscalerScalingRules{
+					SelectPolicy: "Max",
+					Policies: []dbmodel.AutoscalerScalingPolicy{
+						{Type: "Pods", Value: 4, PeriodSeconds: 60},
+						{Type: "Percent", Value: 100, PeriodSeconds: 15},
+					},
+				},
+				ScaleDown: &dbmodel.AutoscalerScalingRules{StabilizationWindowSeconds: &window},
+			},
+		},
+		{
+			name:     "stabilization window too long",
+			behavior: &dbmodel.AutoscalerBehavior{ScaleDown: &dbmodel.AutoscalerScalingRules{StabilizationWindowSeconds: &tooLong}},
+			wantErr:  "stabilization_window_seconds",
+		},
+		{
+			name:     "unknown select policy",
+			behavior: &dbmodel.AutoscalerBehavior{ScaleUp: &dbmodel.AutoscalerScalingRules{SelectPolicy: "Average"}},
+			wantErr:  "unsupported select_policy",
+		},
+		{
+			name: "unknown policy type",
+			behavior: &dbmodel.AutoscalerBehavior{ScaleUp: &dbmodel.AutoscalerScalingRules{
+				Policies: []dbmodel.AutoscalerScalingPolicy{{Type: "Nodes", Value: 1, PeriodSeconds: 60}},
+			}},
+			wantErr: "unsupported policy type",
+		},
+		{
+			name: "policy period out of range",
+			behavior: &dbmodel.AutoscalerBehavior{ScaleDown: &dbmodel.AutoscalerScalingRules{
+				Policies: []dbmodel.AutoscalerScalingPolicy{{Type: "Pods", Value: 1, PeriodSeconds: 3600}},
+			}},
+			wantErr: "period_seconds",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &AutoscalerRuleReq{MinReplicas: 1, MaxReplicas: 5, Behavior: tc.behavior}
+			err := req.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
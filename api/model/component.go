@@ -120,6 +120,7 @@ func (t *TenantComponentRelation) DbModel(tenantID, componentID string) *dbmodel
 type ComponentConfigFile struct {
 	VolumeName  string `json:"volume_name"`
 	FileContent string `json:"file_content"`
+	Secret      bool   `json:"secret"`
 }
 
 // DbModel return database model
@@ -128,6 +129,7 @@ func (c *ComponentConfigFile) DbModel(componentID string) *dbmodel.TenantService
 		ServiceID:   componentID,
 		VolumeName:  c.VolumeName,
 		FileContent: c.FileContent,
+		Secret:      c.Secret,
 	}
 }
 
@@ -0,0 +1,22 @@
+package model
+
+// DeadLetter 进入死信主题的任务消息
+type DeadLetter struct {
+	MessageID  string `json:"message_id"`
+	Topic      string `json:"topic"`
+	TaskID     string `json:"task_id"`
+	TaskType   string `json:"task_type"`
+	TaskBody   string `json:"task_body"`
+	CreateTime string `json:"create_time"`
+	User       string `json:"user"`
+	Arch       string `json:"arch,omitempty"`
+	Attempts   int32  `json:"attempts"`
+	LastError  string `json:"last_error"`
+	DeadTime   string `json:"dead_time"`
+}
+
+// DeadLetterReq 重放或删除死信消息的请求，操作主题的全部死信消息时需要显式指定 all
+type DeadLetterReq struct {
+	MessageIDs []string `json:"message_ids"`
+	All        bool     `json:"all"`
+}
@@ -427,6 +427,8 @@ type TenantServiceVolumeStruct struct {
 	IsReadOnly bool `json:"is_read_only"`
 
 	FileContent string `json:"file_content"`
+	// Secret 配置文件内容是否为敏感信息，为 true 时以组件独立的 Secret 挂载
+	Secret bool `json:"secret"`
 	// VolumeCapacity 存储大小
 	VolumeCapacity int64 `json:"volume_capacity"`
 	// AccessMode 读写模式（Important! A volume can only be mounted using one access mode at a time, even if it supports many. For example, a GCEPersistentDisk can be mounted as ReadWriteOnce by a single node or ReadOnlyMany by many nodes, but not at the same time. #https://kubernetes.io/docs/concepts/storage/persistent-volumes/#access-modes）
@@ -789,6 +791,14 @@ type UpdateTenantStruct struct {
 		// in : body
 		// required: false
 		LimitMemory int `json:"limit_memory" validate:"limit_memory"`
+		// 同时执行的构建任务上限，0 表示不限制，不传时保持不变
+		// in : body
+		// required: false
+		BuildQuota *int `json:"build_quota" validate:"build_quota"`
+		// 构建任务在租户之间公平调度的权重，不传时保持不变
+		// in : body
+		// required: false
+		BuildWeight *int `json:"build_weight" validate:"build_weight"`
 	}
 }
 
@@ -1385,6 +1395,22 @@ type AddProbeStruct struct {
 		// in: body
 		// required: false
 		SuccessThreshold int `json:"success_threshold"`
+		// gRPC 健康检查的服务名, scheme 为 grpc 时有效
+		// in: body
+		// required: false
+		GRPCService string `json:"grpc_service"`
+		// gRPC 健康检查是否使用 TLS
+		// in: body
+		// required: false
+		GRPCTLS bool `json:"grpc_tls"`
+		// gRPC 健康检查是否跳过证书校验
+		// in: body
+		// required: false
+		GRPCInsecureSkipVerify bool `json:"grpc_insecure_skip_verify"`
+		// gRPC 健康检查校验证书使用的服务器名称
+		// in: body
+		// required: false
+		GRPCServerName string `json:"grpc_server_name"`
 	}
 }
 
@@ -1626,26 +1652,38 @@ type ServiceProbe struct {
 	//标志为成功的检测次数
 	SuccessThreshold int    `gorm:"column:success_threshold;size:2;default:1" json:"success_threshold" validate:"success_threshold"`
 	FailureAction    string `json:"failure_action" validate:"failure_action"`
+	//gRPC 健康检查的服务名，scheme 为 grpc 时有效
+	GRPCService string `json:"grpc_service" validate:"grpc_service"`
+	//gRPC 健康检查是否使用 TLS
+	GRPCTLS bool `json:"grpc_tls" validate:"grpc_tls"`
+	//gRPC 健康检查是否跳过证书校验
+	GRPCInsecureSkipVerify bool `json:"grpc_insecure_skip_verify" validate:"grpc_insecure_skip_verify"`
+	//gRPC 健康检查校验证书使用的服务器名称
+	GRPCServerName string `json:"grpc_server_name" validate:"grpc_server_name"`
 }
 
 // DbModel return database model
 func (p *ServiceProbe) DbModel(componentID string) *dbmodel.TenantServiceProbe {
 	return &dbmodel.TenantServiceProbe{
-		ServiceID:          componentID,
-		Cmd:                p.Cmd,
-		FailureThreshold:   p.FailureThreshold,
-		HTTPHeader:         p.HTTPHeader,
-		InitialDelaySecond: p.InitialDelaySecond,
-		IsUsed:             &p.IsUsed,
-		Mode:               p.Mode,
-		Path:               p.Path,
-		PeriodSecond:       p.PeriodSecond,
-		Port:               p.Port,
-		ProbeID:            p.ProbeID,
-		Scheme:             p.Scheme,
-		SuccessThreshold:   p.SuccessThreshold,
-		TimeoutSecond:      p.TimeoutSecond,
-		FailureAction:      p.FailureAction,
+		ServiceID:              componentID,
+		Cmd:                    p.Cmd,
+		FailureThreshold:       p.FailureThreshold,
+		HTTPHeader:             p.HTTPHeader,
+		InitialDelaySecond:     p.InitialDelaySecond,
+		IsUsed:                 &p.IsUsed,
+		Mode:                   p.Mode,
+		Path:                   p.Path,
+		PeriodSecond:           p.PeriodSecond,
+		Port:                   p.Port,
+		ProbeID:                p.ProbeID,
+		Scheme:                 p.Scheme,
+		SuccessThreshold:       p.SuccessThreshold,
+		TimeoutSecond:          p.TimeoutSecond,
+		FailureAction:          p.FailureAction,
+		GRPCService:            p.GRPCService,
+		GRPCTLS:                p.GRPCTLS,
+		GRPCInsecureSkipVerify: p.GRPCInsecureSkipVerify,
+		GRPCServerName:         p.GRPCServerName,
 	}
 }
 
@@ -1772,6 +1810,30 @@ type BuildCodeInfo struct {
 	// in: body
 	// required: false
 	DockerfilePath string `json:"dockerfile_path"`
+	// 组件构建使用的仓库子目录，用于单仓库多组件
+	// in: body
+	// required: false
+	SubDir string `json:"sub_dir"`
+	// 触发构建的路径规则，相对仓库根目录，支持 * 和 ** 通配
+	// in: body
+	// required: false
+	IncludePaths []string `json:"include_paths"`
+	// 不触发构建的路径规则
+	// in: body
+	// required: false
+	ExcludePaths []string `json:"exclude_paths"`
+	// webhook 推送前的提交，设置后仅在组件路径发生变更时构建
+	// in: body
+	// required: false
+	CommitBefore string `json:"commit_before"`
+	// webhook 推送后的提交
+	// in: body
+	// required: false
+	CommitAfter string `json:"commit_after"`
+	// 源码归档的校验和，如 sha256:<hex>，server_type 为 archive 时使用
+	// in: body
+	// required: false
+	Checksum string `json:"checksum"`
 	// 构建类型: cnb 或 slug
 	// in: body
 	// required: false
@@ -1859,6 +1921,10 @@ type ComponentBuildReq struct {
 	TenantName string `json:"-"`
 	//InRolling
 	InRolling bool `json:"in_rolling"`
+	// 构建任务的优先级 high、normal 或 low，回滚与紧急修复可以使用 high 优先执行
+	// in: body
+	// required: false
+	Priority string `json:"priority" validate:"priority|in:high,normal,low"`
 }
 
 // GetEventID -
@@ -21,12 +21,20 @@ package model
 // AddEndpiontsReq is one of the Endpoints in the request to add the endpints.
 type AddEndpiontsReq struct {
 	Address string `json:"address" validate:"address|required"`
+	// The relative traffic weight of the endpoint, nil means the default weight and 0 means no traffic.
+	Weight *int `json:"weight"`
+	// The zone the endpoint is located in.
+	Zone string `json:"zone" validate:"max:64"`
 }
 
 // UpdEndpiontsReq is one of the Endpoints in the request to update the endpints.
 type UpdEndpiontsReq struct {
 	EpID    string `json:"ep_id" validate:"required|len:32"`
 	Address string `json:"address"`
+	// nil means keep the current weight.
+	Weight *int `json:"weight"`
+	// nil means keep the current zone.
+	Zone *string `json:"zone"`
 }
 
 // DelEndpiontsReq is one of the Endpoints in the request to update the endpints.
@@ -41,6 +49,8 @@ type ThirdEndpoint struct {
 	Address  string `json:"address"`
 	Status   string `json:"status"`
 	IsStatic bool   `json:"is_static"`
+	Weight   *int   `json:"weight,omitempty"`
+	Zone     string `json:"zone,omitempty"`
 }
 
 // ThirdEndpoints -
@@ -70,4 +80,12 @@ type ThridPartyServiceProbe struct {
 	TimeInterval int    `json:"time_interval"`
 	MaxErrorNum  int    `json:"max_error_num"`
 	Action       string `json:"action"`
+	// GRPCService the service name of the grpc health check, only used when the scheme is grpc
+	GRPCService string `json:"grpc_service,omitempty"`
+	// GRPCTLS connect to the endpoints with TLS when checking grpc health
+	GRPCTLS bool `json:"grpc_tls,omitempty"`
+	// GRPCInsecureSkipVerify skip verifying the certificate of the endpoints
+	GRPCInsecureSkipVerify bool `json:"grpc_insecure_skip_verify,omitempty"`
+	// GRPCServerName the server name used to verify the certificate of the endpoints
+	GRPCServerName string `json:"grpc_server_name,omitempty"`
 }
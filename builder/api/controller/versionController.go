@@ -118,8 +118,28 @@ func DeleteVersionByEventID(w http.ResponseWriter, r *http.Request) {
 		httputil.ReturnError(r, w, 404, err.Error())
 		return
 	}
+	if err := db.GetManager().VersionSBOMDao().DeleteSBOMByEventID(eventID); err != nil {
+		logrus.Errorf("error delete sbom of version %s, details %s", eventID, err.Error())
+	}
 	httputil.ReturnSuccess(r, w, nil)
 }
+
+// GetVersionSBOMByEventID 获取构建版本镜像的软件物料清单
+func GetVersionSBOMByEventID(w http.ResponseWriter, r *http.Request) {
+	eventID := strings.TrimSpace(chi.URLParam(r, "eventID"))
+
+	sbom, err := db.GetManager().VersionSBOMDao().GetSBOMByEventID(eventID)
+	if err != nil {
+		httputil.ReturnError(r, w, 404, err.Error())
+		return
+	}
+	if r.URL.Query().Get("raw") == "true" {
+		w.Header().Set("Content-Type", "application/vnd.cyclonedx+json")
+		w.Write([]byte(sbom.Document))
+		return
+	}
+	httputil.ReturnSuccess(r, w, sbom)
+}
 func UpdateDeliveredPath(w http.ResponseWriter, r *http.Request) {
 	in, err := ioutil.ReadAll(r.Body)
 	if err != nil {
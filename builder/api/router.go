@@ -58,6 +58,7 @@ func APIServer() *chi.Mux {
 			r.Post("/", controller.UpdateDeliveredPath)
 			r.Get("/event/{eventID}", controller.GetVersionByEventID)
 			r.Post("/event/{eventID}", controller.UpdateVersionByEventID)
+			r.Get("/event/{eventID}/sbom", controller.GetVersionSBOMByEventID)
 			r.Get("/service/{serviceID}", controller.GetVersionByServiceID)
 			r.Delete("/service/{eventID}", controller.DeleteVersionByEventID)
 		})
@@ -56,6 +56,13 @@ func init() {
 
 var buildcreaters map[code.Lang]CreaterBuild
 
+// cnbOnlyLangs 没有 slug 构建支持，只能通过 CNB 构建的语言
+var cnbOnlyLangs = map[code.Lang]bool{
+	code.Rust:   true,
+	code.Scala:  true,
+	code.Elixir: true,
+}
+
 // cnbCreater is registered by the cnb subpackage via init()
 var cnbCreater CreaterBuild
 
@@ -129,7 +136,30 @@ type Request struct {
 	HostAlias        []HostAlias
 	Ctx              context.Context
 	Arch             string
-	BRVersion        string
+	// Platforms 一次构建的多个目标平台，如 linux/amd64、linux/arm64，为空时只构建 Arch
+	Platforms []string
+	BRVersion string
+}
+
+// MultiPlatform reports whether the request builds one image for several platforms
+func (re *Request) MultiPlatform() bool {
+	return len(re.Platforms) > 1
+}
+
+// PlatformBuildKitArgs returns the buildctl args building the image for every platform of the request
+func (re *Request) PlatformBuildKitArgs() []string {
+	return sources.BuildKitPlatformArgs(re.Platforms)
+}
+
+// RejectMultiPlatform 产物依赖构建节点架构的构建方式不支持多平台构建，请求多个平台时返回错误，
+// 避免静默地只构建当前架构的镜像
+func (re *Request) RejectMultiPlatform(buildType string) error {
+	if !re.MultiPlatform() {
+		return nil
+	}
+	err := fmt.Errorf("%s build does not support multi-platform images %s, build the component with a Dockerfile or build for a single platform", buildType, strings.Join(re.Platforms, ","))
+	re.Logger.Error(err.Error(), map[string]string{"step": "builder-exector", "status": "failure"})
+	return err
 }
 
 // CNBVersionPolicy is the normalized policy snapshot sent by console for CNB builds.
@@ -174,6 +204,12 @@ func GetBuild(lang code.Lang) (Build, error) {
 // For Node.js with CNB build type, returns CNB builder
 // For other cases, falls back to the default builder for the language
 func GetBuildByType(lang code.Lang, buildType string) (Build, error) {
+	if cnbOnlyLangs[lang] {
+		if cnbCreater == nil {
+			return nil, fmt.Errorf("CNB builder not registered")
+		}
+		return cnbCreater()
+	}
 	switch strings.ToLower(strings.TrimSpace(buildType)) {
 	case "cnb":
 		if cnbCreater == nil {
@@ -0,0 +1,318 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/sources/registry"
+	"github.com/sirupsen/logrus"
+)
+
+// BuildCacheKind 构建缓存类型
+type BuildCacheKind string
+
+const (
+	// CNBBuildCache CNB lifecycle 的 layer cache
+	CNBBuildCache BuildCacheKind = "cnb-cache"
+	// BuildKitBuildCache BuildKit 的 registry cache
+	BuildKitBuildCache BuildCacheKind = "buildkit-cache"
+)
+
+// noLockFileCacheKey 源码中没有依赖锁文件时使用的缓存 key
+const noLockFileCacheKey = "nolock"
+
+// latestCacheKey 最近一次成功构建的缓存 key
+const latestCacheKey = "latest"
+
+// defaultBuildCacheKeep 每个组件每种缓存默认保留的缓存数量，可通过 BUILD_CACHE_KEEP 调整
+const defaultBuildCacheKeep = 5
+
+// cacheRegistryRetryInterval 镜像仓库不可用时重新创建客户端的间隔
+const cacheRegistryRetryInterval = time.Minute
+
+// cacheLockFiles 参与构建缓存 key 计算的依赖锁文件，依赖不变时缓存可以在构建节点间共享
+var cacheLockFiles = []string{
+	"package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+	"pom.xml", "build.gradle", "build.gradle.kts", "build.sbt",
+	"go.sum", "Cargo.lock", "mix.lock",
+	"requirements.txt", "Pipfile.lock", "poetry.lock", "uv.lock",
+	"composer.lock", "Gemfile.lock", "packages.lock.json",
+}
+
+// CacheRegistry 镜像仓库中构建缓存制品的操作
+type CacheRegistry interface {
+	HasManifest(repository, reference string) (bool, error)
+	TagManifest(repository, source, target string) error
+	Tags(repository string) ([]string, error)
+	// DeleteTag 删除标签，标签指向的制品同时被 keep 中的标签引用时不删除
+	DeleteTag(repository, tag string, keep []string) error
+}
+
+// cacheRegistry 集群镜像仓库客户端，删除标签时避免误删仍被引用的制品
+type cacheRegistry struct {
+	*registry.Registry
+}
+
+func (c *cacheRegistry) DeleteTag(repository, tag string, keep []string) error {
+	dig, err := c.ManifestDigestV2(repository, tag)
+	if err != nil {
+		return err
+	}
+	for _, k := range keep {
+		if d, err := c.ManifestDigestV2(repository, k); err == nil && d == dig {
+			return nil
+		}
+	}
+	return c.DeleteManifest(repository, dig)
+}
+
+var (
+	defaultCacheRegistryLock    sync.Mutex
+	defaultCacheRegistry        CacheRegistry
+	defaultCacheRegistryFailure time.Time
+)
+
+// DefaultCacheRegistry 返回集群镜像仓库的共享客户端，客户端只在首次使用时创建（会 ping 镜像仓库），
+// 创建失败后在 cacheRegistryRetryInterval 内不再重试，返回 nil 表示构建缓存不可用
+func DefaultCacheRegistry() CacheRegistry {
+	defaultCacheRegistryLock.Lock()
+	defer defaultCacheRegistryLock.Unlock()
+	if defaultCacheRegistry != nil {
+		return defaultCacheRegistry
+	}
+	if time.Since(defaultCacheRegistryFailure) < cacheRegistryRetryInterval {
+		return nil
+	}
+	domain := strings.SplitN(builder.REGISTRYDOMAIN, "/", 2)[0]
+	reg, err := registry.NewInsecure(domain, builder.REGISTRYUSER, builder.REGISTRYPASS)
+	if err != nil {
+		logrus.Warningf("create build cache registry client failure: %s", err.Error())
+		defaultCacheRegistryFailure = time.Now()
+		return nil
+	}
+	defaultCacheRegistry = &cacheRegistry{Registry: reg}
+	return defaultCacheRegistry
+}
+
+// buildCacheKeep 每个组件每种缓存保留的缓存数量
+func buildCacheKeep() int {
+	if keep, err := strconv.Atoi(os.Getenv("BUILD_CACHE_KEEP")); err == nil && keep > 0 {
+		return keep
+	}
+	return defaultBuildCacheKeep
+}
+
+// recentCacheKeys 记录本节点最近使用的缓存 key，按仓库和缓存类型区分，越靠前越新。
+// 镜像仓库不记录标签的使用时间，清理缓存时以此判断新旧，未记录的缓存视为最旧。
+var recentCacheKeys = struct {
+	sync.Mutex
+	keys map[string][]string
+}{keys: make(map[string][]string)}
+
+func touchCacheKey(repo string, kind BuildCacheKind, key string) []string {
+	recentCacheKeys.Lock()
+	defer recentCacheKeys.Unlock()
+	id := repo + ":" + string(kind)
+	keys := []string{key}
+	for _, k := range recentCacheKeys.keys[id] {
+		if k != key && len(keys) < 4*buildCacheKeep() {
+			keys = append(keys, k)
+		}
+	}
+	recentCacheKeys.keys[id] = keys
+	return append([]string(nil), keys...)
+}
+
+// BuildCacheKey 根据依赖锁文件计算构建缓存的 key
+func BuildCacheKey(sourceDir string) string {
+	hash := sha256.New()
+	found := false
+	for _, name := range cacheLockFiles {
+		body, err := os.ReadFile(filepath.Join(sourceDir, name))
+		if err != nil {
+			continue
+		}
+		found = true
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(body))
+		hash.Write(body)
+	}
+	if !found {
+		return noLockFileCacheKey
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// BuildCacheRef 构建缓存制品的镜像引用，与组件镜像位于同一仓库
+func BuildCacheRef(imageName string, kind BuildCacheKind, key string) string {
+	repo, _ := splitImageTag(imageName)
+	return fmt.Sprintf("%s:%s-%s", repo, kind, key)
+}
+
+// splitImageTag 拆分镜像名称的仓库和标签，兼容带端口的仓库地址
+func splitImageTag(imageName string) (string, string) {
+	i := strings.LastIndex(imageName, ":")
+	if i == -1 || strings.Contains(imageName[i:], "/") {
+		return imageName, ""
+	}
+	return imageName[:i], imageName[i+1:]
+}
+
+// BuildCache 以 OCI 制品的形式保存在集群镜像仓库中的构建缓存，按组件和依赖锁文件哈希区分。
+// 缓存的镜像引用为 <组件镜像>:<kind>-<key>，最近一次成功构建的缓存同时标记为 <kind>-latest，
+// 锁文件变化后可以从最近的缓存预热。每种缓存只保留最近使用的 BUILD_CACHE_KEEP 个 key。
+type BuildCache struct {
+	Kind      BuildCacheKind
+	Key       string
+	Ref       string
+	LatestRef string
+	re        *Request
+	registry  CacheRegistry
+}
+
+// NewBuildCache 创建组件镜像的构建缓存，reg 为 nil 时缓存不可用
+func NewBuildCache(re *Request, kind BuildCacheKind, imageName string, reg CacheRegistry) *BuildCache {
+	key := BuildCacheKey(re.SourceDir)
+	return &BuildCache{
+		Kind:      kind,
+		Key:       key,
+		Ref:       BuildCacheRef(imageName, kind, key),
+		LatestRef: BuildCacheRef(imageName, kind, latestCacheKey),
+		re:        re,
+		registry:  reg,
+	}
+}
+
+// Import 检查缓存是否命中并在事件日志中记录命中情况。
+// seed 为 true 时，未命中的缓存从最近一次的缓存预热，用于只能使用单个缓存镜像的构建（如 CNB）。
+func (c *BuildCache) Import(seed bool) (hit bool) {
+	if c.registry == nil {
+		c.log("miss, registry is unavailable")
+		return false
+	}
+	repo, tag := c.repository(c.Ref)
+	exists, err := c.registry.HasManifest(repo, tag)
+	if err != nil {
+		logrus.Warningf("check build cache %s failure: %s", c.Ref, err.Error())
+	}
+	if exists {
+		c.log("hit")
+		return true
+	}
+	_, latestTag := c.repository(c.LatestRef)
+	latest, err := c.registry.HasManifest(repo, latestTag)
+	if err != nil {
+		logrus.Warningf("check build cache %s failure: %s", c.LatestRef, err.Error())
+	}
+	if !latest {
+		c.log("miss, no previous cache")
+		return false
+	}
+	if seed {
+		if err := c.registry.TagManifest(repo, latestTag, tag); err != nil {
+			logrus.Warningf("restore build cache %s from %s failure: %s", c.Ref, c.LatestRef, err.Error())
+			c.log("miss, restore from previous cache failure")
+			return false
+		}
+	}
+	c.log("miss, restored from previous cache")
+	return false
+}
+
+// Export 在构建成功后将本次缓存标记为最近一次的缓存，其他构建节点的下一次构建可以直接使用
+func (c *BuildCache) Export() {
+	if c.registry == nil {
+		return
+	}
+	repo, tag := c.repository(c.Ref)
+	_, latestTag := c.repository(c.LatestRef)
+	if err := c.registry.TagManifest(repo, tag, latestTag); err != nil {
+		logrus.Warningf("export build cache %s failure: %s", c.Ref, err.Error())
+		c.re.Logger.Info(fmt.Sprintf("Build cache %s export failure, key %s", c.Kind, c.Key), map[string]string{"step": "build-cache"})
+		return
+	}
+	c.re.Logger.Info(fmt.Sprintf("Build cache %s exported, key %s", c.Kind, c.Key), map[string]string{"step": "build-cache"})
+	c.prune(repo, buildCacheKeep())
+}
+
+// prune 删除多余的缓存标签，只保留最近使用的 keep 个 key 和 <kind>-latest
+func (c *BuildCache) prune(repo string, keep int) {
+	tags, err := c.registry.Tags(repo)
+	if err != nil {
+		logrus.Warningf("list build cache tags of %s failure: %s", repo, err.Error())
+		return
+	}
+	prefix := string(c.Kind) + "-"
+	latestTag := prefix + latestCacheKey
+	existing := make(map[string]bool)
+	var unknown []string
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) || tag == latestTag {
+			continue
+		}
+		existing[tag] = true
+	}
+	var ordered []string
+	for _, key := range touchCacheKey(repo, c.Kind, c.Key) {
+		if existing[prefix+key] {
+			ordered = append(ordered, prefix+key)
+			delete(existing, prefix+key)
+		}
+	}
+	for tag := range existing {
+		unknown = append(unknown, tag)
+	}
+	sort.Strings(unknown)
+	ordered = append(ordered, unknown...)
+	if len(ordered) <= keep {
+		return
+	}
+	kept := append([]string{latestTag}, ordered[:keep]...)
+	for _, tag := range ordered[keep:] {
+		if err := c.registry.DeleteTag(repo, tag, kept); err != nil {
+			logrus.Warningf("prune build cache %s:%s failure: %s", repo, tag, err.Error())
+			continue
+		}
+		logrus.Infof("pruned build cache %s:%s", repo, tag)
+	}
+}
+
+func (c *BuildCache) log(result string) {
+	logrus.Infof("build cache %s for service %s: %s, ref %s", c.Kind, c.re.ServiceID, result, c.Ref)
+	c.re.Logger.Info(fmt.Sprintf("Build cache %s %s, key %s", c.Kind, result, c.Key), map[string]string{"step": "build-cache"})
+}
+
+// repository 返回镜像引用在镜像仓库中的仓库路径和标签
+func (c *BuildCache) repository(ref string) (string, string) {
+	repo, tag := splitImageTag(ref)
+	if parts := strings.SplitN(repo, "/", 2); len(parts) == 2 {
+		repo = parts[1]
+	}
+	return repo, tag
+}
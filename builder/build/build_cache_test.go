@@ -0,0 +1,165 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/event"
+)
+
+type fakeCacheRegistry struct {
+	manifests map[string]bool
+	tagged    [][2]string
+	deleted   []string
+}
+
+func (f *fakeCacheRegistry) HasManifest(repository, reference string) (bool, error) {
+	return f.manifests[repository+":"+reference], nil
+}
+
+func (f *fakeCacheRegistry) TagManifest(repository, source, target string) error {
+	f.tagged = append(f.tagged, [2]string{repository + ":" + source, repository + ":" + target})
+	f.manifests[repository+":"+target] = true
+	return nil
+}
+
+func (f *fakeCacheRegistry) Tags(repository string) ([]string, error) {
+	var tags []string
+	for ref := range f.manifests {
+		if strings.HasPrefix(ref, repository+":") {
+			tags = append(tags, strings.TrimPrefix(ref, repository+":"))
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (f *fakeCacheRegistry) DeleteTag(repository, tag string, keep []string) error {
+	f.deleted = append(f.deleted, repository+":"+tag)
+	delete(f.manifests, repository+":"+tag)
+	return nil
+}
+
+// capability_id: rainbond.build-cache.lockfile-key
+func TestBuildCacheKeyFollowsLockFiles(t *testing.T) {
+	dir := t.TempDir()
+	if got := BuildCacheKey(dir); got != noLockFileCacheKey {
+		t.Fatalf("expected %q without lock files, got %q", noLockFileCacheKey, got)
+	}
+	lockfile := filepath.Join(dir, "package-lock.json")
+	if err := os.WriteFile(lockfile, []byte(`{"lockfileVersion":3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	key := BuildCacheKey(dir)
+	if len(key) != 16 || key != BuildCacheKey(dir) {
+		t.Fatalf("expected a stable 16 char key, got %q", key)
+	}
+	if err := os.WriteFile(lockfile, []byte(`{"lockfileVersion":2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if BuildCacheKey(dir) == key {
+		t.Fatal("expected key to change with the lock file")
+	}
+}
+
+// capability_id: rainbond.build-cache.ref
+func TestBuildCacheRef(t *testing.T) {
+	tests := map[string]string{
+		"goodrain.me/app:20260101":        "goodrain.me/app:cnb-cache-abc",
+		"registry.local:5000/team/app:v1": "registry.local:5000/team/app:cnb-cache-abc",
+		"registry.local:5000/team/app":    "registry.local:5000/team/app:cnb-cache-abc",
+	}
+	for image, want := range tests {
+		if got := BuildCacheRef(image, CNBBuildCache, "abc"); got != want {
+			t.Errorf("BuildCacheRef(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+// capability_id: rainbond.build-cache.import-export
+func TestBuildCacheImportAndExport(t *testing.T) {
+	reg := &fakeCacheRegistry{manifests: map[string]bool{"team/app:cnb-cache-latest": true}}
+	re := &Request{SourceDir: t.TempDir(), ServiceID: "svc", Logger: event.GetTestLogger()}
+
+	cache := NewBuildCache(re, CNBBuildCache, "goodrain.me/team/app:v1", reg)
+	if cache.Ref != "goodrain.me/team/app:cnb-cache-nolock" {
+		t.Fatalf("unexpected cache ref %q", cache.Ref)
+	}
+	if hit := cache.Import(true); hit {
+		t.Fatal("expected a cache miss")
+	}
+	if len(reg.tagged) != 1 || reg.tagged[0] != [2]string{"team/app:cnb-cache-latest", "team/app:cnb-cache-nolock"} {
+		t.Fatalf("expected the cache to be restored from latest, got %v", reg.tagged)
+	}
+	if hit := cache.Import(true); !hit {
+		t.Fatal("expected a cache hit after restore")
+	}
+
+	cache.Export()
+	if len(reg.tagged) != 2 || reg.tagged[1] != [2]string{"team/app:cnb-cache-nolock", "team/app:cnb-cache-latest"} {
+		t.Fatalf("expected the cache to be exported as latest, got %v", reg.tagged)
+	}
+}
+
+// capability_id: rainbond.build-cache.buildkit-args
+func TestBuildCacheImportWithoutSeedAndBuildKitArgs(t *testing.T) {
+	reg := &fakeCacheRegistry{manifests: map[string]bool{"app:buildkit-cache-latest": true}}
+	re := &Request{SourceDir: t.TempDir(), ServiceID: "svc", Logger: event.GetTestLogger()}
+
+	cache := NewBuildCache(re, BuildKitBuildCache, "goodrain.me/app:v1", reg)
+	if hit := cache.Import(false); hit || len(reg.tagged) != 0 {
+		t.Fatalf("expected a miss without restoring, hit=%v tagged=%v", hit, reg.tagged)
+	}
+	args := registryBuildKitCacheArgs(cache)
+	want := []string{
+		"--export-cache", "type=registry,ref=goodrain.me/app:buildkit-cache-nolock,mode=max",
+		"--import-cache", "type=registry,ref=goodrain.me/app:buildkit-cache-nolock",
+		"--import-cache", "type=registry,ref=goodrain.me/app:buildkit-cache-latest",
+	}
+	if len(args) != len(want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("args = %v, want %v", args, want)
+		}
+	}
+}
+
+// capability_id: rainbond.build-cache.import-export
+func TestBuildCacheExportPrunesOldKeys(t *testing.T) {
+	t.Setenv("BUILD_CACHE_KEEP", "2")
+	reg := &fakeCacheRegistry{manifests: map[string]bool{
+		"prune/app:cnb-cache-old1":      true,
+		"prune/app:cnb-cache-old2":      true,
+		"prune/app:cnb-cache-latest":    true,
+		"prune/app:buildkit-cache-old1": true,
+		"prune/app:v1":                  true,
+	}}
+	re := &Request{SourceDir: t.TempDir(), ServiceID: "svc", Logger: event.GetTestLogger()}
+
+	cache := NewBuildCache(re, CNBBuildCache, "goodrain.me/prune/app:v1", reg)
+	reg.manifests["prune/app:cnb-cache-nolock"] = true
+	cache.Export()
+	if len(reg.deleted) != 1 || reg.deleted[0] != "prune/app:cnb-cache-old2" {
+		t.Fatalf("expected only the oldest cnb cache to be pruned, got %v", reg.deleted)
+	}
+	for _, ref := range []string{"prune/app:cnb-cache-nolock", "prune/app:cnb-cache-latest", "prune/app:buildkit-cache-old1", "prune/app:v1"} {
+		if !reg.manifests[ref] {
+			t.Fatalf("expected %s to be kept", ref)
+		}
+	}
+}
+
+// capability_id: rainbond.build-cache.import-export
+func TestBuildCacheWithoutRegistry(t *testing.T) {
+	re := &Request{SourceDir: t.TempDir(), ServiceID: "svc", Logger: event.GetTestLogger()}
+	cache := NewBuildCache(re, CNBBuildCache, "goodrain.me/app:v1", nil)
+	if hit := cache.Import(true); hit {
+		t.Fatal("expected a miss without a registry")
+	}
+	cache.Export()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/goodrain/rainbond/builder/build"
 	jobc "github.com/goodrain/rainbond/builder/job"
+	"github.com/goodrain/rainbond/builder/parser/code"
 	"github.com/goodrain/rainbond/builder/sources"
 	"github.com/goodrain/rainbond/util"
 	"github.com/sirupsen/logrus"
@@ -29,6 +30,7 @@ type Builder struct {
 	prepareBuildKit  func(ctx context.Context, kubeClient kubernetes.Interface, namespace, cmName, imageDomain string) error
 	createConfigMap  func(ctx context.Context, kubeClient kubernetes.Interface, namespace string, cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
 	deleteConfigMap  func(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string) error
+	cacheRegistry    func() build.CacheRegistry
 }
 
 // NewBuilder creates a new CNB builder
@@ -37,6 +39,7 @@ func NewBuilder() (build.Build, error) {
 		jobCtrl:          jobc.GetJobController(),
 		createAuthSecret: build.CreateAuthSecret,
 		deleteAuthSecret: build.DeleteAuthSecret,
+		cacheRegistry:    build.DefaultCacheRegistry,
 		prepareBuildKit:  sources.PrepareBuildKitTomlCM,
 		createConfigMap: func(ctx context.Context, kubeClient kubernetes.Interface, namespace string, cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
 			return kubeClient.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{})
@@ -54,6 +57,9 @@ func NewBuilder() (build.Build, error) {
 // Build executes the CNB build process
 func (b *Builder) Build(re *build.Request) (*build.Response, error) {
 	re.Logger.Info("Starting CNB build", map[string]string{"step": "builder-exector"})
+	if err := re.RejectMultiPlatform("CNB"); err != nil {
+		return nil, err
+	}
 
 	if err := applyVersionPolicy(re); err != nil {
 		re.Logger.Error(err.Error(), map[string]string{"step": "build-code", "status": "failure"})
@@ -63,6 +69,12 @@ func (b *Builder) Build(re *build.Request) (*build.Response, error) {
 		re.Logger.Error(err.Error(), map[string]string{"step": "build-code", "status": "failure"})
 		return nil, err
 	}
+	if re.Lang == code.Elixir {
+		if _, err := elixirBuildpack(); err != nil {
+			re.Logger.Error(err.Error(), map[string]string{"step": "build-code", "status": "failure"})
+			return nil, err
+		}
+	}
 
 	b.stopPreBuildJob(re)
 
@@ -956,7 +956,32 @@ func (m *mockJobCtrl) GetDefaultLanguageBuildSetting(_ context.Context, _ code.L
 	return m.defaultLanguageBuildSetting
 }
 
+// fakeCacheRegistry records build cache operations without calling a real registry
+type fakeCacheRegistry struct {
+	manifests map[string]bool
+	tagged    [][2]string
+}
+
+func (f *fakeCacheRegistry) HasManifest(repository, reference string) (bool, error) {
+	return f.manifests[repository+":"+reference], nil
+}
+
+func (f *fakeCacheRegistry) TagManifest(repository, source, target string) error {
+	f.tagged = append(f.tagged, [2]string{repository + ":" + source, repository + ":" + target})
+	f.manifests[repository+":"+target] = true
+	return nil
+}
+
+func (f *fakeCacheRegistry) Tags(repository string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeCacheRegistry) DeleteTag(repository, tag string, keep []string) error {
+	return nil
+}
+
 func newTestBuilder(ctrl *mockJobCtrl) *Builder {
+	reg := &fakeCacheRegistry{manifests: map[string]bool{}}
 	return &Builder{
 		jobCtrl: ctrl,
 		createAuthSecret: func(re *build.Request) (corev1.Secret, error) {
@@ -966,6 +991,7 @@ func newTestBuilder(ctrl *mockJobCtrl) *Builder {
 		prepareBuildKit: func(ctx context.Context, kubeClient kubernetes.Interface, namespace, cmName, imageDomain string) error {
 			return nil
 		},
+		cacheRegistry: func() build.CacheRegistry { return reg },
 	}
 }
 
@@ -1090,6 +1116,10 @@ func TestRunCNBBuildJob(t *testing.T) {
 		if len(ctrl.deleted) != 1 {
 			t.Errorf("expected job cleanup, got %d deletions", len(ctrl.deleted))
 		}
+		reg := b.cacheRegistry().(*fakeCacheRegistry)
+		if len(reg.tagged) != 1 || reg.tagged[0] != [2]string{"img:cnb-cache-nolock", "img:cnb-cache-latest"} {
+			t.Errorf("expected the build cache to be exported through the injected registry, got %v", reg.tagged)
+		}
 	})
 
 	t.Run("procfile build env is injected through binding and cleaned up", func(t *testing.T) {
@@ -124,6 +124,16 @@ func (b *Builder) runCNBBuildJob(re *build.Request, buildImageName string) error
 
 	podSpec.Volumes = volumes
 
+	var cache *build.BuildCache
+	if !noBuildCache(re) {
+		var reg build.CacheRegistry
+		if b.cacheRegistry != nil {
+			reg = b.cacheRegistry()
+		}
+		cache = build.NewBuildCache(re, build.CNBBuildCache, buildImageName, reg)
+		cache.Import(true)
+	}
+
 	creatorArgs := b.buildCreatorArgs(re, buildImageName, cnbRunImage)
 
 	// Chown workspace to cnb user inside the builder image (where cnb user exists with correct UID),
@@ -161,7 +171,13 @@ func (b *Builder) runCNBBuildJob(re *build.Request, buildImageName string) error
 
 	defer b.jobCtrl.DeleteJob(job.Name)
 
-	return b.waitingComplete(re, reChan)
+	if err := b.waitingComplete(re, reChan); err != nil {
+		return err
+	}
+	if cache != nil {
+		cache.Export()
+	}
+	return nil
 }
 
 // buildCreatorArgs builds the lifecycle creator arguments
@@ -174,8 +190,6 @@ func (b *Builder) buildCreatorArgs(re *build.Request, buildImageName, runImage s
 		logLevel = v
 	}
 
-	noCache := truthyBuildEnv(re.BuildEnvs["NO_CACHE"]) || truthyBuildEnv(re.BuildEnvs["BUILD_NO_CACHE"])
-
 	args := []string{
 		"-app=/workspace",
 		"-layers=/layers",
@@ -186,12 +200,12 @@ func (b *Builder) buildCreatorArgs(re *build.Request, buildImageName, runImage s
 		"-log-level=" + logLevel,
 	}
 
-	if noCache {
+	if noBuildCache(re) {
 		// Skip both cache restore and image layer reuse
 		args = append(args, "-skip-restore")
 	} else {
-		// Enable registry cache, previous image layer reuse, and parallel export
-		args = append(args, "-cache-image="+stableImageTag(buildImageName, "cnb-cache"))
+		// Enable registry cache keyed by lockfile hash, previous image layer reuse, and parallel export
+		args = append(args, "-cache-image="+build.BuildCacheRef(buildImageName, build.CNBBuildCache, build.BuildCacheKey(re.SourceDir)))
 		args = append(args, "-previous-image="+latestImage)
 		args = append(args, "-parallel")
 	}
@@ -208,6 +222,11 @@ func (b *Builder) buildCreatorArgs(re *build.Request, buildImageName, runImage s
 	return args
 }
 
+// noBuildCache reports whether the build disables cache restore and export.
+func noBuildCache(re *build.Request) bool {
+	return truthyBuildEnv(re.BuildEnvs["NO_CACHE"]) || truthyBuildEnv(re.BuildEnvs["BUILD_NO_CACHE"])
+}
+
 // buildEnvVars builds environment variables for the CNB build container.
 func (b *Builder) buildEnvVars(re *build.Request) []corev1.EnvVar {
 	registryHost, _ := sources.GetImageFirstPart(builder.REGISTRYDOMAIN)
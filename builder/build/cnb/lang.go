@@ -35,6 +35,12 @@ func getLanguageConfig(re *build.Request) LanguageConfig {
 		return &phpConfig{}
 	case code.NetCore:
 		return &dotnetConfig{}
+	case code.Rust:
+		return &rustConfig{}
+	case code.Scala:
+		return &scalaConfig{}
+	case code.Elixir:
+		return &elixirConfig{}
 	case code.Static:
 		return &staticConfig{}
 	case code.Nodejs:
@@ -0,0 +1,65 @@
+package cnb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder/build"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultMixEnv = "prod"
+
+// elixirBuildpackEnv names the buildpack used for Elixir builds. Neither the default builder nor
+// the Paketo project ships an Elixir buildpack, so the operator has to provide one in the builder image.
+const elixirBuildpackEnv = "CNB_ELIXIR_BUILDPACK"
+
+// elixirConfig implements LanguageConfig for mix projects.
+type elixirConfig struct{}
+
+func (e *elixirConfig) BuildAnnotations(re *build.Request, annotations map[string]string) {
+	applyDependencyMirrorAnnotation(annotations)
+	setAnnotationValue(annotations, "cnb-bp-elixir-version", firstNonEmptyEnv(re.BuildEnvs, "BP_ELIXIR_VERSION", "BUILD_RUNTIMES", "RUNTIMES"))
+	setAnnotationValue(annotations, "cnb-bp-erlang-version", firstNonEmptyEnv(re.BuildEnvs, "BP_ERLANG_VERSION", "BUILD_ERLANG_VERSION", "ERLANG_VERSION"))
+	setAnnotationValue(annotations, "cnb-mix-env", resolveMixEnv(re))
+}
+
+func (e *elixirConfig) BuildEnvVars(re *build.Request) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	envs = appendEnvVar(envs, "MIX_ENV", resolveMixEnv(re))
+	envs = appendEnvVar(envs, "HEX_MIRROR", firstNonEmptyEnv(re.BuildEnvs, "HEX_MIRROR", "BUILD_HEX_MIRROR"))
+	return envs
+}
+
+func (e *elixirConfig) InjectMirrorConfig(re *build.Request) error {
+	return ensureProcfile(re)
+}
+
+// CustomOrder returns the Elixir buildpack order using the buildpack configured by CNB_ELIXIR_BUILDPACK.
+func (e *elixirConfig) CustomOrder(re *build.Request) []orderBuildpack {
+	id, err := elixirBuildpack()
+	if err != nil {
+		return nil
+	}
+	return []orderBuildpack{
+		{ID: id, Version: os.Getenv("CNB_ELIXIR_BUILDPACK_VERSION")},
+		{ID: "paketo-buildpacks/procfile", Optional: true},
+	}
+}
+
+// elixirBuildpack returns the configured Elixir buildpack id, or an error when none is configured.
+func elixirBuildpack() (string, error) {
+	id := strings.TrimSpace(os.Getenv(elixirBuildpackEnv))
+	if id == "" {
+		return "", fmt.Errorf("elixir source build requires an Elixir buildpack in the CNB builder, set %s on rbd-chaos to its buildpack id", elixirBuildpackEnv)
+	}
+	return id, nil
+}
+
+func resolveMixEnv(re *build.Request) string {
+	if env := firstNonEmptyEnv(re.BuildEnvs, "MIX_ENV", "BUILD_MIX_ENV"); env != "" {
+		return env
+	}
+	return defaultMixEnv
+}
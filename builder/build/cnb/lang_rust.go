@@ -0,0 +1,36 @@
+package cnb
+
+import (
+	"os"
+
+	"github.com/goodrain/rainbond/builder/build"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rustConfig implements LanguageConfig for Cargo projects.
+type rustConfig struct{}
+
+func (r *rustConfig) BuildAnnotations(re *build.Request, annotations map[string]string) {
+	applyDependencyMirrorAnnotation(annotations)
+	setAnnotationValue(annotations, "cnb-bp-rust-toolchain", firstNonEmptyEnv(re.BuildEnvs, "BP_RUST_TOOLCHAIN", "BUILD_RUNTIMES", "RUNTIMES"))
+	setAnnotationValue(annotations, "cnb-bp-rust-profile", firstNonEmptyEnv(re.BuildEnvs, "BP_RUST_PROFILE", "BUILD_RUST_PROFILE"))
+	setAnnotationValue(annotations, "cnb-bp-cargo-install-args", firstNonEmptyEnv(re.BuildEnvs, "BP_CARGO_INSTALL_ARGS", "BUILD_CARGO_INSTALL_ARGS"))
+	setAnnotationValue(annotations, "cnb-bp-cargo-workspace-members", firstNonEmptyEnv(re.BuildEnvs, "BP_CARGO_WORKSPACE_MEMBERS", "BUILD_CARGO_WORKSPACE_MEMBERS"))
+	setAnnotationValue(annotations, "cnb-bp-cargo-exclude-folders", firstNonEmptyEnv(re.BuildEnvs, "BP_CARGO_EXCLUDE_FOLDERS", "BUILD_CARGO_EXCLUDE_FOLDERS"))
+}
+
+func (r *rustConfig) BuildEnvVars(re *build.Request) []corev1.EnvVar {
+	return nil
+}
+
+func (r *rustConfig) InjectMirrorConfig(re *build.Request) error {
+	return ensureProcfile(re)
+}
+
+// CustomOrder returns the Rust buildpack order, the default builder does not include Rust.
+func (r *rustConfig) CustomOrder(re *build.Request) []orderBuildpack {
+	return []orderBuildpack{
+		{ID: "paketo-community/rust", Version: os.Getenv("CNB_RUST_BUILDPACK_VERSION")},
+		{ID: "paketo-buildpacks/procfile", Optional: true},
+	}
+}
@@ -0,0 +1,131 @@
+package cnb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/build"
+	"github.com/goodrain/rainbond/builder/parser/code"
+)
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestRustLanguageConfigAnnotationsAndOrder(t *testing.T) {
+	t.Setenv("CNB_RUST_BUILDPACK_VERSION", "1.2.3")
+	re := &build.Request{
+		Lang:      code.Rust,
+		SourceDir: t.TempDir(),
+		BuildEnvs: map[string]string{
+			"BUILD_RUNTIMES":                "1.88",
+			"BUILD_CARGO_INSTALL_ARGS":      "--locked",
+			"BUILD_CARGO_WORKSPACE_MEMBERS": "api,worker",
+			"BP_CARGO_EXCLUDE_FOLDERS":      "static",
+		},
+	}
+
+	lang, ok := getLanguageConfig(re).(*rustConfig)
+	if !ok {
+		t.Fatal("expected rustConfig for rust build")
+	}
+	annotations := (&Builder{}).buildPlatformAnnotations(re)
+	if annotations["cnb-bp-rust-toolchain"] != "1.88" {
+		t.Fatalf("expected cnb-bp-rust-toolchain=1.88, got %q", annotations["cnb-bp-rust-toolchain"])
+	}
+	if annotations["cnb-bp-cargo-install-args"] != "--locked" {
+		t.Fatalf("expected cnb-bp-cargo-install-args, got %q", annotations["cnb-bp-cargo-install-args"])
+	}
+	if annotations["cnb-bp-cargo-workspace-members"] != "api,worker" {
+		t.Fatalf("expected cnb-bp-cargo-workspace-members, got %q", annotations["cnb-bp-cargo-workspace-members"])
+	}
+	if annotations["cnb-bp-cargo-exclude-folders"] != "static" {
+		t.Fatalf("expected cnb-bp-cargo-exclude-folders, got %q", annotations["cnb-bp-cargo-exclude-folders"])
+	}
+	order := lang.CustomOrder(re)
+	if len(order) == 0 || order[0].ID != "paketo-community/rust" || order[0].Version != "1.2.3" {
+		t.Fatalf("unexpected rust buildpack order %+v", order)
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestScalaLanguageConfigUsesJavaBuildpack(t *testing.T) {
+	re := &build.Request{
+		Lang:      code.Scala,
+		SourceDir: t.TempDir(),
+		BuildEnvs: map[string]string{
+			"BUILD_RUNTIMES":            "21",
+			"BUILD_SBT_BUILD_ARGUMENTS": "clean stage",
+			"BUILD_SBT_OPTS":            "-Xmx2g",
+		},
+	}
+
+	lang, ok := getLanguageConfig(re).(*scalaConfig)
+	if !ok {
+		t.Fatal("expected scalaConfig for scala build")
+	}
+	annotations := (&Builder{}).buildPlatformAnnotations(re)
+	if annotations["cnb-bp-jvm-version"] != "21" {
+		t.Fatalf("expected cnb-bp-jvm-version=21, got %q", annotations["cnb-bp-jvm-version"])
+	}
+	if annotations["cnb-bp-sbt-build-arguments"] != "clean stage" {
+		t.Fatalf("expected cnb-bp-sbt-build-arguments, got %q", annotations["cnb-bp-sbt-build-arguments"])
+	}
+	envs := lang.BuildEnvVars(re)
+	if len(envs) != 1 || envs[0].Name != "SBT_OPTS" || envs[0].Value != "-Xmx2g" {
+		t.Fatalf("unexpected scala build envs %+v", envs)
+	}
+	if order := lang.CustomOrder(re); order != nil {
+		t.Fatalf("expected scala builds to use the default builder order, got %+v", order)
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestElixirLanguageConfigAnnotationsAndOrder(t *testing.T) {
+	t.Setenv("CNB_ELIXIR_BUILDPACK", "example/elixir")
+	re := &build.Request{
+		Lang:      code.Elixir,
+		SourceDir: t.TempDir(),
+		BuildEnvs: map[string]string{
+			"BUILD_RUNTIMES":       "1.17",
+			"BUILD_ERLANG_VERSION": "27.1",
+			"BUILD_HEX_MIRROR":     "https://hexpm.upyun.com",
+		},
+	}
+
+	lang, ok := getLanguageConfig(re).(*elixirConfig)
+	if !ok {
+		t.Fatal("expected elixirConfig for elixir build")
+	}
+	annotations := (&Builder{}).buildPlatformAnnotations(re)
+	if annotations["cnb-bp-elixir-version"] != "1.17" {
+		t.Fatalf("expected cnb-bp-elixir-version=1.17, got %q", annotations["cnb-bp-elixir-version"])
+	}
+	if annotations["cnb-bp-erlang-version"] != "27.1" {
+		t.Fatalf("expected cnb-bp-erlang-version=27.1, got %q", annotations["cnb-bp-erlang-version"])
+	}
+	if annotations["cnb-mix-env"] != "prod" {
+		t.Fatalf("expected default cnb-mix-env=prod, got %q", annotations["cnb-mix-env"])
+	}
+	envs := map[string]string{}
+	for _, env := range lang.BuildEnvVars(re) {
+		envs[env.Name] = env.Value
+	}
+	if envs["MIX_ENV"] != "prod" || envs["HEX_MIRROR"] != "https://hexpm.upyun.com" {
+		t.Fatalf("unexpected elixir build envs %+v", envs)
+	}
+	order := lang.CustomOrder(re)
+	if len(order) == 0 || order[0].ID != "example/elixir" {
+		t.Fatalf("unexpected elixir buildpack order %+v", order)
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestElixirBuildpackMustBeConfigured(t *testing.T) {
+	t.Setenv("CNB_ELIXIR_BUILDPACK", "")
+	re := &build.Request{Lang: code.Elixir, SourceDir: t.TempDir()}
+
+	if _, err := elixirBuildpack(); err == nil || !strings.Contains(err.Error(), "CNB_ELIXIR_BUILDPACK") {
+		t.Fatalf("expected an error naming CNB_ELIXIR_BUILDPACK, got %v", err)
+	}
+	if order := (&elixirConfig{}).CustomOrder(re); order != nil {
+		t.Fatalf("expected no buildpack order without a configured buildpack, got %+v", order)
+	}
+}
@@ -0,0 +1,33 @@
+package cnb
+
+import (
+	"github.com/goodrain/rainbond/builder/build"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// scalaConfig implements LanguageConfig for sbt projects, built by the Java buildpack's sbt support.
+type scalaConfig struct{}
+
+func (s *scalaConfig) BuildAnnotations(re *build.Request, annotations map[string]string) {
+	applyDependencyMirrorAnnotation(annotations)
+	setAnnotationValue(annotations, "cnb-bp-jvm-version", firstNonEmptyEnv(re.BuildEnvs, "BP_JVM_VERSION", "BUILD_RUNTIMES", "RUNTIMES"))
+	setAnnotationValue(annotations, "cnb-bp-jvm-type", firstNonEmptyEnv(re.BuildEnvs, "BP_JVM_TYPE"))
+	setAnnotationValue(annotations, "cnb-bp-sbt-build-arguments", firstNonEmptyEnv(re.BuildEnvs, "BP_SBT_BUILD_ARGUMENTS", "BUILD_SBT_BUILD_ARGUMENTS"))
+	setAnnotationValue(annotations, "cnb-bp-sbt-additional-build-arguments", firstNonEmptyEnv(re.BuildEnvs, "BP_SBT_ADDITIONAL_BUILD_ARGUMENTS", "BUILD_SBT_ADDITIONAL_BUILD_ARGUMENTS"))
+	setAnnotationValue(annotations, "cnb-bp-sbt-built-module", firstNonEmptyEnv(re.BuildEnvs, "BP_SBT_BUILT_MODULE", "BUILD_SBT_BUILT_MODULE"))
+	setAnnotationValue(annotations, "cnb-bp-sbt-built-artifact", firstNonEmptyEnv(re.BuildEnvs, "BP_SBT_BUILT_ARTIFACT", "BUILD_SBT_BUILT_ARTIFACT"))
+}
+
+func (s *scalaConfig) BuildEnvVars(re *build.Request) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	envs = appendEnvVar(envs, "SBT_OPTS", firstNonEmptyEnv(re.BuildEnvs, "SBT_OPTS", "BUILD_SBT_OPTS"))
+	return envs
+}
+
+func (s *scalaConfig) InjectMirrorConfig(re *build.Request) error {
+	return ensureProcfile(re)
+}
+
+func (s *scalaConfig) CustomOrder(re *build.Request) []orderBuildpack {
+	return nil
+}
@@ -93,6 +93,12 @@ func cnbDebugLanguage(re *build.Request) string {
 		return "php"
 	case code.NetCore:
 		return "dotnet"
+	case code.Rust:
+		return "rust"
+	case code.Scala:
+		return "scala"
+	case code.Elixir:
+		return "elixir"
 	case code.Static:
 		return "static"
 	default:
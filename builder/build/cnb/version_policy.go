@@ -25,6 +25,9 @@ var versionPolicyRules = map[code.Lang]versionPolicyRule{
 	code.Golang:    {policyKey: "golang", explicitKeys: []string{"BP_GO_VERSION", "BUILD_GOVERSION", "GOVERSION"}, bpKey: "BP_GO_VERSION", setKeys: []string{"BUILD_GOVERSION", "BP_GO_VERSION"}, ossDefault: "1.25"},
 	code.NetCore:   {policyKey: "dotnet", explicitKeys: []string{"BP_DOTNET_FRAMEWORK_VERSION"}, bpKey: "BP_DOTNET_FRAMEWORK_VERSION", setKeys: []string{"BP_DOTNET_FRAMEWORK_VERSION"}, ossDefault: "8.0"},
 	code.PHP:       {policyKey: "php", explicitKeys: []string{"BP_PHP_VERSION", "BUILD_RUNTIMES", "RUNTIMES"}, bpKey: "BP_PHP_VERSION", setKeys: []string{"BUILD_RUNTIMES", "BP_PHP_VERSION"}, ossDefault: "8.3"},
+	code.Rust:      {policyKey: "rust", explicitKeys: []string{"BP_RUST_TOOLCHAIN", "BUILD_RUNTIMES", "RUNTIMES"}, bpKey: "BP_RUST_TOOLCHAIN", setKeys: []string{"BUILD_RUNTIMES", "BP_RUST_TOOLCHAIN"}, ossDefault: code.DefaultRustVersion},
+	code.Scala:     {policyKey: "java", explicitKeys: []string{"BUILD_RUNTIMES", "RUNTIMES", "BP_JVM_VERSION"}, bpKey: "BP_JVM_VERSION", setKeys: []string{"BUILD_RUNTIMES", "BP_JVM_VERSION"}, ossDefault: code.DefaultScalaJVMVersion},
+	code.Elixir:    {policyKey: "elixir", explicitKeys: []string{"BP_ELIXIR_VERSION", "BUILD_RUNTIMES", "RUNTIMES"}, bpKey: "BP_ELIXIR_VERSION", setKeys: []string{"BUILD_RUNTIMES", "BP_ELIXIR_VERSION"}, ossDefault: code.DefaultElixirVersion},
 	code.Nodejs:    {policyKey: "nodejs", explicitKeys: []string{"CNB_NODE_VERSION", "BUILD_RUNTIMES", "RUNTIMES", "BP_NODE_VERSION"}, bpKey: "BP_NODE_VERSION", setKeys: []string{"CNB_NODE_VERSION", "BP_NODE_VERSION"}, ossDefault: "24.13.0"},
 }
 
@@ -145,7 +148,7 @@ func normalizeVersionByLanguage(lang code.Lang, version string) (string, error)
 	}
 
 	switch lang {
-	case code.JavaMaven, code.JaveWar, code.JavaJar, code.Gradle:
+	case code.JavaMaven, code.JaveWar, code.JavaJar, code.Gradle, code.Scala:
 		if strings.HasPrefix(version, "1.") {
 			version = version[2:]
 		}
@@ -185,6 +188,13 @@ func normalizeVersionByLanguage(lang code.Lang, version string) (string, error)
 			return "", fmt.Errorf("invalid dotnet cnb version %q", version)
 		}
 		return strings.Join(parts[:2], "."), nil
+	case code.Rust:
+		return code.ResolveRustVersion(version), nil
+	case code.Elixir:
+		if !strings.ContainsAny(version, "0123456789") {
+			return "", fmt.Errorf("invalid elixir cnb version %q", version)
+		}
+		return code.ResolveElixirVersion(version), nil
 	case code.Nodejs:
 		return normalizeNodeVersion(version)
 	default:
@@ -223,3 +223,30 @@ func TestResolvePHPOssFallbackVersionWithoutPolicy(t *testing.T) {
 		t.Fatalf("expected BP_PHP_VERSION=8.3, got %q", got)
 	}
 }
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestVersionPolicyDetectsRustAndElixirVersions(t *testing.T) {
+	rustDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rustDir, "rust-toolchain.toml"), []byte("[toolchain]\nchannel = \"1.86.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	re := &build.Request{Lang: code.Rust, BuildStrategy: "cnb", SourceDir: rustDir, BuildEnvs: map[string]string{}}
+	if err := applyVersionPolicy(re); err != nil {
+		t.Fatalf("applyVersionPolicy returned error: %v", err)
+	}
+	if got := re.BuildEnvs["BP_RUST_TOOLCHAIN"]; got != "1.86" {
+		t.Fatalf("expected BP_RUST_TOOLCHAIN=1.86, got %q", got)
+	}
+
+	elixirDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(elixirDir, "mix.exs"), []byte("def project do\n  [app: :demo, elixir: \"~> 1.16.2\"]\nend\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	re = &build.Request{Lang: code.Elixir, BuildStrategy: "cnb", SourceDir: elixirDir, BuildEnvs: map[string]string{}}
+	if err := applyVersionPolicy(re); err != nil {
+		t.Fatalf("applyVersionPolicy returned error: %v", err)
+	}
+	if got := re.BuildEnvs["BP_ELIXIR_VERSION"]; got != "1.16" {
+		t.Fatalf("expected BP_ELIXIR_VERSION=1.16, got %q", got)
+	}
+}
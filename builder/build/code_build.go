@@ -58,6 +58,9 @@ func (s *slugBuild) Build(re *Request) (*Response, error) {
 	normalizedReq := *re
 	normalizedReq.BuildEnvs = expandBuildEnvsForSlugBuild(re.BuildEnvs)
 	re = &normalizedReq
+	if err := re.RejectMultiPlatform("source code"); err != nil {
+		return nil, err
+	}
 	s.re = re
 	s.buildCacheDir = re.CacheDir
 	packageName := fmt.Sprintf("%s/%s.tgz", s.tgzDir, re.DeployVersion)
@@ -232,10 +235,10 @@ func (s *slugBuild) getSourceCodeTarFile(re *Request) (string, error) {
 	switch re.ServerType {
 	case "svn":
 		cmd = append(cmd, "tar", "-cf", sourceTarFile, "./")
-	case "git":
+	case "git", "hg", "archive":
 		cmd = append(cmd, "tar", "-cf", sourceTarFile, "./")
 	default:
-		return "", fmt.Errorf("unsupported server type '%s' for source code tar operation, only 'git' and 'svn' are supported", re.ServerType)
+		return "", fmt.Errorf("unsupported server type '%s' for source code tar operation, only 'git', 'svn', 'hg' and 'archive' are supported", re.ServerType)
 	}
 
 	// 防御性检查：确保cmd不为空
@@ -323,8 +326,8 @@ func (s *slugBuild) runBuildJob(re *Request) error {
 	re.Logger.Info(util.Translation("Start make code package"), map[string]string{"step": "build-exector"})
 	start := time.Now()
 	var sourceTarFileName string
-	// 只有 git 和 svn 类型需要创建源码 tar 文件
-	if re.ServerType == "git" || re.ServerType == "svn" {
+	// 只有 git、svn、hg 和 archive 类型需要创建源码 tar 文件
+	if re.ServerType == "git" || re.ServerType == "svn" || re.ServerType == "hg" || re.ServerType == "archive" {
 		var err error
 		// handle nodejs or static dir
 		if err := s.HandleNodeJsDir(re); err != nil {
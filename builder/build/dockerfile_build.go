@@ -185,7 +185,7 @@ func (d *dockerfileBuild) runBuildJob(re *Request, buildImageName string) error
 			"--local",
 			fmt.Sprintf("dockerfile=%v", re.SourceDir),
 			"--output",
-			fmt.Sprintf("type=image,name=%s,push=true", buildImageName),
+			sources.BuildKitPlatformOutput(fmt.Sprintf("type=image,name=%s,push=true", buildImageName), re.PlatformBuildKitArgs()),
 		},
 		SecurityContext: &corev1.SecurityContext{
 			Privileged: &privileged,
@@ -203,6 +203,10 @@ func (d *dockerfileBuild) runBuildJob(re *Request, buildImageName string) error
 	if len(re.BuildKitArgs) > 0 {
 		container.Args = append(container.Args, re.BuildKitArgs...)
 	}
+	if platformArgs := re.PlatformBuildKitArgs(); len(platformArgs) > 0 {
+		container.Args = append(container.Args, platformArgs...)
+		re.Logger.Info(fmt.Sprintf("build image for platforms %s", strings.Join(re.Platforms, ",")), map[string]string{"step": "builder-exector"})
+	}
 	for key := range re.BuildEnvs {
 		if strings.HasPrefix(key, "ARG_") {
 			envKey := strings.Replace(key, "ARG_", "", -1)
@@ -211,15 +215,21 @@ func (d *dockerfileBuild) runBuildJob(re *Request, buildImageName string) error
 	}
 
 	// 添加 BuildKit 缓存支持
+	var cache *BuildCache
 	if re.BuildKitCache {
 		// 使用 Registry cache，支持多阶段构建和完整的层缓存
-		// 缓存镜像基于服务ID，不包含版本号，这样所有版本可以共享缓存
-		baseImageName := CreateImageName(re.ServiceID, "cache")
-		cacheRef := util.GetenvDefault("BUILDKIT_CACHE_REF", baseImageName)
-		container.Args = append(container.Args,
-			"--export-cache", fmt.Sprintf("type=registry,ref=%s,mode=max", cacheRef),
-			"--import-cache", fmt.Sprintf("type=registry,ref=%s", cacheRef))
-		re.Logger.Info(fmt.Sprintf("BuildKit cache enabled, cache ref: %s", cacheRef), map[string]string{"step": "builder-exector"})
+		if cacheRef := os.Getenv("BUILDKIT_CACHE_REF"); cacheRef != "" {
+			container.Args = append(container.Args,
+				"--export-cache", fmt.Sprintf("type=registry,ref=%s,mode=max", cacheRef),
+				"--import-cache", fmt.Sprintf("type=registry,ref=%s", cacheRef))
+			re.Logger.Info(fmt.Sprintf("BuildKit cache enabled, cache ref: %s", cacheRef), map[string]string{"step": "builder-exector"})
+		} else {
+			// 缓存按组件和依赖锁文件哈希区分，同时导入最近一次的缓存，任意构建节点都可以复用
+			cache = NewBuildCache(re, BuildKitBuildCache, buildImageName, DefaultCacheRegistry())
+			cache.Import(false)
+			container.Args = append(container.Args, registryBuildKitCacheArgs(cache)...)
+			re.Logger.Info(fmt.Sprintf("BuildKit cache enabled, cache ref: %s", cache.Ref), map[string]string{"step": "builder-exector"})
+		}
 	}
 
 	container.VolumeMounts = mounts
@@ -240,7 +250,23 @@ func (d *dockerfileBuild) runBuildJob(re *Request, buildImageName string) error
 	// delete job after complete
 	defer d.deleteAuthSecret(re, secret.Name)
 	defer jobc.GetJobController().DeleteJob(job.Name)
-	return d.waitingComplete(re, reChan)
+	if err := d.waitingComplete(re, reChan); err != nil {
+		return err
+	}
+	if cache != nil {
+		cache.Export()
+	}
+	return nil
+}
+
+// registryBuildKitCacheArgs 返回 buildctl 使用镜像仓库缓存的参数，
+// 导出到当前锁文件对应的缓存，并依次从当前缓存和最近一次的缓存导入。
+func registryBuildKitCacheArgs(cache *BuildCache) []string {
+	return []string{
+		"--export-cache", fmt.Sprintf("type=registry,ref=%s,mode=max", cache.Ref),
+		"--import-cache", fmt.Sprintf("type=registry,ref=%s", cache.Ref),
+		"--import-cache", fmt.Sprintf("type=registry,ref=%s", cache.LatestRef),
+	}
 }
 
 // localBuildKitCacheArgs 返回 buildctl 使用本地目录作为 layer cache 的参数。
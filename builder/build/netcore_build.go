@@ -96,7 +96,7 @@ func (d *customDockerfileBuild) Build(re *Request) (*Response, error) {
 		return nil, fmt.Errorf("write default dockerfile error:%s", err.Error())
 	}
 	// build image
-	err := sources.ImageBuild(re.Arch, d.sourceDir, re.RbdNamespace, re.ServiceID, re.DeployVersion, re.Logger, "nc-build", "", re.BuildKitImage, re.BuildKitArgs, re.BuildKitCache, re.KubeClient)
+	err := sources.ImageBuild(re.Arch, d.sourceDir, re.RbdNamespace, re.ServiceID, re.DeployVersion, re.Logger, "nc-build", "", re.BuildKitImage, append(append([]string{}, re.BuildKitArgs...), re.PlatformBuildKitArgs()...), re.BuildKitCache, re.KubeClient)
 	if err != nil {
 		re.Logger.Error(fmt.Sprintf("build image %s failure, find log in rbd-chaos", d.buildImageName), map[string]string{"step": "builder-exector", "status": "failure"})
 		logrus.Errorf("build image error: %s", err.Error())
@@ -0,0 +1,32 @@
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/event"
+)
+
+// capability_id: rainbond.multi-arch.source-build
+func TestRejectMultiPlatform(t *testing.T) {
+	re := &Request{Arch: "amd64", Platforms: []string{"linux/amd64"}, Logger: event.GetTestLogger()}
+	if err := re.RejectMultiPlatform("CNB"); err != nil {
+		t.Fatalf("single platform builds should be accepted, got %v", err)
+	}
+	re.Platforms = []string{"linux/amd64", "linux/arm64"}
+	err := re.RejectMultiPlatform("CNB")
+	if err == nil || !strings.Contains(err.Error(), "linux/amd64,linux/arm64") {
+		t.Fatalf("expected multi-platform CNB builds to be rejected, got %v", err)
+	}
+	if len(re.Platforms) != 2 {
+		t.Fatalf("platforms should not be dropped silently, got %v", re.Platforms)
+	}
+}
+
+// capability_id: rainbond.multi-arch.source-build
+func TestSlugBuildRejectsMultiPlatform(t *testing.T) {
+	re := &Request{Arch: "amd64", Platforms: []string{"linux/amd64", "linux/arm64"}, Logger: event.GetTestLogger()}
+	if _, err := (&slugBuild{}).Build(re); err == nil || !strings.Contains(err.Error(), "multi-platform") {
+		t.Fatalf("expected slug builds to reject multiple platforms, got %v", err)
+	}
+}
@@ -30,7 +30,6 @@ import (
 	"github.com/goodrain/rainbond/mq/api/grpc/pb"
 	"github.com/goodrain/rainbond/mq/client"
 	"github.com/sirupsen/logrus"
-	grpc1 "google.golang.org/grpc"
 )
 
 // WTOPIC is builder
@@ -74,8 +73,7 @@ func (t *TaskManager) Start(errChan chan error) error {
 	go t.Do(errChan)
 
 	// 等待消费循环启动并进入等待状态
-	// 这个时间需要足够长，确保 Do() 循环已经执行到 Dequeue 的 Wait() 调用
-	// 避免 lost wakeup 问题导致第一次任务丢失
+	// 这个时间需要足够长，确保 Do() 中的订阅已经建立
 	logrus.Info("waiting for consumer loop to start...")
 	time.Sleep(time.Second * 3)
 
@@ -113,6 +111,12 @@ func (t *TaskManager) Start(errChan chan error) error {
 	return nil
 }
 func (t *TaskManager) callback(task *pb.TaskMessage) {
+	if task.MessageId != "" {
+		// 通过 requeue 将消息立即退回队列，不计入失败次数
+		t.nack(configs.Default().ChaosConfig.Topic, task, "", true)
+		logrus.Infof("The build controller returns an indigestible task(%s) to the messaging system", task.TaskId)
+		return
+	}
 	ctx, cancel := context.WithCancel(t.ctx)
 	defer cancel()
 	_, err := t.client.Enqueue(ctx, &pb.EnqueueRequest{
@@ -128,34 +132,25 @@ func (t *TaskManager) callback(task *pb.TaskMessage) {
 // Do do
 func (t *TaskManager) Do(errChan chan error) {
 	hostName, _ := os.Hostname()
-	for {
-		select {
-		case <-t.discoverCtx.Done():
-			return
-		default:
-			ctx, cancel := context.WithCancel(t.discoverCtx)
-			topic := configs.Default().ChaosConfig.Topic
-			data, err := t.client.Dequeue(ctx, &pb.DequeueRequest{Topic: topic, ClientHost: hostName + "-builder"})
-			cancel()
-			if err != nil {
-				if grpc1.ErrorDesc(err) == context.DeadlineExceeded.Error() {
-					logrus.Debug("waiting for build task...")
-					continue
-				}
-				if grpc1.ErrorDesc(err) == "context timeout" {
-					logrus.Debug("waiting for build task...")
-					continue
-				}
-				logrus.Errorf("message dequeue failure %s, will retry", err.Error())
-				time.Sleep(time.Second * 2)
-				continue
-			}
-			err = t.exec.AddTask(data)
-			if err != nil {
-				t.callbackChan <- data
-				logrus.Error("add task error:", err.Error())
-			}
+	topic := configs.Default().ChaosConfig.Topic
+	// 订阅的额度与执行器可以同时执行的任务数一致，多个副本通过同一消费组共享任务
+	messages := t.client.SubscribeTopic(t.discoverCtx, topic, client.BuilderConsumerGroup, hostName+"-builder", t.exec.GetTaskCredits())
+	for data := range messages {
+		err := t.exec.AddTask(data)
+		if err != nil {
+			logrus.Error("add task error:", err.Error())
+			t.nack(topic, data, err.Error(), false)
 		}
+		// 任务执行结束后由执行器确认消息
+	}
+}
+
+// nack 任务无法被执行器接收，让消息队列重新投递
+func (t *TaskManager) nack(topic string, data *pb.TaskMessage, reason string, requeue bool) {
+	ctx, cancel := context.WithTimeout(t.ctx, time.Second*5)
+	defer cancel()
+	if _, err := t.client.Nack(ctx, &pb.AckRequest{Topic: topic, MessageId: data.MessageId, Reason: reason, Requeue: requeue}); err != nil {
+		logrus.Errorf("nack task(%s) message failure %s, it will be redelivered after visibility timeout", data.TaskId, err.Error())
 	}
 }
 
@@ -28,8 +28,10 @@ import (
 	"github.com/goodrain/rainbond/builder"
 	"github.com/goodrain/rainbond/builder/build"
 	"github.com/goodrain/rainbond/builder/sources"
+	"github.com/goodrain/rainbond/builder/sources/signature"
 	"github.com/goodrain/rainbond/db"
 	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
 	"github.com/goodrain/rainbond/util"
 	"github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -54,6 +56,9 @@ type ImageBuildItem struct {
 	Action        string
 	Configs       map[string]gjson.Result `json:"configs"`
 	FailCause     string
+	// verified 外部镜像的签名验证结果，signed 推送到集群镜像仓库的镜像的签名结果
+	verified *signature.Result
+	signed   *signature.Result
 }
 
 // NewImageBuildItem 创建实体
@@ -97,16 +102,35 @@ func (i *ImageBuildItem) Run(timeout time.Duration) error {
 	}
 
 	user, pass := builder.GetImageUserInfoV2(i.Image, i.HubUser, i.HubPassword)
-	_, err := i.ImageClient.ImagePull(i.Image, user, pass, i.Logger, 30)
+	signConfig := defaultImageSignConfig()
+	verified, err := verifySourceImage(signConfig, i.Image, signature.Auth{Username: user, Password: pass}, i.Logger)
+	i.verified = verified
 	if err != nil {
-		logrus.Errorf("pull image %s error: %s", i.Image, err.Error())
-		failCause := fmt.Sprintf("%s: %s", util.Translation("Pull image failed, please check if the image is accessible"), i.Image)
+		logrus.Errorf("build from image failed: %s", err.Error())
+		failCause := fmt.Sprintf("%s: %s", util.Translation("Image signature verification failed"), i.Image)
 		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
 		i.FailCause = failCause
 		return err
 	}
+	// 签名验证通过后按摘要拉取，避免验证与拉取之间标签被替换
+	pullImage, err := verifiedImageRef(i.Image, verified)
+	if err != nil {
+		logrus.Errorf("build from image failed: %s", err.Error())
+		failCause := fmt.Sprintf("%s: %s", util.Translation("Image signature verification failed"), i.Image)
+		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
+		i.FailCause = failCause
+		return err
+	}
+	_, err = i.ImageClient.ImagePull(pullImage, user, pass, i.Logger, 30)
+	if err != nil {
+		logrus.Errorf("pull image %s error: %s", pullImage, err.Error())
+		failCause := fmt.Sprintf("%s: %s", util.Translation("Pull image failed, please check if the image is accessible"), i.Image)
+		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
+		i.FailCause = failCause
+		return mqclient.Retryable(err)
+	}
 	localImageURL := build.CreateImageName(i.ServiceID, i.DeployVersion)
-	if err := i.ImageClient.ImageTag(i.Image, localImageURL, i.Logger, 1); err != nil {
+	if err := i.ImageClient.ImageTag(pullImage, localImageURL, i.Logger, 1); err != nil {
 		logrus.Errorf("change image tag error: %s", err.Error())
 		failCause := fmt.Sprintf("%s: %s -> %s", util.Translation("Tag image failed"), i.Image, localImageURL)
 		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
@@ -119,18 +143,34 @@ func (i *ImageBuildItem) Run(timeout time.Duration) error {
 		failCause := util.Translation("Push image to registry failed")
 		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
 		i.FailCause = failCause
-		return err
+		return mqclient.Retryable(err)
 	}
 
+	i.signed = signPushedImage(signConfig, localImageURL, i.Logger)
+
 	if err := i.ImageClient.ImageRemove(localImageURL); err != nil {
 		logrus.Errorf("remove image %s failure %s", localImageURL, err.Error())
 	}
 
 	if os.Getenv("DISABLE_IMAGE_CACHE") == "true" {
-		if err := i.ImageClient.ImageRemove(i.Image); err != nil {
-			logrus.Errorf("remove image %s failure %s", i.Image, err.Error())
+		if err := i.ImageClient.ImageRemove(pullImage); err != nil {
+			logrus.Errorf("remove image %s failure %s", pullImage, err.Error())
 		}
 	}
+	s := &imageSBOM{
+		ServiceID:    i.ServiceID,
+		EventID:      i.EventID,
+		BuildVersion: i.DeployVersion,
+		ImageName:    localImageURL,
+		Logger:       i.Logger,
+		config:       defaultSBOMConfig(),
+	}
+	if err := s.generate(); err != nil {
+		failCause := fmt.Sprintf("%s: %s", util.Translation("Image vulnerability check failed"), err.Error())
+		i.Logger.Error(failCause, map[string]string{"step": "build-sbom", "status": "failure"})
+		i.FailCause = failCause
+		return err
+	}
 	if err := i.StorageVersionInfo(localImageURL); err != nil {
 		logrus.Errorf("storage version info error, ignor it: %s", err.Error())
 		failCause := util.Translation("Update version info failed")
@@ -153,6 +193,7 @@ func (i *ImageBuildItem) StorageVersionInfo(imageURL string) error {
 	version.RepoURL = i.Image
 	version.FinalStatus = "success"
 	version.FinishTime = time.Now()
+	applySignatureResults(version, i.verified, i.signed)
 	if err := db.GetManager().VersionInfoDao().UpdateModel(version); err != nil {
 		return err
 	}
@@ -168,6 +209,7 @@ func (i *ImageBuildItem) UpdateVersionInfo(status string) error {
 	version.FinalStatus = status
 	version.RepoURL = i.Image
 	version.FinishTime = time.Now()
+	applySignatureResults(version, i.verified, i.signed)
 	if err := db.GetManager().VersionInfoDao().UpdateModel(version); err != nil {
 		return err
 	}
@@ -37,14 +37,17 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/goodrain/rainbond/builder"
 	"github.com/goodrain/rainbond/builder/build"
 	_ "github.com/goodrain/rainbond/builder/build/cnb" // register CNB builder
 	"github.com/goodrain/rainbond/builder/parser/code"
 	"github.com/goodrain/rainbond/builder/sources"
+	"github.com/goodrain/rainbond/builder/sources/signature"
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
 	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
 	"github.com/goodrain/rainbond/pkg/component/k8s"
 	"github.com/goodrain/rainbond/util"
 	"github.com/pquerna/ffjson/ffjson"
@@ -92,6 +95,12 @@ type SourceCodeBuildItem struct {
 	Ctx              context.Context
 	FailCause        string
 	BRVersion        string
+	// Platforms 多平台构建的目标平台
+	Platforms []string
+	// signed 构建产出镜像的签名结果
+	signed *signature.Result
+	// archDigests 多架构镜像各架构的 digest
+	archDigests map[string]string
 }
 
 // Commit code Commit
@@ -115,6 +124,13 @@ func NewSouceCodeBuildItem(in []byte) *SourceCodeBuildItem {
 		ServiceID:      gjson.GetBytes(in, "service_id").String(),
 		Configs:        gjson.GetBytes(in, "configs").Map(),
 		DockerfilePath: gjson.GetBytes(in, "dockerfile_path").String(),
+		SubDir:         gjson.GetBytes(in, "sub_dir").String(),
+		IncludePaths:   gjsonStrings(gjson.GetBytes(in, "include_paths")),
+		ExcludePaths:   gjsonStrings(gjson.GetBytes(in, "exclude_paths")),
+		CommitBefore:   gjson.GetBytes(in, "commit_before").String(),
+		CommitAfter:    gjson.GetBytes(in, "commit_after").String(),
+		Checksum:       gjson.GetBytes(in, "checksum").String(),
+		SkipTLSVerify:  gjson.GetBytes(in, "skip_tls_verify").Bool(),
 	}
 	envs := gjson.GetBytes(in, "envs").String()
 	be := make(map[string]string)
@@ -147,6 +163,7 @@ func NewSouceCodeBuildItem(in []byte) *SourceCodeBuildItem {
 		Configs:          gjson.GetBytes(in, "configs").Map(),
 		BuildEnvs:        be,
 		CNBVersionPolicy: parseCNBVersionPolicy(in),
+		Platforms:        parseBuildPlatforms(in, be),
 	}
 	scb.CacheDir = fmt.Sprintf("/cache/build/%s/cache/%s", scb.TenantID, scb.ServiceID)
 	//scb.SourceDir = scb.CodeSouceInfo.GetCodeSourceDir()
@@ -223,6 +240,50 @@ func (i *SourceCodeBuildItem) validateCNBVersionPolicy() error {
 	return fmt.Errorf("cnb_version_policy is required for enterprise cnb builds")
 }
 
+// errBuildSkipped the pushed commits do not change the component paths
+var errBuildSkipped = errors.New("build skipped, no changes under the component paths")
+
+func gjsonStrings(result gjson.Result) []string {
+	var values []string
+	for _, item := range result.Array() {
+		if value := strings.TrimSpace(item.String()); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// pathsChanged 判断 webhook 推送的提交范围内组件关注的路径是否有变更，无法判断时按有变更处理
+func (i *SourceCodeBuildItem) pathsChanged(rs *git.Repository) bool {
+	csi := i.CodeSouceInfo
+	if csi.CommitBefore == "" {
+		return true
+	}
+	filter := sources.NewPathFilter(csi)
+	if filter.Empty() {
+		return true
+	}
+	files, err := sources.ChangedFiles(rs, csi.CommitBefore, csi.CommitAfter)
+	if err != nil {
+		logrus.Warningf("get changed files of service %s error: %v", i.ServiceID, err)
+		i.Logger.Info("Unable to get the changed files of the pushed commits, build the component", map[string]string{"step": "code-version"})
+		return true
+	}
+	matched := filter.Filter(files)
+	if len(matched) == 0 {
+		return false
+	}
+	i.Logger.Info(fmt.Sprintf("%d of %d changed files match the component paths", len(matched), len(files)), map[string]string{"step": "code-version"})
+	return true
+}
+
 // Run Run
 func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 	// 1.clone
@@ -234,7 +295,10 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 		failCause := util.Translation("git project warehouse address format error")
 		i.Logger.Error(failCause, map[string]string{"step": "parse"})
 		i.FailCause = failCause
-		return err
+		return mqclient.NonRetryable(err)
+	}
+	if subDir := sources.CleanSubDir(i.CodeSouceInfo.SubDir); subDir != "" {
+		rbi.BuildPath = subDir
 	}
 	i.RepoInfo = rbi
 	if err := i.prepare(); err != nil {
@@ -245,6 +309,7 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 		return err
 	}
 	i.CodeSouceInfo.RepositoryURL = rbi.RepostoryURL
+	pathsChanged := true
 	switch i.CodeSouceInfo.ServerType {
 	case "svn":
 		csi := i.CodeSouceInfo
@@ -255,7 +320,7 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 			failCause := util.Translation("Checkout svn code failed, please make sure the code can be downloaded properly")
 			i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
 			i.FailCause = failCause
-			return err
+			return retryableIfNetwork(err)
 		}
 		if rs.Logs == nil || len(rs.Logs.CommitEntrys) < 1 {
 			logrus.Errorf("get code commit info error: %s", err.Error())
@@ -269,6 +334,46 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 			Message: rs.Logs.CommitEntrys[0].Msg,
 			Author:  rs.Logs.CommitEntrys[0].Author,
 		}
+	case "hg":
+		hgclient := sources.NewHgClient(i.CodeSouceInfo, rbi.GetCodeHome(), i.Logger)
+		rs, err := hgclient.UpdateOrCheckout(rbi.BuildPath)
+		if err != nil {
+			logrus.Errorf("checkout hg code error: %s", err.Error())
+			failCause := util.Translation("Checkout hg code failed, please make sure the code can be downloaded properly")
+			i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
+			i.FailCause = failCause
+			return retryableIfNetwork(err)
+		}
+		if rs.Logs == nil || len(rs.Logs.CommitEntrys) < 1 {
+			failCause := util.Translation("get code commit info error")
+			i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
+			i.FailCause = failCause
+			return fmt.Errorf("no commit found in hg repository %s", i.CodeSouceInfo.RepositoryURL)
+		}
+		i.commit = Commit{
+			Hash:    rs.Logs.CommitEntrys[0].Revision,
+			Message: rs.Logs.CommitEntrys[0].Msg,
+			Author:  rs.Logs.CommitEntrys[0].Author,
+		}
+	case "archive":
+		info, err := sources.NewArchiveSource(i.CodeSouceInfo, rbi.GetCodeHome(), i.Logger).Fetch()
+		if err != nil {
+			logrus.Errorf("download archive code error: %s", err.Error())
+			failCause := util.Translation("Download archive failed, please check the archive url and checksum")
+			i.Logger.Error(fmt.Sprintf("%s: %s", failCause, err.Error()), map[string]string{"step": "builder-exector", "status": "failure"})
+			i.FailCause = failCause
+			return retryableIfNetwork(err)
+		}
+		i.Logger.Info(fmt.Sprintf("archive %s digest %s", info.FileName, info.Digest), map[string]string{"step": "code-version"})
+		// code_version 字段长度为 40，与 git 提交一样记录摘要的前 40 位
+		hash := info.Digest[strings.Index(info.Digest, ":")+1:]
+		if len(hash) > 40 {
+			hash = hash[:40]
+		}
+		i.commit = Commit{
+			Hash:    hash,
+			Message: info.FileName,
+		}
 	case "oss":
 		i.commit = Commit{}
 	case "pkg":
@@ -365,7 +470,7 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 				i.FailCause = failCause
 				i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
 			}
-			return err
+			return retryableIfNetwork(err)
 		}
 		//get last commit
 		commit, err := sources.GetLastCommit(rs)
@@ -381,6 +486,7 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 			Author:  commit.Author.Name,
 			Message: commit.Message,
 		}
+		pathsChanged = i.pathsChanged(rs)
 	}
 	// clean cache code
 	defer func() {
@@ -397,6 +503,10 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 	}
 	info := fmt.Sprintf("CodeVersion:%s Author:%s Commit:%s ", hash, i.commit.Author, i.commit.Message)
 	i.Logger.Info(info, map[string]string{"step": "code-version"})
+	if !pathsChanged {
+		i.Logger.Info(fmt.Sprintf("No changes under the component paths between %s and %s, skip build", shortCommit(i.CodeSouceInfo.CommitBefore), hash), map[string]string{"step": "build-skipped", "status": "success"})
+		return errBuildSkipped
+	}
 	if _, ok := i.BuildEnvs["REPARSE"]; ok {
 		_, lang, err := parser.ReadRbdConfigAndLang(rbi)
 		if err != nil {
@@ -404,11 +514,24 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 			logrus.Errorf("reparse code lange error %s", err.Error())
 			i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
 			i.FailCause = failCause
-			return err
+			return mqclient.NonRetryable(err)
 		}
 		i.Lang = string(lang)
 	}
 
+	testStage, err := preBuildTestStage(i.Lang, rbi.GetCodeBuildAbsPath(), i.BuildEnvs)
+	if err != nil {
+		failCause := util.Translation("Pre-build test failed, please check the test logs")
+		i.Logger.Error(fmt.Sprintf("%s: %s", failCause, err.Error()), map[string]string{"step": pipelineStageTest, "status": "failure"})
+		i.FailCause = failCause
+		return mqclient.NonRetryable(err)
+	}
+	if testStage != nil {
+		if err := i.runPipelineStage(testStage, "Pre-build test failed, please check the test logs"); err != nil {
+			return err
+		}
+	}
+
 	i.Logger.Info("pull or clone code successfully, start code build", map[string]string{"step": "codee-version"})
 	res, err := i.codeBuild()
 	if err != nil {
@@ -423,6 +546,30 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 		}
 		return err
 	}
+	if res.MediumType == build.ImageMediumType {
+		i.archDigests = archDigests(res.MediumPath, i.Platforms, i.Logger)
+		if smokeStage := smokeTestStage(res.MediumPath, i.BuildEnvs); smokeStage != nil {
+			if err := i.runPipelineStage(smokeStage, "Post-build smoke test failed, please check the test logs"); err != nil {
+				return err
+			}
+		}
+		i.signed = signPushedImage(defaultImageSignConfig(), res.MediumPath, i.Logger)
+		s := &imageSBOM{
+			ServiceID:    i.ServiceID,
+			EventID:      i.EventID,
+			BuildVersion: i.DeployVersion,
+			ImageName:    res.MediumPath,
+			SourceDir:    rbi.GetCodeBuildAbsPath(),
+			Logger:       i.Logger,
+			config:       defaultSBOMConfig(),
+		}
+		if err := s.generate(); err != nil {
+			failCause := fmt.Sprintf("%s: %s", util.Translation("Image vulnerability check failed"), err.Error())
+			i.Logger.Error(failCause, map[string]string{"step": "build-sbom", "status": "failure"})
+			i.FailCause = failCause
+			return err
+		}
+	}
 	if err := i.UpdateBuildVersionInfo(res); err != nil {
 		return err
 	}
@@ -431,7 +578,7 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 
 func (i *SourceCodeBuildItem) codeBuild() (*build.Response, error) {
 	if err := i.validateCNBVersionPolicy(); err != nil {
-		return nil, err
+		return nil, mqclient.NonRetryable(err)
 	}
 	buildType := strings.TrimSpace(i.BuildStrategy)
 	if buildType == "" {
@@ -446,7 +593,7 @@ func (i *SourceCodeBuildItem) codeBuild() (*build.Response, error) {
 	if err != nil {
 		logrus.Errorf("get code build error: %s lang %s", err.Error(), i.Lang)
 		i.Logger.Error(util.Translation("No way of compiling to support this source type was found"), map[string]string{"step": "builder-exector", "status": "failure"})
-		return nil, err
+		return nil, mqclient.NonRetryable(err)
 	}
 	hostAlias, err := i.getHostAlias()
 	if err != nil {
@@ -485,6 +632,7 @@ func (i *SourceCodeBuildItem) codeBuild() (*build.Response, error) {
 		CacheMode:        i.CacheMode,
 		CachePath:        i.CachePath,
 		Arch:             i.Arch,
+		Platforms:        i.Platforms,
 		BRVersion:        i.BRVersion,
 	}
 	res, err := codeBuild.Build(buildReq)
@@ -599,6 +747,12 @@ func (i *SourceCodeBuildItem) UpdateVersionInfo(vi *dbmodel.VersionInfo) error {
 	if vi.FinalStatus != "" {
 		version.FinalStatus = vi.FinalStatus
 	}
+	if vi.SignatureStatus != "" {
+		version.ImageDigest = vi.ImageDigest
+		version.SignatureStatus = vi.SignatureStatus
+		version.SignatureMessage = vi.SignatureMessage
+	}
+	version.ArchDigests = vi.ArchDigests
 	version.CommitMsg = vi.CommitMsg
 	version.Author = vi.Author
 	version.CodeVersion = vi.CodeVersion
@@ -624,6 +778,8 @@ func (i *SourceCodeBuildItem) UpdateBuildVersionInfo(res *build.Response) error
 		Author:        i.commit.Author,
 		FinishTime:    time.Now(),
 	}
+	applySignatureResults(vi, nil, i.signed)
+	vi.SetArchDigests(i.archDigests)
 	if err := i.UpdateVersionInfo(vi); err != nil {
 		logrus.Errorf("update version info error: %s", err.Error())
 		i.Logger.Error(util.Translation("Update application service version information failed"), map[string]string{"step": "build-code", "status": "failure"})
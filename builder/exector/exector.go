@@ -21,8 +21,10 @@ package exector
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"runtime"
 	"runtime/debug"
@@ -65,6 +67,8 @@ var MetricBackTaskNum float64
 // Manager 任务执行管理器
 type Manager interface {
 	GetMaxConcurrentTask() float64
+	// GetTaskCredits 可以同时持有的任务数，包括为高优先级任务预留的并发
+	GetTaskCredits() int32
 	GetCurrentConcurrentTask() float64
 	AddTask(*pb.TaskMessage) error
 	SetReturnTaskChan(func(*pb.TaskMessage))
@@ -105,6 +109,10 @@ func NewManager() (Manager, error) {
 	numCPU := runtime.NumCPU()
 	// 示例逻辑：根据 CPU 核数设置基准最大并发数，实际中可以加上内存的判断
 	maxConcurrentTask := numCPU * 2
+	reservedTask := maxConcurrentTask / 4
+	if reservedTask < 1 {
+		reservedTask = 1
+	}
 	stop := make(chan struct{})
 	if err := job.InitJobController(configDefault.PublicConfig.RbdNamespace, stop, kubeClient); err != nil {
 		cancel()
@@ -119,8 +127,9 @@ func NewManager() (Manager, error) {
 		KubeClient:        kubeClient,
 		RainbondClient:    rainbondClient,
 		mqClient:          mq.Default().MqClient,
-		tasks:             make(chan *pb.TaskMessage, maxConcurrentTask),
+		tasks:             make(chan *pb.TaskMessage, maxConcurrentTask+reservedTask),
 		maxConcurrentTask: maxConcurrentTask,
+		reservedTask:      reservedTask,
 		ctx:               ctx,
 		cancel:            cancel,
 		imageClient:       imageClient,
@@ -136,11 +145,13 @@ type exectorManager struct {
 	tasks             chan *pb.TaskMessage
 	callback          func(*pb.TaskMessage)
 	maxConcurrentTask int
-	mqClient          mqclient.MQClient
-	ctx               context.Context
-	cancel            context.CancelFunc
-	runningTask       sync.Map
-	imageClient       sources.ImageClient
+	// reservedTask 为高优先级任务预留的并发数，普通任务占满并发时回滚、紧急修复仍可执行
+	reservedTask int
+	mqClient     mqclient.MQClient
+	ctx          context.Context
+	cancel       context.CancelFunc
+	runningTask  sync.Map
+	imageClient  sources.ImageClient
 }
 
 // TaskWorker worker interface
@@ -177,11 +188,14 @@ func (e *exectorManager) SetReturnTaskChan(re func(*pb.TaskMessage)) {
 // share-slug share app with slug
 // share-image share app with image
 // build_from_kubeblocks build app from kubeblocks, actually no build action, workload was managed by block-mechanica
+//
+// 执行器无法处理的任务由 callback 以 requeue 退回消息队列，其余任务执行结束后由 finish 确认消息
 func (e *exectorManager) AddTask(task *pb.TaskMessage) error {
 	if task.TaskType == "" {
+		e.finish(task, fmt.Errorf("task %s has no task type", task.TaskId))
 		return nil
 	}
-	if e.callback != nil && len(e.tasks) > e.maxConcurrentTask {
+	if e.callback != nil && len(e.tasks) >= e.taskLimit(task) {
 		e.callback(task)
 		time.Sleep(time.Second * 2)
 		MetricBackTaskNum++
@@ -215,6 +229,15 @@ func (e *exectorManager) AddTask(task *pb.TaskMessage) error {
 		return ErrCallback
 	}
 }
+
+// taskLimit 返回任务可以使用的并发上限，高优先级任务可以使用预留的并发
+func (e *exectorManager) taskLimit(task *pb.TaskMessage) int {
+	if task.Priority > mqclient.PriorityNormal {
+		return e.maxConcurrentTask + e.reservedTask
+	}
+	return e.maxConcurrentTask
+}
+
 func (e *exectorManager) runTask(f func(task *pb.TaskMessage), task *pb.TaskMessage, concurrencyControl bool) {
 	logrus.Infof("Build task %s in progress", task.TaskId)
 	e.runningTask.LoadOrStore(task.TaskId, task)
@@ -225,11 +248,14 @@ func (e *exectorManager) runTask(f func(task *pb.TaskMessage), task *pb.TaskMess
 	}
 	f(task)
 	e.runningTask.Delete(task.TaskId)
+	e.finish(task, nil)
 	logrus.Infof("Build task %s is completed", task.TaskId)
 }
 
 func (e *exectorManager) runTaskWithErr(f func(task *pb.TaskMessage) error, task *pb.TaskMessage, concurrencyControl bool) {
 	if task.TaskType == "" || task.TaskId == "" {
+		<-e.tasks
+		e.finish(task, fmt.Errorf("task type or task id is empty"))
 		return
 	}
 	logrus.Infof("Build task %s in progress", task.TaskId)
@@ -241,22 +267,64 @@ func (e *exectorManager) runTaskWithErr(f func(task *pb.TaskMessage) error, task
 	} else {
 		defer func() { <-e.tasks }()
 	}
-	if err := f(task); err != nil {
+	err := f(task)
+	if err != nil {
 		logrus.Errorf("[runTask] Task execution failed: task_id=%s, error=%s", task.TaskId, err.Error())
 	}
 	e.runningTask.Delete(task.TaskId)
+	e.finish(task, err)
 	logrus.Infof("[runTask] Task completed: task_id=%s", task.TaskId)
 }
+
+// finish 任务执行结束后确认消息，执行失败的任务按照消息队列的重试策略重新投递或进入死信主题
+func (e *exectorManager) finish(task *pb.TaskMessage, taskErr error) {
+	if task.MessageId == "" || e.mqClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	topic := configs.Default().ChaosConfig.Topic
+	if taskErr == nil {
+		if _, err := e.mqClient.Ack(ctx, &pb.AckRequest{Topic: topic, MessageId: task.MessageId}); err != nil {
+			logrus.Warningf("ack task(%s) message failure %s, it may be redelivered", task.TaskId, err.Error())
+		}
+		return
+	}
+	// 只有基础设施错误按重试策略重新投递，构建失败时版本信息和事件日志已经记录为失败，直接进入死信主题
+	nack := &pb.AckRequest{Topic: topic, MessageId: task.MessageId, Reason: taskErr.Error(), DeadLetter: !mqclient.IsRetryable(taskErr)}
+	if _, err := e.mqClient.Nack(ctx, nack); err != nil {
+		logrus.Errorf("nack task(%s) message failure %s, it will be redelivered after visibility timeout", task.TaskId, err.Error())
+	}
+}
+
+// networkErrors 代码仓库客户端在网络故障时返回的错误信息
+var networkErrors = []string{"connection refused", "connection reset", "i/o timeout", "no such host", "network is unreachable", "tls handshake timeout", "temporary failure in name resolution"}
+
+// retryableIfNetwork 拉取代码的网络错误标记为可重试，仓库地址、认证等错误重试也无法成功
+func retryableIfNetwork(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return mqclient.Retryable(err)
+	}
+	msg := strings.ToLower(err.Error())
+	for _, networkErr := range networkErrors {
+		if strings.Contains(msg, networkErr) {
+			return mqclient.Retryable(err)
+		}
+	}
+	return err
+}
+
 func (e *exectorManager) RunTask(task *pb.TaskMessage) {
 	logrus.Infof("[RunTask] Received task from MQ: task_id=%s, task_type=%s", task.TaskId, task.TaskType)
 
 	switch task.TaskType {
 	case "build_from_image":
-		go e.runTask(e.buildFromImage, task, false)
+		go e.runTaskWithErr(e.buildFromImage, task, false)
 	case "build_from_vm":
-		go e.runTask(e.buildFromVM, task, false)
+		go e.runTaskWithErr(e.buildFromVM, task, false)
 	case "build_from_source_code":
-		go e.runTask(e.buildFromSourceCode, task, true)
+		go e.runTaskWithErr(e.buildFromSourceCode, task, true)
 	case "build_from_market_slug":
 		//deprecated
 		go e.runTask(e.buildFromMarketSlug, task, false)
@@ -283,6 +351,7 @@ func (e *exectorManager) RunTask(task *pb.TaskMessage) {
 		// 直接忽略，从任务队列中移除即可
 		logrus.Info("[RunTask] Received warmup task, consumer loop is active")
 		<-e.tasks // 从队列中移除
+		e.finish(task, nil)
 	default:
 		if _, ok := workerCreaterList[task.TaskType]; ok {
 			go e.runTaskWithErr(e.exec, task, false)
@@ -321,7 +390,7 @@ func (e *exectorManager) exec(task *pb.TaskMessage) error {
 }
 
 // buildFromImage build app from docker image
-func (e *exectorManager) buildFromImage(task *pb.TaskMessage) {
+func (e *exectorManager) buildFromImage(task *pb.TaskMessage) (buildErr error) {
 	i := NewImageBuildItem(task.TaskBody)
 	i.ImageClient = e.imageClient
 	i.Logger.Info("Start with the image build application task", map[string]string{"step": "builder-exector", "status": "starting"})
@@ -331,6 +400,7 @@ func (e *exectorManager) buildFromImage(task *pb.TaskMessage) {
 			fmt.Println(r)
 			debug.PrintStack()
 			i.Logger.Error(util.Translation("Back end service drift. Please check the rbd-chaos log"), map[string]string{"step": "callback", "status": "failure"})
+			buildErr = fmt.Errorf("build from image panic: %v", r)
 		}
 	}()
 	start := time.Now()
@@ -349,6 +419,7 @@ func (e *exectorManager) buildFromImage(task *pb.TaskMessage) {
 				if err := i.UpdateVersionInfo("failure"); err != nil {
 					logrus.Debugf("update version Info error: %s", err.Error())
 				}
+				buildErr = err
 			}
 		} else {
 			var configs = make(map[string]string, len(i.Configs))
@@ -366,11 +437,12 @@ func (e *exectorManager) buildFromImage(task *pb.TaskMessage) {
 			break
 		}
 	}
+	return buildErr
 }
 
 // buildFromSourceCode build app from source code
 // support git repository
-func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
+func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) (buildErr error) {
 	// Check if this is a callback from source-scan
 	sourceScanCompleted := gjson.GetBytes(task.TaskBody, "source_scan_completed").Bool()
 	if sourceScanCompleted {
@@ -410,7 +482,7 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 			if err := e.updateSourceScanEvent(scanEventID, "failure", "complete", "源码安全检测未通过"); err != nil {
 				logrus.Errorf("Failed to update source scan event: %v", err)
 			}
-			return
+			return nil
 		}
 	} else {
 		// Check if source code scanning is required
@@ -451,7 +523,7 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 			} else {
 				logrus.Info("Task successfully forwarded to source-scan topic, waiting for scan result")
 				// Stop here, wait for scan result
-				return
+				return nil
 			}
 		} else {
 			// Source scan not needed, create build event if it was deferred
@@ -484,12 +556,29 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 			fmt.Println(r)
 			debug.PrintStack()
 			i.Logger.Error(util.Translation("Back end service drift. Please check the rbd-chaos log"), map[string]string{"step": "callback", "status": "failure"})
+			buildErr = fmt.Errorf("build from source code panic: %v", r)
 		}
 	}()
 	defer func() {
 		logrus.Debugf("Complete build from source code, consuming time %s", time.Now().Sub(start).String())
 	}()
 	err := i.Run(time.Minute * 30)
+	if errors.Is(err, errBuildSkipped) {
+		vi := &dbmodel.VersionInfo{
+			FinalStatus: "skipped",
+			EventID:     i.EventID,
+			CodeBranch:  i.CodeSouceInfo.Branch,
+			CodeVersion: i.commit.Hash,
+			CommitMsg:   i.commit.Message,
+			Author:      i.commit.Author,
+			FinishTime:  time.Now(),
+		}
+		if err := i.UpdateVersionInfo(vi); err != nil {
+			logrus.Errorf("update version Info error: %s", err.Error())
+		}
+		i.Logger.Info("Build skipped, the pushed commits do not change the component paths", event.GetLastLoggerOption())
+		return nil
+	}
 	if err != nil {
 		logrus.Errorf("build from source code error: %s", err.Error())
 		i.Logger.Error(i.FailCause, map[string]string{"step": "callback", "status": "failure"})
@@ -506,6 +595,7 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 			logrus.Errorf("update version Info error: %s", err.Error())
 			i.Logger.Error(fmt.Sprintf("error updating version info: %v", err), event.GetCallbackLoggerOption())
 		}
+		return err
 	} else {
 		var configs = make(map[string]string, len(i.Configs))
 		for k, v := range i.Configs {
@@ -513,17 +603,18 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 		}
 		if err := e.UpdateDeployVersion(i.ServiceID, i.DeployVersion); err != nil {
 			logrus.Errorf("Update app service deploy version failure %s, service %s do not auto upgrade", err.Error(), i.ServiceID)
-			return
+			return nil
 		}
 		err = e.sendAction(i.TenantID, i.ServiceID, i.EventID, i.DeployVersion, i.Action, configs, i.Logger)
 		if err != nil {
 			i.Logger.Error("Send upgrade action failed", map[string]string{"step": "callback", "status": "failure"})
 		}
 	}
+	return nil
 }
 
 // buildFromVM build app from vm
-func (e *exectorManager) buildFromVM(task *pb.TaskMessage) {
+func (e *exectorManager) buildFromVM(task *pb.TaskMessage) (buildErr error) {
 	v := NewVMBuildItem(task.TaskBody)
 	v.ImageClient = e.imageClient
 	v.BuildKitImage = e.BuildKitImage
@@ -536,6 +627,7 @@ func (e *exectorManager) buildFromVM(task *pb.TaskMessage) {
 		if r := recover(); r != nil {
 			debug.PrintStack()
 			v.Logger.Error(util.Translation("Back end service drift. Please check the rbd-chaos log"), map[string]string{"step": "builder-exector", "status": "starting"})
+			buildErr = fmt.Errorf("build from vm panic: %v", r)
 		}
 	}()
 	start := time.Now()
@@ -550,7 +642,7 @@ func (e *exectorManager) buildFromVM(task *pb.TaskMessage) {
 			if updateErr := v.UpdateVersionInfo("failure"); updateErr != nil {
 				logrus.Debugf("update vm version info error: %s", updateErr.Error())
 			}
-			return
+			return err
 		}
 	}
 	var configs = make(map[string]string, len(v.Configs))
@@ -564,6 +656,7 @@ func (e *exectorManager) buildFromVM(task *pb.TaskMessage) {
 	if err != nil {
 		v.Logger.Error("Send upgrade action failed", map[string]string{"step": "callback", "status": "failure"})
 	}
+	return nil
 }
 
 // buildFromMarketSlug build app from market slug
@@ -832,6 +925,10 @@ func (e *exectorManager) GetMaxConcurrentTask() float64 {
 	return float64(e.maxConcurrentTask)
 }
 
+func (e *exectorManager) GetTaskCredits() int32 {
+	return int32(e.maxConcurrentTask + e.reservedTask)
+}
+
 func (e *exectorManager) GetCurrentConcurrentTask() float64 {
 	return float64(len(e.tasks))
 }
@@ -1024,6 +1121,8 @@ func (e *exectorManager) forwardToSourceScan(task *pb.TaskMessage, scanEventID s
 		TaskType: "source_code_scan",
 		TaskBody: taskBodyMap,
 		Arch:     task.Arch,
+		Priority: task.Priority,
+		TenantID: task.TenantId,
 	}
 
 	// Send to source-scan topic
@@ -2,11 +2,15 @@ package exector
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/goodrain/rainbond/event"
 	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	mqclient "github.com/goodrain/rainbond/mq/client"
 	"github.com/sirupsen/logrus"
 )
 
@@ -97,3 +101,44 @@ func TestRunTaskDoesNotWarnForRegisteredWorker(t *testing.T) {
 		t.Fatalf("expected registered worker to avoid unknown task warning, got logs: %s", got)
 	}
 }
+
+// capability_id: rainbond.mq.priority-fair-scheduling
+func TestAddTaskReservesSlotsForHighPriority(t *testing.T) {
+	var returned []*pb.TaskMessage
+	manager := &exectorManager{
+		tasks:             make(chan *pb.TaskMessage, 2),
+		maxConcurrentTask: 1,
+		reservedTask:      1,
+		callback:          func(task *pb.TaskMessage) { returned = append(returned, task) },
+	}
+	manager.tasks <- &pb.TaskMessage{TaskId: "running"}
+
+	if got := manager.taskLimit(&pb.TaskMessage{}); got != 1 {
+		t.Fatalf("normal task limit = %d, want 1", got)
+	}
+	high := &pb.TaskMessage{TaskId: "rollback", TaskType: "warmup", Priority: 1}
+	if err := manager.AddTask(high); err != nil {
+		t.Fatal(err)
+	}
+	if len(returned) != 0 {
+		t.Fatalf("high priority task should use the reserved slot, returned %v", returned)
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestRetryableIfNetwork(t *testing.T) {
+	for _, err := range []error{
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		fmt.Errorf("clone repository: dial tcp: lookup git.example.com: no such host"),
+	} {
+		if !mqclient.IsRetryable(retryableIfNetwork(err)) {
+			t.Fatalf("network error %v should be retried", err)
+		}
+	}
+	if mqclient.IsRetryable(retryableIfNetwork(errors.New("authentication required"))) {
+		t.Fatal("authentication failure should not be retried")
+	}
+	if mqclient.IsRetryable(mqclient.NonRetryable(mqclient.Retryable(errors.New("test failure")))) {
+		t.Fatal("non retryable mark should win over the retryable cause")
+	}
+}
@@ -0,0 +1,195 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/sources/signature"
+	"github.com/goodrain/rainbond/config/configs"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// 镜像签名验证模式
+const (
+	imageVerifyOff     = "off"
+	imageVerifyWarn    = "warn"
+	imageVerifyEnforce = "enforce"
+)
+
+// imageSignConfig 镜像签名与验证的配置
+type imageSignConfig struct {
+	SignKey    string
+	VerifyKey  string
+	VerifyMode string
+	Registries []string
+	// InsecureRegistries 使用 HTTP 或自签名证书的镜像仓库
+	InsecureRegistries []string
+}
+
+func defaultImageSignConfig() imageSignConfig {
+	cfg := configs.Default()
+	if cfg == nil || cfg.ChaosConfig == nil {
+		return imageSignConfig{}
+	}
+	return imageSignConfig{
+		SignKey:            cfg.ChaosConfig.ImageSignKey,
+		VerifyKey:          cfg.ChaosConfig.ImageVerifyKey,
+		VerifyMode:         strings.ToLower(cfg.ChaosConfig.ImageVerifyMode),
+		Registries:         splitRegistries(cfg.ChaosConfig.ImageVerifyRegistries),
+		InsecureRegistries: splitRegistries(cfg.ChaosConfig.ImageInsecureRegistries),
+	}
+}
+
+func splitRegistries(value string) []string {
+	var registries []string
+	for _, registry := range strings.Split(value, ",") {
+		if registry = strings.TrimSpace(registry); registry != "" {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
+
+// insecureRegistry 判断镜像所在仓库是否配置为不校验证书，集群镜像仓库始终使用自签名证书
+func (c imageSignConfig) insecureRegistry(image string) bool {
+	return signature.MatchRegistry(image, append([]string{builder.REGISTRYDOMAIN}, c.InsecureRegistries...))
+}
+
+// signImage 对镜像签名的实现，测试中可以替换
+var signImage = func(keyFile, image string, auth signature.Auth) (signature.Result, error) {
+	key, err := signature.LoadPrivateKey(keyFile, []byte(os.Getenv("COSIGN_PASSWORD")))
+	if err != nil {
+		return signature.Result{}, err
+	}
+	return signature.NewSigner(key).Sign(image, auth)
+}
+
+// verifyImage 验证镜像签名的实现，测试中可以替换
+var verifyImage = func(keyFile, image string, auth signature.Auth) (signature.Result, error) {
+	keys, err := signature.LoadPublicKeys(keyFile)
+	if err != nil {
+		return signature.Result{}, err
+	}
+	return signature.NewVerifier(keys).Verify(image, auth)
+}
+
+// signPushedImage 使用集群管理的私钥对构建推送到集群镜像仓库的镜像签名。
+// 签名失败不影响构建，结果记录在构建版本中。未配置签名私钥时返回 nil。
+func signPushedImage(cfg imageSignConfig, image string, logger event.Logger) *signature.Result {
+	if cfg.SignKey == "" {
+		return nil
+	}
+	result, err := signImage(cfg.SignKey, image, signature.Auth{Username: builder.REGISTRYUSER, Password: builder.REGISTRYPASS, Insecure: cfg.insecureRegistry(image)})
+	if err != nil {
+		logrus.Errorf("sign image %s failure: %s", image, err.Error())
+		logger.Error(fmt.Sprintf("Sign image %s failed: %s", image, err.Error()), map[string]string{"step": "image-signature"})
+		return &signature.Result{Status: signature.StatusError, Message: err.Error()}
+	}
+	logger.Info(fmt.Sprintf("Image %s signed, digest %s", image, result.Digest), map[string]string{"step": "image-signature"})
+	return &result
+}
+
+// verifySourceImage 验证来自配置镜像仓库的外部镜像签名。
+// enforce 模式下镜像未签名或签名不可信时返回错误；不需要验证时返回的结果为 nil。
+func verifySourceImage(cfg imageSignConfig, image string, auth signature.Auth, logger event.Logger) (*signature.Result, error) {
+	if cfg.VerifyMode == "" || cfg.VerifyMode == imageVerifyOff || !signature.MatchRegistry(image, cfg.Registries) {
+		return nil, nil
+	}
+	if cfg.VerifyKey == "" {
+		logger.Error("Image signature verification is enabled but no trusted public key is configured", map[string]string{"step": "image-signature"})
+		result := &signature.Result{Status: signature.StatusError, Message: "no trusted public key configured"}
+		if cfg.VerifyMode == imageVerifyEnforce {
+			return result, fmt.Errorf("verify signature of image %s: %s", image, result.Message)
+		}
+		return result, nil
+	}
+	auth.Insecure = cfg.insecureRegistry(image)
+	result, err := verifyImage(cfg.VerifyKey, image, auth)
+	if err != nil {
+		logrus.Errorf("verify signature of image %s failure: %s", image, err.Error())
+		result.Status = signature.StatusError
+		result.Message = err.Error()
+	}
+	if result.Verified() {
+		logger.Info(fmt.Sprintf("Signature of image %s verified, digest %s", image, result.Digest), map[string]string{"step": "image-signature"})
+		return &result, nil
+	}
+	logger.Error(fmt.Sprintf("Signature of image %s is %s: %s", image, result.Status, result.Message), map[string]string{"step": "image-signature"})
+	if cfg.VerifyMode == imageVerifyEnforce {
+		return &result, fmt.Errorf("image %s signature is %s: %s", image, result.Status, result.Message)
+	}
+	return &result, nil
+}
+
+// verifiedImageRef 返回签名验证通过的镜像摘要引用（repo@digest），未验证的镜像原样返回
+func verifiedImageRef(image string, verified *signature.Result) (string, error) {
+	if verified == nil || !verified.Verified() || verified.Digest == "" {
+		return image, nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("parse image name %s: %v", image, err)
+	}
+	dgst, err := digest.Parse(verified.Digest)
+	if err != nil {
+		return "", fmt.Errorf("parse verified digest %s of image %s: %v", verified.Digest, image, err)
+	}
+	canonical, err := reference.WithDigest(reference.TrimNamed(named), dgst)
+	if err != nil {
+		return "", err
+	}
+	return canonical.String(), nil
+}
+
+// applySignatureResults 将签名验证结果记录到构建版本，外部镜像的验证结果优先于构建产物的签名结果
+func applySignatureResults(version *dbmodel.VersionInfo, verified, signed *signature.Result) {
+	if verified == nil && signed == nil {
+		return
+	}
+	version.SignatureStatus = ""
+	var messages []string
+	for _, result := range []*signature.Result{verified, signed} {
+		if result == nil {
+			continue
+		}
+		if version.SignatureStatus == "" {
+			version.SignatureStatus = result.Status
+		}
+		if result.Digest != "" {
+			version.ImageDigest = result.Digest
+		}
+		if result.Message != "" {
+			messages = append(messages, result.Message)
+		}
+	}
+	if message := strings.Join(messages, "; "); message != "" {
+		if len(message) > 1024 {
+			message = message[:1024]
+		}
+		version.SignatureMessage = message
+	}
+}
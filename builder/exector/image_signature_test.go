@@ -0,0 +1,102 @@
+package exector
+
+import (
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/sources/signature"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+)
+
+// capability_id: rainbond.image-signature.enforce
+func TestVerifySourceImageModes(t *testing.T) {
+	previous := verifyImage
+	defer func() { verifyImage = previous }()
+	status := signature.StatusUnsigned
+	calls := 0
+	verifyImage = func(keyFile, image string, auth signature.Auth) (signature.Result, error) {
+		calls++
+		return signature.Result{Status: status, Digest: "sha256:abc", Message: status}, nil
+	}
+	logger := event.GetTestLogger()
+	cfg := imageSignConfig{VerifyKey: "/keys/cosign.pub", VerifyMode: imageVerifyEnforce, Registries: []string{"harbor.example.com"}}
+
+	if result, err := verifySourceImage(cfg, "docker.io/library/nginx:latest", signature.Auth{}, logger); result != nil || err != nil || calls != 0 {
+		t.Fatalf("images from other registries should not be verified, got %+v err=%v", result, err)
+	}
+	result, err := verifySourceImage(cfg, "harbor.example.com/demo/app:v1", signature.Auth{}, logger)
+	if err == nil || result == nil || result.Status != signature.StatusUnsigned {
+		t.Fatalf("enforce mode should reject unsigned image, got %+v err=%v", result, err)
+	}
+	status = signature.StatusInvalid
+	cfg.VerifyMode = imageVerifyWarn
+	if result, err := verifySourceImage(cfg, "harbor.example.com/demo/app:v1", signature.Auth{}, logger); err != nil || result.Status != signature.StatusInvalid {
+		t.Fatalf("warn mode should only record the result, got %+v err=%v", result, err)
+	}
+	status = signature.StatusVerified
+	cfg.VerifyMode = imageVerifyEnforce
+	if result, err := verifySourceImage(cfg, "harbor.example.com/demo/app:v1", signature.Auth{}, logger); err != nil || result.Status != signature.StatusVerified {
+		t.Fatalf("enforce mode should accept verified image, got %+v err=%v", result, err)
+	}
+	cfg.VerifyKey = ""
+	if _, err := verifySourceImage(cfg, "harbor.example.com/demo/app:v1", signature.Auth{}, logger); err == nil {
+		t.Fatal("enforce mode without trusted keys should reject the image")
+	}
+}
+
+// capability_id: rainbond.image-signature.enforce
+func TestApplySignatureResults(t *testing.T) {
+	version := &dbmodel.VersionInfo{SignatureStatus: "stale"}
+	applySignatureResults(version,
+		&signature.Result{Status: signature.StatusVerified, Digest: "sha256:source", Message: "source verified"},
+		&signature.Result{Status: signature.StatusSigned, Digest: "sha256:pushed", Message: "signature pushed"})
+	if version.SignatureStatus != signature.StatusVerified || version.ImageDigest != "sha256:pushed" || version.SignatureMessage != "source verified; signature pushed" {
+		t.Fatalf("unexpected version signature fields: %+v", version)
+	}
+
+	version = &dbmodel.VersionInfo{}
+	applySignatureResults(version, nil, nil)
+	if version.SignatureStatus != "" {
+		t.Fatalf("expected no signature status, got %q", version.SignatureStatus)
+	}
+}
+
+// capability_id: rainbond.image-signature.enforce
+func TestVerifiedImageRef(t *testing.T) {
+	const dgst = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	image, err := verifiedImageRef("harbor.example.com/demo/app:v1", &signature.Result{Status: signature.StatusVerified, Digest: dgst})
+	if err != nil || image != "harbor.example.com/demo/app@"+dgst {
+		t.Fatalf("expected a digest reference, got %q err=%v", image, err)
+	}
+	image, err = verifiedImageRef("nginx:latest", &signature.Result{Status: signature.StatusVerified, Digest: dgst})
+	if err != nil || image != "docker.io/library/nginx@"+dgst {
+		t.Fatalf("expected a normalized digest reference, got %q err=%v", image, err)
+	}
+	for _, result := range []*signature.Result{nil, {Status: signature.StatusUnsigned, Digest: dgst}} {
+		if image, err := verifiedImageRef("nginx:latest", result); err != nil || image != "nginx:latest" {
+			t.Fatalf("unverified images should be pulled by tag, got %q err=%v", image, err)
+		}
+	}
+	if _, err := verifiedImageRef("nginx:latest", &signature.Result{Status: signature.StatusVerified, Digest: "bad"}); err == nil {
+		t.Fatal("expected an error for an invalid digest")
+	}
+}
+
+// capability_id: rainbond.image-signature.enforce
+func TestVerifySourceImageHonoursInsecureRegistries(t *testing.T) {
+	previous := verifyImage
+	defer func() { verifyImage = previous }()
+	var insecure bool
+	verifyImage = func(keyFile, image string, auth signature.Auth) (signature.Result, error) {
+		insecure = auth.Insecure
+		return signature.Result{Status: signature.StatusVerified}, nil
+	}
+	logger := event.GetTestLogger()
+	cfg := imageSignConfig{VerifyKey: "/keys/cosign.pub", VerifyMode: imageVerifyEnforce, Registries: []string{"*"}, InsecureRegistries: []string{"harbor.local"}}
+	if _, err := verifySourceImage(cfg, "harbor.example.com/demo/app:v1", signature.Auth{Insecure: true}, logger); err != nil || insecure {
+		t.Fatalf("expected certificates to be verified for secure registries, insecure=%v err=%v", insecure, err)
+	}
+	if _, err := verifySourceImage(cfg, "harbor.local/demo/app:v1", signature.Auth{}, logger); err != nil || !insecure {
+		t.Fatalf("expected certificate verification to be skipped for insecure registries, insecure=%v err=%v", insecure, err)
+	}
+}
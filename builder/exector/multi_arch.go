@@ -0,0 +1,67 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/sources"
+	"github.com/goodrain/rainbond/event"
+	"github.com/tidwall/gjson"
+)
+
+// imagePlatformDigests 读取多架构镜像各架构 digest 的实现，测试中可以替换
+var imagePlatformDigests = func(image string) (map[string]string, error) {
+	return sources.ImagePlatformDigests(image, builder.REGISTRYUSER, builder.REGISTRYPASS)
+}
+
+// parseBuildPlatforms 读取构建任务的目标平台，任务参数 platforms 优先，其次是构建环境变量 BUILD_PLATFORMS
+func parseBuildPlatforms(in []byte, envs map[string]string) []string {
+	platforms := sources.NormalizePlatforms(gjsonStrings(gjson.GetBytes(in, "platforms"))...)
+	if len(platforms) == 0 {
+		platforms = sources.NormalizePlatforms(firstNonEmptyBuildEnv(envs, "PLATFORMS", "BUILD_PLATFORMS"))
+	}
+	return platforms
+}
+
+// archDigests 多平台构建完成后读取镜像 index 中各架构的 digest，
+// 读取失败不影响构建结果，组件仍按构建节点的架构调度。
+func archDigests(image string, platforms []string, logger event.Logger) map[string]string {
+	if len(platforms) < 2 {
+		return nil
+	}
+	digests, err := imagePlatformDigests(image)
+	if err != nil {
+		logger.Error(fmt.Sprintf("get platform digests of image %s failure: %s", image, err.Error()), map[string]string{"step": "builder-exector"})
+		return nil
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+	arches := make([]string, 0, len(digests))
+	for arch := range digests {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	logger.Info(fmt.Sprintf("image %s is available for %s", image, strings.Join(arches, ",")), map[string]string{"step": "builder-exector"})
+	return digests
+}
@@ -0,0 +1,56 @@
+package exector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+)
+
+// capability_id: rainbond.multi-arch.source-build
+func TestParseBuildPlatforms(t *testing.T) {
+	platforms := parseBuildPlatforms([]byte(`{"platforms":["arm64","linux/amd64"]}`), map[string]string{"BUILD_PLATFORMS": "linux/riscv64"})
+	if strings.Join(platforms, ",") != "linux/amd64,linux/arm64" {
+		t.Fatalf("task platforms should take precedence, got %v", platforms)
+	}
+	platforms = parseBuildPlatforms([]byte(`{}`), map[string]string{"BUILD_PLATFORMS": "amd64,arm64"})
+	if strings.Join(platforms, ",") != "linux/amd64,linux/arm64" {
+		t.Fatalf("unexpected platforms from build env %v", platforms)
+	}
+	if platforms := parseBuildPlatforms([]byte(`{}`), nil); platforms != nil {
+		t.Fatalf("expected no platforms, got %v", platforms)
+	}
+}
+
+// capability_id: rainbond.multi-arch.source-build
+func TestArchDigestsRecordedOnVersion(t *testing.T) {
+	previous := imagePlatformDigests
+	defer func() { imagePlatformDigests = previous }()
+	calls := 0
+	imagePlatformDigests = func(image string) (map[string]string, error) {
+		calls++
+		if image == "goodrain.me/broken:v1" {
+			return nil, fmt.Errorf("registry unavailable")
+		}
+		return map[string]string{"arm64": "sha256:arm", "amd64": "sha256:amd"}, nil
+	}
+	logger := event.GetTestLogger()
+
+	if digests := archDigests("goodrain.me/app:v1", []string{"linux/amd64"}, logger); digests != nil || calls != 0 {
+		t.Fatalf("single platform build should not read the index, got %v", digests)
+	}
+	if digests := archDigests("goodrain.me/broken:v1", []string{"linux/amd64", "linux/arm64"}, logger); digests != nil {
+		t.Fatalf("expected no digests when the registry fails, got %v", digests)
+	}
+	digests := archDigests("goodrain.me/app:v1", []string{"linux/amd64", "linux/arm64"}, logger)
+	version := &dbmodel.VersionInfo{}
+	version.SetArchDigests(digests)
+	if arches := version.Arches(); strings.Join(arches, ",") != "amd64,arm64" {
+		t.Fatalf("unexpected version arches %v", arches)
+	}
+	if version.GetArchDigests()["arm64"] != "sha256:arm" {
+		t.Fatalf("unexpected version digests %s", version.ArchDigests)
+	}
+}
@@ -0,0 +1,264 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eapache/channels"
+	jobc "github.com/goodrain/rainbond/builder/job"
+	"github.com/goodrain/rainbond/builder/parser/code"
+	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
+	"github.com/goodrain/rainbond/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 构建流水线阶段
+const (
+	// pipelineStageTest 构建前在语言构建镜像中运行测试命令
+	pipelineStageTest = "build-test"
+	// pipelineStageSmoke 构建后运行产出镜像进行冒烟测试
+	pipelineStageSmoke = "build-smoke-test"
+)
+
+// defaultStageTimeout 测试阶段的默认超时时间
+var defaultStageTimeout = 30 * time.Minute
+
+// languageBuildImages 运行构建前测试使用的语言构建镜像，可以通过 BUILD_TEST_IMAGE 覆盖
+var languageBuildImages = map[code.Lang]string{
+	code.Nodejs:       "node:${RUNTIMES:20}-bullseye-slim",
+	code.NodeJSStatic: "node:${RUNTIMES:20}-bullseye-slim",
+	code.Python:       "python:3.11-slim",
+	code.JavaMaven:    "maven:3.9-eclipse-temurin-17",
+	code.JaveWar:      "maven:3.9-eclipse-temurin-17",
+	code.JavaJar:      "eclipse-temurin:17-jdk",
+	code.Gradle:       "gradle:8-jdk17",
+	code.Golang:       "golang:1.22",
+	code.PHP:          "php:8.2-cli",
+	code.Ruby:         "ruby:3.3",
+	code.NetCore:      "mcr.microsoft.com/dotnet/sdk:8.0",
+	code.Rust:         "rust:1",
+	code.Elixir:       "elixir:1.16",
+}
+
+// pipelineStage 组件构建流水线中可选的测试阶段，通过构建环境变量声明
+type pipelineStage struct {
+	// Name 阶段名称，同时作为事件日志的 step
+	Name    string
+	Image   string
+	Command string
+	// SourceDir 构建前测试挂载的源码目录，冒烟测试为空
+	SourceDir string
+	Timeout   time.Duration
+}
+
+// languageBuildImage 返回检测到的语言对应的构建镜像，组合语言（如 Node.js,static）使用第一个有镜像的语言
+func languageBuildImage(lang string, envs map[string]string) string {
+	for _, l := range strings.Split(lang, ",") {
+		if image, ok := languageBuildImages[code.Lang(strings.TrimSpace(l))]; ok {
+			return util.ParseVariable(image, envs)
+		}
+	}
+	return ""
+}
+
+func stageTimeout(envs map[string]string) time.Duration {
+	if value := firstNonEmptyBuildEnv(envs, "TEST_TIMEOUT", "BUILD_TEST_TIMEOUT"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultStageTimeout
+}
+
+// preBuildTestStage 读取构建前测试阶段，BUILD_TEST_COMMAND 为空时不运行
+func preBuildTestStage(lang, sourceDir string, envs map[string]string) (*pipelineStage, error) {
+	command := firstNonEmptyBuildEnv(envs, "TEST_COMMAND", "BUILD_TEST_COMMAND")
+	if command == "" {
+		return nil, nil
+	}
+	image := firstNonEmptyBuildEnv(envs, "TEST_IMAGE", "BUILD_TEST_IMAGE")
+	if image == "" {
+		image = languageBuildImage(lang, envs)
+	}
+	if image == "" {
+		return nil, mqclient.NonRetryable(fmt.Errorf("no build image for language %s, please set BUILD_TEST_IMAGE", lang))
+	}
+	return &pipelineStage{
+		Name:      pipelineStageTest,
+		Image:     image,
+		Command:   command,
+		SourceDir: sourceDir,
+		Timeout:   stageTimeout(envs),
+	}, nil
+}
+
+// smokeTestStage 读取构建后冒烟测试阶段，BUILD_SMOKE_TEST_COMMAND 为空时不运行
+func smokeTestStage(image string, envs map[string]string) *pipelineStage {
+	command := firstNonEmptyBuildEnv(envs, "SMOKE_TEST_COMMAND", "BUILD_SMOKE_TEST_COMMAND")
+	if command == "" {
+		return nil
+	}
+	return &pipelineStage{
+		Name:    pipelineStageSmoke,
+		Image:   image,
+		Command: command,
+		Timeout: stageTimeout(envs),
+	}
+}
+
+// stagePod 创建运行测试阶段的 job。构建前测试将源码复制到临时目录后执行，避免测试产物进入构建上下文；
+// job 调度到当前构建节点，与其他构建 job 一样由 builder/job 控制器跟踪状态。
+func stagePod(stage *pipelineStage, namespace, serviceID, deployVersion, arch string) *corev1.Pod {
+	name := fmt.Sprintf("%s-%s-%s", serviceID, deployVersion, strings.TrimPrefix(stage.Name, "build-"))
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"service": serviceID,
+				"job":     "codebuild",
+				"stage":   stage.Name,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    stage.Name,
+				Image:   stage.Image,
+				Command: []string{"/bin/sh", "-c", stage.Command},
+			}},
+		},
+	}
+	if stage.SourceDir != "" {
+		hostPathType := corev1.HostPathDirectory
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "source",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: path.Join("/opt/rainbond/", stage.SourceDir),
+						Type: &hostPathType,
+					},
+				},
+			},
+			{
+				Name:         "workspace",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		}
+		pod.Spec.InitContainers = []corev1.Container{{
+			Name:    "prepare-source",
+			Image:   stage.Image,
+			Command: []string{"/bin/sh", "-c", "cp -a /source/. /workspace/"},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "source", MountPath: "/source", ReadOnly: true},
+				{Name: "workspace", MountPath: "/workspace"},
+			},
+		}}
+		pod.Spec.Containers[0].WorkingDir = "/workspace"
+		pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "workspace", MountPath: "/workspace"}}
+		if hostIP := os.Getenv("HOST_IP"); hostIP != "" {
+			pod.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": hostIP}
+			pod.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+		}
+	} else if arch != "" {
+		// 冒烟测试运行构建节点架构的镜像
+		pod.Spec.NodeSelector = map[string]string{"kubernetes.io/arch": arch}
+	}
+	if imagePullSecretName := os.Getenv("IMAGE_PULL_SECRET"); imagePullSecretName != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: imagePullSecretName}}
+	}
+	return pod
+}
+
+// errStageJobFailed 测试阶段的命令执行失败，重新构建也会失败
+var errStageJobFailed = errors.New("job exec failure")
+
+// runStageJob 运行测试阶段 job 并等待结束，测试中可以替换
+var runStageJob = func(ctx context.Context, pod *corev1.Pod, logger event.Logger, timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	writer := logger.GetWriter("builder", "info")
+	reChan := channels.NewRingChannel(10)
+	if err := jobc.GetJobController().ExecJob(ctx, pod, writer, reChan); err != nil {
+		return fmt.Errorf("create job %s: %v", pod.Name, err)
+	}
+	defer jobc.GetJobController().DeleteJob(pod.Name)
+	return waitStageJob(reChan, timeout)
+}
+
+// waitStageJob 等待 job 结束且日志读取完成
+func waitStageJob(reChan *channels.RingChannel, timeout time.Duration) (err error) {
+	var logComplete, jobComplete bool
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return fmt.Errorf("job time out (more than %s)", timeout)
+		case status := <-reChan.Out():
+			switch status.(string) {
+			case "complete":
+				jobComplete = true
+			case "failed":
+				jobComplete = true
+				err = errStageJobFailed
+			case "cancel":
+				jobComplete = true
+				err = fmt.Errorf("job is canceled")
+			case "logcomplete":
+				logComplete = true
+			}
+			if jobComplete && logComplete {
+				return err
+			}
+		}
+	}
+}
+
+// runPipelineStage 运行测试阶段，失败时以阶段名称作为事件 step 上报，并终止本次构建和部署
+func (i *SourceCodeBuildItem) runPipelineStage(stage *pipelineStage, failMessage string) error {
+	i.Logger.Info(fmt.Sprintf("start %s with image %s: %s", stage.Name, stage.Image, stage.Command), map[string]string{"step": stage.Name})
+	ctx := i.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pod := stagePod(stage, i.RbdNamespace, i.ServiceID, i.DeployVersion, i.Arch)
+	if err := runStageJob(ctx, pod, i.Logger, stage.Timeout); err != nil {
+		failCause := util.Translation(failMessage)
+		i.Logger.Error(failCause, map[string]string{"step": stage.Name, "status": "failure"})
+		i.FailCause = failCause
+		if errors.Is(err, errStageJobFailed) {
+			return mqclient.NonRetryable(fmt.Errorf("%s: %w", stage.Name, err))
+		}
+		return fmt.Errorf("%s: %v", stage.Name, err)
+	}
+	i.Logger.Info(fmt.Sprintf("%s passed", stage.Name), map[string]string{"step": stage.Name, "status": "success"})
+	return nil
+}
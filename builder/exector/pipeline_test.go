@@ -0,0 +1,114 @@
+package exector
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eapache/channels"
+	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// capability_id: rainbond.build-pipeline.stages
+func TestPreBuildTestStage(t *testing.T) {
+	if stage, err := preBuildTestStage("Node.js", "/grdata/source/app", map[string]string{}); stage != nil || err != nil {
+		t.Fatalf("no test command should skip the stage, got %+v err=%v", stage, err)
+	}
+	stage, err := preBuildTestStage("Node.js,static", "/grdata/source/app", map[string]string{"BUILD_TEST_COMMAND": "npm ci && npm test", "RUNTIMES": "18", "BUILD_TEST_TIMEOUT": "120"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stage.Image != "node:18-bullseye-slim" || stage.Command != "npm ci && npm test" || stage.Timeout != 2*time.Minute || stage.Name != pipelineStageTest {
+		t.Fatalf("unexpected stage %+v", stage)
+	}
+	stage, err = preBuildTestStage("dockerfile", "/grdata/source/app", map[string]string{"BUILD_TEST_COMMAND": "make test", "BUILD_TEST_IMAGE": "golang:1.22"})
+	if err != nil || stage.Image != "golang:1.22" || stage.Timeout != defaultStageTimeout {
+		t.Fatalf("BUILD_TEST_IMAGE should be used, got %+v err=%v", stage, err)
+	}
+	if _, err := preBuildTestStage("dockerfile", "/grdata/source/app", map[string]string{"BUILD_TEST_COMMAND": "make test"}); err == nil || !mqclient.IsNonRetryable(err) {
+		t.Fatalf("language without build image should require BUILD_TEST_IMAGE without retrying, got %v", err)
+	}
+
+	if smokeTestStage("goodrain.me/app:v1", map[string]string{}) != nil {
+		t.Fatal("no smoke test command should skip the stage")
+	}
+	smoke := smokeTestStage("goodrain.me/app:v1", map[string]string{"SMOKE_TEST_COMMAND": "/app/server --version"})
+	if smoke == nil || smoke.Image != "goodrain.me/app:v1" || smoke.SourceDir != "" || smoke.Name != pipelineStageSmoke {
+		t.Fatalf("unexpected smoke stage %+v", smoke)
+	}
+}
+
+// capability_id: rainbond.build-pipeline.stages
+func TestStagePod(t *testing.T) {
+	t.Setenv("HOST_IP", "node1")
+	stage := &pipelineStage{Name: pipelineStageTest, Image: "node:20-bullseye-slim", Command: "npm test", SourceDir: "/grdata/source/build/t1/abc"}
+	pod := stagePod(stage, "rbd-system", "svc1", "20261017", "amd64")
+	if pod.Name != "svc1-20261017-test" || pod.Labels["job"] != "codebuild" || pod.Labels["service"] != "svc1" {
+		t.Fatalf("unexpected pod meta %+v", pod.ObjectMeta)
+	}
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever || pod.Spec.NodeSelector["kubernetes.io/hostname"] != "node1" {
+		t.Fatalf("test job should run once on the builder node, got %+v", pod.Spec)
+	}
+	if len(pod.Spec.InitContainers) != 1 || pod.Spec.Volumes[0].HostPath.Path != "/opt/rainbond/grdata/source/build/t1/abc" {
+		t.Fatalf("source should be copied from the builder host path, got %+v", pod.Spec)
+	}
+	container := pod.Spec.Containers[0]
+	if container.WorkingDir != "/workspace" || strings.Join(container.Command, " ") != "/bin/sh -c npm test" {
+		t.Fatalf("unexpected test container %+v", container)
+	}
+
+	smoke := stagePod(&pipelineStage{Name: pipelineStageSmoke, Image: "goodrain.me/app:v1", Command: "true"}, "rbd-system", "svc1", "20261017", "arm64")
+	if smoke.Name != "svc1-20261017-smoke-test" || len(smoke.Spec.Volumes) != 0 || smoke.Spec.NodeSelector["kubernetes.io/arch"] != "arm64" {
+		t.Fatalf("unexpected smoke test pod %+v", smoke)
+	}
+}
+
+// capability_id: rainbond.build-pipeline.stages
+func TestWaitStageJob(t *testing.T) {
+	reChan := channels.NewRingChannel(10)
+	reChan.In() <- "complete"
+	reChan.In() <- "logcomplete"
+	if err := waitStageJob(reChan, time.Second); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	reChan = channels.NewRingChannel(10)
+	reChan.In() <- "logcomplete"
+	reChan.In() <- "failed"
+	if err := waitStageJob(reChan, time.Second); err == nil {
+		t.Fatal("expected failed job error")
+	}
+	if err := waitStageJob(channels.NewRingChannel(10), 10*time.Millisecond); err == nil {
+		t.Fatal("expected timeout error")
+	}
+}
+
+// capability_id: rainbond.build-pipeline.stages
+func TestRunPipelineStageReportsFailure(t *testing.T) {
+	previous := runStageJob
+	defer func() { runStageJob = previous }()
+	var pods []string
+	runStageJob = func(ctx context.Context, pod *corev1.Pod, logger event.Logger, timeout time.Duration) error {
+		pods = append(pods, pod.Name)
+		if pod.Labels["stage"] == pipelineStageSmoke {
+			return errStageJobFailed
+		}
+		return nil
+	}
+	item := &SourceCodeBuildItem{ServiceID: "svc1", DeployVersion: "v1", RbdNamespace: "rbd-system", Logger: event.GetTestLogger()}
+	if err := item.runPipelineStage(&pipelineStage{Name: pipelineStageTest, Image: "node:20", Command: "npm test", SourceDir: "/src"}, "Pre-build test failed, please check the test logs"); err != nil {
+		t.Fatalf("expected test stage to pass, got %v", err)
+	}
+	err := item.runPipelineStage(&pipelineStage{Name: pipelineStageSmoke, Image: "goodrain.me/app:v1", Command: "false"}, "Post-build smoke test failed, please check the test logs")
+	if err == nil || !strings.Contains(err.Error(), pipelineStageSmoke) || item.FailCause == "" {
+		t.Fatalf("smoke test failure should stop the build, got err=%v fail cause=%q", err, item.FailCause)
+	}
+	if !mqclient.IsNonRetryable(err) {
+		t.Fatalf("failed stage should not be retried by the builder queue, got %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("expected two stage jobs, got %v", pods)
+	}
+}
@@ -0,0 +1,143 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/parser/code"
+	"github.com/goodrain/rainbond/builder/sbom"
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
+	"github.com/sirupsen/logrus"
+)
+
+// imagePackages 读取镜像中的系统软件包
+var imagePackages = sbom.ImagePackages
+
+// loadVulnDatabase 加载本地漏洞库
+var loadVulnDatabase = sbom.LoadDatabase
+
+// saveVersionSBOM 保存构建版本的 SBOM
+var saveVersionSBOM = func(s *dbmodel.VersionSBOM) error {
+	return db.GetManager().VersionSBOMDao().AddModel(s)
+}
+
+// sbomConfig SBOM 生成与漏洞检查的配置
+type sbomConfig struct {
+	Enabled    bool
+	VulnDBPath string
+	Policy     sbom.Policy
+}
+
+func defaultSBOMConfig() sbomConfig {
+	cfg := configs.Default()
+	if cfg == nil || cfg.ChaosConfig == nil {
+		return sbomConfig{}
+	}
+	return sbomConfig{
+		Enabled:    cfg.ChaosConfig.SBOM,
+		VulnDBPath: cfg.ChaosConfig.VulnDBPath,
+		Policy: sbom.Policy{
+			Mode:         strings.ToLower(cfg.ChaosConfig.VulnPolicy),
+			FailSeverity: cfg.ChaosConfig.VulnSeverity,
+		},
+	}
+}
+
+// imageSBOM 构建产出镜像的 SBOM 生成任务
+type imageSBOM struct {
+	ServiceID    string
+	EventID      string
+	BuildVersion string
+	ImageName    string
+	// SourceDir 源码目录，用于读取依赖锁文件，镜像构建时为空
+	SourceDir string
+	Logger    event.Logger
+	config    sbomConfig
+}
+
+// generate 生成镜像的 SBOM 并与构建版本一起保存。
+// SBOM 生成失败不影响构建，只有漏洞检查策略为 fail 且命中漏洞时返回错误。
+func (s *imageSBOM) generate() error {
+	if !s.config.Enabled {
+		return nil
+	}
+	s.Logger.Info("Start generating the software bill of materials of the image", map[string]string{"step": "build-sbom"})
+	var pkgs []sbom.Package
+	osPkgs, err := imagePackages(s.ImageName, builder.REGISTRYUSER, builder.REGISTRYPASS)
+	if err != nil {
+		logrus.Warningf("read packages of image %s failure: %s", s.ImageName, err.Error())
+		s.Logger.Info("Read system packages of the image failed, only the source dependencies are recorded", map[string]string{"step": "build-sbom"})
+	}
+	pkgs = append(pkgs, osPkgs...)
+	if s.SourceDir != "" {
+		pkgs = append(pkgs, sbom.FromDependencies(code.ListDependencies(s.SourceDir))...)
+	}
+	pkgs = sbom.Dedup(pkgs)
+
+	var findings []sbom.Finding
+	result := "skip"
+	var gateErr error
+	if s.config.VulnDBPath != "" && s.config.Policy.Mode != sbom.PolicyOff {
+		vulnDB, err := loadVulnDatabase(s.config.VulnDBPath)
+		if err != nil {
+			logrus.Errorf("load vulnerability database failure: %s", err.Error())
+			s.Logger.Error(fmt.Sprintf("Load vulnerability database %s failed, skip the vulnerability check", s.config.VulnDBPath), map[string]string{"step": "build-sbom"})
+		} else {
+			findings = vulnDB.Match(pkgs)
+			result, gateErr = s.config.Policy.Evaluate(findings)
+			// 漏洞策略拒绝的镜像重试也不会通过，直接进入死信主题
+			gateErr = mqclient.NonRetryable(gateErr)
+			for _, finding := range findings {
+				s.Logger.Info(fmt.Sprintf("Vulnerability %s (%s) found in %s@%s: %s", finding.ID, finding.Severity, finding.Package.Name, finding.Package.Version, finding.Summary), map[string]string{"step": "build-sbom"})
+			}
+		}
+	}
+
+	document, err := sbom.Generate(s.ImageName, pkgs, findings)
+	if err != nil {
+		logrus.Errorf("generate sbom of image %s failure: %s", s.ImageName, err.Error())
+		s.Logger.Error("Generate the software bill of materials failed", map[string]string{"step": "build-sbom"})
+		return gateErr
+	}
+	record := &dbmodel.VersionSBOM{
+		ServiceID:          s.ServiceID,
+		EventID:            s.EventID,
+		BuildVersion:       s.BuildVersion,
+		ImageName:          s.ImageName,
+		Format:             sbom.FormatCycloneDX,
+		Document:           string(document),
+		PackageCount:       len(pkgs),
+		VulnerabilityCount: len(findings),
+		PolicyResult:       result,
+	}
+	if err := saveVersionSBOM(record); err != nil {
+		logrus.Errorf("save sbom of image %s failure: %s", s.ImageName, err.Error())
+		s.Logger.Error("Save the software bill of materials failed", map[string]string{"step": "build-sbom"})
+	} else {
+		s.Logger.Info(fmt.Sprintf("Software bill of materials generated, %d packages, %d vulnerabilities, result %s", len(pkgs), len(findings), result), map[string]string{"step": "build-sbom"})
+	}
+	return gateErr
+}
@@ -0,0 +1,72 @@
+package exector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/sbom"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
+)
+
+func stubImageSBOM(t *testing.T, osPkgs []sbom.Package) *dbmodel.VersionSBOM {
+	previousPackages, previousSave := imagePackages, saveVersionSBOM
+	t.Cleanup(func() { imagePackages, saveVersionSBOM = previousPackages, previousSave })
+	saved := &dbmodel.VersionSBOM{}
+	imagePackages = func(imageName, user, password string) ([]sbom.Package, error) {
+		return osPkgs, nil
+	}
+	saveVersionSBOM = func(s *dbmodel.VersionSBOM) error {
+		*saved = *s
+		return nil
+	}
+	return saved
+}
+
+// capability_id: rainbond.sbom.vulnerability-gate
+func TestImageSBOMGenerateAppliesVulnerabilityPolicy(t *testing.T) {
+	saved := stubImageSBOM(t, []sbom.Package{{Name: "openssl", Version: "3.0.2-0ubuntu1", Type: "deb", Distro: "ubuntu"}})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("django==4.2.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vulnDB := filepath.Join(dir, "vulns.json")
+	if err := os.WriteFile(vulnDB, []byte(`{"vulnerabilities":[{"id":"CVE-2023-0002","ecosystem":"pypi","package":"django","severity":"high","ranges":[{"fixed":"4.2.2"}]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &imageSBOM{
+		ServiceID:    "service",
+		EventID:      "event",
+		BuildVersion: "20240101",
+		ImageName:    "goodrain.me/service:20240101",
+		SourceDir:    dir,
+		Logger:       event.GetTestLogger(),
+		config:       sbomConfig{Enabled: true, VulnDBPath: vulnDB, Policy: sbom.Policy{Mode: sbom.PolicyWarn}},
+	}
+	if err := s.generate(); err != nil {
+		t.Fatalf("warn policy should not fail the build: %v", err)
+	}
+	if saved.EventID != "event" || saved.PackageCount != 2 || saved.VulnerabilityCount != 1 || saved.PolicyResult != "warn" {
+		t.Fatalf("unexpected saved sbom: %+v", saved)
+	}
+
+	s.config.Policy = sbom.Policy{Mode: sbom.PolicyFail, FailSeverity: "high"}
+	if err := s.generate(); err == nil || !mqclient.IsNonRetryable(err) {
+		t.Fatalf("fail policy should fail the build without retry when a high vulnerability matches, got %v", err)
+	}
+	if saved.PolicyResult != "fail" || saved.Document == "" {
+		t.Fatalf("expected the failed sbom to be saved, got %+v", saved)
+	}
+}
+
+// capability_id: rainbond.sbom.vulnerability-gate
+func TestImageSBOMGenerateDisabled(t *testing.T) {
+	saved := stubImageSBOM(t, nil)
+	s := &imageSBOM{EventID: "event", Logger: event.GetTestLogger()}
+	if err := s.generate(); err != nil || saved.EventID != "" {
+		t.Fatalf("expected no sbom when disabled, got err=%v saved=%+v", err, saved)
+	}
+}
@@ -0,0 +1,91 @@
+package exector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/goodrain/rainbond/builder/sources"
+	"github.com/goodrain/rainbond/event"
+	"github.com/tidwall/gjson"
+)
+
+func commitSourceFile(t *testing.T, repo *git.Repository, dir, name, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := tree.Commit("update "+name, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()
+}
+
+// capability_id: rainbond.source-build.monorepo-paths
+func TestSourceCodeBuildItemPathsChanged(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := commitSourceFile(t, repo, dir, "services/api/main.go", "package main")
+	second := commitSourceFile(t, repo, dir, "services/worker/main.go", "package main")
+	third := commitSourceFile(t, repo, dir, "services/api/README.md", "# api")
+
+	item := &SourceCodeBuildItem{
+		ServiceID: "service-1",
+		Logger:    event.NewLogger("test-event", make(chan []byte, 100)),
+		CodeSouceInfo: sources.CodeSourceInfo{
+			SubDir:       "services/api",
+			ExcludePaths: []string{"**/*.md"},
+		},
+	}
+	if !item.pathsChanged(repo) {
+		t.Fatal("builds without a commit range must not be skipped")
+	}
+
+	item.CodeSouceInfo.CommitBefore, item.CodeSouceInfo.CommitAfter = first, second
+	if item.pathsChanged(repo) {
+		t.Fatal("changes of other services must skip the build")
+	}
+	item.CodeSouceInfo.CommitBefore, item.CodeSouceInfo.CommitAfter = second, third
+	if item.pathsChanged(repo) {
+		t.Fatal("excluded changes must skip the build")
+	}
+	item.CodeSouceInfo.CommitBefore = "0000000000000000000000000000000000000000"
+	if !item.pathsChanged(repo) {
+		t.Fatal("unknown commit range must build the component")
+	}
+
+	// 未指定推送后的提交时使用 HEAD
+	item.CodeSouceInfo.CommitBefore, item.CodeSouceInfo.CommitAfter = first, ""
+	item.CodeSouceInfo.ExcludePaths = nil
+	if !item.pathsChanged(repo) {
+		t.Fatal("changes under the sub directory must build the component")
+	}
+}
+
+// capability_id: rainbond.source-build.monorepo-paths
+func TestGjsonStrings(t *testing.T) {
+	values := gjsonStrings(gjson.Parse(`["services/api/**", " ", "libs/**"]`))
+	if len(values) != 2 || values[0] != "services/api/**" || values[1] != "libs/**" {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if values := gjsonStrings(gjson.Parse(`null`)); values != nil {
+		t.Fatalf("expected nil values, got %v", values)
+	}
+}
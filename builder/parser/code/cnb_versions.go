@@ -47,6 +47,20 @@ var cnbPythonVersions = []CNBVersion{
 	{Version: "3.14", Default: true},
 }
 
+// cnbRustVersions defines the supported Rust toolchain versions for CNB builds.
+var cnbRustVersions = []CNBVersion{
+	{Version: "1.86", Default: false},
+	{Version: "1.88", Default: false},
+	{Version: "1.90", Default: true},
+}
+
+// cnbElixirVersions defines the supported Elixir major.minor versions for CNB builds.
+var cnbElixirVersions = []CNBVersion{
+	{Version: "1.16", Default: false},
+	{Version: "1.17", Default: false},
+	{Version: "1.18", Default: true},
+}
+
 // GetCNBVersions returns the supported CNB versions for a given language.
 // Supports composite languages like "dockerfile,Node.js" by checking each part.
 func GetCNBVersions(lang string) []CNBVersion {
@@ -55,12 +69,16 @@ func GetCNBVersions(lang string) []CNBVersion {
 		switch strings.TrimSpace(part) {
 		case "nodejs", "node", "node.js":
 			return cnbNodeVersions
-		case "java", "openjdk", "java-maven", "java-war", "java-jar", "gradle", "java-gradle", "javagradle":
+		case "java", "openjdk", "java-maven", "java-war", "java-jar", "gradle", "java-gradle", "javagradle", "scala", "sbt":
 			return cnbJavaVersions
 		case "go", "golang":
 			return cnbGolangVersions
 		case "python":
 			return cnbPythonVersions
+		case "rust":
+			return cnbRustVersions
+		case "elixir":
+			return cnbElixirVersions
 		}
 	}
 	return []CNBVersion{}
@@ -137,6 +155,9 @@ func normalizeCNBVersionSpec(lang, spec string) (string, bool) {
 	case "go", "golang":
 		normalized, err := normalizeGolangRuntimeVersion(trimVersionSpecPrefixes(spec))
 		return normalized, err == nil
+	case "rust", "elixir":
+		normalized := majorMinor(trimVersionSpecPrefixes(spec))
+		return normalized, normalized != ""
 	default:
 		return "", false
 	}
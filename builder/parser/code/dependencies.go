@@ -19,12 +19,18 @@
 package code
 
 import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
 	"path"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/goodrain/rainbond/util"
 )
 
-//CheckDependencies check dependencies with lang
+// CheckDependencies check dependencies with lang
 func CheckDependencies(buildPath string, lang Lang) bool {
 	switch lang {
 	case PHP:
@@ -57,3 +63,311 @@ func CheckDependencies(buildPath string, lang Lang) bool {
 		return true
 	}
 }
+
+// Dependency 依赖锁文件中声明的依赖包
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Ecosystem 包管理生态，与 purl 的 type 一致，如 npm、pypi、maven、golang、cargo、composer
+	Ecosystem string `json:"ecosystem"`
+	// Source 声明该依赖的文件
+	Source string `json:"source"`
+}
+
+// dependencyParsers 依赖锁文件及其解析函数，按文件名顺序解析
+var dependencyParsers = []struct {
+	file      string
+	ecosystem string
+	parse     func(content string) [][2]string
+}{
+	{"package-lock.json", "npm", parsePackageLock},
+	{"yarn.lock", "npm", parseYarnLock},
+	{"pnpm-lock.yaml", "npm", parsePnpmLock},
+	{"requirements.txt", "pypi", parseRequirements},
+	{"Pipfile.lock", "pypi", parsePipfileLock},
+	{"poetry.lock", "pypi", parseTOMLPackages},
+	{"uv.lock", "pypi", parseTOMLPackages},
+	{"go.mod", "golang", parseGoMod},
+	{"Cargo.lock", "cargo", parseTOMLPackages},
+	{"composer.lock", "composer", parseComposerLock},
+	{"pom.xml", "maven", parsePom},
+	{"Gemfile.lock", "gem", parseGemfileLock},
+	{"mix.lock", "hex", parseMixLock},
+}
+
+// ListDependencies 解析代码目录中的依赖锁文件，返回去重并排序后的依赖列表
+func ListDependencies(buildPath string) []Dependency {
+	seen := make(map[string]bool)
+	var deps []Dependency
+	for _, parser := range dependencyParsers {
+		content := readFileString(buildPath, parser.file)
+		if content == "" {
+			continue
+		}
+		for _, nv := range parser.parse(content) {
+			if nv[0] == "" || nv[1] == "" {
+				continue
+			}
+			key := parser.ecosystem + "/" + nv[0] + "@" + nv[1]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			deps = append(deps, Dependency{Name: nv[0], Version: nv[1], Ecosystem: parser.ecosystem, Source: parser.file})
+		}
+	}
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Ecosystem != deps[j].Ecosystem {
+			return deps[i].Ecosystem < deps[j].Ecosystem
+		}
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Version < deps[j].Version
+	})
+	return deps
+}
+
+func parsePackageLock(content string) (deps [][2]string) {
+	var lock struct {
+		Packages map[string]struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+	// lockfileVersion 2、3 使用 packages，key 为 node_modules 路径
+	for key, pkg := range lock.Packages {
+		if key == "" {
+			continue
+		}
+		name := pkg.Name
+		if name == "" {
+			name = key[strings.LastIndex(key, "node_modules/")+len("node_modules/"):]
+		}
+		deps = append(deps, [2]string{name, pkg.Version})
+	}
+	if len(lock.Packages) == 0 {
+		for name, dep := range lock.Dependencies {
+			deps = append(deps, [2]string{name, dep.Version})
+		}
+	}
+	return deps
+}
+
+var yarnVersionRe = regexp.MustCompile(`^\s+version:?\s+"?([^"\s]+)"?`)
+
+func parseYarnLock(content string) (deps [][2]string) {
+	var names []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":") {
+			// "@babel/core@^7.0.0", "@babel/core@^7.1.0": 或 "lodash@npm:4.17.21":
+			names = names[:0]
+			for _, spec := range strings.Split(strings.TrimSuffix(line, ":"), ",") {
+				spec = strings.Trim(strings.TrimSpace(spec), `"`)
+				if i := strings.LastIndex(spec, "@"); i > 0 {
+					names = append(names, spec[:i])
+				}
+			}
+			continue
+		}
+		if matches := yarnVersionRe.FindStringSubmatch(line); len(matches) == 2 && len(names) > 0 {
+			deps = append(deps, [2]string{names[0], matches[1]})
+			names = names[:0]
+		}
+	}
+	return deps
+}
+
+var pnpmPackageRe = regexp.MustCompile(`^  /?'?((?:@[^/@\s]+/)?[^@\s/']+)[@/]([0-9][^(:'\s]*)`)
+
+func parsePnpmLock(content string) (deps [][2]string) {
+	inPackages := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") && line != "" {
+			inPackages = strings.HasPrefix(line, "packages:")
+			continue
+		}
+		if !inPackages {
+			continue
+		}
+		if matches := pnpmPackageRe.FindStringSubmatch(line); len(matches) == 3 {
+			deps = append(deps, [2]string{matches[1], matches[2]})
+		}
+	}
+	return deps
+}
+
+var requirementRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*===?\s*([^\s;#]+)`)
+
+func parseRequirements(content string) (deps [][2]string) {
+	for _, line := range strings.Split(content, "\n") {
+		if matches := requirementRe.FindStringSubmatch(strings.TrimSpace(line)); len(matches) == 3 {
+			deps = append(deps, [2]string{strings.ToLower(matches[1]), matches[2]})
+		}
+	}
+	return deps
+}
+
+func parsePipfileLock(content string) (deps [][2]string) {
+	var lock map[string]map[string]struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+	for _, section := range []string{"default", "develop"} {
+		for name, pkg := range lock[section] {
+			deps = append(deps, [2]string{strings.ToLower(name), strings.TrimPrefix(pkg.Version, "==")})
+		}
+	}
+	return deps
+}
+
+var tomlStringRe = regexp.MustCompile(`^(name|version)\s*=\s*"([^"]*)"`)
+
+// parseTOMLPackages 解析 Cargo.lock、poetry.lock、uv.lock 中的 [[package]] 表
+func parseTOMLPackages(content string) (deps [][2]string) {
+	var name, version string
+	inPackage := false
+	flush := func() {
+		if inPackage {
+			deps = append(deps, [2]string{name, version})
+		}
+		name, version = "", ""
+	}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			if line == "[[package]]" {
+				flush()
+				inPackage = true
+			} else if !strings.HasPrefix(line, "[package.") {
+				flush()
+				inPackage = false
+			}
+			continue
+		}
+		if !inPackage {
+			continue
+		}
+		if matches := tomlStringRe.FindStringSubmatch(line); len(matches) == 3 {
+			if matches[1] == "name" && name == "" {
+				name = matches[2]
+			} else if matches[1] == "version" && version == "" {
+				version = matches[2]
+			}
+		}
+	}
+	flush()
+	return deps
+}
+
+var goRequireRe = regexp.MustCompile(`^(?:require\s+)?([^\s()]+\.[^\s()]+)\s+(v[^\s]+)`)
+
+func parseGoMod(content string) (deps [][2]string) {
+	inRequire := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "require ("):
+			inRequire = true
+			continue
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case !inRequire && !strings.HasPrefix(line, "require "):
+			continue
+		}
+		if matches := goRequireRe.FindStringSubmatch(line); len(matches) == 3 {
+			deps = append(deps, [2]string{matches[1], matches[2]})
+		}
+	}
+	return deps
+}
+
+func parseComposerLock(content string) (deps [][2]string) {
+	var lock struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+		PackagesDev []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages-dev"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+	for _, pkg := range append(lock.Packages, lock.PackagesDev...) {
+		deps = append(deps, [2]string{pkg.Name, strings.TrimPrefix(pkg.Version, "v")})
+	}
+	return deps
+}
+
+// parsePom 解析 pom.xml 中显式声明版本的依赖，版本为属性引用时使用 properties 中的值
+func parsePom(content string) (deps [][2]string) {
+	var pom struct {
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Dependencies []struct {
+			GroupID    string `xml:"groupId"`
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal([]byte(content), &pom); err != nil {
+		return nil
+	}
+	props := make(map[string]string, len(pom.Properties.Entries))
+	for _, entry := range pom.Properties.Entries {
+		props[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	for _, dep := range pom.Dependencies {
+		version := strings.TrimSpace(dep.Version)
+		if strings.HasPrefix(version, "${") && strings.HasSuffix(version, "}") {
+			version = props[strings.TrimSuffix(strings.TrimPrefix(version, "${"), "}")]
+		}
+		deps = append(deps, [2]string{strings.TrimSpace(dep.GroupID) + ":" + strings.TrimSpace(dep.ArtifactID), version})
+	}
+	return deps
+}
+
+var gemSpecRe = regexp.MustCompile(`^    ([A-Za-z0-9._-]+) \(([^)\s]+)\)$`)
+
+func parseGemfileLock(content string) (deps [][2]string) {
+	for _, line := range strings.Split(content, "\n") {
+		if matches := gemSpecRe.FindStringSubmatch(strings.TrimRight(line, "\r")); len(matches) == 3 {
+			deps = append(deps, [2]string{matches[1], matches[2]})
+		}
+	}
+	return deps
+}
+
+var mixLockRe = regexp.MustCompile(`^\s*"([^"]+)":\s*\{:hex,\s*:[^,]+,\s*"([^"]+)"`)
+
+func parseMixLock(content string) (deps [][2]string) {
+	for _, line := range strings.Split(content, "\n") {
+		if matches := mixLockRe.FindStringSubmatch(line); len(matches) == 3 {
+			deps = append(deps, [2]string{matches[1], matches[2]})
+		}
+	}
+	return deps
+}
@@ -0,0 +1,87 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package code
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+// capability_id: rainbond.sbom.lockfile-dependencies
+func TestListDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"package-lock.json": `{"lockfileVersion":3,"packages":{"":{"name":"demo"},"node_modules/lodash":{"version":"4.17.20"},"node_modules/@babel/core":{"version":"7.22.0"}}}`,
+		"requirements.txt":  "Django==4.2.1\nrequests[socks]>=2.0\n# comment\nflask == 2.3.2 ; python_version > '3.8'\n",
+		"go.mod":            "module demo\n\ngo 1.21\n\nrequire (\n\tgithub.com/pkg/errors v0.9.1\n\tgolang.org/x/net v0.17.0 // indirect\n)\n\nrequire github.com/sirupsen/logrus v1.9.3\n",
+		"Cargo.lock":        "version = 3\n\n[[package]]\nname = \"serde\"\nversion = \"1.0.188\"\nsource = \"registry+https://github.com/rust-lang/crates.io-index\"\n\n[[package]]\nname = \"demo\"\nversion = \"0.1.0\"\n",
+		"pom.xml": `<project><properties><spring.version>5.3.20</spring.version></properties><dependencies>
+<dependency><groupId>org.springframework</groupId><artifactId>spring-core</artifactId><version>${spring.version}</version></dependency>
+<dependency><groupId>junit</groupId><artifactId>junit</artifactId></dependency>
+</dependencies></project>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(path.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(map[string]string)
+	for _, dep := range ListDependencies(tmpDir) {
+		got[dep.Ecosystem+"/"+dep.Name] = dep.Version
+	}
+	want := map[string]string{
+		"npm/lodash":                            "4.17.20",
+		"npm/@babel/core":                       "7.22.0",
+		"pypi/django":                           "4.2.1",
+		"pypi/flask":                            "2.3.2",
+		"golang/github.com/pkg/errors":          "v0.9.1",
+		"golang/golang.org/x/net":               "v0.17.0",
+		"golang/github.com/sirupsen/logrus":     "v1.9.3",
+		"cargo/serde":                           "1.0.188",
+		"cargo/demo":                            "0.1.0",
+		"maven/org.springframework:spring-core": "5.3.20",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d dependencies, got %d: %v", len(want), len(got), got)
+	}
+	for key, version := range want {
+		if got[key] != version {
+			t.Errorf("dependency %s: expected version %q, got %q", key, version, got[key])
+		}
+	}
+}
+
+// capability_id: rainbond.sbom.lockfile-dependencies
+func TestParseYarnLock(t *testing.T) {
+	content := `# yarn lockfile v1
+
+"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.10.4":
+  version "7.12.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz"
+
+lodash@^4.17.19:
+  version "4.17.21"
+`
+	deps := parseYarnLock(content)
+	if len(deps) != 2 || deps[0] != [2]string{"@babel/code-frame", "7.12.13"} || deps[1] != [2]string{"lodash", "4.17.21"} {
+		t.Fatalf("unexpected yarn dependencies: %v", deps)
+	}
+}
@@ -46,6 +46,8 @@ func init() {
 	checkFuncList = append(checkFuncList, grails)
 	checkFuncList = append(checkFuncList, scala)
 	checkFuncList = append(checkFuncList, netcore)
+	checkFuncList = append(checkFuncList, rust)
+	checkFuncList = append(checkFuncList, elixir)
 }
 
 // ErrCodeNotExist 代码为空错误
@@ -125,6 +127,15 @@ var Grails Lang = "Grails"
 // NetCore Lang
 var NetCore Lang = ".NetCore"
 
+// Rust Lang
+var Rust Lang = "Rust"
+
+// Scala Lang, 使用 sbt 构建
+var Scala Lang = "Scala"
+
+// Elixir Lang
+var Elixir Lang = "Elixir"
+
 // OSS Lang
 var OSS Lang = "OSS"
 
@@ -345,8 +356,30 @@ func netcore(homepath string) Lang {
 	return NO
 }
 
-// 暂时不支持
+// scala sbt 项目
 func scala(homepath string) Lang {
+	if ok, _ := util.FileExists(path.Join(homepath, "build.sbt")); ok {
+		return Scala
+	}
+	if ok, _ := util.FileExists(path.Join(homepath, "project", "build.properties")); ok {
+		if body, err := ioutil.ReadFile(path.Join(homepath, "project", "build.properties")); err == nil && strings.Contains(string(body), "sbt.version") {
+			return Scala
+		}
+	}
+	return NO
+}
+
+func rust(homepath string) Lang {
+	if ok, _ := util.FileExists(path.Join(homepath, "Cargo.toml")); ok {
+		return Rust
+	}
+	return NO
+}
+
+func elixir(homepath string) Lang {
+	if ok, _ := util.FileExists(path.Join(homepath, "mix.exs")); ok {
+		return Elixir
+	}
 	return NO
 }
 
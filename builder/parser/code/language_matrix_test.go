@@ -37,6 +37,10 @@ func TestGetLangType_SupportedSourceBuildLanguages(t *testing.T) {
 		{name: "nodejs", files: map[string]string{"package.json": "{\"name\":\"demo\"}\n"}, want: Nodejs},
 		{name: "static", files: map[string]string{"index.html": "<html></html>\n"}, want: Static},
 		{name: "netcore", files: map[string]string{"demo.csproj": "<Project />\n"}, want: NetCore},
+		{name: "rust", files: map[string]string{"Cargo.toml": "[package]\nname = \"demo\"\n"}, want: Rust},
+		{name: "scala-sbt", files: map[string]string{"build.sbt": "scalaVersion := \"3.3.3\"\n"}, want: Scala},
+		{name: "scala-sbt-properties", files: map[string]string{"project/build.properties": "sbt.version=1.10.1\n"}, want: Scala},
+		{name: "elixir", files: map[string]string{"mix.exs": "defmodule Demo.MixProject do\nend\n"}, want: Elixir},
 	}
 
 	for _, tt := range tests {
@@ -22,10 +22,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path"
+	"strings"
 
 	"github.com/goodrain/rainbond/util"
 	"github.com/sirupsen/logrus"
-	yaml "gopkg.in/yaml.v2"
 )
 
 //RainbondFileConfig 云帮源码配置文件
@@ -36,13 +36,19 @@ type RainbondFileConfig struct {
 	Envs      map[string]interface{} `yaml:"envs"`
 	Cmd       string                 `yaml:"cmd"`
 	Services  []*Service             `yaml:"services"`
+	// BuildEnvs 构建环境变量，未以 BUILD_ 开头的变量名会自动添加前缀
+	BuildEnvs map[string]interface{} `yaml:"build_envs"`
+	// CNBVersionPolicy CNB 构建的语言版本策略，key 为语言，如 nodejs、java
+	CNBVersionPolicy map[string]CNBLanguageVersion `yaml:"cnb_version_policy"`
+	DeploySpec       `yaml:",inline"`
 }
 
 // Service contains
 type Service struct {
-	Name  string            `yaml:"name"`
-	Ports []Port            `yaml:"ports"`
-	Envs  map[string]string `yaml:"envs"`
+	Name       string            `yaml:"name"`
+	Ports      []Port            `yaml:"ports"`
+	Envs       map[string]string `yaml:"envs"`
+	DeploySpec `yaml:",inline"`
 }
 
 //Port Port
@@ -51,7 +57,66 @@ type Port struct {
 	Protocol string `yaml:"protocol"`
 }
 
+// DeploySpec 组件部署属性，可以定义在顶层或 services 中
+type DeploySpec struct {
+	Probes    []Probe    `yaml:"probes"`
+	Resources *Resources `yaml:"resources"`
+	Volumes   []Volume   `yaml:"volumes"`
+	DependsOn []string   `yaml:"depends_on"`
+	Hooks     *Hooks     `yaml:"hooks"`
+}
+
+// CNBLanguageVersion CNB 构建某种语言允许使用的版本
+type CNBLanguageVersion struct {
+	Default string   `yaml:"default"`
+	Allowed []string `yaml:"allowed"`
+}
+
+// Probe 健康检测
+type Probe struct {
+	// Mode liveness 或 readiness，默认 readiness
+	Mode string `yaml:"mode"`
+	// Scheme tcp、http 或 cmd，默认 tcp
+	Scheme              string `yaml:"scheme"`
+	Port                int    `yaml:"port"`
+	Path                string `yaml:"path"`
+	Cmd                 string `yaml:"cmd"`
+	InitialDelaySeconds int    `yaml:"initial_delay_seconds"`
+	PeriodSeconds       int    `yaml:"period_seconds"`
+	TimeoutSeconds      int    `yaml:"timeout_seconds"`
+	FailureThreshold    int    `yaml:"failure_threshold"`
+	SuccessThreshold    int    `yaml:"success_threshold"`
+}
+
+// Resources 资源请求，格式与 Kubernetes 一致，如 cpu: 500m, memory: 1Gi
+type Resources struct {
+	CPU    string `yaml:"cpu"`
+	Memory string `yaml:"memory"`
+}
+
+// Volume 存储
+type Volume struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	// Type 存储类型，默认 share-file
+	Type    string `yaml:"type"`
+	Content string `yaml:"content"`
+}
+
+// Hooks 部署钩子
+type Hooks struct {
+	PreDeploy []Hook `yaml:"pre_deploy"`
+}
+
+// Hook 使用组件镜像执行的命令
+type Hook struct {
+	Name           string `yaml:"name"`
+	Command        string `yaml:"command"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
 //ReadRainbondFile 读取云帮代码配置
+// 文件存在定义错误时返回 RainbondFileErrors，只有可忽略的错误时同时返回配置
 func ReadRainbondFile(homepath string) (*RainbondFileConfig, error) {
 	if ok, _ := util.FileExists(path.Join(homepath, "rainbondfile")); !ok {
 		return nil, ErrRainbondFileNotFound
@@ -61,10 +126,57 @@ func ReadRainbondFile(homepath string) (*RainbondFileConfig, error) {
 		logrus.Error("read rainbond file error,", err.Error())
 		return nil, fmt.Errorf("read rainbond file error")
 	}
-	var rbdfile RainbondFileConfig
-	if err := yaml.Unmarshal(body, &rbdfile); err != nil {
-		logrus.Error("marshal rainbond file error,", err.Error())
-		return nil, fmt.Errorf("marshal rainbond file error")
+	rbdfile, errs := ParseRainbondFile(body)
+	if len(errs) > 0 {
+		logrus.Warningf("rainbond file has errors: %s", errs.Error())
+		return rbdfile, errs
+	}
+	return rbdfile, nil
+}
+
+// ResolveCNBVersion 根据 cnb_version_policy 确定语言版本
+// 检测到的版本不在允许的版本中时使用策略的默认版本，changed 表示版本被策略修改
+func (c *RainbondFileConfig) ResolveCNBVersion(lang Lang, detected string) (version string, changed bool) {
+	if c == nil || len(c.CNBVersionPolicy) == 0 {
+		return detected, false
+	}
+	policy, ok := c.CNBVersionPolicy[cnbPolicyLanguage(lang)]
+	if !ok {
+		return detected, false
+	}
+	if detected == "" {
+		return policy.Default, policy.Default != ""
+	}
+	if len(policy.Allowed) == 0 || containsString(policy.Allowed, detected) {
+		return detected, false
+	}
+	if policy.Default != "" {
+		return policy.Default, true
+	}
+	return policy.Allowed[0], true
+}
+
+// cnbPolicyLanguage 返回语言在 cnb_version_policy 中的 key，支持 dockerfile,Node.js 等组合语言
+func cnbPolicyLanguage(lang Lang) string {
+	for _, part := range strings.Split(strings.ToLower(lang.String()), ",") {
+		switch strings.TrimSpace(part) {
+		case "node.js", "nodejs", "node", "nodejsstatic":
+			return "nodejs"
+		case "java-maven", "java-war", "java-jar", "gradle", "scala", "java":
+			return "java"
+		case "go", "golang":
+			return "golang"
+		case "python":
+			return "python"
+		case ".netcore", "dotnet":
+			return "dotnet"
+		case "php":
+			return "php"
+		case "rust":
+			return "rust"
+		case "elixir":
+			return "elixir"
+		}
 	}
-	return &rbdfile, nil
+	return ""
 }
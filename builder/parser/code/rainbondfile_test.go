@@ -69,3 +69,151 @@ func TestReadRainbondFile_ParsesYamlConfig(t *testing.T) {
 		t.Fatalf("Ports parsed incorrectly: %+v", rbdfile.Ports)
 	}
 }
+
+// capability_id: rainbond.rainbondfile.deploy-manifest
+func TestParseRainbondFile_ParsesDeployManifest(t *testing.T) {
+	content := []byte(`language: Node.js
+build_envs:
+  NODE_ENV: production
+  BUILD_NPM_REGISTRY: https://registry.npmmirror.com
+cnb_version_policy:
+  nodejs:
+    default: 20.20.0
+    allowed: [20.20.0, 22.22.0]
+probes:
+- mode: liveness
+  scheme: http
+  port: 3000
+  path: /healthz
+resources:
+  cpu: 500m
+  memory: 1Gi
+volumes:
+- name: data
+  path: /app/data
+hooks:
+  pre_deploy:
+  - name: migrate
+    command: npm run migrate
+    timeout_seconds: 300
+services:
+- name: api
+  depends_on: [worker]
+- name: worker
+`)
+
+	rbdfile, errs := ParseRainbondFile(content)
+	if len(errs) > 0 {
+		t.Fatalf("ParseRainbondFile() errors = %v", errs)
+	}
+	if rbdfile.BuildEnvs["NODE_ENV"] != "production" {
+		t.Fatalf("BuildEnvs parsed incorrectly: %+v", rbdfile.BuildEnvs)
+	}
+	if policy := rbdfile.CNBVersionPolicy["nodejs"]; policy.Default != "20.20.0" || len(policy.Allowed) != 2 {
+		t.Fatalf("CNBVersionPolicy parsed incorrectly: %+v", rbdfile.CNBVersionPolicy)
+	}
+	if len(rbdfile.Probes) != 1 || rbdfile.Probes[0].Path != "/healthz" || rbdfile.Probes[0].Port != 3000 {
+		t.Fatalf("Probes parsed incorrectly: %+v", rbdfile.Probes)
+	}
+	if rbdfile.Resources == nil || rbdfile.Resources.CPU != "500m" || rbdfile.Resources.Memory != "1Gi" {
+		t.Fatalf("Resources parsed incorrectly: %+v", rbdfile.Resources)
+	}
+	if len(rbdfile.Volumes) != 1 || rbdfile.Volumes[0].Path != "/app/data" {
+		t.Fatalf("Volumes parsed incorrectly: %+v", rbdfile.Volumes)
+	}
+	if rbdfile.Hooks == nil || len(rbdfile.Hooks.PreDeploy) != 1 || rbdfile.Hooks.PreDeploy[0].TimeoutSeconds != 300 {
+		t.Fatalf("Hooks parsed incorrectly: %+v", rbdfile.Hooks)
+	}
+	if len(rbdfile.Services) != 2 || len(rbdfile.Services[0].DependsOn) != 1 || rbdfile.Services[0].DependsOn[0] != "worker" {
+		t.Fatalf("Services parsed incorrectly: %+v", rbdfile.Services)
+	}
+}
+
+// capability_id: rainbond.rainbondfile.line-errors
+func TestParseRainbondFile_ReportsLineLevelErrors(t *testing.T) {
+	content := []byte(`language: Node.js
+ports:
+- port: 70000
+probes:
+- scheme: http
+  port: 3000
+  path: healthz
+resources:
+  memory: lots
+services:
+- name: api
+  depends_on: [db]
+`)
+
+	rbdfile, errs := ParseRainbondFile(content)
+	if rbdfile != nil {
+		t.Fatalf("expected nil config for fatal errors, got %+v", rbdfile)
+	}
+	want := map[string]int{
+		"ports[0].port":             3,
+		"probes[0].path":            7,
+		"resources.memory":          9,
+		"services[0].depends_on[0]": 12,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for _, e := range errs {
+		line, ok := want[e.Field]
+		if !ok || e.Line != line || !e.Fatal {
+			t.Fatalf("unexpected error %+v", e)
+		}
+	}
+}
+
+// capability_id: rainbond.rainbondfile.type-errors
+func TestParseRainbondFile_ReportsTypeErrorLine(t *testing.T) {
+	content := []byte("language: Node.js\nports:\n- port: http\n")
+
+	_, errs := ParseRainbondFile(content)
+	if len(errs) != 1 || errs[0].Line != 3 || !errs[0].Fatal {
+		t.Fatalf("expected a fatal error at line 3, got %v", errs)
+	}
+}
+
+// capability_id: rainbond.rainbondfile.unknown-fields
+func TestReadRainbondFile_ReturnsConfigWithNegligibleErrors(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("language: Node.js\nprobs:\n- port: 80\n")
+	if err := os.WriteFile(filepath.Join(dir, "rainbondfile"), content, 0o644); err != nil {
+		t.Fatalf("write rainbondfile: %v", err)
+	}
+
+	rbdfile, err := ReadRainbondFile(dir)
+	errs, ok := err.(RainbondFileErrors)
+	if !ok || errs.IsFatal() || len(errs) != 1 || errs[0].Line != 2 || errs[0].Field != "probs" {
+		t.Fatalf("expected an unknown field error at line 2, got %v", err)
+	}
+	if rbdfile == nil || rbdfile.Language != "Node.js" {
+		t.Fatalf("expected config to be returned, got %+v", rbdfile)
+	}
+}
+
+// capability_id: rainbond.rainbondfile.cnb-version-policy
+func TestRainbondFileConfig_ResolveCNBVersion(t *testing.T) {
+	rbdfile := &RainbondFileConfig{CNBVersionPolicy: map[string]CNBLanguageVersion{
+		"nodejs": {Default: "20.20.0", Allowed: []string{"20.20.0", "22.22.0"}},
+	}}
+	tests := []struct {
+		lang        Lang
+		detected    string
+		wantVersion string
+		wantChanged bool
+	}{
+		{lang: Nodejs, detected: "22.22.0", wantVersion: "22.22.0"},
+		{lang: Nodejs, detected: "18.20.8", wantVersion: "20.20.0", wantChanged: true},
+		{lang: Lang("dockerfile,Node.js"), detected: "", wantVersion: "20.20.0", wantChanged: true},
+		{lang: Python, detected: "3.12", wantVersion: "3.12"},
+	}
+	for _, tt := range tests {
+		version, changed := rbdfile.ResolveCNBVersion(tt.lang, tt.detected)
+		if version != tt.wantVersion || changed != tt.wantChanged {
+			t.Fatalf("ResolveCNBVersion(%s, %q) = %q, %v; want %q, %v", tt.lang, tt.detected, version, changed, tt.wantVersion, tt.wantChanged)
+		}
+	}
+}
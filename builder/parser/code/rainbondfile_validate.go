@@ -0,0 +1,398 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package code
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	yaml "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var yamlErrorLineRe = regexp.MustCompile(`line (\d+):?\s*`)
+
+// cnbPolicyLanguages rainbondfile 中 cnb_version_policy 支持的语言
+var cnbPolicyLanguages = map[string]bool{
+	"java": true, "nodejs": true, "python": true, "golang": true,
+	"dotnet": true, "php": true, "rust": true, "elixir": true,
+}
+
+var rainbondFileVolumeTypes = map[string]bool{
+	dbmodel.ShareFileVolumeType.String():  true,
+	dbmodel.LocalVolumeType.String():      true,
+	dbmodel.MemoryFSVolumeType.String():   true,
+	dbmodel.ConfigFileVolumeType.String(): true,
+}
+
+// RainbondFileError rainbondfile 中的定义错误
+type RainbondFileError struct {
+	Line    int
+	Field   string
+	Message string
+	// Fatal 为 false 时错误可以忽略，如未知的字段
+	Fatal bool
+}
+
+func (e RainbondFileError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+}
+
+// RainbondFileErrors rainbondfile 中的定义错误列表
+type RainbondFileErrors []RainbondFileError
+
+func (es RainbondFileErrors) Error() string {
+	var msgs []string
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// IsFatal 是否存在无法忽略的错误
+func (es RainbondFileErrors) IsFatal() bool {
+	for _, e := range es {
+		if e.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRainbondFile parses and validates rainbondfile content.
+// The config is nil if the content is not valid yaml or has fatal errors.
+func ParseRainbondFile(body []byte) (*RainbondFileConfig, RainbondFileErrors) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, RainbondFileErrors{{Line: yamlErrorLine(err.Error()), Message: yamlErrorLineRe.ReplaceAllString(strings.TrimPrefix(err.Error(), "yaml: "), "")}}
+	}
+	config := &RainbondFileConfig{}
+	if len(doc.Content) == 0 {
+		return config, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, RainbondFileErrors{{Line: root.Line, Message: "rainbondfile must be a mapping", Fatal: true}}
+	}
+	v := &rainbondFileValidator{root: root}
+	if err := root.Decode(config); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, RainbondFileErrors{{Line: root.Line, Message: err.Error(), Fatal: true}}
+		}
+		for _, msg := range typeErr.Errors {
+			v.errs = append(v.errs, RainbondFileError{Line: yamlErrorLine(msg), Message: yamlErrorLineRe.ReplaceAllString(msg, ""), Fatal: true})
+		}
+	}
+	v.checkUnknownFields(root, reflect.TypeOf(*config), "")
+	// 类型错误的字段值不完整，校验会产生重复的错误
+	if !v.errs.IsFatal() {
+		v.validate(config)
+	}
+	if v.errs.IsFatal() {
+		return nil, v.errs
+	}
+	return config, v.errs
+}
+
+func yamlErrorLine(msg string) int {
+	matches := yamlErrorLineRe.FindStringSubmatch(msg)
+	if len(matches) < 2 {
+		return 0
+	}
+	line, _ := strconv.Atoi(matches[1])
+	return line
+}
+
+type rainbondFileValidator struct {
+	root *yaml.Node
+	errs RainbondFileErrors
+}
+
+// line 返回路径对应节点的行号，路径由 mapping 的 key 和 sequence 的下标组成，找不到时返回最近的上级节点
+func (v *rainbondFileValidator) line(path ...interface{}) int {
+	node := v.root
+	for _, elem := range path {
+		next := childNode(node, elem)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node.Line
+}
+
+func childNode(node *yaml.Node, elem interface{}) *yaml.Node {
+	switch key := elem.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case int:
+		if node.Kind == yaml.SequenceNode && key < len(node.Content) {
+			return node.Content[key]
+		}
+	}
+	return nil
+}
+
+func (v *rainbondFileValidator) errorf(path []interface{}, format string, args ...interface{}) {
+	v.errs = append(v.errs, RainbondFileError{Line: v.line(path...), Field: fieldName(path), Message: fmt.Sprintf(format, args...), Fatal: true})
+}
+
+func fieldName(path []interface{}) string {
+	var name string
+	for _, elem := range path {
+		switch key := elem.(type) {
+		case string:
+			if name != "" {
+				name += "."
+			}
+			name += key
+		case int:
+			name += fmt.Sprintf("[%d]", key)
+		}
+	}
+	return name
+}
+
+func appendPath(path []interface{}, elems ...interface{}) []interface{} {
+	return append(append([]interface{}{}, path...), elems...)
+}
+
+// checkUnknownFields 未知字段作为可忽略的错误，避免拼写错误的配置被静默忽略
+func (v *rainbondFileValidator) checkUnknownFields(node *yaml.Node, typ reflect.Type, prefix string) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case node.Kind == yaml.SequenceNode && typ.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			v.checkUnknownFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", prefix, i))
+		}
+	case node.Kind == yaml.MappingNode && typ.Kind() == reflect.Struct:
+		fields := yamlFields(typ)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			name := key.Value
+			if prefix != "" {
+				name = prefix + "." + key.Value
+			}
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				v.errs = append(v.errs, RainbondFileError{Line: key.Line, Field: name, Message: "unknown field"})
+				continue
+			}
+			v.checkUnknownFields(node.Content[i+1], fieldType, name)
+		}
+	}
+}
+
+func yamlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("yaml")
+		name := strings.Split(tag, ",")[0]
+		if strings.Contains(tag, ",inline") {
+			for k, t := range yamlFields(field.Type) {
+				fields[k] = t
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func (v *rainbondFileValidator) validate(config *RainbondFileConfig) {
+	v.validatePorts(nil, config.Ports)
+	langs := make([]string, 0, len(config.CNBVersionPolicy))
+	for lang := range config.CNBVersionPolicy {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		policy := config.CNBVersionPolicy[lang]
+		path := []interface{}{"cnb_version_policy", lang}
+		if !cnbPolicyLanguages[lang] {
+			v.errorf(path, "not support cnb language %s", lang)
+			continue
+		}
+		if policy.Default != "" && len(policy.Allowed) > 0 && !containsString(policy.Allowed, policy.Default) {
+			v.errorf(appendPath(path, "default"), "default version %s is not in the allowed versions", policy.Default)
+		}
+	}
+	v.validateDeploySpec(nil, config.DeploySpec)
+	names := make(map[string]bool, len(config.Services))
+	for i, svc := range config.Services {
+		if svc == nil {
+			continue
+		}
+		path := []interface{}{"services", i}
+		if svc.Name == "" {
+			v.errorf(path, "service name can not be empty")
+		} else if names[svc.Name] {
+			v.errorf(appendPath(path, "name"), "duplicate service %s", svc.Name)
+		}
+		names[svc.Name] = true
+		v.validatePorts(path, svc.Ports)
+		v.validateDeploySpec(path, svc.DeploySpec)
+	}
+	for i, svc := range config.Services {
+		if svc == nil {
+			continue
+		}
+		for j, dep := range svc.DependsOn {
+			if dep == svc.Name {
+				v.errorf([]interface{}{"services", i, "depends_on", j}, "service can not depend on itself")
+			} else if !names[dep] {
+				v.errorf([]interface{}{"services", i, "depends_on", j}, "service %s is not defined in services", dep)
+			}
+		}
+	}
+}
+
+func (v *rainbondFileValidator) validatePorts(path []interface{}, ports []Port) {
+	for i, port := range ports {
+		if port.Port < 1 || port.Port > 65535 {
+			v.errorf(appendPath(path, "ports", i, "port"), "port must be between 1 and 65535")
+		}
+	}
+}
+
+func (v *rainbondFileValidator) validateDeploySpec(path []interface{}, spec DeploySpec) {
+	for i, probe := range spec.Probes {
+		v.validateProbe(appendPath(path, "probes", i), probe)
+	}
+	if spec.Resources != nil {
+		if spec.Resources.CPU != "" {
+			if _, err := resource.ParseQuantity(spec.Resources.CPU); err != nil {
+				v.errorf(appendPath(path, "resources", "cpu"), "invalid cpu quantity %s", spec.Resources.CPU)
+			}
+		}
+		if spec.Resources.Memory != "" {
+			if _, err := resource.ParseQuantity(spec.Resources.Memory); err != nil {
+				v.errorf(appendPath(path, "resources", "memory"), "invalid memory quantity %s", spec.Resources.Memory)
+			}
+		}
+	}
+	paths := make(map[string]bool, len(spec.Volumes))
+	for i, volume := range spec.Volumes {
+		volumePath := appendPath(path, "volumes", i)
+		if !strings.HasPrefix(volume.Path, "/") {
+			v.errorf(appendPath(volumePath, "path"), "volume path must be an absolute path")
+		} else if paths[volume.Path] {
+			v.errorf(appendPath(volumePath, "path"), "duplicate volume path %s", volume.Path)
+		}
+		paths[volume.Path] = true
+		if volume.Type != "" && !rainbondFileVolumeTypes[volume.Type] {
+			v.errorf(appendPath(volumePath, "type"), "not support volume type %s", volume.Type)
+		}
+		if volume.Type == dbmodel.ConfigFileVolumeType.String() && volume.Content == "" {
+			v.errorf(volumePath, "config-file volume requires content")
+		}
+	}
+	for i, dep := range spec.DependsOn {
+		if strings.TrimSpace(dep) == "" {
+			v.errorf(appendPath(path, "depends_on", i), "dependency can not be empty")
+		}
+	}
+	if spec.Hooks != nil {
+		for i, hook := range spec.Hooks.PreDeploy {
+			hookPath := appendPath(path, "hooks", "pre_deploy", i)
+			if strings.TrimSpace(hook.Command) == "" {
+				v.errorf(hookPath, "hook command can not be empty")
+			}
+			if hook.TimeoutSeconds < 0 {
+				v.errorf(appendPath(hookPath, "timeout_seconds"), "timeout can not be negative")
+			}
+		}
+	}
+}
+
+func (v *rainbondFileValidator) validateProbe(path []interface{}, probe Probe) {
+	switch probe.Mode {
+	case "", "liveness", "readiness":
+	default:
+		v.errorf(appendPath(path, "mode"), "probe mode must be liveness or readiness")
+	}
+	switch probe.Scheme {
+	case "", "tcp":
+		if probe.Port == 0 {
+			v.errorf(path, "tcp probe requires port")
+		}
+	case "http":
+		if probe.Port == 0 {
+			v.errorf(path, "http probe requires port")
+		}
+		if probe.Path != "" && !strings.HasPrefix(probe.Path, "/") {
+			v.errorf(appendPath(path, "path"), "http probe path must start with /")
+		}
+	case "cmd":
+		if strings.TrimSpace(probe.Cmd) == "" {
+			v.errorf(path, "cmd probe requires cmd")
+		}
+	default:
+		v.errorf(appendPath(path, "scheme"), "probe scheme must be tcp, http or cmd")
+	}
+	if probe.Port < 0 || probe.Port > 65535 {
+		v.errorf(appendPath(path, "port"), "port must be between 1 and 65535")
+	}
+	for _, field := range []struct {
+		key   string
+		value int
+	}{
+		{"initial_delay_seconds", probe.InitialDelaySeconds},
+		{"period_seconds", probe.PeriodSeconds},
+		{"timeout_seconds", probe.TimeoutSeconds},
+		{"failure_threshold", probe.FailureThreshold},
+		{"success_threshold", probe.SuccessThreshold},
+	} {
+		if field.value < 0 {
+			v.errorf(appendPath(path, field.key), "can not be negative")
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
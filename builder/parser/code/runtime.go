@@ -85,6 +85,12 @@ func checkRuntime(buildPath string, lang Lang, buildStrategy string) (map[string
 			return readDotnetRuntimeInfoForCNB(buildPath)
 		}
 		return nil, nil
+	case Rust:
+		return readRustRuntimeInfo(buildPath)
+	case Scala:
+		return readScalaRuntimeInfo(buildPath)
+	case Elixir:
+		return readElixirRuntimeInfo(buildPath)
 	case Nodejs:
 		return readNodeRuntimeInfo(buildPath)
 	case Static:
@@ -189,6 +195,41 @@ func readGolangRuntimeInfoForCNB(buildPath string) (map[string]string, error) {
 	return runtimeInfo, nil
 }
 
+func readRustRuntimeInfo(buildPath string) (map[string]string, error) {
+	info := ParseRustVersion(buildPath)
+	return map[string]string{
+		"RUNTIMES":        info.Resolved,
+		"RUNTIMES_SOURCE": info.Source,
+	}, nil
+}
+
+func readScalaRuntimeInfo(buildPath string) (map[string]string, error) {
+	info := ParseSbtVersion(buildPath)
+	runtimeInfo := map[string]string{
+		"RUNTIMES":        info.JVM.Resolved,
+		"RUNTIMES_SOURCE": info.JVM.Source,
+	}
+	if info.SbtVersion != "" {
+		runtimeInfo["SBT_VERSION"] = info.SbtVersion
+	}
+	if info.ScalaVersion != "" {
+		runtimeInfo["SCALA_VERSION"] = info.ScalaVersion
+	}
+	return runtimeInfo, nil
+}
+
+func readElixirRuntimeInfo(buildPath string) (map[string]string, error) {
+	info := ParseElixirVersion(buildPath)
+	runtimeInfo := map[string]string{
+		"RUNTIMES":        info.Elixir.Resolved,
+		"RUNTIMES_SOURCE": info.Elixir.Source,
+	}
+	if info.OTPVersion != "" {
+		runtimeInfo["ERLANG_VERSION"] = info.OTPVersion
+	}
+	return runtimeInfo, nil
+}
+
 func readDotnetRuntimeInfoForCNB(buildPath string) (map[string]string, error) {
 	var runtimeInfo = make(map[string]string, 1)
 	projectFiles, err := filepath.Glob(path.Join(buildPath, "*.csproj"))
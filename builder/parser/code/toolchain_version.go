@@ -0,0 +1,249 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package code
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultRustVersion is the default Rust toolchain when none is pinned
+	DefaultRustVersion = "1.90"
+	// DefaultElixirVersion is the default Elixir version when none is specified
+	DefaultElixirVersion = "1.18"
+	// DefaultScalaJVMVersion is the default JVM major version for sbt builds
+	DefaultScalaJVMVersion = "17"
+)
+
+var (
+	rustToolchainChannelRe = regexp.MustCompile(`(?m)^\s*channel\s*=\s*"([^"]+)"`)
+	cargoRustVersionRe     = regexp.MustCompile(`(?m)^\s*rust-version\s*=\s*"([^"]+)"`)
+	sbtVersionRe           = regexp.MustCompile(`(?m)^\s*sbt\.version\s*=\s*(\S+)`)
+	scalaVersionRe         = regexp.MustCompile(`scalaVersion\s*:=\s*"([^"]+)"`)
+	javaRuntimeVersionRe   = regexp.MustCompile(`(?m)^\s*java\.runtime\.version\s*=\s*(\S+)`)
+	mixElixirVersionRe     = regexp.MustCompile(`elixir:\s*"([^"]+)"`)
+)
+
+// ToolchainVersionInfo contains the resolved toolchain version of a project
+type ToolchainVersionInfo struct {
+	Original string // version declared in the project
+	Resolved string // version used for build
+	Source   string // file the version came from, default if not declared
+}
+
+// SbtVersionInfo contains the versions declared by an sbt project
+type SbtVersionInfo struct {
+	SbtVersion   string
+	ScalaVersion string
+	JVM          ToolchainVersionInfo
+}
+
+// ElixirVersionInfo contains the versions declared by a mix project
+type ElixirVersionInfo struct {
+	Elixir     ToolchainVersionInfo
+	OTPVersion string
+}
+
+func defaultToolchainVersion(version string) ToolchainVersionInfo {
+	return ToolchainVersionInfo{Resolved: version, Source: "default"}
+}
+
+func readFileString(buildPath string, elem ...string) string {
+	body, err := os.ReadFile(path.Join(append([]string{buildPath}, elem...)...))
+	if err != nil {
+		return ""
+	}
+	return string(body)
+}
+
+func firstSubmatch(re *regexp.Regexp, content string) string {
+	matches := re.FindStringSubmatch(content)
+	if len(matches) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(matches[1])
+}
+
+// majorMinor returns the "X.Y" part of a version string, or empty if it can not be parsed
+func majorMinor(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	for _, part := range parts[:2] {
+		if _, err := strconv.Atoi(part); err != nil {
+			return ""
+		}
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// versionAtLeast compares two "X.Y" versions
+func versionAtLeast(version, min string) bool {
+	vp, mp := strings.Split(version, "."), strings.Split(min, ".")
+	for i := 0; i < len(vp) && i < len(mp); i++ {
+		v, _ := strconv.Atoi(vp[i])
+		m, _ := strconv.Atoi(mp[i])
+		if v != m {
+			return v > m
+		}
+	}
+	return len(vp) >= len(mp)
+}
+
+// ParseRustVersion parses the Rust toolchain from rust-toolchain.toml, rust-toolchain
+// or the rust-version field of Cargo.toml
+func ParseRustVersion(buildPath string) ToolchainVersionInfo {
+	if channel := firstSubmatch(rustToolchainChannelRe, readFileString(buildPath, "rust-toolchain.toml")); channel != "" {
+		return ToolchainVersionInfo{Original: channel, Resolved: ResolveRustVersion(channel), Source: "rust-toolchain.toml"}
+	}
+	if legacy := strings.TrimSpace(readFileString(buildPath, "rust-toolchain")); legacy != "" {
+		// 旧格式的 rust-toolchain 既可能是纯文本也可能是 toml
+		if channel := firstSubmatch(rustToolchainChannelRe, legacy); channel != "" {
+			legacy = channel
+		}
+		return ToolchainVersionInfo{Original: legacy, Resolved: ResolveRustVersion(legacy), Source: "rust-toolchain"}
+	}
+	if msrv := firstSubmatch(cargoRustVersionRe, readFileString(buildPath, "Cargo.toml")); msrv != "" {
+		// rust-version 是最低支持版本，默认版本满足要求时使用默认版本
+		resolved := majorMinor(msrv)
+		if resolved == "" || versionAtLeast(DefaultRustVersion, resolved) {
+			resolved = DefaultRustVersion
+		}
+		return ToolchainVersionInfo{Original: msrv, Resolved: resolved, Source: "Cargo.toml"}
+	}
+	return defaultToolchainVersion(DefaultRustVersion)
+}
+
+// ResolveRustVersion resolves a rustup toolchain name to the toolchain used for build
+// Supports formats:
+//   - "stable"            → default version
+//   - "1.78.0", "1.78"    → 1.78
+//   - "nightly-2024-05-01", "beta" → used directly
+func ResolveRustVersion(toolchain string) string {
+	toolchain = strings.Trim(strings.TrimSpace(toolchain), `"'`)
+	if toolchain == "" || toolchain == "stable" {
+		return DefaultRustVersion
+	}
+	if strings.HasPrefix(toolchain, "nightly") || strings.HasPrefix(toolchain, "beta") {
+		return toolchain
+	}
+	if version := majorMinor(toolchain); version != "" {
+		return version
+	}
+	return DefaultRustVersion
+}
+
+// ParseSbtVersion parses sbt, Scala and JVM versions of an sbt project
+func ParseSbtVersion(buildPath string) SbtVersionInfo {
+	info := SbtVersionInfo{
+		SbtVersion:   firstSubmatch(sbtVersionRe, readFileString(buildPath, "project", "build.properties")),
+		ScalaVersion: firstSubmatch(scalaVersionRe, readFileString(buildPath, "build.sbt")),
+		JVM:          defaultToolchainVersion(DefaultScalaJVMVersion),
+	}
+	if jvm := firstSubmatch(javaRuntimeVersionRe, readFileString(buildPath, "system.properties")); jvm != "" {
+		if resolved, err := normalizeJavaRuntimeVersion(jvm); err == nil {
+			info.JVM = ToolchainVersionInfo{Original: jvm, Resolved: resolved, Source: "system.properties"}
+		}
+	} else if jvm := strings.TrimSpace(readFileString(buildPath, ".java-version")); jvm != "" {
+		if resolved, err := normalizeJavaRuntimeVersion(jvm); err == nil {
+			info.JVM = ToolchainVersionInfo{Original: jvm, Resolved: resolved, Source: ".java-version"}
+		}
+	}
+	return info
+}
+
+// ParseElixirVersion parses Elixir and OTP versions from .tool-versions or mix.exs
+func ParseElixirVersion(buildPath string) ElixirVersionInfo {
+	info := ElixirVersionInfo{Elixir: defaultToolchainVersion(DefaultElixirVersion)}
+	scanner := bufio.NewScanner(strings.NewReader(readFileString(buildPath, ".tool-versions")))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "elixir":
+			// 如 1.16.2-otp-26，otp 后缀表示编译使用的 OTP 版本
+			version := fields[1]
+			if idx := strings.Index(version, "-otp-"); idx > 0 {
+				if info.OTPVersion == "" {
+					info.OTPVersion = version[idx+len("-otp-"):]
+				}
+				version = version[:idx]
+			}
+			if resolved := majorMinor(version); resolved != "" {
+				info.Elixir = ToolchainVersionInfo{Original: fields[1], Resolved: resolved, Source: ".tool-versions"}
+			}
+		case "erlang":
+			info.OTPVersion = fields[1]
+		}
+	}
+	if info.Elixir.Source != "default" {
+		return info
+	}
+	if requirement := firstSubmatch(mixElixirVersionRe, readFileString(buildPath, "mix.exs")); requirement != "" {
+		info.Elixir = ToolchainVersionInfo{Original: requirement, Resolved: ResolveElixirVersion(requirement), Source: "mix.exs"}
+	}
+	return info
+}
+
+// ResolveElixirVersion resolves a mix version requirement to a concrete Elixir version
+// Supports formats:
+//   - "~> 1.15"   → default version if it satisfies the requirement, otherwise 1.15
+//   - "~> 1.15.2" → 1.15
+//   - "== 1.16.1" → 1.16
+//   - ">= 1.14.0" → default version if it satisfies the requirement, otherwise 1.14
+func ResolveElixirVersion(requirement string) string {
+	// 多个条件时只取第一个
+	requirement = strings.TrimSpace(strings.Split(requirement, " and ")[0])
+	requirement = strings.TrimSpace(strings.Split(requirement, " or ")[0])
+	var op string
+	for _, prefix := range []string{"~>", "==", ">=", ">", "<=", "<"} {
+		if strings.HasPrefix(requirement, prefix) {
+			op = prefix
+			requirement = strings.TrimSpace(strings.TrimPrefix(requirement, prefix))
+			break
+		}
+	}
+	version := majorMinor(requirement)
+	if version == "" {
+		return DefaultElixirVersion
+	}
+	switch op {
+	case "~>":
+		if strings.Count(requirement, ".") >= 2 {
+			return version
+		}
+		fallthrough
+	case ">=", ">":
+		if versionAtLeast(DefaultElixirVersion, version) && strings.Split(DefaultElixirVersion, ".")[0] == strings.Split(version, ".")[0] {
+			return DefaultElixirVersion
+		}
+		return version
+	default:
+		return version
+	}
+}
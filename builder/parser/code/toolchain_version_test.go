@@ -0,0 +1,88 @@
+package code
+
+import "testing"
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestParseRustVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   string
+		source string
+	}{
+		{name: "default", files: map[string]string{"Cargo.toml": "[package]\nname = \"demo\"\n"}, want: DefaultRustVersion, source: "default"},
+		{name: "toolchain-toml", files: map[string]string{"rust-toolchain.toml": "[toolchain]\nchannel = \"1.86.0\"\n"}, want: "1.86", source: "rust-toolchain.toml"},
+		{name: "toolchain-legacy", files: map[string]string{"rust-toolchain": "nightly-2024-05-01\n"}, want: "nightly-2024-05-01", source: "rust-toolchain"},
+		{name: "toolchain-stable", files: map[string]string{"rust-toolchain": "stable\n"}, want: DefaultRustVersion, source: "rust-toolchain"},
+		{name: "msrv-satisfied", files: map[string]string{"Cargo.toml": "[package]\nrust-version = \"1.70\"\n"}, want: DefaultRustVersion, source: "Cargo.toml"},
+		{name: "msrv-newer", files: map[string]string{"Cargo.toml": "[package]\nrust-version = \"1.99.1\"\n"}, want: "1.99", source: "Cargo.toml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tt.files)
+			info := ParseRustVersion(dir)
+			if info.Resolved != tt.want || info.Source != tt.source {
+				t.Fatalf("ParseRustVersion() = %+v, want %s from %s", info, tt.want, tt.source)
+			}
+		})
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestParseSbtVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"build.sbt":                "ThisBuild / scalaVersion := \"3.3.3\"\n",
+		"project/build.properties": "sbt.version=1.10.1\n",
+		"system.properties":        "java.runtime.version=21\n",
+	})
+	info := ParseSbtVersion(dir)
+	if info.SbtVersion != "1.10.1" || info.ScalaVersion != "3.3.3" {
+		t.Fatalf("unexpected sbt versions %+v", info)
+	}
+	if info.JVM.Resolved != "21" || info.JVM.Source != "system.properties" {
+		t.Fatalf("unexpected jvm version %+v", info.JVM)
+	}
+
+	info = ParseSbtVersion(t.TempDir())
+	if info.JVM.Resolved != DefaultScalaJVMVersion || info.JVM.Source != "default" {
+		t.Fatalf("expected default jvm version, got %+v", info.JVM)
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestParseElixirVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"mix.exs":        "def project do\n  [app: :demo, elixir: \"~> 1.14\"]\nend\n",
+		".tool-versions": "erlang 26.2.5\nelixir 1.16.2-otp-26\n",
+	})
+	info := ParseElixirVersion(dir)
+	if info.Elixir.Resolved != "1.16" || info.Elixir.Source != ".tool-versions" || info.OTPVersion != "26.2.5" {
+		t.Fatalf("unexpected elixir versions %+v", info)
+	}
+
+	dir = t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"mix.exs": "def project do\n  [app: :demo, elixir: \"~> 1.14\"]\nend\n"})
+	info = ParseElixirVersion(dir)
+	if info.Elixir.Resolved != DefaultElixirVersion || info.Elixir.Source != "mix.exs" {
+		t.Fatalf("unexpected mix.exs elixir version %+v", info)
+	}
+}
+
+// capability_id: rainbond.source-build.rust-scala-elixir
+func TestResolveElixirVersion(t *testing.T) {
+	for requirement, want := range map[string]string{
+		"~> 1.14":             DefaultElixirVersion,
+		"~> 1.15.2":           "1.15",
+		"== 1.16.1":           "1.16",
+		">= 1.14.0 and < 2.0": DefaultElixirVersion,
+		"~> 2.1":              "2.1",
+		"latest":              DefaultElixirVersion,
+	} {
+		if got := ResolveElixirVersion(requirement); got != want {
+			t.Fatalf("ResolveElixirVersion(%q) = %q, want %q", requirement, got, want)
+		}
+	}
+}
@@ -104,6 +104,17 @@ type ServiceConfig struct {
 	Volumes          []Volumes           `compose:""`
 	HealthChecks     HealthCheck         `compose:""`
 	Placement        map[string]string   `compose:""`
+	Secrets          []FileObjectMount   `compose:"secrets"`
+	Configs          []FileObjectMount   `compose:"configs"`
+}
+
+// FileObjectMount a compose secret or config mounted into the service as a file
+type FileObjectMount struct {
+	Name    string  // name of the top-level secret or config
+	Target  string  // mount path in container
+	Content string  // file content
+	Mode    *uint32 // file mode, nil means default
+	Secret  bool    // whether the content is secret material
 }
 
 // HealthCheck the healthcheck configuration for a service
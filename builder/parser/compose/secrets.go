@@ -0,0 +1,156 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/compose/types"
+)
+
+const (
+	// maxFileObjectSize ConfigMap 和 Secret 的大小限制
+	maxFileObjectSize = 1 << 20
+	// defaultSecretDir compose secrets 默认的挂载目录
+	defaultSecretDir = "/run/secrets"
+)
+
+// fileObjectSource 顶层 secrets/configs 的定义，file、environment、content 三选一
+type fileObjectSource struct {
+	file        string
+	environment string
+	content     string
+	external    bool
+}
+
+// fileObjectRef 服务对 secrets/configs 的引用
+type fileObjectRef struct {
+	source string
+	target string
+	mode   *uint32
+}
+
+// resolveFileObjects 将服务引用的 secrets/configs 转换为挂载文件，无法导入的条目作为有限支持记录到报告中
+func resolveFileObjects(serviceName string, refs []fileObjectRef, sources map[string]fileObjectSource, env map[string]string, secret bool, report *FieldSupportReport) []FileObjectMount {
+	field, kind := "configs", "config"
+	if secret {
+		field, kind = "secrets", "secret"
+	}
+	var mounts []FileObjectMount
+	for _, ref := range refs {
+		source, ok := sources[ref.source]
+		if !ok {
+			report.AddDegraded(serviceName, field,
+				fmt.Sprintf("%s %q is not defined and will be ignored", kind, ref.source),
+				fmt.Sprintf("Define %s %q at the top level of the compose file", kind, ref.source))
+			continue
+		}
+		content, err := source.read(env)
+		if err != nil {
+			report.AddDegraded(serviceName, field,
+				fmt.Sprintf("%s %q can not be imported: %v", kind, ref.source, err),
+				"Add the file in Rainbond's configuration file management after import")
+			continue
+		}
+		mounts = append(mounts, FileObjectMount{
+			Name:    ref.source,
+			Target:  fileObjectTarget(ref, secret),
+			Content: content,
+			Mode:    ref.mode,
+			Secret:  secret,
+		})
+	}
+	return mounts
+}
+
+func (f fileObjectSource) read(env map[string]string) (string, error) {
+	var content string
+	switch {
+	case f.external:
+		return "", fmt.Errorf("external definition is managed outside the compose file")
+	case f.file != "":
+		info, err := os.Stat(f.file)
+		if err != nil {
+			return "", fmt.Errorf("file %s not found", filepath.Base(f.file))
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", filepath.Base(f.file))
+		}
+		if info.Size() > maxFileObjectSize {
+			return "", fmt.Errorf("file %s is larger than 1MB", filepath.Base(f.file))
+		}
+		data, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			return "", err
+		}
+		content = string(data)
+	case f.environment != "":
+		value, ok := env[f.environment]
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", f.environment)
+		}
+		content = value
+	default:
+		content = f.content
+	}
+	if content == "" {
+		return "", fmt.Errorf("content is empty")
+	}
+	if len(content) > maxFileObjectSize {
+		return "", fmt.Errorf("content is larger than 1MB")
+	}
+	return content, nil
+}
+
+// fileObjectTarget secrets 默认挂载到 /run/secrets/<name>，configs 默认挂载到 /<name>，相对路径基于默认目录
+func fileObjectTarget(ref fileObjectRef, secret bool) string {
+	target := ref.target
+	if target == "" {
+		target = ref.source
+	}
+	if path.IsAbs(target) {
+		return path.Clean(target)
+	}
+	if secret {
+		return path.Join(defaultSecretDir, target)
+	}
+	return path.Join("/", target)
+}
+
+// v3FileObjectSources docker/cli 仅支持 file 和 external 两种定义
+func v3FileObjectSources(secrets map[string]types.SecretConfig, configs map[string]types.ConfigObjConfig) (map[string]fileObjectSource, map[string]fileObjectSource) {
+	secretSources := make(map[string]fileObjectSource, len(secrets))
+	for name, obj := range secrets {
+		secretSources[name] = fileObjectSource{file: obj.File, external: obj.External.External}
+	}
+	configSources := make(map[string]fileObjectSource, len(configs))
+	for name, obj := range configs {
+		configSources[name] = fileObjectSource{file: obj.File, external: obj.External.External}
+	}
+	return secretSources, configSources
+}
+
+func specFileObjectSource(obj composetypes.FileObjectConfig) fileObjectSource {
+	return fileObjectSource{
+		file:        obj.File,
+		environment: obj.Environment,
+		content:     obj.Content,
+		external:    bool(obj.External),
+	}
+}
+
+// specFileObjectSources compose spec 额外支持 environment 和 content 内联定义
+func specFileObjectSources(project *composetypes.Project) (map[string]fileObjectSource, map[string]fileObjectSource) {
+	secretSources := make(map[string]fileObjectSource, len(project.Secrets))
+	for name, obj := range project.Secrets {
+		secretSources[name] = specFileObjectSource(composetypes.FileObjectConfig(obj))
+	}
+	configSources := make(map[string]fileObjectSource, len(project.Configs))
+	for name, obj := range project.Configs {
+		configSources[name] = specFileObjectSource(composetypes.FileObjectConfig(obj))
+	}
+	return secretSources, configSources
+}
@@ -0,0 +1,139 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func findFileObject(mounts []FileObjectMount, name string) *FileObjectMount {
+	for i := range mounts {
+		if mounts[i].Name == name {
+			return &mounts[i]
+		}
+	}
+	return nil
+}
+
+func countIssues(report *FieldSupportReport, field string, level SupportLevel) int {
+	var count int
+	for _, issue := range report.GetIssuesByLevel(level) {
+		if issue.Field == field {
+			count++
+		}
+	}
+	return count
+}
+
+// capability_id: rainbond.compose.secrets-configs
+func TestLoadComposeV3SecretsAndConfigs(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "db_password.txt"), []byte("s3cr3t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "nginx.conf"), []byte("worker_processes 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	body := `version: "3.7"
+services:
+  web:
+    image: nginx
+    secrets:
+      - db_password
+      - source: api_key
+        target: /etc/api/key
+    configs:
+      - source: nginx_conf
+        target: /etc/nginx/nginx.conf
+        mode: 0440
+secrets:
+  db_password:
+    file: ./db_password.txt
+  api_key:
+    external: true
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+`
+	co, err := (&Compose{}).LoadBytesWithWorkDir([][]byte{[]byte(body)}, workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := co.ServiceConfigs["web"]
+	if len(web.Secrets) != 1 {
+		t.Fatalf("expected only the file secret to be imported, got %+v", web.Secrets)
+	}
+	secret := findFileObject(web.Secrets, "db_password")
+	if secret == nil || secret.Target != "/run/secrets/db_password" || secret.Content != "s3cr3t" || !secret.Secret {
+		t.Fatalf("unexpected secret mount %+v", secret)
+	}
+	config := findFileObject(web.Configs, "nginx_conf")
+	if config == nil || config.Target != "/etc/nginx/nginx.conf" || config.Content != "worker_processes 1;" || config.Secret {
+		t.Fatalf("unexpected config mount %+v", config)
+	}
+	if config.Mode == nil || *config.Mode != 0440 {
+		t.Fatalf("unexpected config mode %v", config.Mode)
+	}
+	if countIssues(co.SupportReport, "secrets", SupportLevelSupported) != 1 || countIssues(co.SupportReport, "configs", SupportLevelSupported) != 1 {
+		t.Fatalf("secrets and configs should be reported as supported, got %+v", co.SupportReport.Issues)
+	}
+	if countIssues(co.SupportReport, "secrets", SupportLevelDegraded) != 1 {
+		t.Fatalf("external secret should be reported as degraded, got %+v", co.SupportReport.Issues)
+	}
+	if co.SupportReport.HasErrors() {
+		t.Fatalf("secrets and configs should not be reported as unsupported, got %+v", co.SupportReport.Issues)
+	}
+}
+
+// capability_id: rainbond.compose.secrets-configs
+func TestLoadComposeSpecSecretsAndConfigs(t *testing.T) {
+	t.Setenv("RAINBOND_TEST_TOKEN", "token-value")
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "db_password.txt"), []byte("s3cr3t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	body := `version: "3.8"
+services:
+  api:
+    image: nginx
+    secrets:
+      - db_password
+      - source: token
+        target: app/token
+      - missing
+    configs:
+      - app_config
+configs:
+  app_config:
+    content: |
+      listen: 8080
+secrets:
+  db_password:
+    file: ./db_password.txt
+  token:
+    environment: RAINBOND_TEST_TOKEN
+`
+	co, err := (&Compose{}).LoadBytesWithWorkDir([][]byte{[]byte(body)}, workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := co.ServiceConfigs["api"]
+	if len(api.Secrets) != 2 {
+		t.Fatalf("expected two secrets, got %+v", api.Secrets)
+	}
+	if secret := findFileObject(api.Secrets, "db_password"); secret == nil || secret.Content != "s3cr3t" {
+		t.Fatalf("unexpected file secret %+v", secret)
+	}
+	if secret := findFileObject(api.Secrets, "token"); secret == nil || secret.Target != "/run/secrets/app/token" || secret.Content != "token-value" {
+		t.Fatalf("unexpected environment secret %+v", secret)
+	}
+	if config := findFileObject(api.Configs, "app_config"); config == nil || config.Target != "/app_config" || config.Content != "listen: 8080\n" {
+		t.Fatalf("unexpected inline config %+v", config)
+	}
+	if countIssues(co.SupportReport, "secrets", SupportLevelDegraded) != 1 {
+		t.Fatalf("undefined secret should be reported as degraded, got %+v", co.SupportReport.Issues)
+	}
+	if co.SupportReport.HasErrors() {
+		t.Fatalf("secrets and configs should not be reported as unsupported, got %+v", co.SupportReport.Issues)
+	}
+}
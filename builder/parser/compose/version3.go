@@ -174,6 +174,8 @@ func dockerComposeToKomposeMapping(composeObject *types.Config) (ComposeObject,
 		ServiceConfigs: make(map[string]ServiceConfig),
 	}
 
+	secretSources, configSources := v3FileObjectSources(composeObject.Secrets, composeObject.Configs)
+
 	// Step 2. Parse through the object and convert it to ComposeObject!
 	// Here we "clean up" the service configuration so we return something that includes
 	// all relevant information as well as avoid the unsupported keys as well.
@@ -295,6 +297,17 @@ func dockerComposeToKomposeMapping(composeObject *types.Config) (ComposeObject,
 		// https://docs.docker.com/compose/compose-file/#long-syntax-2
 		serviceConfig.VolList = loadV3Volumes(composeServiceConfig.Volumes)
 
+		// Parse the secrets and configs, they are mounted as config files
+		var secretRefs, configRefs []fileObjectRef
+		for _, secret := range composeServiceConfig.Secrets {
+			secretRefs = append(secretRefs, fileObjectRef{source: secret.Source, target: secret.Target, mode: secret.Mode})
+		}
+		for _, config := range composeServiceConfig.Configs {
+			configRefs = append(configRefs, fileObjectRef{source: config.Source, target: config.Target, mode: config.Mode})
+		}
+		serviceConfig.Secrets = resolveFileObjects(name, secretRefs, secretSources, nil, true, report)
+		serviceConfig.Configs = resolveFileObjects(name, configRefs, configSources, nil, false, report)
+
 		// Label handler
 		// Labels used to influence conversion of kompose will be handled
 		// from here for docker-compose. Each loader will have such handler.
@@ -346,18 +359,16 @@ func checkV3UnsupportedFields(config *types.Config, report *FieldSupportReport)
 				"Rainbond manages networking automatically")
 		}
 
-		// Check for secrets (not supported)
+		// Check for secrets (stored in kubernetes secrets and mounted as config files)
 		if len(service.Secrets) > 0 {
-			report.AddUnsupported(serviceName, "secrets",
-				"Docker Compose secrets are not supported",
-				"Use Rainbond's configuration management or environment variables instead")
+			report.AddSupported(serviceName, "secrets",
+				"Docker Compose secrets are stored in Kubernetes Secrets and mounted as config files")
 		}
 
-		// Check for configs (not supported)
+		// Check for configs (mounted as config files)
 		if len(service.Configs) > 0 {
-			report.AddUnsupported(serviceName, "configs",
-				"Docker Compose configs are not supported",
-				"Use Rainbond's configuration file management instead")
+			report.AddSupported(serviceName, "configs",
+				"Docker Compose configs are mounted as config files")
 		}
 
 		// Check for logging (limited support)
@@ -112,6 +112,8 @@ func composeGoToKomposeMapping(project *composetypes.Project) (ComposeObject, *F
 		ServiceConfigs: make(map[string]ServiceConfig),
 	}
 
+	secretSources, configSources := specFileObjectSources(project)
+
 	// Convert each service
 	for _, service := range project.Services {
 		name := service.Name
@@ -153,6 +155,17 @@ func composeGoToKomposeMapping(project *composetypes.Project) (ComposeObject, *F
 		// Volumes
 		serviceConfig.Volumes, serviceConfig.VolList = convertSpecVolumes(name, service.Volumes, report)
 
+		// Secrets and configs are mounted as config files
+		var secretRefs, configRefs []fileObjectRef
+		for _, secret := range service.Secrets {
+			secretRefs = append(secretRefs, fileObjectRef{source: secret.Source, target: secret.Target, mode: secret.Mode})
+		}
+		for _, config := range service.Configs {
+			configRefs = append(configRefs, fileObjectRef{source: config.Source, target: config.Target, mode: config.Mode})
+		}
+		serviceConfig.Secrets = resolveFileObjects(name, secretRefs, secretSources, project.Environment, true, report)
+		serviceConfig.Configs = resolveFileObjects(name, configRefs, configSources, project.Environment, false, report)
+
 		// DependsOn - convert long format to simple array
 		serviceConfig.DependsON = convertSpecDependsOn(name, service.DependsOn, report)
 
@@ -462,18 +475,16 @@ func checkSpecUnsupportedFields(serviceName string, service *composetypes.Servic
 			"Rainbond manages networking automatically")
 	}
 
-	// Check for secrets (not supported)
+	// Check for secrets (stored in kubernetes secrets and mounted as config files)
 	if len(service.Secrets) > 0 {
-		report.AddUnsupported(serviceName, "secrets",
-			"Docker Compose secrets are not supported",
-			"Use Rainbond's configuration management or environment variables instead")
+		report.AddSupported(serviceName, "secrets",
+			"Docker Compose secrets are stored in Kubernetes Secrets and mounted as config files")
 	}
 
-	// Check for configs (not supported)
+	// Check for configs (mounted as config files)
 	if len(service.Configs) > 0 {
-		report.AddUnsupported(serviceName, "configs",
-			"Docker Compose configs are not supported",
-			"Use Rainbond's configuration file management instead")
+		report.AddSupported(serviceName, "configs",
+			"Docker Compose configs are mounted as config files")
 	}
 
 	// Check for external_links (not supported)
@@ -25,6 +25,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/docker/docker/client"
@@ -95,6 +96,19 @@ func (d *ServiceInfoFromDC) GetEnvs() (envs []types.Env) {
 	return
 }
 
+// composeFileMode 将 compose 中的八进制文件权限转换为平台使用的权限格式，如 0440 -> 440
+func composeFileMode(mode *uint32) *int32 {
+	if mode == nil {
+		return nil
+	}
+	m, err := strconv.Atoi(strconv.FormatUint(uint64(*mode), 8))
+	if err != nil {
+		return nil
+	}
+	res := int32(m)
+	return &res
+}
+
 //CreateDockerComposeParse create parser
 func CreateDockerComposeParse(source string, user, pass string, logger event.Logger) Parser {
 	return &DockerComposeParse{
@@ -281,6 +295,34 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 				FileContent: fileContent,
 			}
 		}
+		// configs 作为配置文件挂载
+		for _, c := range sc.Configs {
+			volumes[c.Target] = &types.Volume{
+				VolumePath:  c.Target,
+				VolumeType:  model.ConfigFileVolumeType.String(),
+				FileContent: c.Content,
+				Mode:        composeFileMode(c.Mode),
+			}
+		}
+		// secrets 以组件独立的 Secret 挂载到目标路径，内容不会保存在 ConfigMap 中，也不会注入为环境变量
+		for _, s := range sc.Secrets {
+			if exist, ok := volumes[s.Target]; ok {
+				kind := "volume"
+				if exist.Secret {
+					kind = "secret"
+				}
+				d.errappend(ErrorAndSolve(FatalError, fmt.Sprintf("服务%s的secret %s 挂载路径 %s 与其他%s冲突", kev, s.Name, s.Target, kind),
+					SolveAdvice("modify_compose", "请为secret指定不同的target")))
+				continue
+			}
+			volumes[s.Target] = &types.Volume{
+				VolumePath:  s.Target,
+				VolumeType:  model.ConfigFileVolumeType.String(),
+				FileContent: s.Content,
+				Secret:      true,
+				Mode:        composeFileMode(s.Mode),
+			}
+		}
 		envs := make(map[string]*types.Env)
 		for _, e := range sc.Environment {
 			envs[e.Name] = &types.Env{
@@ -490,6 +532,10 @@ func (d *DockerComposeParse) getSimplifiedWarningMessage(issue compose.FieldIssu
 		return fmt.Sprintf("服务 %s：自定义容器名称在多副本时会被自动生成", serviceName)
 	case "profiles":
 		return fmt.Sprintf("服务 %s：profiles 配置将被忽略，所有服务都会被部署", serviceName)
+	case "secrets":
+		return fmt.Sprintf("服务 %s：secret 无法导入（%s），请在应用配置组中手动添加", serviceName, issue.Message)
+	case "configs":
+		return fmt.Sprintf("服务 %s：config 无法导入（%s），请手动添加配置文件", serviceName, issue.Message)
 	default:
 		// Fallback to generic message
 		return fmt.Sprintf("服务 %s：%s 配置有限支持，可能会被调整", serviceName, field)
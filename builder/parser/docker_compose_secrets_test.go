@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/parser/types"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/event"
+)
+
+// capability_id: rainbond.compose.secrets-configs
+func TestDockerComposeParseSecretsAndConfigs(t *testing.T) {
+	composeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(composeDir, "db_password.txt"), []byte("s3cr3t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content := `version: "3.8"
+services:
+  web:
+    image: nginx
+    secrets:
+      - source: db-password
+        mode: 0400
+    configs:
+      - source: app_config
+        target: /etc/app/config.yml
+configs:
+  app_config:
+    content: "debug: true"
+secrets:
+  db-password:
+    file: ./db_password.txt
+`
+	parser := CreateDockerComposeParse(content, "", "", event.NewLogger("test-event", make(chan []byte, 100))).(*DockerComposeParse)
+	parser.composeDir = composeDir
+	parser.Parse()
+
+	services := parser.GetServiceInfo()
+	if len(services) != 1 {
+		t.Fatalf("expected one service, got %d", len(services))
+	}
+	volumes := make(map[string]types.Volume)
+	for _, v := range services[0].Volumes {
+		volumes[v.VolumePath] = v
+	}
+
+	secret, ok := volumes["/run/secrets/db-password"]
+	if !ok {
+		t.Fatalf("secret volume not found in %+v", services[0].Volumes)
+	}
+	if secret.VolumeType != model.ConfigFileVolumeType.String() || !secret.Secret || secret.FileContent != "s3cr3t" {
+		t.Fatalf("unexpected secret volume %+v", secret)
+	}
+	if secret.Mode == nil || *secret.Mode != 400 {
+		t.Fatalf("unexpected secret mode %v", secret.Mode)
+	}
+
+	config, ok := volumes["/etc/app/config.yml"]
+	if !ok || config.VolumeType != model.ConfigFileVolumeType.String() || config.FileContent != "debug: true" || config.Secret {
+		t.Fatalf("unexpected config volume %+v", config)
+	}
+}
+
+// capability_id: rainbond.compose.secrets-configs
+func TestDockerComposeParseSecretTargetConflict(t *testing.T) {
+	content := `version: "3.8"
+services:
+  web:
+    image: nginx
+    secrets:
+      - source: db-pass
+        target: password
+    configs:
+      - source: db_pass
+        target: /run/secrets/password
+configs:
+  db_pass:
+    content: "plain"
+secrets:
+  db-pass:
+    file: ./db_password.txt
+`
+	composeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(composeDir, "db_password.txt"), []byte("s3cr3t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	parser := CreateDockerComposeParse(content, "", "", event.NewLogger("test-event", make(chan []byte, 100))).(*DockerComposeParse)
+	parser.composeDir = composeDir
+	if errs := parser.Parse(); !errs.IsFatalError() {
+		t.Fatalf("expected a fatal error for a secret mounted over another file, got %+v", errs)
+	}
+}
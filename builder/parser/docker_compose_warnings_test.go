@@ -39,7 +39,7 @@ func TestDockerComposeParseWithWarnings(t *testing.T) {
 	}
 
 	// Check for current degraded warnings returned to API clients.
-	// Unsupported fields such as secrets/configs stay in SupportReport and are not appended to Parse errors.
+	// Unsupported fields such as ulimits stay in SupportReport and are not appended to Parse errors.
 	hasNetworkWarning := false
 	hasLoggingWarning := false
 
@@ -192,6 +192,11 @@ type ServiceInfo struct {
 	ServiceType    string         `json:"service_type,omitempty"`
 	Branchs        []string       `json:"branchs,omitempty"`
 	Memory         int            `json:"memory,omitempty"`
+	// CPU 请求的 CPU，单位 millicores
+	CPU            int            `json:"cpu,omitempty"`
+	Probes         []types.Probe  `json:"probes,omitempty"`
+	// PreDeployHooks 部署前执行的命令
+	PreDeployHooks []types.Hook   `json:"pre_deploy_hooks,omitempty"`
 	Lang           code.Lang      `json:"language,omitempty"`
 	ImageAlias     string         `json:"image_alias,omitempty"`
 	TarImages      []*types.Image `json:"tar_images,omitempty"`
@@ -43,6 +43,7 @@ import (
 	"github.com/melbahja/got"
 	"github.com/pquerna/ffjson/ffjson"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // SourceCodeParse docker run 命令解析或直接镜像名解析
@@ -68,6 +69,10 @@ type SourceCodeParse struct {
 	services      []*types.Service
 	runtimeInfo   *types.RuntimeInfo // structured detection results
 	buildStrategy string
+
+	// deploySpec rainbondfile 顶层定义的部署属性，serviceDeploySpecs 为 services 中按名称定义的部署属性
+	deploySpec         code.DeploySpec
+	serviceDeploySpecs map[string]code.DeploySpec
 }
 
 // CreateSourceCodeParse create parser
@@ -334,6 +339,28 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 		d.branchs = rs.Branchs
 		return nil
 	}
+	hgFunc := func() ParseErrorList {
+		csi.RepositoryURL = buildInfo.RepostoryURL
+		hgclient := sources.NewHgClient(csi, buildInfo.GetCodeHome(), d.logger)
+		rs, err := hgclient.Checkout()
+		if err != nil {
+			logrus.Errorf("hg checkout error,%s", err.Error())
+			d.errappend(ErrorAndSolve(FatalError, "获取代码失败"+err.Error(), "请确认仓库能否正常访问，以及指定的分支或标签是否存在"))
+			return d.errors
+		}
+		d.branchs = rs.Branchs
+		return nil
+	}
+	archiveFunc := func() ParseErrorList {
+		info, err := sources.NewArchiveSource(csi, buildInfo.GetCodeHome(), d.logger).Fetch()
+		if err != nil {
+			logrus.Errorf("fetch archive %s failure %s", csi.RepositoryURL, err.Error())
+			d.errappend(ErrorAndSolve(FatalError, "源码归档获取失败:"+err.Error(), "请确认归档地址可以被正常下载，且校验和与归档文件一致"))
+			return d.errors
+		}
+		logrus.Infof("fetch archive %s success, digest %s", info.FileName, info.Digest)
+		return nil
+	}
 	packageFunc := func() ParseErrorList {
 		var checkPath string
 		checkPath = buildInfo.RepostoryURL
@@ -432,6 +459,14 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 		if err := svnFunc(); err != nil && err.IsFatalError() {
 			return err
 		}
+	case "hg":
+		if err := hgFunc(); err != nil && err.IsFatalError() {
+			return err
+		}
+	case "archive":
+		if err := archiveFunc(); err != nil && err.IsFatalError() {
+			return err
+		}
 	case "oss":
 		if err := ossFunc(); err != nil && err.IsFatalError() {
 			return err
@@ -450,9 +485,9 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 
 	//read rainbondfile
 	rbdfileConfig, err := code.ReadRainbondFile(buildInfo.GetCodeBuildAbsPath())
-	if err != nil {
-		if err != code.ErrRainbondFileNotFound {
-			d.errappend(ErrorAndSolve(NegligibleError, "rainbondfile定义格式有误", "可以参考文档说明配置此文件定义应用属性"))
+	if err != nil && err != code.ErrRainbondFileNotFound {
+		if d.appendRainbondFileErrors(err) {
+			return d.errors
 		}
 	}
 	//判断对象目录
@@ -511,6 +546,18 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 		d.errappend(ErrorAndSolve(FatalError, "代码选择的运行时版本不支持", "请参考文档查看平台各语言支持的Runtime版本"))
 		return d.errors
 	}
+	if strings.EqualFold(strings.TrimSpace(csi.BuildStrategy), "cnb") && rbdfileConfig != nil {
+		if version, changed := rbdfileConfig.ResolveCNBVersion(lang, runtimeInfo["RUNTIMES"]); changed {
+			if runtimeInfo == nil {
+				runtimeInfo = make(map[string]string)
+			}
+			if runtimeInfo["RUNTIMES"] != "" {
+				d.errappend(ErrorAndSolve(NegligibleError, fmt.Sprintf("代码指定的版本 %s 不在 rainbondfile 允许的版本中，将使用 %s", runtimeInfo["RUNTIMES"], version), "请修改代码中的版本或 rainbondfile 的 cnb_version_policy"))
+			}
+			runtimeInfo["RUNTIMES"] = version
+			runtimeInfo["RUNTIMES_SOURCE"] = "rainbondfile"
+		}
+	}
 	applyRuntimeBuildEnvs(d.envs, runtimeInfo, csi.BuildStrategy)
 	// Build structured RuntimeInfo for API response
 	d.runtimeInfo = d.buildRuntimeInfo(runtimeInfo, lang)
@@ -608,6 +655,23 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 		if rbdfileConfig.Cmd != "" {
 			d.args = strings.Split(rbdfileConfig.Cmd, " ")
 		}
+		//handle profile build env
+		for k, v := range rbdfileConfig.BuildEnvs {
+			if !strings.HasPrefix(k, "BUILD_") {
+				k = "BUILD_" + k
+			}
+			d.envs[k] = &types.Env{Name: k, Value: fmt.Sprintf("%v", v)}
+		}
+		d.deploySpec = rbdfileConfig.DeploySpec
+		for _, svc := range rbdfileConfig.Services {
+			if svc == nil {
+				continue
+			}
+			if d.serviceDeploySpecs == nil {
+				d.serviceDeploySpecs = make(map[string]code.DeploySpec, len(rbdfileConfig.Services))
+			}
+			d.serviceDeploySpecs[svc.Name] = svc.DeploySpec
+		}
 	}
 
 	// CNB 构建默认端口
@@ -631,7 +695,9 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 func ReadRbdConfigAndLang(buildInfo *sources.RepostoryBuildInfo) (*code.RainbondFileConfig, code.Lang, error) {
 	rbdfileConfig, err := code.ReadRainbondFile(buildInfo.GetCodeBuildAbsPath())
 	if err != nil {
-		return nil, code.NO, err
+		if errs, ok := err.(code.RainbondFileErrors); !ok || errs.IsFatal() {
+			return nil, code.NO, err
+		}
 	}
 	var lang code.Lang
 	if rbdfileConfig != nil && rbdfileConfig.Language != "" {
@@ -647,7 +713,7 @@ func ReadRbdConfigAndLang(buildInfo *sources.RepostoryBuildInfo) (*code.Rainbond
 
 func getRecommendedMemory(lang code.Lang) int {
 	//java recommended 1024
-	if lang == code.JavaJar || lang == code.JavaMaven || lang == code.JaveWar || lang == code.Gradle {
+	if lang == code.JavaJar || lang == code.JavaMaven || lang == code.JaveWar || lang == code.Gradle || lang == code.Scala {
 		return 1024
 	}
 	if lang == code.Python {
@@ -789,6 +855,7 @@ func (d *SourceCodeParse) GetServiceInfo() []ServiceInfo {
 		Dockerfiles: d.dockerfiles,
 		RuntimeInfo: d.runtimeInfo,
 	}
+	applyDeploySpec(&serviceInfo, d.deploySpec)
 	var res []ServiceInfo
 	if d.isMulti && d.services != nil && len(d.services) > 0 {
 		serviceInfo.Lang = normalizeMultiModuleLanguage(serviceInfo.Lang)
@@ -805,6 +872,9 @@ func (d *SourceCodeParse) GetServiceInfo() []ServiceInfo {
 			for i := range svc.Ports {
 				info.Ports = append(info.Ports, *svc.Ports[i])
 			}
+			if spec, ok := d.serviceDeploySpecs[svc.Name]; ok {
+				applyDeploySpec(&info, spec)
+			}
 			res = append(res, info)
 		}
 	} else {
@@ -816,6 +886,107 @@ func (d *SourceCodeParse) GetServiceInfo() []ServiceInfo {
 	return res
 }
 
+// appendRainbondFileErrors 将 rainbondfile 的定义错误转换为解析错误，返回是否存在致命错误
+func (d *SourceCodeParse) appendRainbondFileErrors(err error) bool {
+	errs, ok := err.(code.RainbondFileErrors)
+	if !ok {
+		d.errappend(ErrorAndSolve(NegligibleError, "rainbondfile定义格式有误", "可以参考文档说明配置此文件定义应用属性"))
+		return false
+	}
+	for _, e := range errs {
+		errType := NegligibleError
+		if e.Fatal {
+			errType = FatalError
+		}
+		d.errappend(ErrorAndSolve(errType, fmt.Sprintf("rainbondfile定义有误, %s", e.Error()), "可以参考文档说明配置此文件定义应用属性"))
+	}
+	return errs.IsFatal()
+}
+
+// applyDeploySpec 将 rainbondfile 定义的部署属性应用到组件，services 中定义的属性覆盖顶层定义
+func applyDeploySpec(info *ServiceInfo, spec code.DeploySpec) {
+	if len(spec.Probes) > 0 {
+		info.Probes = nil
+		for _, p := range spec.Probes {
+			probe := types.Probe{
+				Mode:               p.Mode,
+				Scheme:             p.Scheme,
+				Path:               p.Path,
+				Port:               p.Port,
+				Cmd:                p.Cmd,
+				InitialDelaySecond: p.InitialDelaySeconds,
+				PeriodSecond:       p.PeriodSeconds,
+				TimeoutSecond:      p.TimeoutSeconds,
+				FailureThreshold:   p.FailureThreshold,
+				SuccessThreshold:   p.SuccessThreshold,
+			}
+			if probe.Mode == "" {
+				probe.Mode = "readiness"
+			}
+			if probe.Scheme == "" {
+				probe.Scheme = "tcp"
+			}
+			if probe.InitialDelaySecond == 0 {
+				probe.InitialDelaySecond = 4
+			}
+			if probe.PeriodSecond == 0 {
+				probe.PeriodSecond = 3
+			}
+			if probe.TimeoutSecond == 0 {
+				probe.TimeoutSecond = 5
+			}
+			if probe.FailureThreshold == 0 {
+				probe.FailureThreshold = 3
+			}
+			if probe.SuccessThreshold == 0 {
+				probe.SuccessThreshold = 1
+			}
+			info.Probes = append(info.Probes, probe)
+		}
+	}
+	if spec.Resources != nil {
+		if q, err := resource.ParseQuantity(spec.Resources.CPU); err == nil {
+			info.CPU = int(q.MilliValue())
+		}
+		if q, err := resource.ParseQuantity(spec.Resources.Memory); err == nil {
+			info.Memory = int(q.Value() / 1024 / 1024)
+		}
+	}
+	if len(spec.Volumes) > 0 {
+		volumes := make(map[string]types.Volume, len(info.Volumes)+len(spec.Volumes))
+		var paths []string
+		for _, v := range info.Volumes {
+			if _, ok := volumes[v.VolumePath]; !ok {
+				paths = append(paths, v.VolumePath)
+			}
+			volumes[v.VolumePath] = v
+		}
+		for _, v := range spec.Volumes {
+			volumeType := v.Type
+			if volumeType == "" {
+				volumeType = model.ShareFileVolumeType.String()
+			}
+			if _, ok := volumes[v.Path]; !ok {
+				paths = append(paths, v.Path)
+			}
+			volumes[v.Path] = types.Volume{VolumeName: v.Name, VolumePath: v.Path, VolumeType: volumeType, FileContent: v.Content}
+		}
+		info.Volumes = nil
+		for _, p := range paths {
+			info.Volumes = append(info.Volumes, volumes[p])
+		}
+	}
+	if len(spec.DependsOn) > 0 {
+		info.DependServices = append([]string{}, spec.DependsOn...)
+	}
+	if spec.Hooks != nil && len(spec.Hooks.PreDeploy) > 0 {
+		info.PreDeployHooks = nil
+		for _, h := range spec.Hooks.PreDeploy {
+			info.PreDeployHooks = append(info.PreDeployHooks, types.Hook{Name: h.Name, Command: h.Command, TimeoutSeconds: h.TimeoutSeconds})
+		}
+	}
+}
+
 func normalizeMultiModuleLanguage(lang code.Lang) code.Lang {
 	for _, part := range strings.Split(string(lang), ",") {
 		if strings.TrimSpace(part) == string(code.JavaMaven) {
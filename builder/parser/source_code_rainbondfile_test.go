@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/parser/code"
+	"github.com/goodrain/rainbond/builder/parser/types"
+)
+
+// capability_id: rainbond.source-rainbondfile.deploy-spec
+func TestGetServiceInfo_AppliesRainbondFileDeploySpec(t *testing.T) {
+	d := &SourceCodeParse{
+		Lang:    code.JavaMaven,
+		isMulti: true,
+		memory:  1024,
+		volumes: map[string]*types.Volume{},
+		services: []*types.Service{
+			{Name: "api"},
+			{Name: "worker"},
+		},
+		deploySpec: code.DeploySpec{
+			Probes:    []code.Probe{{Port: 8080}},
+			Resources: &code.Resources{CPU: "500m", Memory: "2Gi"},
+			Volumes:   []code.Volume{{Name: "data", Path: "/data"}},
+		},
+		serviceDeploySpecs: map[string]code.DeploySpec{
+			"api": {
+				Probes:    []code.Probe{{Mode: "liveness", Scheme: "http", Port: 8080, Path: "/actuator/health"}},
+				DependsOn: []string{"worker"},
+				Hooks:     &code.Hooks{PreDeploy: []code.Hook{{Name: "migrate", Command: "java -jar migrate.jar"}}},
+			},
+		},
+	}
+
+	infos := d.GetServiceInfo()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 services, got %d", len(infos))
+	}
+	api, worker := infos[0], infos[1]
+	if api.CPU != 500 || api.Memory != 2048 || worker.CPU != 500 || worker.Memory != 2048 {
+		t.Fatalf("resources applied incorrectly: api=%d/%d worker=%d/%d", api.CPU, api.Memory, worker.CPU, worker.Memory)
+	}
+	if len(api.Probes) != 1 || api.Probes[0].Scheme != "http" || api.Probes[0].Path != "/actuator/health" {
+		t.Fatalf("expected service probe to override top level probe, got %+v", api.Probes)
+	}
+	if len(worker.Probes) != 1 || worker.Probes[0].Mode != "readiness" || worker.Probes[0].Scheme != "tcp" || worker.Probes[0].PeriodSecond != 3 {
+		t.Fatalf("expected top level probe with defaults, got %+v", worker.Probes)
+	}
+	if len(worker.Volumes) != 1 || worker.Volumes[0].VolumePath != "/data" || worker.Volumes[0].VolumeType != "share-file" {
+		t.Fatalf("volumes applied incorrectly: %+v", worker.Volumes)
+	}
+	if len(api.DependServices) != 1 || api.DependServices[0] != "worker" || len(worker.DependServices) != 0 {
+		t.Fatalf("dependencies applied incorrectly: api=%v worker=%v", api.DependServices, worker.DependServices)
+	}
+	if len(api.PreDeployHooks) != 1 || api.PreDeployHooks[0].Command != "java -jar migrate.jar" || len(worker.PreDeployHooks) != 0 {
+		t.Fatalf("hooks applied incorrectly: api=%+v worker=%+v", api.PreDeployHooks, worker.PreDeployHooks)
+	}
+}
+
+// capability_id: rainbond.source-rainbondfile.parse-errors
+func TestAppendRainbondFileErrors(t *testing.T) {
+	d := &SourceCodeParse{}
+	_, errs := code.ParseRainbondFile([]byte("language: Node.js\nprobs: []\nports:\n- port: 0\n"))
+
+	if fatal := d.appendRainbondFileErrors(errs); !fatal {
+		t.Fatalf("expected fatal rainbondfile errors, got %v", errs)
+	}
+	if len(d.errors) != 2 {
+		t.Fatalf("expected 2 parse errors, got %v", d.errors)
+	}
+	if d.errors[0].ErrorType != NegligibleError || d.errors[0].ErrorInfo != "rainbondfile定义有误, line 2: probs: unknown field" {
+		t.Fatalf("unexpected unknown field error %+v", d.errors[0])
+	}
+	if d.errors[1].ErrorType != FatalError || d.errors[1].ErrorInfo != "rainbondfile定义有误, line 4: ports[0].port: port must be between 1 and 65535" {
+		t.Fatalf("unexpected port error %+v", d.errors[1])
+	}
+}
@@ -36,9 +36,35 @@ type Port struct {
 
 // Volume -
 type Volume struct {
+	VolumeName  string `json:"volume_name,omitempty"`
 	VolumePath  string `json:"volume_path"`
 	VolumeType  string `json:"volume_type"`
 	FileContent string `json:"file_content,omitempty"`
+	// Secret 为 true 时配置文件内容以组件独立的 Secret 存储并挂载
+	Secret bool `json:"secret,omitempty"`
+	// Mode 文件权限，如 644
+	Mode *int32 `json:"mode,omitempty"`
+}
+
+// Probe 健康检测，字段与 TenantServiceProbe 一致
+type Probe struct {
+	Mode               string `json:"mode"`
+	Scheme             string `json:"scheme"`
+	Path               string `json:"path,omitempty"`
+	Port               int    `json:"port,omitempty"`
+	Cmd                string `json:"cmd,omitempty"`
+	InitialDelaySecond int    `json:"initial_delay_second"`
+	PeriodSecond       int    `json:"period_second"`
+	TimeoutSecond      int    `json:"timeout_second"`
+	FailureThreshold   int    `json:"failure_threshold"`
+	SuccessThreshold   int    `json:"success_threshold"`
+}
+
+// Hook 部署前使用组件镜像执行的命令
+type Hook struct {
+	Name           string `json:"name,omitempty"`
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
 }
 
 // Env env desc
@@ -0,0 +1,197 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// osPackageDBs 镜像中系统包管理器的数据库文件及其解析函数
+var osPackageDBs = map[string]func(content string) []Package{
+	"var/lib/dpkg/status":  parseDpkgStatus,
+	"lib/apk/db/installed": parseApkInstalled,
+}
+
+// osReleaseFile 用于识别镜像发行版的文件
+const osReleaseFile = "etc/os-release"
+
+// ImagePackages 从镜像仓库读取镜像各层，返回最终文件系统中系统包管理器记录的软件包。
+// 上层对包数据库的修改或删除会覆盖下层。
+func ImagePackages(imageName, user, password string) ([]Package, error) {
+	ref, err := name.ParseReference(imageName, name.Insecure)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse image name %s", imageName)
+	}
+	options := []remote.Option{remote.WithTransport(&http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})}
+	if user != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{Username: user, Password: password}))
+	}
+	img, err := remote.Image(ref, options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get image %s", imageName)
+	}
+	return imagePackages(img)
+}
+
+func imagePackages(img v1.Image) ([]Package, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, errors.Wrap(err, "get image layers")
+	}
+	files := make(map[string]string)
+	for _, layer := range layers {
+		if err := readLayerFiles(layer, files); err != nil {
+			return nil, err
+		}
+	}
+	distro := parseOSReleaseID(files[osReleaseFile])
+	var pkgs []Package
+	for file, parse := range osPackageDBs {
+		content, ok := files[file]
+		if !ok {
+			continue
+		}
+		for _, pkg := range parse(content) {
+			pkg.Source = "/" + file
+			pkg.Distro = distro
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+// readLayerFiles 读取镜像层中的包数据库文件，处理 whiteout 删除标记
+func readLayerFiles(layer v1.Layer, files map[string]string) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return errors.Wrap(err, "read image layer")
+	}
+	defer rc.Close()
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read image layer")
+		}
+		file := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(file)
+		if base == ".wh..wh..opq" {
+			for existing := range files {
+				if strings.HasPrefix(existing, dir) {
+					delete(files, existing)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			delete(files, dir+strings.TrimPrefix(base, ".wh."))
+			continue
+		}
+		if _, ok := osPackageDBs[file]; !ok && file != osReleaseFile {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		body, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.Wrapf(err, "read %s from image layer", file)
+		}
+		files[file] = string(body)
+	}
+}
+
+// parseDpkgStatus 解析 dpkg 的 status 文件，只返回已安装的包
+func parseDpkgStatus(content string) (pkgs []Package) {
+	var name, version, status string
+	flush := func() {
+		if name != "" && version != "" && strings.HasSuffix(status, " installed") {
+			pkgs = append(pkgs, Package{Name: name, Version: version, Type: "deb"})
+		}
+		name, version, status = "", "", ""
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "Package: "):
+			name = strings.TrimPrefix(line, "Package: ")
+		case strings.HasPrefix(line, "Version: "):
+			version = strings.TrimPrefix(line, "Version: ")
+		case strings.HasPrefix(line, "Status: "):
+			status = strings.TrimPrefix(line, "Status: ")
+		}
+	}
+	flush()
+	return pkgs
+}
+
+// parseApkInstalled 解析 apk 的 installed 数据库
+func parseApkInstalled(content string) (pkgs []Package) {
+	var name, version string
+	flush := func() {
+		if name != "" && version != "" {
+			pkgs = append(pkgs, Package{Name: name, Version: version, Type: "apk"})
+		}
+		name, version = "", ""
+	}
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "P:"):
+			name = line[2:]
+		case strings.HasPrefix(line, "V:"):
+			version = line[2:]
+		}
+	}
+	flush()
+	return pkgs
+}
+
+func parseOSReleaseID(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "ID=") {
+			return strings.Trim(strings.TrimPrefix(line, "ID="), `"'`)
+		}
+	}
+	return ""
+}
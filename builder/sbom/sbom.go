@@ -0,0 +1,194 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sbom 为构建产出的镜像生成软件物料清单（SBOM），并根据本地漏洞库检查其中的软件包。
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/builder/parser/code"
+	"github.com/goodrain/rainbond/util"
+)
+
+// FormatCycloneDX 生成的 SBOM 格式
+const FormatCycloneDX = "CycloneDX-1.5-json"
+
+// Package SBOM 中的软件包
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Type 与 purl 的 type 一致，如 deb、apk、npm、pypi、maven、golang
+	Type string `json:"type"`
+	// Source 软件包的来源，镜像层中的包数据库或源码中的依赖锁文件
+	Source string `json:"source"`
+	// Distro 系统软件包所属的发行版，如 debian、alpine
+	Distro string `json:"distro,omitempty"`
+}
+
+// PURL 软件包的 package url
+func (p Package) PURL() string {
+	name := p.Name
+	namespace := ""
+	switch p.Type {
+	case "maven":
+		if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+	case "deb", "apk":
+		namespace = p.Distro
+	default:
+		if i := strings.LastIndex(name, "/"); i > 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	}
+	purl := "pkg:" + p.Type + "/"
+	if namespace != "" {
+		var segments []string
+		for _, segment := range strings.Split(namespace, "/") {
+			segments = append(segments, strings.ReplaceAll(url.PathEscape(segment), "@", "%40"))
+		}
+		purl += strings.Join(segments, "/") + "/"
+	}
+	return purl + url.PathEscape(name) + "@" + url.PathEscape(p.Version)
+}
+
+// FromDependencies 将源码依赖锁文件中的依赖转换为 SBOM 软件包
+func FromDependencies(deps []code.Dependency) []Package {
+	pkgs := make([]Package, 0, len(deps))
+	for _, dep := range deps {
+		pkgs = append(pkgs, Package{Name: dep.Name, Version: dep.Version, Type: dep.Ecosystem, Source: dep.Source})
+	}
+	return pkgs
+}
+
+type cyclonedxDocument struct {
+	BOMFormat       string               `json:"bomFormat"`
+	SpecVersion     string               `json:"specVersion"`
+	SerialNumber    string               `json:"serialNumber"`
+	Version         int                  `json:"version"`
+	Metadata        cyclonedxMetadata    `json:"metadata"`
+	Components      []cyclonedxComponent `json:"components"`
+	Vulnerabilities []cyclonedxVuln      `json:"vulnerabilities,omitempty"`
+}
+
+type cyclonedxMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cyclonedxTool    `json:"tools"`
+	Component cyclonedxComponent `json:"component"`
+}
+
+type cyclonedxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cyclonedxComponent struct {
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Type       string              `json:"type"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cyclonedxProperty `json:"properties,omitempty"`
+}
+
+type cyclonedxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cyclonedxVuln struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	Ratings     []cyclonedxRating `json:"ratings,omitempty"`
+	Affects     []cyclonedxAffect `json:"affects"`
+}
+
+type cyclonedxRating struct {
+	Severity string `json:"severity"`
+}
+
+type cyclonedxAffect struct {
+	Ref string `json:"ref"`
+}
+
+// Generate 生成镜像的 CycloneDX JSON 文档，findings 为漏洞库匹配结果，会一并写入文档
+func Generate(imageName string, pkgs []Package, findings []Finding) ([]byte, error) {
+	pkgs = Dedup(pkgs)
+	doc := cyclonedxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuidFormat(util.NewUUID()),
+		Version:      1,
+		Metadata: cyclonedxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     []cyclonedxTool{{Vendor: "goodrain", Name: "rainbond-builder"}},
+			Component: cyclonedxComponent{Type: "container", Name: imageName},
+		},
+		Components: make([]cyclonedxComponent, 0, len(pkgs)),
+	}
+	for _, pkg := range pkgs {
+		purl := pkg.PURL()
+		doc.Components = append(doc.Components, cyclonedxComponent{
+			BOMRef:     purl,
+			Type:       "library",
+			Name:       pkg.Name,
+			Version:    pkg.Version,
+			PURL:       purl,
+			Properties: []cyclonedxProperty{{Name: "rainbond:source", Value: pkg.Source}},
+		})
+	}
+	for _, finding := range findings {
+		doc.Vulnerabilities = append(doc.Vulnerabilities, cyclonedxVuln{
+			ID:          finding.ID,
+			Description: finding.Summary,
+			Ratings:     []cyclonedxRating{{Severity: finding.Severity}},
+			Affects:     []cyclonedxAffect{{Ref: finding.Package.PURL()}},
+		})
+	}
+	return json.Marshal(doc)
+}
+
+// Dedup 按 purl 去重并排序，镜像层与锁文件中重复出现的包只保留一个
+func Dedup(pkgs []Package) []Package {
+	seen := make(map[string]bool, len(pkgs))
+	var result []Package
+	for _, pkg := range pkgs {
+		purl := pkg.PURL()
+		if seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PURL() < result[j].PURL() })
+	return result
+}
+
+// uuidFormat 将不带分隔符的 uuid 转换为标准格式
+func uuidFormat(id string) string {
+	if len(id) != 32 {
+		return id
+	}
+	return fmt.Sprintf("%s-%s-%s-%s-%s", id[0:8], id[8:12], id[12:16], id[16:20], id[20:])
+}
@@ -0,0 +1,138 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// capability_id: rainbond.sbom.cyclonedx-document
+func TestGenerateCycloneDX(t *testing.T) {
+	pkgs := []Package{
+		{Name: "openssl", Version: "3.0.11-1~deb12u1", Type: "deb", Distro: "debian", Source: "/var/lib/dpkg/status"},
+		{Name: "org.springframework:spring-core", Version: "5.3.20", Type: "maven", Source: "pom.xml"},
+		{Name: "@babel/core", Version: "7.22.0", Type: "npm", Source: "package-lock.json"},
+		{Name: "@babel/core", Version: "7.22.0", Type: "npm", Source: "yarn.lock"},
+	}
+	findings := []Finding{{Vulnerability: Vulnerability{ID: "CVE-2023-0001", Severity: "high"}, Package: pkgs[0]}}
+	body, err := Generate("goodrain.me/demo:20240101", pkgs, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc cyclonedxDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.Metadata.Component.Name != "goodrain.me/demo:20240101" {
+		t.Fatalf("unexpected document metadata: %+v", doc)
+	}
+	var purls []string
+	for _, component := range doc.Components {
+		purls = append(purls, component.PURL)
+	}
+	want := []string{
+		"pkg:deb/debian/openssl@3.0.11-1~deb12u1",
+		"pkg:maven/org.springframework/spring-core@5.3.20",
+		"pkg:npm/%40babel/core@7.22.0",
+	}
+	if strings.Join(purls, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected components %v, got %v", want, purls)
+	}
+	if len(doc.Vulnerabilities) != 1 || doc.Vulnerabilities[0].Affects[0].Ref != want[0] {
+		t.Fatalf("unexpected vulnerabilities: %+v", doc.Vulnerabilities)
+	}
+}
+
+// capability_id: rainbond.sbom.os-packages
+func TestParseOSPackageDatabases(t *testing.T) {
+	dpkg := "Package: libc6\nStatus: install ok installed\nVersion: 2.36-9\n\nPackage: removed\nStatus: deinstall ok config-files\nVersion: 1.0\n"
+	if pkgs := parseDpkgStatus(dpkg); len(pkgs) != 1 || pkgs[0].Name != "libc6" || pkgs[0].Version != "2.36-9" {
+		t.Fatalf("unexpected dpkg packages: %+v", pkgs)
+	}
+	apk := "C:Q1abc=\nP:musl\nV:1.2.4-r2\n\nP:busybox\nV:1.36.1-r5\n"
+	if pkgs := parseApkInstalled(apk); len(pkgs) != 2 || pkgs[1].Name != "busybox" || pkgs[1].Version != "1.36.1-r5" {
+		t.Fatalf("unexpected apk packages: %+v", pkgs)
+	}
+	if id := parseOSReleaseID("NAME=\"Alpine Linux\"\nID=alpine\n"); id != "alpine" {
+		t.Fatalf("expected alpine, got %q", id)
+	}
+}
+
+// capability_id: rainbond.sbom.vulnerability-gate
+func TestVulnerabilityMatchAndPolicy(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "vulns.json")
+	content := `{"vulnerabilities":[
+{"id":"CVE-2021-23337","ecosystem":"npm","package":"lodash","severity":"high","ranges":[{"fixed":"4.17.21"}]},
+{"id":"CVE-2023-0002","ecosystem":"pypi","package":"Django","severity":"medium","versions":["4.2.1"]},
+{"id":"CVE-2022-0003","ecosystem":"npm","package":"lodash","severity":"critical","ranges":[{"introduced":"3.0.0","fixed":"3.10.0"}]}
+]}`
+	if err := os.WriteFile(dbFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadDatabase(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := db.Match([]Package{
+		{Name: "lodash", Version: "4.17.20", Type: "npm"},
+		{Name: "django", Version: "4.2.1", Type: "pypi"},
+		{Name: "lodash", Version: "4.17.21", Type: "npm"},
+	})
+	if len(findings) != 2 || findings[0].ID != "CVE-2021-23337" || findings[1].ID != "CVE-2023-0002" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	if result, err := (Policy{Mode: PolicyWarn}).Evaluate(findings); result != "warn" || err != nil {
+		t.Fatalf("warn policy: expected warn without error, got %s %v", result, err)
+	}
+	if result, err := (Policy{Mode: PolicyFail, FailSeverity: "critical"}).Evaluate(findings); result != "warn" || err != nil {
+		t.Fatalf("fail policy below threshold: expected warn without error, got %s %v", result, err)
+	}
+	result, err := (Policy{Mode: PolicyFail, FailSeverity: "high"}).Evaluate(findings)
+	if result != "fail" || err == nil || !strings.Contains(err.Error(), "CVE-2021-23337") {
+		t.Fatalf("fail policy: expected failure for CVE-2021-23337, got %s %v", result, err)
+	}
+	if result, err := (Policy{Mode: PolicyFail, FailSeverity: "high"}).Evaluate(nil); result != "pass" || err != nil {
+		t.Fatalf("expected pass without findings, got %s %v", result, err)
+	}
+}
+
+// capability_id: rainbond.sbom.vulnerability-gate
+func TestCompareVersion(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"4.17.20", "4.17.21", -1},
+		{"v1.10.0", "1.9.3", 1},
+		{"1.0", "1.0.0", 0},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"2.36-9", "2.36-10", -1},
+		{"1.2.4-r2", "1.2.4-r2", 0},
+	}
+	for _, c := range cases {
+		if got := compareVersion(c.a, c.b); got != c.want {
+			t.Errorf("compareVersion(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
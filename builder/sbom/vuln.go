@@ -0,0 +1,267 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// 漏洞等级，按严重程度递增
+var severityLevels = map[string]int{
+	"unknown":  0,
+	"low":      1,
+	"medium":   2,
+	"moderate": 2,
+	"high":     3,
+	"critical": 4,
+}
+
+// Vulnerability 本地漏洞库中的一条记录。
+// 受影响的版本可以通过 Versions 精确列出，也可以通过 Ranges 给出 [Introduced, Fixed) 区间。
+type Vulnerability struct {
+	ID        string         `json:"id"`
+	Ecosystem string         `json:"ecosystem"`
+	Package   string         `json:"package"`
+	Severity  string         `json:"severity"`
+	Summary   string         `json:"summary"`
+	Versions  []string       `json:"versions,omitempty"`
+	Ranges    []VersionRange `json:"ranges,omitempty"`
+}
+
+// VersionRange 受影响的版本区间，Introduced 为空表示所有更早版本，Fixed 为空表示尚未修复
+type VersionRange struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+// Database 本地漏洞库
+type Database struct {
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	index           map[string][]Vulnerability
+}
+
+// Finding 软件包命中的漏洞
+type Finding struct {
+	Vulnerability
+	Package Package `json:"package"`
+}
+
+// LoadDatabase 加载 JSON 格式的本地漏洞库
+func LoadDatabase(file string) (*Database, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read vulnerability database %s", file)
+	}
+	var db Database
+	if err := json.Unmarshal(body, &db); err != nil {
+		return nil, errors.Wrapf(err, "decode vulnerability database %s", file)
+	}
+	db.buildIndex()
+	return &db, nil
+}
+
+func (d *Database) buildIndex() {
+	d.index = make(map[string][]Vulnerability, len(d.Vulnerabilities))
+	for _, vuln := range d.Vulnerabilities {
+		key := indexKey(vuln.Ecosystem, vuln.Package)
+		d.index[key] = append(d.index[key], vuln)
+	}
+}
+
+func indexKey(ecosystem, pkg string) string {
+	ecosystem = strings.ToLower(ecosystem)
+	if ecosystem == "pypi" {
+		pkg = strings.ToLower(pkg)
+	}
+	return ecosystem + "/" + pkg
+}
+
+// Match 返回命中漏洞库的软件包，结果按严重程度从高到低排序
+func (d *Database) Match(pkgs []Package) []Finding {
+	if d.index == nil {
+		d.buildIndex()
+	}
+	var findings []Finding
+	for _, pkg := range Dedup(pkgs) {
+		for _, vuln := range d.index[indexKey(pkg.Type, pkg.Name)] {
+			if vuln.affects(pkg.Version) {
+				findings = append(findings, Finding{Vulnerability: vuln, Package: pkg})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityLevel(findings[i].Severity) > severityLevel(findings[j].Severity)
+	})
+	return findings
+}
+
+func (v Vulnerability) affects(version string) bool {
+	for _, affected := range v.Versions {
+		if compareVersion(affected, version) == 0 {
+			return true
+		}
+	}
+	for _, r := range v.Ranges {
+		if r.Introduced != "" && r.Introduced != "0" && compareVersion(version, r.Introduced) < 0 {
+			continue
+		}
+		if r.Fixed != "" && compareVersion(version, r.Fixed) >= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func severityLevel(severity string) int {
+	return severityLevels[strings.ToLower(severity)]
+}
+
+// compareVersion 比较两个版本号，数字段按数值比较，其余按字典序比较。
+// 可以处理 semver、PEP 440 常见写法以及 deb/apk 的版本号，不追求与各生态完全一致。
+func compareVersion(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareSegment(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func versionSegments(version string) []string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	var segments []string
+	var current []rune
+	isDigit := false
+	for _, r := range version {
+		switch {
+		case unicode.IsDigit(r):
+			if len(current) > 0 && !isDigit {
+				segments = append(segments, string(current))
+				current = nil
+			}
+			isDigit = true
+			current = append(current, r)
+		case unicode.IsLetter(r):
+			if len(current) > 0 && isDigit {
+				segments = append(segments, string(current))
+				current = nil
+			}
+			isDigit = false
+			current = append(current, r)
+		default:
+			if len(current) > 0 {
+				segments = append(segments, string(current))
+				current = nil
+			}
+		}
+	}
+	if len(current) > 0 {
+		segments = append(segments, string(current))
+	}
+	return segments
+}
+
+// compareSegment 比较版本号中的一段，缺失的段视为 0；字母段（如 rc、beta）小于缺失的段
+func compareSegment(x, y string) int {
+	xn, xerr := strconv.Atoi(x)
+	yn, yerr := strconv.Atoi(y)
+	switch {
+	case x == y:
+		return 0
+	case x == "" && yerr == nil:
+		return compareInt(0, yn)
+	case y == "" && xerr == nil:
+		return compareInt(xn, 0)
+	case x == "":
+		return 1
+	case y == "":
+		return -1
+	case xerr == nil && yerr == nil:
+		return compareInt(xn, yn)
+	case xerr == nil:
+		return 1
+	case yerr == nil:
+		return -1
+	}
+	return strings.Compare(x, y)
+}
+
+func compareInt(x, y int) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+const (
+	// PolicyOff 不检查漏洞
+	PolicyOff = "off"
+	// PolicyWarn 命中漏洞时只在构建日志中告警
+	PolicyWarn = "warn"
+	// PolicyFail 命中达到等级的漏洞时构建失败
+	PolicyFail = "fail"
+)
+
+// Policy 漏洞检查策略
+type Policy struct {
+	Mode string
+	// FailSeverity Mode 为 fail 时导致构建失败的最低漏洞等级
+	FailSeverity string
+}
+
+// Evaluate 根据检查策略评估漏洞匹配结果，返回记录到版本中的结果（pass、warn、fail）以及需要使构建失败的错误
+func (p Policy) Evaluate(findings []Finding) (string, error) {
+	if len(findings) == 0 {
+		return "pass", nil
+	}
+	if p.Mode != PolicyFail {
+		return "warn", nil
+	}
+	threshold := severityLevel(p.FailSeverity)
+	var blocking []string
+	for _, finding := range findings {
+		if severityLevel(finding.Severity) >= threshold {
+			blocking = append(blocking, fmt.Sprintf("%s(%s %s@%s)", finding.ID, finding.Severity, finding.Package.Name, finding.Package.Version))
+		}
+	}
+	if len(blocking) == 0 {
+		return "warn", nil
+	}
+	return "fail", fmt.Errorf("image contains %d vulnerabilities with severity %s or above: %s", len(blocking), p.FailSeverity, strings.Join(blocking, ", "))
+}
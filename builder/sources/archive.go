@@ -0,0 +1,415 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sources
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ArchiveInfo 归档源码的下载结果
+type ArchiveInfo struct {
+	// FileName 归档文件名，如 app-1.2.0.tar.gz
+	FileName string
+	// Digest 归档文件的校验和，如 sha256:<hex>
+	Digest string
+	// Verified 是否与指定的校验和一致，未指定校验和时为 false
+	Verified bool
+}
+
+// archiveMaxSize 归档文件的大小上限，超过时中止下载
+var archiveMaxSize int64 = 2 << 30
+
+// ArchiveSource 从版本化的归档地址下载 .tar.gz/.tgz/.tar/.zip 源码包，校验后解压到代码目录
+type ArchiveSource struct {
+	csi      CodeSourceInfo
+	codeHome string
+	logger   event.Logger
+	client   *http.Client
+}
+
+// NewArchiveSource new archive url source
+func NewArchiveSource(csi CodeSourceInfo, codeHome string, logger event.Logger) *ArchiveSource {
+	return &ArchiveSource{
+		csi:      csi,
+		codeHome: codeHome,
+		logger:   logger,
+		client:   &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+// archiveChecksum 期望的校验和
+type archiveChecksum struct {
+	algorithm string
+	value     string
+}
+
+// parseArchiveChecksum 解析 sha256:<hex>、sha512:<hex>、sha256=<hex> 或不带算法的十六进制校验和
+func parseArchiveChecksum(checksum string) (*archiveChecksum, error) {
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	if checksum == "" {
+		return nil, nil
+	}
+	algorithm, value := "", checksum
+	if index := strings.IndexAny(checksum, ":="); index > -1 {
+		algorithm, value = checksum[:index], checksum[index+1:]
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return nil, fmt.Errorf("invalid checksum %s", checksum)
+	}
+	if algorithm == "" {
+		switch len(value) {
+		case sha256.Size * 2:
+			algorithm = "sha256"
+		case sha512.Size * 2:
+			algorithm = "sha512"
+		}
+	}
+	switch {
+	case algorithm == "sha256" && len(value) == sha256.Size*2:
+	case algorithm == "sha512" && len(value) == sha512.Size*2:
+	default:
+		return nil, fmt.Errorf("unsupported checksum %s, only sha256 and sha512 are supported", checksum)
+	}
+	return &archiveChecksum{algorithm: algorithm, value: value}, nil
+}
+
+func (c *archiveChecksum) hash() hash.Hash {
+	if c != nil && c.algorithm == "sha512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// archiveExt 根据文件名判断归档格式
+func archiveExt(name string) (string, bool) {
+	lowerName := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lowerName, ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// Fetch 下载归档文件，校验通过后解压到代码目录。
+// 校验和依次取自构建参数 checksum、地址中的 #sha256=<hex>，以及与归档同名的 .sha256 文件。
+func (a *ArchiveSource) Fetch() (*ArchiveInfo, error) {
+	archiveURL, err := url.Parse(a.csi.RepositoryURL)
+	if err != nil || (archiveURL.Scheme != "http" && archiveURL.Scheme != "https") {
+		return nil, fmt.Errorf("invalid archive url %s", a.csi.RepositoryURL)
+	}
+	fragment := archiveURL.Fragment
+	archiveURL.Fragment = ""
+	fileName := path.Base(archiveURL.Path)
+	ext, ok := archiveExt(fileName)
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive %s, only .tar.gz, .tgz, .tar and .zip are supported", fileName)
+	}
+	checksumValue := a.csi.Checksum
+	if checksumValue == "" {
+		checksumValue = fragment
+	}
+	expected, err := parseArchiveChecksum(checksumValue)
+	if err != nil {
+		return nil, err
+	}
+	if expected == nil {
+		if expected, err = a.sidecarChecksum(archiveURL.String()); err != nil {
+			return nil, err
+		}
+	}
+	if expected == nil {
+		a.logger.Info(fmt.Sprintf("no checksum is provided for %s, skip the checksum verification", fileName), map[string]string{"step": "builder-exector"})
+	}
+
+	if err := os.RemoveAll(a.codeHome); err != nil {
+		return nil, err
+	}
+	if err := util.CheckAndCreateDir(a.codeHome); err != nil {
+		return nil, err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(a.codeHome), "archive-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpFile.Name())
+	a.logger.Info(fmt.Sprintf("start download archive %s", fileName), map[string]string{"step": "builder-exector"})
+	h := expected.hash()
+	size, err := a.download(archiveURL.String(), io.MultiWriter(tmpFile, h))
+	tmpFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	info := &ArchiveInfo{FileName: fileName}
+	algorithm := "sha256"
+	if expected != nil {
+		algorithm = expected.algorithm
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	info.Digest = algorithm + ":" + actual
+	if expected != nil {
+		if actual != expected.value {
+			return nil, fmt.Errorf("checksum mismatch for %s, expected %s:%s, got %s", fileName, expected.algorithm, expected.value, info.Digest)
+		}
+		info.Verified = true
+	}
+	logrus.Infof("download archive %s success, size %d KB, digest %s", fileName, size/1024, info.Digest)
+
+	switch ext {
+	case ".zip":
+		err = util.Unzip(tmpFile.Name(), a.codeHome)
+	default:
+		if err = untarArchive(tmpFile.Name(), a.codeHome, ext != ".tar"); err == nil {
+			err = flattenSingleDir(a.codeHome)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unpack archive %s: %v", fileName, err)
+	}
+	a.logger.Info(fmt.Sprintf("unpack archive %s success", fileName), map[string]string{"step": "builder-exector"})
+	return info, nil
+}
+
+// sidecarChecksum 读取与归档同名的 .sha256 文件，文件不存在时返回 nil
+func (a *ArchiveSource) sidecarChecksum(archiveURL string) (*archiveChecksum, error) {
+	req, err := a.newRequest(archiveURL + ".sha256")
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		logrus.Debugf("get checksum file of %s: %v", archiveURL, err)
+		return nil, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	// 文件格式与 sha256sum 的输出一致：<hex>  <file name>
+	line, err := bufio.NewReader(io.LimitReader(res.Body, 4096)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return parseArchiveChecksum("sha256:" + fields[0])
+}
+
+func (a *ArchiveSource) newRequest(rawURL string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.csi.User != "" {
+		req.SetBasicAuth(a.csi.User, a.csi.Password)
+	}
+	return req, nil
+}
+
+func (a *ArchiveSource) download(rawURL string, w io.Writer) (int64, error) {
+	req, err := a.newRequest(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("download archive: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("download archive: unexpected status %s", res.Status)
+	}
+	if res.ContentLength > archiveMaxSize {
+		return 0, fmt.Errorf("download archive: size %d exceeds the limit of %d bytes", res.ContentLength, archiveMaxSize)
+	}
+	// 多读取一个字节用于判断是否超出上限
+	size, err := io.Copy(w, io.LimitReader(res.Body, archiveMaxSize+1))
+	if err != nil {
+		return size, err
+	}
+	if size > archiveMaxSize {
+		return size, fmt.Errorf("download archive: size exceeds the limit of %d bytes", archiveMaxSize)
+	}
+	return size, nil
+}
+
+// untarArchive 解压 tar 包，拒绝解压到目标目录之外的文件
+func untarArchive(archive, target string, gz bool) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gz {
+		gzr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+	root, err := filepath.EvalSymlinks(filepath.Clean(target))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(root, hdr.Name)
+		if !withinDir(root, dest) {
+			return fmt.Errorf("invalid file path: %s (path traversal attempt)", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			dir, err := resolveInRoot(root, dest)
+			if err != nil {
+				return fmt.Errorf("invalid file path: %s (%v)", hdr.Name, err)
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			// 每次写入前都重新解析父目录，防止经由先前解压的符号链接写到目标目录之外
+			parent, err := resolveInRoot(root, filepath.Dir(dest))
+			if err != nil {
+				return fmt.Errorf("invalid file path: %s (%v)", hdr.Name, err)
+			}
+			if err := os.MkdirAll(parent, 0755); err != nil {
+				return err
+			}
+			dest = filepath.Join(parent, filepath.Base(dest))
+			// 同名的符号链接直接替换，不跟随写入
+			if fi, err := os.Lstat(dest); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(dest); err != nil {
+					return err
+				}
+			}
+			out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			parent, err := resolveInRoot(root, filepath.Dir(dest))
+			if err != nil {
+				return fmt.Errorf("invalid symlink: %s (%v)", hdr.Name, err)
+			}
+			if filepath.IsAbs(hdr.Linkname) || !withinDir(root, filepath.Join(parent, hdr.Linkname)) {
+				return fmt.Errorf("invalid symlink: %s -> %s", hdr.Name, hdr.Linkname)
+			}
+			if err := os.MkdirAll(parent, 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, filepath.Join(parent, filepath.Base(dest))); err != nil {
+				return err
+			}
+		default:
+			logrus.Debugf("skip unsupported tar entry %s", hdr.Name)
+		}
+	}
+}
+
+// withinDir 判断 p 是否为 root 或其子路径
+func withinDir(root, p string) bool {
+	return p == root || strings.HasPrefix(p, root+string(os.PathSeparator))
+}
+
+// resolveInRoot 解析 p 中已存在部分的符号链接，返回真实路径，解析结果不在 root 内时返回错误
+func resolveInRoot(root, p string) (string, error) {
+	existing, rest := p, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		if !withinDir(root, existing) {
+			return "", fmt.Errorf("path %s is outside %s", p, root)
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !withinDir(root, resolved) {
+		return "", fmt.Errorf("path %s resolves outside %s", p, root)
+	}
+	return filepath.Join(resolved, rest), nil
+}
+
+// flattenSingleDir 归档中只有一个顶层目录（如 app-1.2.0/）时，将其内容上移到目标目录，
+// 保证语言识别在源码根目录进行
+func flattenSingleDir(target string) error {
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return nil
+	}
+	// 先重命名顶层目录，避免与其中同名的文件冲突
+	top := filepath.Join(target, ".archive-root")
+	if err := os.Rename(filepath.Join(target, entries[0].Name()), top); err != nil {
+		return err
+	}
+	children, err := os.ReadDir(top)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := os.Rename(filepath.Join(top, child.Name()), filepath.Join(target, child.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(top)
+}
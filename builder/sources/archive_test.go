@@ -0,0 +1,222 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/event"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// capability_id: rainbond.source-archive.fetch
+func TestArchiveSourceFetch(t *testing.T) {
+	release := tarGz(t, map[string]string{"app-1.2.0/package.json": `{"name":"app"}`, "app-1.2.0/src/index.js": "console.log(1)"})
+	bundle := zipArchive(t, map[string]string{"go.mod": "module demo", "main.go": "package main"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "ci" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/app-1.2.0.tar.gz":
+			w.Write(release)
+		case "/app-1.2.0.tar.gz.sha256":
+			w.Write([]byte(sha256Hex(release) + "  app-1.2.0.tar.gz\n"))
+		case "/bundle.zip":
+			w.Write(bundle)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	logger := event.GetTestLogger()
+	codeHome := filepath.Join(t.TempDir(), "code")
+
+	// 未指定校验和时使用同名的 .sha256 文件校验，并去掉归档的顶层目录
+	csi := CodeSourceInfo{RepositoryURL: server.URL + "/app-1.2.0.tar.gz", User: "ci", Password: "token"}
+	info, err := NewArchiveSource(csi, codeHome, logger).Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Verified || info.Digest != "sha256:"+sha256Hex(release) || info.FileName != "app-1.2.0.tar.gz" {
+		t.Fatalf("unexpected archive info %+v", info)
+	}
+	if _, err := os.Stat(filepath.Join(codeHome, "package.json")); err != nil {
+		t.Fatalf("archive should be unpacked to the code home root: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(codeHome, "src", "index.js")); err != nil {
+		t.Fatalf("nested files should be unpacked: %v", err)
+	}
+
+	// 校验和不一致时拒绝构建
+	csi.Checksum = "sha256:" + strings.Repeat("0", 64)
+	if _, err := NewArchiveSource(csi, codeHome, logger).Fetch(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+
+	// 地址中的校验和与 zip 包
+	csi = CodeSourceInfo{RepositoryURL: server.URL + "/bundle.zip#sha256=" + sha256Hex(bundle), User: "ci", Password: "token"}
+	info, err = NewArchiveSource(csi, codeHome, logger).Fetch()
+	if err != nil || !info.Verified {
+		t.Fatalf("unexpected zip fetch result %+v err=%v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(codeHome, "go.mod")); err != nil {
+		t.Fatalf("zip archive should be unpacked: %v", err)
+	}
+
+	csi = CodeSourceInfo{RepositoryURL: server.URL + "/app.rar"}
+	if _, err := NewArchiveSource(csi, codeHome, logger).Fetch(); err == nil {
+		t.Fatal("unsupported archive type should fail")
+	}
+}
+
+// capability_id: rainbond.source-archive.fetch
+func TestParseArchiveChecksum(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	for _, value := range []string{"sha256:" + digest, "sha256=" + digest, digest, "SHA256:" + strings.ToUpper(digest)} {
+		checksum, err := parseArchiveChecksum(value)
+		if err != nil || checksum.algorithm != "sha256" || checksum.value != digest {
+			t.Fatalf("parseArchiveChecksum(%q)=%+v err=%v", value, checksum, err)
+		}
+	}
+	if checksum, err := parseArchiveChecksum(strings.Repeat("cd", 64)); err != nil || checksum.algorithm != "sha512" {
+		t.Fatalf("expected sha512 checksum, got %+v err=%v", checksum, err)
+	}
+	for _, value := range []string{"md5:" + strings.Repeat("a", 32), "sha256:xyz", "sha256:abcd"} {
+		if _, err := parseArchiveChecksum(value); err == nil {
+			t.Fatalf("parseArchiveChecksum(%q) should fail", value)
+		}
+	}
+	if checksum, err := parseArchiveChecksum(""); checksum != nil || err != nil {
+		t.Fatalf("empty checksum should be ignored, got %+v err=%v", checksum, err)
+	}
+}
+
+// capability_id: rainbond.source-archive.fetch
+func TestUntarArchiveRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.tar.gz")
+	if err := os.WriteFile(archive, tarGz(t, map[string]string{"../escape.txt": "x"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "code")
+	os.MkdirAll(target, 0755)
+	if err := untarArchive(archive, target, true); err == nil {
+		t.Fatal("expected path traversal to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("file should not be written outside the target directory")
+	}
+}
+
+// capability_id: rainbond.source-archive.fetch
+func TestUntarArchiveRejectsSymlinkEscape(t *testing.T) {
+	dir := t.TempDir()
+	// 单独看每个符号链接都在目标目录内，组合后 link/up 指向目标目录的上级
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "link", Linkname: ".", Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "link/up", Linkname: "..", Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "link/up/escape.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+	archive := filepath.Join(dir, "evil.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "code")
+	os.MkdirAll(target, 0755)
+	if err := untarArchive(archive, target, false); err == nil {
+		t.Fatal("expected symlink escape to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("file should not be written outside the target directory")
+	}
+
+	// 通过先解压的指向目标目录之外的符号链接写入文件
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "a", Linkname: ".", Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "b", Linkname: "a/..", Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "b/escape.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+	os.WriteFile(archive, buf.Bytes(), 0644)
+	os.RemoveAll(target)
+	os.MkdirAll(target, 0755)
+	if err := untarArchive(archive, target, false); err == nil {
+		t.Fatal("expected write through an escaping symlink to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("file should not be written outside the target directory")
+	}
+}
+
+// capability_id: rainbond.source-archive.fetch
+func TestArchiveDownloadSizeLimit(t *testing.T) {
+	defer func(size int64) { archiveMaxSize = size }(archiveMaxSize)
+	archiveMaxSize = 16
+	body := tarGz(t, map[string]string{"main.go": strings.Repeat("x", 1024)})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha256") {
+			http.NotFound(w, r)
+			return
+		}
+		// 不设置 Content-Length，确保按实际读取的大小限制
+		w.Header().Set("Transfer-Encoding", "chunked")
+		w.(http.Flusher).Flush()
+		w.Write(body)
+	}))
+	defer server.Close()
+	csi := CodeSourceInfo{RepositoryURL: server.URL + "/app.tar.gz"}
+	_, err := NewArchiveSource(csi, filepath.Join(t.TempDir(), "code"), event.GetTestLogger()).Fetch()
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
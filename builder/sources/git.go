@@ -92,6 +92,16 @@ type CodeSourceInfo struct {
 	BuildStrategy  string                  `json:"build_strategy"`
 	Configs        map[string]gjson.Result `json:"configs"`
 	DockerfilePath string                  `json:"dockerfile_path"` // Dockerfile路径，用于指定子目录中的Dockerfile
+	SubDir         string                  `json:"sub_dir"`         // 组件构建使用的仓库子目录
+	IncludePaths   []string                `json:"include_paths"`   // 触发构建的路径，相对仓库根目录
+	ExcludePaths   []string                `json:"exclude_paths"`   // 不触发构建的路径，相对仓库根目录
+	// webhook 推送的提交范围，设置后仅在组件路径发生变更时构建
+	CommitBefore string `json:"commit_before"`
+	CommitAfter  string `json:"commit_after"`
+	// 归档源码的校验和，如 sha256:<hex>，仅 archive 类型使用
+	Checksum string `json:"checksum"`
+	// 访问代码仓库时跳过 TLS 证书校验，用于自签名证书的仓库
+	SkipTLSVerify bool `json:"skip_tls_verify"`
 	//避免项目之间冲突，代码缓存目录提高到租户
 	TenantID  string `json:"tenant_id"`
 	ServiceID string `json:"service_id"`
@@ -182,7 +192,7 @@ Loop:
 		SingleBranch:      true,
 		Tags:              git.NoTags,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-		Depth:             1,
+		Depth:             cloneDepth(csi),
 	}
 	if csi.Branch != "" {
 		opts.ReferenceName = getBranch(csi.Branch)
@@ -479,7 +489,7 @@ Loop:
 	opts := &git.PullOptions{
 		Progress:     writer,
 		SingleBranch: true,
-		Depth:        1,
+		Depth:        cloneDepth(csi),
 	}
 	if csi.Branch != "" {
 		opts.ReferenceName = getBranch(csi.Branch)
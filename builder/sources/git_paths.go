@@ -0,0 +1,198 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// changedPathsCloneDepth 按提交范围过滤构建时的克隆深度，保证推送前的提交在本地可用
+const changedPathsCloneDepth = 50
+
+// ErrCommitRangeUnknown the commit range can not be resolved in the local repository
+var ErrCommitRangeUnknown = errors.New("commit range can not be resolved")
+
+// PathFilter selects the repository paths a component is built from.
+// All paths are relative to the repository root, patterns support "*" and "**".
+type PathFilter struct {
+	SubDir  string
+	Include []string
+	Exclude []string
+}
+
+// NewPathFilter creates the path filter of a code source
+func NewPathFilter(csi CodeSourceInfo) PathFilter {
+	return PathFilter{SubDir: CleanSubDir(csi.SubDir), Include: csi.IncludePaths, Exclude: csi.ExcludePaths}
+}
+
+// Empty returns true if the filter matches the whole repository
+func (p PathFilter) Empty() bool {
+	return p.SubDir == "" && len(p.Include) == 0 && len(p.Exclude) == 0
+}
+
+// Match returns true if the file is selected by the filter.
+// Without include patterns the sub directory is the default include path.
+func (p PathFilter) Match(file string) bool {
+	file = strings.TrimPrefix(path.Clean("/"+file), "/")
+	include := p.Include
+	if len(include) == 0 && p.SubDir != "" {
+		include = []string{p.SubDir}
+	}
+	if len(include) > 0 && !matchAnyPath(include, file) {
+		return false
+	}
+	return !matchAnyPath(p.Exclude, file)
+}
+
+// Filter returns the files selected by the filter
+func (p PathFilter) Filter(files []string) []string {
+	var matched []string
+	for _, file := range files {
+		if p.Match(file) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+// CleanSubDir normalizes a sub directory, directories outside the repository are ignored
+func CleanSubDir(subDir string) string {
+	subDir = strings.TrimSpace(subDir)
+	if subDir == "" {
+		return ""
+	}
+	cleaned := path.Clean(subDir)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return ""
+	}
+	return strings.Trim(strings.TrimPrefix(path.Clean("/"+cleaned), "/"), "/")
+}
+
+func matchAnyPath(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if MatchPathPattern(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPathPattern matches a file against a path pattern.
+// A pattern matching a directory also matches all files under it.
+func MatchPathPattern(pattern, file string) bool {
+	pattern = strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(pattern)), "/")
+	if pattern == "" {
+		return true
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchPathSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchPathSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return true
+}
+
+// cloneDepth 设置了提交范围时需要更深的历史来计算变更路径
+func cloneDepth(csi CodeSourceInfo) int {
+	if csi.CommitBefore != "" {
+		return changedPathsCloneDepth
+	}
+	return 1
+}
+
+func isZeroCommit(commit string) bool {
+	return strings.Trim(commit, "0") == ""
+}
+
+// ChangedFiles returns the files changed between two commits.
+// If after is empty, HEAD is used.
+func ChangedFiles(repo *git.Repository, before, after string) ([]string, error) {
+	if isZeroCommit(before) {
+		// 新建分支没有推送前的提交
+		return nil, ErrCommitRangeUnknown
+	}
+	afterCommit, err := resolveCommit(repo, after)
+	if err != nil {
+		return nil, err
+	}
+	beforeCommit, err := resolveCommit(repo, before)
+	if err != nil {
+		return nil, err
+	}
+	beforeTree, err := beforeCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	afterTree, err := afterCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(beforeTree, afterTree)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, change := range changes {
+		if change.From.Name != "" {
+			files = append(files, change.From.Name)
+		}
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			files = append(files, change.To.Name)
+		}
+	}
+	return files, nil
+}
+
+func resolveCommit(repo *git.Repository, revision string) (*object.Commit, error) {
+	if revision == "" {
+		return GetLastCommit(repo)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve %s: %v", ErrCommitRangeUnknown, revision, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: get commit %s: %v", ErrCommitRangeUnknown, revision, err)
+	}
+	return commit, nil
+}
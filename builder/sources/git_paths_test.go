@@ -0,0 +1,113 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func commitFiles(t *testing.T, repo *git.Repository, dir string, files map[string]string) string {
+	t.Helper()
+	tree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, name)
+		if content == "" {
+			if _, err := tree.Remove(name); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := tree.Commit("update", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()
+}
+
+// capability_id: rainbond.source-build.monorepo-paths
+func TestPathFilterMatch(t *testing.T) {
+	filter := NewPathFilter(CodeSourceInfo{
+		SubDir:       "./services/api/",
+		ExcludePaths: []string{"**/*.md", "services/api/docs"},
+	})
+	if filter.SubDir != "services/api" {
+		t.Fatalf("unexpected sub dir %q", filter.SubDir)
+	}
+	for file, want := range map[string]bool{
+		"services/api/main.go":         true,
+		"services/api/internal/h.go":   true,
+		"services/api/README.md":       false,
+		"services/api/docs/index.html": false,
+		"services/api-gateway/main.go": false,
+		"services/worker/main.go":      false,
+		"go.mod":                       false,
+	} {
+		if got := filter.Match(file); got != want {
+			t.Fatalf("Match(%q) = %v, want %v", file, got, want)
+		}
+	}
+
+	filter = NewPathFilter(CodeSourceInfo{SubDir: "services/api", IncludePaths: []string{"services/api/**", "libs/*/go.mod"}})
+	if !filter.Match("libs/common/go.mod") || filter.Match("libs/common/util.go") {
+		t.Fatal("include paths should replace the sub directory default")
+	}
+	if !NewPathFilter(CodeSourceInfo{SubDir: "../outside"}).Empty() {
+		t.Fatal("sub directory outside the repository should be ignored")
+	}
+}
+
+// capability_id: rainbond.source-build.monorepo-paths
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := commitFiles(t, repo, dir, map[string]string{
+		"services/api/main.go":    "package main",
+		"services/worker/main.go": "package main",
+	})
+	commitFiles(t, repo, dir, map[string]string{"services/worker/main.go": "package main\n\nfunc main() {}"})
+	after := commitFiles(t, repo, dir, map[string]string{"services/worker/job.go": "package main", "services/api/main.go": ""})
+
+	files, err := ChangedFiles(repo, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := []string{"services/api/main.go", "services/worker/job.go", "services/worker/main.go"}
+	if len(files) != len(want) {
+		t.Fatalf("ChangedFiles() = %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("ChangedFiles() = %v, want %v", files, want)
+		}
+	}
+
+	if _, err := ChangedFiles(repo, "0000000000000000000000000000000000000000", after); !errors.Is(err, ErrCommitRangeUnknown) {
+		t.Fatalf("expected unknown range for a new branch, got %v", err)
+	}
+	if _, err := ChangedFiles(repo, "1234567890abcdef1234567890abcdef12345678", ""); !errors.Is(err, ErrCommitRangeUnknown) {
+		t.Fatalf("expected unknown range for a missing commit, got %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sources
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/util"
+	"github.com/sirupsen/logrus"
+)
+
+// hg log 输出中字段与记录的分隔符
+const (
+	hgFieldSep  = "\x1f"
+	hgRecordSep = "\x1e"
+)
+
+// hgLogTemplate hg log 输出格式，依次为 revision、author、date、message
+var hgLogTemplate = "{node}" + hgFieldSep + "{author}" + hgFieldSep + "{date|isodate}" + hgFieldSep + "{desc}" + hgRecordSep
+
+// HgClient mercurial client
+type HgClient interface {
+	Checkout() (*Info, error)
+	Update(childpath string) (*Info, error)
+	UpdateOrCheckout(childpath string) (*Info, error)
+}
+
+type hgclient struct {
+	username string
+	password string
+	hgURL    string
+	hgDir    string
+	Env      []string
+	logger   event.Logger
+	csi      CodeSourceInfo
+}
+
+// NewHgClient new mercurial client
+func NewHgClient(csi CodeSourceInfo, codeHome string, logger event.Logger) HgClient {
+	return &hgclient{csi: csi, username: csi.User, password: csi.Password, hgURL: csi.RepositoryURL, hgDir: codeHome, logger: logger}
+}
+
+// hgRevision 将构建分支转换为 hg update 的目标，tag:v1 表示标签，空分支使用 default
+func hgRevision(branch string) string {
+	if strings.HasPrefix(branch, "tag:") {
+		return branch[4:]
+	}
+	// 源码构建未指定分支时默认使用 master，mercurial 的默认分支为 default
+	if branch == "" || branch == "master" {
+		return "default"
+	}
+	return branch
+}
+
+// validateHgURL 仅允许 http、https 和 ssh 协议的仓库地址，
+// 避免 file:// 等协议读取构建节点上的本地仓库
+func validateHgURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid hg repository url %s", rawURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "ssh":
+		return nil
+	}
+	return fmt.Errorf("unsupported hg repository url %s, only http, https and ssh are supported", rawURL)
+}
+
+// revision 返回 hg update 的目标，拒绝以 - 开头、可能被当作命令选项的分支名
+func (c *hgclient) revision() (string, error) {
+	rev := hgRevision(c.csi.Branch)
+	if strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid hg revision %s", rev)
+	}
+	return rev, nil
+}
+
+// Checkout clone the repository and update to the build branch
+func (c *hgclient) Checkout() (*Info, error) {
+	if err := validateHgURL(c.hgURL); err != nil {
+		return nil, err
+	}
+	rev, err := c.revision()
+	if err != nil {
+		return nil, err
+	}
+	if !util.DirIsEmpty(c.hgDir) {
+		os.RemoveAll(c.hgDir)
+	}
+	if err := os.MkdirAll(c.hgDir, 0755); err != nil {
+		return nil, err
+	}
+	if _, err := c.runWithLogger("", []string{"clone", "--noupdate"}, c.hgURL, c.hgDir); err != nil {
+		return nil, err
+	}
+	if _, err := c.runWithLogger(c.hgDir, []string{"update", "--clean"}, rev); err != nil {
+		return nil, err
+	}
+	return c.Info()
+}
+
+// Update pull the latest changesets and update to the build branch.
+// mercurial 只能更新整个仓库，childpath 仅为与 SVNClient 保持一致
+func (c *hgclient) Update(childpath string) (*Info, error) {
+	if err := validateHgURL(c.hgURL); err != nil {
+		return nil, err
+	}
+	rev, err := c.revision()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.runWithLogger(c.hgDir, []string{"pull"}, c.hgURL); err != nil {
+		return nil, err
+	}
+	if _, err := c.runWithLogger(c.hgDir, []string{"update", "--clean"}, rev); err != nil {
+		return nil, err
+	}
+	return c.Info()
+}
+
+func (c *hgclient) UpdateOrCheckout(childpath string) (*Info, error) {
+	var rs *Info
+	var err error
+	if ok := util.DirIsEmpty(c.hgDir); !ok {
+		rs, err = c.Update(childpath)
+		if err != nil {
+			logrus.Errorf("update hg code error: %s", err.Error())
+			c.logger.Error("Update hg code failed, please make sure the code can be downloaded properly", map[string]string{"step": "builder-exector", "status": "failure"})
+		} else {
+			return rs, nil
+		}
+	}
+	rs, err = c.Checkout()
+	if err != nil {
+		logrus.Errorf("checkout hg code error: %s", err.Error())
+		c.logger.Error("Checkout hg code failed, please make sure the code can be downloaded properly", map[string]string{"step": "builder-exector", "status": "failure"})
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Log 返回当前工作区所在提交及其祖先的提交记录，第一条为当前提交
+func (c *hgclient) Log() (*Logs, error) {
+	out, err := c.run(c.hgDir, []string{"log", "--rev", "reverse(::.)", "--limit", "10", "--template", hgLogTemplate})
+	if err != nil {
+		return nil, err
+	}
+	return parseHgLog(out), nil
+}
+
+// Info ...
+func (c *hgclient) Info() (*Info, error) {
+	info := &Info{URL: c.hgURL, Root: c.hgURL, WcrootAbspath: c.hgDir}
+	log, err := c.Log()
+	if err != nil {
+		return nil, err
+	}
+	info.Logs = log
+	if out, err := c.run(c.hgDir, []string{"branches", "--template", "{branch}\n"}); err == nil {
+		info.Branchs = splitLines(out)
+	}
+	if out, err := c.run(c.hgDir, []string{"tags", "--template", "{tag}\n"}); err == nil {
+		for _, tag := range splitLines(out) {
+			if tag != "tip" {
+				info.Tags = append(info.Tags, tag)
+			}
+		}
+	}
+	return info, nil
+}
+
+func parseHgLog(out []byte) *Logs {
+	logs := &Logs{}
+	for _, record := range strings.Split(string(out), hgRecordSep) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, hgFieldSep, 4)
+		if len(fields) != 4 {
+			continue
+		}
+		logs.CommitEntrys = append(logs.CommitEntrys, Commit{
+			Revision: fields[0],
+			Author:   fields[1],
+			Date:     fields[2],
+			Msg:      fields[3],
+		})
+	}
+	return logs
+}
+
+func splitLines(out []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// remoteCommand 是否为访问远程仓库的命令
+func remoteCommand(command string) bool {
+	return command == "clone" || command == "pull"
+}
+
+// globalArgs 所有命令的通用选项，--insecure 仅在显式要求跳过 TLS 校验时用于远程命令
+func (c *hgclient) globalArgs(command string) []string {
+	args := []string{"--noninteractive"}
+	if c.csi.SkipTLSVerify && remoteCommand(command) {
+		args = append(args, "--insecure")
+	}
+	return args
+}
+
+// authConfig 生成包含仓库账号的 hgrc 内容，账号不出现在命令行参数、仓库地址和 .hg/hgrc 中
+func (c *hgclient) authConfig() (string, error) {
+	if strings.ContainsAny(c.username+c.password, "\r\n") {
+		return "", fmt.Errorf("hg username or password must not contain line breaks")
+	}
+	prefix := c.hgURL
+	if u, err := url.Parse(c.hgURL); err == nil && u.Host != "" {
+		prefix = u.Scheme + "://" + u.Host
+	}
+	return fmt.Sprintf("[auth]\nrainbond.prefix = %s\nrainbond.username = %s\nrainbond.password = %s\n", prefix, c.username, c.password), nil
+}
+
+// writeAuthConfig 将账号写入临时目录中的 hgrc，返回 HGRCPATH 与清理函数。
+// HGRCPATH 保留系统与用户的默认配置，账号配置最后加载
+func (c *hgclient) writeAuthConfig() (string, func(), error) {
+	content, err := c.authConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp("", "hgrc-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	hgrc := filepath.Join(dir, "hgrc")
+	if err := os.WriteFile(hgrc, []byte(content), 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	paths := []string{"/etc/mercurial/hgrc", "/etc/mercurial/hgrc.d"}
+	if env := os.Getenv("HGRCPATH"); env != "" {
+		paths = []string{env}
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".hgrc"), filepath.Join(home, ".config", "hg", "hgrc"))
+	}
+	return strings.Join(append(paths, hgrc), string(os.PathListSeparator)), cleanup, nil
+}
+
+// command 构造 hg 命令，options 的第一个元素为子命令，positional 放在 -- 之后，
+// 避免仓库地址或分支名被当作命令选项解析。返回的清理函数需在命令结束后调用
+func (c *hgclient) command(dir string, options []string, positional ...string) (*exec.Cmd, func(), error) {
+	args := append(append([]string{}, options...), c.globalArgs(options[0])...)
+	if len(positional) > 0 {
+		args = append(append(args, "--"), positional...)
+	}
+	cmd := exec.Command("hg", args...)
+	env := append([]string{}, c.Env...)
+	cleanup := func() {}
+	if c.username != "" && remoteCommand(options[0]) {
+		hgrcPath, remove, err := c.writeAuthConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		env = append(env, "HGRCPATH="+hgrcPath)
+		cleanup = remove
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Dir = dir
+	return cmd, cleanup, nil
+}
+
+// runWithLogger 运行命令并将输出写入构建日志
+func (c *hgclient) runWithLogger(dir string, options []string, positional ...string) ([]byte, error) {
+	cmd, cleanup, err := c.command(dir, options, positional...)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	writer := c.logger.GetWriter("progress", "debug")
+	writer.SetFormat(map[string]interface{}{"progress": "%s", "id": "HG:"})
+	cmd.Stdout = writer
+	errorWriter := bytes.NewBuffer(nil)
+	cmd.Stderr = errorWriter
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("hg error:%s", errorWriter.String())
+	}
+	return nil, nil
+}
+
+// run 运行命令
+func (c *hgclient) run(dir string, options []string, positional ...string) ([]byte, error) {
+	cmd, cleanup, err := c.command(dir, options, positional...)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return cmd.Output()
+}
@@ -0,0 +1,117 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// capability_id: rainbond.source-hg.client
+func TestHgRevision(t *testing.T) {
+	tests := map[string]string{
+		"":          "default",
+		"master":    "default",
+		"stable":    "stable",
+		"tag:1.2.0": "1.2.0",
+	}
+	for branch, want := range tests {
+		if got := hgRevision(branch); got != want {
+			t.Fatalf("hgRevision(%q)=%q, want %q", branch, got, want)
+		}
+	}
+}
+
+// capability_id: rainbond.source-hg.client
+func TestParseHgLog(t *testing.T) {
+	out := "abc123" + hgFieldSep + "Alice <alice@example.com>" + hgFieldSep + "2026-10-01 10:00 +0800" + hgFieldSep + "fix build\nsecond line" + hgRecordSep +
+		"def456" + hgFieldSep + "Bob" + hgFieldSep + "2026-09-30 09:00 +0800" + hgFieldSep + "init" + hgRecordSep
+	logs := parseHgLog([]byte(out))
+	if len(logs.CommitEntrys) != 2 {
+		t.Fatalf("expected 2 commits, got %+v", logs.CommitEntrys)
+	}
+	first := logs.CommitEntrys[0]
+	if first.Revision != "abc123" || first.Author != "Alice <alice@example.com>" || first.Msg != "fix build\nsecond line" {
+		t.Fatalf("unexpected first commit %+v", first)
+	}
+	if len(parseHgLog(nil).CommitEntrys) != 0 {
+		t.Fatal("empty output should have no commits")
+	}
+}
+
+// capability_id: rainbond.source-hg.client
+func TestHgCommandArgs(t *testing.T) {
+	c := &hgclient{username: "dev", password: "secret", hgURL: "https://hg.example.com/repos/app"}
+	cmd, cleanup, err := c.command("", []string{"clone", "--noupdate"}, c.hgURL, "/tmp/code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	if args != "hg clone --noupdate --noninteractive -- https://hg.example.com/repos/app /tmp/code" {
+		t.Fatalf("unexpected clone args %q", args)
+	}
+	var hgrcPath string
+	for _, env := range cmd.Env {
+		if strings.HasPrefix(env, "HGRCPATH=") {
+			hgrcPath = strings.TrimPrefix(env, "HGRCPATH=")
+		}
+	}
+	paths := filepath.SplitList(hgrcPath)
+	if len(paths) == 0 {
+		t.Fatalf("clone should set HGRCPATH, got env %v", cmd.Env)
+	}
+	hgrc := paths[len(paths)-1]
+	content, err := os.ReadFile(hgrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"rainbond.prefix = https://hg.example.com", "rainbond.username = dev", "rainbond.password = secret"} {
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("hgrc %q should contain %q", content, want)
+		}
+	}
+	cleanup()
+	if _, err := os.Stat(hgrc); !os.IsNotExist(err) {
+		t.Fatal("hgrc should be removed after the command")
+	}
+
+	// 本地命令不写入账号，--insecure 仅在显式跳过 TLS 校验时使用
+	cmd, cleanup, _ = c.command("/tmp/code", []string{"update", "--clean"}, "default")
+	cleanup()
+	if args := strings.Join(cmd.Args, " "); args != "hg update --clean --noninteractive -- default" || cmd.Env != nil {
+		t.Fatalf("unexpected update command %q env %v", args, cmd.Env)
+	}
+	c.csi.SkipTLSVerify = true
+	if args := strings.Join(c.globalArgs("pull"), " "); args != "--noninteractive --insecure" {
+		t.Fatalf("pull should use --insecure when TLS verification is skipped, got %q", args)
+	}
+	if args := strings.Join(c.globalArgs("log"), " "); strings.Contains(args, "--insecure") {
+		t.Fatalf("local commands should not use --insecure, got %q", args)
+	}
+
+	c.password = "secret\n[hooks]"
+	if _, _, err := c.command("", []string{"pull"}, c.hgURL); err == nil {
+		t.Fatal("password with line breaks should be rejected")
+	}
+}
+
+// capability_id: rainbond.source-hg.client
+func TestHgRejectsUnsafeInput(t *testing.T) {
+	for _, rawURL := range []string{"https://hg.example.com/app", "http://hg.example.com/app", "ssh://hg@hg.example.com/app"} {
+		if err := validateHgURL(rawURL); err != nil {
+			t.Fatalf("validateHgURL(%q) should pass, got %v", rawURL, err)
+		}
+	}
+	for _, rawURL := range []string{"file:///etc", "/var/lib/repo", "--config=hooks.pre-clone=id", "ext::sh"} {
+		if err := validateHgURL(rawURL); err == nil {
+			t.Fatalf("validateHgURL(%q) should fail", rawURL)
+		}
+	}
+	c := &hgclient{hgURL: "https://hg.example.com/app", csi: CodeSourceInfo{Branch: "--config=hooks.update=id"}}
+	if _, err := c.revision(); err == nil {
+		t.Fatal("revision starting with - should be rejected")
+	}
+	if _, err := c.Checkout(); err == nil {
+		t.Fatal("checkout should reject the revision before running hg")
+	}
+}
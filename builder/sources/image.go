@@ -594,7 +594,7 @@ func ImageBuild(arch, contextDir, RbdNamespace, ServiceID, DeployVersion string,
 			"--local",
 			"dockerfile=/workspace",
 			"--output",
-			buildKitImageOutput(buildType, buildImageName),
+			BuildKitPlatformOutput(buildKitImageOutput(buildType, buildImageName), BuildKitArgs),
 		},
 		SecurityContext: &corev1.SecurityContext{
 			Privileged: &privileged,
@@ -0,0 +1,132 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sources
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// NormalizePlatforms 将 amd64、linux/arm64 等写法统一为 os/arch 形式，去重并排序。
+// 参数可以是逗号分隔的多个平台。
+func NormalizePlatforms(values ...string) []string {
+	seen := make(map[string]bool)
+	var platforms []string
+	for _, value := range values {
+		for _, platform := range strings.Split(value, ",") {
+			platform = strings.ToLower(strings.TrimSpace(platform))
+			if platform == "" {
+				continue
+			}
+			if !strings.Contains(platform, "/") {
+				platform = "linux/" + platform
+			}
+			if !seen[platform] {
+				seen[platform] = true
+				platforms = append(platforms, platform)
+			}
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// PlatformArch returns the architecture part of a platform, e.g. arm64 for linux/arm64/v8
+func PlatformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return platform
+	}
+	return parts[1]
+}
+
+// BuildKitPlatformArgs 返回让 buildctl 一次构建多个平台的参数，单个平台时无需额外参数
+func BuildKitPlatformArgs(platforms []string) []string {
+	if len(platforms) < 2 {
+		return nil
+	}
+	return []string{"--opt", "platform=" + strings.Join(platforms, ",")}
+}
+
+// BuildKitPlatformOutput 多平台构建时让 buildkit 以 OCI index 的形式推送镜像
+func BuildKitPlatformOutput(output string, args []string) string {
+	for i, arg := range args {
+		value := strings.TrimPrefix(arg, "--opt=")
+		if arg == "--opt" && i+1 < len(args) {
+			value = args[i+1]
+		}
+		if strings.HasPrefix(value, "platform=") && strings.Contains(value, ",") {
+			return output + ",oci-mediatypes=true"
+		}
+	}
+	return output
+}
+
+// ImagePlatformDigests 读取镜像仓库中的 index，返回各架构镜像的 digest。
+// 镜像不是多架构镜像时返回 nil。
+func ImagePlatformDigests(image, username, password string) (map[string]string, error) {
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("parse image %s: %v", image, err)
+	}
+	options := []remote.Option{remote.WithTransport(&http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})}
+	if username != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{Username: username, Password: password}))
+	}
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return nil, fmt.Errorf("get image %s: %v", image, err)
+	}
+	if !desc.MediaType.IsIndex() {
+		return nil, nil
+	}
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	digests := make(map[string]string)
+	for _, m := range manifest.Manifests {
+		// buildkit 的 attestation manifest 平台为 unknown/unknown，跳过
+		if m.Platform == nil || m.Platform.OS == "unknown" || m.Platform.Architecture == "" {
+			continue
+		}
+		arch := m.Platform.Architecture
+		if _, ok := digests[arch]; !ok {
+			digests[arch] = m.Digest.String()
+		}
+	}
+	if len(digests) == 0 {
+		return nil, nil
+	}
+	return digests, nil
+}
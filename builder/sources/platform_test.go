@@ -0,0 +1,88 @@
+package sources
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// capability_id: rainbond.multi-arch.buildkit-platforms
+func TestBuildKitPlatformArgs(t *testing.T) {
+	platforms := NormalizePlatforms("arm64, linux/amd64", "amd64", "")
+	if strings.Join(platforms, ",") != "linux/amd64,linux/arm64" {
+		t.Fatalf("unexpected platforms %v", platforms)
+	}
+	if PlatformArch("linux/arm64/v8") != "arm64" {
+		t.Fatalf("unexpected arch %s", PlatformArch("linux/arm64/v8"))
+	}
+	if args := BuildKitPlatformArgs([]string{"linux/amd64"}); args != nil {
+		t.Fatalf("single platform should not add args, got %v", args)
+	}
+	args := BuildKitPlatformArgs(platforms)
+	if strings.Join(args, " ") != "--opt platform=linux/amd64,linux/arm64" {
+		t.Fatalf("unexpected platform args %v", args)
+	}
+
+	output := "type=image,name=goodrain.me/demo:v1,push=true"
+	if got := BuildKitPlatformOutput(output, append([]string{"--opt=build-arg:A=1"}, args...)); got != output+",oci-mediatypes=true" {
+		t.Fatalf("multi-platform build should push an OCI index, got %s", got)
+	}
+	if got := BuildKitPlatformOutput(output, []string{"--opt=platform=linux/arm64"}); got != output {
+		t.Fatalf("single platform build should keep the output, got %s", got)
+	}
+}
+
+// capability_id: rainbond.multi-arch.platform-digests
+func TestImagePlatformDigests(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	amd64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arm64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attestation, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}}},
+		mutate.IndexAddendum{Add: attestation, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"}}},
+	)
+	indexRef, _ := name.ParseReference(host+"/demo/app:multi", name.Insecure)
+	if err := remote.WriteIndex(indexRef, index); err != nil {
+		t.Fatal(err)
+	}
+	singleRef, _ := name.ParseReference(host+"/demo/app:single", name.Insecure)
+	if err := remote.Write(singleRef, amd64); err != nil {
+		t.Fatal(err)
+	}
+
+	digests, err := ImagePlatformDigests(host+"/demo/app:multi", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	amd64Digest, _ := amd64.Digest()
+	arm64Digest, _ := arm64.Digest()
+	if len(digests) != 2 || digests["amd64"] != amd64Digest.String() || digests["arm64"] != arm64Digest.String() {
+		t.Fatalf("unexpected digests %v", digests)
+	}
+	digests, err = ImagePlatformDigests(host+"/demo/app:single", "", "")
+	if err != nil || digests != nil {
+		t.Fatalf("single-arch image should have no platform digests, got %v err=%v", digests, err)
+	}
+}
@@ -51,3 +51,40 @@ func TestManifestExistsAcceptsOCIManifestTypes(t *testing.T) {
 		}
 	}
 }
+
+// capability_id: rainbond.registry.tag-manifest
+func TestTagManifestCopiesRawManifest(t *testing.T) {
+	const manifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`
+	var putBody, putType string
+	reg := &Registry{
+		URL: "https://registry.example.com",
+		Client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			header := make(http.Header)
+			switch {
+			case r.Method == "HEAD" && r.URL.Path == "/v2/demo/manifests/missing":
+				return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader("")), Header: header}, nil
+			case r.Method == "GET" && r.URL.Path == "/v2/demo/manifests/cache-latest":
+				header.Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(manifest)), Header: header}, nil
+			case r.Method == "PUT" && r.URL.Path == "/v2/demo/manifests/cache-abc":
+				body, _ := ioutil.ReadAll(r.Body)
+				putBody, putType = string(body), r.Header.Get("Content-Type")
+				return &http.Response{StatusCode: http.StatusCreated, Body: ioutil.NopCloser(strings.NewReader("")), Header: header}, nil
+			}
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+			return nil, nil
+		})},
+		Logf: Quiet,
+	}
+
+	exists, err := reg.HasManifest("demo", "missing")
+	if err != nil || exists {
+		t.Fatalf("expected missing manifest without error, got exists=%v err=%v", exists, err)
+	}
+	if err := reg.TagManifest("demo", "cache-latest", "cache-abc"); err != nil {
+		t.Fatalf("TagManifest() error = %v", err)
+	}
+	if putBody != manifest || putType != "application/vnd.oci.image.index.v1+json" {
+		t.Fatalf("expected raw manifest to be uploaded, got type=%q body=%q", putType, putBody)
+	}
+}
@@ -0,0 +1,126 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HasManifest checks whether the reference exists, a missing manifest is not an error.
+func (registry *Registry) HasManifest(repository, reference string) (bool, error) {
+	url := registry.url("/v2/%s/manifests/%s", repository, reference)
+	registry.Logf("registry.manifest.head url=%s repository=%s reference=%s", url, repository, reference)
+
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", acceptedManifestMediaTypes())
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 400:
+		return false, fmt.Errorf("head manifest %s:%s status %d", repository, reference, resp.StatusCode)
+	}
+	return true, nil
+}
+
+// RawManifest returns the manifest body and its media type without decoding it,
+// so image indexes and OCI artifacts such as build caches are kept intact.
+func (registry *Registry) RawManifest(repository, reference string) ([]byte, string, error) {
+	url := registry.url("/v2/%s/manifests/%s", repository, reference)
+	registry.Logf("registry.manifest.get url=%s repository=%s reference=%s", url, repository, reference)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", acceptedManifestMediaTypes())
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", errors.Wrap(ErrManifestNotFound, reference)
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errors.Wrap(ErrManifestNotFound, reference)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("get manifest %s:%s status %d", repository, reference, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// PutRawManifest uploads a manifest body with the given media type.
+func (registry *Registry) PutRawManifest(repository, reference, mediaType string, body []byte) error {
+	url := registry.url("/v2/%s/manifests/%s", repository, reference)
+	registry.Logf("registry.manifest.put url=%s repository=%s reference=%s", url, repository, reference)
+
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("put manifest %s:%s status %d", repository, reference, resp.StatusCode)
+	}
+	return nil
+}
+
+// TagManifest tags the manifest of source as target in the same repository.
+// The blobs are already in the repository, so only the manifest is uploaded.
+func (registry *Registry) TagManifest(repository, source, target string) error {
+	body, mediaType, err := registry.RawManifest(repository, source)
+	if err != nil {
+		return err
+	}
+	return registry.PutRawManifest(repository, target, mediaType, body)
+}
+
+func isNotFound(err error) bool {
+	var statusErr *HttpStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Response != nil && statusErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,309 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package signature 使用与 cosign 兼容的格式对镜像签名和验证签名。
+// 签名保存在镜像所在仓库的 sha256-<digest>.sig 标签中，可以直接使用 cosign verify --key 验证。
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SimpleSigningMediaType cosign 签名层的媒体类型
+	SimpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// SignatureAnnotation 签名层中保存 base64 签名的 annotation
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// payloadType simple signing payload 的类型
+	payloadType = "cosign container image signature"
+)
+
+// 签名验证结果的状态，保存在构建版本中
+const (
+	// StatusSigned 构建产出的镜像已签名
+	StatusSigned = "signed"
+	// StatusVerified 镜像签名验证通过
+	StatusVerified = "verified"
+	// StatusUnsigned 镜像没有签名
+	StatusUnsigned = "unsigned"
+	// StatusInvalid 镜像的签名不能被信任的公钥验证
+	StatusInvalid = "invalid"
+	// StatusError 签名或验证过程出错，如镜像仓库不可访问
+	StatusError = "error"
+)
+
+// Result 签名或验证的结果
+type Result struct {
+	Status  string `json:"status"`
+	Digest  string `json:"digest"`
+	Message string `json:"message"`
+}
+
+// Verified 镜像签名是否可信
+func (r Result) Verified() bool {
+	return r.Status == StatusVerified || r.Status == StatusSigned
+}
+
+// simpleSigning cosign 签名的内容
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// Auth 访问镜像仓库的账号
+type Auth struct {
+	Username string
+	Password string
+	// Insecure 镜像仓库使用 HTTP 或自签名证书，为 true 时不校验证书
+	Insecure bool
+}
+
+func (a Auth) nameOptions() []name.Option {
+	if a.Insecure {
+		return []name.Option{name.Insecure}
+	}
+	return nil
+}
+
+func (a Auth) options() []remote.Option {
+	options := []remote.Option{remote.WithTransport(&http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: a.Insecure},
+	})}
+	if a.Username != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{Username: a.Username, Password: a.Password}))
+	}
+	return options
+}
+
+// Signer 使用集群管理的私钥对镜像签名
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner create signer
+func NewSigner(key *ecdsa.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// Sign 对镜像仓库中的镜像签名，签名追加到已有的签名镜像中
+func (s *Signer) Sign(image string, auth Auth) (Result, error) {
+	ref, err := name.ParseReference(image, auth.nameOptions()...)
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "parse image name %s", image)
+	}
+	options := auth.options()
+	desc, err := remote.Head(ref, options...)
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "get digest of image %s", image)
+	}
+	payload, err := newPayload(ref.Context().Name(), desc.Digest.String())
+	if err != nil {
+		return Result{}, err
+	}
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	if err != nil {
+		return Result{}, errors.Wrap(err, "sign image")
+	}
+	sigRef := signatureRef(ref.Context(), desc.Digest)
+	base, err := remote.Image(sigRef, options...)
+	if err != nil {
+		if !isNotFound(err) {
+			return Result{}, errors.Wrapf(err, "get signatures of image %s", image)
+		}
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	}
+	sigImage, err := mutate.Append(base, mutate.Addendum{
+		Layer:       static.NewLayer(payload, SimpleSigningMediaType),
+		Annotations: map[string]string{SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return Result{}, errors.Wrap(err, "create signature image")
+	}
+	if err := remote.Write(sigRef, sigImage, options...); err != nil {
+		return Result{}, errors.Wrapf(err, "push signature %s", sigRef.String())
+	}
+	return Result{Status: StatusSigned, Digest: desc.Digest.String(), Message: fmt.Sprintf("signature pushed to %s", sigRef.String())}, nil
+}
+
+// Verifier 使用信任的公钥验证镜像签名
+type Verifier struct {
+	keys []crypto.PublicKey
+}
+
+// NewVerifier create verifier
+func NewVerifier(keys []crypto.PublicKey) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// Verify 验证镜像签名，只要有一个签名能被任一公钥验证即视为可信。
+// 镜像仓库访问失败时返回 error，其余情况通过 Result.Status 区分。
+func (v *Verifier) Verify(image string, auth Auth) (Result, error) {
+	ref, err := name.ParseReference(image, auth.nameOptions()...)
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "parse image name %s", image)
+	}
+	options := auth.options()
+	desc, err := remote.Head(ref, options...)
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "get digest of image %s", image)
+	}
+	result := Result{Digest: desc.Digest.String()}
+	sigRef := signatureRef(ref.Context(), desc.Digest)
+	sigImage, err := remote.Image(sigRef, options...)
+	if err != nil {
+		if isNotFound(err) {
+			result.Status = StatusUnsigned
+			result.Message = fmt.Sprintf("no signature found for %s@%s", ref.Context().Name(), result.Digest)
+			return result, nil
+		}
+		return result, errors.Wrapf(err, "get signatures of image %s", image)
+	}
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return result, errors.Wrapf(err, "get signatures of image %s", image)
+	}
+	checked := 0
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != SimpleSigningMediaType {
+			continue
+		}
+		checked++
+		sig, err := base64.StdEncoding.DecodeString(layer.Annotations[SignatureAnnotation])
+		if err != nil || len(sig) == 0 {
+			continue
+		}
+		blob, err := sigImage.LayerByDigest(layer.Digest)
+		if err != nil {
+			return result, errors.Wrap(err, "get signature payload")
+		}
+		rc, err := blob.Compressed()
+		if err != nil {
+			return result, errors.Wrap(err, "get signature payload")
+		}
+		payload, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return result, errors.Wrap(err, "read signature payload")
+		}
+		if v.verifyPayload(payload, sig, result.Digest) {
+			result.Status = StatusVerified
+			result.Message = fmt.Sprintf("signature of %s@%s verified", ref.Context().Name(), result.Digest)
+			return result, nil
+		}
+	}
+	result.Status = StatusInvalid
+	result.Message = fmt.Sprintf("none of %d signatures of %s@%s is signed by a trusted key", checked, ref.Context().Name(), result.Digest)
+	return result, nil
+}
+
+func (v *Verifier) verifyPayload(payload, sig []byte, digest string) bool {
+	hash := sha256.Sum256(payload)
+	trusted := false
+	for _, key := range v.keys {
+		if pub, ok := key.(*ecdsa.PublicKey); ok && ecdsa.VerifyASN1(pub, hash[:], sig) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return false
+	}
+	var ss simpleSigning
+	if err := json.Unmarshal(payload, &ss); err != nil {
+		return false
+	}
+	return ss.Critical.Type == payloadType && ss.Critical.Image.DockerManifestDigest == digest
+}
+
+func newPayload(repository, digest string) ([]byte, error) {
+	var ss simpleSigning
+	ss.Critical.Identity.DockerReference = repository
+	ss.Critical.Image.DockerManifestDigest = digest
+	ss.Critical.Type = payloadType
+	return json.Marshal(ss)
+}
+
+// signatureRef cosign 签名镜像的标签，sha256:abc 对应 sha256-abc.sig
+func signatureRef(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		return terr.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// MatchRegistry 判断镜像是否属于配置的镜像仓库。
+// 配置项可以是仓库地址（如 harbor.example.com）或带命名空间的前缀（如 docker.io/library），* 表示所有仓库。
+func MatchRegistry(image string, registries []string) bool {
+	ref, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return false
+	}
+	repository := ref.Context().Name()
+	for _, registry := range registries {
+		registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+		if registry == "" {
+			continue
+		}
+		if registry == "*" {
+			return true
+		}
+		if registry == "docker.io" || strings.HasPrefix(registry, "docker.io/") {
+			registry = name.DefaultRegistry + strings.TrimPrefix(registry, "docker.io")
+		}
+		if repository == registry || strings.HasPrefix(repository, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
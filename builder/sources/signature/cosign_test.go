@@ -0,0 +1,166 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func pushRandomImage(t *testing.T, image string) {
+	t.Helper()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// capability_id: rainbond.image-signature.sign-verify
+func TestSignAndVerify(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	image := host + "/demo/app:v1"
+	pushRandomImage(t, image)
+
+	trusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	untrusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	verifier := NewVerifier([]crypto.PublicKey{&trusted.PublicKey})
+
+	result, err := verifier.Verify(image, Auth{})
+	if err != nil || result.Status != StatusUnsigned {
+		t.Fatalf("expected unsigned image, got %+v err=%v", result, err)
+	}
+
+	if _, err := NewSigner(untrusted).Sign(image, Auth{}); err != nil {
+		t.Fatalf("sign with untrusted key: %v", err)
+	}
+	result, err = verifier.Verify(image, Auth{})
+	if err != nil || result.Status != StatusInvalid {
+		t.Fatalf("expected invalid signature, got %+v err=%v", result, err)
+	}
+
+	signed, err := NewSigner(trusted).Sign(image, Auth{})
+	if err != nil || signed.Status != StatusSigned || !strings.HasPrefix(signed.Digest, "sha256:") {
+		t.Fatalf("sign with trusted key: %+v err=%v", signed, err)
+	}
+	result, err = verifier.Verify(image, Auth{})
+	if err != nil || result.Status != StatusVerified || result.Digest != signed.Digest {
+		t.Fatalf("expected verified signature, got %+v err=%v", result, err)
+	}
+
+	// 签名保存在 cosign 约定的标签中，包含两个签名层
+	sigRef, _ := name.ParseReference(host+"/demo/app:sha256-"+strings.TrimPrefix(signed.Digest, "sha256:")+".sig", name.Insecure)
+	sigImage, err := remote.Image(sigRef)
+	if err != nil {
+		t.Fatalf("expected cosign signature tag: %v", err)
+	}
+	manifest, _ := sigImage.Manifest()
+	if len(manifest.Layers) != 2 || manifest.Layers[1].MediaType != SimpleSigningMediaType || manifest.Layers[1].Annotations[SignatureAnnotation] == "" {
+		t.Fatalf("unexpected signature manifest: %+v", manifest.Layers)
+	}
+}
+
+// capability_id: rainbond.image-signature.keys
+func TestParseKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil)
+	if err != nil || !parsed.Equal(key) {
+		t.Fatalf("parse pkcs8 key: %v", err)
+	}
+
+	// cosign generate-key-pair 生成的加密私钥
+	salt := []byte("0123456789abcdef0123456789abcdef")
+	var nonce [24]byte
+	var secret [32]byte
+	copy(nonce[:], "0123456789abcdef01234567")
+	derived, _ := scrypt.Key([]byte("passw0rd"), salt, 32768, 8, 1, 32)
+	copy(secret[:], derived)
+	var encrypted encryptedKey
+	encrypted.KDF.Name = "scrypt"
+	encrypted.KDF.Params.N, encrypted.KDF.Params.R, encrypted.KDF.Params.P = 32768, 8, 1
+	encrypted.KDF.Salt = salt
+	encrypted.Cipher.Name = "nacl/secretbox"
+	encrypted.Cipher.Nonce = nonce[:]
+	encrypted.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+	body, _ := json.Marshal(encrypted)
+	cosignKey := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: body})
+	if parsed, err := ParsePrivateKey(cosignKey, []byte("passw0rd")); err != nil || !parsed.Equal(key) {
+		t.Fatalf("parse cosign key: %v", err)
+	}
+	if _, err := ParsePrivateKey(cosignKey, []byte("wrong")); err == nil {
+		t.Fatal("expected wrong password to fail")
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var pubs []byte
+	for _, k := range []*ecdsa.PrivateKey{key, other} {
+		pubDER, _ := x509.MarshalPKIXPublicKey(&k.PublicKey)
+		pubs = append(pubs, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})...)
+	}
+	keys, err := ParsePublicKeys(pubs)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("expected two public keys, got %d err=%v", len(keys), err)
+	}
+}
+
+// capability_id: rainbond.image-signature.registry-match
+func TestMatchRegistry(t *testing.T) {
+	registries := []string{"harbor.example.com", "docker.io/library", "ghcr.io/goodrain/"}
+	cases := map[string]bool{
+		"harbor.example.com/demo/app:v1": true,
+		"harbor.example.com:8443/app:v1": false,
+		"nginx:1.25":                     true,
+		"docker.io/bitnami/redis":        false,
+		"ghcr.io/goodrain/rainbond:v6":   true,
+		"ghcr.io/goodrain-fork/app":      false,
+	}
+	for image, want := range cases {
+		if got := MatchRegistry(image, registries); got != want {
+			t.Errorf("MatchRegistry(%q) = %v, want %v", image, got, want)
+		}
+	}
+	if !MatchRegistry("any.example.com/app", []string{"*"}) {
+		t.Error("expected * to match all registries")
+	}
+}
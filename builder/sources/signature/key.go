@@ -0,0 +1,157 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// cosign generate-key-pair 生成的加密私钥的 PEM 类型
+var encryptedKeyTypes = map[string]bool{
+	"ENCRYPTED COSIGN PRIVATE KEY":   true,
+	"ENCRYPTED SIGSTORE PRIVATE KEY": true,
+}
+
+// encryptedKey cosign 加密私钥的内容，私钥使用 scrypt 派生的密钥通过 nacl/secretbox 加密
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey 加载签名私钥，支持 cosign 加密私钥以及未加密的 PKCS8、SEC1 格式的 ECDSA 私钥
+func LoadPrivateKey(file string, password []byte) (*ecdsa.PrivateKey, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read signing key %s", file)
+	}
+	return ParsePrivateKey(body, password)
+}
+
+// ParsePrivateKey 解析 PEM 格式的签名私钥
+func ParsePrivateKey(body, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not PEM encoded")
+	}
+	der := block.Bytes
+	var err error
+	switch {
+	case encryptedKeyTypes[block.Type]:
+		if der, err = decryptCosignKey(block.Bytes, password); err != nil {
+			return nil, err
+		}
+	case block.Type == "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	case block.Type != "PRIVATE KEY":
+		return nil, fmt.Errorf("unsupported signing key type %s", block.Type)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse signing key")
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is %T, only ECDSA keys are supported", key)
+	}
+	return ecKey, nil
+}
+
+func decryptCosignKey(body, password []byte) ([]byte, error) {
+	var key encryptedKey
+	if err := json.Unmarshal(body, &key); err != nil {
+		return nil, errors.Wrap(err, "decode encrypted signing key")
+	}
+	if key.KDF.Name != "scrypt" || key.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported encrypted signing key kdf %s cipher %s", key.KDF.Name, key.Cipher.Name)
+	}
+	if len(key.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("invalid nonce length %d of encrypted signing key", len(key.Cipher.Nonce))
+	}
+	secret, err := scrypt.Key(password, key.KDF.Salt, key.KDF.Params.N, key.KDF.Params.R, key.KDF.Params.P, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive signing key secret")
+	}
+	var nonce [24]byte
+	var secretKey [32]byte
+	copy(nonce[:], key.Cipher.Nonce)
+	copy(secretKey[:], secret)
+	der, ok := secretbox.Open(nil, key.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, fmt.Errorf("decrypt signing key failure, please check the key password")
+	}
+	return der, nil
+}
+
+// LoadPublicKeys 加载验证签名的公钥，一个文件中可以包含多个 PEM 格式的公钥
+func LoadPublicKeys(file string) ([]crypto.PublicKey, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read verification key %s", file)
+	}
+	return ParsePublicKeys(body)
+}
+
+// ParsePublicKeys 解析 PEM 格式的公钥
+func ParsePublicKeys(body []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, body = pem.Decode(body)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse verification key")
+		}
+		if _, ok := key.(*ecdsa.PublicKey); !ok {
+			return nil, fmt.Errorf("verification key is %T, only ECDSA keys are supported", key)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public key found")
+	}
+	return keys, nil
+}
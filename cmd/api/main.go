@@ -21,6 +21,7 @@ package main
 
 import (
 	"context"
+	"github.com/goodrain/rainbond/api/eventlog/store"
 	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/pkg/component"
 	sentryobs "github.com/goodrain/rainbond/pkg/observability/sentry"
@@ -36,6 +37,10 @@ func main() {
 		cmd.ShowVersion("api")
 	}
 	configs.Default().SetAppName("rbd-api").SetAPIFlags().SetPublicFlags().Parse().SetLog()
+	if len(os.Args) > 1 && os.Args[1] == "migrate-eventlog" {
+		migrateEventLog()
+		return
+	}
 	sentryobs.Init("rbd-api")
 	defer sentryobs.Flush()
 	// 启动 rbd-api
@@ -59,3 +64,14 @@ func main() {
 		logrus.Errorf("start rbd-api error %s", err.Error())
 	}
 }
+
+// migrateEventLog 将本地磁盘上的事件日志迁移到 --eventlog.store.type 指定的存储后端
+func migrateEventLog() {
+	rainbond.New(context.Background(), configs.Default()).Registry(component.Database())
+	migrated, err := store.MigrateLocalFileStore(configs.Default().EventLogConfig.Conf.EventStore, logrus.WithField("module", "EventLogMigrate"))
+	if err != nil {
+		logrus.Errorf("migrate event log failure after %d events migrated: %s", migrated, err.Error())
+		os.Exit(1)
+	}
+	logrus.Infof("migrate %d events to %s store success", migrated, configs.Default().EventLogConfig.Conf.EventStore.FileStoreType)
+}
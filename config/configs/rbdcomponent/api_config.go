@@ -1,6 +1,8 @@
 package rbdcomponent
 
 import (
+	"time"
+
 	"github.com/goodrain/rainbond-operator/util/constants"
 	"github.com/goodrain/rainbond/api/eventlog/conf"
 	utils "github.com/goodrain/rainbond/util"
@@ -142,4 +144,13 @@ func AddEventLogFlags(fs *pflag.FlagSet, elc *EventLogConfig) {
 	fs.StringVar(&elc.Conf.Entry.NewMonitorMessageServerConf.ListenerHost, "monitor.udp.host", "0.0.0.0", "receive new monitor udp server host")
 	fs.IntVar(&elc.Conf.Entry.NewMonitorMessageServerConf.ListenerPort, "monitor.udp.port", 6166, "receive new monitor udp server port")
 	fs.StringVar(&elc.Conf.EventStore.StorageHomePath, "docker.log.homepath", "/grdata/logs/", "container log persistent home path")
+	fs.StringVar(&elc.Conf.EventStore.FileStoreType, "eventlog.store.type", "local", "the backend of event log messages, support local, s3 and sql. use s3 or sql when api runs with more than one replica")
+	fs.StringVar(&elc.Conf.EventStore.FileStorePath, "eventlog.store.s3.path", "/grdata/logs/eventlog", "the path of event log messages in s3, the first directory is the bucket")
+	fs.StringVar(&elc.Conf.EventStore.FileCompression, "eventlog.store.compression", "gzip", "the compression of closed event log files in local store, support none, gzip and zstd")
+	fs.IntVar(&elc.Conf.EventStore.FileMaxSizeMB, "eventlog.store.max-file-size", 16, "the max size(MB) of an event log file in local store before it is rotated, never rotate when less than or equal to 0")
+	fs.DurationVar(&elc.Conf.EventStore.FileCompressAfter, "eventlog.store.compress-after", 10*time.Minute, "the event log file in local store without writing for this duration is closed and compressed")
+	fs.DurationVar(&elc.Conf.EventStore.Retention, "eventlog.retention", 0, "the default retention of event logs in local store, keep forever when less than or equal to 0")
+	fs.StringVar(&elc.Conf.EventStore.TenantRetention, "eventlog.retention.tenants", "", "the retention of event logs for tenants in local store, e.g. <tenant_id>=168h,<tenant_id>=2160h")
+	fs.BoolVar(&elc.Conf.EventStore.SearchIndex, "eventlog.search.index", false, "whether to index event log messages for searching, the index is kept for eventlog.search.retention days")
+	fs.IntVar(&elc.Conf.EventStore.SearchIndexRetentionDays, "eventlog.search.retention", 30, "the days to keep the search index of event log messages, never clean when less than or equal to 0")
 }
@@ -5,27 +5,36 @@ import (
 )
 
 type ChaosConfig struct {
-	RunMode          string
-	ClusterName      string
-	BuildKitImage    string
-	BuildKitArgs     string
-	BuildKitCache    bool
-	MaxTasks         int
-	DockerEndpoint   string
-	CleanUp          bool
-	Topic            string
-	RbdRepoName      string
-	GRDataPVCName    string
-	CachePVCName     string
-	CacheMode        string
-	CachePath        string
-	ContainerRuntime string
-	RuntimeEndpoint  string
-	KeepCount        int
-	CleanInterval    int
-	BRVersion        string
-	SourceScanURL    string
-	RegistryMirrors  string
+	RunMode                 string
+	ClusterName             string
+	BuildKitImage           string
+	BuildKitArgs            string
+	BuildKitCache           bool
+	MaxTasks                int
+	DockerEndpoint          string
+	CleanUp                 bool
+	Topic                   string
+	RbdRepoName             string
+	GRDataPVCName           string
+	CachePVCName            string
+	CacheMode               string
+	CachePath               string
+	ContainerRuntime        string
+	RuntimeEndpoint         string
+	KeepCount               int
+	CleanInterval           int
+	BRVersion               string
+	SourceScanURL           string
+	RegistryMirrors         string
+	SBOM                    bool
+	VulnDBPath              string
+	VulnPolicy              string
+	VulnSeverity            string
+	ImageSignKey            string
+	ImageVerifyKey          string
+	ImageVerifyMode         string
+	ImageVerifyRegistries   string
+	ImageInsecureRegistries string
 }
 
 func AddChaosFlags(fs *pflag.FlagSet, cc *ChaosConfig) {
@@ -49,4 +58,13 @@ func AddChaosFlags(fs *pflag.FlagSet, cc *ChaosConfig) {
 	fs.StringVar(&cc.BRVersion, "br-version", "stable", "builder and runner version")
 	fs.StringVar(&cc.SourceScanURL, "source-scan-url", "", "rainbond source scan service URL, eg: http://rainbond-sourcescan:8080")
 	fs.StringVar(&cc.RegistryMirrors, "registry-mirrors", "", "comma-separated registry mirrors for docker.io base-image pulls in dockerfile builds, empty means no mirror; can be overridden by env REGISTRY_MIRRORS. prefix a value with http:// to mark it as a plain-HTTP mirror endpoint")
+	fs.BoolVar(&cc.SBOM, "sbom", false, "generate a CycloneDX SBOM for every built image and store it with the build version")
+	fs.StringVar(&cc.VulnDBPath, "vuln-db", "", "local vulnerability database file (JSON) used to check SBOM packages, empty means no check")
+	fs.StringVar(&cc.VulnPolicy, "vuln-policy", "warn", "action when SBOM packages match the vulnerability database, can be off, warn or fail")
+	fs.StringVar(&cc.VulnSeverity, "vuln-fail-severity", "high", "minimum severity that fails the build when vuln-policy is fail, can be low, medium, high or critical")
+	fs.StringVar(&cc.ImageSignKey, "image-sign-key", "", "cosign compatible private key used to sign images pushed by the builder, empty means no signing. the key password is read from env COSIGN_PASSWORD")
+	fs.StringVar(&cc.ImageVerifyKey, "image-verify-key", "", "PEM file of trusted public keys used to verify signatures of images built from external registries")
+	fs.StringVar(&cc.ImageVerifyMode, "image-verify-mode", "off", "signature verification of images from image-verify-registries, can be off, warn or enforce. enforce rejects unsigned or wrongly-signed images")
+	fs.StringVar(&cc.ImageVerifyRegistries, "image-verify-registries", "", "comma-separated registries or repository prefixes whose images are verified, * means all registries")
+	fs.StringVar(&cc.ImageInsecureRegistries, "image-insecure-registries", "", "comma-separated registries using plain HTTP or self-signed certificates, their TLS certificates are not verified when checking image signatures. the cluster registry is always insecure")
 }
@@ -3,10 +3,15 @@ package rbdcomponent
 import "github.com/spf13/pflag"
 
 type MQConfig struct {
-	KeyPrefix string
-	RunMode   string //http grpc
-	HostName  string
-	APIPort   int
+	KeyPrefix         string
+	RunMode           string //http grpc
+	HostName          string
+	APIPort           int
+	StorageMode       string //memory wal
+	DataDir           string
+	VisibilityTimeout int
+	RetryPolicies     string
+	ConsumerGroupTTL  int
 }
 
 func AddMQFlags(fs *pflag.FlagSet, mqc *MQConfig) {
@@ -14,4 +19,9 @@ func AddMQFlags(fs *pflag.FlagSet, mqc *MQConfig) {
 	fs.StringVar(&mqc.RunMode, "mode", "grpc", "the api server run mode grpc or http")
 	fs.StringVar(&mqc.HostName, "hostName", "", "Current node host name")
 	fs.IntVar(&mqc.APIPort, "api-port", 6300, "the api server listen port")
+	fs.StringVar(&mqc.StorageMode, "mq-storage-mode", "wal", "the message storage mode, memory or wal(write-ahead log on local disk)")
+	fs.StringVar(&mqc.DataDir, "mq-data-dir", "/data/mq", "the directory of the message write-ahead log")
+	fs.IntVar(&mqc.VisibilityTimeout, "mq-visibility-timeout", 300, "seconds an unacked message stays invisible before it is redelivered")
+	fs.StringVar(&mqc.RetryPolicies, "mq-retry-policies", "", "per topic retry policy overrides, format topic=maxAttempts/initialBackoff/maxBackoff[/visibilityTimeout], e.g. builder=3/30s/5m/1h,worker=5/2s/1m")
+	fs.IntVar(&mqc.ConsumerGroupTTL, "mq-consumer-group-ttl", 86400, "seconds a consumer group may stay idle before it is removed and stops receiving messages, 0 means never")
 }
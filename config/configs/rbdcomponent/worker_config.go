@@ -15,6 +15,9 @@ type WorkerConfig struct {
 	SharedStorageClass      string
 	LeaderElectionNamespace string
 	LeaderElectionIdentity  string
+	ActivatorListen         string
+	ActivatorTimeout        int
+	GatewayZone             string
 	Helm                    Helm
 }
 
@@ -33,6 +36,9 @@ func AddWorkerFlags(fs *pflag.FlagSet, wc *WorkerConfig) {
 	fs.IntVar(&wc.ServerPort, "server-port", 6535, "the listen port that app runtime server")
 	fs.StringVar(&wc.LeaderElectionNamespace, "leader-election-namespace", "rbd-system", "Namespace where this attacher runs.")
 	fs.StringVar(&wc.LeaderElectionIdentity, "leader-election-identity", "", "Unique idenity of this attcher. Typically name of the pod where the attacher runs.")
+	fs.StringVar(&wc.ActivatorListen, "activator-listen", ":6370", "the listen address of the activator that wakes up components scaled to zero")
+	fs.IntVar(&wc.ActivatorTimeout, "activator-timeout", 120, "seconds the activator holds a request while waiting for the woken up component to be ready")
+	fs.StringVar(&wc.GatewayZone, "gateway-zone", "", "the zone of the gateway, the gateway upstream of third components prefers the endpoints in this zone")
 	fs.StringVar(&wc.Helm.DataDir, "/grdata/helm", "/grdata/helm", "The data directory of Helm.")
 	fs.StringVar(&wc.SharedStorageClass, "shared-storageclass", "", "custom shared storage class.use the specified storageclass to create shared storage, if this parameter is not specified, it will use rainbondsssc by default")
 	wc.Helm.RepoFile = path.Join(wc.Helm.DataDir, "repo/repositories.yaml")
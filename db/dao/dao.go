@@ -479,6 +479,14 @@ type VersionInfoDao interface {
 	ListVersionsByComponentIDs(componentIDs []string) ([]*model.VersionInfo, error)
 }
 
+// VersionSBOMDao build version sbom dao
+type VersionSBOMDao interface {
+	Dao
+	GetSBOMByEventID(eventID string) (*model.VersionSBOM, error)
+	DeleteSBOMByEventID(eventID string) error
+	DeleteSBOMByServiceID(serviceID string) error
+}
+
 // RegionUserInfoDao UserRegionInfoDao
 type RegionUserInfoDao interface {
 	Dao
@@ -503,6 +511,28 @@ type NotificationEventDao interface {
 	GetNotificationEventNotHandle() ([]*model.NotificationEvent, error)
 }
 
+// EventLogRecordDao event log record dao
+type EventLogRecordDao interface {
+	Dao
+	GetEventLogRecords(eventID string) ([]*model.EventLogRecord, error)
+	GetLastEventLogRecords(eventID string, n int) ([]*model.EventLogRecord, error)
+	GetEventIDs() ([]string, error)
+	DeleteEventLogRecords(eventID string) error
+	// DeleteEventLogRecordsBefore 删除最后一条消息早于 before 的事件
+	DeleteEventLogRecordsBefore(before time.Time) (int64, error)
+}
+
+// EventLogIndexDao event log search index dao
+type EventLogIndexDao interface {
+	Dao
+	CreateEventLogIndexesInBatch(indexes []*model.EventLogIndex) error
+	// SearchEventLogIndexes 按条件检索事件日志消息，按消息时间倒序返回，同时返回总数
+	SearchEventLogIndexes(query *model.EventLogSearchQuery, offset, limit int) ([]*model.EventLogIndex, int, error)
+	DeleteEventLogIndexes(eventID string) error
+	// DeleteEventLogIndexesBefore 删除消息时间早于 before 的索引，返回删除的条数
+	DeleteEventLogIndexesBefore(before time.Time) (int64, error)
+}
+
 // AppBackupDao group app backup history
 type AppBackupDao interface {
 	Dao
@@ -624,11 +654,31 @@ type TenantServceAutoscalerRuleMetricsDao interface {
 	Dao
 	UpdateOrCreate(metric *model.TenantServiceAutoscalerRuleMetrics) error
 	ListByRuleID(ruleID string) ([]*model.TenantServiceAutoscalerRuleMetrics, error)
+	ListCustomOfEnableRules() ([]*model.TenantServiceAutoscalerRuleMetrics, error)
 	DeleteByRuleID(ruldID string) error
 	DeleteByRuleIDs(ruleIDs []string) error
 	CreateOrUpdateScaleRuleMetricsInBatch(metrics []*model.TenantServiceAutoscalerRuleMetrics) error
 }
 
+// TenantServiceScheduledScalingDao -
+type TenantServiceScheduledScalingDao interface {
+	Dao
+	GetByScheduleID(scheduleID string) (*model.TenantServiceScheduledScaling, error)
+	ListByServiceID(serviceID string) ([]*model.TenantServiceScheduledScaling, error)
+	ListEnableOnes() ([]*model.TenantServiceScheduledScaling, error)
+	DeleteByScheduleID(scheduleID string) error
+	DeleteByComponentIDs(componentIDs []string) error
+}
+
+// TenantServiceIdlePolicyDao -
+type TenantServiceIdlePolicyDao interface {
+	Dao
+	GetByServiceID(serviceID string) (*model.TenantServiceIdlePolicy, error)
+	ListEnableOnes() ([]*model.TenantServiceIdlePolicy, error)
+	UpdateLastRequestTime(serviceID string, lastRequestTime time.Time) error
+	DeleteByComponentIDs(componentIDs []string) error
+}
+
 // TenantServiceScalingRecordsDao -
 type TenantServiceScalingRecordsDao interface {
 	Dao
@@ -4025,6 +4025,90 @@ func (mr *MockVersionInfoDaoMockRecorder) SearchVersionInfo() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchVersionInfo", reflect.TypeOf((*MockVersionInfoDao)(nil).SearchVersionInfo))
 }
 
+// MockVersionSBOMDao is a mock of VersionSBOMDao interface
+type MockVersionSBOMDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionSBOMDaoMockRecorder
+}
+
+// MockVersionSBOMDaoMockRecorder is the mock recorder for MockVersionSBOMDao
+type MockVersionSBOMDaoMockRecorder struct {
+	mock *MockVersionSBOMDao
+}
+
+// NewMockVersionSBOMDao creates a new mock instance
+func NewMockVersionSBOMDao(ctrl *gomock.Controller) *MockVersionSBOMDao {
+	mock := &MockVersionSBOMDao{ctrl: ctrl}
+	mock.recorder = &MockVersionSBOMDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVersionSBOMDao) EXPECT() *MockVersionSBOMDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockVersionSBOMDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockVersionSBOMDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockVersionSBOMDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockVersionSBOMDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockVersionSBOMDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockVersionSBOMDao)(nil).UpdateModel), arg0)
+}
+
+// GetSBOMByEventID mocks base method
+func (m *MockVersionSBOMDao) GetSBOMByEventID(eventID string) (*model.VersionSBOM, error) {
+	ret := m.ctrl.Call(m, "GetSBOMByEventID", eventID)
+	ret0, _ := ret[0].(*model.VersionSBOM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSBOMByEventID indicates an expected call of GetSBOMByEventID
+func (mr *MockVersionSBOMDaoMockRecorder) GetSBOMByEventID(eventID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSBOMByEventID", reflect.TypeOf((*MockVersionSBOMDao)(nil).GetSBOMByEventID), eventID)
+}
+
+// DeleteSBOMByEventID mocks base method
+func (m *MockVersionSBOMDao) DeleteSBOMByEventID(eventID string) error {
+	ret := m.ctrl.Call(m, "DeleteSBOMByEventID", eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSBOMByEventID indicates an expected call of DeleteSBOMByEventID
+func (mr *MockVersionSBOMDaoMockRecorder) DeleteSBOMByEventID(eventID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSBOMByEventID", reflect.TypeOf((*MockVersionSBOMDao)(nil).DeleteSBOMByEventID), eventID)
+}
+
+// DeleteSBOMByServiceID mocks base method
+func (m *MockVersionSBOMDao) DeleteSBOMByServiceID(serviceID string) error {
+	ret := m.ctrl.Call(m, "DeleteSBOMByServiceID", serviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSBOMByServiceID indicates an expected call of DeleteSBOMByServiceID
+func (mr *MockVersionSBOMDaoMockRecorder) DeleteSBOMByServiceID(serviceID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSBOMByServiceID", reflect.TypeOf((*MockVersionSBOMDao)(nil).DeleteSBOMByServiceID), serviceID)
+}
+
 // MockRegionUserInfoDao is a mock of RegionUserInfoDao interface
 type MockRegionUserInfoDao struct {
 	ctrl     *gomock.Controller
@@ -4282,6 +4366,215 @@ func (mr *MockNotificationEventDaoMockRecorder) GetNotificationEventNotHandle()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationEventNotHandle", reflect.TypeOf((*MockNotificationEventDao)(nil).GetNotificationEventNotHandle))
 }
 
+// MockEventLogRecordDao is a mock of EventLogRecordDao interface
+type MockEventLogRecordDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventLogRecordDaoMockRecorder
+}
+
+// MockEventLogRecordDaoMockRecorder is the mock recorder for MockEventLogRecordDao
+type MockEventLogRecordDaoMockRecorder struct {
+	mock *MockEventLogRecordDao
+}
+
+// NewMockEventLogRecordDao creates a new mock instance
+func NewMockEventLogRecordDao(ctrl *gomock.Controller) *MockEventLogRecordDao {
+	mock := &MockEventLogRecordDao{ctrl: ctrl}
+	mock.recorder = &MockEventLogRecordDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventLogRecordDao) EXPECT() *MockEventLogRecordDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockEventLogRecordDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockEventLogRecordDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockEventLogRecordDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockEventLogRecordDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockEventLogRecordDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockEventLogRecordDao)(nil).UpdateModel), arg0)
+}
+
+// GetEventLogRecords mocks base method
+func (m *MockEventLogRecordDao) GetEventLogRecords(eventID string) ([]*model.EventLogRecord, error) {
+	ret := m.ctrl.Call(m, "GetEventLogRecords", eventID)
+	ret0, _ := ret[0].([]*model.EventLogRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventLogRecords indicates an expected call of GetEventLogRecords
+func (mr *MockEventLogRecordDaoMockRecorder) GetEventLogRecords(eventID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventLogRecords", reflect.TypeOf((*MockEventLogRecordDao)(nil).GetEventLogRecords), eventID)
+}
+
+// GetLastEventLogRecords mocks base method
+func (m *MockEventLogRecordDao) GetLastEventLogRecords(eventID string, n int) ([]*model.EventLogRecord, error) {
+	ret := m.ctrl.Call(m, "GetLastEventLogRecords", eventID, n)
+	ret0, _ := ret[0].([]*model.EventLogRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEventLogRecords indicates an expected call of GetLastEventLogRecords
+func (mr *MockEventLogRecordDaoMockRecorder) GetLastEventLogRecords(eventID, n interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEventLogRecords", reflect.TypeOf((*MockEventLogRecordDao)(nil).GetLastEventLogRecords), eventID, n)
+}
+
+// GetEventIDs mocks base method
+func (m *MockEventLogRecordDao) GetEventIDs() ([]string, error) {
+	ret := m.ctrl.Call(m, "GetEventIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventIDs indicates an expected call of GetEventIDs
+func (mr *MockEventLogRecordDaoMockRecorder) GetEventIDs() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventIDs", reflect.TypeOf((*MockEventLogRecordDao)(nil).GetEventIDs))
+}
+
+// DeleteEventLogRecords mocks base method
+func (m *MockEventLogRecordDao) DeleteEventLogRecords(eventID string) error {
+	ret := m.ctrl.Call(m, "DeleteEventLogRecords", eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEventLogRecords indicates an expected call of DeleteEventLogRecords
+func (mr *MockEventLogRecordDaoMockRecorder) DeleteEventLogRecords(eventID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventLogRecords", reflect.TypeOf((*MockEventLogRecordDao)(nil).DeleteEventLogRecords), eventID)
+}
+
+// DeleteEventLogRecordsBefore mocks base method
+func (m *MockEventLogRecordDao) DeleteEventLogRecordsBefore(before time.Time) (int64, error) {
+	ret := m.ctrl.Call(m, "DeleteEventLogRecordsBefore", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventLogRecordsBefore indicates an expected call of DeleteEventLogRecordsBefore
+func (mr *MockEventLogRecordDaoMockRecorder) DeleteEventLogRecordsBefore(before interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventLogRecordsBefore", reflect.TypeOf((*MockEventLogRecordDao)(nil).DeleteEventLogRecordsBefore), before)
+}
+
+// MockEventLogIndexDao is a mock of EventLogIndexDao interface
+type MockEventLogIndexDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventLogIndexDaoMockRecorder
+}
+
+// MockEventLogIndexDaoMockRecorder is the mock recorder for MockEventLogIndexDao
+type MockEventLogIndexDaoMockRecorder struct {
+	mock *MockEventLogIndexDao
+}
+
+// NewMockEventLogIndexDao creates a new mock instance
+func NewMockEventLogIndexDao(ctrl *gomock.Controller) *MockEventLogIndexDao {
+	mock := &MockEventLogIndexDao{ctrl: ctrl}
+	mock.recorder = &MockEventLogIndexDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventLogIndexDao) EXPECT() *MockEventLogIndexDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockEventLogIndexDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockEventLogIndexDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockEventLogIndexDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockEventLogIndexDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockEventLogIndexDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockEventLogIndexDao)(nil).UpdateModel), arg0)
+}
+
+// CreateEventLogIndexesInBatch mocks base method
+func (m *MockEventLogIndexDao) CreateEventLogIndexesInBatch(indexes []*model.EventLogIndex) error {
+	ret := m.ctrl.Call(m, "CreateEventLogIndexesInBatch", indexes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEventLogIndexesInBatch indicates an expected call of CreateEventLogIndexesInBatch
+func (mr *MockEventLogIndexDaoMockRecorder) CreateEventLogIndexesInBatch(indexes interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEventLogIndexesInBatch", reflect.TypeOf((*MockEventLogIndexDao)(nil).CreateEventLogIndexesInBatch), indexes)
+}
+
+// SearchEventLogIndexes mocks base method
+func (m *MockEventLogIndexDao) SearchEventLogIndexes(query *model.EventLogSearchQuery, offset, limit int) ([]*model.EventLogIndex, int, error) {
+	ret := m.ctrl.Call(m, "SearchEventLogIndexes", query, offset, limit)
+	ret0, _ := ret[0].([]*model.EventLogIndex)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchEventLogIndexes indicates an expected call of SearchEventLogIndexes
+func (mr *MockEventLogIndexDaoMockRecorder) SearchEventLogIndexes(query, offset, limit interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEventLogIndexes", reflect.TypeOf((*MockEventLogIndexDao)(nil).SearchEventLogIndexes), query, offset, limit)
+}
+
+// DeleteEventLogIndexes mocks base method
+func (m *MockEventLogIndexDao) DeleteEventLogIndexes(eventID string) error {
+	ret := m.ctrl.Call(m, "DeleteEventLogIndexes", eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEventLogIndexes indicates an expected call of DeleteEventLogIndexes
+func (mr *MockEventLogIndexDaoMockRecorder) DeleteEventLogIndexes(eventID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventLogIndexes", reflect.TypeOf((*MockEventLogIndexDao)(nil).DeleteEventLogIndexes), eventID)
+}
+
+// DeleteEventLogIndexesBefore mocks base method
+func (m *MockEventLogIndexDao) DeleteEventLogIndexesBefore(before time.Time) (int64, error) {
+	ret := m.ctrl.Call(m, "DeleteEventLogIndexesBefore", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventLogIndexesBefore indicates an expected call of DeleteEventLogIndexesBefore
+func (mr *MockEventLogIndexDaoMockRecorder) DeleteEventLogIndexesBefore(before interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventLogIndexesBefore", reflect.TypeOf((*MockEventLogIndexDao)(nil).DeleteEventLogIndexesBefore), before)
+}
+
 // MockAppBackupDao is a mock of AppBackupDao interface
 type MockAppBackupDao struct {
 	ctrl     *gomock.Controller
@@ -5293,6 +5586,19 @@ func (mr *MockTenantServceAutoscalerRuleMetricsDaoMockRecorder) ListByRuleID(rul
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRuleID", reflect.TypeOf((*MockTenantServceAutoscalerRuleMetricsDao)(nil).ListByRuleID), ruleID)
 }
 
+// ListCustomOfEnableRules mocks base method
+func (m *MockTenantServceAutoscalerRuleMetricsDao) ListCustomOfEnableRules() ([]*model.TenantServiceAutoscalerRuleMetrics, error) {
+	ret := m.ctrl.Call(m, "ListCustomOfEnableRules")
+	ret0, _ := ret[0].([]*model.TenantServiceAutoscalerRuleMetrics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCustomOfEnableRules indicates an expected call of ListCustomOfEnableRules
+func (mr *MockTenantServceAutoscalerRuleMetricsDaoMockRecorder) ListCustomOfEnableRules() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCustomOfEnableRules", reflect.TypeOf((*MockTenantServceAutoscalerRuleMetricsDao)(nil).ListCustomOfEnableRules))
+}
+
 // DeleteByRuleID mocks base method
 func (m *MockTenantServceAutoscalerRuleMetricsDao) DeleteByRuleID(ruldID string) error {
 	ret := m.ctrl.Call(m, "DeleteByRuleID", ruldID)
@@ -5305,6 +5611,213 @@ func (mr *MockTenantServceAutoscalerRuleMetricsDaoMockRecorder) DeleteByRuleID(r
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRuleID", reflect.TypeOf((*MockTenantServceAutoscalerRuleMetricsDao)(nil).DeleteByRuleID), ruldID)
 }
 
+// MockTenantServiceScheduledScalingDao is a mock of TenantServiceScheduledScalingDao interface
+type MockTenantServiceScheduledScalingDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantServiceScheduledScalingDaoMockRecorder
+}
+
+// MockTenantServiceScheduledScalingDaoMockRecorder is the mock recorder for MockTenantServiceScheduledScalingDao
+type MockTenantServiceScheduledScalingDaoMockRecorder struct {
+	mock *MockTenantServiceScheduledScalingDao
+}
+
+// NewMockTenantServiceScheduledScalingDao creates a new mock instance
+func NewMockTenantServiceScheduledScalingDao(ctrl *gomock.Controller) *MockTenantServiceScheduledScalingDao {
+	mock := &MockTenantServiceScheduledScalingDao{ctrl: ctrl}
+	mock.recorder = &MockTenantServiceScheduledScalingDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTenantServiceScheduledScalingDao) EXPECT() *MockTenantServiceScheduledScalingDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockTenantServiceScheduledScalingDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockTenantServiceScheduledScalingDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).UpdateModel), arg0)
+}
+
+// GetByScheduleID mocks base method
+func (m *MockTenantServiceScheduledScalingDao) GetByScheduleID(scheduleID string) (*model.TenantServiceScheduledScaling, error) {
+	ret := m.ctrl.Call(m, "GetByScheduleID", scheduleID)
+	ret0, _ := ret[0].(*model.TenantServiceScheduledScaling)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByScheduleID indicates an expected call of GetByScheduleID
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) GetByScheduleID(scheduleID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByScheduleID", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).GetByScheduleID), scheduleID)
+}
+
+// ListByServiceID mocks base method
+func (m *MockTenantServiceScheduledScalingDao) ListByServiceID(serviceID string) ([]*model.TenantServiceScheduledScaling, error) {
+	ret := m.ctrl.Call(m, "ListByServiceID", serviceID)
+	ret0, _ := ret[0].([]*model.TenantServiceScheduledScaling)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByServiceID indicates an expected call of ListByServiceID
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) ListByServiceID(serviceID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByServiceID", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).ListByServiceID), serviceID)
+}
+
+// ListEnableOnes mocks base method
+func (m *MockTenantServiceScheduledScalingDao) ListEnableOnes() ([]*model.TenantServiceScheduledScaling, error) {
+	ret := m.ctrl.Call(m, "ListEnableOnes")
+	ret0, _ := ret[0].([]*model.TenantServiceScheduledScaling)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnableOnes indicates an expected call of ListEnableOnes
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) ListEnableOnes() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnableOnes", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).ListEnableOnes))
+}
+
+// DeleteByScheduleID mocks base method
+func (m *MockTenantServiceScheduledScalingDao) DeleteByScheduleID(scheduleID string) error {
+	ret := m.ctrl.Call(m, "DeleteByScheduleID", scheduleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByScheduleID indicates an expected call of DeleteByScheduleID
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) DeleteByScheduleID(scheduleID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByScheduleID", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).DeleteByScheduleID), scheduleID)
+}
+
+// DeleteByComponentIDs mocks base method
+func (m *MockTenantServiceScheduledScalingDao) DeleteByComponentIDs(componentIDs []string) error {
+	ret := m.ctrl.Call(m, "DeleteByComponentIDs", componentIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByComponentIDs indicates an expected call of DeleteByComponentIDs
+func (mr *MockTenantServiceScheduledScalingDaoMockRecorder) DeleteByComponentIDs(componentIDs interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByComponentIDs", reflect.TypeOf((*MockTenantServiceScheduledScalingDao)(nil).DeleteByComponentIDs), componentIDs)
+}
+
+// MockTenantServiceIdlePolicyDao is a mock of TenantServiceIdlePolicyDao interface
+type MockTenantServiceIdlePolicyDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantServiceIdlePolicyDaoMockRecorder
+}
+
+// MockTenantServiceIdlePolicyDaoMockRecorder is the mock recorder for MockTenantServiceIdlePolicyDao
+type MockTenantServiceIdlePolicyDaoMockRecorder struct {
+	mock *MockTenantServiceIdlePolicyDao
+}
+
+// NewMockTenantServiceIdlePolicyDao creates a new mock instance
+func NewMockTenantServiceIdlePolicyDao(ctrl *gomock.Controller) *MockTenantServiceIdlePolicyDao {
+	mock := &MockTenantServiceIdlePolicyDao{ctrl: ctrl}
+	mock.recorder = &MockTenantServiceIdlePolicyDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTenantServiceIdlePolicyDao) EXPECT() *MockTenantServiceIdlePolicyDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockTenantServiceIdlePolicyDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockTenantServiceIdlePolicyDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).UpdateModel), arg0)
+}
+
+// GetByServiceID mocks base method
+func (m *MockTenantServiceIdlePolicyDao) GetByServiceID(serviceID string) (*model.TenantServiceIdlePolicy, error) {
+	ret := m.ctrl.Call(m, "GetByServiceID", serviceID)
+	ret0, _ := ret[0].(*model.TenantServiceIdlePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByServiceID indicates an expected call of GetByServiceID
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) GetByServiceID(serviceID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByServiceID", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).GetByServiceID), serviceID)
+}
+
+// ListEnableOnes mocks base method
+func (m *MockTenantServiceIdlePolicyDao) ListEnableOnes() ([]*model.TenantServiceIdlePolicy, error) {
+	ret := m.ctrl.Call(m, "ListEnableOnes")
+	ret0, _ := ret[0].([]*model.TenantServiceIdlePolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnableOnes indicates an expected call of ListEnableOnes
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) ListEnableOnes() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnableOnes", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).ListEnableOnes))
+}
+
+// UpdateLastRequestTime mocks base method
+func (m *MockTenantServiceIdlePolicyDao) UpdateLastRequestTime(serviceID string, lastRequestTime time.Time) error {
+	ret := m.ctrl.Call(m, "UpdateLastRequestTime", serviceID, lastRequestTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastRequestTime indicates an expected call of UpdateLastRequestTime
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) UpdateLastRequestTime(serviceID, lastRequestTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastRequestTime", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).UpdateLastRequestTime), serviceID, lastRequestTime)
+}
+
+// DeleteByComponentIDs mocks base method
+func (m *MockTenantServiceIdlePolicyDao) DeleteByComponentIDs(componentIDs []string) error {
+	ret := m.ctrl.Call(m, "DeleteByComponentIDs", componentIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByComponentIDs indicates an expected call of DeleteByComponentIDs
+func (mr *MockTenantServiceIdlePolicyDaoMockRecorder) DeleteByComponentIDs(componentIDs interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByComponentIDs", reflect.TypeOf((*MockTenantServiceIdlePolicyDao)(nil).DeleteByComponentIDs), componentIDs)
+}
+
 // MockTenantServiceScalingRecordsDao is a mock of TenantServiceScalingRecordsDao interface
 type MockTenantServiceScalingRecordsDao struct {
 	ctrl     *gomock.Controller
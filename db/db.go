@@ -104,6 +104,7 @@ type Manager interface {
 
 	VersionInfoDao() dao.VersionInfoDao
 	VersionInfoDaoTransactions(db *gorm.DB) dao.VersionInfoDao
+	VersionSBOMDao() dao.VersionSBOMDao
 
 	RegionUserInfoDao() dao.RegionUserInfoDao
 	RegionUserInfoDaoTransactions(db *gorm.DB) dao.RegionUserInfoDao
@@ -112,6 +113,8 @@ type Manager interface {
 	RegionAPIClassDaoTransactions(db *gorm.DB) dao.RegionAPIClassDao
 
 	NotificationEventDao() dao.NotificationEventDao
+	EventLogRecordDao() dao.EventLogRecordDao
+	EventLogIndexDao() dao.EventLogIndexDao
 	AppBackupDao() dao.AppBackupDao
 	AppBackupDaoTransactions(db *gorm.DB) dao.AppBackupDao
 	ServiceSourceDao() dao.ServiceSourceDao
@@ -140,6 +143,10 @@ type Manager interface {
 	TenantServceAutoscalerRulesDaoTransactions(db *gorm.DB) dao.TenantServceAutoscalerRulesDao
 	TenantServceAutoscalerRuleMetricsDao() dao.TenantServceAutoscalerRuleMetricsDao
 	TenantServceAutoscalerRuleMetricsDaoTransactions(db *gorm.DB) dao.TenantServceAutoscalerRuleMetricsDao
+	TenantServiceScheduledScalingDao() dao.TenantServiceScheduledScalingDao
+	TenantServiceScheduledScalingDaoTransactions(db *gorm.DB) dao.TenantServiceScheduledScalingDao
+	TenantServiceIdlePolicyDao() dao.TenantServiceIdlePolicyDao
+	TenantServiceIdlePolicyDaoTransactions(db *gorm.DB) dao.TenantServiceIdlePolicyDao
 	TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao
 	TenantServiceScalingRecordsDaoTransactions(db *gorm.DB) dao.TenantServiceScalingRecordsDao
 
@@ -592,6 +592,20 @@ func (mr *MockManagerMockRecorder) TenantServiceLabelDaoTransactions(db interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceLabelDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServiceLabelDaoTransactions), db)
 }
 
+// VersionSBOMDao mocks base method
+func (m *MockManager) VersionSBOMDao() dao.VersionSBOMDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VersionSBOMDao")
+	ret0, _ := ret[0].(dao.VersionSBOMDao)
+	return ret0
+}
+
+// VersionSBOMDao indicates an expected call of VersionSBOMDao
+func (mr *MockManagerMockRecorder) VersionSBOMDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionSBOMDao", reflect.TypeOf((*MockManager)(nil).VersionSBOMDao))
+}
+
 // LocalSchedulerDao mocks base method
 func (m *MockManager) LocalSchedulerDao() dao.LocalSchedulerDao {
 	m.ctrl.T.Helper()
@@ -956,6 +970,34 @@ func (mr *MockManagerMockRecorder) NotificationEventDao() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotificationEventDao", reflect.TypeOf((*MockManager)(nil).NotificationEventDao))
 }
 
+// EventLogRecordDao mocks base method
+func (m *MockManager) EventLogRecordDao() dao.EventLogRecordDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventLogRecordDao")
+	ret0, _ := ret[0].(dao.EventLogRecordDao)
+	return ret0
+}
+
+// EventLogRecordDao indicates an expected call of EventLogRecordDao
+func (mr *MockManagerMockRecorder) EventLogRecordDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventLogRecordDao", reflect.TypeOf((*MockManager)(nil).EventLogRecordDao))
+}
+
+// EventLogIndexDao mocks base method
+func (m *MockManager) EventLogIndexDao() dao.EventLogIndexDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventLogIndexDao")
+	ret0, _ := ret[0].(dao.EventLogIndexDao)
+	return ret0
+}
+
+// EventLogIndexDao indicates an expected call of EventLogIndexDao
+func (mr *MockManagerMockRecorder) EventLogIndexDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventLogIndexDao", reflect.TypeOf((*MockManager)(nil).EventLogIndexDao))
+}
+
 // AppBackupDao mocks base method
 func (m *MockManager) AppBackupDao() dao.AppBackupDao {
 	m.ctrl.T.Helper()
@@ -1250,6 +1292,62 @@ func (mr *MockManagerMockRecorder) TenantServceAutoscalerRuleMetricsDaoTransacti
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServceAutoscalerRuleMetricsDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServceAutoscalerRuleMetricsDaoTransactions), db)
 }
 
+// TenantServiceScheduledScalingDao mocks base method
+func (m *MockManager) TenantServiceScheduledScalingDao() dao.TenantServiceScheduledScalingDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceScheduledScalingDao")
+	ret0, _ := ret[0].(dao.TenantServiceScheduledScalingDao)
+	return ret0
+}
+
+// TenantServiceScheduledScalingDao indicates an expected call of TenantServiceScheduledScalingDao
+func (mr *MockManagerMockRecorder) TenantServiceScheduledScalingDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceScheduledScalingDao", reflect.TypeOf((*MockManager)(nil).TenantServiceScheduledScalingDao))
+}
+
+// TenantServiceScheduledScalingDaoTransactions mocks base method
+func (m *MockManager) TenantServiceScheduledScalingDaoTransactions(db *gorm.DB) dao.TenantServiceScheduledScalingDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceScheduledScalingDaoTransactions", db)
+	ret0, _ := ret[0].(dao.TenantServiceScheduledScalingDao)
+	return ret0
+}
+
+// TenantServiceScheduledScalingDaoTransactions indicates an expected call of TenantServiceScheduledScalingDaoTransactions
+func (mr *MockManagerMockRecorder) TenantServiceScheduledScalingDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceScheduledScalingDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServiceScheduledScalingDaoTransactions), db)
+}
+
+// TenantServiceIdlePolicyDao mocks base method
+func (m *MockManager) TenantServiceIdlePolicyDao() dao.TenantServiceIdlePolicyDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceIdlePolicyDao")
+	ret0, _ := ret[0].(dao.TenantServiceIdlePolicyDao)
+	return ret0
+}
+
+// TenantServiceIdlePolicyDao indicates an expected call of TenantServiceIdlePolicyDao
+func (mr *MockManagerMockRecorder) TenantServiceIdlePolicyDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceIdlePolicyDao", reflect.TypeOf((*MockManager)(nil).TenantServiceIdlePolicyDao))
+}
+
+// TenantServiceIdlePolicyDaoTransactions mocks base method
+func (m *MockManager) TenantServiceIdlePolicyDaoTransactions(db *gorm.DB) dao.TenantServiceIdlePolicyDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceIdlePolicyDaoTransactions", db)
+	ret0, _ := ret[0].(dao.TenantServiceIdlePolicyDao)
+	return ret0
+}
+
+// TenantServiceIdlePolicyDaoTransactions indicates an expected call of TenantServiceIdlePolicyDaoTransactions
+func (mr *MockManagerMockRecorder) TenantServiceIdlePolicyDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceIdlePolicyDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServiceIdlePolicyDaoTransactions), db)
+}
+
 // TenantServiceScalingRecordsDao mocks base method
 func (m *MockManager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
 	m.ctrl.T.Helper()
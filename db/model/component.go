@@ -71,6 +71,8 @@ const (
 	K8sAttributeNameWorkingDir = "workingDir"
 	// K8sAttributeNameVMDiskImports -
 	K8sAttributeNameVMDiskImports = "vm_disk_imports"
+	// K8sAttributeNameVMRestoredClaims records the pvc restored from a vm snapshot for each vm volume
+	K8sAttributeNameVMRestoredClaims = "vm_restored_claims"
 )
 
 // ComponentK8sAttributes -
@@ -18,6 +18,8 @@
 
 package model
 
+import "time"
+
 //EventLogMessage event log message struct
 type EventLogMessage struct {
 	Model
@@ -30,3 +32,51 @@ type EventLogMessage struct {
 func (t *EventLogMessage) TableName() string {
 	return "event_log_message"
 }
+
+//EventLogRecord 事件日志的一条消息，事件日志使用数据库存储时使用
+type EventLogRecord struct {
+	Model
+	EventID string `gorm:"column:event_id;size:40;index:idx_event_log_record_event_id"`
+	Step    string `gorm:"column:step;size:64"`
+	Status  string `gorm:"column:status;size:32"`
+	Level   string `gorm:"column:level;size:16"`
+	Message string `gorm:"column:message;type:text"`
+	Time    string `gorm:"column:time;size:40"`
+}
+
+//TableName 表名
+func (t *EventLogRecord) TableName() string {
+	return "event_log_records"
+}
+
+//EventLogIndex 事件日志消息的检索索引，用于跨事件按租户、组件、步骤、级别和时间检索日志
+type EventLogIndex struct {
+	Model
+	TenantID    string    `gorm:"column:tenant_id;size:40;index:idx_event_log_index_tenant" json:"tenant_id"`
+	ServiceID   string    `gorm:"column:service_id;size:40;index:idx_event_log_index_service" json:"service_id"`
+	EventID     string    `gorm:"column:event_id;size:40;index:idx_event_log_index_event" json:"event_id"`
+	OptType     string    `gorm:"column:opt_type;size:40" json:"opt_type"`
+	Step        string    `gorm:"column:step;size:64" json:"step"`
+	Level       string    `gorm:"column:level;size:16" json:"level"`
+	Message     string    `gorm:"column:message;type:text" json:"message"`
+	MessageTime time.Time `gorm:"column:message_time;index:idx_event_log_index_time" json:"message_time"`
+}
+
+//TableName 表名
+func (t *EventLogIndex) TableName() string {
+	return "event_log_index"
+}
+
+//EventLogSearchQuery 事件日志检索条件，为空的条件不参与过滤
+type EventLogSearchQuery struct {
+	TenantID  string
+	ServiceID string
+	EventID   string
+	OptType   string
+	Step      string
+	Level     string
+	// Keyword 消息中包含的关键字
+	Keyword   string
+	StartTime time.Time
+	EndTime   time.Time
+}
@@ -19,6 +19,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -69,6 +70,10 @@ type Tenants struct {
 	LimitMemory  int    `gorm:"column:limit_memory"`
 	Status       string `gorm:"column:status;default:'normal'"`
 	Namespace    string `gorm:"column:namespace;size:32;unique_index"`
+	// BuildQuota 租户同时执行的构建任务上限，0 表示不限制
+	BuildQuota int `gorm:"column:build_quota;default:0"`
+	// BuildWeight 构建任务在租户之间公平调度的权重
+	BuildWeight int `gorm:"column:build_weight;default:1"`
 }
 
 // TableName 返回租户表名称
@@ -590,6 +595,8 @@ type TenantServiceConfigFile struct {
 	ServiceID   string `gorm:"column:service_id;size:32" json:"service_id"`
 	VolumeName  string `gorm:"column:volume_name;size:128" json:"volume_name"`
 	FileContent string `gorm:"column:file_content;size:65535" json:"filename"`
+	// Secret 为 true 时文件内容以组件独立的 Secret 挂载，不写入 ConfigMap
+	Secret bool `gorm:"column:secret;default:false" json:"secret"`
 }
 
 // TableName returns table name of TenantServiceConfigFile.
@@ -654,6 +661,14 @@ type TenantServiceProbe struct {
 	//标志为成功的检测次数
 	SuccessThreshold int    `gorm:"column:success_threshold;size:2;default:1" json:"success_threshold" validate:"success_threshold"`
 	FailureAction    string `gorm:"column:failure_action;" json:"failure_action" validate:"failure_action"`
+	//gRPC 健康检查的服务名，为空时检查整个服务端
+	GRPCService string `gorm:"column:grpc_service;size:255" json:"grpc_service"`
+	//gRPC 健康检查是否使用 TLS
+	GRPCTLS bool `gorm:"column:grpc_tls;default:false" json:"grpc_tls"`
+	//gRPC 健康检查是否跳过证书校验
+	GRPCInsecureSkipVerify bool `gorm:"column:grpc_insecure_skip_verify;default:false" json:"grpc_insecure_skip_verify"`
+	//gRPC 健康检查校验证书使用的服务器名称
+	GRPCServerName string `gorm:"column:grpc_server_name;size:255" json:"grpc_server_name"`
 }
 
 // FailureActionType  type of failure action.
@@ -686,6 +701,24 @@ type TenantServiceAutoscalerRules struct {
 	XPAType     string `gorm:"column:xpa_type;size:3"`
 	MinReplicas int    `gorm:"colume:min_replicas"`
 	MaxReplicas int    `gorm:"colume:max_replicas"`
+	// Behavior json of AutoscalerBehavior, empty means the default behavior of the HPA
+	Behavior string `gorm:"column:behavior;type:text"`
+	// ScheduledMinReplicas and ScheduledMaxReplicas are set by the scheduled scaling and override
+	// the replicas range of the rule, nil means no schedule is in effect
+	ScheduledMinReplicas *int `gorm:"column:scheduled_min_replicas"`
+	ScheduledMaxReplicas *int `gorm:"column:scheduled_max_replicas"`
+}
+
+// ReplicasRange returns the replicas range of the HPA, the scheduled override takes precedence
+func (t *TenantServiceAutoscalerRules) ReplicasRange() (int, int) {
+	minReplicas, maxReplicas := t.MinReplicas, t.MaxReplicas
+	if t.ScheduledMinReplicas != nil {
+		minReplicas = *t.ScheduledMinReplicas
+	}
+	if t.ScheduledMaxReplicas != nil {
+		maxReplicas = *t.ScheduledMaxReplicas
+	}
+	return minReplicas, maxReplicas
 }
 
 // TableName -
@@ -693,6 +726,55 @@ func (t *TenantServiceAutoscalerRules) TableName() string {
 	return "tenant_services_autoscaler_rules"
 }
 
+// GetBehavior returns the scale up and scale down behavior of the rule, nil means the default behavior
+func (t *TenantServiceAutoscalerRules) GetBehavior() (*AutoscalerBehavior, error) {
+	if t.Behavior == "" {
+		return nil, nil
+	}
+	var behavior AutoscalerBehavior
+	if err := json.Unmarshal([]byte(t.Behavior), &behavior); err != nil {
+		return nil, fmt.Errorf("unmarshal behavior of rule %s: %v", t.RuleID, err)
+	}
+	return &behavior, nil
+}
+
+// SetBehavior -
+func (t *TenantServiceAutoscalerRules) SetBehavior(behavior *AutoscalerBehavior) error {
+	if behavior == nil {
+		t.Behavior = ""
+		return nil
+	}
+	body, err := json.Marshal(behavior)
+	if err != nil {
+		return err
+	}
+	t.Behavior = string(body)
+	return nil
+}
+
+// AutoscalerBehavior the scale up and scale down behavior of the HPA
+type AutoscalerBehavior struct {
+	ScaleUp   *AutoscalerScalingRules `json:"scale_up,omitempty"`
+	ScaleDown *AutoscalerScalingRules `json:"scale_down,omitempty"`
+}
+
+// AutoscalerScalingRules scaling policies of one direction
+type AutoscalerScalingRules struct {
+	// StabilizationWindowSeconds the number of seconds the past recommendations are considered
+	StabilizationWindowSeconds *int32 `json:"stabilization_window_seconds,omitempty"`
+	// SelectPolicy Max, Min or Disabled
+	SelectPolicy string                    `json:"select_policy,omitempty"`
+	Policies     []AutoscalerScalingPolicy `json:"policies,omitempty"`
+}
+
+// AutoscalerScalingPolicy a single policy which must hold true for a specified past interval
+type AutoscalerScalingPolicy struct {
+	// Type Pods or Percent
+	Type          string `json:"type"`
+	Value         int32  `json:"value"`
+	PeriodSeconds int32  `json:"period_seconds"`
+}
+
 // TenantServiceAutoscalerRuleMetrics -
 type TenantServiceAutoscalerRuleMetrics struct {
 	Model
@@ -701,6 +783,29 @@ type TenantServiceAutoscalerRuleMetrics struct {
 	MetricsName       string `gorm:"column:metric_name;not null"`
 	MetricTargetType  string `gorm:"column:metric_target_type;not null"`
 	MetricTargetValue int    `gorm:"column:metric_target_value;not null"`
+	// MetricQuery is the PromQL expression backing pods, object and external metrics
+	MetricQuery string `gorm:"column:metric_query;type:text"`
+	// ObjectKind, ObjectName and ObjectAPIVersion describe the object of an object metric
+	ObjectKind       string `gorm:"column:object_kind"`
+	ObjectName       string `gorm:"column:object_name"`
+	ObjectAPIVersion string `gorm:"column:object_api_version"`
+}
+
+// autoscaler rule metric types
+const (
+	// ResourceMetricsType cpu or memory of the pods
+	ResourceMetricsType = "resource_metrics"
+	// PodsMetricsType a custom metric averaged across the pods, eg: requests per second
+	PodsMetricsType = "pods_metrics"
+	// ObjectMetricsType a custom metric describing a single kubernetes object, eg: ingress hits
+	ObjectMetricsType = "object_metrics"
+	// ExternalMetricsType a metric not associated with any kubernetes object, eg: queue depth
+	ExternalMetricsType = "external_metrics"
+)
+
+// IsCustom returns whether the metric is backed by a PromQL query instead of the resource metrics api
+func (t *TenantServiceAutoscalerRuleMetrics) IsCustom() bool {
+	return t.MetricsType == PodsMetricsType || t.MetricsType == ObjectMetricsType || t.MetricsType == ExternalMetricsType
 }
 
 // TableName -
@@ -708,6 +813,44 @@ func (t *TenantServiceAutoscalerRuleMetrics) TableName() string {
 	return "tenant_services_autoscaler_rule_metrics"
 }
 
+// TenantServiceScheduledScaling cron based scaling rule of the component
+type TenantServiceScheduledScaling struct {
+	Model
+	ScheduleID string `gorm:"column:schedule_id;unique;size:32" json:"schedule_id"`
+	ServiceID  string `gorm:"column:service_id;size:32" json:"service_id"`
+	Name       string `gorm:"column:name" json:"name"`
+	// Cron five field cron expression, eg: "0 9 * * 1-5"
+	Cron string `gorm:"column:cron;not null" json:"cron"`
+	// Timezone IANA time zone the cron expression is evaluated in, default is UTC
+	Timezone    string     `gorm:"column:timezone" json:"timezone"`
+	MinReplicas int        `gorm:"column:min_replicas" json:"min_replicas"`
+	MaxReplicas int        `gorm:"column:max_replicas" json:"max_replicas"`
+	Enable      bool       `gorm:"column:enable" json:"enable"`
+	LastTime    *time.Time `gorm:"column:last_time" json:"last_time"`
+}
+
+// TableName -
+func (t *TenantServiceScheduledScaling) TableName() string {
+	return "tenant_services_scheduled_scaling"
+}
+
+// TenantServiceIdlePolicy scale the component to zero when there is no http traffic for IdleMinutes,
+// the activator wakes it up on the next request.
+type TenantServiceIdlePolicy struct {
+	Model
+	ServiceID   string `gorm:"column:service_id;unique;size:32" json:"service_id"`
+	Enable      bool   `gorm:"column:enable" json:"enable"`
+	IdleMinutes int    `gorm:"column:idle_minutes" json:"idle_minutes"`
+	// LastRequestTime the last time the activator forwarded a request to the component
+	LastRequestTime *time.Time `gorm:"column:last_request_time" json:"last_request_time"`
+	UpdateTime      time.Time  `gorm:"column:update_time" json:"update_time"`
+}
+
+// TableName -
+func (t *TenantServiceIdlePolicy) TableName() string {
+	return "tenant_services_idle_policy"
+}
+
 // TenantServiceScalingRecords -
 type TenantServiceScalingRecords struct {
 	Model
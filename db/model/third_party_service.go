@@ -29,6 +29,10 @@ type Endpoint struct {
 	ServiceID string `gorm:"column:service_id;size:32;not null" json:"service_id"`
 	IP        string `gorm:"column:ip;not null" json:"ip"`
 	Port      int    `gorm:"column:port;size:65535" json:"port"`
+	// Weight 实例在网关中的流量权重，为空时使用默认权重，0 表示实例不接收流量
+	Weight *int `gorm:"column:weight" json:"weight,omitempty"`
+	// Zone 实例所在的可用区
+	Zone string `gorm:"column:zone;size:64" json:"zone"`
 }
 
 // TableName returns table name of Endpoint.
@@ -19,7 +19,9 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/docker/distribution/reference"
@@ -52,6 +54,18 @@ type VersionInfo struct {
 	FinalStatus string    `gorm:"column:final_status;size:40" json:"final_status"`
 	FinishTime  time.Time `gorm:"column:finish_time;" json:"finish_time"`
 	PlanVersion string    `gorm:"column:plan_version;size:250" json:"plan_version"`
+	// ImageDigest 交付镜像在镜像仓库中的 digest
+	ImageDigest string `gorm:"column:image_digest;size:100" json:"image_digest"`
+	//SignatureStatus image signature status
+	//signed: the image pushed by builder is signed
+	//verified: the signature of the source image is verified
+	//unsigned: the source image has no signature
+	//invalid: the source image is not signed by a trusted key
+	//error: signing or verification failed
+	SignatureStatus  string `gorm:"column:signature_status;size:20" json:"signature_status"`
+	SignatureMessage string `gorm:"column:signature_message;size:1024" json:"signature_message"`
+	// ArchDigests 多架构镜像各架构的 digest，JSON 格式，如 {"amd64":"sha256:...","arm64":"sha256:..."}
+	ArchDigests string `gorm:"column:arch_digests;type:text" json:"arch_digests"`
 }
 
 // VersionInfoCount VersionInfoCount
@@ -82,3 +96,66 @@ func (t *VersionInfo) CreateShareImage(hubURL, namespace, appVersion string) (st
 	image.Name = fmt.Sprintf("%s:%s", t.ServiceID, t.BuildVersion)
 	return image.String(), nil
 }
+
+// GetArchDigests returns the per-arch digests of a multi-arch image
+func (t *VersionInfo) GetArchDigests() map[string]string {
+	if t.ArchDigests == "" {
+		return nil
+	}
+	digests := make(map[string]string)
+	if err := json.Unmarshal([]byte(t.ArchDigests), &digests); err != nil {
+		logrus.Warningf("unmarshal arch digests of version %s: %v", t.BuildVersion, err)
+		return nil
+	}
+	return digests
+}
+
+// SetArchDigests records the per-arch digests of a multi-arch image
+func (t *VersionInfo) SetArchDigests(digests map[string]string) {
+	if len(digests) == 0 {
+		t.ArchDigests = ""
+		return
+	}
+	body, _ := json.Marshal(digests)
+	t.ArchDigests = string(body)
+}
+
+// Arches returns the sorted architectures the delivered image is available for,
+// nil means the image only matches the architecture it was built on.
+func (t *VersionInfo) Arches() []string {
+	digests := t.GetArchDigests()
+	if len(digests) == 0 {
+		return nil
+	}
+	arches := make([]string, 0, len(digests))
+	for arch := range digests {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	return arches
+}
+
+// VersionSBOM 构建版本产出镜像的软件物料清单，与 VersionInfo 通过 event_id 关联
+type VersionSBOM struct {
+	Model
+	ServiceID    string `gorm:"column:service_id;size:40;index:service_id" json:"service_id"`
+	EventID      string `gorm:"column:event_id;size:40;uniqueIndex:sbom_event_id" json:"event_id"`
+	BuildVersion string `gorm:"column:build_version;size:40" json:"build_version"`
+	ImageName    string `gorm:"column:image_name;size:250" json:"image_name"`
+	Format       string `gorm:"column:format;size:40" json:"format"`
+	Document     string `gorm:"column:document;type:longtext" json:"document"`
+	PackageCount int    `gorm:"column:package_count" json:"package_count"`
+	// VulnerabilityCount 命中本地漏洞库的数量
+	VulnerabilityCount int `gorm:"column:vulnerability_count" json:"vulnerability_count"`
+	// PolicyResult 漏洞检查结果
+	//pass: no vulnerability matched
+	//warn: vulnerabilities matched but the build is allowed
+	//fail: the build is failed by the vulnerability policy
+	//skip: vulnerability check is not configured
+	PolicyResult string `gorm:"column:policy_result;size:20" json:"policy_result"`
+}
+
+// TableName 表名
+func (t *VersionSBOM) TableName() string {
+	return "tenant_service_version_sbom"
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dao
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/jinzhu/gorm"
+)
+
+// EventLogRecordDaoImpl EventLogRecordDaoImpl
+type EventLogRecordDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel AddModel
+func (c *EventLogRecordDaoImpl) AddModel(mo model.Interface) error {
+	record := mo.(*model.EventLogRecord)
+	return c.DB.Create(record).Error
+}
+
+// UpdateModel UpdateModel
+func (c *EventLogRecordDaoImpl) UpdateModel(mo model.Interface) error {
+	record := mo.(*model.EventLogRecord)
+	return c.DB.Save(record).Error
+}
+
+// GetEventLogRecords 按写入顺序返回事件的所有消息
+func (c *EventLogRecordDaoImpl) GetEventLogRecords(eventID string) ([]*model.EventLogRecord, error) {
+	var records []*model.EventLogRecord
+	if err := c.DB.Where("event_id = ?", eventID).Order("ID asc").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetLastEventLogRecords 按写入顺序返回事件的最后 n 条消息
+func (c *EventLogRecordDaoImpl) GetLastEventLogRecords(eventID string, n int) ([]*model.EventLogRecord, error) {
+	var records []*model.EventLogRecord
+	if err := c.DB.Where("event_id = ?", eventID).Order("ID desc").Limit(n).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// GetEventIDs 返回有消息的所有事件
+func (c *EventLogRecordDaoImpl) GetEventIDs() ([]string, error) {
+	var eventIDs []string
+	if err := c.DB.Model(&model.EventLogRecord{}).Pluck("distinct(event_id)", &eventIDs).Error; err != nil {
+		return nil, err
+	}
+	return eventIDs, nil
+}
+
+// DeleteEventLogRecords 删除事件的所有消息
+func (c *EventLogRecordDaoImpl) DeleteEventLogRecords(eventID string) error {
+	return c.DB.Where("event_id = ?", eventID).Delete(&model.EventLogRecord{}).Error
+}
+
+// DeleteEventLogRecordsBefore 删除最后一条消息早于 before 的事件，返回删除的消息数
+func (c *EventLogRecordDaoImpl) DeleteEventLogRecordsBefore(before time.Time) (int64, error) {
+	// mysql 不允许在删除的子查询中直接引用同一张表，需要再包装一层
+	result := c.DB.Where("event_id IN (SELECT event_id FROM (SELECT event_id FROM event_log_records GROUP BY event_id HAVING MAX(create_time) < ?) expired)", before).
+		Delete(&model.EventLogRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// EventLogIndexDaoImpl EventLogIndexDaoImpl
+type EventLogIndexDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel AddModel
+func (c *EventLogIndexDaoImpl) AddModel(mo model.Interface) error {
+	index := mo.(*model.EventLogIndex)
+	return c.DB.Create(index).Error
+}
+
+// UpdateModel UpdateModel
+func (c *EventLogIndexDaoImpl) UpdateModel(mo model.Interface) error {
+	index := mo.(*model.EventLogIndex)
+	return c.DB.Save(index).Error
+}
+
+// CreateEventLogIndexesInBatch 在一个事务中写入一批索引
+func (c *EventLogIndexDaoImpl) CreateEventLogIndexesInBatch(indexes []*model.EventLogIndex) error {
+	tx := c.DB.Begin()
+	for _, index := range indexes {
+		if err := tx.Create(index).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// SearchEventLogIndexes 按条件检索事件日志消息
+func (c *EventLogIndexDaoImpl) SearchEventLogIndexes(query *model.EventLogSearchQuery, offset, limit int) ([]*model.EventLogIndex, int, error) {
+	db := c.DB.Model(&model.EventLogIndex{})
+	if query.TenantID != "" {
+		db = db.Where("tenant_id = ?", query.TenantID)
+	}
+	if query.ServiceID != "" {
+		db = db.Where("service_id = ?", query.ServiceID)
+	}
+	if query.EventID != "" {
+		db = db.Where("event_id = ?", query.EventID)
+	}
+	if query.OptType != "" {
+		db = db.Where("opt_type = ?", query.OptType)
+	}
+	if query.Step != "" {
+		db = db.Where("step = ?", query.Step)
+	}
+	if query.Level != "" {
+		db = db.Where("level = ?", query.Level)
+	}
+	if query.Keyword != "" {
+		db = c.whereKeyword(db, query.Keyword)
+	}
+	if !query.StartTime.IsZero() {
+		db = db.Where("message_time >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		db = db.Where("message_time < ?", query.EndTime)
+	}
+	var total int
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var indexes []*model.EventLogIndex
+	if err := db.Order("message_time desc, ID desc").Offset(offset).Limit(limit).Find(&indexes).Error; err != nil {
+		return nil, 0, err
+	}
+	return indexes, total, nil
+}
+
+// eventLogNgramTokenSize mysql ngram 全文解析器默认的分词长度 ngram_token_size
+const eventLogNgramTokenSize = 2
+
+// whereKeyword 按关键字过滤消息，mysql 使用 message 列的全文索引做短语匹配，
+// 其他数据库以及短于 ngram 分词长度的关键字使用 LIKE 匹配
+func (c *EventLogIndexDaoImpl) whereKeyword(db *gorm.DB, keyword string) *gorm.DB {
+	if c.DB.Dialect().GetName() == "mysql" && utf8.RuneCountInString(keyword) >= eventLogNgramTokenSize {
+		phrase := `"` + strings.ReplaceAll(keyword, `"`, " ") + `"`
+		return db.Where("MATCH(message) AGAINST(? IN BOOLEAN MODE)", phrase)
+	}
+	return db.Where("message LIKE ?", "%"+keyword+"%")
+}
+
+// DeleteEventLogIndexes 删除事件的所有索引
+func (c *EventLogIndexDaoImpl) DeleteEventLogIndexes(eventID string) error {
+	return c.DB.Where("event_id = ?", eventID).Delete(&model.EventLogIndex{}).Error
+}
+
+// DeleteEventLogIndexesBefore 删除消息时间早于 before 的索引
+func (c *EventLogIndexDaoImpl) DeleteEventLogIndexesBefore(before time.Time) (int64, error) {
+	result := c.DB.Where("message_time < ?", before).Delete(&model.EventLogIndex{})
+	return result.RowsAffected, result.Error
+}
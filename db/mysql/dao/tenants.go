@@ -1567,6 +1567,7 @@ func (t *TenantServiceConfigFileDaoImpl) AddModel(mo model.Interface) error {
 		}
 	} else {
 		old.FileContent = configFile.FileContent
+		old.Secret = configFile.Secret
 		if err := t.DB.Save(&old).Error; err != nil {
 			return err
 		}
@@ -2167,6 +2168,10 @@ func (t *TenantServceAutoscalerRuleMetricsDaoImpl) UpdateOrCreate(metric *model.
 	} else {
 		old.MetricTargetType = metric.MetricTargetType
 		old.MetricTargetValue = metric.MetricTargetValue
+		old.MetricQuery = metric.MetricQuery
+		old.ObjectKind = metric.ObjectKind
+		old.ObjectName = metric.ObjectName
+		old.ObjectAPIVersion = metric.ObjectAPIVersion
 		if err := t.DB.Save(&old).Error; err != nil {
 			return err
 		}
@@ -2183,6 +2188,17 @@ func (t *TenantServceAutoscalerRuleMetricsDaoImpl) ListByRuleID(ruleID string) (
 	return metrics, nil
 }
 
+// ListCustomOfEnableRules lists the PromQL backed metrics of all enabled rules
+func (t *TenantServceAutoscalerRuleMetricsDaoImpl) ListCustomOfEnableRules() ([]*model.TenantServiceAutoscalerRuleMetrics, error) {
+	var metrics []*model.TenantServiceAutoscalerRuleMetrics
+	ruleIDs := t.DB.Model(&model.TenantServiceAutoscalerRules{}).Select("rule_id").Where("enable=?", true).QueryExpr()
+	if err := t.DB.Where("rule_id in (?) and metric_type in (?)", ruleIDs,
+		[]string{model.PodsMetricsType, model.ObjectMetricsType, model.ExternalMetricsType}).Order("rule_id, metric_name").Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
 // DeleteByRuleID -
 func (t *TenantServceAutoscalerRuleMetricsDaoImpl) DeleteByRuleID(ruldID string) error {
 	if err := t.DB.Where("rule_id=?", ruldID).Delete(&model.TenantServiceAutoscalerRuleMetrics{}).Error; err != nil {
@@ -2288,6 +2304,113 @@ func (t *TenantServiceScalingRecordsDaoImpl) CountByServiceID(serviceID string)
 	return count, nil
 }
 
+// TenantServiceScheduledScalingDaoImpl -
+type TenantServiceScheduledScalingDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel -
+func (t *TenantServiceScheduledScalingDaoImpl) AddModel(mo model.Interface) error {
+	schedule := mo.(*model.TenantServiceScheduledScaling)
+	var old model.TenantServiceScheduledScaling
+	if ok := t.DB.Where("schedule_id=?", schedule.ScheduleID).Find(&old).RecordNotFound(); ok {
+		return t.DB.Create(schedule).Error
+	}
+	return dberr.ErrRecordAlreadyExist
+}
+
+// UpdateModel -
+func (t *TenantServiceScheduledScalingDaoImpl) UpdateModel(mo model.Interface) error {
+	schedule := mo.(*model.TenantServiceScheduledScaling)
+	return t.DB.Save(schedule).Error
+}
+
+// GetByScheduleID -
+func (t *TenantServiceScheduledScalingDaoImpl) GetByScheduleID(scheduleID string) (*model.TenantServiceScheduledScaling, error) {
+	var schedule model.TenantServiceScheduledScaling
+	if err := t.DB.Where("schedule_id=?", scheduleID).Find(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListByServiceID -
+func (t *TenantServiceScheduledScalingDaoImpl) ListByServiceID(serviceID string) ([]*model.TenantServiceScheduledScaling, error) {
+	var schedules []*model.TenantServiceScheduledScaling
+	if err := t.DB.Where("service_id=?", serviceID).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ListEnableOnes -
+func (t *TenantServiceScheduledScalingDaoImpl) ListEnableOnes() ([]*model.TenantServiceScheduledScaling, error) {
+	var schedules []*model.TenantServiceScheduledScaling
+	if err := t.DB.Where("enable=?", true).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// DeleteByScheduleID -
+func (t *TenantServiceScheduledScalingDaoImpl) DeleteByScheduleID(scheduleID string) error {
+	return t.DB.Where("schedule_id=?", scheduleID).Delete(&model.TenantServiceScheduledScaling{}).Error
+}
+
+// DeleteByComponentIDs -
+func (t *TenantServiceScheduledScalingDaoImpl) DeleteByComponentIDs(componentIDs []string) error {
+	return t.DB.Where("service_id in (?)", componentIDs).Delete(&model.TenantServiceScheduledScaling{}).Error
+}
+
+// TenantServiceIdlePolicyDaoImpl -
+type TenantServiceIdlePolicyDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel -
+func (t *TenantServiceIdlePolicyDaoImpl) AddModel(mo model.Interface) error {
+	policy := mo.(*model.TenantServiceIdlePolicy)
+	var old model.TenantServiceIdlePolicy
+	if ok := t.DB.Where("service_id=?", policy.ServiceID).Find(&old).RecordNotFound(); ok {
+		return t.DB.Create(policy).Error
+	}
+	return dberr.ErrRecordAlreadyExist
+}
+
+// UpdateModel -
+func (t *TenantServiceIdlePolicyDaoImpl) UpdateModel(mo model.Interface) error {
+	policy := mo.(*model.TenantServiceIdlePolicy)
+	return t.DB.Save(policy).Error
+}
+
+// GetByServiceID -
+func (t *TenantServiceIdlePolicyDaoImpl) GetByServiceID(serviceID string) (*model.TenantServiceIdlePolicy, error) {
+	var policy model.TenantServiceIdlePolicy
+	if err := t.DB.Where("service_id=?", serviceID).Find(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListEnableOnes -
+func (t *TenantServiceIdlePolicyDaoImpl) ListEnableOnes() ([]*model.TenantServiceIdlePolicy, error) {
+	var policies []*model.TenantServiceIdlePolicy
+	if err := t.DB.Where("enable=?", true).Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// UpdateLastRequestTime -
+func (t *TenantServiceIdlePolicyDaoImpl) UpdateLastRequestTime(serviceID string, lastRequestTime time.Time) error {
+	return t.DB.Model(&model.TenantServiceIdlePolicy{}).Where("service_id=?", serviceID).Update("last_request_time", lastRequestTime).Error
+}
+
+// DeleteByComponentIDs -
+func (t *TenantServiceIdlePolicyDaoImpl) DeleteByComponentIDs(componentIDs []string) error {
+	return t.DB.Where("service_id in (?)", componentIDs).Delete(&model.TenantServiceIdlePolicy{}).Error
+}
+
 // ComponentK8sAttributeDaoImpl The K8s attribute value of the component
 type ComponentK8sAttributeDaoImpl struct {
 	DB *gorm.DB
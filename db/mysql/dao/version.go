@@ -198,3 +198,45 @@ func (c *VersionInfoDaoImpl) ListVersionsByComponentIDs(componentIDs []string) (
 	}
 	return result, nil
 }
+
+// VersionSBOMDaoImpl VersionSBOMDaoImpl
+type VersionSBOMDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel 添加构建版本的 SBOM，同一事件重复生成时覆盖旧的记录
+func (c *VersionSBOMDaoImpl) AddModel(mo model.Interface) error {
+	result := mo.(*model.VersionSBOM)
+	var old model.VersionSBOM
+	if ok := c.DB.Where("event_id=?", result.EventID).Find(&old).RecordNotFound(); ok {
+		return c.DB.Create(result).Error
+	}
+	result.ID = old.ID
+	result.CreatedAt = old.CreatedAt
+	return c.DB.Save(result).Error
+}
+
+// UpdateModel UpdateModel
+func (c *VersionSBOMDaoImpl) UpdateModel(mo model.Interface) error {
+	result := mo.(*model.VersionSBOM)
+	return c.DB.Save(result).Error
+}
+
+// GetSBOMByEventID get sbom by build event id
+func (c *VersionSBOMDaoImpl) GetSBOMByEventID(eventID string) (*model.VersionSBOM, error) {
+	var result model.VersionSBOM
+	if err := c.DB.Where("event_id=?", eventID).Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteSBOMByEventID DeleteSBOMByEventID
+func (c *VersionSBOMDaoImpl) DeleteSBOMByEventID(eventID string) error {
+	return c.DB.Where("event_id = ?", eventID).Delete(&model.VersionSBOM{}).Error
+}
+
+// DeleteSBOMByServiceID DeleteSBOMByServiceID
+func (c *VersionSBOMDaoImpl) DeleteSBOMByServiceID(serviceID string) error {
+	return c.DB.Where("service_id = ?", serviceID).Delete(&model.VersionSBOM{}).Error
+}
@@ -353,6 +353,13 @@ func (m *Manager) VersionInfoDaoTransactions(db *gorm.DB) dao.VersionInfoDao {
 	}
 }
 
+// VersionSBOMDao VersionSBOMDao
+func (m *Manager) VersionSBOMDao() dao.VersionSBOMDao {
+	return &mysqldao.VersionSBOMDaoImpl{
+		DB: m.db,
+	}
+}
+
 // LocalSchedulerDao 本地调度信息
 func (m *Manager) LocalSchedulerDao() dao.LocalSchedulerDao {
 	return &mysqldao.LocalSchedulerDaoImpl{
@@ -395,6 +402,20 @@ func (m *Manager) NotificationEventDao() dao.NotificationEventDao {
 	}
 }
 
+// EventLogRecordDao EventLogRecordDao
+func (m *Manager) EventLogRecordDao() dao.EventLogRecordDao {
+	return &mysqldao.EventLogRecordDaoImpl{
+		DB: m.db,
+	}
+}
+
+// EventLogIndexDao EventLogIndexDao
+func (m *Manager) EventLogIndexDao() dao.EventLogIndexDao {
+	return &mysqldao.EventLogIndexDaoImpl{
+		DB: m.db,
+	}
+}
+
 // AppDao app export and import info
 func (m *Manager) AppDao() dao.AppDao {
 	return &mysqldao.AppDaoImpl{
@@ -633,6 +654,34 @@ func (m *Manager) TenantServceAutoscalerRuleMetricsDaoTransactions(db *gorm.DB)
 	}
 }
 
+// TenantServiceScheduledScalingDao -
+func (m *Manager) TenantServiceScheduledScalingDao() dao.TenantServiceScheduledScalingDao {
+	return &mysqldao.TenantServiceScheduledScalingDaoImpl{
+		DB: m.db,
+	}
+}
+
+// TenantServiceScheduledScalingDaoTransactions -
+func (m *Manager) TenantServiceScheduledScalingDaoTransactions(db *gorm.DB) dao.TenantServiceScheduledScalingDao {
+	return &mysqldao.TenantServiceScheduledScalingDaoImpl{
+		DB: db,
+	}
+}
+
+// TenantServiceIdlePolicyDao -
+func (m *Manager) TenantServiceIdlePolicyDao() dao.TenantServiceIdlePolicyDao {
+	return &mysqldao.TenantServiceIdlePolicyDaoImpl{
+		DB: m.db,
+	}
+}
+
+// TenantServiceIdlePolicyDaoTransactions -
+func (m *Manager) TenantServiceIdlePolicyDaoTransactions(db *gorm.DB) dao.TenantServiceIdlePolicyDao {
+	return &mysqldao.TenantServiceIdlePolicyDaoImpl{
+		DB: db,
+	}
+}
+
 // TenantServiceScalingRecordsDao -
 func (m *Manager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
 	return &mysqldao.TenantServiceScalingRecordsDaoImpl{
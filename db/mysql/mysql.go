@@ -204,12 +204,15 @@ func (m *Manager) RegisterTableModel() {
 	m.models = append(m.models, &model.CodeCheckResult{})
 	m.models = append(m.models, &model.ServiceEvent{})
 	m.models = append(m.models, &model.VersionInfo{})
+	m.models = append(m.models, &model.VersionSBOM{})
 	m.models = append(m.models, &model.RegionUserInfo{})
 	m.models = append(m.models, &model.TenantServicesStreamPluginPort{})
 	m.models = append(m.models, &model.RegionAPIClass{})
 	m.models = append(m.models, &model.RegionProcotols{})
 	m.models = append(m.models, &model.LocalScheduler{})
 	m.models = append(m.models, &model.NotificationEvent{})
+	m.models = append(m.models, &model.EventLogRecord{})
+	m.models = append(m.models, &model.EventLogIndex{})
 	m.models = append(m.models, &model.AppStatus{})
 	m.models = append(m.models, &model.AppBackup{})
 	m.models = append(m.models, &model.UploadSession{})
@@ -235,6 +238,8 @@ func (m *Manager) RegisterTableModel() {
 	m.models = append(m.models, &model.TenantServiceAutoscalerRules{})
 	m.models = append(m.models, &model.TenantServiceAutoscalerRuleMetrics{})
 	m.models = append(m.models, &model.TenantServiceScalingRecords{})
+	m.models = append(m.models, &model.TenantServiceScheduledScaling{})
+	m.models = append(m.models, &model.TenantServiceIdlePolicy{})
 	m.models = append(m.models, &model.TenantServiceMonitor{})
 	m.models = append(m.models, &model.ComponentK8sAttributes{})
 	m.models = append(m.models, &model.K8sResource{})
@@ -293,6 +298,9 @@ func (m *Manager) patchTable() {
 	if err := m.patchLanguageVersionUniqueIndex(); err != nil {
 		logrus.Errorf("patch enterprise_language_version unique index error: %s", err.Error())
 	}
+	if err := m.patchEventLogIndexFulltext(); err != nil {
+		logrus.Errorf("patch event_log_index fulltext index error: %s", err.Error())
+	}
 	m.db.Model(&model.EnterpriseLanguageVersion{}).Count(&count)
 	if count == 0 {
 		m.initLanguageVersion()
@@ -448,6 +456,22 @@ func (m *Manager) patchLanguageVersionUniqueIndex() error {
 	}
 }
 
+// patchEventLogIndexFulltext 为事件日志检索索引的消息列建立全文索引，使用 ngram 解析器以支持中文关键字
+func (m *Manager) patchEventLogIndexFulltext() error {
+	if m.config.DBType != "mysql" {
+		return nil
+	}
+	var count int
+	row := m.db.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?", "event_log_index", "idx_event_log_index_message").Row()
+	if err := row.Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return m.db.Exec("alter table event_log_index add fulltext index idx_event_log_index_message (message) with parser ngram;").Error
+}
+
 func applySeedLongVersionDefaults(versions []*model.EnterpriseLanguageVersion) {
 	for _, version := range versions {
 		if version == nil {
@@ -514,6 +538,16 @@ func cnbSeedLanguageVersions() []*model.EnterpriseLanguageVersion {
 		{Version: "8.2", Default: false},
 		{Version: "8.3", Default: true},
 	})...)
+	versions = append(versions, buildStrategySeedVersions("rust", model.LongVersionBuildStrategyCNB, []cnbSeedVersion{
+		{Version: "1.86", Default: false},
+		{Version: "1.88", Default: false},
+		{Version: "1.90", Default: true},
+	})...)
+	versions = append(versions, buildStrategySeedVersions("elixir", model.LongVersionBuildStrategyCNB, []cnbSeedVersion{
+		{Version: "1.16", Default: false},
+		{Version: "1.17", Default: false},
+		{Version: "1.18", Default: true},
+	})...)
 	return versions
 }
 
@@ -95,6 +95,8 @@ func TestCNBSeedVersionsUseCNBStrategy(t *testing.T) {
 	foundPHPDefault := false
 	foundPHPStable := false
 	foundUnsupportedPHP := false
+	foundRustDefault := false
+	foundElixirDefault := false
 	for _, version := range versions {
 		if version.BuildStrategy != model.LongVersionBuildStrategyCNB {
 			t.Fatalf("expected cnb build strategy for %s-%s, got %q", version.Lang, version.Version, version.BuildStrategy)
@@ -135,6 +137,12 @@ func TestCNBSeedVersionsUseCNBStrategy(t *testing.T) {
 		if version.Lang == "php" && (version.Version == "8.4" || version.Version == "8.5") {
 			foundUnsupportedPHP = true
 		}
+		if version.Lang == "rust" && version.Version == "1.90" && version.FirstChoice {
+			foundRustDefault = true
+		}
+		if version.Lang == "elixir" && version.Version == "1.18" && version.FirstChoice {
+			foundElixirDefault = true
+		}
 	}
 	if !foundJava {
 		t.Fatal("expected Java CNB seed version 17")
@@ -169,6 +177,12 @@ func TestCNBSeedVersionsUseCNBStrategy(t *testing.T) {
 	if foundUnsupportedPHP {
 		t.Fatal("expected unsupported PHP CNB seed versions 8.4/8.5 to be removed")
 	}
+	if !foundRustDefault {
+		t.Fatal("expected Rust CNB default seed version 1.90")
+	}
+	if !foundElixirDefault {
+		t.Fatal("expected Elixir CNB default seed version 1.18")
+	}
 }
 
 func TestLongVersionBackfillLegacyStrategy(t *testing.T) {
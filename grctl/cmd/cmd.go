@@ -38,6 +38,7 @@ func GetCmds() []cli.Command {
 	cmds = append(cmds, NewCmdReplace())
 	cmds = append(cmds, NewCmdMigrateConsole())
 	cmds = append(cmds, NewCmdGPUShare())
+	cmds = append(cmds, NewCmdMQ())
 	return cmds
 }
 
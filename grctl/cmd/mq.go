@@ -0,0 +1,178 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/goodrain/rainbond/mq/client"
+	utils "github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/util/termtables"
+	"github.com/urfave/cli"
+)
+
+var mqFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "mq-api",
+		Usage: "rbd-mq grpc api address",
+		Value: utils.GetenvDefault("MQ_API", "rbd-mq:6300"),
+	},
+	cli.StringFlag{
+		Name:  "topic,t",
+		Usage: "the topic of dead letters, e.g. builder or worker",
+		Value: client.BuilderTopic,
+	},
+}
+
+// NewCmdMQ mq manage cmd
+func NewCmdMQ() cli.Command {
+	c := cli.Command{
+		Name:  "mq",
+		Usage: "message queue manage cmd",
+		Subcommands: []cli.Command{
+			cli.Command{
+				Name:  "dlq",
+				Usage: "manage tasks that run out of retry attempts",
+				Subcommands: []cli.Command{
+					cli.Command{
+						Name:  "list",
+						Usage: "list dead letters of the topic",
+						Flags: mqFlags,
+						Action: func(c *cli.Context) error {
+							return listDeadLetters(c)
+						},
+					},
+					cli.Command{
+						Name:      "inspect",
+						Usage:     "show the dead letter detail",
+						ArgsUsage: "<message id>",
+						Flags:     mqFlags,
+						Action: func(c *cli.Context) error {
+							return inspectDeadLetter(c)
+						},
+					},
+					cli.Command{
+						Name:      "replay",
+						Usage:     "redeliver dead letters to the origin topic",
+						ArgsUsage: "<message id>...",
+						Flags:     append(mqFlags, cli.BoolFlag{Name: "all", Usage: "replay all dead letters of the topic"}),
+						Action: func(c *cli.Context) error {
+							return changeDeadLetters(c, "replay")
+						},
+					},
+					cli.Command{
+						Name:      "purge",
+						Usage:     "delete dead letters of the topic",
+						ArgsUsage: "<message id>...",
+						Flags:     append(mqFlags, cli.BoolFlag{Name: "all", Usage: "delete all dead letters of the topic"}),
+						Action: func(c *cli.Context) error {
+							return changeDeadLetters(c, "purge")
+						},
+					},
+				},
+			},
+		},
+	}
+	return c
+}
+
+func newMQClient(c *cli.Context) client.MQClient {
+	mqClient, err := client.NewMqClient(c.String("mq-api"))
+	if err != nil {
+		showError(fmt.Sprintf("create mq client failure %s", err.Error()))
+	}
+	return mqClient
+}
+
+func listDeadLetters(c *cli.Context) error {
+	mqClient := newMQClient(c)
+	defer mqClient.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := mqClient.ListDeadLetters(ctx, &pb.DeadLetterRequest{Topic: c.String("topic")})
+	if err != nil {
+		showError(err.Error())
+	}
+	table := termtables.CreateTable()
+	table.AddHeaders("MessageID", "TaskType", "TaskID", "Attempts", "DeadTime", "LastError")
+	for _, dl := range reply.DeadLetters {
+		table.AddRow(dl.MessageId, dl.GetMessage().GetTaskType(), dl.GetMessage().GetTaskId(), dl.Attempts, dl.DeadTime, dl.LastError)
+	}
+	fmt.Println(table.Render())
+	return nil
+}
+
+func inspectDeadLetter(c *cli.Context) error {
+	messageID := c.Args().First()
+	if messageID == "" {
+		showError("Please specify the dead letter message id")
+	}
+	mqClient := newMQClient(c)
+	defer mqClient.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := mqClient.ListDeadLetters(ctx, &pb.DeadLetterRequest{Topic: c.String("topic"), MessageIds: []string{messageID}})
+	if err != nil {
+		showError(err.Error())
+	}
+	if len(reply.DeadLetters) == 0 {
+		showError(fmt.Sprintf("dead letter %s not found in topic %s", messageID, c.String("topic")))
+	}
+	dl := reply.DeadLetters[0]
+	table := termtables.CreateTable()
+	table.AddRow("MessageID:", dl.MessageId)
+	table.AddRow("Topic:", dl.Topic)
+	table.AddRow("TaskID:", dl.GetMessage().GetTaskId())
+	table.AddRow("TaskType:", dl.GetMessage().GetTaskType())
+	table.AddRow("User:", dl.GetMessage().GetUser())
+	table.AddRow("CreateTime:", dl.GetMessage().GetCreateTime())
+	table.AddRow("Attempts:", dl.Attempts)
+	table.AddRow("DeadTime:", dl.DeadTime)
+	table.AddRow("LastError:", dl.LastError)
+	fmt.Println(table.Render())
+	fmt.Println(string(dl.GetMessage().GetTaskBody()))
+	return nil
+}
+
+func changeDeadLetters(c *cli.Context, action string) error {
+	messageIDs := []string(c.Args())
+	if len(messageIDs) == 0 && !c.Bool("all") {
+		showError("Please specify the dead letter message ids or use --all")
+	}
+	mqClient := newMQClient(c)
+	defer mqClient.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	request := &pb.DeadLetterRequest{Topic: c.String("topic"), MessageIds: messageIDs}
+	var reply *pb.TaskReply
+	var err error
+	if action == "replay" {
+		reply, err = mqClient.ReplayDeadLetters(ctx, request)
+	} else {
+		reply, err = mqClient.PurgeDeadLetters(ctx, request)
+	}
+	if err != nil {
+		showError(err.Error())
+	}
+	fmt.Printf("%s %d dead letters of topic %s\n", action, reply.Count, c.String("topic"))
+	return nil
+}
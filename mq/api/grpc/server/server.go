@@ -20,6 +20,7 @@ package server
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/goodrain/rainbond/util"
 
@@ -35,7 +36,8 @@ import (
 )
 
 type mqServer struct {
-	actionMQ mq.ActionMQ
+	actionMQ      mq.ActionMQ
+	subscriptions *subscriptionManager
 }
 
 func (s *mqServer) Enqueue(ctx context.Context, in *pb.EnqueueRequest) (*pb.TaskReply, error) {
@@ -51,7 +53,12 @@ func (s *mqServer) Enqueue(ctx context.Context, in *pb.EnqueueRequest) (*pb.Task
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	err = s.actionMQ.Enqueue(ctx, in.Topic, string(message))
+	err = s.actionMQ.EnqueueWithSchedule(ctx, in.Topic, string(message), mq.Schedule{
+		Priority: int(in.Message.Priority),
+		TenantID: in.Message.TenantId,
+		Weight:   int(in.TenantWeight),
+		Quota:    int(in.TenantQuota),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -73,20 +80,203 @@ func (s *mqServer) Dequeue(ctx context.Context, in *pb.DequeueRequest) (*pb.Task
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	message, err := s.actionMQ.Dequeue(ctx, in.Topic)
+	var task pb.TaskMessage
+	if in.ManualAck {
+		message, err := s.actionMQ.Receive(ctx, in.Topic, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := proto.Unmarshal([]byte(message.Body), &task); err != nil {
+			// 无法解析的消息永远不会被正确处理，直接确认丢弃
+			s.actionMQ.Ack(ctx, in.Topic, message.ID)
+			return nil, err
+		}
+		task.MessageId = message.ID
+		task.Attempts = int32(message.Attempts)
+	} else {
+		message, err := s.actionMQ.Dequeue(ctx, in.Topic)
+		if err != nil {
+			return nil, err
+		}
+		if err := proto.Unmarshal([]byte(message), &task); err != nil {
+			return nil, err
+		}
+	}
+	logrus.Debugf("task (%s) dnqueue by (%s).", task.GetTaskType(), in.ClientHost)
+	return &task, nil
+}
+
+func (s *mqServer) Ack(ctx context.Context, in *pb.AckRequest) (*pb.TaskReply, error) {
+	if in.MessageId == "" {
+		return nil, fmt.Errorf("message id can not be empty")
+	}
+	defer s.subscriptions.done(in.MessageId)
+	if err := s.actionMQ.Ack(ctx, in.Topic, in.MessageId); err != nil {
+		return nil, err
+	}
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+func (s *mqServer) Nack(ctx context.Context, in *pb.AckRequest) (*pb.TaskReply, error) {
+	if in.MessageId == "" {
+		return nil, fmt.Errorf("message id can not be empty")
+	}
+	defer s.subscriptions.done(in.MessageId)
+	if in.DeadLetter {
+		if err := s.actionMQ.Reject(ctx, in.Topic, in.MessageId, in.Reason); err != nil {
+			return nil, err
+		}
+		logrus.Debugf("message (%s) of topic (%s) is rejected to dead letter topic.", in.MessageId, in.Topic)
+		return &pb.TaskReply{
+			Status: "success",
+		}, nil
+	}
+	if err := s.actionMQ.Nack(ctx, in.Topic, in.MessageId, in.Reason, in.Requeue); err != nil {
+		return nil, err
+	}
+	logrus.Debugf("message (%s) of topic (%s) is nacked.", in.MessageId, in.Topic)
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+// Subscribe 作为消费组的成员订阅主题，在订阅者还有额度时持续推送消息。
+// 每个消费组都会收到主题中的每条消息，同一消费组的成员共享该组的消息，每条消息只会推送给其中一个成员。
+func (s *mqServer) Subscribe(in *pb.SubscribeRequest, stream pb.TaskQueue_SubscribeServer) error {
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	sub := s.subscriptions.join(in)
+	defer s.unsubscribe(sub)
+	ctx := stream.Context()
+	// 重新认领连接断开前推送但尚未确认的消息，其他订阅者的消息不能被认领
+	for _, id := range in.ResumeMessageIds {
+		if !s.subscriptions.resumable(sub, id) {
+			logrus.Warningf("message (%s) of topic (%s) is not held by (%s), ignore it", id, in.Topic, in.ClientHost)
+			continue
+		}
+		if !sub.claim() {
+			logrus.Warningf("(%s) has no credits to resume message (%s) of topic (%s)", in.ClientHost, id, in.Topic)
+			continue
+		}
+		if err := s.actionMQ.Touch(ctx, in.Topic, id, 0); err != nil {
+			logrus.Warningf("message (%s) of topic (%s) can not be resumed by (%s): %s", id, in.Topic, in.ClientHost, err.Error())
+			sub.release()
+			continue
+		}
+		s.subscriptions.track(sub, id)
+	}
+	logrus.Infof("(%s) subscribe topic (%s) in consumer group (%s)", in.ClientHost, in.Topic, sub.group)
+	for {
+		if err := sub.acquire(ctx); err != nil {
+			return nil
+		}
+		message, err := s.actionMQ.Receive(ctx, in.Topic, sub.group)
+		if err != nil {
+			sub.release()
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err == context.DeadlineExceeded {
+				continue
+			}
+			return err
+		}
+		var task pb.TaskMessage
+		if err := proto.Unmarshal([]byte(message.Body), &task); err != nil {
+			// 无法解析的消息永远不会被正确处理，直接确认丢弃
+			s.actionMQ.Ack(ctx, in.Topic, message.ID)
+			sub.release()
+			continue
+		}
+		task.MessageId = message.ID
+		task.Attempts = int32(message.Attempts)
+		s.subscriptions.track(sub, message.ID)
+		if err := stream.Send(&task); err != nil {
+			// 消息没有送达，立即退回队列
+			s.subscriptions.done(message.ID)
+			s.actionMQ.Nack(context.Background(), in.Topic, message.ID, "", true)
+			return err
+		}
+		logrus.Debugf("task (%s) is pushed to (%s).", task.GetTaskType(), in.ClientHost)
+	}
+}
+
+// unsubscribe 订阅者退出，其未确认的消息在 releaseGrace 内没有被重新认领则重新投递
+func (s *mqServer) unsubscribe(sub *subscription) {
+	ids := s.subscriptions.leave(sub)
+	for _, id := range ids {
+		s.actionMQ.Touch(context.Background(), sub.topic, id, releaseGrace)
+	}
+	logrus.Infof("(%s) unsubscribe topic (%s), %d unacked messages are released", sub.host, sub.topic, len(ids))
+}
+
+func (s *mqServer) ListDeadLetters(ctx context.Context, in *pb.DeadLetterRequest) (*pb.DeadLetterReply, error) {
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return nil, fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	messages, err := s.actionMQ.DeadLetters(ctx, in.Topic)
 	if err != nil {
 		return nil, err
 	}
-	var task pb.TaskMessage
-	err = proto.Unmarshal([]byte(message), &task)
+	selected := make(map[string]bool, len(in.MessageIds))
+	for _, id := range in.MessageIds {
+		selected[id] = true
+	}
+	var reply pb.DeadLetterReply
+	for _, message := range messages {
+		if len(selected) > 0 && !selected[message.ID] {
+			continue
+		}
+		var task pb.TaskMessage
+		if err := proto.Unmarshal([]byte(message.Body), &task); err != nil {
+			logrus.Warningf("dead letter %s of topic %s can not be decoded: %s", message.ID, in.Topic, err.Error())
+		}
+		reply.DeadLetters = append(reply.DeadLetters, &pb.DeadLetter{
+			MessageId: message.ID,
+			Topic:     message.OriginTopic,
+			Message:   &task,
+			Attempts:  int32(message.Attempts),
+			LastError: message.LastError,
+			DeadTime:  message.DeadTime.Format(time.RFC3339),
+		})
+	}
+	return &reply, nil
+}
+
+func (s *mqServer) ReplayDeadLetters(ctx context.Context, in *pb.DeadLetterRequest) (*pb.TaskReply, error) {
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return nil, fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	count, err := s.actionMQ.ReplayDeadLetters(ctx, in.Topic, in.MessageIds)
 	if err != nil {
 		return nil, err
 	}
-	logrus.Debugf("task (%s) dnqueue by (%s).", task.GetTaskType(), in.ClientHost)
-	return &task, nil
+	logrus.Infof("replay %d dead letters of topic (%s).", count, in.Topic)
+	return &pb.TaskReply{
+		Status: "success",
+		Count:  int32(count),
+	}, nil
+}
+
+func (s *mqServer) PurgeDeadLetters(ctx context.Context, in *pb.DeadLetterRequest) (*pb.TaskReply, error) {
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return nil, fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	count, err := s.actionMQ.PurgeDeadLetters(ctx, in.Topic, in.MessageIds)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("purge %d dead letters of topic (%s).", count, in.Topic)
+	return &pb.TaskReply{
+		Status: "success",
+		Count:  int32(count),
+	}, nil
 }
 
 //RegisterServer 注册服务
 func RegisterServer(server *grpc1.Server, actionMQ mq.ActionMQ) {
-	pb.RegisterTaskQueueServer(server, &mqServer{actionMQ})
+	pb.RegisterTaskQueueServer(server, &mqServer{actionMQ: actionMQ, subscriptions: newSubscriptionManager()})
 }
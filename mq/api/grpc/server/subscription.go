@@ -0,0 +1,200 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	context "golang.org/x/net/context"
+)
+
+// defaultConsumerGroup 订阅时没有指定消费组
+const defaultConsumerGroup = "default"
+
+// maxCredits 单个订阅者最多可以持有的未确认消息数
+const maxCredits = 1000
+
+// releaseGrace 订阅断开后，其未确认的消息在该时间内没有被重新认领则重新投递
+var releaseGrace = 30 * time.Second
+
+// subscription 消费组中的一个订阅者，按额度接收消息，每确认一条消息归还一个额度。
+// 每个消费组都会收到主题中的每条消息，同一消费组的订阅者共享该组的消息。
+type subscription struct {
+	topic, group, host string
+	mu                 sync.Mutex
+	credits            int
+	wake               chan struct{}
+	outstanding        map[string]struct{}
+}
+
+// acquire 占用一个额度，没有额度时等待消息被确认
+func (s *subscription) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.credits > 0 {
+			s.credits--
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// claim 重新认领断线前推送的消息，直接占用额度，没有额度时不能认领
+func (s *subscription) claim() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.credits <= 0 {
+		return false
+	}
+	s.credits--
+	return true
+}
+
+// same 判断是否为同一主题、同一消费组的同一订阅者
+func (s *subscription) same(other *subscription) bool {
+	return s.topic == other.topic && s.group == other.group && s.host == other.host
+}
+
+// release 归还一个额度
+func (s *subscription) release() {
+	s.mu.Lock()
+	s.credits++
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// releasedDelivery 订阅断开时尚未确认的消息，在 deadline 前可以被同一订阅者重新认领
+type releasedDelivery struct {
+	sub      *subscription
+	deadline time.Time
+}
+
+// subscriptionManager 管理已经推送给订阅者的消息
+type subscriptionManager struct {
+	mu         sync.Mutex
+	deliveries map[string]*subscription
+	released   map[string]releasedDelivery
+}
+
+func newSubscriptionManager() *subscriptionManager {
+	return &subscriptionManager{
+		deliveries: make(map[string]*subscription),
+		released:   make(map[string]releasedDelivery),
+	}
+}
+
+// join 加入主题的消费组
+func (m *subscriptionManager) join(in *pb.SubscribeRequest) *subscription {
+	group := in.ConsumerGroup
+	if group == "" {
+		group = defaultConsumerGroup
+	}
+	credits := int(in.Credits)
+	if credits <= 0 {
+		credits = 1
+	}
+	if credits > maxCredits {
+		credits = maxCredits
+	}
+	return &subscription{
+		topic:       in.Topic,
+		group:       group,
+		host:        in.ClientHost,
+		credits:     credits,
+		wake:        make(chan struct{}, 1),
+		outstanding: make(map[string]struct{}),
+	}
+}
+
+// resumable 判断消息是否可以被订阅者重新认领，只有推送给同一主题、同一消费组的同一订阅者的消息才能认领
+func (m *subscriptionManager) resumable(sub *subscription, id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if holder, ok := m.deliveries[id]; ok {
+		return holder.same(sub)
+	}
+	released, ok := m.released[id]
+	return ok && time.Now().Before(released.deadline) && released.sub.same(sub)
+}
+
+// leave 订阅者退出消费组，返回其尚未确认且没有被其他订阅者认领的消息
+func (m *subscriptionManager) leave(sub *subscription) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, released := range m.released {
+		if !now.Before(released.deadline) {
+			delete(m.released, id)
+		}
+	}
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	var ids []string
+	for id := range sub.outstanding {
+		// 已经被重新连接的订阅者认领的消息不再释放
+		if m.deliveries[id] == sub {
+			delete(m.deliveries, id)
+			m.released[id] = releasedDelivery{sub: sub, deadline: now.Add(releaseGrace)}
+			ids = append(ids, id)
+		}
+	}
+	sub.outstanding = make(map[string]struct{})
+	return ids
+}
+
+// track 记录消息已经推送给订阅者
+func (m *subscriptionManager) track(sub *subscription, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[id] = sub
+	delete(m.released, id)
+	sub.mu.Lock()
+	sub.outstanding[id] = struct{}{}
+	sub.mu.Unlock()
+}
+
+// done 消息已经被确认或拒绝，归还订阅者的额度
+func (m *subscriptionManager) done(id string) {
+	m.mu.Lock()
+	sub, ok := m.deliveries[id]
+	delete(m.deliveries, id)
+	delete(m.released, id)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	sub.mu.Lock()
+	_, held := sub.outstanding[id]
+	delete(sub.outstanding, id)
+	sub.mu.Unlock()
+	if held {
+		sub.release()
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/goodrain/rainbond/mq/api/mq"
+	"github.com/goodrain/rainbond/mq/client"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+type fakeSubscribeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.TaskMessage
+}
+
+func (f *fakeSubscribeStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeSubscribeStream) Send(task *pb.TaskMessage) error {
+	f.sent <- task
+	return nil
+}
+
+type testSubscriber struct {
+	stream *fakeSubscribeStream
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func newTestServer(t *testing.T) *mqServer {
+	t.Helper()
+	configs.Default().MQConfig.StorageMode = "memory"
+	actionMQ := mq.NewActionMQ(context.Background())
+	if err := actionMQ.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { actionMQ.Stop() })
+	return &mqServer{actionMQ: actionMQ, subscriptions: newSubscriptionManager()}
+}
+
+func subscribe(s *mqServer, in *pb.SubscribeRequest) *testSubscriber {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &testSubscriber{
+		stream: &fakeSubscribeStream{ctx: ctx, sent: make(chan *pb.TaskMessage, 10)},
+		cancel: cancel,
+		done:   make(chan error, 1),
+	}
+	go func() {
+		sub.done <- s.Subscribe(in, sub.stream)
+	}()
+	return sub
+}
+
+func (sub *testSubscriber) receive(t *testing.T) *pb.TaskMessage {
+	t.Helper()
+	select {
+	case task := <-sub.stream.sent:
+		return task
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message is pushed to the subscriber")
+	}
+	return nil
+}
+
+func (sub *testSubscriber) expectNothing(t *testing.T) {
+	t.Helper()
+	select {
+	case task := <-sub.stream.sent:
+		t.Fatalf("subscriber without credits received %s", task.TaskId)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (sub *testSubscriber) close(t *testing.T) {
+	t.Helper()
+	sub.cancel()
+	select {
+	case <-sub.done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("subscription should stop after the stream is closed")
+	}
+}
+
+func enqueue(t *testing.T, s *mqServer, topic, taskID string) {
+	t.Helper()
+	if _, err := s.Enqueue(context.Background(), &pb.EnqueueRequest{Topic: topic, Message: &pb.TaskMessage{TaskId: taskID}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestSubscribeConsumerGroupSharesTopic(t *testing.T) {
+	s := newTestServer(t)
+	first := subscribe(s, &pb.SubscribeRequest{Topic: client.BuilderTopic, ConsumerGroup: client.BuilderConsumerGroup, ClientHost: "builder-0", Credits: 1})
+	defer first.close(t)
+	second := subscribe(s, &pb.SubscribeRequest{Topic: client.BuilderTopic, ConsumerGroup: client.BuilderConsumerGroup, ClientHost: "builder-1", Credits: 1})
+	defer second.close(t)
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		enqueue(t, s, client.BuilderTopic, id)
+	}
+
+	a, b := first.receive(t), second.receive(t)
+	if a.TaskId == b.TaskId {
+		t.Fatalf("task %s is pushed to both members of the consumer group", a.TaskId)
+	}
+	first.expectNothing(t)
+	second.expectNothing(t)
+
+	if _, err := s.Ack(context.Background(), &pb.AckRequest{Topic: client.BuilderTopic, MessageId: a.MessageId}); err != nil {
+		t.Fatal(err)
+	}
+	c := first.receive(t)
+	if c.TaskId == a.TaskId || c.TaskId == b.TaskId {
+		t.Fatalf("task %s is pushed twice", c.TaskId)
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestSubscribeDeliversToEveryConsumerGroup(t *testing.T) {
+	s := newTestServer(t)
+	worker := subscribe(s, &pb.SubscribeRequest{Topic: client.WorkerTopic, ConsumerGroup: client.WorkerConsumerGroup, Credits: 2})
+	defer worker.close(t)
+	audit := subscribe(s, &pb.SubscribeRequest{Topic: client.WorkerTopic, ConsumerGroup: "audit", Credits: 2})
+	defer audit.close(t)
+	// 等待两个消费组都完成订阅，之后的消息会复制给每个消费组
+	time.Sleep(200 * time.Millisecond)
+	enqueue(t, s, client.WorkerTopic, "task-1")
+	enqueue(t, s, client.WorkerTopic, "task-2")
+
+	for _, sub := range []*testSubscriber{worker, audit} {
+		received := map[string]string{}
+		for i := 0; i < 2; i++ {
+			task := sub.receive(t)
+			received[task.TaskId] = task.MessageId
+		}
+		if received["task-1"] == "" || received["task-2"] == "" {
+			t.Fatalf("every consumer group should receive every message, got %v", received)
+		}
+		sub.expectNothing(t)
+		for _, id := range received {
+			if _, err := s.Ack(context.Background(), &pb.AckRequest{Topic: client.WorkerTopic, MessageId: id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestSubscribeRejectsForeignResume(t *testing.T) {
+	s := newTestServer(t)
+	enqueue(t, s, client.SourceScanTopic, "task-1")
+	owner := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", ClientHost: "scanner-0", Credits: 1})
+	defer owner.close(t)
+	held := owner.receive(t)
+
+	// 其他订阅者不能认领不属于自己的消息，额度也不会被占用
+	other := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", ClientHost: "scanner-1", Credits: 1, ResumeMessageIds: []string{held.MessageId, "unknown"}})
+	defer other.close(t)
+	enqueue(t, s, client.SourceScanTopic, "task-2")
+	if task := other.receive(t); task.TaskId != "task-2" {
+		t.Fatalf("unexpected task %s", task.TaskId)
+	}
+	s.subscriptions.mu.Lock()
+	holder := s.subscriptions.deliveries[held.MessageId]
+	s.subscriptions.mu.Unlock()
+	if holder == nil || holder.host != "scanner-0" {
+		t.Fatal("message should still be held by its subscriber")
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestSubscriptionClaimKeepsCreditsNonNegative(t *testing.T) {
+	sub := newSubscriptionManager().join(&pb.SubscribeRequest{Topic: client.BuilderTopic, Credits: 1})
+	if !sub.claim() {
+		t.Fatal("subscriber with credits should claim a message")
+	}
+	if sub.claim() {
+		t.Fatal("subscriber without credits should not claim more messages")
+	}
+	if sub.credits != 0 {
+		t.Fatalf("credits = %d, want 0", sub.credits)
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestSubscribeResumeUnackedMessages(t *testing.T) {
+	old := releaseGrace
+	releaseGrace = 50 * time.Millisecond
+	defer func() { releaseGrace = old }()
+	s := newTestServer(t)
+
+	// 重新连接的订阅者认领了未确认的消息，消息不会被重新投递，额度也被占用
+	enqueue(t, s, client.SourceScanTopic, "task-1")
+	broken := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", Credits: 1})
+	held := broken.receive(t)
+	broken.close(t)
+	resumed := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", Credits: 1, ResumeMessageIds: []string{held.MessageId}})
+	enqueue(t, s, client.SourceScanTopic, "task-2")
+	time.Sleep(1500 * time.Millisecond)
+	resumed.expectNothing(t)
+	if _, err := s.Ack(context.Background(), &pb.AckRequest{Topic: client.SourceScanTopic, MessageId: held.MessageId}); err != nil {
+		t.Fatalf("resumed message should still be acked: %v", err)
+	}
+	task := resumed.receive(t)
+	if task.TaskId != "task-2" {
+		t.Fatalf("unexpected task %s", task.TaskId)
+	}
+	if _, err := s.Ack(context.Background(), &pb.AckRequest{Topic: client.SourceScanTopic, MessageId: task.MessageId}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有被认领的消息在 releaseGrace 后重新投递给消费组的其他成员
+	resumed.close(t)
+	enqueue(t, s, client.SourceScanTopic, "task-3")
+	crashed := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", Credits: 2})
+	lost := crashed.receive(t)
+	crashed.close(t)
+	other := subscribe(s, &pb.SubscribeRequest{Topic: client.SourceScanTopic, ConsumerGroup: "scanner", Credits: 2})
+	defer other.close(t)
+	redelivered := other.receive(t)
+	if redelivered.MessageId != lost.MessageId {
+		t.Fatalf("redelivered %s, want %s", redelivered.MessageId, lost.MessageId)
+	}
+}
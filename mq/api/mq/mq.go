@@ -19,12 +19,14 @@
 package mq
 
 import (
+	"fmt"
 	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/config/configs/rbdcomponent"
 	"github.com/goodrain/rainbond/mq/client"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -34,7 +36,25 @@ import (
 // ActionMQ 队列操作
 type ActionMQ interface {
 	Enqueue(context.Context, string, string) error
+	// EnqueueWithSchedule 按优先级与租户调度属性入队
+	EnqueueWithSchedule(ctx context.Context, topic, value string, schedule Schedule) error
 	Dequeue(context.Context, string) (string, error)
+	// Receive 以消费组的身份取出一条消息但不删除，消息需要通过 Ack 确认，超过可见性超时未确认会重新投递。
+	// 默认消费组和每个消费组都会收到主题中的每条消息，group 为空时从默认消费组中取消息。
+	Receive(ctx context.Context, topic, group string) (*Message, error)
+	Ack(ctx context.Context, topic, id string) error
+	// Nack 拒绝消息，requeue 为 false 时按主题重试策略退避重试，用尽后进入死信主题
+	Nack(ctx context.Context, topic, id, reason string, requeue bool) error
+	// Reject 拒绝消息且不再重试，消息直接进入死信主题
+	Reject(ctx context.Context, topic, id, reason string) error
+	// Touch 重新设置未确认消息的可见性超时，timeout 小于等于 0 时使用主题的可见性超时
+	Touch(ctx context.Context, topic, id string, timeout time.Duration) error
+	// DeadLetters 返回主题的死信消息
+	DeadLetters(ctx context.Context, topic string) ([]*Message, error)
+	// ReplayDeadLetters 将死信消息重新投递到原主题，ids 为空时重放全部
+	ReplayDeadLetters(ctx context.Context, topic string, ids []string) (int, error)
+	// PurgeDeadLetters 删除死信消息，ids 为空时删除全部
+	PurgeDeadLetters(ctx context.Context, topic string, ids []string) (int, error)
 	TopicIsExist(string) bool
 	GetAllTopics() []string
 	Start() error
@@ -59,9 +79,10 @@ func NewActionMQ(ctx context.Context) ActionMQ {
 }
 
 type etcdQueue struct {
-	client     *KeyValueStore
+	client     *messageStore
 	mqConfig   *rbdcomponent.MQConfig
 	ctx        context.Context
+	cancel     context.CancelFunc
 	queues     map[string]string
 	queuesLock sync.Mutex
 }
@@ -69,7 +90,40 @@ type etcdQueue struct {
 func (e *etcdQueue) Start() error {
 	logrus.Debug("etcd message queue client starting")
 
-	e.client = NewKeyValueStore()
+	visibilityTimeout := time.Duration(e.mqConfig.VisibilityTimeout) * time.Second
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = 5 * time.Minute
+	}
+	policies := DefaultRetryPolicies()
+	if e.mqConfig.RetryPolicies != "" {
+		custom, err := ParseRetryPolicies(e.mqConfig.RetryPolicies)
+		if err != nil {
+			return err
+		}
+		for topic, policy := range custom {
+			policies[topic] = policy
+		}
+	}
+	switch e.mqConfig.StorageMode {
+	case "memory":
+		e.client = newMessageStore(nil, visibilityTimeout, policies)
+	case "wal", "":
+		j, err := openJournal(e.mqConfig.DataDir)
+		if err != nil {
+			return err
+		}
+		e.client = newMessageStore(j, visibilityTimeout, policies)
+		recovered, err := e.client.Recover()
+		if err != nil {
+			return fmt.Errorf("recover message from wal failure: %s", err.Error())
+		}
+		logrus.Infof("recovered %d pending messages from %s", recovered, e.mqConfig.DataDir)
+	default:
+		return fmt.Errorf("mq storage mode %s is not support", e.mqConfig.StorageMode)
+	}
+	e.client.groupTTL = time.Duration(e.mqConfig.ConsumerGroupTTL) * time.Second
+	e.ctx, e.cancel = context.WithCancel(e.ctx)
+	go e.maintain()
 	topics := os.Getenv("topics")
 	if topics != "" {
 		ts := strings.Split(topics, ",")
@@ -87,11 +141,36 @@ func (e *etcdQueue) Start() error {
 	return nil
 }
 
-// registerTopic 注册消息队列主题
+// maintain 周期性地重新投递超时未确认的消息、移除空闲的消费组并压缩预写日志
+func (e *etcdQueue) maintain() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if count := e.client.RequeueExpired(now); count > 0 {
+				logrus.Warningf("%d messages are not acked before visibility timeout, redeliver them", count)
+			}
+			if count := e.client.ExpireGroups(now); count > 0 {
+				logrus.Warningf("%d idle consumer groups are removed", count)
+			}
+			if err := e.client.Compact(); err != nil {
+				logrus.Errorf("compact mq wal failure %s", err.Error())
+			}
+		}
+	}
+}
+
+// registerTopic 注册消息队列主题，同时注册其死信主题
 func (e *etcdQueue) registerTopic(topic string) {
 	e.queuesLock.Lock()
 	defer e.queuesLock.Unlock()
 	e.queues[topic] = topic
+	if !IsDeadLetterTopic(topic) {
+		e.queues[DeadLetterTopic(topic)] = DeadLetterTopic(topic)
+	}
 }
 
 func (e *etcdQueue) TopicIsExist(topic string) bool {
@@ -101,6 +180,8 @@ func (e *etcdQueue) TopicIsExist(topic string) bool {
 	return ok
 }
 func (e *etcdQueue) GetAllTopics() []string {
+	e.queuesLock.Lock()
+	defer e.queuesLock.Unlock()
 	var topics []string
 	for k := range e.queues {
 		topics = append(topics, k)
@@ -109,25 +190,65 @@ func (e *etcdQueue) GetAllTopics() []string {
 }
 
 func (e *etcdQueue) Stop() error {
-
+	if e.cancel != nil {
+		e.cancel()
+	}
+	if e.client != nil {
+		return e.client.Close()
+	}
 	return nil
 }
-func (e *etcdQueue) queueKey(topic string) string {
-	return e.mqConfig.KeyPrefix + "/" + topic
-}
+
 func (e *etcdQueue) Enqueue(ctx context.Context, topic, value string) error {
+	return e.EnqueueWithSchedule(ctx, topic, value, Schedule{})
+}
+
+func (e *etcdQueue) EnqueueWithSchedule(ctx context.Context, topic, value string, schedule Schedule) error {
 	EnqueueNumber++
-	e.client.Put(e.queueKey(topic), value)
-	return nil
+	_, err := e.client.PutWithSchedule(topic, value, schedule)
+	return err
 }
 
 func (e *etcdQueue) Dequeue(ctx context.Context, topic string) (string, error) {
 	DequeueNumber++
-	res, ok := e.client.Get(e.queueKey(topic))
-	if !ok {
-		return "", context.DeadlineExceeded
+	msg, err := e.client.Take(ctx, topic, true)
+	if err != nil {
+		return "", err
 	}
-	return res, nil
+	return msg.Body, nil
+}
+
+func (e *etcdQueue) Receive(ctx context.Context, topic, group string) (*Message, error) {
+	DequeueNumber++
+	return e.client.TakeGroup(ctx, topic, group, false)
+}
+
+func (e *etcdQueue) Ack(ctx context.Context, topic, id string) error {
+	return e.client.Ack(id)
+}
+
+func (e *etcdQueue) Nack(ctx context.Context, topic, id, reason string, requeue bool) error {
+	return e.client.Nack(id, reason, requeue)
+}
+
+func (e *etcdQueue) Reject(ctx context.Context, topic, id, reason string) error {
+	return e.client.Reject(id, reason)
+}
+
+func (e *etcdQueue) Touch(ctx context.Context, topic, id string, timeout time.Duration) error {
+	return e.client.Touch(id, timeout)
+}
+
+func (e *etcdQueue) DeadLetters(ctx context.Context, topic string) ([]*Message, error) {
+	return e.client.DeadLetters(topic), nil
+}
+
+func (e *etcdQueue) ReplayDeadLetters(ctx context.Context, topic string, ids []string) (int, error) {
+	return e.client.ReplayDeadLetters(topic, ids)
+}
+
+func (e *etcdQueue) PurgeDeadLetters(ctx context.Context, topic string, ids []string) (int, error) {
+	return e.client.PurgeDeadLetters(topic, ids)
 }
 
 func (e *etcdQueue) MessageQueueSize(topic string) int64 {
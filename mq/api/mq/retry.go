@@ -0,0 +1,139 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/mq/client"
+)
+
+// DeadLetterSuffix 死信主题后缀，超过最大投递次数的消息会被移动到 <topic>.dlq
+const DeadLetterSuffix = ".dlq"
+
+// DeadLetterTopic 返回主题对应的死信主题
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// IsDeadLetterTopic 判断主题是否为死信主题
+func IsDeadLetterTopic(topic string) bool {
+	return strings.HasSuffix(topic, DeadLetterSuffix)
+}
+
+// RetryPolicy 主题的重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大投递次数，达到后消息进入死信主题，0 表示不限制
+	MaxAttempts int
+	// InitialBackoff 第一次失败后重新投递的等待时间，之后每次翻倍
+	InitialBackoff time.Duration
+	// MaxBackoff 重新投递等待时间的上限
+	MaxBackoff time.Duration
+	// VisibilityTimeout 投递后未确认的消息重新投递前的超时时间，为 0 时使用全局配置
+	VisibilityTimeout time.Duration
+}
+
+// Backoff 返回第 attempts 次投递失败后需要等待的时间
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	if p.InitialBackoff <= 0 || attempts <= 0 {
+		return 0
+	}
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// Exhausted 消息投递 attempts 次后是否已经用尽重试次数
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// DefaultRetryPolicies 内置主题的默认重试策略。
+// 构建任务执行时间长且失败后重试代价高，只自动重试一次，之后进入死信主题等待人工重放。
+// 重试只针对基础设施错误，消费者以 DeadLetter 拒绝的消息（参见 client.NonRetryableError）不会重试。
+func DefaultRetryPolicies() map[string]RetryPolicy {
+	return map[string]RetryPolicy{
+		client.BuilderTopic: {
+			MaxAttempts:       2,
+			InitialBackoff:    time.Minute,
+			MaxBackoff:        10 * time.Minute,
+			VisibilityTimeout: 2 * time.Hour,
+		},
+		client.WindowsBuilderTopic: {
+			MaxAttempts:       2,
+			InitialBackoff:    time.Minute,
+			MaxBackoff:        10 * time.Minute,
+			VisibilityTimeout: 2 * time.Hour,
+		},
+		client.WorkerTopic: {
+			MaxAttempts:    5,
+			InitialBackoff: 2 * time.Second,
+			MaxBackoff:     time.Minute,
+		},
+	}
+}
+
+// ParseRetryPolicies 解析重试策略配置，格式为 topic=maxAttempts/initialBackoff/maxBackoff[/visibilityTimeout]，
+// 多个主题之间使用逗号分隔，例如 builder=3/30s/5m/1h,worker=5/2s/1m
+func ParseRetryPolicies(value string) (map[string]RetryPolicy, error) {
+	policies := make(map[string]RetryPolicy)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("retry policy %q is invalid, want topic=maxAttempts/initialBackoff/maxBackoff", item)
+		}
+		fields := strings.Split(kv[1], "/")
+		if len(fields) < 3 || len(fields) > 4 {
+			return nil, fmt.Errorf("retry policy %q is invalid, want topic=maxAttempts/initialBackoff/maxBackoff", item)
+		}
+		var policy RetryPolicy
+		var err error
+		if policy.MaxAttempts, err = strconv.Atoi(fields[0]); err != nil || policy.MaxAttempts < 0 {
+			return nil, fmt.Errorf("retry policy %q has invalid max attempts", item)
+		}
+		if policy.InitialBackoff, err = time.ParseDuration(fields[1]); err != nil {
+			return nil, fmt.Errorf("retry policy %q has invalid initial backoff: %s", item, err.Error())
+		}
+		if policy.MaxBackoff, err = time.ParseDuration(fields[2]); err != nil {
+			return nil, fmt.Errorf("retry policy %q has invalid max backoff: %s", item, err.Error())
+		}
+		if len(fields) == 4 {
+			if policy.VisibilityTimeout, err = time.ParseDuration(fields[3]); err != nil {
+				return nil, fmt.Errorf("retry policy %q has invalid visibility timeout: %s", item, err.Error())
+			}
+		}
+		policies[strings.TrimSpace(kv[0])] = policy
+	}
+	return policies, nil
+}
@@ -0,0 +1,177 @@
+package mq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+	want := []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for attempts, backoff := range want {
+		if got := policy.Backoff(attempts); got != backoff {
+			t.Fatalf("backoff of attempts %d = %s, want %s", attempts, got, backoff)
+		}
+	}
+	if policy.Exhausted(3) || !policy.Exhausted(4) {
+		t.Fatal("policy should be exhausted after 4 attempts")
+	}
+	if (RetryPolicy{}).Exhausted(100) {
+		t.Fatal("zero max attempts should retry forever")
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestParseRetryPolicies(t *testing.T) {
+	policies, err := ParseRetryPolicies("builder=3/30s/5m/1h, worker=5/2s/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := policies["builder"]
+	if builder.MaxAttempts != 3 || builder.InitialBackoff != 30*time.Second || builder.MaxBackoff != 5*time.Minute || builder.VisibilityTimeout != time.Hour {
+		t.Fatalf("unexpected builder policy %+v", builder)
+	}
+	if policies["worker"].VisibilityTimeout != 0 {
+		t.Fatalf("unexpected worker policy %+v", policies["worker"])
+	}
+	for _, value := range []string{"builder", "builder=3/30s", "builder=x/30s/5m", "builder=3/30s/5m/1h/2h", "builder=3/abc/5m"} {
+		if _, err := ParseRetryPolicies(value); err == nil {
+			t.Fatalf("parse %q should fail", value)
+		}
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestMessageStoreNackBackoffThenDeadLetter(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, map[string]RetryPolicy{
+		"worker": {MaxAttempts: 2, InitialBackoff: time.Minute, MaxBackoff: time.Hour},
+	})
+	store.Put("worker", "task")
+
+	msg, _ := store.Take(context.Background(), "worker", false)
+	if err := store.Nack(msg.ID, "first failure", false); err != nil {
+		t.Fatal(err)
+	}
+	if store.Size("worker") != 0 {
+		t.Fatal("nacked message should wait for backoff")
+	}
+	store.RequeueExpired(time.Now().Add(30 * time.Second))
+	if store.Size("worker") != 0 {
+		t.Fatal("message should not be redelivered before backoff")
+	}
+	store.RequeueExpired(time.Now().Add(2 * time.Minute))
+	if store.Size("worker") != 1 {
+		t.Fatal("message should be redelivered after backoff")
+	}
+
+	msg, _ = store.Take(context.Background(), "worker", false)
+	if err := store.Nack(msg.ID, "second failure", false); err != nil {
+		t.Fatal(err)
+	}
+	if store.Size("worker") != 0 || store.Size(DeadLetterTopic("worker")) != 1 {
+		t.Fatal("exhausted message should move to dead letter topic")
+	}
+	dead := store.DeadLetters("worker")
+	if len(dead) != 1 || dead[0].OriginTopic != "worker" || dead[0].LastError != "second failure" || dead[0].Attempts != 2 {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestMessageStoreRejectSkipsRetry(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, map[string]RetryPolicy{
+		"builder": {MaxAttempts: 2, InitialBackoff: time.Minute},
+	})
+	store.Put("builder", "task")
+
+	msg, _ := store.Take(context.Background(), "builder", false)
+	if err := store.Reject(msg.ID, "invalid task body"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Size("builder") != 0 || store.Size(DeadLetterTopic("builder")) != 1 {
+		t.Fatal("rejected message should move to dead letter topic without retry")
+	}
+	dead := store.DeadLetters("builder")
+	if len(dead) != 1 || dead[0].LastError != "invalid task body" || dead[0].Attempts != 1 {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+	if err := store.Reject(msg.ID, "again"); err != ErrMessageNotFound {
+		t.Fatalf("reject a settled message should fail, got %v", err)
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestMessageStoreVisibilityTimeoutDeadLetter(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, map[string]RetryPolicy{
+		"builder": {MaxAttempts: 1, VisibilityTimeout: time.Hour},
+	})
+	store.Put("builder", "task")
+	store.Take(context.Background(), "builder", false)
+
+	store.RequeueExpired(time.Now().Add(2 * time.Minute))
+	if store.Size(DeadLetterTopic("builder")) != 0 {
+		t.Fatal("topic visibility timeout should override the global one")
+	}
+	if count := store.RequeueExpired(time.Now().Add(2 * time.Hour)); count != 1 {
+		t.Fatalf("expired count = %d, want 1", count)
+	}
+	if store.Size(DeadLetterTopic("builder")) != 1 {
+		t.Fatal("expired message without retries left should be dead lettered")
+	}
+}
+
+// capability_id: rainbond.mq.retry-dead-letter
+func TestMessageStoreReplayAndPurgeDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := map[string]RetryPolicy{"builder": {MaxAttempts: 1}}
+	store := newMessageStore(j, time.Minute, policies)
+	for _, body := range []string{"first", "second", "third"} {
+		store.Put("builder", body)
+		msg, _ := store.Take(context.Background(), "builder", false)
+		store.Nack(msg.ID, "build failure", false)
+	}
+	dead := store.DeadLetters("builder")
+	if len(dead) != 3 {
+		t.Fatalf("dead letters count = %d, want 3", len(dead))
+	}
+
+	count, err := store.ReplayDeadLetters("builder", []string{dead[0].ID})
+	if err != nil || count != 1 {
+		t.Fatalf("replay count = %d, err = %v", count, err)
+	}
+	count, err = store.PurgeDeadLetters("builder", []string{dead[1].ID})
+	if err != nil || count != 1 {
+		t.Fatalf("purge count = %d, err = %v", count, err)
+	}
+	store.Close()
+
+	j, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := newMessageStore(j, time.Minute, policies)
+	defer recovered.Close()
+	if _, err := recovered.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := recovered.Take(context.Background(), "builder", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Body != "first" || replayed.Attempts != 1 || replayed.LastError != "" {
+		t.Fatalf("unexpected replayed message %+v", replayed)
+	}
+	remain := recovered.DeadLetters("builder")
+	if len(remain) != 1 || remain[0].Body != "third" {
+		t.Fatalf("unexpected remaining dead letters %+v", remain)
+	}
+	if count, _ := recovered.PurgeDeadLetters("builder", nil); count != 1 {
+		t.Fatalf("purge all count = %d, want 1", count)
+	}
+}
@@ -0,0 +1,153 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+// Schedule 消息的调度属性，由生产者在入队时指定
+type Schedule struct {
+	// Priority 优先级通道，数值越大越先被消费，0 为普通通道
+	Priority int `json:"priority,omitempty"`
+	// TenantID 同一优先级通道内按租户公平调度
+	TenantID string `json:"tenant_id,omitempty"`
+	// Weight 租户在同一优先级通道内的调度权重，小于等于 0 时为 1
+	Weight int `json:"weight,omitempty"`
+	// Quota 租户在该主题上已投递未确认消息的上限，0 表示不限制
+	Quota int `json:"quota,omitempty"`
+}
+
+func (s Schedule) weight() float64 {
+	if s.Weight <= 0 {
+		return 1
+	}
+	return float64(s.Weight)
+}
+
+// nextLocked 选择队列中下一条要投递的消息，返回其在队列中的下标，没有可投递的消息时返回 -1。
+// 先选择最高的优先级通道，通道内按租户的加权虚拟时间选择最落后的租户，租户内保持先进先出。
+// 已达到并发配额的租户暂时跳过，直到其消息被确认。调用方需持有锁。
+func (s *messageStore) nextLocked(queue string) int {
+	values := s.ready[queue]
+	if len(values) == 0 {
+		return -1
+	}
+	var running map[string]int
+	for _, msg := range values {
+		if msg.Quota > 0 {
+			running = s.runningLocked(queue)
+			break
+		}
+	}
+	var (
+		found    bool
+		priority int
+		heads    = make(map[string]int)
+	)
+	for i, msg := range values {
+		if msg.Quota > 0 && running[msg.TenantID] >= msg.Quota {
+			continue
+		}
+		if !found || msg.Priority > priority {
+			found = true
+			priority = msg.Priority
+			heads = map[string]int{msg.TenantID: i}
+			continue
+		}
+		if msg.Priority == priority {
+			if _, ok := heads[msg.TenantID]; !ok {
+				heads[msg.TenantID] = i
+			}
+		}
+	}
+	if !found {
+		return -1
+	}
+	if len(heads) == 1 {
+		for _, i := range heads {
+			return i
+		}
+	}
+	served := s.served[queue]
+	// 新加入的租户从当前最小的虚拟时间开始，避免长时间空闲的租户突发占满通道
+	var floor float64
+	var hasFloor bool
+	for tenant := range heads {
+		if v, ok := served[tenant]; ok && (!hasFloor || v < floor) {
+			floor, hasFloor = v, true
+		}
+	}
+	best := -1
+	var bestServed float64
+	for tenant, i := range heads {
+		v, ok := served[tenant]
+		if !ok {
+			v = floor
+		}
+		if best == -1 || v < bestServed || (v == bestServed && i < best) {
+			best, bestServed = i, v
+		}
+	}
+	return best
+}
+
+// activateLocked 租户有新的消息入队时，从当前最小的虚拟时间开始参与调度，调用方需持有锁
+func (s *messageStore) activateLocked(msg *Message) float64 {
+	served, ok := s.served[msg.queue()]
+	if !ok {
+		served = make(map[string]float64)
+		s.served[msg.queue()] = served
+	}
+	v, ok := served[msg.TenantID]
+	if ok {
+		return v
+	}
+	for _, other := range served {
+		if !ok || other < v {
+			v, ok = other, true
+		}
+	}
+	served[msg.TenantID] = v
+	return v
+}
+
+// chargeLocked 记录租户被调度一次，调用方需持有锁
+func (s *messageStore) chargeLocked(msg *Message) {
+	v := s.activateLocked(msg)
+	served := s.served[msg.queue()]
+	served[msg.TenantID] = v + 1/msg.weight()
+	// 队列中已经没有该租户的消息时清理记录
+	for _, pending := range s.ready[msg.queue()] {
+		if pending.TenantID == msg.TenantID {
+			return
+		}
+	}
+	delete(served, msg.TenantID)
+	if len(served) == 0 {
+		delete(s.served, msg.queue())
+	}
+}
+
+// runningLocked 统计队列中每个租户已投递未确认的消息数量，调用方需持有锁
+func (s *messageStore) runningLocked(queue string) map[string]int {
+	running := make(map[string]int)
+	for _, in := range s.inflight {
+		if !in.delayed && in.msg.queue() == queue {
+			running[in.msg.TenantID]++
+		}
+	}
+	return running
+}
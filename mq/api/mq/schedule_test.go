@@ -0,0 +1,95 @@
+package mq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func takeBodies(t *testing.T, store *messageStore, topic string, n int) []string {
+	t.Helper()
+	var bodies []string
+	for i := 0; i < n; i++ {
+		msg, err := store.Take(context.Background(), topic, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, msg.Body)
+	}
+	return bodies
+}
+
+// capability_id: rainbond.mq.priority-fair-scheduling
+func TestMessageStorePriorityLanes(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, nil)
+	store.PutWithSchedule("builder", "bulk", Schedule{Priority: -1})
+	store.PutWithSchedule("builder", "normal", Schedule{})
+	store.PutWithSchedule("builder", "rollback", Schedule{Priority: 1})
+	store.PutWithSchedule("builder", "normal-2", Schedule{})
+
+	got := takeBodies(t, store, "builder", 4)
+	want := []string{"rollback", "normal", "normal-2", "bulk"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dequeue order = %v, want %v", got, want)
+		}
+	}
+}
+
+// capability_id: rainbond.mq.priority-fair-scheduling
+func TestMessageStoreWeightedFairAcrossTenants(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, nil)
+	for i := 0; i < 6; i++ {
+		store.PutWithSchedule("builder", "a", Schedule{TenantID: "a", Weight: 2})
+	}
+	for i := 0; i < 3; i++ {
+		store.PutWithSchedule("builder", "b", Schedule{TenantID: "b"})
+	}
+
+	// 租户 a 的权重是 b 的两倍，前 6 次调度中应各占 4 次和 2 次，而不是先消费完 a 的 6 条
+	got := takeBodies(t, store, "builder", 6)
+	count := map[string]int{}
+	for _, body := range got {
+		count[body]++
+	}
+	if count["a"] != 4 || count["b"] != 2 {
+		t.Fatalf("dequeue order = %v, want 4 of a and 2 of b", got)
+	}
+}
+
+// capability_id: rainbond.mq.priority-fair-scheduling
+func TestMessageStoreTenantQuota(t *testing.T) {
+	old := dequeueWait
+	dequeueWait = 50 * time.Millisecond
+	defer func() { dequeueWait = old }()
+
+	store := newMessageStore(nil, time.Minute, nil)
+	store.PutWithSchedule("builder", "a-1", Schedule{TenantID: "a", Quota: 1})
+	store.PutWithSchedule("builder", "a-2", Schedule{TenantID: "a", Quota: 1})
+
+	first, err := store.Take(context.Background(), "builder", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Take(context.Background(), "builder", false); err != context.DeadlineExceeded {
+		t.Fatalf("take over quota error = %v, want DeadlineExceeded", err)
+	}
+
+	done := make(chan *Message)
+	go func() {
+		msg, _ := store.Take(context.Background(), "builder", false)
+		done <- msg
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := store.Ack(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-done:
+		if msg == nil || msg.Body != "a-2" {
+			t.Fatalf("unexpected message after ack %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting consumer should be woken up after quota is released")
+	}
+}
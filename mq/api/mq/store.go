@@ -1,69 +1,629 @@
 package mq
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/goodrain/rainbond/util"
 )
 
-// KeyValueStore 是一个简单的键值存储结构，支持多值
-type KeyValueStore struct {
-	data map[string][]string
-	mu   sync.Mutex
+// dequeueWait 出队时队列为空的最长等待时间
+var dequeueWait = 5 * time.Second
+
+// defaultGroupTTL 消费组没有消费者取消息超过该时间后被移除
+var defaultGroupTTL = 24 * time.Hour
+
+// ErrMessageNotFound 确认或拒绝的消息不存在或已经超时重新投递
+var ErrMessageNotFound = errors.New("message not found or delivery expired")
+
+// Message 队列中的一条消息
+type Message struct {
+	ID          string    `json:"id"`
+	Topic       string    `json:"topic"`
+	Body        string    `json:"body"`
+	Attempts    int       `json:"attempts"`
+	EnqueueTime time.Time `json:"enqueue_time"`
+	// OriginTopic 死信消息原来所在的主题
+	OriginTopic string    `json:"origin_topic,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	DeadTime    time.Time `json:"dead_time,omitempty"`
+	// Group 消息所属的消费组，为空时属于默认消费组
+	Group string `json:"group,omitempty"`
+	Schedule
 }
 
-// NewKeyValueStore 创建一个新的键值存储实例
-func NewKeyValueStore() *KeyValueStore {
-	return &KeyValueStore{
-		data: make(map[string][]string),
+// queue 返回消息所在的队列
+func (m *Message) queue() string {
+	return queueName(m.Topic, m.Group)
+}
+
+// queueName 返回主题中消费组的队列，每个消费组有独立的队列，默认消费组的消息直接保存在主题队列中
+func queueName(topic, group string) string {
+	if group == "" {
+		return topic
 	}
+	return topic + "#" + group
 }
 
-// Put 将键值对放入存储
-func (kv *KeyValueStore) Put(key, value string) {
-	kv.mu.Lock()
-	defer kv.mu.Unlock()
+type inflightMessage struct {
+	msg      *Message
+	deadline time.Time
+	// delayed 为 true 表示消息处于失败退避等待中，而不是已投递待确认
+	delayed bool
+}
 
-	if existingValues, ok := kv.data[key]; ok {
-		kv.data[key] = append(existingValues, value)
-	} else {
-		kv.data[key] = []string{value}
+// messageStore 按主题保存待消费的消息以及已投递但尚未确认的消息。
+// journal 不为空时，消息变更会先写入预写日志，重启后可以恢复。
+type messageStore struct {
+	mu                sync.Mutex
+	ready             map[string][]*Message
+	inflight          map[string]*inflightMessage
+	journal           *journal
+	notify            chan struct{}
+	visibilityTimeout time.Duration
+	policies          map[string]RetryPolicy
+	// served 每个队列中各租户的加权虚拟时间，用于公平调度
+	served map[string]map[string]float64
+	// groups 订阅主题的消费组及其最近一次取消息的时间，新消息会复制到默认消费组和每个消费组的队列中
+	groups map[string]map[string]time.Time
+	// groupTTL 消费组空闲超过该时间后被移除，不再接收新消息
+	groupTTL time.Duration
+}
+
+// newMessageStore 创建一个新的消息存储实例，journal 为空时只保存在内存中
+func newMessageStore(j *journal, visibilityTimeout time.Duration, policies map[string]RetryPolicy) *messageStore {
+	if policies == nil {
+		policies = make(map[string]RetryPolicy)
+	}
+	return &messageStore{
+		ready:             make(map[string][]*Message),
+		inflight:          make(map[string]*inflightMessage),
+		journal:           j,
+		notify:            make(chan struct{}),
+		visibilityTimeout: visibilityTimeout,
+		policies:          policies,
+		served:            make(map[string]map[string]float64),
+		groups:            make(map[string]map[string]time.Time),
+		groupTTL:          defaultGroupTTL,
 	}
 }
 
-// Get 根据键获取第一个值并删除该键值对中的第一个值
-func (kv *KeyValueStore) Get(key string) (string, bool) {
-	deadline := time.Now().Add(5 * time.Second)
+// policy 返回主题的重试策略，死信主题不再重试
+func (s *messageStore) policy(topic string) RetryPolicy {
+	policy := s.policies[topic]
+	if IsDeadLetterTopic(topic) {
+		policy = RetryPolicy{}
+	}
+	if policy.VisibilityTimeout <= 0 {
+		policy.VisibilityTimeout = s.visibilityTimeout
+	}
+	return policy
+}
 
-	for {
-		// 检查是否超时
-		if time.Now().After(deadline) {
-			return "", false
+// Recover 从预写日志中恢复所有未确认的消息，上次未确认的投递会重新变为可消费
+func (s *messageStore) Recover() (int, error) {
+	if s.journal == nil {
+		return 0, nil
+	}
+	messages, groups, err := s.journal.replay()
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for topic, names := range groups {
+		for _, group := range names {
+			s.registerGroupLocked(topic, group, now)
+		}
+	}
+	for _, msg := range messages {
+		if msg.Group != "" {
+			// 兼容没有消费组记录的旧日志，按消息所属的消费组恢复
+			topic := msg.Topic
+			if msg.OriginTopic != "" {
+				topic = msg.OriginTopic
+			}
+			s.registerGroupLocked(topic, msg.Group, now)
+		}
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+	}
+	s.broadcastLocked()
+	// 回放后立即压缩，丢弃已经确认的记录
+	return len(messages), s.journal.compact(messages, s.groupNamesLocked())
+}
+
+// Put 将消息放入主题队列，消息写入日志后才对消费者可见
+func (s *messageStore) Put(topic, body string) (*Message, error) {
+	return s.PutWithSchedule(topic, body, Schedule{})
+}
+
+// PutWithSchedule 按指定的优先级与租户调度属性将消息放入主题队列。
+// 默认消费组总会收到消息，订阅主题的每个消费组另外收到一份独立的消息，返回默认消费组的一份。
+func (s *messageStore) PutWithSchedule(topic, body string, schedule Schedule) (*Message, error) {
+	// 持有锁写日志，避免与日志压缩交错导致新消息丢失
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groups := append([]string{""}, s.namedGroupsLocked(topic)...)
+	messages := make([]*Message, 0, len(groups))
+	for i, group := range groups {
+		msg := &Message{
+			ID:          util.NewUUID(),
+			Topic:       topic,
+			Group:       group,
+			Body:        body,
+			EnqueueTime: time.Now(),
+			Schedule:    schedule,
+		}
+		if s.journal != nil {
+			if err := s.journal.append(walRecord{Op: walOpPut, Message: msg}, i == len(groups)-1); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, msg)
+	}
+	for _, msg := range messages {
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+		s.activateLocked(msg)
+	}
+	s.broadcastLocked()
+	return messages[0], nil
+}
+
+// joinGroupLocked 登记订阅主题的消费组并刷新其活跃时间。第一个订阅的消费组复制一份默认消费组中待消费的消息，
+// 之后订阅的消费组只接收订阅后的新消息。消费组写入日志，重启后即使没有待消费的消息也能继续接收新消息。
+// 调用方需持有锁。
+func (s *messageStore) joinGroupLocked(topic, group string, now time.Time) {
+	if IsDeadLetterTopic(topic) {
+		return
+	}
+	if !s.registerGroupLocked(topic, group, now) || group == "" {
+		return
+	}
+	if err := s.journalLocked(walRecord{Op: walOpJoin, Topic: topic, Group: group}, true); err != nil {
+		logrus.Errorf("write consumer group %s of topic %s record failure %s", group, topic, err.Error())
+	}
+	if len(s.namedGroupsLocked(topic)) > 1 {
+		return
+	}
+	for _, msg := range s.ready[topic] {
+		copied := *msg
+		copied.ID = util.NewUUID()
+		copied.Group = group
+		copied.Attempts = 0
+		s.ready[copied.queue()] = append(s.ready[copied.queue()], &copied)
+		s.activateLocked(&copied)
+		if err := s.journalLocked(walRecord{Op: walOpPut, Message: &copied}, false); err != nil {
+			logrus.Errorf("write message %s group record failure %s", copied.ID, err.Error())
+		}
+	}
+	if len(s.ready[topic]) > 0 {
+		s.broadcastLocked()
+	}
+}
+
+// registerGroupLocked 记录消费组的活跃时间，返回消费组是否是新登记的。调用方需持有锁。
+func (s *messageStore) registerGroupLocked(topic, group string, now time.Time) bool {
+	groups, ok := s.groups[topic]
+	if !ok {
+		// 默认消费组从主题第一次被订阅时开始计算空闲时间
+		groups = map[string]time.Time{"": now}
+		s.groups[topic] = groups
+	}
+	_, joined := groups[group]
+	groups[group] = now
+	return !joined
+}
+
+// namedGroupsLocked 按名称排序返回主题中除默认消费组以外的消费组，调用方需持有锁
+func (s *messageStore) namedGroupsLocked(topic string) []string {
+	var groups []string
+	for group := range s.groups[topic] {
+		if group != "" {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// groupNamesLocked 返回所有主题的消费组，用于压缩日志时保留消费组记录，调用方需持有锁
+func (s *messageStore) groupNamesLocked() map[string][]string {
+	groups := make(map[string][]string, len(s.groups))
+	for topic := range s.groups {
+		if names := s.namedGroupsLocked(topic); len(names) > 0 {
+			groups[topic] = names
+		}
+	}
+	return groups
+}
+
+// ExpireGroups 移除空闲超过 groupTTL 的消费组，丢弃其尚未消费的消息，消费组的死信消息转入主题的死信队列。
+// 默认消费组不会被移除，但主题被其他消费组订阅时，默认消费组空闲超时后丢弃其积压的消息，避免没有消费者时无限积压。
+// 返回移除的消费组数量。
+func (s *messageStore) ExpireGroups(now time.Time) int {
+	if s.groupTTL <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int
+	for topic, groups := range s.groups {
+		for group, active := range groups {
+			if now.Sub(active) < s.groupTTL {
+				continue
+			}
+			if group == "" {
+				if len(groups) > 1 {
+					s.dropQueueLocked(topic)
+				}
+				continue
+			}
+			s.leaveGroupLocked(topic, group)
+			count++
+		}
+	}
+	return count
+}
+
+// leaveGroupLocked 移除消费组，调用方需持有锁
+func (s *messageStore) leaveGroupLocked(topic, group string) {
+	logrus.Warningf("consumer group %s of topic %s is idle for %s, remove it", group, topic, s.groupTTL)
+	delete(s.groups[topic], group)
+	s.dropQueueLocked(queueName(topic, group))
+	delete(s.served, queueName(topic, group))
+	for id, in := range s.inflight {
+		if in.msg.Topic == topic && in.msg.Group == group {
+			delete(s.inflight, id)
+			if err := s.journalLocked(walRecord{Op: walOpAck, ID: id}, false); err != nil {
+				logrus.Errorf("write message %s ack record failure %s", id, err.Error())
+			}
+		}
+	}
+	dlq := queueName(DeadLetterTopic(topic), group)
+	for _, msg := range s.ready[dlq] {
+		msg.Group = ""
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+		if err := s.journalLocked(walRecord{Op: walOpPut, Message: msg}, false); err != nil {
+			logrus.Errorf("write message %s group record failure %s", msg.ID, err.Error())
 		}
+	}
+	delete(s.ready, dlq)
+	if err := s.journalLocked(walRecord{Op: walOpLeave, Topic: topic, Group: group}, true); err != nil {
+		logrus.Errorf("write consumer group %s of topic %s record failure %s", group, topic, err.Error())
+	}
+}
+
+// dropQueueLocked 丢弃队列中尚未消费的消息，调用方需持有锁
+func (s *messageStore) dropQueueLocked(queue string) {
+	for _, msg := range s.ready[queue] {
+		if err := s.journalLocked(walRecord{Op: walOpAck, ID: msg.ID}, false); err != nil {
+			logrus.Errorf("write message %s ack record failure %s", msg.ID, err.Error())
+		}
+	}
+	delete(s.ready, queue)
+}
+
+// Take 按优先级与租户公平调度取出主题中默认消费组的下一条消息
+func (s *messageStore) Take(ctx context.Context, topic string, autoAck bool) (*Message, error) {
+	return s.TakeGroup(ctx, topic, "", autoAck)
+}
 
-		kv.mu.Lock()
-		values, ok := kv.data[key]
-		if ok && len(values) > 0 {
-			// 获取第一个值并从切片中删除
-			firstValue := values[0]
+// TakeGroup 以消费组的身份取出主题中的下一条消息，没有可投递的消息时最多等待 dequeueWait。
+// 每个消费组都会收到主题中的每条消息，同一消费组的多个消费者共享该组的消息。
+// autoAck 为 true 时消息取出即确认；否则消息进入未确认状态，超过可见性超时后重新投递。
+func (s *messageStore) TakeGroup(ctx context.Context, topic, group string, autoAck bool) (*Message, error) {
+	timer := time.NewTimer(dequeueWait)
+	defer timer.Stop()
+	queue := queueName(topic, group)
+	s.mu.Lock()
+	s.joinGroupLocked(topic, group, time.Now())
+	s.mu.Unlock()
+	for {
+		s.mu.Lock()
+		if i := s.nextLocked(queue); i >= 0 {
+			values := s.ready[queue]
+			msg := values[i]
+			if autoAck && s.journal != nil {
+				// 自动确认的消息出队后不再保留，确认记录需在出队前持有锁写入，写入失败时消息仍留在队列中
+				if err := s.journal.append(walRecord{Op: walOpAck, ID: msg.ID}, false); err != nil {
+					s.mu.Unlock()
+					return nil, err
+				}
+			}
 			if len(values) == 1 {
-				delete(kv.data, key)
+				delete(s.ready, queue)
+			} else {
+				s.ready[queue] = append(values[:i], values[i+1:]...)
+			}
+			s.chargeLocked(msg)
+			msg.Attempts++
+			if !autoAck {
+				s.inflight[msg.ID] = &inflightMessage{msg: msg, deadline: time.Now().Add(s.policy(topic).VisibilityTimeout)}
+			}
+			s.mu.Unlock()
+			if !autoAck && s.journal != nil {
+				// 投递记录丢失只会导致重复投递，不必每次落盘；写入失败时消息留在 inflight 中，超时后重新投递
+				record := walRecord{Op: walOpDeliver, ID: msg.ID, Attempts: msg.Attempts}
+				if err := s.journal.append(record, false); err != nil {
+					return nil, err
+				}
+			}
+			copied := *msg
+			return &copied, nil
+		}
+		wait := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, context.DeadlineExceeded
+		}
+	}
+}
+
+// Ack 确认消息已被处理，消息从存储中删除
+func (s *messageStore) Ack(id string) error {
+	s.mu.Lock()
+	if in, ok := s.inflight[id]; !ok || in.delayed {
+		s.mu.Unlock()
+		return ErrMessageNotFound
+	}
+	delete(s.inflight, id)
+	// 租户配额释放后唤醒等待的消费者
+	s.broadcastLocked()
+	s.mu.Unlock()
+	if s.journal != nil {
+		return s.journal.append(walRecord{Op: walOpAck, ID: id}, false)
+	}
+	return nil
+}
+
+// Touch 重新设置未确认消息的可见性超时，timeout 小于等于 0 时使用主题的可见性超时
+func (s *messageStore) Touch(id string, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in, ok := s.inflight[id]
+	if !ok || in.delayed {
+		return ErrMessageNotFound
+	}
+	if timeout <= 0 {
+		timeout = s.policy(in.msg.Topic).VisibilityTimeout
+	}
+	in.deadline = time.Now().Add(timeout)
+	return nil
+}
+
+// Nack 拒绝消息。requeue 为 true 时消息立即回到队列末尾且不计入失败次数；
+// 否则按照主题的重试策略退避后重新投递，用尽重试次数的消息进入死信主题。
+func (s *messageStore) Nack(id, reason string, requeue bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in, ok := s.inflight[id]
+	if !ok || in.delayed {
+		return ErrMessageNotFound
+	}
+	delete(s.inflight, id)
+	msg := in.msg
+	if requeue {
+		if msg.Attempts > 0 {
+			msg.Attempts--
+		}
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+		s.broadcastLocked()
+		return s.journalLocked(walRecord{Op: walOpDeliver, ID: msg.ID, Attempts: msg.Attempts}, false)
+	}
+	msg.LastError = reason
+	return s.retryLocked(msg, time.Now(), false)
+}
+
+// Reject 拒绝消息且不再重试，用于重试也无法成功的失败，消息直接进入死信主题
+func (s *messageStore) Reject(id, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in, ok := s.inflight[id]
+	if !ok || in.delayed {
+		return ErrMessageNotFound
+	}
+	delete(s.inflight, id)
+	in.msg.LastError = reason
+	return s.deadLetterLocked(in.msg, time.Now())
+}
+
+// retryLocked 按重试策略处理一次失败的投递，调用方需持有锁
+func (s *messageStore) retryLocked(msg *Message, now time.Time, front bool) error {
+	policy := s.policy(msg.Topic)
+	if policy.Exhausted(msg.Attempts) {
+		return s.deadLetterLocked(msg, now)
+	}
+	if backoff := policy.Backoff(msg.Attempts); backoff > 0 {
+		s.inflight[msg.ID] = &inflightMessage{msg: msg, deadline: now.Add(backoff), delayed: true}
+	} else if front {
+		s.ready[msg.queue()] = append([]*Message{msg}, s.ready[msg.queue()]...)
+	} else {
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+	}
+	// 即使消息进入退避等待，其占用的租户配额也已经释放
+	s.broadcastLocked()
+	return s.journalLocked(walRecord{Op: walOpPut, Message: msg}, false)
+}
+
+// deadLetterLocked 将消息移动到死信主题，调用方需持有锁
+func (s *messageStore) deadLetterLocked(msg *Message, now time.Time) error {
+	logrus.Warningf("message %s of topic %s failed %d times, move it to dead letter topic: %s", msg.ID, msg.Topic, msg.Attempts, msg.LastError)
+	msg.OriginTopic = msg.Topic
+	msg.Topic = DeadLetterTopic(msg.Topic)
+	msg.DeadTime = now
+	s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+	s.broadcastLocked()
+	return s.journalLocked(walRecord{Op: walOpPut, Message: msg}, true)
+}
+
+// RequeueExpired 处理到期的消息：退避结束的消息回到队列末尾；超过可见性超时仍未确认的消息视为一次失败，
+// 按重试策略放回队列头部或进入死信主题。返回超时未确认的消息数量。
+func (s *messageStore) RequeueExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int
+	for id, in := range s.inflight {
+		if now.Before(in.deadline) {
+			continue
+		}
+		delete(s.inflight, id)
+		if in.delayed {
+			s.ready[in.msg.queue()] = append(s.ready[in.msg.queue()], in.msg)
+			s.broadcastLocked()
+			continue
+		}
+		count++
+		in.msg.LastError = "visibility timeout expired before ack"
+		if err := s.retryLocked(in.msg, now, true); err != nil {
+			logrus.Errorf("write message %s retry record failure %s", id, err.Error())
+		}
+	}
+	return count
+}
+
+// DeadLetters 返回主题的死信消息，包括各消费组的死信消息
+func (s *messageStore) DeadLetters(topic string) []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []*Message
+	for _, queue := range s.queuesLocked(DeadLetterTopic(topic)) {
+		for _, msg := range s.ready[queue] {
+			copied := *msg
+			messages = append(messages, &copied)
+		}
+	}
+	return messages
+}
+
+// queuesLocked 返回主题的所有队列，死信主题按照原主题的消费组查找，调用方需持有锁
+func (s *messageStore) queuesLocked(topic string) []string {
+	queues := []string{topic}
+	for _, group := range s.namedGroupsLocked(strings.TrimSuffix(topic, DeadLetterSuffix)) {
+		queues = append(queues, queueName(topic, group))
+	}
+	return queues
+}
+
+// ReplayDeadLetters 将死信消息重新放回原主题并重置投递次数，ids 为空时重放全部死信消息
+func (s *messageStore) ReplayDeadLetters(topic string, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int
+	for _, msg := range s.takeDeadLettersLocked(topic, ids) {
+		msg.Topic = msg.OriginTopic
+		msg.OriginTopic = ""
+		msg.Attempts = 0
+		msg.LastError = ""
+		msg.DeadTime = time.Time{}
+		s.ready[msg.queue()] = append(s.ready[msg.queue()], msg)
+		count++
+		if err := s.journalLocked(walRecord{Op: walOpPut, Message: msg}, true); err != nil {
+			return count, err
+		}
+	}
+	if count > 0 {
+		s.broadcastLocked()
+	}
+	return count, nil
+}
+
+// PurgeDeadLetters 删除死信消息，ids 为空时清空主题的全部死信消息
+func (s *messageStore) PurgeDeadLetters(topic string, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int
+	for _, msg := range s.takeDeadLettersLocked(topic, ids) {
+		count++
+		if err := s.journalLocked(walRecord{Op: walOpAck, ID: msg.ID}, false); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// takeDeadLettersLocked 从死信主题中取出指定的消息，调用方需持有锁
+func (s *messageStore) takeDeadLettersLocked(topic string, ids []string) []*Message {
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var taken []*Message
+	for _, dlq := range s.queuesLocked(DeadLetterTopic(topic)) {
+		var remain []*Message
+		for _, msg := range s.ready[dlq] {
+			if len(ids) == 0 || selected[msg.ID] {
+				taken = append(taken, msg)
 			} else {
-				kv.data[key] = values[1:]
+				remain = append(remain, msg)
 			}
-			kv.mu.Unlock()
-			return firstValue, true
 		}
-		kv.mu.Unlock()
+		if len(remain) == 0 {
+			delete(s.ready, dlq)
+		} else {
+			s.ready[dlq] = remain
+		}
+	}
+	return taken
+}
+
+// journalLocked 在持有锁时写日志，内存模式下直接返回
+func (s *messageStore) journalLocked(record walRecord, sync bool) error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.append(record, sync)
+}
 
-		// 短暂休眠后重试，避免忙等待
-		time.Sleep(100 * time.Millisecond)
+// Compact 日志过大时重写为当前未确认消息的快照
+func (s *messageStore) Compact() error {
+	if s.journal == nil || !s.journal.needCompact() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []*Message
+	for _, values := range s.ready {
+		pending = append(pending, values...)
 	}
+	for _, in := range s.inflight {
+		pending = append(pending, in.msg)
+	}
+	return s.journal.compact(pending, s.groupNamesLocked())
 }
 
-// Size 返回特定键的值列表大小
-func (kv *KeyValueStore) Size(topic string) int64 {
-	kv.mu.Lock()
-	defer kv.mu.Unlock()
+// Size 返回特定主题中待消费的消息数量，包括各消费组队列中的消息
+func (s *messageStore) Size(topic string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var size int
+	for _, queue := range s.queuesLocked(topic) {
+		size += len(s.ready[queue])
+	}
+	return int64(size)
+}
+
+// Close 关闭预写日志
+func (s *messageStore) Close() error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.close()
+}
 
-	return int64(len(kv.data[topic]))
+// broadcastLocked 唤醒所有等待消息的消费者，调用方需持有锁
+func (s *messageStore) broadcastLocked() {
+	close(s.notify)
+	s.notify = make(chan struct{})
 }
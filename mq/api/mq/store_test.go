@@ -0,0 +1,317 @@
+package mq
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreAckAndRedeliver(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, nil)
+	if _, err := store.Put("builder", "task-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := store.Take(context.Background(), "builder", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Body != "task-1" || msg.Attempts != 1 {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if store.Size("builder") != 0 {
+		t.Fatal("delivered message should not be visible")
+	}
+
+	if count := store.RequeueExpired(time.Now().Add(2 * time.Minute)); count != 1 {
+		t.Fatalf("requeue expired count = %d, want 1", count)
+	}
+	redelivered, err := store.Take(context.Background(), "builder", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivered.ID != msg.ID || redelivered.Attempts != 2 {
+		t.Fatalf("unexpected redelivered message %+v", redelivered)
+	}
+	if err := store.Ack(msg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Ack(msg.ID); err != ErrMessageNotFound {
+		t.Fatalf("ack twice error = %v, want ErrMessageNotFound", err)
+	}
+}
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreNackRequeuesToTail(t *testing.T) {
+	store := newMessageStore(nil, time.Minute, nil)
+	store.Put("worker", "first")
+	store.Put("worker", "second")
+
+	first, _ := store.Take(context.Background(), "worker", false)
+	if err := store.Nack(first.ID, "", true); err != nil {
+		t.Fatal(err)
+	}
+	next, _ := store.Take(context.Background(), "worker", true)
+	if next.Body != "second" {
+		t.Fatalf("next body = %s, want second", next.Body)
+	}
+	again, _ := store.Take(context.Background(), "worker", true)
+	if again.Body != "first" {
+		t.Fatalf("nacked body = %s, want first", again.Body)
+	}
+}
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreTakeTimeout(t *testing.T) {
+	old := dequeueWait
+	dequeueWait = 50 * time.Millisecond
+	defer func() { dequeueWait = old }()
+
+	store := newMessageStore(nil, time.Minute, nil)
+	if _, err := store.Take(context.Background(), "builder", true); err != context.DeadlineExceeded {
+		t.Fatalf("take from empty topic error = %v, want DeadlineExceeded", err)
+	}
+}
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreRecoverFromWAL(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMessageStore(j, time.Minute, nil)
+	store.Put("builder", "acked")
+	store.Put("builder", "inflight")
+	store.Put("worker", "pending")
+
+	acked, _ := store.Take(context.Background(), "builder", false)
+	store.Ack(acked.ID)
+	store.Take(context.Background(), "builder", false)
+	store.Close()
+
+	// 模拟进程崩溃时写了一半的记录
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 1, 0, 1})
+	f.Close()
+
+	j, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := newMessageStore(j, time.Minute, nil)
+	defer recovered.Close()
+	count, err := recovered.Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("recovered count = %d, want 2", count)
+	}
+	msg, err := recovered.Take(context.Background(), "builder", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Body != "inflight" || msg.Attempts != 2 {
+		t.Fatalf("unexpected recovered message %+v", msg)
+	}
+	if recovered.Size("worker") != 1 {
+		t.Fatalf("worker topic size = %d, want 1", recovered.Size("worker"))
+	}
+}
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreRecoverDropsOversizedRecord(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMessageStore(j, time.Minute, nil)
+	store.Put("builder", "kept")
+	store.Close()
+
+	// 损坏的记录头声明了远超上限的长度
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0xff, 0xff, 0xff, 0xf0, 0, 0, 0, 0, '{', '}'})
+	f.Close()
+
+	j, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := newMessageStore(j, time.Minute, nil)
+	defer recovered.Close()
+	count, err := recovered.Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("recovered count = %d, want 1", count)
+	}
+	if _, err := recovered.Put("builder", "after"); err != nil {
+		t.Fatalf("append after dropping the corrupted tail: %v", err)
+	}
+}
+
+// capability_id: rainbond.mq.durable-ack-queue
+func TestMessageStoreAutoAckKeepsMessageWhenWALFails(t *testing.T) {
+	j, err := openJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMessageStore(j, time.Minute, nil)
+	store.Put("builder", "task")
+	j.file.Close()
+
+	if _, err := store.Take(context.Background(), "builder", true); err == nil {
+		t.Fatal("expected take to fail when the ack record can not be written")
+	}
+	if store.Size("builder") != 1 {
+		t.Fatalf("builder topic size = %d, want the message kept in queue", store.Size("builder"))
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestMessageStoreConsumerGroups(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMessageStore(j, time.Minute, nil)
+	store.Put("builder", "backlog")
+
+	// 第一个订阅的消费组收到已有消息的副本，之后订阅的消费组只接收新消息
+	first, err := store.TakeGroup(context.Background(), "builder", "rbd-chaos", false)
+	if err != nil || first.Body != "backlog" || first.Group != "rbd-chaos" {
+		t.Fatalf("first group should receive the backlog, got %+v err=%v", first, err)
+	}
+	if err := store.Ack(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := store.TakeGroup(ctx, "builder", "audit", false); err == nil {
+		t.Fatal("new consumer group should not receive old messages")
+	}
+
+	store.Put("builder", "task")
+	if store.Size("builder") != 4 {
+		t.Fatalf("size = %d, want a copy for the default group and each consumer group", store.Size("builder"))
+	}
+	chaos, _ := store.TakeGroup(context.Background(), "builder", "rbd-chaos", false)
+	audit, _ := store.TakeGroup(context.Background(), "builder", "audit", false)
+	if chaos.Body != "task" || audit.Body != "task" || chaos.ID == audit.ID {
+		t.Fatalf("every consumer group should receive its own copy, got %+v and %+v", chaos, audit)
+	}
+	// 默认消费组不受其他消费组影响，Dequeue 仍然能取到所有消息
+	for _, want := range []string{"backlog", "task"} {
+		if msg, err := store.Take(context.Background(), "builder", true); err != nil || msg.Body != want || msg.Group != "" {
+			t.Fatalf("default group should receive %s, got %+v err=%v", want, msg, err)
+		}
+	}
+	if err := store.Ack(chaos.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reject(audit.ID, "bad task"); err != nil {
+		t.Fatal(err)
+	}
+	if dead := store.DeadLetters("builder"); len(dead) != 1 || dead[0].Group != "audit" {
+		t.Fatalf("dead letters of consumer groups should be listed with the topic, got %+v", dead)
+	}
+	store.Close()
+
+	// 没有待消费消息的消费组也能从日志中恢复
+	j, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := newMessageStore(j, time.Minute, nil)
+	defer recovered.Close()
+	if _, err := recovered.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	recovered.Put("builder", "after restart")
+	if recovered.Size("builder") != 3 || len(recovered.DeadLetters("builder")) != 1 {
+		t.Fatalf("consumer groups should be recovered from wal, size = %d", recovered.Size("builder"))
+	}
+	if next, _ := recovered.TakeGroup(context.Background(), "builder", "rbd-chaos", false); next.Body != "after restart" {
+		t.Fatalf("unexpected message %+v", next)
+	}
+	if count, err := recovered.ReplayDeadLetters("builder", nil); err != nil || count != 1 {
+		t.Fatalf("replay count = %d, err = %v", count, err)
+	}
+	if next, _ := recovered.TakeGroup(context.Background(), "builder", "audit", false); next.Body != "after restart" {
+		t.Fatalf("unexpected message %+v", next)
+	}
+	replayed, _ := recovered.TakeGroup(context.Background(), "builder", "audit", false)
+	if replayed.Body != "task" || replayed.Group != "audit" {
+		t.Fatalf("dead letter should be replayed to its consumer group, got %+v", replayed)
+	}
+}
+
+// capability_id: rainbond.mq.subscribe-consumer-group
+func TestMessageStoreExpireGroups(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMessageStore(j, time.Minute, nil)
+	store.groupTTL = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	store.TakeGroup(ctx, "builder", "rbd-chaos", false)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	store.TakeGroup(ctx, "builder", "abandoned", false)
+	store.Put("builder", "task")
+	held, _ := store.TakeGroup(context.Background(), "builder", "abandoned", false)
+	store.Put("builder", "pending")
+	if err := store.Reject(held.ID, "bad task"); err != nil {
+		t.Fatal(err)
+	}
+
+	// rbd-chaos 在此期间一直在取消息
+	store.mu.Lock()
+	store.groups["builder"]["rbd-chaos"] = time.Now().Add(2 * time.Hour)
+	store.mu.Unlock()
+	if count := store.ExpireGroups(time.Now().Add(90 * time.Minute)); count != 1 {
+		t.Fatalf("expired groups = %d, want 1", count)
+	}
+	// 空闲的默认消费组积压的消息被丢弃，被移除的消费组的死信消息转入主题的死信队列
+	if store.Size("builder") != 2 {
+		t.Fatalf("size = %d, want only the messages of rbd-chaos", store.Size("builder"))
+	}
+	if dead := store.DeadLetters("builder"); len(dead) != 1 || dead[0].Group != "" {
+		t.Fatalf("dead letters of removed group should be kept, got %+v", dead)
+	}
+	store.Put("builder", "after expire")
+	if store.Size("builder") != 4 {
+		t.Fatalf("size = %d, removed group should not receive new messages", store.Size("builder"))
+	}
+	store.Close()
+
+	j, err = openJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered := newMessageStore(j, time.Minute, nil)
+	defer recovered.Close()
+	if _, err := recovered.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if groups := recovered.namedGroupsLocked("builder"); len(groups) != 1 || groups[0] != "rbd-chaos" {
+		t.Fatalf("removed group should not be recovered, got %v", groups)
+	}
+}
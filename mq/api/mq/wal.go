@@ -0,0 +1,275 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	walFileName = "queue.wal"
+	// walHeaderSize 每条记录的头部：4 字节长度 + 4 字节 crc32
+	walHeaderSize = 8
+	// walCompactSize 日志超过该大小后重写为只包含未确认消息的快照
+	walCompactSize = 64 << 20
+	// walMaxRecordSize 单条记录的最大长度，回放时超过该长度的记录头视为损坏
+	walMaxRecordSize = walCompactSize
+
+	walOpPut     = "put"
+	walOpDeliver = "deliver"
+	walOpAck     = "ack"
+	walOpJoin    = "join"
+	walOpLeave   = "leave"
+)
+
+// walRecord 预写日志中的一条记录
+type walRecord struct {
+	Op       string   `json:"op"`
+	Message  *Message `json:"message,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Attempts int      `json:"attempts,omitempty"`
+	// Topic 与 Group 记录消费组的加入与移除
+	Topic string `json:"topic,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// journal 消息队列的预写日志，所有消息变更先追加到本地磁盘文件，重启时通过回放恢复未确认的消息
+type journal struct {
+	lock sync.Mutex
+	dir  string
+	file *os.File
+	size int64
+}
+
+// openJournal 打开（不存在时创建）指定目录下的预写日志
+func openJournal(dir string) (*journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create mq data dir %s failure: %s", dir, err.Error())
+	}
+	file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open mq wal failure: %s", err.Error())
+	}
+	return &journal{dir: dir, file: file}, nil
+}
+
+// replay 按写入顺序回放日志，返回所有尚未确认的消息以及每个主题的消费组。
+// 进程崩溃可能在文件末尾留下不完整的记录，回放时会截断到最后一条完整记录。
+func (j *journal) replay() ([]*Message, map[string][]string, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	var (
+		order   []string
+		pending = make(map[string]*Message)
+		groups  = make(map[string][]string)
+		offset  int64
+		reader  = bufio.NewReader(j.file)
+		header  = make([]byte, walHeaderSize)
+	)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err != io.EOF {
+				logrus.Warningf("mq wal has a truncated record header at offset %d, drop it", offset)
+			}
+			break
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		checksum := binary.BigEndian.Uint32(header[4:])
+		if length > walMaxRecordSize {
+			// 损坏的记录头可能给出极大的长度，按截断的尾部处理，避免按该长度分配内存
+			logrus.Warningf("mq wal record at offset %d has invalid length %d, drop the rest of log", offset, length)
+			break
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			logrus.Warningf("mq wal has a truncated record at offset %d, drop it", offset)
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != checksum {
+			logrus.Warningf("mq wal record checksum mismatch at offset %d, drop the rest of log", offset)
+			break
+		}
+		var record walRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			logrus.Warningf("mq wal record at offset %d can not be decoded: %s", offset, err.Error())
+			break
+		}
+		offset += int64(walHeaderSize) + int64(length)
+		switch record.Op {
+		case walOpPut:
+			if record.Message == nil {
+				continue
+			}
+			if _, ok := pending[record.Message.ID]; !ok {
+				order = append(order, record.Message.ID)
+			}
+			pending[record.Message.ID] = record.Message
+		case walOpDeliver:
+			if msg, ok := pending[record.ID]; ok {
+				msg.Attempts = record.Attempts
+			}
+		case walOpAck:
+			delete(pending, record.ID)
+		case walOpJoin:
+			groups[record.Topic] = append(removeGroup(groups[record.Topic], record.Group), record.Group)
+		case walOpLeave:
+			groups[record.Topic] = removeGroup(groups[record.Topic], record.Group)
+		}
+	}
+	if err := j.file.Truncate(offset); err != nil {
+		return nil, nil, err
+	}
+	if _, err := j.file.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	j.size = offset
+	var messages []*Message
+	for _, id := range order {
+		if msg, ok := pending[id]; ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, groups, nil
+}
+
+// removeGroup 从消费组列表中删除指定的消费组
+func removeGroup(groups []string, group string) []string {
+	var remain []string
+	for _, name := range groups {
+		if name != group {
+			remain = append(remain, name)
+		}
+	}
+	return remain
+}
+
+// append 追加一条记录，sync 为 true 时在返回前落盘
+func (j *journal) append(record walRecord, sync bool) error {
+	data, err := encodeWALRecord(record)
+	if err != nil {
+		return err
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	n, err := j.file.Write(data)
+	j.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write mq wal failure: %s", err.Error())
+	}
+	if sync {
+		return j.file.Sync()
+	}
+	return nil
+}
+
+// needCompact 日志是否已经大到需要压缩
+func (j *journal) needCompact() bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.size > walCompactSize
+}
+
+// compact 将日志重写为只包含消费组与 pending 消息的快照，先写临时文件再原子替换
+func (j *journal) compact(pending []*Message, groups map[string][]string) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	tmpPath := filepath.Join(j.dir, walFileName+".tmp")
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	writer := bufio.NewWriter(tmp)
+	var records []walRecord
+	for topic, names := range groups {
+		for _, group := range names {
+			records = append(records, walRecord{Op: walOpJoin, Topic: topic, Group: group})
+		}
+	}
+	for _, msg := range pending {
+		records = append(records, walRecord{Op: walOpPut, Message: msg})
+	}
+	for _, record := range records {
+		data, err := encodeWALRecord(record)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		n, err := writer.Write(data)
+		size += int64(n)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(j.dir, walFileName)); err != nil {
+		tmp.Close()
+		return err
+	}
+	j.file.Close()
+	j.file = tmp
+	j.size = size
+	_, err = j.file.Seek(size, io.SeekStart)
+	return err
+}
+
+func (j *journal) close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if err := j.file.Sync(); err != nil {
+		logrus.Warningf("sync mq wal failure %s", err.Error())
+	}
+	return j.file.Close()
+}
+
+func encodeWALRecord(record walRecord) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > walMaxRecordSize {
+		return nil, fmt.Errorf("mq wal record size %d exceeds the limit %d", len(payload), walMaxRecordSize)
+	}
+	data := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	copy(data[walHeaderSize:], payload)
+	return data, nil
+}
@@ -21,6 +21,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/goodrain/rainbond/mq/api/grpc/pb"
@@ -45,17 +46,53 @@ var WorkerHealth = "worker-health"
 // SourceScanTopic source code scan topic
 var SourceScanTopic = "source-scan"
 
+// 消费组，同一消费组的多个副本共享主题中的消息
+const (
+	// BuilderConsumerGroup rbd-chaos 的消费组
+	BuilderConsumerGroup = "rbd-chaos"
+	// WorkerConsumerGroup rbd-worker 的消费组
+	WorkerConsumerGroup = "rbd-worker"
+)
+
+// 任务优先级通道，数值越大越先被调度
+const (
+	// PriorityLow 批量重建等可以延后的任务
+	PriorityLow int32 = -1
+	// PriorityNormal 默认优先级
+	PriorityNormal int32 = 0
+	// PriorityHigh 回滚、紧急修复等需要尽快执行的任务
+	PriorityHigh int32 = 1
+)
+
+// ParsePriority 将 high、normal、low 转换为优先级，无法识别时为普通优先级
+func ParsePriority(priority string) int32 {
+	switch priority {
+	case "high":
+		return PriorityHigh
+	case "low":
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
 // MQClient mq  client
 type MQClient interface {
 	pb.TaskQueueClient
 	Close()
 	SendBuilderTopic(t TaskStruct) error
+	// SubscribeTopic 以消费组成员的身份订阅主题，credits 为同时持有的未确认消息数，
+	// 收到的消息需要通过 Ack 或 Nack 确认。ctx 结束或客户端关闭后返回的通道被关闭。
+	SubscribeTopic(ctx context.Context, topic, group, host string, credits int32) <-chan *pb.TaskMessage
 }
 
 type mqClient struct {
 	pb.TaskQueueClient
 	ctx    context.Context
 	cancel context.CancelFunc
+	// held 通过订阅收到但尚未确认的消息，重新订阅时由消息队列重新认领
+	held     map[string]string
+	heldLock sync.Mutex
 }
 
 // NewMqClient new a mq client
@@ -93,6 +130,7 @@ func NewMqClient(mqAddr string) (MQClient, error) {
 	client := &mqClient{
 		ctx:    ctx,
 		cancel: cancel,
+		held:   make(map[string]string),
 	}
 	client.TaskQueueClient = cli
 	return client, nil
@@ -103,12 +141,105 @@ func (m *mqClient) Close() {
 	m.cancel()
 }
 
+// SubscribeTopic 订阅主题，订阅断开后自动重新订阅，并认领尚未确认的消息，避免消息被重复投递
+func (m *mqClient) SubscribeTopic(ctx context.Context, topic, group, host string, credits int32) <-chan *pb.TaskMessage {
+	messages := make(chan *pb.TaskMessage)
+	go func() {
+		defer close(messages)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-m.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		for {
+			err := m.subscribe(ctx, &pb.SubscribeRequest{
+				Topic:            topic,
+				ConsumerGroup:    group,
+				ClientHost:       host,
+				Credits:          credits,
+				ResumeMessageIds: m.heldMessages(topic),
+			}, messages)
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.Warningf("subscription of topic %s is broken: %v, will subscribe again", topic, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second * 2):
+			}
+		}
+	}()
+	return messages
+}
+
+func (m *mqClient) subscribe(ctx context.Context, in *pb.SubscribeRequest, messages chan<- *pb.TaskMessage) error {
+	stream, err := m.TaskQueueClient.Subscribe(ctx, in)
+	if err != nil {
+		return err
+	}
+	for {
+		message, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		m.heldLock.Lock()
+		m.held[message.MessageId] = in.Topic
+		m.heldLock.Unlock()
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (m *mqClient) heldMessages(topic string) []string {
+	m.heldLock.Lock()
+	defer m.heldLock.Unlock()
+	var ids []string
+	for id, t := range m.held {
+		if t == topic {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (m *mqClient) forget(id string) {
+	m.heldLock.Lock()
+	delete(m.held, id)
+	m.heldLock.Unlock()
+}
+
+// Ack 确认消息，订阅者的额度由消息队列归还
+func (m *mqClient) Ack(ctx context.Context, in *pb.AckRequest, opts ...grpc.CallOption) (*pb.TaskReply, error) {
+	m.forget(in.MessageId)
+	return m.TaskQueueClient.Ack(ctx, in, opts...)
+}
+
+// Nack 拒绝消息，订阅者的额度由消息队列归还
+func (m *mqClient) Nack(ctx context.Context, in *pb.AckRequest, opts ...grpc.CallOption) (*pb.TaskReply, error) {
+	m.forget(in.MessageId)
+	return m.TaskQueueClient.Nack(ctx, in, opts...)
+}
+
 // TaskStruct task struct
 type TaskStruct struct {
 	Topic    string
 	Arch     string
 	TaskType string
 	TaskBody interface{}
+	// Priority 任务的优先级通道
+	Priority int32
+	// TenantID 同一优先级通道内按租户公平调度，TenantWeight 为租户权重，TenantQuota 为租户并发上限
+	TenantID     string
+	TenantWeight int32
+	TenantQuota  int32
 }
 
 // buildTask build task
@@ -120,12 +251,16 @@ func buildTask(t TaskStruct) (*pb.EnqueueRequest, error) {
 		return &er, err
 	}
 	er.Topic = t.Topic
+	er.TenantWeight = t.TenantWeight
+	er.TenantQuota = t.TenantQuota
 	er.Message = &pb.TaskMessage{
 		TaskType:   t.TaskType,
 		CreateTime: time.Now().Format(time.RFC3339),
 		TaskBody:   taskJSON,
 		User:       "rainbond",
 		Arch:       t.Arch,
+		Priority:   t.Priority,
+		TenantId:   t.TenantID,
 	}
 	return &er, nil
 }
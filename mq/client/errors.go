@@ -0,0 +1,77 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import "errors"
+
+// NonRetryableError 重试也无法成功的任务失败，例如任务参数校验失败或者用户代码的测试失败。
+// 消费者遇到该错误时以 DeadLetter 拒绝消息，消息不再重试而是直接进入死信主题。
+type NonRetryableError struct {
+	Err error
+}
+
+func (e *NonRetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NonRetryableError) Unwrap() error {
+	return e.Err
+}
+
+// NonRetryable 将错误标记为不可重试，err 为 nil 时返回 nil
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &NonRetryableError{Err: err}
+}
+
+// IsNonRetryable 判断错误是否被标记为不可重试，其余错误视为基础设施错误按重试策略重新投递
+func IsNonRetryable(err error) bool {
+	var nonRetryable *NonRetryableError
+	return errors.As(err, &nonRetryable)
+}
+
+// RetryableError 镜像仓库、消息队列或者网络故障等基础设施错误，重试可能成功。
+// 默认不重试的消费者（例如构建任务）只重新投递该类错误。
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable 将错误标记为可重试，err 为 nil 时返回 nil
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err}
+}
+
+// IsRetryable 判断错误是否被标记为可重试，同时被标记为不可重试时以不可重试为准
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable) && !IsNonRetryable(err)
+}
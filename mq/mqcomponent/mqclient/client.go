@@ -3,6 +3,7 @@ package mqclient
 import (
 	"context"
 	"github.com/goodrain/rainbond/mq/api/mq"
+	"github.com/sirupsen/logrus"
 )
 
 var defaultMQClientComponent *Component
@@ -30,6 +31,11 @@ func (m *Component) Start(ctx context.Context) error {
 
 // CloseHandle -
 func (m *Component) CloseHandle() {
+	if m.actionMQ != nil {
+		if err := m.actionMQ.Stop(); err != nil {
+			logrus.Errorf("stop message queue failure %s", err.Error())
+		}
+	}
 }
 
 // New -
@@ -89,7 +89,7 @@ func (in ThirdComponentSpec) NeedProbe() bool {
 	if in.Probe == nil {
 		return false
 	}
-	return in.IsStaticEndpoints()
+	return in.IsStaticEndpoints() || in.IsRegistryEndpoints()
 }
 
 // IsStaticEndpoints -
@@ -97,14 +97,24 @@ func (in ThirdComponentSpec) IsStaticEndpoints() bool {
 	return len(in.EndpointSource.StaticEndpoints) > 0
 }
 
+// IsRegistryEndpoints endpoints are discovered from DNS or a service catalog periodically
+func (in ThirdComponentSpec) IsRegistryEndpoints() bool {
+	return in.EndpointSource.DNS != nil || in.EndpointSource.Catalog != nil
+}
+
 // ThirdComponentEndpointSource -
 type ThirdComponentEndpointSource struct {
 	StaticEndpoints   []*ThirdComponentEndpoint `json:"endpoints,omitempty"`
 	KubernetesService *KubernetesServiceSource  `json:"kubernetesService,omitempty"`
+	// DNS discover endpoints from DNS SRV or A records
+	// +optional
+	DNS *DNSSource `json:"dns,omitempty"`
+	// Catalog discover endpoints from a Consul compatible catalog HTTP API
+	// +optional
+	Catalog *CatalogSource `json:"catalog,omitempty"`
 	//other source
 	// NacosSource
 	// EurekaSource
-	// ConsulSource
 	// CustomAPISource
 }
 
@@ -121,6 +131,13 @@ type ThirdComponentEndpoint struct {
 	// Specify a private certificate when the protocol is HTTPS
 	// +optional
 	ClientSecret string `json:"clientSecret,omitempty"`
+	// The relative traffic weight of the endpoint in the gateway. Defaults to 100 if not specified,
+	// 0 means the endpoint receives no traffic.
+	// +optional
+	Weight *int `json:"weight,omitempty"`
+	// The zone the endpoint is located in, same-zone endpoints are preferred by kube-proxy.
+	// +optional
+	Zone string `json:"zone,omitempty"`
 }
 
 // GetPort -
@@ -152,6 +169,50 @@ type KubernetesServiceSource struct {
 	Name      string `json:"name"`
 }
 
+// DNS record types supported by DNSSource
+const (
+	DNSRecordSRV = "SRV"
+	DNSRecordA   = "A"
+)
+
+// DNSSource -
+type DNSSource struct {
+	// The domain name to resolve, e.g. _http._tcp.legacy.example.com for SRV records
+	Name string `json:"name"`
+	// The record type, SRV or A. Defaults to A.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// The port of A records. If not specified, every component port is used.
+	// +optional
+	Port int `json:"port,omitempty"`
+	// The DNS server address including the port number. If not specified, the system resolver is used.
+	// +optional
+	Server string `json:"server,omitempty"`
+	// How often (in seconds) to resolve the records. Defaults to 30 seconds.
+	// +optional
+	RefreshIntervalSeconds int32 `json:"refreshIntervalSeconds,omitempty"`
+}
+
+// CatalogSource -
+type CatalogSource struct {
+	// The address of the catalog HTTP API, e.g. http://consul.example.com:8500
+	Address string `json:"address"`
+	// The service name registered in the catalog
+	Service string `json:"service"`
+	// Only the instances with all of the tags are used
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+	// The datacenter to query. If not specified, the datacenter of the agent is used.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+	// The ACL token of the catalog
+	// +optional
+	Token string `json:"token,omitempty"`
+	// How often (in seconds) to query the catalog. Defaults to 30 seconds.
+	// +optional
+	RefreshIntervalSeconds int32 `json:"refreshIntervalSeconds,omitempty"`
+}
+
 // Probe describes a health check to be performed against a container to determine whether it is
 // alive or ready to receive traffic.
 type Probe struct {
@@ -203,6 +264,9 @@ type Handler struct {
 	// TODO: implement a realistic TCP lifecycle hook
 	// +optional
 	TCPSocket *TCPSocketAction `json:"tcpSocket,omitempty"`
+	// GRPC specifies an action involving a gRPC health check (grpc.health.v1).
+	// +optional
+	GRPC *GRPCAction `json:"grpc,omitempty"`
 }
 
 // Equals -
@@ -217,6 +281,9 @@ func (in *Handler) Equals(target *Handler) bool {
 	if !in.HTTPGet.Equals(target.HTTPGet) {
 		return false
 	}
+	if !in.GRPC.Equals(target.GRPC) {
+		return false
+	}
 	return in.TCPSocket.Equals(target.TCPSocket)
 }
 
@@ -237,6 +304,33 @@ func (in *TCPSocketAction) Equals(target *TCPSocketAction) bool {
 	return true
 }
 
+// GRPCAction enable grpc health check
+type GRPCAction struct {
+	// The name of the service to check. If not specified, the overall health of the server is checked.
+	// +optional
+	Service string `json:"service,omitempty"`
+	// Whether to connect to the endpoint with TLS.
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+	// Skip verifying the certificate of the endpoint, only used when TLS is enabled.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// The server name used to verify the certificate. Defaults to the host of the endpoint.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// Equals -
+func (in *GRPCAction) Equals(target *GRPCAction) bool {
+	if in == nil && target == nil {
+		return true
+	}
+	if in == nil || target == nil {
+		return false
+	}
+	return *in == *target
+}
+
 // HTTPGetAction enable http check
 type HTTPGetAction struct {
 	// Path to access on the HTTP server.
@@ -333,6 +427,10 @@ func (e EndpointAddress) GetIP() string {
 }
 
 func (e EndpointAddress) getIP() string {
+	// IPv6 addresses are in the form of [ip]:port
+	if host, _, err := net.SplitHostPort(string(e)); err == nil && net.ParseIP(host) != nil {
+		return host
+	}
 	info := strings.Split(string(e), ":")
 	if len(info) == 2 {
 		return info[0]
@@ -346,6 +444,10 @@ func (e EndpointAddress) getIP() string {
 // GetPort -
 func (e EndpointAddress) GetPort() int {
 	if !validation.IsDomainNotIP(e.getIP()) {
+		if host, port, err := net.SplitHostPort(string(e)); err == nil && net.ParseIP(host) != nil {
+			p, _ := strconv.Atoi(port)
+			return p
+		}
 		info := strings.Split(string(e), ":")
 		if len(info) == 2 {
 			port, _ := strconv.Atoi(info[1])
@@ -398,7 +500,7 @@ func NewEndpointAddress(host string, port int) *EndpointAddress {
 		if port < 0 || port > 65533 {
 			return nil
 		}
-		ea := EndpointAddress(fmt.Sprintf("%s:%d", host, port))
+		ea := EndpointAddress(net.JoinHostPort(host, strconv.Itoa(port)))
 		return &ea
 	}
 
@@ -426,4 +528,28 @@ type ThirdComponentEndpointStatus struct {
 	Status EndpointStatus `json:"status"`
 	//Reason probe not passed reason
 	Reason string `json:"reason,omitempty"`
+	// The relative traffic weight of the endpoint in the gateway. Defaults to 100 if not specified,
+	// 0 means the endpoint receives no traffic.
+	// +optional
+	Weight *int `json:"weight,omitempty"`
+	// The zone the endpoint is located in.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+}
+
+// DefaultEndpointWeight the weight of endpoints which do not specify one
+const DefaultEndpointWeight = 100
+
+// GetWeight returns the traffic weight of the endpoint, 0 means the endpoint receives no traffic
+func (in *ThirdComponentEndpointStatus) GetWeight() int {
+	if in.Weight == nil || *in.Weight < 0 {
+		return DefaultEndpointWeight
+	}
+	return *in.Weight
+}
+
+// ThirdComponentUpstreamName the name of the gateway upstream which carries the weighted endpoints
+// of a third component service port
+func ThirdComponentUpstreamName(serviceName string, port int) string {
+	return fmt.Sprintf("%s-%d-weighted", serviceName, port)
 }
@@ -20,6 +20,7 @@ package component
 
 import (
 	"context"
+	"fmt"
 	"github.com/eapache/channels"
 	"github.com/goodrain/rainbond/api/controller"
 	api_db "github.com/goodrain/rainbond/api/db"
@@ -49,9 +50,12 @@ import (
 	"github.com/goodrain/rainbond/pkg/component/storage"
 	"github.com/goodrain/rainbond/pkg/gogo"
 	"github.com/goodrain/rainbond/pkg/rainbond"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/worker/activator"
 	"github.com/goodrain/rainbond/worker/appm/componentdefinition"
 	worker_controller "github.com/goodrain/rainbond/worker/appm/controller"
 	"github.com/goodrain/rainbond/worker/appm/store"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
 	"github.com/goodrain/rainbond/worker/discover"
 	"github.com/goodrain/rainbond/worker/gc"
 	"github.com/goodrain/rainbond/worker/master"
@@ -199,6 +203,23 @@ func WorkerInit() rainbond.FuncComponent {
 				errChan <- err
 				return
 			}
+			activatorToken, err := activator.EnsureToken(ctx, k8s.Default().Clientset.CoreV1().Secrets(configs.Default().PublicConfig.RbdNamespace))
+			if err != nil {
+				errChan <- fmt.Errorf("ensure activator token: %v", err)
+				return
+			}
+			act := activator.New(db.GetManager(), cacheStore, cacheStore.Lister().Service, k8s.Default().ApiSixClient.ApisixV2(),
+				func(as v1.AppService, replicas int) error {
+					as.Logger = event.GetManager().GetLogger(util.NewUUID())
+					as.Replicas = replicas
+					return controllerManager.StartController(worker_controller.TypeScalingController, as)
+				}, time.Duration(configs.Default().WorkerConfig.ActivatorTimeout)*time.Second, activatorToken)
+			go act.StartIdleScaler(ctx, masterCon.IsLeader)
+			go func() {
+				if err := http.ListenAndServe(configs.Default().WorkerConfig.ActivatorListen, act); err != nil {
+					errChan <- err
+				}
+			}()
 			runtimeServer := worker_server.CreaterRuntimeServer(cacheStore, updateCh)
 			runtimeServer.Start(errChan)
 			exporterManager := monitor.NewManager(masterCon, controllerManager)
@@ -40,6 +40,7 @@ type EventlogComponent struct {
 	Entry          *entry.Entry
 	SocketServer   *web.SocketServer
 	EventLogConfig *rbdcomponent.EventLogConfig
+	fileStore      store.FileStore
 }
 
 // New -
@@ -60,6 +61,7 @@ func (s *EventlogComponent) Start(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+			s.fileStore = storeManager.MessageFileStore()
 			healthInfo := storeManager.HealthCheck()
 			if err := storeManager.Run(); err != nil {
 				return err
@@ -123,6 +125,11 @@ func (r *EventlogComponent) CloseHandle() {
 
 }
 
+// FileStore 返回事件日志的存储后端，eventlog 服务启动前返回 nil
+func (s *EventlogComponent) FileStore() store.FileStore {
+	return s.fileStore
+}
+
 // Default -
 func Default() *EventlogComponent {
 	return defaultEventlogComponent
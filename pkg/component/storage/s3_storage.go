@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -1034,6 +1035,69 @@ func (s3s *S3Storage) ReadFile(filePath string) (ReadCloser, error) {
 	return result.Body, nil
 }
 
+// FileInfo 对象存储中的文件信息
+type FileInfo struct {
+	// Path 包含桶名的文件路径，可以直接用于 ReadFile
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// WriteFile 将内容写入文件，文件已存在时覆盖
+func (s3s *S3Storage) WriteFile(filePath string, data []byte) error {
+	bucketName, key, err := s3s.ParseDirPath(filePath, true)
+	if err != nil {
+		return fmt.Errorf("failed to parse file path: %w", err)
+	}
+	_, err = s3s.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object to S3: %w", err)
+	}
+	return nil
+}
+
+// ListFiles 递归列出目录下的所有文件
+func (s3s *S3Storage) ListFiles(dirPath string) ([]FileInfo, error) {
+	bucketName, prefix, err := s3s.ParseDirPath(dirPath, false)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileInfo
+	err = s3s.s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			if strings.HasSuffix(aws.StringValue(obj.Key), "/") {
+				continue
+			}
+			files = append(files, FileInfo{
+				Path:    "/" + bucketName + "/" + aws.StringValue(obj.Key),
+				Size:    aws.Int64Value(obj.Size),
+				ModTime: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	return files, nil
+}
+
+// RemoveDir 删除目录下的所有文件
+func (s3s *S3Storage) RemoveDir(dirPath string) error {
+	bucketName, prefix, err := s3s.ParseDirPath(dirPath, false)
+	if err != nil {
+		return err
+	}
+	return s3s.ClearDirectory(bucketName, prefix)
+}
+
 // InitBucketLifecycle 在 API 启动时主动初始化默认 bucket 的生命周期策略
 func (s3s *S3Storage) InitBucketLifecycle() error {
 	// 默认初始化 grdata bucket 的生命周期策略
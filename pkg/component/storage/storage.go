@@ -36,18 +36,10 @@ func (s *StorageComponent) Start(ctx context.Context) error {
 	var storageCli InterfaceStorage
 	logrus.Infof("create s3 client %v,----%v,----%v", s.storageConfig.StorageType, s.storageConfig.S3AccessKeyID, s.storageConfig.S3SecretAccessKey)
 	if s.storageConfig.StorageType == "s3" {
-		sess, err := session.NewSession(&aws.Config{
-			Endpoint:         aws.String(s.storageConfig.S3Endpoint),
-			Region:           aws.String("rainbond"), // 可以根据需要选择区域
-			Credentials:      credentials.NewStaticCredentials(s.storageConfig.S3AccessKeyID, s.storageConfig.S3SecretAccessKey, ""),
-			S3ForcePathStyle: aws.Bool(true), // 使用路径风格
-		})
+		s3Storage, err := NewS3Storage(s.storageConfig)
 		if err != nil {
-			logrus.Errorf("failed to create session: %v", err)
 			return err
 		}
-		s3Client := s3.New(sess)
-		s3Storage := &S3Storage{s3Client: s3Client}
 
 		// API 启动时主动初始化 bucket 生命周期策略
 		logrus.Info("Initializing S3 bucket lifecycle policies on startup...")
@@ -66,6 +58,21 @@ func (s *StorageComponent) Start(ctx context.Context) error {
 	return nil
 }
 
+// NewS3Storage 根据存储配置创建 S3 存储
+func NewS3Storage(storageConfig *configs.StorageConfig) (*S3Storage, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(storageConfig.S3Endpoint),
+		Region:           aws.String("rainbond"), // 可以根据需要选择区域
+		Credentials:      credentials.NewStaticCredentials(storageConfig.S3AccessKeyID, storageConfig.S3SecretAccessKey, ""),
+		S3ForcePathStyle: aws.Bool(true), // 使用路径风格
+	})
+	if err != nil {
+		logrus.Errorf("failed to create session: %v", err)
+		return nil, err
+	}
+	return &S3Storage{s3Client: s3.New(sess)}, nil
+}
+
 // CloseHandle -
 func (s *StorageComponent) CloseHandle() {
 }
@@ -43,3 +43,15 @@ const (
 	ResourceInstanceLabel  = "app.kubernetes.io/instance"
 	ResourceAppLabel       = "app"
 )
+
+// Scale-to-zero activator, it holds the requests of idle components until they are woken up
+const (
+	// ActivatorPort listen port of the activator in rbd-worker
+	ActivatorPort = 6370
+	// ActivatorUpstreamLabel label of the apisix upstreams that forward requests to the activator
+	ActivatorUpstreamLabel = "rainbond.io/activator"
+	// ActivatorTokenHeader header set by the gateway, the activator rejects the requests without the token
+	ActivatorTokenHeader = "X-Rainbond-Activator-Token"
+	// ActivatorTokenSecret secret in the rbd namespace holding the token shared by the gateway and the activator
+	ActivatorTokenSecret = "rbd-activator-token"
+)
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// capability_id: rainbond.util.cron.parse-and-next
+func TestScheduleNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load timezone: %v", err)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 * * *", time.Date(2026, 3, 2, 8, 59, 30, 0, shanghai), time.Date(2026, 3, 2, 9, 0, 0, 0, shanghai)},
+		{"0 9 * * *", time.Date(2026, 3, 2, 9, 0, 0, 0, shanghai), time.Date(2026, 3, 3, 9, 0, 0, 0, shanghai)},
+		{"*/15 * * * *", time.Date(2026, 3, 2, 10, 7, 0, 0, time.UTC), time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)},
+		{"30 22 * * mon-fri", time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 22, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		// day of month and day of week are or-ed when both are restricted
+		{"0 0 13 * 5", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 2, 10, 7, 0, 0, time.UTC), time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Date(2026, 3, 2, 10, 7, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, tc := range tests {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.spec, err)
+		}
+		if got := s.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%q next of %s: want %s, got %s", tc.spec, tc.from, tc.want, got)
+		}
+	}
+}
+
+// capability_id: rainbond.util.cron.parse-and-next
+func TestScheduleParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "*-5 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+// capability_id: rainbond.util.cron.parse-and-next
+func TestSchedulePrev(t *testing.T) {
+	s, err := Parse("0 9,21 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if got, want := s.Prev(since, now), time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+	if got := s.Prev(now, now.Add(time.Hour)); !got.IsZero() {
+		t.Fatalf("expected no activation, got %s", got)
+	}
+}
@@ -11,10 +11,13 @@ import (
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -153,6 +156,46 @@ func GetKubeVersion() *utilversion.Version {
 	return utilversion.MustParseSemantic(serverVersion.GitVersion)
 }
 
+var hpaV2Once sync.Once
+var hpaV2Supported bool
+
+// IsHPAV2Supported reports whether the cluster serves autoscaling/v2 HorizontalPodAutoscalers,
+// autoscaling/v2beta2 is only used on the older clusters. The result is detected once.
+func IsHPAV2Supported() bool {
+	hpaV2Once.Do(func() {
+		hpaV2Supported = SupportsHPAV2(GetClientSet().Discovery())
+	})
+	return hpaV2Supported
+}
+
+// SupportsHPAV2 detects autoscaling/v2 from the resources served by the cluster,
+// the server version is used when the discovery fails.
+func SupportsHPAV2(dc discovery.DiscoveryInterface) bool {
+	resources, err := dc.ServerResourcesForGroupVersion(autoscalingv2.SchemeGroupVersion.String())
+	if err == nil {
+		for _, r := range resources.APIResources {
+			if r.Name == "horizontalpodautoscalers" {
+				return true
+			}
+		}
+		return false
+	}
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	logrus.Warningf("discover %s: %v", autoscalingv2.SchemeGroupVersion, err)
+	serverVersion, err := dc.ServerVersion()
+	if err != nil {
+		logrus.Warningf("get kubernetes version: %v, assume autoscaling/v2 is served", err)
+		return true
+	}
+	v, err := utilversion.ParseGeneric(serverVersion.GitVersion)
+	if err != nil {
+		return true
+	}
+	return v.AtLeast(utilversion.MustParseGeneric("v1.23.0"))
+}
+
 // GetClientSet -
 func GetClientSet() kubernetes.Interface {
 	if clientset == nil {
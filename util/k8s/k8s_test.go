@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// capability_id: rainbond.util.k8s.detect-hpa-v2
+func TestSupportsHPAV2(t *testing.T) {
+	newDiscovery := func(groupVersions ...string) *fakediscovery.FakeDiscovery {
+		dc := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+		for _, gv := range groupVersions {
+			dc.Resources = append(dc.Resources, &metav1.APIResourceList{
+				GroupVersion: gv,
+				APIResources: []metav1.APIResource{{Name: "horizontalpodautoscalers", Kind: "HorizontalPodAutoscaler"}},
+			})
+		}
+		return dc
+	}
+
+	if !SupportsHPAV2(newDiscovery("autoscaling/v1", "autoscaling/v2")) {
+		t.Fatal("autoscaling/v2 is served")
+	}
+	if SupportsHPAV2(newDiscovery("autoscaling/v1", "autoscaling/v2beta2")) {
+		t.Fatal("only autoscaling/v2beta2 is served")
+	}
+
+	// the server version decides when the discovery fails
+	for gitVersion, want := range map[string]bool{"v1.22.17": false, "v1.28.2+k3s1": true} {
+		dc := newDiscovery()
+		dc.FakedServerVersion = &version.Info{GitVersion: gitVersion}
+		dc.PrependReactor("get", "resource", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("connection refused")
+		})
+		if got := SupportsHPAV2(dc); got != want {
+			t.Fatalf("version %s: want %v, got %v", gitVersion, want, got)
+		}
+	}
+}
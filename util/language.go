@@ -49,6 +49,10 @@ var translationMetadata = map[string]string{
 	"git project warehouse address format error":     "Git项目仓库地址格式错误",
 	"prepare build code error":                       "准备源码构建失败",
 	"Checkout svn code failed, please make sure the code can be downloaded properly":    "检查svn代码失败，请确保代码可以被正常下载",
+	"Checkout hg code failed, please make sure the code can be downloaded properly":     "检出hg代码失败，请确保代码可以被正常下载",
+	"Download archive failed, please check the archive url and checksum":                "下载源码归档失败，请检查归档地址和校验和",
+	"Pre-build test failed, please check the test logs":                                 "构建前测试失败，请查看测试日志",
+	"Post-build smoke test failed, please check the test logs":                           "构建后冒烟测试失败，请查看测试日志",
 	"Pull image failed, please check if the image is accessible":                        "拉取镜像失败，请排查镜像是否可以访问",
 	"Pull source code failed, please check if the repository is accessible":             "拉取源码失败，请排查仓库是否可以访问",
 	"Build timeout, exceeded maximum build time of 60 minutes, please check build logs": "编译超时，超过最大编译时间60分钟，请查看构建日志",
@@ -61,6 +65,8 @@ var translationMetadata = map[string]string{
 	"Push image to registry failed":                         "推送镜像至镜像仓库失败",
 	"Update version info failed":                            "更新应用版本信息失败",
 	"Update application service version information failed": "更新应用服务版本信息失败",
+	"Image vulnerability check failed":                      "镜像漏洞检查未通过",
+	"Image signature verification failed":                   "镜像签名验证未通过，镜像未签名或签名不可信",
 
 	// Git related errors
 	"Pull code error, authentication required":         "拉取代码发生错误，代码源需要授权访问",
@@ -0,0 +1,338 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package activator
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apisixclientv2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/typed/config/v2"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/util/constants"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// flushInterval the last request time of a component is saved at most once in it
+	flushInterval = 30 * time.Second
+	// readyCheckInterval interval to check whether the woken up component is ready
+	readyCheckInterval = 500 * time.Millisecond
+	// recordType record type of the scaling records created by the activator
+	recordType = "idle"
+)
+
+// appServiceGetter is implemented by the app runtime store
+type appServiceGetter interface {
+	GetAppService(serviceID string) *v1.AppService
+}
+
+// ScaleFunc scales the workload of the component to replicas
+type ScaleFunc func(as v1.AppService, replicas int) error
+
+// wakeCall a wake up in progress, the requests of the same component wait for it
+type wakeCall struct {
+	done chan struct{}
+	err  error
+}
+
+// Activator receives the gateway traffic of the components that enable the idle policy.
+// It records the last request time of the components, wakes up the components scaled to
+// zero and holds the requests until they are ready.
+//
+// While the idle policy is enabled, every request of the component goes through the activator,
+// awake or not, which adds a hop in rbd-worker to the requests. The routes are switched back to
+// the component services when the policy is disabled.
+type Activator struct {
+	dbmanager db.Manager
+	store     appServiceGetter
+	services  corelisters.ServiceLister
+	apisix    apisixclientv2.ApisixV2Interface
+	scale     ScaleFunc
+	timeout   time.Duration
+	transport http.RoundTripper
+	now       func() time.Time
+	// token the gateway sets in the ActivatorTokenHeader, requests without it are rejected
+	token string
+
+	lock     sync.Mutex
+	lastSeen map[string]time.Time
+	flushed  map[string]time.Time
+	waking   map[string]*wakeCall
+	// resolved the hosts checked recently, host to component id
+	resolved map[string]resolvedTarget
+}
+
+// resolvedTarget a target host that passed the checks at time
+type resolvedTarget struct {
+	serviceID string
+	time      time.Time
+}
+
+// New creates an activator, token is shared with the gateway, see EnsureToken
+func New(dbmanager db.Manager, store appServiceGetter, services corelisters.ServiceLister, apisix apisixclientv2.ApisixV2Interface, scale ScaleFunc, timeout time.Duration, token string) *Activator {
+	return &Activator{
+		dbmanager: dbmanager,
+		store:     store,
+		services:  services,
+		apisix:    apisix,
+		scale:     scale,
+		timeout:   timeout,
+		now:       time.Now,
+		lastSeen:  make(map[string]time.Time),
+		flushed:   make(map[string]time.Time),
+		waking:    make(map[string]*wakeCall),
+		resolved:  make(map[string]resolvedTarget),
+		token:     token,
+	}
+}
+
+// ServeHTTP the gateway rewrites the host of the request to <service>.<namespace>:<port>
+// of the target component, the original host is kept in X-Forwarded-Host.
+func (a *Activator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(constants.ActivatorTokenHeader)), []byte(a.token)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	// the token is not passed to the component
+	r.Header.Del(constants.ActivatorTokenHeader)
+	target, serviceID, err := a.resolve(r.Context(), r.Host)
+	if err != nil {
+		logrus.Debugf("activator: %v", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	a.touch(serviceID)
+	defer a.touch(serviceID)
+
+	if err := a.wake(r.Context(), serviceID); err != nil {
+		logrus.Warningf("activator: wake up component %s: %v", serviceID, err)
+		http.Error(w, "component is starting, please retry later", http.StatusServiceUnavailable)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Transport: a.transport,
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = target
+			if host := req.Header.Get("X-Forwarded-Host"); host != "" {
+				req.Host = host
+			}
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// resolve returns the address and component id of the target service. Only the ports of
+// the component services that enable the idle policy and are routed through the activator
+// are accepted, the result is kept for flushInterval.
+func (a *Activator) resolve(ctx context.Context, host string) (string, string, error) {
+	now := a.now()
+	a.lock.Lock()
+	cached, ok := a.resolved[host]
+	a.lock.Unlock()
+	if ok && now.Sub(cached.time) < flushInterval {
+		return host, cached.serviceID, nil
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid host %s", host)
+	}
+	parts := strings.Split(hostname, ".")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid host %s", host)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid host %s", host)
+	}
+	svc, err := a.services.Services(parts[1]).Get(parts[0])
+	if err != nil {
+		return "", "", fmt.Errorf("service %s: %v", hostname, err)
+	}
+	serviceID := svc.Labels["service_id"]
+	if serviceID == "" {
+		return "", "", fmt.Errorf("service %s is not a component service", hostname)
+	}
+	var hasPort bool
+	for _, p := range svc.Spec.Ports {
+		if int(p.Port) == portNum {
+			hasPort = true
+		}
+	}
+	if !hasPort {
+		return "", "", fmt.Errorf("service %s has no port %d", hostname, portNum)
+	}
+	policy, err := a.dbmanager.TenantServiceIdlePolicyDao().GetByServiceID(serviceID)
+	if err != nil || !policy.Enable {
+		return "", "", fmt.Errorf("component %s does not enable the idle policy", serviceID)
+	}
+	// the upstreams through the activator are created by rainbond, see useActivator in the api
+	upstreams, err := a.apisix.ApisixUpstreams(parts[1]).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,service_id=%s", constants.ActivatorUpstreamLabel, serviceID),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("list activator upstreams of component %s: %v", serviceID, err)
+	}
+	for _, upstream := range upstreams.Items {
+		if upstream.Spec != nil && upstream.Spec.UpstreamHost == host {
+			a.lock.Lock()
+			a.resolved[host] = resolvedTarget{serviceID: serviceID, time: now}
+			a.lock.Unlock()
+			return host, serviceID, nil
+		}
+	}
+	return "", "", fmt.Errorf("service %s is not routed through the activator", host)
+}
+
+// touch records a request of the component, the last request time is saved asynchronously
+// so that the leader worker sees the traffic of all the workers.
+func (a *Activator) touch(serviceID string) {
+	now := a.now()
+	a.lock.Lock()
+	a.lastSeen[serviceID] = now
+	if now.Sub(a.flushed[serviceID]) < flushInterval {
+		a.lock.Unlock()
+		return
+	}
+	a.flushed[serviceID] = now
+	a.lock.Unlock()
+
+	go func() {
+		if err := a.dbmanager.TenantServiceIdlePolicyDao().UpdateLastRequestTime(serviceID, now); err != nil {
+			logrus.Warningf("update last request time of component %s: %v", serviceID, err)
+		}
+	}()
+}
+
+// wake starts the component scaled to zero and waits until it is ready. The concurrent
+// requests of the same component share one wake up.
+func (a *Activator) wake(ctx context.Context, serviceID string) error {
+	as := a.store.GetAppService(serviceID)
+	if as == nil || as.IsClosed() {
+		// the components stopped by users are not started by requests
+		return fmt.Errorf("component is closed")
+	}
+	if as.GetReadyReplicas() > 0 {
+		return nil
+	}
+
+	a.lock.Lock()
+	call, ok := a.waking[serviceID]
+	if !ok {
+		call = &wakeCall{done: make(chan struct{})}
+		a.waking[serviceID] = call
+		go func() {
+			call.err = a.doWake(serviceID)
+			a.lock.Lock()
+			delete(a.waking, serviceID)
+			a.lock.Unlock()
+			close(call.done)
+		}()
+	}
+	a.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *Activator) doWake(serviceID string) error {
+	as := a.store.GetAppService(serviceID)
+	if as == nil {
+		return fmt.Errorf("component not found")
+	}
+	if workloadReplicas(as) == 0 {
+		component, err := a.dbmanager.TenantServiceDao().GetServiceByID(serviceID)
+		if err != nil {
+			return fmt.Errorf("get component: %v", err)
+		}
+		replicas := component.Replicas
+		if replicas < 1 {
+			replicas = 1
+		}
+		err = a.scale(*as, replicas)
+		a.record(serviceID, "WakeUp", fmt.Sprintf("the replicas is scaling from 0 to %d by a request", replicas), err)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	ticker := time.NewTicker(readyCheckInterval)
+	defer ticker.Stop()
+	for {
+		if as := a.store.GetAppService(serviceID); as != nil && as.GetReadyReplicas() > 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("component is not ready in %s", a.timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Activator) record(serviceID, reason, desc string, err error) {
+	if err != nil {
+		reason = "Failed" + reason
+		desc = fmt.Sprintf("%s: %v", desc, err)
+	}
+	record := &model.TenantServiceScalingRecords{
+		ServiceID:   serviceID,
+		EventName:   util.NewUUID(),
+		RecordType:  recordType,
+		Reason:      reason,
+		Count:       1,
+		Description: desc,
+		Operator:    "system",
+		LastTime:    a.now(),
+	}
+	if err := a.dbmanager.TenantServiceScalingRecordsDao().AddModel(record); err != nil {
+		logrus.Warningf("save scaling record: %v", err)
+	}
+}
+
+// workloadReplicas the desired replicas of the workload of the component
+func workloadReplicas(as *v1.AppService) int32 {
+	if sts := as.GetStatefulSet(); sts != nil && sts.Spec.Replicas != nil {
+		return *sts.Spec.Replicas
+	}
+	if deploy := as.GetDeployment(); deploy != nil && deploy.Spec.Replicas != nil {
+		return *deploy.Spec.Replicas
+	}
+	return 0
+}
@@ -0,0 +1,354 @@
+package activator
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	apisixfake "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/dao"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util/constants"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeStore struct {
+	lock     sync.Mutex
+	services map[string]*v1.AppService
+}
+
+func (f *fakeStore) GetAppService(serviceID string) *v1.AppService {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.services[serviceID]
+}
+
+func (f *fakeStore) set(as *v1.AppService) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.services[as.ServiceID] = as
+}
+
+// testManager serves the daos used by the activator, other methods of db.Manager are not called
+type testManager struct {
+	db.Manager
+	policyDao  dao.TenantServiceIdlePolicyDao
+	recordDao  dao.TenantServiceScalingRecordsDao
+	serviceDao dao.TenantServiceDao
+	ruleDao    dao.TenantServceAutoscalerRulesDao
+}
+
+func (m *testManager) TenantServiceIdlePolicyDao() dao.TenantServiceIdlePolicyDao {
+	return m.policyDao
+}
+
+func (m *testManager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
+	return m.recordDao
+}
+
+func (m *testManager) TenantServiceDao() dao.TenantServiceDao {
+	return m.serviceDao
+}
+
+func (m *testManager) TenantServceAutoscalerRulesDao() dao.TenantServceAutoscalerRulesDao {
+	return m.ruleDao
+}
+
+// the generated mock of the service dao is out of date, so a minimal fake is used
+type fakeServiceDao struct {
+	dao.TenantServiceDao
+	component *model.TenantServices
+}
+
+func (f *fakeServiceDao) GetServiceByID(serviceID string) (*model.TenantServices, error) {
+	return f.component, nil
+}
+
+// the generated mock of the autoscaler rules dao is out of date, so a minimal fake is used
+type fakeAutoscalerRulesDao struct {
+	dao.TenantServceAutoscalerRulesDao
+	rules map[string][]*model.TenantServiceAutoscalerRules
+}
+
+func (f *fakeAutoscalerRulesDao) ListEnableOnesByServiceID(serviceID string) ([]*model.TenantServiceAutoscalerRules, error) {
+	return f.rules[serviceID], nil
+}
+
+func newAppService(serviceID string, replicas, ready int32) *v1.AppService {
+	as := &v1.AppService{}
+	as.ServiceID = serviceID
+	as.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}})
+	as.SetDeployment(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceID + "-deployment", Namespace: "tenant", ResourceVersion: "1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+	})
+	return as
+}
+
+func newServiceLister(t *testing.T, services ...*corev1.Service) corelisters.ServiceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, svc := range services {
+		if err := indexer.Add(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return corelisters.NewServiceLister(indexer)
+}
+
+// capability_id: rainbond.worker.activator.wake-up
+func TestActivatorWakeUpAndProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(constants.ActivatorTokenHeader) != "" {
+			io.WriteString(w, "token leaked to the component")
+			return
+		}
+		io.WriteString(w, "hello from "+r.Host)
+	}))
+	defer backend.Close()
+	backendAddr := backend.Listener.Addr().String()
+	_, portStr, _ := net.SplitHostPort(backendAddr)
+	port, _ := strconv.Atoi(portStr)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	policyDao := dao.NewMockTenantServiceIdlePolicyDao(ctrl)
+	policyDao.EXPECT().UpdateLastRequestTime("sid", gomock.Any()).Return(nil).AnyTimes()
+	policyDao.EXPECT().GetByServiceID("sid").Return(&model.TenantServiceIdlePolicy{ServiceID: "sid", Enable: true}, nil).AnyTimes()
+	recordDao := dao.NewMockTenantServiceScalingRecordsDao(ctrl)
+	var reasons []string
+	recordDao.EXPECT().AddModel(gomock.Any()).DoAndReturn(func(mo model.Interface) error {
+		reasons = append(reasons, mo.(*model.TenantServiceScalingRecords).Reason)
+		return nil
+	}).Times(1)
+	dbmanager := &testManager{
+		policyDao:  policyDao,
+		recordDao:  recordDao,
+		serviceDao: &fakeServiceDao{component: &model.TenantServices{Replicas: 2}},
+	}
+
+	store := &fakeStore{services: map[string]*v1.AppService{"sid": newAppService("sid", 0, 0)}}
+	services := newServiceLister(t,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "tenant", Labels: map[string]string{"service_id": "sid"}},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: int32(port)}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443}}},
+		},
+	)
+	var scaled []int
+	var scaleLock sync.Mutex
+	scale := func(as v1.AppService, replicas int) error {
+		scaleLock.Lock()
+		scaled = append(scaled, replicas)
+		scaleLock.Unlock()
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			store.set(newAppService("sid", int32(replicas), int32(replicas)))
+		}()
+		return nil
+	}
+	apisix := apisixfake.NewSimpleClientset(&v2.ApisixUpstream{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-" + portStr + "-activator",
+			Namespace: "tenant",
+			Labels:    map[string]string{constants.ActivatorUpstreamLabel: "true", "service_id": "sid"},
+		},
+		Spec: &v2.ApisixUpstreamSpec{ApisixUpstreamConfig: v2.ApisixUpstreamConfig{UpstreamHost: "web.tenant:" + portStr}},
+	}).ApisixV2()
+	a := New(dbmanager, store, services, apisix, scale, 5*time.Second, "token")
+	a.transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial(network, backendAddr)
+		},
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 3)
+	bodies := make([]string, 3)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = "web.tenant:" + portStr
+			req.Header.Set("X-Forwarded-Host", "www.example.com")
+			req.Header.Set(constants.ActivatorTokenHeader, "token")
+			rec := httptest.NewRecorder()
+			a.ServeHTTP(rec, req)
+			codes[i], bodies[i] = rec.Code, rec.Body.String()
+		}(i)
+	}
+	wg.Wait()
+	for i := range codes {
+		if codes[i] != http.StatusOK || bodies[i] != "hello from www.example.com" {
+			t.Fatalf("request %d: unexpected response %d %q", i, codes[i], bodies[i])
+		}
+	}
+	// concurrent requests share one wake up to the replicas of the component
+	if len(scaled) != 1 || scaled[0] != 2 {
+		t.Fatalf("unexpected scaling %v", scaled)
+	}
+	if len(reasons) != 1 || reasons[0] != "WakeUp" {
+		t.Fatalf("unexpected records %v", reasons)
+	}
+
+	// only the ports of component services are proxied
+	for _, host := range []string{"kubernetes.default:443", "web.tenant:1", "web:80", "missing.tenant:80"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		req.Header.Set(constants.ActivatorTokenHeader, "token")
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("host %s: want 404, got %d", host, rec.Code)
+		}
+	}
+}
+
+// capability_id: rainbond.worker.activator.scale-idle
+func TestScaleIdle(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	recent := now.Add(-5 * time.Minute)
+	longAgo := now.Add(-time.Hour)
+	policies := []*model.TenantServiceIdlePolicy{
+		{ServiceID: "idle", Enable: true, IdleMinutes: 30, LastRequestTime: &longAgo, UpdateTime: longAgo},
+		{ServiceID: "busy", Enable: true, IdleMinutes: 30, LastRequestTime: &recent, UpdateTime: longAgo},
+		{ServiceID: "updated", Enable: true, IdleMinutes: 30, UpdateTime: recent},
+		{ServiceID: "no-route", Enable: true, IdleMinutes: 30, UpdateTime: longAgo},
+		{ServiceID: "stopped", Enable: true, IdleMinutes: 30, UpdateTime: longAgo},
+		{ServiceID: "seen", Enable: true, IdleMinutes: 30, UpdateTime: longAgo},
+		{ServiceID: "autoscaled", Enable: true, IdleMinutes: 30, UpdateTime: longAgo},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	policyDao := dao.NewMockTenantServiceIdlePolicyDao(ctrl)
+	policyDao.EXPECT().ListEnableOnes().Return(policies, nil)
+	recordDao := dao.NewMockTenantServiceScalingRecordsDao(ctrl)
+	recordDao.EXPECT().AddModel(gomock.Any()).DoAndReturn(func(mo model.Interface) error {
+		record := mo.(*model.TenantServiceScalingRecords)
+		if record.ServiceID != "idle" || record.Reason != "ScaledToZero" || record.RecordType != "idle" {
+			t.Fatalf("unexpected record %+v", record)
+		}
+		return nil
+	})
+	ruleDao := &fakeAutoscalerRulesDao{rules: map[string][]*model.TenantServiceAutoscalerRules{
+		"autoscaled": {{RuleID: "rule", ServiceID: "autoscaled", Enable: true}},
+	}}
+	dbmanager := &testManager{policyDao: policyDao, recordDao: recordDao, ruleDao: ruleDao}
+
+	store := &fakeStore{services: map[string]*v1.AppService{
+		"idle":       newAppService("idle", 2, 2),
+		"busy":       newAppService("busy", 2, 2),
+		"updated":    newAppService("updated", 2, 2),
+		"no-route":   newAppService("no-route", 2, 2),
+		"stopped":    newAppService("stopped", 0, 0),
+		"seen":       newAppService("seen", 2, 2),
+		"autoscaled": newAppService("autoscaled", 2, 2),
+	}}
+	var upstreams []runtime.Object
+	for _, id := range []string{"idle", "busy", "updated", "stopped", "seen", "autoscaled"} {
+		upstreams = append(upstreams, &v2.ApisixUpstream{ObjectMeta: metav1.ObjectMeta{
+			Name:      id + "-80-activator",
+			Namespace: "tenant",
+			Labels:    map[string]string{constants.ActivatorUpstreamLabel: "true", "service_id": id},
+		}})
+	}
+	apisix := apisixfake.NewSimpleClientset(upstreams...).ApisixV2()
+
+	var scaled []string
+	scale := func(as v1.AppService, replicas int) error {
+		if replicas != 0 {
+			t.Fatalf("want scaling to zero, got %d", replicas)
+		}
+		scaled = append(scaled, as.ServiceID)
+		return nil
+	}
+	a := New(dbmanager, store, newServiceLister(t), apisix, scale, time.Minute, "token")
+	a.now = func() time.Time { return now }
+	// a request received by this worker but not saved yet
+	a.lastSeen["seen"] = recent
+
+	a.scaleIdle(context.Background())
+	if len(scaled) != 1 || scaled[0] != "idle" {
+		t.Fatalf("unexpected scaled components %v", scaled)
+	}
+}
+
+// capability_id: rainbond.worker.activator.wake-up
+func TestActivatorRejectsUnknownTargets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	policyDao := dao.NewMockTenantServiceIdlePolicyDao(ctrl)
+	policyDao.EXPECT().GetByServiceID("on").Return(&model.TenantServiceIdlePolicy{ServiceID: "on", Enable: true}, nil).AnyTimes()
+	policyDao.EXPECT().GetByServiceID("off").Return(&model.TenantServiceIdlePolicy{ServiceID: "off"}, nil).AnyTimes()
+	dbmanager := &testManager{policyDao: policyDao}
+	services := newServiceLister(t,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "on", Namespace: "tenant", Labels: map[string]string{"service_id": "on"}},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}, {Port: 81}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "off", Namespace: "tenant", Labels: map[string]string{"service_id": "off"}},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+		},
+	)
+	var upstreams []runtime.Object
+	for _, host := range []string{"on.tenant:80", "off.tenant:80"} {
+		serviceID := host[:strings.Index(host, ".")]
+		upstreams = append(upstreams, &v2.ApisixUpstream{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      serviceID + "-80-activator",
+				Namespace: "tenant",
+				Labels:    map[string]string{constants.ActivatorUpstreamLabel: "true", "service_id": serviceID},
+			},
+			Spec: &v2.ApisixUpstreamSpec{ApisixUpstreamConfig: v2.ApisixUpstreamConfig{UpstreamHost: host}},
+		})
+	}
+	a := New(dbmanager, &fakeStore{}, services, apisixfake.NewSimpleClientset(upstreams...).ApisixV2(), nil, time.Second, "token")
+
+	serve := func(host, token string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		if token != "" {
+			req.Header.Set(constants.ActivatorTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// requests not sent by the gateway are rejected
+	for _, token := range []string{"", "wrong"} {
+		if code := serve("on.tenant:80", token); code != http.StatusForbidden {
+			t.Fatalf("token %q: want 403, got %d", token, code)
+		}
+	}
+	// the policy is disabled, or the port is not routed through the activator
+	for _, host := range []string{"off.tenant:80", "on.tenant:81"} {
+		if code := serve(host, "token"); code != http.StatusNotFound {
+			t.Fatalf("host %s: want 404, got %d", host, code)
+		}
+	}
+	if _, serviceID, err := a.resolve(context.Background(), "on.tenant:80"); err != nil || serviceID != "on" {
+		t.Fatalf("unexpected resolve result %s %v", serviceID, err)
+	}
+}
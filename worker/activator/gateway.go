@@ -0,0 +1,255 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package activator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	apisixclientv2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/typed/config/v2"
+	"github.com/goodrain/rainbond/util/constants"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// proxyRewritePlugin the apisix plugin that adds the token header to the requests of the route
+const proxyRewritePlugin = "proxy-rewrite"
+
+// EnsureToken returns the token shared by the gateway and the activator, it is generated
+// and saved in the secret on the first start.
+func EnsureToken(ctx context.Context, secrets typedcorev1.SecretInterface) (string, error) {
+	if token, err := GetToken(ctx, secrets); err == nil || !k8serrors.IsNotFound(err) {
+		return token, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   constants.ActivatorTokenSecret,
+			Labels: map[string]string{"creator": "Rainbond"},
+		},
+		Data: map[string][]byte{"token": []byte(hex.EncodeToString(buf))},
+	}
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return "", err
+	}
+	// another worker may have created it first
+	return GetToken(ctx, secrets)
+}
+
+// GetToken returns the token shared by the gateway and the activator
+func GetToken(ctx context.Context, secrets typedcorev1.SecretInterface) (string, error) {
+	secret, err := secrets.Get(ctx, constants.ActivatorTokenSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	token := string(secret.Data["token"])
+	if token == "" {
+		return "", fmt.Errorf("secret %s has no token", constants.ActivatorTokenSecret)
+	}
+	return token, nil
+}
+
+// WithToken sets the token header by the proxy-rewrite plugin of the route. The header is sent
+// to every backend of the route, so the routes through the activator have no other backends.
+func WithToken(plugins []v2.ApisixRoutePlugin, token string) []v2.ApisixRoutePlugin {
+	for i, plugin := range plugins {
+		if plugin.Name != proxyRewritePlugin {
+			continue
+		}
+		if !plugin.Enable || plugin.Config == nil {
+			// the config of a disabled plugin is not in use, only the token header is set
+			plugin.Config = v2.ApisixRoutePluginConfig{}
+		}
+		headers, _ := plugin.Config["headers"].(map[string]interface{})
+		if headers == nil {
+			headers = map[string]interface{}{"set": map[string]interface{}{}}
+		}
+		if set, ok := operationHeaders(headers); ok {
+			set[constants.ActivatorTokenHeader] = token
+			headers["set"] = set
+		} else {
+			// the headers are set directly in the deprecated format
+			headers[constants.ActivatorTokenHeader] = token
+		}
+		plugin.Config["headers"] = headers
+		plugin.Enable = true
+		plugins[i] = plugin
+		return plugins
+	}
+	return append(plugins, v2.ApisixRoutePlugin{
+		Name:   proxyRewritePlugin,
+		Enable: true,
+		Config: v2.ApisixRoutePluginConfig{
+			"headers": map[string]interface{}{
+				"set": map[string]interface{}{constants.ActivatorTokenHeader: token},
+			},
+		},
+	})
+}
+
+// WithoutToken removes the token header from the proxy-rewrite plugin of the route, the plugin
+// is removed when it sets nothing else.
+func WithoutToken(plugins []v2.ApisixRoutePlugin) []v2.ApisixRoutePlugin {
+	var res []v2.ApisixRoutePlugin
+	for _, plugin := range plugins {
+		if plugin.Name == proxyRewritePlugin && plugin.Config != nil {
+			if headers, ok := plugin.Config["headers"].(map[string]interface{}); ok {
+				if set, ok := operationHeaders(headers); ok {
+					delete(set, constants.ActivatorTokenHeader)
+					if len(set) == 0 {
+						delete(headers, "set")
+					}
+				} else {
+					delete(headers, constants.ActivatorTokenHeader)
+				}
+				if len(headers) == 0 {
+					delete(plugin.Config, "headers")
+				}
+			}
+			if len(plugin.Config) == 0 {
+				continue
+			}
+		}
+		res = append(res, plugin)
+	}
+	return res
+}
+
+// operationHeaders returns the set operation of the headers, ok is false when the headers
+// use the deprecated format without the add, set and remove operations.
+func operationHeaders(headers map[string]interface{}) (map[string]interface{}, bool) {
+	_, hasAdd := headers["add"]
+	_, hasSet := headers["set"]
+	_, hasRemove := headers["remove"]
+	if !hasAdd && !hasSet && !hasRemove {
+		if len(headers) == 0 {
+			return make(map[string]interface{}), true
+		}
+		return nil, false
+	}
+	set, _ := headers["set"].(map[string]interface{})
+	if set == nil {
+		set = make(map[string]interface{})
+	}
+	return set, true
+}
+
+// RestoreDirectRoutes switches the routes of the component from the activator back to the
+// component services, it is called when the idle policy of the component is disabled. The
+// other activator upstreams of the same route are restored as well, so the token is never
+// sent to a component, and the activator upstreams no route references are deleted.
+func RestoreDirectRoutes(ctx context.Context, c apisixclientv2.ApisixV2Interface, namespace, serviceID string) error {
+	list, err := c.ApisixUpstreams(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: constants.ActivatorUpstreamLabel + "=true",
+	})
+	if err != nil {
+		return err
+	}
+	upstreams := make(map[string]*v2.ApisixUpstream)
+	var found bool
+	for i := range list.Items {
+		upstream := &list.Items[i]
+		upstreams[upstream.Name] = upstream
+		found = found || upstream.Labels["service_id"] == serviceID
+	}
+	if !found {
+		return nil
+	}
+	routes, err := c.ApisixRoutes(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		var changed bool
+		for j := range route.Spec.HTTP {
+			if restoreHTTP(&route.Spec.HTTP[j], upstreams, namespace, serviceID) {
+				changed = true
+			}
+			for _, ref := range route.Spec.HTTP[j].Upstreams {
+				referenced[ref.Name] = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, err := c.ApisixRoutes(namespace).Update(ctx, route, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update route %s: %v", route.Name, err)
+		}
+	}
+	for name := range upstreams {
+		if referenced[name] {
+			continue
+		}
+		if err := c.ApisixUpstreams(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("delete activator upstream %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// restoreHTTP replaces the activator upstreams of the route rule with the component services
+// when the rule routes the component through the activator
+func restoreHTTP(rule *v2.ApisixRouteHTTP, upstreams map[string]*v2.ApisixUpstream, namespace, serviceID string) bool {
+	var matched bool
+	for _, ref := range rule.Upstreams {
+		if upstream, ok := upstreams[ref.Name]; ok && upstream.Labels["service_id"] == serviceID {
+			matched = true
+		}
+	}
+	if !matched {
+		return false
+	}
+	var refs []v2.ApisixRouteUpstreamReference
+	for _, ref := range rule.Upstreams {
+		upstream, ok := upstreams[ref.Name]
+		if !ok || upstream.Spec == nil {
+			refs = append(refs, ref)
+			continue
+		}
+		// the upstream host is <service>.<namespace>:<port>, see useActivator in the api
+		host, port, err := net.SplitHostPort(upstream.Spec.UpstreamHost)
+		portNum, perr := strconv.Atoi(port)
+		if err != nil || perr != nil {
+			refs = append(refs, ref)
+			continue
+		}
+		rule.Backends = append(rule.Backends, v2.ApisixRouteHTTPBackend{
+			ServiceName: strings.TrimSuffix(host, "."+namespace),
+			ServicePort: intstr.FromInt(portNum),
+			Weight:      ref.Weight,
+		})
+	}
+	rule.Upstreams = refs
+	rule.Plugins = WithoutToken(rule.Plugins)
+	return true
+}
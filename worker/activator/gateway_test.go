@@ -0,0 +1,110 @@
+package activator
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	apisixfake "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/fake"
+	"github.com/goodrain/rainbond/util/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// capability_id: rainbond.worker.activator.wake-up
+func TestEnsureToken(t *testing.T) {
+	secrets := fake.NewSimpleClientset().CoreV1().Secrets("rbd-system")
+	token, err := EnsureToken(context.Background(), secrets)
+	if err != nil || len(token) != 64 {
+		t.Fatalf("unexpected token %q err=%v", token, err)
+	}
+	again, err := EnsureToken(context.Background(), secrets)
+	if err != nil || again != token {
+		t.Fatalf("the token should be kept, got %q err=%v", again, err)
+	}
+}
+
+// capability_id: rainbond.worker.activator.wake-up
+func TestTokenHeaderPlugin(t *testing.T) {
+	plugins := WithToken(nil, "secret")
+	headers := plugins[0].Config["headers"].(map[string]interface{})["set"].(map[string]interface{})
+	if len(plugins) != 1 || !plugins[0].Enable || headers[constants.ActivatorTokenHeader] != "secret" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	if plugins = WithoutToken(plugins); len(plugins) != 0 {
+		t.Fatalf("the plugin only setting the token should be removed, got %+v", plugins)
+	}
+
+	// the headers set by users are kept
+	plugins = WithToken([]v2.ApisixRoutePlugin{{Name: "proxy-rewrite", Enable: true, Config: v2.ApisixRoutePluginConfig{
+		"uri":     "/api",
+		"headers": map[string]interface{}{"add": map[string]interface{}{"X-A": "1"}},
+	}}}, "secret")
+	headers = plugins[0].Config["headers"].(map[string]interface{})
+	if headers["set"].(map[string]interface{})[constants.ActivatorTokenHeader] != "secret" || headers["add"] == nil {
+		t.Fatalf("unexpected headers %+v", headers)
+	}
+	plugins = WithoutToken(plugins)
+	headers = plugins[0].Config["headers"].(map[string]interface{})
+	if len(plugins) != 1 || plugins[0].Config["uri"] != "/api" || headers["set"] != nil || headers["add"] == nil {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+}
+
+// capability_id: rainbond.worker.activator.wake-up
+func TestRestoreDirectRoutes(t *testing.T) {
+	weight := 30
+	upstream := func(name, serviceID, host string) *v2.ApisixUpstream {
+		return &v2.ApisixUpstream{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant",
+				Labels: map[string]string{constants.ActivatorUpstreamLabel: "true", "service_id": serviceID}},
+			Spec: &v2.ApisixUpstreamSpec{ApisixUpstreamConfig: v2.ApisixUpstreamConfig{UpstreamHost: host}},
+		}
+	}
+	route := &v2.ApisixRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "tenant"},
+		Spec: v2.ApisixRouteSpec{HTTP: []v2.ApisixRouteHTTP{{
+			Name: "rule",
+			Upstreams: []v2.ApisixRouteUpstreamReference{
+				{Name: "web-80-activator", Weight: &weight},
+				{Name: "api-8080-activator"},
+			},
+			Plugins: WithToken(nil, "secret"),
+		}}},
+	}
+	other := &v2.ApisixRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "tenant"},
+		Spec: v2.ApisixRouteSpec{HTTP: []v2.ApisixRouteHTTP{{
+			Name:      "rule",
+			Upstreams: []v2.ApisixRouteUpstreamReference{{Name: "job-80-activator"}},
+		}}},
+	}
+	c := apisixfake.NewSimpleClientset(route, other,
+		upstream("web-80-activator", "web", "web.tenant:80"),
+		upstream("api-8080-activator", "api", "api.tenant:8080"),
+		upstream("job-80-activator", "job", "job.tenant:80"),
+	).ApisixV2()
+
+	if err := RestoreDirectRoutes(context.Background(), c, "tenant", "web"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ApisixRoutes("tenant").Get(context.Background(), "route", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := got.Spec.HTTP[0]
+	if len(rule.Upstreams) != 0 || len(rule.Backends) != 2 || len(rule.Plugins) != 0 {
+		t.Fatalf("the route should use the component services, got %+v", rule)
+	}
+	if rule.Backends[0].ServiceName != "web" || rule.Backends[0].ServicePort.IntValue() != 80 || *rule.Backends[0].Weight != 30 ||
+		rule.Backends[1].ServiceName != "api" || rule.Backends[1].ServicePort.IntValue() != 8080 {
+		t.Fatalf("unexpected backends %+v", rule.Backends)
+	}
+	// the upstreams no route references are deleted, the other routes are not changed
+	for name, want := range map[string]bool{"web-80-activator": false, "api-8080-activator": false, "job-80-activator": true} {
+		_, err := c.ApisixUpstreams("tenant").Get(context.Background(), name, metav1.GetOptions{})
+		if (err == nil) != want {
+			t.Fatalf("upstream %s exists=%v, want %v", name, err == nil, want)
+		}
+	}
+}
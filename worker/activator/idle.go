@@ -0,0 +1,107 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package activator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goodrain/rainbond/util/constants"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// idleCheckInterval the idle policies have a minute precision
+const idleCheckInterval = time.Minute
+
+// StartIdleScaler scales the idle components to zero periodically until the context is done,
+// only the leader worker does the scaling.
+func (a *Activator) StartIdleScaler(ctx context.Context, isLeader func() bool) {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if isLeader() {
+				a.scaleIdle(ctx)
+			}
+		}
+	}
+}
+
+func (a *Activator) scaleIdle(ctx context.Context) {
+	policies, err := a.dbmanager.TenantServiceIdlePolicyDao().ListEnableOnes()
+	if err != nil {
+		logrus.Warningf("list idle policies: %v", err)
+		return
+	}
+	now := a.now()
+	for _, policy := range policies {
+		// the idle time is counted from the latest request or the policy update
+		since := policy.UpdateTime
+		if policy.LastRequestTime != nil && policy.LastRequestTime.After(since) {
+			since = *policy.LastRequestTime
+		}
+		a.lock.Lock()
+		if seen, ok := a.lastSeen[policy.ServiceID]; ok && seen.After(since) {
+			since = seen
+		}
+		a.lock.Unlock()
+		if now.Sub(since) < time.Duration(policy.IdleMinutes)*time.Minute {
+			continue
+		}
+
+		as := a.store.GetAppService(policy.ServiceID)
+		if as == nil || as.IsClosed() || workloadReplicas(as) == 0 {
+			continue
+		}
+		// without the activator in front of it, a component scaled to zero can not be woken up
+		upstreams, err := a.apisix.ApisixUpstreams(as.GetNamespace()).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=true,service_id=%s", constants.ActivatorUpstreamLabel, policy.ServiceID),
+		})
+		if err != nil {
+			logrus.Warningf("list activator upstreams of component %s: %v", policy.ServiceID, err)
+			continue
+		}
+		if len(upstreams.Items) == 0 {
+			logrus.Debugf("component %s has no gateway route through the activator, skip scaling to zero", policy.ServiceID)
+			continue
+		}
+		// the hpa keeps the replicas above its min replicas, it would scale the component up again at once
+		rules, err := a.dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByServiceID(policy.ServiceID)
+		if err != nil {
+			logrus.Warningf("list autoscaler rules of component %s: %v", policy.ServiceID, err)
+			continue
+		}
+		if len(rules) > 0 {
+			logrus.Debugf("component %s has enabled autoscaler rules, skip scaling to zero", policy.ServiceID)
+			continue
+		}
+
+		// the replicas of the component is kept, it is restored by the wake up
+		err = a.scale(*as, 0)
+		a.record(policy.ServiceID, "ScaledToZero", fmt.Sprintf("the replicas is scaling from %d to 0 after %d idle minutes", workloadReplicas(as), policy.IdleMinutes), err)
+		if err != nil {
+			logrus.Warningf("scale idle component %s to zero: %v", policy.ServiceID, err)
+		}
+	}
+}
@@ -173,6 +173,8 @@ func (c *Builder) listStaticEndpoints(componentID string) ([]*v1alpha1.ThirdComp
 		res = append(res, &v1alpha1.ThirdComponentEndpoint{
 			Address: ep.GetAddress(),
 			Name:    ep.UUID,
+			Weight:  ep.Weight,
+			Zone:    ep.Zone,
 		})
 	}
 	return res, nil
@@ -265,9 +267,12 @@ func (c *Builder) createProbe(componentID string) (*v1alpha1.Probe, error) {
 		SuccessThreshold: int32(probe.SuccessThreshold),
 		FailureThreshold: int32(probe.FailureThreshold),
 	}
-	if probe.Scheme == "tcp" {
+	switch probe.Scheme {
+	case "tcp":
 		p.TCPSocket = c.createTCPGetAction(probe)
-	} else {
+	case "grpc":
+		p.GRPC = c.createGRPCAction(probe)
+	default:
 		p.HTTPGet = c.createHTTPGetAction(probe)
 	}
 
@@ -303,3 +308,12 @@ func (c *Builder) createHTTPGetAction(probe *dbmodel.TenantServiceProbe) *v1alph
 func (c *Builder) createTCPGetAction(probe *dbmodel.TenantServiceProbe) *v1alpha1.TCPSocketAction {
 	return &v1alpha1.TCPSocketAction{}
 }
+
+func (c *Builder) createGRPCAction(probe *dbmodel.TenantServiceProbe) *v1alpha1.GRPCAction {
+	return &v1alpha1.GRPCAction{
+		Service:            probe.GRPCService,
+		TLS:                probe.GRPCTLS,
+		InsecureSkipVerify: probe.GRPCInsecureSkipVerify,
+		ServerName:         probe.GRPCServerName,
+	}
+}
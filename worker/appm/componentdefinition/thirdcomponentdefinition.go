@@ -63,6 +63,8 @@ parameter: {
 		name?:         string
 		protocol?:     string
 		clientSecret?: string
+		weight?:       int
+		zone?:         string
 	}]
 	port?: [...{
 		name:   string
@@ -80,6 +82,12 @@ parameter: {
 		}
 		tcpSocket?:{
 		}
+		grpc?: {
+			service?: string
+			tls?: bool
+			insecureSkipVerify?: bool
+			serverName?: string
+		}
 		timeoutSeconds?: >0 & <=65533
 		periodSeconds?: >0 & <=65533
 		successThreshold?: >0 & <=65533
@@ -97,7 +105,7 @@ var thirdComponentDefine = v1alpha1.ComponentDefinition{
 		Name: thirdComponentDefineName,
 		Annotations: map[string]string{
 			"definition.oam.dev/description": "Rainbond built-in component type that defines third-party service components.",
-			"version":                        "0.2",
+			"version":                        "0.4",
 		},
 	},
 	Spec: v1alpha1.ComponentDefinitionSpec{
@@ -0,0 +1,88 @@
+package componentdefinition
+
+import (
+	"path/filepath"
+	"testing"
+
+	cdb "github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	mysqldao "github.com/goodrain/rainbond/db/mysql/dao"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type probeTestManager struct {
+	cdb.Manager
+	probeDao dbdao.ServiceProbeDao
+}
+
+func (m probeTestManager) ServiceProbeDao() dbdao.ServiceProbeDao {
+	return m.probeDao
+}
+
+// capability_id: rainbond.worker.thirdcomponent.prober.grpc-probe
+func TestThirdComponentGRPCProbe(t *testing.T) {
+	gdb, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "probe.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gdb.Close()
+	if err := gdb.AutoMigrate(&dbmodel.TenantServiceProbe{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	isUsed := 1
+	probe := &dbmodel.TenantServiceProbe{
+		ServiceID:        "component-1",
+		ProbeID:          "probe-1",
+		Mode:             "readiness",
+		Scheme:           "grpc",
+		IsUsed:           &isUsed,
+		PeriodSecond:     5,
+		TimeoutSecond:    2,
+		FailureThreshold: 3,
+		SuccessThreshold: 1,
+		GRPCService:      "orders",
+		GRPCTLS:          true,
+		GRPCServerName:   "orders.example.com",
+	}
+	if err := gdb.Create(probe).Error; err != nil {
+		t.Fatal(err)
+	}
+	cdb.SetTestManager(probeTestManager{probeDao: &mysqldao.ServiceProbeDaoImpl{DB: gdb}})
+	defer cdb.SetTestManager(nil)
+
+	p, err := (&Builder{logger: logrus.WithField("WHO", "Builder")}).createProbe("component-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GRPC == nil || p.HTTPGet != nil || p.TCPSocket != nil {
+		t.Fatalf("grpc scheme should create grpc handler, got %+v", p.Handler)
+	}
+	if p.GRPC.Service != "orders" || !p.GRPC.TLS || p.GRPC.InsecureSkipVerify || p.GRPC.ServerName != "orders.example.com" {
+		t.Fatalf("unexpected grpc action %+v", p.GRPC)
+	}
+
+	// 探针通过组件定义模板渲染到 ThirdComponent 中
+	as := &v1.AppService{}
+	as.ServiceID = "component-1"
+	as.TenantID = "tenant-1"
+	as.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-1"}})
+	manifests, err := NewTemplateContext(as, cueTemplate, &ThirdComponentProperties{Probe: p, Port: []*ThirdComponentPort{}}).GenerateComponentManifests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("unexpected manifests %v", manifests)
+	}
+	service, _, _ := unstructured.NestedString(manifests[0].Object, "spec", "probe", "grpc", "service")
+	tls, _, _ := unstructured.NestedBool(manifests[0].Object, "spec", "probe", "grpc", "tls")
+	if service != "orders" || !tls {
+		t.Fatalf("unexpected rendered probe %v", manifests[0].Object["spec"])
+	}
+}
@@ -77,6 +77,8 @@ type Manager struct {
 	store         store.Storer
 	lock          sync.Mutex
 	kubevirtCli   kubecli.KubevirtClient
+	// restoring the vm restore controller of each component under restore
+	restoring map[string]string
 }
 
 // NewManager new manager
@@ -91,6 +93,7 @@ func NewManager(store store.Storer) *Manager {
 		controllers:   make(map[string]Controller),
 		store:         store,
 		kubevirtCli:   k8s.Default().KubevirtCli,
+		restoring:     make(map[string]string),
 	}
 }
 
@@ -199,6 +202,15 @@ func (m *Manager) StartController(controllerType TypeController, apps ...v1.AppS
 	}
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	switch controllerType {
+	case TypeStartController, TypeRestartController, TypeUpgradeController, TypeScalingController:
+		// the vm under restore is started by the restore controller with the restored disks
+		for _, app := range apps {
+			if _, ok := m.restoring[app.ServiceID]; ok {
+				return ErrVMRestoring
+			}
+		}
+	}
 	m.controllers[controllerID] = controller
 	go controller.Begin()
 	return nil
@@ -208,6 +220,11 @@ func (m *Manager) callback(controllerID string, err error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	delete(m.controllers, controllerID)
+	for serviceID, id := range m.restoring {
+		if id == controllerID {
+			delete(m.restoring, serviceID)
+		}
+	}
 }
 
 type sequencelist []sequence
@@ -21,7 +21,6 @@ package controller
 import (
 	"context"
 	k8sutil "github.com/goodrain/rainbond/util/k8s"
-	utilversion "k8s.io/apimachinery/pkg/util/version"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -57,7 +56,7 @@ func (a *refreshXPAController) Begin() {
 }
 
 func (a *refreshXPAController) applyOne(clientset kubernetes.Interface, app *v1.AppService) error {
-	if k8sutil.GetKubeVersion().AtLeast(utilversion.MustParseSemantic("v1.23.0")) {
+	if k8sutil.IsHPAV2Supported() {
 		for _, hpa := range app.GetHPAs() {
 			f.EnsureHPA(hpa, clientset)
 		}
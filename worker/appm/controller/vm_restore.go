@@ -0,0 +1,362 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/worker/appm/conversion"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/goodrain/rainbond/worker/appm/volume"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	snapshotv1 "kubevirt.io/api/snapshot/v1beta1"
+)
+
+// vmRestoreTimeout max time waiting for VirtualMachineRestore to complete
+var vmRestoreTimeout = time.Minute * 30
+
+// vmRestorePollInterval interval of checking restore and vm deletion progress
+var vmRestorePollInterval = time.Second * 3
+
+// ErrVMRestoring the vm of the component is being restored from a snapshot
+var ErrVMRestoring = fmt.Errorf("the vm is being restored from a snapshot")
+
+// VMRestoreOption vm snapshot restore option
+type VMRestoreOption struct {
+	// RestoreName name of the VirtualMachineRestore resource
+	RestoreName string
+	// SnapshotName name of the VirtualMachineSnapshot, in the namespace of the target component
+	SnapshotName string
+	// SourceServiceID the component the snapshot was taken from,
+	// it is different from the target component when restoring as a new component
+	SourceServiceID string
+}
+
+// vmRestoreController restores the vm of a component from a VirtualMachineSnapshot.
+// The vm is stopped and started through the stop and start controller, the restored
+// disks are recorded on the component so that later starts keep using them.
+type vmRestoreController struct {
+	stopChan     chan struct{}
+	controllerID string
+	appService   v1.AppService
+	option       VMRestoreOption
+	manager      *Manager
+	ctx          context.Context
+}
+
+// RestoreVMController create and start vm snapshot restore controller
+func (m *Manager) RestoreVMController(option VMRestoreOption, app v1.AppService) error {
+	if app.GetVirtualMachine() == nil {
+		return fmt.Errorf("component %s is not a vm", app.ServiceAlias)
+	}
+	controllerID := util.NewUUID()
+	controller := &vmRestoreController{
+		controllerID: controllerID,
+		appService:   app,
+		option:       option,
+		manager:      m,
+		stopChan:     make(chan struct{}),
+		ctx:          context.Background(),
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.restoring[app.ServiceID]; ok {
+		return ErrVMRestoring
+	}
+	if m.restoring == nil {
+		m.restoring = make(map[string]string)
+	}
+	m.restoring[app.ServiceID] = controllerID
+	m.controllers[controllerID] = controller
+	go controller.Begin()
+	return nil
+}
+
+func (s *vmRestoreController) Begin() {
+	app := s.appService
+	app.Logger.Info(fmt.Sprintf("App runtime begin restore vm %s from snapshot %s", app.ServiceAlias, s.option.SnapshotName), event.GetLoggerOption("starting"))
+	if err := s.restoreOne(app); err != nil {
+		logrus.Errorf("restore vm %s from snapshot %s failure %s", app.ServiceAlias, s.option.SnapshotName, err.Error())
+	} else {
+		app.Logger.Info(fmt.Sprintf("restore vm %s from snapshot %s success", app.ServiceAlias, s.option.SnapshotName), event.GetLastLoggerOption())
+	}
+	s.manager.callback(s.controllerID, nil)
+}
+
+func (s *vmRestoreController) restoreOne(app v1.AppService) error {
+	namespace := app.GetNamespace()
+	vmName := app.GetVirtualMachine().Name
+	fail := func(phase string, err error) error {
+		app.Logger.Error(
+			fmt.Sprintf("restore vm %s failure (%s phase): %s", app.ServiceAlias, phase, truncateErr(err, 1024)),
+			event.GetLoggerOption("failure"))
+		app.Logger.Error(fmt.Sprintf("restore vm from snapshot %s failure", s.option.SnapshotName), event.GetCallbackLoggerOption())
+		return err
+	}
+	// step 1: stop the running vm, VirtualMachineRestore requires the target vm not running
+	if storeApp := s.manager.store.GetAppService(app.ServiceID); storeApp != nil && storeApp.GetVirtualMachine() != nil {
+		stopApp := *storeApp
+		stopApp.Logger = app.Logger
+		stopController := &stopController{
+			manager:      s.manager,
+			waiting:      time.Minute * 5,
+			ctx:          s.ctx,
+			controllerID: s.controllerID,
+		}
+		if err := stopController.stopOne(stopApp); err != nil && err != ErrWaitTimeOut {
+			return fail("stop", err)
+		}
+	}
+	if err := s.waitVMDeleted(namespace, vmName); err != nil {
+		return fail("stop", err)
+	}
+	// step 2: restore the vm from snapshot, kubevirt creates the vm with the restored disks
+	restore, err := buildVMRestore(s.option.RestoreName, namespace, vmName, s.option.SnapshotName, app.GetVirtualMachine().Labels)
+	if err != nil {
+		return fail("restore", err)
+	}
+	if _, err := s.manager.kubevirtCli.VirtualMachineRestore(namespace).Create(s.ctx, restore, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fail("restore", fmt.Errorf("create vm restore failure:%s", err.Error()))
+	}
+	app.Logger.Info(fmt.Sprintf("vm restore %s created, waiting for disks restored", restore.Name), event.GetLoggerOption("running"))
+	restore, err = s.waitVMRestoreComplete(namespace, restore.Name)
+	if err != nil {
+		return fail("restore", err)
+	}
+	if err := s.relabelRestoredClaims(app, restore.Status.Restores); err != nil {
+		return fail("restore", err)
+	}
+	replaced, err := s.saveRestoredClaims(app, restore.Status.Restores)
+	if err != nil {
+		return fail("restore", err)
+	}
+	// step 3: drop the vm created by kubevirt and keep its disks, the vm is recreated from the component model
+	orphan := metav1.DeletePropagationOrphan
+	err = s.manager.kubevirtCli.VirtualMachine(namespace).Delete(s.ctx, vmName, metav1.DeleteOptions{PropagationPolicy: &orphan})
+	if err != nil && !errors.IsNotFound(err) {
+		return fail("restore", fmt.Errorf("delete restored vm failure:%s", err.Error()))
+	}
+	if err := s.waitVMDeleted(namespace, vmName); err != nil {
+		return fail("restore", err)
+	}
+	// step 4: start the vm with the restored disks
+	newAppService, err := conversion.InitAppService(false, db.GetManager(), app.ServiceID, app.ExtensionSet)
+	if err != nil {
+		logrus.Errorf("Application model init create failure:%s", err.Error())
+		return fail("init", fmt.Errorf("application model init create failure,%s", err.Error()))
+	}
+	newAppService.Logger = app.Logger
+	s.manager.store.RegistAppService(newAppService)
+	startController := startController{
+		manager:      s.manager,
+		ctx:          s.ctx,
+		controllerID: s.controllerID,
+	}
+	if err := startController.startOne(*newAppService); err != nil {
+		if err != ErrWaitTimeOut {
+			return fail("start", err)
+		}
+		// the replaced disks are kept until the vm is known to run on the restored ones
+		logrus.Warningf("vm %s is not ready after restore, keep the replaced claims %v", app.ServiceAlias, replaced)
+		return nil
+	}
+	// step 5: the vm runs on the restored disks, drop the disks they replaced
+	s.deleteReplacedClaims(namespace, replaced)
+	return nil
+}
+
+// saveRestoredClaims records the restored pvc on the component and returns the pvc they replace,
+// the manual<ID> pvc created for the volume or the pvc of an earlier restore.
+func (s *vmRestoreController) saveRestoredClaims(app v1.AppService, restores []snapshotv1.VolumeRestore) ([]string, error) {
+	dbmanager := db.GetManager()
+	target, err := dbmanager.TenantServiceVolumeDao().GetTenantServiceVolumesByServiceID(app.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	source := target
+	if s.option.SourceServiceID != "" && s.option.SourceServiceID != app.ServiceID {
+		source, err = dbmanager.TenantServiceVolumeDao().GetTenantServiceVolumesByServiceID(s.option.SourceServiceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	claims := volume.MapVMRestoredClaims(restores, source, target)
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("no disk of snapshot %s matches the volumes of component %s", s.option.SnapshotName, app.ServiceAlias)
+	}
+	previous, err := volume.LoadVMRestoredClaims(app.ServiceID, dbmanager)
+	if err != nil {
+		return nil, err
+	}
+	var replaced []string
+	for claimName, restoredClaim := range claims {
+		old := claimName
+		if previous[claimName] != "" {
+			old = previous[claimName]
+		}
+		if old != restoredClaim {
+			replaced = append(replaced, old)
+		}
+	}
+	sort.Strings(replaced)
+	return replaced, volume.SaveVMRestoredClaims(dbmanager, app.TenantID, app.ServiceID, claims)
+}
+
+// relabelRestoredClaims labels the restored pvc with the labels of the target component,
+// they carry the labels of the snapshot source otherwise and are missed by the component gc.
+func (s *vmRestoreController) relabelRestoredClaims(app v1.AppService, restores []snapshotv1.VolumeRestore) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": app.GetCommonLabels()},
+	})
+	if err != nil {
+		return err
+	}
+	for _, restore := range restores {
+		_, err := s.manager.client.CoreV1().PersistentVolumeClaims(app.GetNamespace()).Patch(s.ctx, restore.PersistentVolumeClaimName, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("relabel restored claim %s failure:%s", restore.PersistentVolumeClaimName, err.Error())
+		}
+	}
+	return nil
+}
+
+func (s *vmRestoreController) deleteReplacedClaims(namespace string, claims []string) {
+	for _, claim := range claims {
+		err := s.manager.client.CoreV1().PersistentVolumeClaims(namespace).Delete(s.ctx, claim, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			logrus.Warningf("delete replaced claim %s/%s failure %s", namespace, claim, err.Error())
+		}
+	}
+}
+
+func (s *vmRestoreController) waitVMDeleted(namespace, name string) error {
+	timer := time.NewTimer(time.Minute * 5)
+	defer timer.Stop()
+	for {
+		_, err := s.manager.kubevirtCli.VirtualMachine(namespace).Get(s.ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			logrus.Warningf("get vm %s/%s failure %s", namespace, name, err.Error())
+		}
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-s.stopChan:
+			return fmt.Errorf("vm restore controller stopped")
+		case <-timer.C:
+			return fmt.Errorf("waiting vm %s deleted timeout", name)
+		case <-time.After(vmRestorePollInterval):
+		}
+	}
+}
+
+func (s *vmRestoreController) waitVMRestoreComplete(namespace, name string) (*snapshotv1.VirtualMachineRestore, error) {
+	timer := time.NewTimer(vmRestoreTimeout)
+	defer timer.Stop()
+	for {
+		restore, err := s.manager.kubevirtCli.VirtualMachineRestore(namespace).Get(s.ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("get vm restore failure:%s", err.Error())
+		}
+		if restore.Status != nil {
+			if restore.Status.Complete != nil && *restore.Status.Complete {
+				return restore, nil
+			}
+			for _, condition := range restore.Status.Conditions {
+				if condition.Type == snapshotv1.ConditionFailure && condition.Status == corev1.ConditionTrue {
+					return nil, fmt.Errorf("vm restore %s failure: %s", name, condition.Message)
+				}
+			}
+		}
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case <-s.stopChan:
+			return nil, fmt.Errorf("vm restore controller stopped")
+		case <-timer.C:
+			return nil, fmt.Errorf("waiting vm restore %s complete timeout", name)
+		case <-time.After(vmRestorePollInterval):
+		}
+	}
+}
+
+// buildVMRestore build the VirtualMachineRestore of the component vm. The vm created by the restore
+// keeps halted and carries the labels of the target component, it is replaced by the component vm later.
+func buildVMRestore(name, namespace, vmName, snapshotName string, labels map[string]string) (*snapshotv1.VirtualMachineRestore, error) {
+	halted, err := json.Marshal(map[string]interface{}{
+		"op":    "replace",
+		"path":  "/spec/runStrategy",
+		"value": kubevirtv1.RunStrategyHalted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	patches := []string{string(halted)}
+	if len(labels) > 0 {
+		labelPatch, err := json.Marshal(map[string]interface{}{
+			"op":    "replace",
+			"path":  "/metadata/labels",
+			"value": labels,
+		})
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, string(labelPatch))
+	}
+	apiGroup := kubevirtv1.VirtualMachineGroupVersionKind.Group
+	return &snapshotv1.VirtualMachineRestore{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: snapshotv1.SchemeGroupVersion.String(),
+			Kind:       "VirtualMachineRestore",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"service_id": labels["service_id"]},
+		},
+		Spec: snapshotv1.VirtualMachineRestoreSpec{
+			Target: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VirtualMachine",
+				Name:     vmName,
+			},
+			VirtualMachineSnapshotName: snapshotName,
+			Patches:                    patches,
+		},
+	}, nil
+}
+
+func (s *vmRestoreController) Stop() error {
+	close(s.stopChan)
+	return nil
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	snapshotv1 "kubevirt.io/api/snapshot/v1beta1"
+	"kubevirt.io/client-go/kubecli"
+	snapshotclient "kubevirt.io/client-go/kubevirt/typed/snapshot/v1beta1"
+)
+
+type vmRestoreClientStub struct {
+	snapshotclient.VirtualMachineRestoreInterface
+	statuses []*snapshotv1.VirtualMachineRestoreStatus
+	gets     int
+}
+
+func (s *vmRestoreClientStub) Get(_ context.Context, name string, _ metav1.GetOptions) (*snapshotv1.VirtualMachineRestore, error) {
+	status := s.statuses[s.gets]
+	if s.gets < len(s.statuses)-1 {
+		s.gets++
+	}
+	return &snapshotv1.VirtualMachineRestore{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: status}, nil
+}
+
+type restoreTestManager struct {
+	db.Manager
+	volumeDao    dbdao.TenantServiceVolumeDao
+	attributeDao dbdao.ComponentK8sAttributeDao
+}
+
+func (m restoreTestManager) TenantServiceVolumeDao() dbdao.TenantServiceVolumeDao {
+	return m.volumeDao
+}
+
+func (m restoreTestManager) ComponentK8sAttributeDao() dbdao.ComponentK8sAttributeDao {
+	return m.attributeDao
+}
+
+type restoreVolumeDaoStub struct {
+	dbdao.TenantServiceVolumeDao
+	volumes map[string][]*dbmodel.TenantServiceVolume
+}
+
+func (d *restoreVolumeDaoStub) GetTenantServiceVolumesByServiceID(serviceID string) ([]*dbmodel.TenantServiceVolume, error) {
+	return d.volumes[serviceID], nil
+}
+
+type restoreAttributeDaoStub struct {
+	dbdao.ComponentK8sAttributeDao
+	attributes map[string]*dbmodel.ComponentK8sAttributes
+}
+
+func (d *restoreAttributeDaoStub) GetByComponentIDAndName(componentID, name string) (*dbmodel.ComponentK8sAttributes, error) {
+	return d.attributes[componentID+"/"+name], nil
+}
+
+func (d *restoreAttributeDaoStub) CreateOrUpdateAttributesInBatch(attributes []*dbmodel.ComponentK8sAttributes) error {
+	for _, attr := range attributes {
+		d.attributes[attr.ComponentID+"/"+attr.Name] = attr
+	}
+	return nil
+}
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestBuildVMRestore(t *testing.T) {
+	labels := map[string]string{"service_id": "service-2", "service_alias": "gr123456"}
+
+	restore, err := buildVMRestore("snap-1-restore-1", "demo-ns", "gr123456", "snap-1", labels)
+	if err != nil {
+		t.Fatalf("build vm restore: %v", err)
+	}
+
+	if restore.Namespace != "demo-ns" || restore.Labels["service_id"] != "service-2" {
+		t.Fatalf("unexpected restore meta %#v", restore.ObjectMeta)
+	}
+	if restore.Spec.Target.Kind != "VirtualMachine" || restore.Spec.Target.Name != "gr123456" || restore.Spec.VirtualMachineSnapshotName != "snap-1" {
+		t.Fatalf("unexpected restore spec %#v", restore.Spec)
+	}
+	if len(restore.Spec.Patches) != 2 {
+		t.Fatalf("expected run strategy and labels patches, got %#v", restore.Spec.Patches)
+	}
+	var patch struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(restore.Spec.Patches[0]), &patch); err != nil {
+		t.Fatalf("parse run strategy patch: %v", err)
+	}
+	if patch.Path != "/spec/runStrategy" || patch.Value != string(kubevirtv1.RunStrategyHalted) {
+		t.Fatalf("expected restored vm to keep halted, got %#v", patch)
+	}
+	if !strings.Contains(restore.Spec.Patches[1], `"path":"/metadata/labels"`) || !strings.Contains(restore.Spec.Patches[1], `"service-2"`) {
+		t.Fatalf("expected restored vm to carry target labels, got %s", restore.Spec.Patches[1])
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestWaitVMRestoreComplete(t *testing.T) {
+	oldInterval := vmRestorePollInterval
+	vmRestorePollInterval = time.Millisecond
+	defer func() { vmRestorePollInterval = oldInterval }()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	complete := true
+	incomplete := false
+	restoreClient := &vmRestoreClientStub{statuses: []*snapshotv1.VirtualMachineRestoreStatus{
+		nil,
+		{Complete: &incomplete},
+		{Complete: &complete, Restores: []snapshotv1.VolumeRestore{{VolumeName: "manual1", PersistentVolumeClaimName: "restore-abc-manual1"}}},
+	}}
+	kubevirtCli := kubecli.NewMockKubevirtClient(ctrl)
+	kubevirtCli.EXPECT().VirtualMachineRestore("demo-ns").Return(restoreClient).AnyTimes()
+	controller := &vmRestoreController{
+		manager:  &Manager{kubevirtCli: kubevirtCli},
+		ctx:      context.Background(),
+		stopChan: make(chan struct{}),
+	}
+
+	restore, err := controller.waitVMRestoreComplete("demo-ns", "snap-1-restore")
+	if err != nil {
+		t.Fatalf("expected restore to complete, got %v", err)
+	}
+	if len(restore.Status.Restores) != 1 || restore.Status.Restores[0].PersistentVolumeClaimName != "restore-abc-manual1" {
+		t.Fatalf("unexpected restore status %#v", restore.Status)
+	}
+
+	failedClient := &vmRestoreClientStub{statuses: []*snapshotv1.VirtualMachineRestoreStatus{
+		{Conditions: []snapshotv1.Condition{{Type: snapshotv1.ConditionFailure, Status: corev1.ConditionTrue, Message: "snapshot content missing"}}},
+	}}
+	kubevirtCli = kubecli.NewMockKubevirtClient(ctrl)
+	kubevirtCli.EXPECT().VirtualMachineRestore("demo-ns").Return(failedClient).AnyTimes()
+	controller.manager = &Manager{kubevirtCli: kubevirtCli}
+	if _, err := controller.waitVMRestoreComplete("demo-ns", "snap-1-restore"); err == nil || !strings.Contains(err.Error(), "snapshot content missing") {
+		t.Fatalf("expected restore failure to be reported, got %v", err)
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestVMRestoreRejectsConcurrentOperations(t *testing.T) {
+	app := v1.AppService{AppServiceBase: v1.AppServiceBase{ServiceID: "service-1", ServiceAlias: "gr123456"}}
+	app.SetVirtualMachine(&kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "gr123456"},
+		Spec:       kubevirtv1.VirtualMachineSpec{Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{}},
+	})
+	m := &Manager{
+		controllers: map[string]Controller{"restore-1": &vmRestoreController{}},
+		restoring:   map[string]string{"service-1": "restore-1"},
+	}
+	if err := m.RestoreVMController(VMRestoreOption{SnapshotName: "snap-2"}, app); err != ErrVMRestoring {
+		t.Fatalf("expected the second restore to be rejected, got %v", err)
+	}
+	for _, controllerType := range []TypeController{TypeStartController, TypeRestartController, TypeUpgradeController, TypeScalingController} {
+		if err := m.StartController(controllerType, app); err != ErrVMRestoring {
+			t.Fatalf("expected %s to be rejected during restore, got %v", controllerType, err)
+		}
+	}
+	if len(m.controllers) != 1 {
+		t.Fatalf("no controller should be started, got %d", len(m.controllers))
+	}
+	m.callback("restore-1", nil)
+	if len(m.restoring) != 0 {
+		t.Fatalf("the component should be released after restore, got %#v", m.restoring)
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.restore
+func TestVMRestoreReplacesClaims(t *testing.T) {
+	volumeDao := &restoreVolumeDaoStub{volumes: map[string][]*dbmodel.TenantServiceVolume{
+		"service-1": {{Model: dbmodel.Model{ID: 1}, VolumeName: "disk"}, {Model: dbmodel.Model{ID: 2}, VolumeName: "data"}},
+		"service-2": {{Model: dbmodel.Model{ID: 11}, VolumeName: "disk"}, {Model: dbmodel.Model{ID: 12}, VolumeName: "data"}},
+	}}
+	attributeDao := &restoreAttributeDaoStub{attributes: map[string]*dbmodel.ComponentK8sAttributes{
+		"service-2/" + dbmodel.K8sAttributeNameVMRestoredClaims: {AttributeValue: `{"manual12":"restore-old-manual2"}`},
+	}}
+	db.SetTestManager(restoreTestManager{volumeDao: volumeDao, attributeDao: attributeDao})
+	defer db.SetTestManager(nil)
+
+	claim := func(name string, labels map[string]string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "demo-ns", Labels: labels}}
+	}
+	client := fake.NewSimpleClientset(
+		claim("manual11", nil),
+		claim("restore-old-manual2", nil),
+		claim("restore-abc-manual1", map[string]string{"service_id": "service-1", "restore.kubevirt.io/source-vm-name": "gr111111"}),
+		claim("restore-abc-manual2", map[string]string{"service_id": "service-1"}),
+	)
+	app := v1.AppService{AppServiceBase: v1.AppServiceBase{ServiceID: "service-2", ServiceAlias: "gr222222", TenantID: "tenant-1"}}
+	app.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo-ns"}})
+	controller := &vmRestoreController{
+		manager: &Manager{client: client},
+		ctx:     context.Background(),
+		option:  VMRestoreOption{SnapshotName: "snap-1", SourceServiceID: "service-1"},
+	}
+	restores := []snapshotv1.VolumeRestore{
+		{VolumeName: "manual1", PersistentVolumeClaimName: "restore-abc-manual1"},
+		{VolumeName: "manual2", PersistentVolumeClaimName: "restore-abc-manual2"},
+	}
+
+	if err := controller.relabelRestoredClaims(app, restores); err != nil {
+		t.Fatalf("relabel restored claims: %v", err)
+	}
+	restored, err := client.CoreV1().PersistentVolumeClaims("demo-ns").Get(context.Background(), "restore-abc-manual1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Labels["service_id"] != "service-2" || restored.Labels["creator"] != "Rainbond" || restored.Labels["restore.kubevirt.io/source-vm-name"] != "gr111111" {
+		t.Fatalf("expected the restored claim to carry the target labels, got %#v", restored.Labels)
+	}
+
+	replaced, err := controller.saveRestoredClaims(app, restores)
+	if err != nil {
+		t.Fatalf("save restored claims: %v", err)
+	}
+	if !reflect.DeepEqual(replaced, []string{"manual11", "restore-old-manual2"}) {
+		t.Fatalf("unexpected replaced claims %v", replaced)
+	}
+	controller.deleteReplacedClaims("demo-ns", append(replaced, "missing"))
+	for _, name := range []string{"manual11", "restore-old-manual2"} {
+		if _, err := client.CoreV1().PersistentVolumeClaims("demo-ns").Get(context.Background(), name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+			t.Fatalf("expected replaced claim %s to be deleted, got %v", name, err)
+		}
+	}
+}
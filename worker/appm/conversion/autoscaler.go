@@ -19,9 +19,9 @@
 package conversion
 
 import (
+	"encoding/json"
 	"fmt"
 	k8sutil "github.com/goodrain/rainbond/util/k8s"
-	utilversion "k8s.io/apimachinery/pkg/util/version"
 
 	"github.com/sirupsen/logrus"
 
@@ -42,9 +42,14 @@ var str2ResourceName = map[string]corev1.ResourceName{
 	"memory": corev1.ResourceMemory,
 }
 
+// metricQueriesAnnotation records the PromQL query of every custom metric of the HPA,
+// keyed by <metric_type>/<metric_name>. The metrics adapter serves them through the rules
+// rendered by worker/master/metricsadapter.
+const metricQueriesAnnotation = "rainbond.io/metric-queries"
+
 // TenantServiceAutoscaler -
 func TenantServiceAutoscaler(as *v1.AppService, dbmanager db.Manager) error {
-	if k8sutil.GetKubeVersion().AtLeast(utilversion.MustParseSemantic("v1.23.0")) {
+	if k8sutil.IsHPAV2Supported() {
 		hpas, err := newHPAs(as, dbmanager)
 		if err != nil {
 			return fmt.Errorf("create HPAs: %v", err)
@@ -127,6 +132,54 @@ func createResourceMetricsBeta2(metric *model.TenantServiceAutoscalerRuleMetrics
 	return ms
 }
 
+// createCustomMetricsBeta2 converts a PromQL backed metric to a pods, object or external metric spec.
+func createCustomMetricsBeta2(metric *model.TenantServiceAutoscalerRuleMetrics) (autoscalingv2beta2.MetricSpec, error) {
+	var target autoscalingv2beta2.MetricTarget
+	quantity := resource.NewQuantity(int64(metric.MetricTargetValue), resource.DecimalSI)
+	switch metric.MetricTargetType {
+	case "value":
+		target = autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.ValueMetricType, Value: quantity}
+	case "average_value":
+		target = autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.AverageValueMetricType, AverageValue: quantity}
+	default:
+		return autoscalingv2beta2.MetricSpec{}, fmt.Errorf("unsupported target type %s", metric.MetricTargetType)
+	}
+	identifier := autoscalingv2beta2.MetricIdentifier{Name: metric.MetricsName}
+
+	switch metric.MetricsType {
+	case model.PodsMetricsType:
+		if target.Type != autoscalingv2beta2.AverageValueMetricType {
+			return autoscalingv2beta2.MetricSpec{}, fmt.Errorf("pods metric only supports average_value target")
+		}
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{Metric: identifier, Target: target},
+		}, nil
+	case model.ObjectMetricsType:
+		if metric.ObjectKind == "" || metric.ObjectName == "" {
+			return autoscalingv2beta2.MetricSpec{}, fmt.Errorf("object metric requires object kind and name")
+		}
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ObjectMetricSourceType,
+			Object: &autoscalingv2beta2.ObjectMetricSource{
+				DescribedObject: autoscalingv2beta2.CrossVersionObjectReference{
+					Kind:       metric.ObjectKind,
+					Name:       metric.ObjectName,
+					APIVersion: metric.ObjectAPIVersion,
+				},
+				Metric: identifier,
+				Target: target,
+			},
+		}, nil
+	case model.ExternalMetricsType:
+		return autoscalingv2beta2.MetricSpec{
+			Type:     autoscalingv2beta2.ExternalMetricSourceType,
+			External: &autoscalingv2beta2.ExternalMetricSource{Metric: identifier, Target: target},
+		}, nil
+	}
+	return autoscalingv2beta2.MetricSpec{}, fmt.Errorf("unsupported metric type %s", metric.MetricsType)
+}
+
 func newHPABeta2(namespace, kind, name string, labels map[string]string, rule *model.TenantServiceAutoscalerRules, metrics []*model.TenantServiceAutoscalerRuleMetrics) *autoscalingv2beta2.HorizontalPodAutoscaler {
 	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
@@ -136,9 +189,10 @@ func newHPABeta2(namespace, kind, name string, labels map[string]string, rule *m
 		},
 	}
 
+	minReplicas, maxReplicas := rule.ReplicasRange()
 	spec := autoscalingv2beta2.HorizontalPodAutoscalerSpec{
-		MinReplicas: util.Int32(int32(rule.MinReplicas)),
-		MaxReplicas: int32(rule.MaxReplicas),
+		MinReplicas: util.Int32(int32(minReplicas)),
+		MaxReplicas: int32(maxReplicas),
 		ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
 			Kind:       kind,
 			Name:       name,
@@ -146,28 +200,72 @@ func newHPABeta2(namespace, kind, name string, labels map[string]string, rule *m
 		},
 	}
 
+	queries := make(map[string]string)
 	for _, metric := range metrics {
-		if metric.MetricsType != "resource_metrics" {
-			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
-			continue
-		}
 		if metric.MetricTargetValue <= 0 {
 			// TODO: If the target value of cpu and memory is 0, it will not take effect.
-			// TODO: The target value of the custom indicator can be 0.
 			continue
 		}
-
-		ms := createResourceMetricsBeta2(metric)
-		spec.Metrics = append(spec.Metrics, ms)
+		switch {
+		case metric.MetricsType == model.ResourceMetricsType:
+			spec.Metrics = append(spec.Metrics, createResourceMetricsBeta2(metric))
+		case metric.IsCustom():
+			ms, err := createCustomMetricsBeta2(metric)
+			if err != nil {
+				logrus.Warningf("rule id: %s; skip metric %s: %v", rule.RuleID, metric.MetricsName, err)
+				continue
+			}
+			spec.Metrics = append(spec.Metrics, ms)
+			queries[metric.MetricsType+"/"+metric.MetricsName] = metric.MetricQuery
+		default:
+			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
+		}
 	}
 	if len(spec.Metrics) == 0 {
 		return nil
 	}
+	behavior, err := rule.GetBehavior()
+	if err != nil {
+		logrus.Warningf("rule id: %s; use the default behavior: %v", rule.RuleID, err)
+	}
+	spec.Behavior = createBehaviorBeta2(behavior)
 	hpa.Spec = spec
+	setMetricQueries(&hpa.ObjectMeta, queries)
 
 	return hpa
 }
 
+func createBehaviorBeta2(behavior *model.AutoscalerBehavior) *autoscalingv2beta2.HorizontalPodAutoscalerBehavior {
+	if behavior == nil {
+		return nil
+	}
+	return &autoscalingv2beta2.HorizontalPodAutoscalerBehavior{
+		ScaleUp:   createScalingRulesBeta2(behavior.ScaleUp),
+		ScaleDown: createScalingRulesBeta2(behavior.ScaleDown),
+	}
+}
+
+func createScalingRulesBeta2(rules *model.AutoscalerScalingRules) *autoscalingv2beta2.HPAScalingRules {
+	if rules == nil {
+		return nil
+	}
+	res := &autoscalingv2beta2.HPAScalingRules{
+		StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+	}
+	if rules.SelectPolicy != "" {
+		selectPolicy := autoscalingv2beta2.ScalingPolicySelect(rules.SelectPolicy)
+		res.SelectPolicy = &selectPolicy
+	}
+	for _, policy := range rules.Policies {
+		res.Policies = append(res.Policies, autoscalingv2beta2.HPAScalingPolicy{
+			Type:          autoscalingv2beta2.HPAScalingPolicyType(policy.Type),
+			Value:         policy.Value,
+			PeriodSeconds: policy.PeriodSeconds,
+		})
+	}
+	return res
+}
+
 func newHPAs(as *v1.AppService, dbmanager db.Manager) ([]*autoscalingv2.HorizontalPodAutoscaler, error) {
 	xpaRules, err := dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByServiceID(as.ServiceID)
 	if err != nil {
@@ -229,6 +327,54 @@ func createResourceMetrics(metric *model.TenantServiceAutoscalerRuleMetrics) aut
 	return ms
 }
 
+// createCustomMetrics converts a PromQL backed metric to a pods, object or external metric spec.
+func createCustomMetrics(metric *model.TenantServiceAutoscalerRuleMetrics) (autoscalingv2.MetricSpec, error) {
+	var target autoscalingv2.MetricTarget
+	quantity := resource.NewQuantity(int64(metric.MetricTargetValue), resource.DecimalSI)
+	switch metric.MetricTargetType {
+	case "value":
+		target = autoscalingv2.MetricTarget{Type: autoscalingv2.ValueMetricType, Value: quantity}
+	case "average_value":
+		target = autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: quantity}
+	default:
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("unsupported target type %s", metric.MetricTargetType)
+	}
+	identifier := autoscalingv2.MetricIdentifier{Name: metric.MetricsName}
+
+	switch metric.MetricsType {
+	case model.PodsMetricsType:
+		if target.Type != autoscalingv2.AverageValueMetricType {
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("pods metric only supports average_value target")
+		}
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{Metric: identifier, Target: target},
+		}, nil
+	case model.ObjectMetricsType:
+		if metric.ObjectKind == "" || metric.ObjectName == "" {
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("object metric requires object kind and name")
+		}
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ObjectMetricSourceType,
+			Object: &autoscalingv2.ObjectMetricSource{
+				DescribedObject: autoscalingv2.CrossVersionObjectReference{
+					Kind:       metric.ObjectKind,
+					Name:       metric.ObjectName,
+					APIVersion: metric.ObjectAPIVersion,
+				},
+				Metric: identifier,
+				Target: target,
+			},
+		}, nil
+	case model.ExternalMetricsType:
+		return autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: identifier, Target: target},
+		}, nil
+	}
+	return autoscalingv2.MetricSpec{}, fmt.Errorf("unsupported metric type %s", metric.MetricsType)
+}
+
 func newHPA(namespace, kind, name string, labels map[string]string, rule *model.TenantServiceAutoscalerRules, metrics []*model.TenantServiceAutoscalerRuleMetrics) *autoscalingv2.HorizontalPodAutoscaler {
 	hpa := &autoscalingv2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
@@ -238,9 +384,10 @@ func newHPA(namespace, kind, name string, labels map[string]string, rule *model.
 		},
 	}
 
+	minReplicas, maxReplicas := rule.ReplicasRange()
 	spec := autoscalingv2.HorizontalPodAutoscalerSpec{
-		MinReplicas: util.Int32(int32(rule.MinReplicas)),
-		MaxReplicas: int32(rule.MaxReplicas),
+		MinReplicas: util.Int32(int32(minReplicas)),
+		MaxReplicas: int32(maxReplicas),
 		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 			Kind:       kind,
 			Name:       name,
@@ -248,24 +395,84 @@ func newHPA(namespace, kind, name string, labels map[string]string, rule *model.
 		},
 	}
 
+	queries := make(map[string]string)
 	for _, metric := range metrics {
-		if metric.MetricsType != "resource_metrics" {
-			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
-			continue
-		}
 		if metric.MetricTargetValue <= 0 {
 			// TODO: If the target value of cpu and memory is 0, it will not take effect.
-			// TODO: The target value of the custom indicator can be 0.
 			continue
 		}
-
-		ms := createResourceMetrics(metric)
-		spec.Metrics = append(spec.Metrics, ms)
+		switch {
+		case metric.MetricsType == model.ResourceMetricsType:
+			spec.Metrics = append(spec.Metrics, createResourceMetrics(metric))
+		case metric.IsCustom():
+			ms, err := createCustomMetrics(metric)
+			if err != nil {
+				logrus.Warningf("rule id: %s; skip metric %s: %v", rule.RuleID, metric.MetricsName, err)
+				continue
+			}
+			spec.Metrics = append(spec.Metrics, ms)
+			queries[metric.MetricsType+"/"+metric.MetricsName] = metric.MetricQuery
+		default:
+			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
+		}
 	}
 	if len(spec.Metrics) == 0 {
 		return nil
 	}
+	behavior, err := rule.GetBehavior()
+	if err != nil {
+		logrus.Warningf("rule id: %s; use the default behavior: %v", rule.RuleID, err)
+	}
+	spec.Behavior = createBehavior(behavior)
 	hpa.Spec = spec
+	setMetricQueries(&hpa.ObjectMeta, queries)
 
 	return hpa
 }
+
+// createBehavior converts the scale up and scale down policies of the rule, nil keeps the default behavior
+func createBehavior(behavior *model.AutoscalerBehavior) *autoscalingv2.HorizontalPodAutoscalerBehavior {
+	if behavior == nil {
+		return nil
+	}
+	return &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp:   createScalingRules(behavior.ScaleUp),
+		ScaleDown: createScalingRules(behavior.ScaleDown),
+	}
+}
+
+func createScalingRules(rules *model.AutoscalerScalingRules) *autoscalingv2.HPAScalingRules {
+	if rules == nil {
+		return nil
+	}
+	res := &autoscalingv2.HPAScalingRules{
+		StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+	}
+	if rules.SelectPolicy != "" {
+		selectPolicy := autoscalingv2.ScalingPolicySelect(rules.SelectPolicy)
+		res.SelectPolicy = &selectPolicy
+	}
+	for _, policy := range rules.Policies {
+		res.Policies = append(res.Policies, autoscalingv2.HPAScalingPolicy{
+			Type:          autoscalingv2.HPAScalingPolicyType(policy.Type),
+			Value:         policy.Value,
+			PeriodSeconds: policy.PeriodSeconds,
+		})
+	}
+	return res
+}
+
+func setMetricQueries(meta *metav1.ObjectMeta, queries map[string]string) {
+	if len(queries) == 0 {
+		return
+	}
+	body, err := json.Marshal(queries)
+	if err != nil {
+		logrus.Warningf("marshal metric queries of %s: %v", meta.Name, err)
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[metricQueriesAnnotation] = string(body)
+}
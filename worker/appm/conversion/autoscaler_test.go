@@ -1,6 +1,7 @@
 package conversion
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/goodrain/rainbond/db/model"
@@ -73,3 +74,157 @@ func TestNewHPA(t *testing.T) {
 		}
 	}
 }
+
+// capability_id: rainbond.worker.appm.autoscaler.custom-metrics
+func TestNewHPACustomMetrics(t *testing.T) {
+	rule := &model.TenantServiceAutoscalerRules{
+		RuleID:      "f4d3c6c2b9c54c1a8e5e4f1d2a3b4c5d",
+		MinReplicas: 1,
+		MaxReplicas: 20,
+	}
+	metrics := []*model.TenantServiceAutoscalerRuleMetrics{
+		{
+			MetricsType:       model.ResourceMetricsType,
+			MetricsName:       "cpu",
+			MetricTargetType:  "utilization",
+			MetricTargetValue: 70,
+		},
+		{
+			MetricsType:       model.PodsMetricsType,
+			MetricsName:       "http_requests_per_second",
+			MetricTargetType:  "average_value",
+			MetricTargetValue: 100,
+			MetricQuery:       `sum(rate(http_requests_total{<<.LabelMatchers>>}[2m])) by (<<.GroupBy>>)`,
+		},
+		{
+			MetricsType:       model.ObjectMetricsType,
+			MetricsName:       "ingress_hits",
+			MetricTargetType:  "value",
+			MetricTargetValue: 2000,
+			MetricQuery:       `sum(rate(nginx_ingress_controller_requests{ingress="web"}[2m]))`,
+			ObjectKind:        "Ingress",
+			ObjectName:        "web",
+			ObjectAPIVersion:  "networking.k8s.io/v1",
+		},
+		{
+			MetricsType:       model.ExternalMetricsType,
+			MetricsName:       "queue_depth",
+			MetricTargetType:  "average_value",
+			MetricTargetValue: 30,
+			MetricQuery:       `sum(rabbitmq_queue_messages_ready{queue="orders"})`,
+		},
+		{
+			// pods metrics can not use a total value target
+			MetricsType:       model.PodsMetricsType,
+			MetricsName:       "bad_target",
+			MetricTargetType:  "value",
+			MetricTargetValue: 1,
+			MetricQuery:       "up",
+		},
+	}
+
+	hpa := newHPA("ns", "Deployment", "web", nil, rule, metrics)
+	if !assert.NotNil(t, hpa) || !assert.Len(t, hpa.Spec.Metrics, 4) {
+		return
+	}
+
+	assert.Equal(t, "Resource", string(hpa.Spec.Metrics[0].Type))
+
+	pods := hpa.Spec.Metrics[1]
+	assert.Equal(t, "Pods", string(pods.Type))
+	if assert.NotNil(t, pods.Pods) {
+		assert.Equal(t, "http_requests_per_second", pods.Pods.Metric.Name)
+		assert.Equal(t, "AverageValue", string(pods.Pods.Target.Type))
+		assert.Equal(t, "100", pods.Pods.Target.AverageValue.String())
+	}
+
+	object := hpa.Spec.Metrics[2]
+	assert.Equal(t, "Object", string(object.Type))
+	if assert.NotNil(t, object.Object) {
+		assert.Equal(t, "Ingress", object.Object.DescribedObject.Kind)
+		assert.Equal(t, "web", object.Object.DescribedObject.Name)
+		assert.Equal(t, "Value", string(object.Object.Target.Type))
+		assert.Equal(t, "2k", object.Object.Target.Value.String())
+	}
+
+	external := hpa.Spec.Metrics[3]
+	assert.Equal(t, "External", string(external.Type))
+	if assert.NotNil(t, external.External) {
+		assert.Equal(t, "queue_depth", external.External.Metric.Name)
+		assert.Equal(t, "AverageValue", string(external.External.Target.Type))
+	}
+
+	var queries map[string]string
+	if assert.NoError(t, json.Unmarshal([]byte(hpa.Annotations[metricQueriesAnnotation]), &queries)) {
+		assert.Len(t, queries, 3)
+		assert.Equal(t, `sum(rabbitmq_queue_messages_ready{queue="orders"})`, queries["external_metrics/queue_depth"])
+	}
+
+	beta2 := newHPABeta2("ns", "Deployment", "web", nil, rule, metrics)
+	if assert.NotNil(t, beta2) && assert.Len(t, beta2.Spec.Metrics, 4) {
+		assert.Equal(t, "External", string(beta2.Spec.Metrics[3].Type))
+		assert.Contains(t, beta2.Annotations, metricQueriesAnnotation)
+	}
+}
+
+// capability_id: rainbond.worker.appm.autoscaler.behavior
+func TestNewHPABehavior(t *testing.T) {
+	window := int32(300)
+	rule := &model.TenantServiceAutoscalerRules{RuleID: "rule1", MinReplicas: 1, MaxReplicas: 10}
+	assert.NoError(t, rule.SetBehavior(&model.AutoscalerBehavior{
+		ScaleDown: &model.AutoscalerScalingRules{
+			StabilizationWindowSeconds: &window,
+			SelectPolicy:               "Min",
+			Policies:                   []model.AutoscalerScalingPolicy{{Type: "Percent", Value: 10, PeriodSeconds: 60}},
+		},
+	}))
+	metrics := []*model.TenantServiceAutoscalerRuleMetrics{
+		{MetricsType: "resource_metrics", MetricsName: "cpu", MetricTargetType: "utilization", MetricTargetValue: 50},
+	}
+
+	hpa := newHPA("ns", "Deployment", "web", nil, rule, metrics)
+	if assert.NotNil(t, hpa) && assert.NotNil(t, hpa.Spec.Behavior) {
+		assert.Nil(t, hpa.Spec.Behavior.ScaleUp)
+		down := hpa.Spec.Behavior.ScaleDown
+		if assert.NotNil(t, down) {
+			assert.Equal(t, int32(300), *down.StabilizationWindowSeconds)
+			assert.Equal(t, "Min", string(*down.SelectPolicy))
+			assert.Equal(t, "Percent", string(down.Policies[0].Type))
+			assert.Equal(t, int32(10), down.Policies[0].Value)
+			assert.Equal(t, int32(60), down.Policies[0].PeriodSeconds)
+		}
+	}
+
+	beta2 := newHPABeta2("ns", "Deployment", "web", nil, rule, metrics)
+	if assert.NotNil(t, beta2) && assert.NotNil(t, beta2.Spec.Behavior) && assert.NotNil(t, beta2.Spec.Behavior.ScaleDown) {
+		assert.Equal(t, int32(300), *beta2.Spec.Behavior.ScaleDown.StabilizationWindowSeconds)
+	}
+
+	// the default behavior is kept without the behavior or with a broken one
+	rule.Behavior = ""
+	assert.Nil(t, newHPA("ns", "Deployment", "web", nil, rule, metrics).Spec.Behavior)
+	rule.Behavior = "{broken"
+	assert.Nil(t, newHPA("ns", "Deployment", "web", nil, rule, metrics).Spec.Behavior)
+}
+
+// capability_id: rainbond.worker.appm.autoscaler.build-hpa-spec
+func TestNewHPAScheduledReplicas(t *testing.T) {
+	minReplicas, maxReplicas := 4, 12
+	rule := &model.TenantServiceAutoscalerRules{RuleID: "rule1", MinReplicas: 1, MaxReplicas: 3,
+		ScheduledMinReplicas: &minReplicas, ScheduledMaxReplicas: &maxReplicas}
+	metrics := []*model.TenantServiceAutoscalerRuleMetrics{
+		{MetricsType: "resource_metrics", MetricsName: "cpu", MetricTargetType: "utilization", MetricTargetValue: 50},
+	}
+	hpa := newHPA("ns", "Deployment", "web", nil, rule, metrics)
+	if assert.NotNil(t, hpa) {
+		assert.Equal(t, int32(4), *hpa.Spec.MinReplicas)
+		assert.Equal(t, int32(12), hpa.Spec.MaxReplicas)
+	}
+
+	rule.ScheduledMinReplicas, rule.ScheduledMaxReplicas = nil, nil
+	beta2 := newHPABeta2("ns", "Deployment", "web", nil, rule, metrics)
+	if assert.NotNil(t, beta2) {
+		assert.Equal(t, int32(1), *beta2.Spec.MinReplicas)
+		assert.Equal(t, int32(3), beta2.Spec.MaxReplicas)
+	}
+}
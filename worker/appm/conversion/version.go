@@ -157,6 +157,7 @@ func TenantServiceVersion(as *v1.AppService, dbmanager db.Manager) error {
 	if err != nil {
 		return fmt.Errorf("create affinity failure: %v", err)
 	}
+	relaxArchAffinity(affinity, version.Arches())
 	san, err := createServiceAccountName(as, dbmanager)
 	if err != nil {
 		return fmt.Errorf("craete service account name failure: %v", err)
@@ -1373,6 +1374,42 @@ func createAffinity(as *v1.AppService, dbmanager db.Manager) (*corev1.Affinity,
 	return &affinity, nil
 }
 
+// relaxArchAffinity 组件镜像为多架构镜像时，将固定在构建节点架构上的 kubernetes.io/arch 约束
+// 放宽为镜像支持的全部架构，约束中包含镜像不支持的架构时保持不变。
+func relaxArchAffinity(affinity *corev1.Affinity, arches []string) {
+	if affinity == nil || affinity.NodeAffinity == nil || len(arches) < 2 {
+		return
+	}
+	supported := make(map[string]bool, len(arches))
+	for _, arch := range arches {
+		supported[arch] = true
+	}
+	relax := func(requirements []corev1.NodeSelectorRequirement) {
+		for i, requirement := range requirements {
+			if requirement.Key != corev1.LabelArchStable || requirement.Operator != corev1.NodeSelectorOpIn {
+				continue
+			}
+			pinned := len(requirement.Values) > 0
+			for _, value := range requirement.Values {
+				if !supported[value] {
+					pinned = false
+				}
+			}
+			if pinned {
+				requirements[i].Values = append([]string{}, arches...)
+			}
+		}
+	}
+	if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+		for i := range required.NodeSelectorTerms {
+			relax(required.NodeSelectorTerms[i].MatchExpressions)
+		}
+	}
+	for i := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		relax(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i].Preference.MatchExpressions)
+	}
+}
+
 func createPodAnnotations(as *v1.AppService, dbmanager db.Manager) (map[string]string, error) {
 	annotations := make(map[string]string)
 	annotationsAttribute, err := dbmanager.ComponentK8sAttributeDao().GetByComponentIDAndName(as.ServiceID, model.K8sAttributeNameAnnotations)
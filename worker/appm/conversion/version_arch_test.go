@@ -0,0 +1,52 @@
+package conversion
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func archAffinity(values ...string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: values},
+						{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{"node1"}},
+					},
+				}},
+			},
+		},
+	}
+}
+
+func requiredValues(affinity *corev1.Affinity, index int) []string {
+	return affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[index].Values
+}
+
+// capability_id: rainbond.multi-arch.worker-affinity
+func TestRelaxArchAffinity(t *testing.T) {
+	affinity := archAffinity("amd64")
+	relaxArchAffinity(affinity, []string{"amd64", "arm64"})
+	if got := requiredValues(affinity, 0); !reflect.DeepEqual(got, []string{"amd64", "arm64"}) {
+		t.Fatalf("builder arch pin should be relaxed, got %v", got)
+	}
+	if got := requiredValues(affinity, 1); !reflect.DeepEqual(got, []string{"node1"}) {
+		t.Fatalf("other requirements should be kept, got %v", got)
+	}
+
+	affinity = archAffinity("riscv64")
+	relaxArchAffinity(affinity, []string{"amd64", "arm64"})
+	if got := requiredValues(affinity, 0); !reflect.DeepEqual(got, []string{"riscv64"}) {
+		t.Fatalf("arch not provided by the image should be kept, got %v", got)
+	}
+
+	affinity = archAffinity("amd64")
+	relaxArchAffinity(affinity, nil)
+	if got := requiredValues(affinity, 0); !reflect.DeepEqual(got, []string{"amd64"}) {
+		t.Fatalf("single-arch image should keep the pin, got %v", got)
+	}
+	relaxArchAffinity(&corev1.Affinity{}, []string{"amd64", "arm64"})
+}
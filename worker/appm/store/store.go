@@ -158,6 +158,8 @@ type appRuntimeStore struct {
 	resourceCache          *ResourceCache
 	initLocks              sync.Map // map[serviceID]*sync.Mutex for AppService initialization
 	syncImagePullSecret    func(string) error
+	// hpaV2 the cluster serves autoscaling/v2, otherwise autoscaling/v2beta2 is watched
+	hpaV2 bool
 }
 
 // NewStore new app runtime store
@@ -178,6 +180,7 @@ func NewStore(dbmanager db.Manager) Storer {
 		volumeTypeListeners: make(map[string]chan<- *model.TenantServiceVolumeType, 1),
 	}
 	store.syncImagePullSecret = store.createOrUpdateImagePullSecret
+	store.hpaV2 = k8sutil.SupportsHPAV2(store.k8sClient.Clientset.Discovery())
 	crdClient, err := internalclientset.NewForConfig(store.k8sClient.RestConfig)
 	if err != nil {
 		logrus.Errorf("create crd client failure %s", err.Error())
@@ -239,7 +242,7 @@ func NewStore(dbmanager db.Manager) Storer {
 
 	store.informers.Events = infFactory.Core().V1().Events().Informer()
 
-	if store.k8sClient.K8SVersion.AtLeast(utilversion.MustParseSemantic("v1.23.0")) {
+	if store.hpaV2 {
 		store.informers.HorizontalPodAutoscaler = infFactory.Autoscaling().V2().HorizontalPodAutoscalers().Informer()
 		store.listers.HorizontalPodAutoscaler = infFactory.Autoscaling().V2().HorizontalPodAutoscalers().Lister()
 	} else {
@@ -1935,7 +1938,7 @@ func (a *appRuntimeStore) scalingRecordServiceAndRuleID(evt *corev1.Event) (stri
 		serviceID = statefulset.GetLabels()["service_id"]
 		ruleID = statefulset.GetLabels()["rule_id"]
 	case "HorizontalPodAutoscaler":
-		if a.k8sClient.K8SVersion.AtLeast(utilversion.MustParseSemantic("v1.23.0")) {
+		if a.hpaV2 {
 			hpa, err := a.listers.HorizontalPodAutoscaler.HorizontalPodAutoscalers(evt.InvolvedObject.Namespace).Get(evt.InvolvedObject.Name)
 			if err != nil {
 				logrus.Warningf("retrieve statefulset: %v", err)
@@ -80,6 +80,10 @@ func (v *ConfigFileVolume) CreateVolume(define *Define) error {
 		logrus.Errorf("error getting config file by volume name(%s): %v", v.svm.VolumeName, err)
 		return fmt.Errorf("error getting config file by volume name(%s): %v", v.svm.VolumeName, err)
 	}
+	if cf.Secret {
+		v.setSecretFile(define, v.as.ServiceID, v.svm.VolumeName, v.svm.VolumePath, cf.FileContent, v.svm.Mode)
+		return nil
+	}
 	cmap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      stableVMConfigMapName(v.as.ServiceID, v.svm.VolumeName),
@@ -137,6 +141,10 @@ func (v *ConfigFileVolume) CreateDependVolume(define *Define) error {
 	if err != nil {
 		return fmt.Errorf("error getting TenantServiceConfigFile according to volumeName(%s): %v", v.smr.VolumeName, err)
 	}
+	if cf.Secret {
+		v.setSecretFile(define, v.smr.DependServiceID, v.smr.VolumeName, v.smr.VolumePath, cf.FileContent, depVol.Mode)
+		return nil
+	}
 
 	cmap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -181,6 +189,58 @@ func (v *ConfigFileVolume) CreateDependVolume(define *Define) error {
 	return nil
 }
 
+// setSecretFile 为配置文件创建组件独立的 Secret，并只挂载到配置文件路径，内容不写入 ConfigMap，也不注入环境变量
+func (v *ConfigFileVolume) setSecretFile(define *Define, serviceID, volumeName, volumePath, content string, mode *int32) {
+	name := stableConfigSecretName(serviceID, volumeName)
+	item := path.Base(volumePath)
+	v.as.SetSecret(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: v.as.GetNamespace(),
+			Labels:    v.as.GetCommonLabels(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{item: []byte(content)},
+	})
+	if v.as.GetVirtualMachine() != nil {
+		volumeLabel := stableVMConfigVolumeLabel(serviceID, volumeName)
+		define.vmVolume = append(define.vmVolume, kubevirtv1.Volume{
+			Name: name,
+			VolumeSource: kubevirtv1.VolumeSource{
+				Secret: &kubevirtv1.SecretVolumeSource{
+					SecretName:  name,
+					VolumeLabel: volumeLabel,
+				},
+			},
+		})
+		define.vmDisk = append(define.vmDisk, kubevirtv1.Disk{
+			Name: name,
+			DiskDevice: kubevirtv1.DiskDevice{
+				CDRom: &kubevirtv1.CDRomTarget{
+					Bus: kubevirtv1.DiskBusSATA,
+				},
+			},
+		})
+		define.AddVMGuestFile(VMGuestFile{
+			VolumeName:  name,
+			VolumeLabel: volumeLabel,
+			SourceFile:  item,
+			TargetPath:  volumePath,
+			Mode:        formatVMGuestFileMode(mode),
+		})
+		return
+	}
+	define.SetVolumeSecret(name, name, item, volumePath, mode)
+}
+
+func stableConfigSecretName(serviceID, volumeName string) string {
+	hash, err := util.CreateHashString(fmt.Sprintf("%s:%s", serviceID, volumeName))
+	if err != nil || len(hash) < 16 {
+		return "cfgsec-" + util.NewUUID()[:16]
+	}
+	return "cfgsec-" + hash[:16]
+}
+
 func stableVMConfigMapName(serviceID, volumeName string) string {
 	serviceID = strings.TrimSpace(serviceID)
 	volumeName = strings.TrimSpace(volumeName)
@@ -0,0 +1,69 @@
+package volume
+
+import (
+	"testing"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	appmtypes "github.com/goodrain/rainbond/worker/appm/types/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// capability_id: rainbond.compose.secrets-configs
+func TestConfigFileVolumeMountsComponentSecret(t *testing.T) {
+	as := &appmtypes.AppService{
+		AppServiceBase: appmtypes.AppServiceBase{
+			ServiceID: "service-1",
+			TenantID:  "tenant-1",
+			AppID:     "app-1",
+		},
+	}
+	as.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	mode := int32(400)
+	serviceVolume := &dbmodel.TenantServiceVolume{
+		ServiceID:  "service-1",
+		VolumeName: "db-password",
+		VolumePath: "/run/secrets/db_password",
+		VolumeType: "config-file",
+		Mode:       &mode,
+	}
+	manager := volumeManagerStub{configFileDao: tenantServiceConfigFileDaoStub{
+		file: &dbmodel.TenantServiceConfigFile{
+			ServiceID:   "service-1",
+			VolumeName:  "db-password",
+			FileContent: "s3cr3t",
+			Secret:      true,
+		},
+	}}
+
+	// 应用级 Secret 不应被注入或挂载
+	appSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "composesecrets-app-1"},
+		Data:       map[string][]byte{"DB_PASSWORD": []byte("other")},
+	}
+	vol := NewVolumeManager(as, serviceVolume, nil, nil, nil, []*corev1.Secret{appSecret}, manager, false)
+	define := &Define{as: as}
+	if err := vol.CreateVolume(define); err != nil {
+		t.Fatal(err)
+	}
+	if len(as.GetConfigMaps()) != 0 {
+		t.Fatalf("secret content must not be rendered into configmaps, got %+v", as.GetConfigMaps())
+	}
+	secretName := stableConfigSecretName("service-1", "db-password")
+	secrets := as.GetSecrets(false)
+	if len(secrets) != 1 || secrets[0].Name != secretName || secrets[0].Namespace != "default" || len(secrets[0].Data) != 1 || string(secrets[0].Data["db_password"]) != "s3cr3t" {
+		t.Fatalf("expected a component secret holding only the config file, got %+v", secrets)
+	}
+	volumes := define.GetVolumes()
+	if len(volumes) != 1 || volumes[0].Secret == nil || volumes[0].Secret.SecretName != secretName {
+		t.Fatalf("expected secret volume, got %+v", volumes)
+	}
+	items := volumes[0].Secret.Items
+	if len(items) != 1 || items[0].Key != "db_password" || items[0].Path != "db_password" || *items[0].Mode != 0400 {
+		t.Fatalf("unexpected secret items %+v", items)
+	}
+	mounts := define.GetVolumeMounts()
+	if len(mounts) != 1 || mounts[0].Name != volumes[0].Name || mounts[0].MountPath != "/run/secrets/db_password" || mounts[0].SubPath != "db_password" || !mounts[0].ReadOnly {
+		t.Fatalf("unexpected volume mounts %+v", mounts)
+	}
+}
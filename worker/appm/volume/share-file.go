@@ -74,12 +74,17 @@ func (v *ShareFileVolume) CreateVolume(define *Define) error {
 		define.volumeMounts = append(define.volumeMounts, *vm)
 	} else if v.as.GetVirtualMachine() != nil {
 		importConfigs := map[string]vmDiskImportConfig{}
+		restoredClaims := map[string]string{}
 		if v.dbmanager != nil {
 			var err error
 			importConfigs, err = loadVMDiskImportConfigs(v.as.ServiceID, v.dbmanager)
 			if err != nil {
 				return err
 			}
+			restoredClaims, err = LoadVMRestoredClaims(v.as.ServiceID, v.dbmanager)
+			if err != nil {
+				return err
+			}
 		}
 		labels := v.as.GetCommonLabels(map[string]string{
 			"volume_name": volumeMountName,
@@ -108,7 +113,17 @@ func (v *ShareFileVolume) CreateVolume(define *Define) error {
 			)
 			importConfig = &cfg
 		}
-		vo, dvTemplate, manualClaim := buildVMVolumeSource(claim, labels, annotations, volumeMountPath, importConfig)
+		var (
+			vo          kubevirtv1.Volume
+			dvTemplate  *kubevirtv1.DataVolumeTemplateSpec
+			manualClaim bool
+		)
+		if restoredClaim, ok := restoredClaims[claim.Name]; ok && restoredClaim != "" {
+			// 磁盘已从快照恢复，直接挂载恢复出的 pvc，不再导入或创建空盘
+			vo = buildVMRestoredVolumeSource(claim, restoredClaim)
+		} else {
+			vo, dvTemplate, manualClaim = buildVMVolumeSource(claim, labels, annotations, volumeMountPath, importConfig)
+		}
 		if dvTemplate != nil {
 			define.vmDVTemplate = append(define.vmDVTemplate, *dvTemplate)
 		}
@@ -0,0 +1,99 @@
+package volume
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	snapshotv1 "kubevirt.io/api/snapshot/v1beta1"
+)
+
+// LoadVMRestoredClaims returns the pvc restored from a vm snapshot for each vm volume name.
+// VirtualMachineRestore always creates pvc with new names, the mapping keeps the component
+// on the restored disks across later stop/start.
+func LoadVMRestoredClaims(serviceID string, dbmanager db.Manager) (map[string]string, error) {
+	attr, err := dbmanager.ComponentK8sAttributeDao().GetByComponentIDAndName(serviceID, dbmodel.K8sAttributeNameVMRestoredClaims)
+	if err != nil {
+		return nil, err
+	}
+	if attr == nil || attr.AttributeValue == "" {
+		return map[string]string{}, nil
+	}
+	claims := map[string]string{}
+	if err := json.Unmarshal([]byte(attr.AttributeValue), &claims); err != nil {
+		return nil, fmt.Errorf("parse vm restored claims: %w", err)
+	}
+	return claims, nil
+}
+
+// SaveVMRestoredClaims merges the restored pvc of a vm restore into the component attribute.
+func SaveVMRestoredClaims(dbmanager db.Manager, tenantID, serviceID string, restored map[string]string) error {
+	claims, err := LoadVMRestoredClaims(serviceID, dbmanager)
+	if err != nil {
+		return err
+	}
+	for volumeName, claimName := range restored {
+		claims[volumeName] = claimName
+	}
+	value, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+	return dbmanager.ComponentK8sAttributeDao().CreateOrUpdateAttributesInBatch([]*dbmodel.ComponentK8sAttributes{
+		{
+			TenantID:       tenantID,
+			ComponentID:    serviceID,
+			Name:           dbmodel.K8sAttributeNameVMRestoredClaims,
+			SaveType:       "json",
+			AttributeValue: string(value),
+		},
+	})
+}
+
+func buildVMRestoredVolumeSource(claim *corev1.PersistentVolumeClaim, restoredClaim string) kubevirtv1.Volume {
+	logrus.Infof("vm volume source resolved: claim=%s restored_claim=%s mode=snapshot-restore", claim.Name, restoredClaim)
+	return kubevirtv1.Volume{
+		Name: claim.Name,
+		VolumeSource: kubevirtv1.VolumeSource{
+			PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+				PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: restoredClaim,
+				},
+			},
+		},
+	}
+}
+
+// MapVMRestoredClaims maps the pvc restored by a VirtualMachineRestore onto the vm volumes of the
+// target component. Volumes of the snapshot source are matched to the target by volume name, so a
+// snapshot can be restored into a new component created with the same storage definition.
+func MapVMRestoredClaims(restores []snapshotv1.VolumeRestore, source, target []*dbmodel.TenantServiceVolume) map[string]string {
+	targetClaims := make(map[string]string, len(target))
+	for _, volume := range target {
+		targetClaims[volume.VolumeName] = vmVolumeClaimName(volume)
+	}
+	sourceClaims := make(map[string]string, len(source))
+	for _, volume := range source {
+		if claimName, ok := targetClaims[volume.VolumeName]; ok {
+			sourceClaims[vmVolumeClaimName(volume)] = claimName
+		}
+	}
+	claims := make(map[string]string, len(restores))
+	for _, restore := range restores {
+		claimName, ok := sourceClaims[restore.VolumeName]
+		if !ok {
+			logrus.Warningf("restored vm volume %s has no matching volume in target component, skip it", restore.VolumeName)
+			continue
+		}
+		claims[claimName] = restore.PersistentVolumeClaimName
+	}
+	return claims
+}
+
+func vmVolumeClaimName(volume *dbmodel.TenantServiceVolume) string {
+	return fmt.Sprintf("manual%d", volume.ID)
+}
@@ -0,0 +1,96 @@
+package volume
+
+import (
+	"testing"
+
+	"github.com/goodrain/rainbond/db"
+	dbdao "github.com/goodrain/rainbond/db/dao"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	snapshotv1 "kubevirt.io/api/snapshot/v1beta1"
+)
+
+type restoredClaimsManagerStub struct {
+	db.Manager
+	attributeDao dbdao.ComponentK8sAttributeDao
+}
+
+func (m restoredClaimsManagerStub) ComponentK8sAttributeDao() dbdao.ComponentK8sAttributeDao {
+	return m.attributeDao
+}
+
+type componentK8sAttributeDaoStub struct {
+	dbdao.ComponentK8sAttributeDao
+	attributes map[string]*dbmodel.ComponentK8sAttributes
+}
+
+func (d *componentK8sAttributeDaoStub) GetByComponentIDAndName(componentID, name string) (*dbmodel.ComponentK8sAttributes, error) {
+	return d.attributes[componentID+"/"+name], nil
+}
+
+func (d *componentK8sAttributeDaoStub) CreateOrUpdateAttributesInBatch(attributes []*dbmodel.ComponentK8sAttributes) error {
+	for _, attr := range attributes {
+		d.attributes[attr.ComponentID+"/"+attr.Name] = attr
+	}
+	return nil
+}
+
+// capability_id: rainbond.vm-snapshot.restore-disks
+func TestMapVMRestoredClaims(t *testing.T) {
+	restores := []snapshotv1.VolumeRestore{
+		{VolumeName: "manual1", PersistentVolumeClaimName: "restore-abc-manual1"},
+		{VolumeName: "manual2", PersistentVolumeClaimName: "restore-abc-manual2"},
+		{VolumeName: "cloudinit", PersistentVolumeClaimName: "restore-abc-cloudinit"},
+	}
+	source := []*dbmodel.TenantServiceVolume{
+		{Model: dbmodel.Model{ID: 1}, VolumeName: "disk"},
+		{Model: dbmodel.Model{ID: 2}, VolumeName: "data"},
+	}
+
+	claims := MapVMRestoredClaims(restores, source, source)
+	if len(claims) != 2 || claims["manual1"] != "restore-abc-manual1" || claims["manual2"] != "restore-abc-manual2" {
+		t.Fatalf("unexpected claims restored to the same component: %#v", claims)
+	}
+
+	target := []*dbmodel.TenantServiceVolume{
+		{Model: dbmodel.Model{ID: 11}, VolumeName: "disk"},
+	}
+	claims = MapVMRestoredClaims(restores, source, target)
+	if len(claims) != 1 || claims["manual11"] != "restore-abc-manual1" {
+		t.Fatalf("unexpected claims restored to a new component: %#v", claims)
+	}
+}
+
+// capability_id: rainbond.vm-snapshot.restore-disks
+func TestShareFileVolumeUsesRestoredClaim(t *testing.T) {
+	attributeDao := &componentK8sAttributeDaoStub{attributes: map[string]*dbmodel.ComponentK8sAttributes{}}
+	dbmanager := restoredClaimsManagerStub{attributeDao: attributeDao}
+	if err := SaveVMRestoredClaims(dbmanager, "tenant-1", "service-1", map[string]string{"manual1": "restore-abc-manual1"}); err != nil {
+		t.Fatalf("save restored claims: %v", err)
+	}
+
+	as := newVMAppServiceForVolumeTest()
+	serviceVolume := &dbmodel.TenantServiceVolume{
+		Model:          dbmodel.Model{ID: 1},
+		ServiceID:      "service-1",
+		VolumeName:     "disk",
+		VolumePath:     "/disk",
+		VolumeType:     "nfs-storage",
+		AccessMode:     "RWX",
+		VolumeCapacity: 20,
+	}
+	manager := NewVolumeManager(as, serviceVolume, nil, nil, nil, nil, dbmanager, false)
+	define := &Define{as: as}
+	if err := manager.CreateVolume(define); err != nil {
+		t.Fatalf("create vm volume: %v", err)
+	}
+
+	if len(define.GetVMDataVolumeTemplates()) != 0 {
+		t.Fatalf("expected restored disk not to create a data volume, got %#v", define.GetVMDataVolumeTemplates())
+	}
+	if len(define.vmVolume) != 1 || define.vmVolume[0].PersistentVolumeClaim == nil {
+		t.Fatalf("expected restored disk to mount a pvc, got %#v", define.vmVolume)
+	}
+	if define.vmVolume[0].Name != "manual1" || define.vmVolume[0].PersistentVolumeClaim.ClaimName != "restore-abc-manual1" {
+		t.Fatalf("unexpected restored vm volume %#v", define.vmVolume[0])
+	}
+}
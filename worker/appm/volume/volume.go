@@ -356,6 +356,40 @@ func (v *Define) SetVolumeCMap(cmap *corev1.ConfigMap, k, p string, isReadOnly b
 	v.volumes = append(v.volumes, vo)
 }
 
+// SetVolumeSecret 将 Secret 中的单个配置项以文件形式挂载到 p
+func (v *Define) SetVolumeSecret(name, secretName, k, p string, mode *int32) {
+	vm := corev1.VolumeMount{
+		MountPath: p,
+		Name:      name,
+		ReadOnly:  true,
+		SubPath:   path.Base(p),
+	}
+	v.volumeMounts = append(v.volumeMounts, vm)
+	var defaultMode int32 = 0644
+	if mode != nil {
+		// convert int to octal
+		octal, _ := strconv.ParseInt(strconv.Itoa(int(*mode)), 8, 64)
+		defaultMode = int32(octal)
+	}
+	vo := corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: &defaultMode,
+				Items: []corev1.KeyToPath{
+					{
+						Key:  k,
+						Path: path.Base(p), // subpath
+						Mode: &defaultMode,
+					},
+				},
+			},
+		},
+	}
+	v.volumes = append(v.volumes, vo)
+}
+
 func convertRulesToEnvs(as *v1.AppService, dbmanager db.Manager, ports []*dbmodel.TenantServicesPort) (re []corev1.EnvVar) {
 	defDomain := fmt.Sprintf(".%s.%s.", as.ServiceAlias, as.TenantName)
 	httpRules, _ := dbmanager.HTTPRuleDao().ListByServiceID(as.ServiceID)
@@ -36,7 +36,6 @@ import (
 	"github.com/goodrain/rainbond/worker/gc"
 	"github.com/goodrain/rainbond/worker/handle"
 	"github.com/sirupsen/logrus"
-	grpc1 "google.golang.org/grpc"
 )
 
 var healthStatus = make(map[string]string, 1)
@@ -89,55 +88,64 @@ func (t *TaskManager) Start() error {
 func (t *TaskManager) Do() {
 	logrus.Info("start receive task from mq")
 	hostname, _ := os.Hostname()
-	for {
-		select {
-		case <-t.ctx.Done():
-			return
-		default:
-			data, err := t.client.Dequeue(t.ctx, &pb.DequeueRequest{Topic: client.WorkerTopic, ClientHost: hostname + "-worker"})
-			if err != nil {
-				if grpc1.ErrorDesc(err) == context.DeadlineExceeded.Error() {
-					continue
-				}
-				if grpc1.ErrorDesc(err) == "context timeout" {
-					continue
-				}
-				logrus.Error("receive task error.", err.Error())
-				time.Sleep(time.Second * 2)
-				continue
-			}
-			logrus.Debugf("receive a task: %v", data)
-			transData, err := model.TransTask(data)
+	// 任务逐个执行，每次只持有一条未确认的消息
+	messages := t.client.SubscribeTopic(t.ctx, client.WorkerTopic, client.WorkerConsumerGroup, hostname+"-worker", 1)
+	for data := range messages {
+		logrus.Debugf("receive a task: %v", data)
+		transData, err := model.TransTask(data)
+		if err != nil {
+			logrus.Error("trans mq msg data error ", err.Error())
+			t.ack(data)
+			continue
+		}
+		rc := t.handleManager.AnalystToExec(transData)
+		if rc != nil && rc != handle.ErrCallback {
+			logrus.Warningf("execute task: %v", rc)
+			TaskError++
+			t.nack(data, rc)
+		} else if rc != nil && rc == handle.ErrCallback {
+			logrus.Errorf("err callback; analyst to exet: %v", rc)
+			ctx, cancel := context.WithCancel(t.ctx)
+			reply, err := t.client.Nack(ctx, &pb.AckRequest{Topic: client.WorkerTopic, MessageId: data.MessageId, Requeue: true})
+			cancel()
+			logrus.Debugf("retry task by nack message ,reply is %v", reply)
 			if err != nil {
-				logrus.Error("trans mq msg data error ", err.Error())
+				logrus.Errorf("nack task %v of mq topic %v Error, it will be redelivered after visibility timeout", data, client.WorkerTopic)
 				continue
 			}
-			rc := t.handleManager.AnalystToExec(transData)
-			if rc != nil && rc != handle.ErrCallback {
-				logrus.Warningf("execute task: %v", rc)
-				TaskError++
-			} else if rc != nil && rc == handle.ErrCallback {
-				logrus.Errorf("err callback; analyst to exet: %v", rc)
-				ctx, cancel := context.WithCancel(t.ctx)
-				reply, err := t.client.Enqueue(ctx, &pb.EnqueueRequest{
-					Topic:   client.WorkerTopic,
-					Message: data,
-				})
-				cancel()
-				logrus.Debugf("retry send task to mq ,reply is %v", reply)
-				if err != nil {
-					logrus.Errorf("enqueue task %v to mq topic %v Error", data, client.WorkerTopic)
-					continue
-				}
-				//if handle is waiting, sleep 3 second
-				time.Sleep(time.Second * 3)
-			} else {
-				TaskNum++
-			}
+			//if handle is waiting, sleep 3 second
+			time.Sleep(time.Second * 3)
+		} else {
+			TaskNum++
+			t.ack(data)
 		}
 	}
 }
 
+// ack 任务已经处理完成，通知消息队列删除该消息
+func (t *TaskManager) ack(data *pb.TaskMessage) {
+	ctx, cancel := context.WithTimeout(t.ctx, time.Second*5)
+	defer cancel()
+	if _, err := t.client.Ack(ctx, &pb.AckRequest{Topic: client.WorkerTopic, MessageId: data.MessageId}); err != nil {
+		logrus.Warningf("ack task(%s) message failure %s, it may be redelivered", data.TaskId, err.Error())
+	}
+}
+
+// nack 任务执行失败，由消息队列按照重试策略重新投递，用尽重试次数后进入死信主题。
+// 任务内容校验失败等不可重试的错误直接进入死信主题。
+func (t *TaskManager) nack(data *pb.TaskMessage, taskErr error) {
+	ctx, cancel := context.WithTimeout(t.ctx, time.Second*5)
+	defer cancel()
+	if _, err := t.client.Nack(ctx, nackRequest(client.WorkerTopic, data.MessageId, taskErr)); err != nil {
+		logrus.Errorf("nack task(%s) message failure %s, it will be redelivered after visibility timeout", data.TaskId, err.Error())
+	}
+}
+
+// nackRequest 构造拒绝消息的请求，不可重试的错误不再重试而是直接进入死信主题
+func nackRequest(topic, messageID string, taskErr error) *pb.AckRequest {
+	return &pb.AckRequest{Topic: topic, MessageId: messageID, Reason: taskErr.Error(), DeadLetter: client.IsNonRetryable(taskErr)}
+}
+
 // Stop 停止
 func (t *TaskManager) Stop() error {
 	logrus.Info("discover manager is stoping")
@@ -219,6 +219,13 @@ func NewTaskBody(taskType string, body []byte) TaskBody {
 			return nil
 		}
 		return b
+	case "vm_restore":
+		b := VMRestoreTaskBody{}
+		err := ffjson.Unmarshal(body, &b)
+		if err != nil {
+			return nil
+		}
+		return b
 	default:
 		return DefaultTaskBody{}
 	}
@@ -253,6 +260,8 @@ func CreateTaskBody(taskType string) TaskBody {
 		return RefreshHPATaskBody{}
 	case "build_from_kubeblocks":
 		return BuildFromKubeBlocksTaskBody{}
+	case "vm_restore":
+		return VMRestoreTaskBody{}
 	default:
 		return DefaultTaskBody{}
 	}
@@ -435,6 +444,18 @@ type DeleteK8sResourceTaskBody struct {
 	ResourceYaml string `json:"resource_yaml"`
 }
 
+// VMRestoreTaskBody 虚拟机快照恢复任务主体
+type VMRestoreTaskBody struct {
+	TenantID string `json:"tenant_id"`
+	// ServiceID the component restored to
+	ServiceID string `json:"service_id"`
+	// SourceServiceID the component the snapshot was taken from
+	SourceServiceID string `json:"source_service_id"`
+	SnapshotName    string `json:"snapshot_name"`
+	RestoreName     string `json:"restore_name"`
+	EventID         string `json:"event_id"`
+}
+
 // BuildFromKubeBlocksTaskBody KubeBlocks组件构建操作任务主体
 type BuildFromKubeBlocksTaskBody struct {
 	TenantID      string            `json:"tenant_id"`
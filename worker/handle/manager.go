@@ -39,6 +39,7 @@ import (
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
 	"github.com/goodrain/rainbond/event"
+	mqclient "github.com/goodrain/rainbond/mq/client"
 	"github.com/goodrain/rainbond/util"
 	"github.com/goodrain/rainbond/worker/appm/controller"
 	"github.com/goodrain/rainbond/worker/appm/conversion"
@@ -142,6 +143,9 @@ func (m *Manager) AnalystToExec(task *model.Task) error {
 	case "build_from_kubeblocks":
 		logrus.Info("start a 'build_from_kubeblocks' task worker")
 		return m.buildFromKubeBlocksExec(task)
+	case "vm_restore":
+		logrus.Info("start a 'vm_restore' task worker")
+		return m.vmRestoreExec(task)
 	default:
 		if task.Type != "" {
 			logrus.Warning("task can not execute because no type is identified ->", task.Type)
@@ -155,7 +159,7 @@ func (m *Manager) startExec(task *model.Task) error {
 	body, ok := task.Body.(model.StartTaskBody)
 	if !ok {
 		logrus.Errorf("start body convert to taskbody error")
-		return fmt.Errorf("start body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("start body convert to taskbody error"))
 	}
 	logger := event.GetManager().GetLogger(body.EventID)
 	appService := m.store.GetAppService(body.ServiceID)
@@ -189,7 +193,7 @@ func (m *Manager) stopExec(task *model.Task) error {
 	body, ok := task.Body.(model.StopTaskBody)
 	if !ok {
 		logrus.Errorf("stop body convert to taskbody error")
-		return fmt.Errorf("stop body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("stop body convert to taskbody error"))
 	}
 	logger := event.GetManager().GetLogger(body.EventID)
 	appService := m.store.GetAppService(body.ServiceID)
@@ -217,7 +221,7 @@ func (m *Manager) restartExec(task *model.Task) error {
 	body, ok := task.Body.(model.RestartTaskBody)
 	if !ok {
 		logrus.Errorf("stop body convert to taskbody error")
-		return fmt.Errorf("stop body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("stop body convert to taskbody error"))
 	}
 	logger := event.GetManager().GetLogger(body.EventID)
 	appService := m.store.GetAppService(body.ServiceID)
@@ -242,11 +246,47 @@ func (m *Manager) restartExec(task *model.Task) error {
 	return nil
 }
 
+// vmRestoreExec restore the vm of a component from a vm snapshot
+func (m *Manager) vmRestoreExec(task *model.Task) error {
+	body, ok := task.Body.(model.VMRestoreTaskBody)
+	if !ok {
+		logrus.Errorf("vm_restore body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("vm_restore body convert to taskbody error"))
+	}
+	logger := event.GetManager().GetLogger(body.EventID)
+	appService := m.store.GetAppService(body.ServiceID)
+	if appService == nil {
+		// the target component is closed, build its model to know the vm to restore
+		newAppService, err := conversion.InitAppService(false, m.dbmanager, body.ServiceID, nil)
+		if err != nil {
+			logrus.Errorf("component init create failure:%s", err.Error())
+			logger.Error(util.Translation("component init create failure"), event.GetCallbackLoggerOption())
+			event.GetManager().ReleaseLogger(logger)
+			return fmt.Errorf("application init create failure")
+		}
+		appService = newAppService
+	}
+	appService.Logger = logger
+	err := m.controllerManager.RestoreVMController(controller.VMRestoreOption{
+		RestoreName:     body.RestoreName,
+		SnapshotName:    body.SnapshotName,
+		SourceServiceID: body.SourceServiceID,
+	}, *appService)
+	if err != nil {
+		logrus.Errorf("component run vm restore controller failure:%s", err.Error())
+		logger.Error("component run vm restore controller failure", event.GetCallbackLoggerOption())
+		event.GetManager().ReleaseLogger(logger)
+		return fmt.Errorf("component vm restore failure")
+	}
+	logrus.Infof("service(%s) %s working is running.", body.ServiceID, "vm_restore")
+	return nil
+}
+
 func (m *Manager) horizontalScalingExec(task *model.Task) (err error) {
 	body, ok := task.Body.(model.HorizontalScalingTaskBody)
 	if !ok {
 		logrus.Errorf("horizontal_scaling body convert to taskbody error")
-		err = fmt.Errorf("a")
+		err = mqclient.NonRetryable(fmt.Errorf("a"))
 		return
 	}
 
@@ -307,7 +347,7 @@ func (m *Manager) verticalScalingExec(task *model.Task) error {
 	body, ok := task.Body.(model.VerticalScalingTaskBody)
 	if !ok {
 		logrus.Errorf("vertical_scaling body convert to taskbody error")
-		return fmt.Errorf("vertical_scaling body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("vertical_scaling body convert to taskbody error"))
 	}
 	logger := event.GetManager().GetLogger(body.EventID)
 	service, err := db.GetManager().TenantServiceDao().GetServiceByID(body.ServiceID)
@@ -352,7 +392,7 @@ func (m *Manager) rollingUpgradeExec(task *model.Task) error {
 	body, ok := task.Body.(model.RollingUpgradeTaskBody)
 	if !ok {
 		logrus.Error("rolling_upgrade body convert to taskbody error", task.Body)
-		return fmt.Errorf("rolling_upgrade body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("rolling_upgrade body convert to taskbody error"))
 	}
 	logger := event.GetManager().GetLogger(body.EventID)
 	newAppService, err := conversion.InitAppService(body.DryRun, m.dbmanager, body.ServiceID, body.Configs)
@@ -407,7 +447,7 @@ func (m *Manager) applyRuleExec(task *model.Task) error {
 	body, ok := task.Body.(*model.ApplyRuleTaskBody)
 	if !ok {
 		logrus.Errorf("Can't convert %s to *model.ApplyRuleTaskBody", reflect.TypeOf(task.Body))
-		return fmt.Errorf("can't convert %s to *model.ApplyRuleTaskBody", reflect.TypeOf(task.Body))
+		return mqclient.NonRetryable(fmt.Errorf("can't convert %s to *model.ApplyRuleTaskBody", reflect.TypeOf(task.Body)))
 	}
 	svc, err := db.GetManager().TenantServiceDao().GetServiceByID(body.ServiceID)
 	if err != nil {
@@ -456,7 +496,7 @@ func (m *Manager) applyPluginConfig(task *model.Task) error {
 	body, ok := task.Body.(*model.ApplyPluginConfigTaskBody)
 	if !ok {
 		logrus.Errorf("Can't convert %s to *model.ApplyPluginConfigTaskBody", reflect.TypeOf(task.Body))
-		return fmt.Errorf("can't convert %s to *model.ApplyPluginConfigTaskBody", reflect.TypeOf(task.Body))
+		return mqclient.NonRetryable(fmt.Errorf("can't convert %s to *model.ApplyPluginConfigTaskBody", reflect.TypeOf(task.Body)))
 	}
 	oldAppService := m.store.GetAppService(body.ServiceID)
 	if oldAppService == nil || oldAppService.IsClosed() {
@@ -480,7 +520,7 @@ func (m *Manager) applyPluginConfig(task *model.Task) error {
 func (m *Manager) ExecServiceGCTask(task *model.Task) error {
 	serviceGCReq, ok := task.Body.(model.ServiceGCTaskBody)
 	if !ok {
-		return fmt.Errorf("can not convert the request body to 'ServiceGCTaskBody'")
+		return mqclient.NonRetryable(fmt.Errorf("can not convert the request body to 'ServiceGCTaskBody'"))
 	}
 
 	m.garbageCollector.DelLogFile(serviceGCReq)
@@ -496,7 +536,7 @@ func (m *Manager) deleteTenant(task *model.Task) (err error) {
 	body, ok := task.Body.(*model.DeleteTenantTaskBody)
 	if !ok {
 		logrus.Errorf("can't convert %s to *model.DeleteTenantTaskBody", reflect.TypeOf(task.Body))
-		err = fmt.Errorf("can't convert %s to *model.DeleteTenantTaskBody", reflect.TypeOf(task.Body))
+		err = mqclient.NonRetryable(fmt.Errorf("can't convert %s to *model.DeleteTenantTaskBody", reflect.TypeOf(task.Body)))
 		return
 	}
 
@@ -544,7 +584,7 @@ func (m *Manager) ExecRefreshHPATask(task *model.Task) error {
 	body, ok := task.Body.(*model.RefreshHPATaskBody)
 	if !ok {
 		logrus.Errorf("exec task 'refreshhpa'; wrong type: %v", reflect.TypeOf(task))
-		return fmt.Errorf("exec task 'refreshhpa': wrong input")
+		return mqclient.NonRetryable(fmt.Errorf("exec task 'refreshhpa': wrong input"))
 	}
 
 	logger := event.GetManager().GetLogger(body.EventID)
@@ -582,7 +622,7 @@ func (m *Manager) ExecRefreshHPATask(task *model.Task) error {
 func (m *Manager) ExecApplyRegistryAuthSecretTask(task *model.Task) error {
 	body, ok := task.Body.(*model.ApplyRegistryAuthSecretTaskBody)
 	if !ok {
-		return fmt.Errorf("can't convert %s to *model.ApplyRegistryAuthSecretTaskBody", reflect.TypeOf(task.Body))
+		return mqclient.NonRetryable(fmt.Errorf("can't convert %s to *model.ApplyRegistryAuthSecretTaskBody", reflect.TypeOf(task.Body)))
 	}
 	tenant, err := m.dbmanager.TenantDao().GetTenantByUUID(body.TenantID)
 	if err != nil {
@@ -656,7 +696,7 @@ func (m *Manager) RefreshMapper() error {
 func (m *Manager) DeleteK8sResource(task *model.Task) error {
 	body, ok := task.Body.(*model.DeleteK8sResourceTaskBody)
 	if !ok {
-		return fmt.Errorf("can't convert %s to *model.DeleteK8sResourceTaskBody", reflect.TypeOf(task.Body))
+		return mqclient.NonRetryable(fmt.Errorf("can't convert %s to *model.DeleteK8sResourceTaskBody", reflect.TypeOf(task.Body)))
 	}
 	var buildResourceList []*model.BuildResource
 	dc, err := dynamic.NewForConfig(m.k8sComponent.RestConfig)
@@ -728,7 +768,7 @@ func (m *Manager) buildFromKubeBlocksExec(task *model.Task) error {
 	body, ok := task.Body.(model.BuildFromKubeBlocksTaskBody)
 	if !ok {
 		logrus.Errorf("build_from_kubeblocks body convert to taskbody error")
-		return fmt.Errorf("build_from_kubeblocks body convert to taskbody error")
+		return mqclient.NonRetryable(fmt.Errorf("build_from_kubeblocks body convert to taskbody error"))
 	}
 	
 	logger := event.GetManager().GetLogger(body.EventID)
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"time"
 
+	apisixversioned "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned"
 	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	rainbondlistersv1alpha1 "github.com/goodrain/rainbond/pkg/generated/listers/rainbond/v1alpha1"
 	"github.com/goodrain/rainbond/util/apply"
@@ -58,6 +59,8 @@ type Reconciler struct {
 	Scheme               *runtime.Scheme
 	concurrentReconciles int
 	applyer              apply.Applicator
+	apisixClient         apisixversioned.Interface
+	gatewayZone          string
 	discoverPool         *DiscoverPool
 	discoverNum          prometheus.Gauge
 
@@ -92,6 +95,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (res recon
 	if component.DeletionTimestamp != nil {
 		log.Infof("component %s will be deleted", req)
 		r.discoverPool.RemoveDiscover(component)
+		if err := r.releaseWeightedUpstreams(ctx, log, component); err != nil {
+			log.Errorf("restore routes of component %s failure %s", req, err.Error())
+			return commonResult, nil
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -169,7 +176,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (res recon
 			ep := createEndpointsOnlyOnePort(component, svc, component.Status.Endpoints)
 			if ep != nil {
 				controllerutil.SetControllerReference(component, ep, r.Scheme)
+				r.applyEndpointSlices(ctx, log, component, ep, component.Status.Endpoints)
 				r.applyEndpointService(ctx, log, &svc, ep)
+				r.applyWeightedUpstream(ctx, log, component, &svc, int(svc.Spec.Ports[0].Port), component.Status.Endpoints)
 			}
 		} else {
 			for _, service := range services.Items {
@@ -182,7 +191,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (res recon
 					}
 					endpoint := createEndpoint(component, &service, sourceEndpoint)
 					controllerutil.SetControllerReference(component, &endpoint, r.Scheme)
+					r.applyEndpointSlices(ctx, log, component, &endpoint, sourceEndpoint)
 					r.applyEndpointService(ctx, log, &service, &endpoint)
+					r.applyWeightedUpstream(ctx, log, component, &service, int(port.Port), sourceEndpoint)
 				}
 			}
 		}
@@ -202,7 +213,9 @@ func (r *Reconciler) applyEndpointService(ctx context.Context, log *logrus.Entry
 	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: ep.Namespace, Name: ep.Name}, &old); err == nil {
 		// no change not apply
 		if reflect.DeepEqual(old.Subsets, ep.Subsets) &&
-			reflect.DeepEqual(old.Annotations, ep.Annotations) {
+			reflect.DeepEqual(old.Annotations, ep.Annotations) &&
+			topologyAware(&old) == topologyAware(ep) &&
+			(svc.Annotations[corev1.AnnotationTopologyMode] == "Auto") == topologyAware(ep) {
 			return
 		}
 	}
@@ -210,7 +223,9 @@ func (r *Reconciler) applyEndpointService(ctx context.Context, log *logrus.Entry
 		log.Errorf("apply endpoint for service %s failure %s", svc.Name, err.Error())
 	}
 
-	svc.Annotations = ep.Annotations
+	// List 返回的 Service 没有 TypeMeta，apply 需要根据它读取已有的对象
+	svc.TypeMeta = metav1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+	svc.Annotations = serviceAnnotations(ep)
 	if err := r.applyer.Apply(ctx, svc); err != nil {
 		log.Errorf("apply service(%s) for updating annotation: %v", svc.Name, err)
 	}
@@ -408,7 +423,7 @@ func (r *Reconciler) Collect(ch chan<- prometheus.Metric) {
 }
 
 // Setup adds a controller that reconciles AppDeployment.
-func Setup(ctx context.Context, mgr ctrl.Manager) (*Reconciler, error) {
+func Setup(ctx context.Context, mgr ctrl.Manager, gatewayZone string) (*Reconciler, error) {
 	informer, err := mgr.GetCache().GetInformerForKind(ctx, v1alpha1.SchemeGroupVersion.WithKind("ThirdComponent"))
 	if err != nil {
 		return nil, errors.WithMessage(err, "get informer for thirdcomponent")
@@ -417,11 +432,18 @@ func Setup(ctx context.Context, mgr ctrl.Manager) (*Reconciler, error) {
 
 	recorder := mgr.GetEventRecorderFor("thirdcomponent-controller")
 
+	apisixClient, err := apisixversioned.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, errors.WithMessage(err, "create apisix client")
+	}
+
 	r := &Reconciler{
-		Client:     mgr.GetClient(),
-		restConfig: mgr.GetConfig(),
-		Scheme:     mgr.GetScheme(),
-		applyer:    apply.NewAPIApplicator(mgr.GetClient()),
+		Client:       mgr.GetClient(),
+		restConfig:   mgr.GetConfig(),
+		Scheme:       mgr.GetScheme(),
+		applyer:      apply.NewAPIApplicator(mgr.GetClient()),
+		apisixClient: apisixClient,
+		gatewayZone:  gatewayZone,
 		discoverNum: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "controller",
 			Name:      "third_component_discover_number",
@@ -0,0 +1,139 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+const catalogCheckCritical = "critical"
+
+// catalogServiceEntry Consul health API 返回的服务实例
+type catalogServiceEntry struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		ID      string `json:"ID"`
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+		Weights struct {
+			Passing int `json:"Passing"`
+		} `json:"Weights"`
+	} `json:"Service"`
+	Checks []struct {
+		Status string `json:"Status"`
+	} `json:"Checks"`
+}
+
+// catalogResolver 通过 Consul 兼容的服务目录 HTTP API 解析实例
+type catalogResolver struct {
+	component *v1alpha1.ThirdComponent
+	source    *v1alpha1.CatalogSource
+	client    *http.Client
+}
+
+func newCatalogResolver(component *v1alpha1.ThirdComponent) (*catalogResolver, error) {
+	source := component.Spec.EndpointSource.Catalog
+	if source.Address == "" || source.Service == "" {
+		return nil, fmt.Errorf("catalog address and service can not be empty")
+	}
+	return &catalogResolver{
+		component: component,
+		source:    source,
+		client:    &http.Client{Timeout: registryResolveTimeout},
+	}, nil
+}
+
+func (c *catalogResolver) url() string {
+	address := strings.TrimSuffix(c.source.Address, "/")
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+	query := url.Values{}
+	if c.source.Datacenter != "" {
+		query.Set("dc", c.source.Datacenter)
+	}
+	for _, tag := range c.source.Tags {
+		query.Add("tag", tag)
+	}
+	u := fmt.Sprintf("%s/v1/health/service/%s", address, url.PathEscape(c.source.Service))
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *catalogResolver) resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.source.Token != "" {
+		req.Header.Set("X-Consul-Token", c.source.Token)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query catalog service %s failure %s", c.source.Service, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("query catalog service %s failure, status code %d: %s", c.source.Service, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var entries []catalogServiceEntry
+	if err := json.NewDecoder(res.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decode catalog service %s failure %s", c.source.Service, err.Error())
+	}
+	var endpoints []*v1alpha1.ThirdComponentEndpointStatus
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		address := v1alpha1.NewEndpointAddress(host, entry.Service.Port)
+		if address == nil {
+			continue
+		}
+		status := v1alpha1.EndpointReady
+		for _, check := range entry.Checks {
+			if check.Status == catalogCheckCritical {
+				status = v1alpha1.EndpointUnhealthy
+				break
+			}
+		}
+		endpoints = append(endpoints, &v1alpha1.ThirdComponentEndpointStatus{
+			Address:     *address,
+			Name:        entry.Service.ID,
+			ServicePort: servicePort(c.component),
+			Status:      status,
+			Weight:      registryWeight(entry.Service.Weights.Passing),
+		})
+	}
+	return endpoints, nil
+}
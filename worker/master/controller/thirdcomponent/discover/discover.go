@@ -63,6 +63,20 @@ func NewDiscover(component *v1alpha1.ThirdComponent,
 			lister:    lister,
 		}, nil
 	}
+	if source := component.Spec.EndpointSource.DNS; source != nil {
+		resolver, err := newDNSResolver(component)
+		if err != nil {
+			return nil, err
+		}
+		return newRegistryDiscover(component, resolver, source.RefreshIntervalSeconds), nil
+	}
+	if source := component.Spec.EndpointSource.Catalog; source != nil {
+		resolver, err := newCatalogResolver(component)
+		if err != nil {
+			return nil, err
+		}
+		return newRegistryDiscover(component, resolver, source.RefreshIntervalSeconds), nil
+	}
 	return nil, fmt.Errorf("not support source type")
 }
 
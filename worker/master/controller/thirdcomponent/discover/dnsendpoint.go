@@ -0,0 +1,121 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+// dnsResolver 通过 DNS 的 SRV 或 A 记录解析实例
+type dnsResolver struct {
+	component *v1alpha1.ThirdComponent
+	source    *v1alpha1.DNSSource
+	resolver  *net.Resolver
+}
+
+func newDNSResolver(component *v1alpha1.ThirdComponent) (*dnsResolver, error) {
+	source := component.Spec.EndpointSource.DNS
+	if source.Name == "" {
+		return nil, fmt.Errorf("dns name can not be empty")
+	}
+	switch strings.ToUpper(source.Type) {
+	case "", v1alpha1.DNSRecordA, v1alpha1.DNSRecordSRV:
+	default:
+		return nil, fmt.Errorf("not support dns record type %s", source.Type)
+	}
+	resolver := net.DefaultResolver
+	if source.Server != "" {
+		server := source.Server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &dnsResolver{component: component, source: source, resolver: resolver}, nil
+}
+
+func (d *dnsResolver) resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	if strings.ToUpper(d.source.Type) == v1alpha1.DNSRecordSRV {
+		return d.resolveSRV(ctx)
+	}
+	return d.resolveA(ctx)
+}
+
+func (d *dnsResolver) resolveSRV(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	_, records, err := d.resolver.LookupSRV(ctx, "", "", d.source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("lookup srv records of %s failure %s", d.source.Name, err.Error())
+	}
+	var endpoints []*v1alpha1.ThirdComponentEndpointStatus
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		ips, err := d.resolver.LookupIP(ctx, "ip4", target)
+		if err != nil {
+			return nil, fmt.Errorf("lookup address of %s failure %s", target, err.Error())
+		}
+		for _, ip := range ips {
+			// SRV 记录的权重作为实例的流量权重
+			endpoints = d.appendEndpoint(endpoints, target, ip.String(), int(record.Port), servicePort(d.component), registryWeight(int(record.Weight)))
+		}
+	}
+	return endpoints, nil
+}
+
+func (d *dnsResolver) resolveA(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	ips, err := d.resolver.LookupIP(ctx, "ip4", d.source.Name)
+	if err != nil {
+		return nil, fmt.Errorf("lookup a records of %s failure %s", d.source.Name, err.Error())
+	}
+	var endpoints []*v1alpha1.ThirdComponentEndpointStatus
+	for _, ip := range ips {
+		if d.source.Port != 0 {
+			endpoints = d.appendEndpoint(endpoints, d.source.Name, ip.String(), d.source.Port, servicePort(d.component), nil)
+			continue
+		}
+		for _, port := range d.component.Spec.Ports {
+			endpoints = d.appendEndpoint(endpoints, d.source.Name, ip.String(), port.Port, port.Port, nil)
+		}
+	}
+	return endpoints, nil
+}
+
+func (d *dnsResolver) appendEndpoint(endpoints []*v1alpha1.ThirdComponentEndpointStatus, name, ip string, port, servicePort int, weight *int) []*v1alpha1.ThirdComponentEndpointStatus {
+	address := v1alpha1.NewEndpointAddress(ip, port)
+	if address == nil {
+		return endpoints
+	}
+	return append(endpoints, &v1alpha1.ThirdComponentEndpointStatus{
+		Address:     *address,
+		Name:        name,
+		ServicePort: servicePort,
+		Status:      v1alpha1.EndpointReady,
+		Weight:      weight,
+	})
+}
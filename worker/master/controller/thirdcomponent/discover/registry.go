@@ -0,0 +1,197 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	defaultRefreshInterval = 30 * time.Second
+	registryResolveTimeout = 10 * time.Second
+)
+
+// resolver 从外部注册中心解析组件的实例地址
+type resolver interface {
+	resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error)
+}
+
+// registryDiscover 定期从 DNS 或服务目录解析实例，解析到的实例交给探针管理器做健康检查。
+// 解析失败时保留上一次的结果，避免注册中心短暂不可用时实例全部下线。
+type registryDiscover struct {
+	component *v1alpha1.ThirdComponent
+	resolver  resolver
+	interval  time.Duration
+
+	pmlock        sync.Mutex
+	proberManager prober.Manager
+
+	lock      sync.Mutex
+	endpoints []*v1alpha1.ThirdComponentEndpointStatus
+}
+
+func newRegistryDiscover(component *v1alpha1.ThirdComponent, resolver resolver, refreshIntervalSeconds int32) *registryDiscover {
+	interval := defaultRefreshInterval
+	if refreshIntervalSeconds > 0 {
+		interval = time.Duration(refreshIntervalSeconds) * time.Second
+	}
+	return &registryDiscover{
+		component: component,
+		resolver:  resolver,
+		interval:  interval,
+		endpoints: component.Status.Endpoints,
+	}
+}
+
+func (r *registryDiscover) GetComponent() *v1alpha1.ThirdComponent {
+	return r.component
+}
+
+func (r *registryDiscover) SetProberManager(proberManager prober.Manager) {
+	r.pmlock.Lock()
+	defer r.pmlock.Unlock()
+	r.proberManager = proberManager
+}
+
+func (r *registryDiscover) getProberManager() prober.Manager {
+	r.pmlock.Lock()
+	defer r.pmlock.Unlock()
+	return r.proberManager
+}
+
+func (r *registryDiscover) Discover(ctx context.Context, update chan *v1alpha1.ThirdComponent) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	var probeUpdates <-chan results.Update
+	if pm := r.getProberManager(); pm != nil {
+		probeUpdates = pm.Updates()
+	}
+	last := r.component.Status.Endpoints
+	notify := func() {
+		endpoints := r.withProbeResult(r.getEndpoints())
+		if reflect.DeepEqual(endpoints, last) {
+			return
+		}
+		newComponent := r.component.DeepCopy()
+		newComponent.Status.Endpoints = endpoints
+		select {
+		case update <- newComponent:
+			last = endpoints
+		case <-ctx.Done():
+		}
+	}
+	refresh := func() {
+		if err := r.refresh(ctx); err != nil {
+			logrus.Warningf("discover endpoints of third component %s/%s failure: %s", r.component.Namespace, r.component.Name, err.Error())
+			return
+		}
+		notify()
+	}
+	refresh()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-ticker.C:
+			refresh()
+		case <-probeUpdates:
+			notify()
+		}
+	}
+}
+
+func (r *registryDiscover) DiscoverOne(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	if err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return r.withProbeResult(r.getEndpoints()), nil
+}
+
+// refresh 解析最新的实例，并同步探针管理器中的探测任务
+func (r *registryDiscover) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, registryResolveTimeout)
+	defer cancel()
+	endpoints, err := r.resolver.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.endpoints = endpoints
+	r.lock.Unlock()
+	if pm := r.getProberManager(); pm != nil {
+		component := r.component.DeepCopy()
+		component.Status.Endpoints = endpoints
+		pm.AddThirdComponent(component)
+	}
+	return nil
+}
+
+func (r *registryDiscover) getEndpoints() []*v1alpha1.ThirdComponentEndpointStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	endpoints := make([]*v1alpha1.ThirdComponentEndpointStatus, 0, len(r.endpoints))
+	for _, ep := range r.endpoints {
+		endpoints = append(endpoints, ep.DeepCopy())
+	}
+	return endpoints
+}
+
+// withProbeResult 配置了探针时使用探测结果覆盖注册中心给出的状态
+func (r *registryDiscover) withProbeResult(endpoints []*v1alpha1.ThirdComponentEndpointStatus) []*v1alpha1.ThirdComponentEndpointStatus {
+	pm := r.getProberManager()
+	if pm == nil || !r.component.Spec.NeedProbe() {
+		return endpoints
+	}
+	for _, ep := range endpoints {
+		result, found := pm.GetResult(r.component.GetEndpointID(ep))
+		if !found {
+			// NotReady means the endpoint should not be online.
+			ep.Status = v1alpha1.EndpointNotReady
+			continue
+		}
+		if result != results.Success {
+			ep.Status = v1alpha1.EndpointUnhealthy
+		}
+	}
+	return endpoints
+}
+
+// servicePort 组件只有一个端口时，注册中心中的实例端口都映射到该端口
+func servicePort(component *v1alpha1.ThirdComponent) int {
+	if len(component.Spec.Ports) == 1 {
+		return component.Spec.Ports[0].Port
+	}
+	return 0
+}
+
+// registryWeight 注册中心中实例的权重，没有设置权重（小于等于 0）时使用默认权重
+func registryWeight(weight int) *int {
+	if weight <= 0 {
+		return nil
+	}
+	return &weight
+}
@@ -0,0 +1,352 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubDNSServer 本地 UDP DNS 服务，按记录表应答 SRV 和 A 查询
+type stubDNSServer struct {
+	conn net.PacketConn
+	lock sync.Mutex
+	srv  map[string][]dnsmessage.SRVResource
+	a    map[string][]string
+}
+
+func newStubDNSServer(t *testing.T) *stubDNSServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubDNSServer{conn: conn, srv: make(map[string][]dnsmessage.SRVResource), a: make(map[string][]string)}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *stubDNSServer) setSRV(name string, records ...dnsmessage.SRVResource) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.srv[name] = records
+}
+
+func (s *stubDNSServer) setA(name string, ips ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.a[name] = ips
+}
+
+func (s *stubDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+			continue
+		}
+		if resp, err := s.answer(req); err == nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *stubDNSServer) answer(req dnsmessage.Message) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q := req.Questions[0]
+	name := strings.TrimSuffix(q.Name.String(), ".")
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: req.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeSuccess},
+		Questions: req.Questions,
+	}
+	header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 1}
+	switch q.Type {
+	case dnsmessage.TypeSRV:
+		records, ok := s.srv[name]
+		if !ok {
+			resp.RCode = dnsmessage.RCodeNameError
+		}
+		for i := range records {
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &records[i]})
+		}
+	case dnsmessage.TypeA:
+		ips, ok := s.a[name]
+		if !ok {
+			resp.RCode = dnsmessage.RCodeNameError
+		}
+		for _, ip := range ips {
+			var a dnsmessage.AResource
+			copy(a.A[:], net.ParseIP(ip).To4())
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &a})
+		}
+	default:
+		if _, ok := s.a[name]; !ok {
+			resp.RCode = dnsmessage.RCodeNameError
+		}
+	}
+	return resp.Pack()
+}
+
+func srvRecord(target string, port uint16) dnsmessage.SRVResource {
+	return dnsmessage.SRVResource{Priority: 10, Weight: 10, Port: port, Target: dnsmessage.MustNewName(target + ".")}
+}
+
+func newRegistryComponent(source v1alpha1.ThirdComponentEndpointSource, ports ...int) *v1alpha1.ThirdComponent {
+	component := &v1alpha1.ThirdComponent{}
+	component.Namespace = "default"
+	component.Name = "legacy"
+	component.Spec.EndpointSource = source
+	for _, port := range ports {
+		component.Spec.Ports = append(component.Spec.Ports, &v1alpha1.ComponentPort{Port: port})
+	}
+	return component
+}
+
+func endpointAddresses(endpoints []*v1alpha1.ThirdComponentEndpointStatus) []string {
+	var addresses []string
+	for _, ep := range endpoints {
+		addresses = append(addresses, string(ep.Address)+"/"+string(ep.Status))
+	}
+	return addresses
+}
+
+// capability_id: rainbond.thirdcomponent.registry-discovery
+func TestDNSDiscoverSRVAndA(t *testing.T) {
+	server := newStubDNSServer(t)
+	server.setSRV("_http._tcp.legacy.example.com", srvRecord("node1.example.com", 8080), srvRecord("node2.example.com", 8081))
+	server.setA("node1.example.com", "10.0.0.1")
+	server.setA("node2.example.com", "10.0.0.2")
+	server.setA("legacy.example.com", "10.0.1.1", "10.0.1.2")
+
+	srv := newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "_http._tcp.legacy.example.com", Type: "srv", Server: server.conn.LocalAddr().String()},
+	}, 80)
+	discover, err := NewDiscover(srv, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := discover.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := endpointAddresses(endpoints)
+	if len(got) != 2 || !strings.Contains(strings.Join(got, ","), "10.0.0.1:8080/Ready") || !strings.Contains(strings.Join(got, ","), "10.0.0.2:8081/Ready") {
+		t.Fatalf("unexpected srv endpoints %v", got)
+	}
+	for _, ep := range endpoints {
+		// 实例端口映射到组件唯一的端口
+		if ep.ServicePort != 80 || !strings.HasPrefix(ep.Name, "node") {
+			t.Fatalf("unexpected srv endpoint %+v", ep)
+		}
+	}
+
+	// A 记录未指定端口时使用组件的每个端口
+	a := newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "legacy.example.com", Server: server.conn.LocalAddr().String()},
+	}, 80, 443)
+	discover, err = NewDiscover(a, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err = discover.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.1.1:80/Ready", "10.0.1.1:443/Ready", "10.0.1.2:80/Ready", "10.0.1.2:443/Ready"}
+	if got := endpointAddresses(endpoints); !reflect.DeepEqual(got, want) {
+		t.Fatalf("a endpoints = %v, want %v", got, want)
+	}
+
+	if _, err := NewDiscover(newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "legacy.example.com", Type: "MX"},
+	}), nil, nil); err == nil {
+		t.Fatal("unsupported record type should be rejected")
+	}
+}
+
+// capability_id: rainbond.thirdcomponent.registry-discovery
+func TestDNSDiscoverRefresh(t *testing.T) {
+	server := newStubDNSServer(t)
+	server.setA("legacy.example.com", "10.0.1.1")
+	component := newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "legacy.example.com", Port: 8080, Server: server.conn.LocalAddr().String()},
+	}, 80)
+	discover, err := NewDiscover(component, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	discover.(*registryDiscover).interval = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	update := make(chan *v1alpha1.ThirdComponent)
+	go discover.Discover(ctx, update)
+	next := func() []string {
+		select {
+		case c := <-update:
+			return endpointAddresses(c.Status.Endpoints)
+		case <-time.After(5 * time.Second):
+			t.Fatal("wait endpoints update timeout")
+		}
+		return nil
+	}
+	if got := next(); !reflect.DeepEqual(got, []string{"10.0.1.1:8080/Ready"}) {
+		t.Fatalf("unexpected endpoints %v", got)
+	}
+	server.setA("legacy.example.com", "10.0.1.1", "10.0.1.2")
+	if got := next(); !reflect.DeepEqual(got, []string{"10.0.1.1:8080/Ready", "10.0.1.2:8080/Ready"}) {
+		t.Fatalf("unexpected endpoints after refresh %v", got)
+	}
+
+	// 解析失败时保留上一次的实例
+	server.lock.Lock()
+	delete(server.a, "legacy.example.com")
+	server.lock.Unlock()
+	select {
+	case c := <-update:
+		t.Fatalf("endpoints should be kept when resolve failed, got %v", endpointAddresses(c.Status.Endpoints))
+	case <-time.After(300 * time.Millisecond):
+	}
+	if got := endpointAddresses(discover.(*registryDiscover).getEndpoints()); len(got) != 2 {
+		t.Fatalf("unexpected kept endpoints %v", got)
+	}
+}
+
+// capability_id: rainbond.thirdcomponent.registry-discovery
+func TestCatalogDiscover(t *testing.T) {
+	var lock sync.Mutex
+	var query, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path != "/v1/health/service/legacy-api" {
+			http.NotFound(w, r)
+			return
+		}
+		query, token = r.URL.RawQuery, r.Header.Get("X-Consul-Token")
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{
+				"Node":    map[string]interface{}{"Node": "node1", "Address": "10.0.0.1"},
+				"Service": map[string]interface{}{"ID": "legacy-api-1", "Address": "", "Port": 8080},
+				"Checks":  []map[string]interface{}{{"Status": "passing"}},
+			},
+			{
+				"Node":    map[string]interface{}{"Node": "node2", "Address": "10.0.0.2"},
+				"Service": map[string]interface{}{"ID": "legacy-api-2", "Address": "10.0.1.2", "Port": 8081},
+				"Checks":  []map[string]interface{}{{"Status": "passing"}, {"Status": "critical"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	component := newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		Catalog: &v1alpha1.CatalogSource{Address: server.URL, Service: "legacy-api", Tags: []string{"v1", "http"}, Datacenter: "dc1", Token: "secret"},
+	}, 80)
+	discover, err := NewDiscover(component, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := discover.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.1:8080/Ready", "10.0.1.2:8081/Unhealthy"}
+	if got := endpointAddresses(endpoints); !reflect.DeepEqual(got, want) {
+		t.Fatalf("catalog endpoints = %v, want %v", got, want)
+	}
+	if endpoints[0].Name != "legacy-api-1" || endpoints[0].ServicePort != 80 {
+		t.Fatalf("unexpected catalog endpoint %+v", endpoints[0])
+	}
+	lock.Lock()
+	if query != "dc=dc1&tag=v1&tag=http" || token != "secret" {
+		t.Fatalf("unexpected catalog request query %q token %q", query, token)
+	}
+	lock.Unlock()
+
+	component.Spec.EndpointSource.Catalog.Service = "missing"
+	if _, err := discover.DiscoverOne(context.Background()); err == nil {
+		t.Fatal("query missing service should fail")
+	}
+}
+
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestDiscoverEndpointWeightAndZone(t *testing.T) {
+	server := newStubDNSServer(t)
+	canary := srvRecord("canary.example.com", 8080)
+	canary.Weight = 5
+	server.setSRV("_http._tcp.legacy.example.com", srvRecord("stable.example.com", 8080), canary)
+	server.setA("stable.example.com", "10.0.0.1")
+	server.setA("canary.example.com", "10.0.0.2")
+	discover, err := NewDiscover(newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "_http._tcp.legacy.example.com", Type: "srv", Server: server.conn.LocalAddr().String()},
+	}, 80), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := discover.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := make(map[string]int)
+	for _, ep := range endpoints {
+		weights[ep.Name] = ep.GetWeight()
+	}
+	if !reflect.DeepEqual(weights, map[string]int{"stable.example.com": 10, "canary.example.com": 5}) {
+		t.Fatalf("srv weights = %v", weights)
+	}
+
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Service": map[string]interface{}{"ID": "api-1", "Address": "10.0.1.1", "Port": 8080, "Weights": map[string]int{"Passing": 20}}},
+		})
+	}))
+	defer catalog.Close()
+	discover, err = NewDiscover(newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		Catalog: &v1alpha1.CatalogSource{Address: catalog.URL, Service: "api"},
+	}, 80), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoints, err = discover.DiscoverOne(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].Weight == nil || endpoints[0].GetWeight() != 20 {
+		t.Fatalf("unexpected catalog endpoints %v", endpoints)
+	}
+
+	// 静态实例的权重和可用区直接来自组件定义，权重为 0 的实例不接收流量
+	weight, drained := 90, 0
+	static := newRegistryComponent(v1alpha1.ThirdComponentEndpointSource{
+		StaticEndpoints: []*v1alpha1.ThirdComponentEndpoint{
+			{Address: "10.0.2.1:8080", Name: "a", Weight: &weight, Zone: "zone-a"},
+			{Address: "10.0.2.2:8080", Name: "b", Zone: "zone-b"},
+			{Address: "10.0.2.3:8080", Name: "c", Weight: &drained},
+		},
+	}, 8080)
+	discover, err = NewDiscover(static, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoints, err = discover.DiscoverOne(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 3 || endpoints[0].GetWeight() != 90 || endpoints[0].Zone != "zone-a" ||
+		endpoints[1].GetWeight() != v1alpha1.DefaultEndpointWeight || endpoints[1].Zone != "zone-b" || endpoints[2].GetWeight() != 0 {
+		t.Fatalf("unexpected static endpoints %+v %+v", endpoints[0], endpoints[1])
+	}
+}
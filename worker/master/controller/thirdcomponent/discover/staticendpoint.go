@@ -44,6 +44,8 @@ func (s *staticEndpoint) DiscoverOne(ctx context.Context) ([]*v1alpha1.ThirdComp
 				endpoints = append(endpoints, &v1alpha1.ThirdComponentEndpointStatus{
 					Address: *address,
 					Name:    ep.Name,
+					Weight:  ep.Weight,
+					Zone:    ep.Zone,
 				})
 			}
 		} else {
@@ -53,6 +55,8 @@ func (s *staticEndpoint) DiscoverOne(ctx context.Context) ([]*v1alpha1.ThirdComp
 					endpoints = append(endpoints, &v1alpha1.ThirdComponentEndpointStatus{
 						Address: *address,
 						Name:    ep.Name,
+						Weight:  ep.Weight,
+						Zone:    ep.Zone,
 					})
 				}
 			}
@@ -108,7 +108,7 @@ func (d *DiscoverPool) newWorker(dis dis.Discover) *Worker {
 	}
 
 	component := dis.GetComponent()
-	if component.Spec.IsStaticEndpoints() {
+	if component.Spec.IsStaticEndpoints() || component.Spec.IsRegistryEndpoints() {
 		proberManager := prober.NewManager(d.recorder)
 		dis.SetProberManager(proberManager)
 		worker.proberManager = proberManager
@@ -135,7 +135,7 @@ func (d *DiscoverPool) AddDiscover(dis dis.Discover) {
 		return
 	}
 	worker := d.newWorker(dis)
-	if component.Spec.IsStaticEndpoints() {
+	if component.Spec.IsStaticEndpoints() || component.Spec.IsRegistryEndpoints() {
 		worker.proberManager.AddThirdComponent(dis.GetComponent())
 	}
 	go worker.Start()
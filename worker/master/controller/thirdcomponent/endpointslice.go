@@ -0,0 +1,217 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package thirdcomponent
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// endpointSliceManagedBy 标识由第三方组件控制器维护的 EndpointSlice
+const endpointSliceManagedBy = "thirdcomponent.rainbond.io"
+
+// createEndpointSlices 根据 Endpoints 生成携带可用区信息的 EndpointSlice，没有实例设置可用区时返回 nil
+func createEndpointSlices(ep *corev1.Endpoints, sourceEndpoints []*v1alpha1.ThirdComponentEndpointStatus) []*discoveryv1.EndpointSlice {
+	zones := make(map[string]string)
+	for _, se := range sourceEndpoints {
+		if se.Zone != "" {
+			zones[se.Address.GetIP()] = se.Zone
+		}
+	}
+	if len(zones) == 0 {
+		return nil
+	}
+
+	var slices []*discoveryv1.EndpointSlice
+	for i, subset := range ep.Subsets {
+		// 一个 EndpointSlice 只能包含一种地址类型，IPv4 与 IPv6 实例分别生成
+		var ipv4, ipv6 *discoveryv1.EndpointSlice
+		newSlice := func(name string, addressType discoveryv1.AddressType) *discoveryv1.EndpointSlice {
+			slice := &discoveryv1.EndpointSlice{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "EndpointSlice",
+					APIVersion: "discovery.k8s.io/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ep.Namespace,
+					Labels: map[string]string{
+						discoveryv1.LabelServiceName: ep.Name,
+						discoveryv1.LabelManagedBy:   endpointSliceManagedBy,
+					},
+				},
+				AddressType: addressType,
+			}
+			for _, port := range subset.Ports {
+				port := port
+				slice.Ports = append(slice.Ports, discoveryv1.EndpointPort{
+					Name:        &port.Name,
+					Port:        &port.Port,
+					Protocol:    &port.Protocol,
+					AppProtocol: port.AppProtocol,
+				})
+			}
+			return slice
+		}
+		appendEndpoint := func(address corev1.EndpointAddress, ready bool) {
+			var slice *discoveryv1.EndpointSlice
+			switch addressType(address.IP) {
+			case discoveryv1.AddressTypeIPv4:
+				if ipv4 == nil {
+					ipv4 = newSlice(fmt.Sprintf("%s-zone-%d", ep.Name, i), discoveryv1.AddressTypeIPv4)
+				}
+				slice = ipv4
+			case discoveryv1.AddressTypeIPv6:
+				if ipv6 == nil {
+					ipv6 = newSlice(fmt.Sprintf("%s-zone-%d-ipv6", ep.Name, i), discoveryv1.AddressTypeIPv6)
+				}
+				slice = ipv6
+			default:
+				return
+			}
+			endpoint := discoveryv1.Endpoint{
+				Addresses:  []string{address.IP},
+				Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+				TargetRef:  address.TargetRef,
+			}
+			if zone, ok := zones[address.IP]; ok {
+				zone := zone
+				endpoint.Zone = &zone
+			}
+			slice.Endpoints = append(slice.Endpoints, endpoint)
+		}
+		for _, address := range subset.Addresses {
+			appendEndpoint(address, true)
+		}
+		for _, address := range subset.NotReadyAddresses {
+			appendEndpoint(address, false)
+		}
+		for _, slice := range []*discoveryv1.EndpointSlice{ipv4, ipv6} {
+			if slice != nil {
+				setZoneHints(slice)
+				slices = append(slices, slice)
+			}
+		}
+	}
+	return slices
+}
+
+// addressType 返回实例地址的类型，不是 IP 地址时返回空
+func addressType(ip string) discoveryv1.AddressType {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return discoveryv1.AddressTypeIPv4
+	default:
+		return discoveryv1.AddressTypeIPv6
+	}
+}
+
+// setZoneHints 所有就绪实例都设置了可用区时，提示 kube-proxy 优先将流量转发到同可用区的实例
+func setZoneHints(slice *discoveryv1.EndpointSlice) {
+	var ready []int
+	for i, endpoint := range slice.Endpoints {
+		if endpoint.Conditions.Ready == nil || !*endpoint.Conditions.Ready {
+			continue
+		}
+		if endpoint.Zone == nil {
+			return
+		}
+		ready = append(ready, i)
+	}
+	for _, i := range ready {
+		slice.Endpoints[i].Hints = &discoveryv1.EndpointHints{
+			ForZones: []discoveryv1.ForZone{{Name: *slice.Endpoints[i].Zone}},
+		}
+	}
+}
+
+// applyEndpointSlices 实例设置了可用区时由控制器维护 EndpointSlice，并禁止 Kubernetes 从 Endpoints 镜像生成，
+// 否则清理之前生成的 EndpointSlice。需要在 applyEndpointService 之前调用，由其为 Service 开启拓扑感知路由
+func (r *Reconciler) applyEndpointSlices(ctx context.Context, log *logrus.Entry, component *v1alpha1.ThirdComponent, ep *corev1.Endpoints, sourceEndpoints []*v1alpha1.ThirdComponentEndpointStatus) {
+	slices := createEndpointSlices(ep, sourceEndpoints)
+	if len(slices) == 0 {
+		var old corev1.Endpoints
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(ep), &old); err != nil || old.Labels[discoveryv1.LabelSkipMirror] != "true" {
+			return
+		}
+	} else {
+		labels := make(map[string]string, len(ep.Labels)+1)
+		for k, v := range ep.Labels {
+			labels[k] = v
+		}
+		labels[discoveryv1.LabelSkipMirror] = "true"
+		ep.Labels = labels
+	}
+
+	desired := make(map[string]bool, len(slices))
+	for _, slice := range slices {
+		desired[slice.Name] = true
+		controllerutil.SetControllerReference(component, slice, r.Scheme)
+		if err := r.applyer.Apply(ctx, slice); err != nil {
+			log.Errorf("apply endpoint slice %s failure %s", slice.Name, err.Error())
+		}
+	}
+
+	var existing discoveryv1.EndpointSliceList
+	if err := r.Client.List(ctx, &existing, client.InNamespace(ep.Namespace), client.MatchingLabels{
+		discoveryv1.LabelServiceName: ep.Name,
+		discoveryv1.LabelManagedBy:   endpointSliceManagedBy,
+	}); err != nil {
+		log.Errorf("list endpoint slices of service %s failure %s", ep.Name, err.Error())
+		return
+	}
+	for i := range existing.Items {
+		if desired[existing.Items[i].Name] {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &existing.Items[i]); client.IgnoreNotFound(err) != nil {
+			log.Errorf("delete endpoint slice %s failure %s", existing.Items[i].Name, err.Error())
+		}
+	}
+}
+
+// topologyAware 控制器为 Endpoints 维护了带可用区的 EndpointSlice 时，Service 需要开启拓扑感知路由
+func topologyAware(ep *corev1.Endpoints) bool {
+	return ep.Labels[discoveryv1.LabelSkipMirror] == "true"
+}
+
+// serviceAnnotations 返回 Service 需要设置的注解，拓扑感知路由的注解设置在 Service 上才会被 kube-proxy 使用
+func serviceAnnotations(ep *corev1.Endpoints) map[string]string {
+	if !topologyAware(ep) {
+		return ep.Annotations
+	}
+	annotations := make(map[string]string, len(ep.Annotations)+1)
+	for k, v := range ep.Annotations {
+		annotations[k] = v
+	}
+	annotations[corev1.AnnotationTopologyMode] = "Auto"
+	return annotations
+}
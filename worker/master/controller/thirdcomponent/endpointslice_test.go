@@ -0,0 +1,152 @@
+package thirdcomponent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/pkg/common"
+	"github.com/goodrain/rainbond/util/apply"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newZoneTestComponent() (*v1alpha1.ThirdComponent, *corev1.Service) {
+	component := &v1alpha1.ThirdComponent{
+		TypeMeta:   metav1.TypeMeta{Kind: "ThirdComponent", APIVersion: v1alpha1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default", UID: "uid-1", Labels: map[string]string{"service_id": "legacy", "service_alias": "gr-legacy"}},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-80", Namespace: "default", Labels: map[string]string{"service_id": "legacy"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}}},
+	}
+	return component, service
+}
+
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestCreateEndpointSlices(t *testing.T) {
+	component, service := newZoneTestComponent()
+	source := []*v1alpha1.ThirdComponentEndpointStatus{
+		{Address: "10.0.0.1:8080", Status: v1alpha1.EndpointReady, Zone: "zone-a"},
+		{Address: "10.0.0.2:8080", Status: v1alpha1.EndpointReady, Zone: "zone-b"},
+		{Address: "10.0.0.3:8080", Status: v1alpha1.EndpointNotReady},
+	}
+	ep := createEndpoint(component, service, source)
+	slices := createEndpointSlices(&ep, source)
+	if len(slices) != 1 {
+		t.Fatalf("expect one endpoint slice, got %d", len(slices))
+	}
+	slice := slices[0]
+	if slice.Name != "legacy-80-zone-0" || slice.Labels[discoveryv1.LabelServiceName] != "legacy-80" ||
+		slice.Labels[discoveryv1.LabelManagedBy] != endpointSliceManagedBy {
+		t.Fatalf("unexpected slice meta %+v", slice.ObjectMeta)
+	}
+	if len(slice.Ports) != 1 || *slice.Ports[0].Port != 80 || *slice.Ports[0].Name != "http" {
+		t.Fatalf("unexpected slice ports %+v", slice.Ports)
+	}
+	if len(slice.Endpoints) != 3 {
+		t.Fatalf("unexpected slice endpoints %+v", slice.Endpoints)
+	}
+	for _, endpoint := range slice.Endpoints {
+		switch endpoint.Addresses[0] {
+		case "10.0.0.1", "10.0.0.2":
+			if !*endpoint.Conditions.Ready || endpoint.Zone == nil || endpoint.Hints == nil || endpoint.Hints.ForZones[0].Name != *endpoint.Zone {
+				t.Fatalf("ready endpoint should carry zone hints %+v", endpoint)
+			}
+		default:
+			if *endpoint.Conditions.Ready || endpoint.Zone != nil || endpoint.Hints != nil {
+				t.Fatalf("unexpected not ready endpoint %+v", endpoint)
+			}
+		}
+	}
+
+	// IPv6 实例生成单独的 EndpointSlice
+	ipv6 := append(source, &v1alpha1.ThirdComponentEndpointStatus{Address: "[fd00::1]:8080", Status: v1alpha1.EndpointReady, Zone: "zone-a"})
+	ep = createEndpoint(component, service, ipv6)
+	slices = createEndpointSlices(&ep, ipv6)
+	if len(slices) != 2 || slices[0].AddressType != discoveryv1.AddressTypeIPv4 || len(slices[0].Endpoints) != 3 ||
+		slices[1].AddressType != discoveryv1.AddressTypeIPv6 || slices[1].Name != "legacy-80-zone-0-ipv6" || slices[1].Endpoints[0].Addresses[0] != "fd00::1" {
+		t.Fatalf("addresses should be split by address type, got %+v", slices)
+	}
+
+	// 有就绪实例未设置可用区时不设置拓扑提示
+	source[2].Status = v1alpha1.EndpointReady
+	ep = createEndpoint(component, service, source)
+	for _, endpoint := range createEndpointSlices(&ep, source)[0].Endpoints {
+		if endpoint.Hints != nil {
+			t.Fatalf("hints should not be set %+v", endpoint)
+		}
+	}
+
+	// 没有实例设置可用区时仍由 Kubernetes 镜像 Endpoints
+	for _, se := range source {
+		se.Zone = ""
+	}
+	if slices := createEndpointSlices(&ep, source); slices != nil {
+		t.Fatalf("expect no endpoint slices, got %v", slices)
+	}
+}
+
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestApplyEndpointSlices(t *testing.T) {
+	component, service := newZoneTestComponent()
+	c := fake.NewClientBuilder().WithScheme(common.Scheme).WithObjects(service.DeepCopy()).Build()
+	r := &Reconciler{Client: c, Scheme: common.Scheme, applyer: apply.NewAPIApplicator(c)}
+	log := logrus.WithField("test", t.Name())
+	ctx := context.Background()
+
+	source := []*v1alpha1.ThirdComponentEndpointStatus{
+		{Address: "10.0.0.1:8080", Status: v1alpha1.EndpointReady, Zone: "zone-a"},
+	}
+	ep := createEndpoint(component, service, source)
+	r.applyEndpointSlices(ctx, log, component, &ep, source)
+	if ep.Labels[discoveryv1.LabelSkipMirror] != "true" {
+		t.Fatalf("endpoints should skip mirroring, got labels %v", ep.Labels)
+	}
+	if _, ok := ep.Annotations[corev1.AnnotationTopologyMode]; ok {
+		t.Fatalf("topology mode should be set on the service, got endpoints annotations %v", ep.Annotations)
+	}
+	r.applyEndpointService(ctx, log, service, &ep)
+	var svc corev1.Service
+	if err := c.Get(ctx, client.ObjectKeyFromObject(service), &svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Annotations[corev1.AnnotationTopologyMode] != "Auto" {
+		t.Fatalf("service should enable topology aware routing, got annotations %v", svc.Annotations)
+	}
+	if _, ok := svc.Labels[discoveryv1.LabelSkipMirror]; ok {
+		t.Fatal("service labels should not be modified")
+	}
+	var slices discoveryv1.EndpointSliceList
+	if err := c.List(ctx, &slices, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	if len(slices.Items) != 1 || len(slices.Items[0].OwnerReferences) != 1 || slices.Items[0].OwnerReferences[0].UID != component.UID {
+		t.Fatalf("unexpected endpoint slices %+v", slices.Items)
+	}
+
+	// 去掉可用区后清理控制器生成的 EndpointSlice，Service 关闭拓扑感知路由
+	source[0].Zone = ""
+	ep = createEndpoint(component, service, source)
+	r.applyEndpointSlices(ctx, log, component, &ep, source)
+	if _, ok := ep.Labels[discoveryv1.LabelSkipMirror]; ok {
+		t.Fatalf("endpoints should be mirrored again, got labels %v", ep.Labels)
+	}
+	r.applyEndpointService(ctx, log, &svc, &ep)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(service), &svc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := svc.Annotations[corev1.AnnotationTopologyMode]; ok {
+		t.Fatalf("service should disable topology aware routing, got annotations %v", svc.Annotations)
+	}
+	if err := c.List(ctx, &slices, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	if len(slices.Items) != 0 {
+		t.Fatalf("stale endpoint slices should be deleted, got %d", len(slices.Items))
+	}
+}
@@ -0,0 +1,61 @@
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const defaultGRPCProbeTimeout = time.Second
+
+type grpcRuntimeProber interface {
+	Probe(address string, action *v1alpha1.GRPCAction, timeout time.Duration) (probeResult, string, error)
+}
+
+func newGRPCRuntimeProber() grpcRuntimeProber {
+	return &defaultGRPCRuntimeProber{}
+}
+
+type defaultGRPCRuntimeProber struct{}
+
+// Probe checks the endpoint with the standard grpc.health.v1 protocol.
+func (p *defaultGRPCRuntimeProber) Probe(address string, action *v1alpha1.GRPCAction, timeout time.Duration) (probeResult, string, error) {
+	creds := insecure.NewCredentials()
+	if action.TLS {
+		creds = credentials.NewTLS(&tls.Config{
+			ServerName:         action.ServerName,
+			InsecureSkipVerify: action.InsecureSkipVerify,
+		})
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return runtimeProbeResultFailure, err.Error(), nil
+	}
+	defer conn.Close()
+
+	if timeout <= 0 {
+		timeout = defaultGRPCProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: action.Service})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return runtimeProbeResultFailure, fmt.Sprintf("endpoint %s does not implement the grpc health protocol", address), nil
+		}
+		return runtimeProbeResultFailure, fmt.Sprintf("gRPC probe failed: %v", err), nil
+	}
+	if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return runtimeProbeResultFailure, fmt.Sprintf("gRPC probe failed with status: %s", res.GetStatus()), nil
+	}
+	return runtimeProbeResultSuccess, "", nil
+}
@@ -0,0 +1,113 @@
+package prober
+
+import (
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func startGRPCServer(t *testing.T, withHealth bool, opts ...grpc.ServerOption) (string, *health.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(opts...)
+	var hs *health.Server
+	if withHealth {
+		hs = health.NewServer()
+		healthpb.RegisterHealthServer(server, hs)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), hs
+}
+
+// capability_id: rainbond.worker.thirdcomponent.prober.grpc-probe
+func TestGRPCRuntimeProber(t *testing.T) {
+	address, hs := startGRPCServer(t, true)
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("payments", healthpb.HealthCheckResponse_NOT_SERVING)
+	unimplemented, _ := startGRPCServer(t, false)
+
+	tests := []struct {
+		name    string
+		address string
+		action  *v1alpha1.GRPCAction
+		result  probeResult
+		output  string
+	}{
+		{name: "server serving", address: address, action: &v1alpha1.GRPCAction{}, result: runtimeProbeResultSuccess},
+		{name: "service serving", address: address, action: &v1alpha1.GRPCAction{Service: "orders"}, result: runtimeProbeResultSuccess},
+		{name: "service not serving", address: address, action: &v1alpha1.GRPCAction{Service: "payments"}, result: runtimeProbeResultFailure, output: "NOT_SERVING"},
+		{name: "unknown service", address: address, action: &v1alpha1.GRPCAction{Service: "unknown"}, result: runtimeProbeResultFailure, output: "NotFound"},
+		{name: "health not implemented", address: unimplemented, action: &v1alpha1.GRPCAction{}, result: runtimeProbeResultFailure, output: "does not implement"},
+	}
+	prober := newGRPCRuntimeProber()
+	for _, test := range tests {
+		result, output, err := prober.Probe(test.address, test.action, time.Second)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error %v", test.name, err)
+		}
+		if result != test.result || !strings.Contains(output, test.output) {
+			t.Fatalf("[%s] result = %s %q, want %s %q", test.name, result, output, test.result, test.output)
+		}
+	}
+}
+
+// capability_id: rainbond.worker.thirdcomponent.prober.grpc-probe
+func TestGRPCRuntimeProberTLS(t *testing.T) {
+	// 复用 httptest 自签名的证书
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	cert := ts.TLS.Certificates[0]
+	ts.Close()
+	address, _ := startGRPCServer(t, true, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+
+	prober := newGRPCRuntimeProber()
+	if result, output, _ := prober.Probe(address, &v1alpha1.GRPCAction{TLS: true, InsecureSkipVerify: true}, time.Second); result != runtimeProbeResultSuccess {
+		t.Fatalf("tls probe should succeed, got %s %s", result, output)
+	}
+	if result, _, _ := prober.Probe(address, &v1alpha1.GRPCAction{TLS: true}, time.Second); result != runtimeProbeResultFailure {
+		t.Fatalf("untrusted certificate should fail, got %s", result)
+	}
+	if result, _, _ := prober.Probe(address, &v1alpha1.GRPCAction{}, time.Second); result != runtimeProbeResultFailure {
+		t.Fatalf("plaintext probe against tls server should fail, got %s", result)
+	}
+}
+
+// capability_id: rainbond.worker.thirdcomponent.prober.grpc-probe
+func TestProbeWithGRPCHandler(t *testing.T) {
+	address, hs := startGRPCServer(t, true)
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	_, port, _ := net.SplitHostPort(address)
+	portNumber, _ := strconv.Atoi(port)
+	endpoint := v1alpha1.NewEndpointAddress("127.0.0.1", portNumber)
+
+	pb := newProber(&record.FakeRecorder{})
+	for service, expected := range map[string]results.Result{"orders": results.Success, "payments": results.Failure} {
+		component := &v1alpha1.ThirdComponent{
+			Spec: v1alpha1.ThirdComponentSpec{
+				Probe: &v1alpha1.Probe{
+					Handler:        v1alpha1.Handler{GRPC: &v1alpha1.GRPCAction{Service: service}},
+					TimeoutSeconds: 1,
+				},
+			},
+		}
+		result, _ := pb.probe(component, &v1alpha1.ThirdComponentEndpointStatus{Address: *endpoint}, "foobar")
+		if result != expected {
+			t.Fatalf("probe service %s = %v, want %v", service, result, expected)
+		}
+	}
+}
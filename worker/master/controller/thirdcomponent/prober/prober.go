@@ -2,8 +2,10 @@ package prober
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
@@ -19,6 +21,7 @@ const maxProbeRetries = 3
 type prober struct {
 	http httpRuntimeProber
 	tcp  tcpRuntimeProber
+	grpc grpcRuntimeProber
 
 	logger   *logrus.Entry
 	recorder record.EventRecorder
@@ -31,6 +34,7 @@ func newProber(
 		logger:   logrus.WithField("WHO", "Thirdcomponent Prober"),
 		http:     newHTTPRuntimeProber(true),
 		tcp:      newTCPRuntimeProber(),
+		grpc:     newGRPCRuntimeProber(),
 		recorder: recorder,
 	}
 }
@@ -90,6 +94,11 @@ func (pb *prober) runProbe(p *v1alpha1.Probe, thirdComponent *v1alpha1.ThirdComp
 		return pb.tcp.Probe(endpointStatus.Address.GetIP(), endpointStatus.Address.GetPort(), timeout)
 	}
 
+	if p.GRPC != nil {
+		address := net.JoinHostPort(endpointStatus.Address.GetIP(), strconv.Itoa(endpointStatus.Address.GetPort()))
+		return pb.grpc.Probe(address, p.GRPC, timeout)
+	}
+
 	pb.logger.Warningf("Failed to find probe builder for endpoint address: %v", endpointID)
 	return runtimeProbeResultUnknown, "", fmt.Errorf("missing probe handler for %s/%s", thirdComponent.Namespace, thirdComponent.Name)
 }
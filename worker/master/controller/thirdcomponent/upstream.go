@@ -0,0 +1,286 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package thirdcomponent
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// weightedUpstreamFinalizer 组件删除前需要将路由中引用带权重上游的规则恢复为直接指向服务端口
+const weightedUpstreamFinalizer = "thirdcomponent.rainbond.io/weighted-upstream"
+
+// needWeightedUpstream 是否有实例设置了流量权重或可用区
+func needWeightedUpstream(endpoints []*v1alpha1.ThirdComponentEndpointStatus) bool {
+	for _, ep := range endpoints {
+		if ep.Weight != nil || ep.Zone != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// createWeightedUpstream 根据就绪实例的权重生成网关使用的 ApisixUpstream，
+// 设置了网关所在可用区且该可用区有就绪实例时，只转发到同可用区的实例
+func createWeightedUpstream(component *v1alpha1.ThirdComponent, service *corev1.Service, port int, sourceEndpoints []*v1alpha1.ThirdComponentEndpointStatus, gatewayZone string) *v2.ApisixUpstream {
+	upstream := &v2.ApisixUpstream{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ApisixUpstream",
+			APIVersion: "apisix.apache.org/v2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v1alpha1.ThirdComponentUpstreamName(service.Name, port),
+			Namespace: service.Namespace,
+			Labels:    service.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(component, v1alpha1.SchemeGroupVersion.WithKind("ThirdComponent")),
+			},
+		},
+		Spec: &v2.ApisixUpstreamSpec{
+			IngressClassName: "apisix",
+		},
+	}
+	var ready []*v1alpha1.ThirdComponentEndpointStatus
+	sameZone := false
+	for _, se := range sourceEndpoints {
+		if se.Status != v1alpha1.EndpointReady {
+			continue
+		}
+		ready = append(ready, se)
+		if gatewayZone != "" && se.Zone == gatewayZone {
+			sameZone = true
+		}
+	}
+	for _, se := range ready {
+		if sameZone && se.Zone != gatewayZone {
+			continue
+		}
+		u, err := url.Parse(se.Address.EnsureScheme())
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		weight, nodePort := se.GetWeight(), se.Address.GetPort()
+		upstream.Spec.ExternalNodes = append(upstream.Spec.ExternalNodes, v2.ApisixUpstreamExternalNode{
+			Name:   u.Hostname(),
+			Type:   v2.ExternalTypeDomain,
+			Weight: &weight,
+			Port:   &nodePort,
+		})
+	}
+	return upstream
+}
+
+// applyWeightedUpstream 实例设置了权重或可用区时为服务端口维护网关上游，并将已有路由改为引用该上游。
+// 清除权重后上游继续保留并按相同的权重转发，避免删除仍被路由引用的上游
+func (r *Reconciler) applyWeightedUpstream(ctx context.Context, log *logrus.Entry, component *v1alpha1.ThirdComponent, service *corev1.Service, port int, sourceEndpoints []*v1alpha1.ThirdComponentEndpointStatus) {
+	if r.apisixClient == nil {
+		return
+	}
+	upstreams := r.apisixClient.ApisixV2().ApisixUpstreams(service.Namespace)
+	name := v1alpha1.ThirdComponentUpstreamName(service.Name, port)
+	desired := createWeightedUpstream(component, service, port, sourceEndpoints, r.gatewayZone)
+	old, err := upstreams.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Errorf("get weighted upstream %s failure %s", name, err.Error())
+			return
+		}
+		if !needWeightedUpstream(sourceEndpoints) {
+			return
+		}
+		if !r.addUpstreamFinalizer(ctx, log, component) {
+			return
+		}
+		if _, err := upstreams.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			log.Errorf("create weighted upstream %s failure %s", name, err.Error())
+			return
+		}
+		r.referWeightedUpstream(ctx, log, component, service, port)
+		return
+	}
+	if !r.addUpstreamFinalizer(ctx, log, component) {
+		return
+	}
+	r.referWeightedUpstream(ctx, log, component, service, port)
+	if reflect.DeepEqual(old.Spec, desired.Spec) && reflect.DeepEqual(old.Labels, desired.Labels) {
+		return
+	}
+	old.Labels = desired.Labels
+	old.OwnerReferences = desired.OwnerReferences
+	old.Spec = desired.Spec
+	if _, err := upstreams.Update(ctx, old, metav1.UpdateOptions{}); err != nil {
+		log.Errorf("update weighted upstream %s failure %s", name, err.Error())
+	}
+}
+
+// routeListOptions 只列出绑定在组件上的路由，网关路由通过 <service_alias>=service_alias 标签关联组件
+func routeListOptions(component *v1alpha1.ThirdComponent) (metav1.ListOptions, bool) {
+	alias := component.Labels["service_alias"]
+	if alias == "" {
+		return metav1.ListOptions{}, false
+	}
+	return metav1.ListOptions{LabelSelector: alias + "=service_alias"}, true
+}
+
+// referWeightedUpstream 将组件路由中指向服务端口的后端改为引用带权重的上游，上游生成之前创建的路由也会被更新
+func (r *Reconciler) referWeightedUpstream(ctx context.Context, log *logrus.Entry, component *v1alpha1.ThirdComponent, service *corev1.Service, port int) {
+	options, ok := routeListOptions(component)
+	if !ok {
+		log.Debugf("component %s has no service alias label, skip referring its routes to weighted upstream", component.Name)
+		return
+	}
+	routes := r.apisixClient.ApisixV2().ApisixRoutes(service.Namespace)
+	list, err := routes.List(ctx, options)
+	if err != nil {
+		log.Errorf("list apisix routes of component %s failure %s", component.Name, err.Error())
+		return
+	}
+	name := v1alpha1.ThirdComponentUpstreamName(service.Name, port)
+	for i := range list.Items {
+		route := &list.Items[i]
+		changed := false
+		for j := range route.Spec.HTTP {
+			http := &route.Spec.HTTP[j]
+			var backends []v2.ApisixRouteHTTPBackend
+			for _, b := range http.Backends {
+				if b.ServiceName != service.Name || b.ServicePort.IntValue() != port {
+					backends = append(backends, b)
+					continue
+				}
+				http.Upstreams = append(http.Upstreams, v2.ApisixRouteUpstreamReference{
+					Name:   name,
+					Weight: b.Weight,
+				})
+				changed = true
+			}
+			http.Backends = backends
+		}
+		if !changed {
+			continue
+		}
+		if _, err := routes.Update(ctx, route, metav1.UpdateOptions{}); err != nil {
+			log.Errorf("update apisix route %s to weighted upstream %s failure %s", route.Name, name, err.Error())
+		}
+	}
+}
+
+// addUpstreamFinalizer 生成带权重的上游之前为组件增加 finalizer，返回组件是否已经带有 finalizer
+func (r *Reconciler) addUpstreamFinalizer(ctx context.Context, log *logrus.Entry, component *v1alpha1.ThirdComponent) bool {
+	if controllerutil.ContainsFinalizer(component, weightedUpstreamFinalizer) {
+		return true
+	}
+	updated := component.DeepCopy()
+	controllerutil.AddFinalizer(updated, weightedUpstreamFinalizer)
+	if err := r.Client.Patch(ctx, updated, client.MergeFrom(component)); err != nil {
+		log.Errorf("add finalizer to component %s failure %s", component.Name, err.Error())
+		return false
+	}
+	component.Finalizers = updated.Finalizers
+	component.ResourceVersion = updated.ResourceVersion
+	return true
+}
+
+// releaseWeightedUpstreams 组件删除时将路由中引用带权重上游的规则恢复为直接指向服务端口，之后移除 finalizer，
+// 带权重的上游随组件一起被回收
+func (r *Reconciler) releaseWeightedUpstreams(ctx context.Context, log *logrus.Entry, component *v1alpha1.ThirdComponent) error {
+	if !controllerutil.ContainsFinalizer(component, weightedUpstreamFinalizer) {
+		return nil
+	}
+	if options, ok := routeListOptions(component); ok && r.apisixClient != nil {
+		routes := r.apisixClient.ApisixV2().ApisixRoutes(component.Namespace)
+		list, err := routes.List(ctx, options)
+		if err != nil {
+			return err
+		}
+		upstreams, err := r.apisixClient.ApisixV2().ApisixUpstreams(component.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		owned := make(map[string]bool)
+		for i := range upstreams.Items {
+			if metav1.IsControlledBy(&upstreams.Items[i], component) {
+				owned[upstreams.Items[i].Name] = true
+			}
+		}
+		for i := range list.Items {
+			route := &list.Items[i]
+			if !restoreRouteBackends(route, owned) {
+				continue
+			}
+			if _, err := routes.Update(ctx, route, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			log.Infof("restore backends of apisix route %s before component %s is deleted", route.Name, component.Name)
+		}
+	}
+	updated := component.DeepCopy()
+	controllerutil.RemoveFinalizer(updated, weightedUpstreamFinalizer)
+	return r.Client.Patch(ctx, updated, client.MergeFrom(component))
+}
+
+// restoreRouteBackends 将路由中引用 upstreams 的规则恢复为指向服务端口的后端，返回路由是否被修改
+func restoreRouteBackends(route *v2.ApisixRoute, upstreams map[string]bool) bool {
+	changed := false
+	for j := range route.Spec.HTTP {
+		http := &route.Spec.HTTP[j]
+		var refs []v2.ApisixRouteUpstreamReference
+		for _, ref := range http.Upstreams {
+			serviceName, port, ok := parseUpstreamName(ref.Name)
+			if !upstreams[ref.Name] || !ok {
+				refs = append(refs, ref)
+				continue
+			}
+			http.Backends = append(http.Backends, v2.ApisixRouteHTTPBackend{
+				ServiceName: serviceName,
+				ServicePort: intstr.FromInt(port),
+				Weight:      ref.Weight,
+			})
+			changed = true
+		}
+		http.Upstreams = refs
+	}
+	return changed
+}
+
+// parseUpstreamName 从 ThirdComponentUpstreamName 生成的上游名称中解析服务名称与端口
+func parseUpstreamName(name string) (string, int, bool) {
+	name = strings.TrimSuffix(name, "-weighted")
+	i := strings.LastIndex(name, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], port, true
+}
@@ -0,0 +1,181 @@
+package thirdcomponent
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/apache/apisix-ingress-controller/pkg/kube/apisix/apis/config/v2"
+	"github.com/apache/apisix-ingress-controller/pkg/kube/apisix/client/clientset/versioned/fake"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/pkg/common"
+	"github.com/goodrain/rainbond/util"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestApplyWeightedUpstream(t *testing.T) {
+	component, service := newZoneTestComponent()
+	apisixClient := fake.NewSimpleClientset()
+	c := ctrlfake.NewClientBuilder().WithScheme(common.Scheme).WithObjects(component.DeepCopy()).Build()
+	r := &Reconciler{Client: c, apisixClient: apisixClient}
+	log := logrus.WithField("test", t.Name())
+	ctx := context.Background()
+	upstreams := apisixClient.ApisixV2().ApisixUpstreams("default")
+	name := v1alpha1.ThirdComponentUpstreamName(service.Name, 80)
+	// 设置权重之前已经存在的路由
+	weight := 50
+	route := &v2.ApisixRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-route", Namespace: "default", Labels: map[string]string{"gr-legacy": "service_alias"}},
+		Spec: v2.ApisixRouteSpec{HTTP: []v2.ApisixRouteHTTP{{
+			Name: "rule",
+			Backends: []v2.ApisixRouteHTTPBackend{
+				{ServiceName: service.Name, ServicePort: intstr.FromInt(80), Weight: &weight},
+				{ServiceName: "other", ServicePort: intstr.FromInt(80)},
+			},
+		}}},
+	}
+	if _, err := apisixClient.ApisixV2().ApisixRoutes("default").Create(ctx, route, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有设置权重时不生成上游
+	source := []*v1alpha1.ThirdComponentEndpointStatus{
+		{Address: "10.0.0.1:8080", Status: v1alpha1.EndpointReady},
+		{Address: "10.0.0.2:8080", Status: v1alpha1.EndpointReady},
+	}
+	r.applyWeightedUpstream(ctx, log, component, service, 80, source)
+	if _, err := upstreams.Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("upstream should not exist, got %v", err)
+	}
+
+	// 其他组件的路由不会被修改
+	other := route.DeepCopy()
+	other.Name, other.Labels = "other-route", map[string]string{"gr-other": "service_alias"}
+	if _, err := apisixClient.ApisixV2().ApisixRoutes("default").Create(ctx, other, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	source[1].Weight = util.Int(10)
+	source = append(source,
+		&v1alpha1.ThirdComponentEndpointStatus{Address: "http://api.example.com:9090", Status: v1alpha1.EndpointReady, Weight: util.Int(30)},
+		&v1alpha1.ThirdComponentEndpointStatus{Address: "10.0.0.3:8080", Status: v1alpha1.EndpointUnhealthy, Weight: util.Int(50)},
+	)
+	r.applyWeightedUpstream(ctx, log, component, service, 80, source)
+	upstream, err := upstreams.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{"10.0.0.1": {100, 8080}, "10.0.0.2": {10, 8080}, "api.example.com": {30, 9090}}
+	if len(upstream.Spec.ExternalNodes) != len(want) {
+		t.Fatalf("unexpected external nodes %+v", upstream.Spec.ExternalNodes)
+	}
+	for _, node := range upstream.Spec.ExternalNodes {
+		if w, ok := want[node.Name]; !ok || *node.Weight != w[0] || *node.Port != w[1] {
+			t.Fatalf("unexpected external node %s weight %d port %d", node.Name, *node.Weight, *node.Port)
+		}
+	}
+	if len(upstream.OwnerReferences) != 1 || upstream.OwnerReferences[0].UID != component.UID {
+		t.Fatalf("unexpected owner references %+v", upstream.OwnerReferences)
+	}
+	if route, err = apisixClient.ApisixV2().ApisixRoutes("default").Get(ctx, route.Name, metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	rule := route.Spec.HTTP[0]
+	if len(rule.Backends) != 1 || rule.Backends[0].ServiceName != "other" {
+		t.Fatalf("only the backend of the weighted service should be replaced, got %+v", rule.Backends)
+	}
+	if len(rule.Upstreams) != 1 || rule.Upstreams[0].Name != name || *rule.Upstreams[0].Weight != 50 {
+		t.Fatalf("existing route should refer to the weighted upstream, got %+v", rule.Upstreams)
+	}
+	if other, _ = apisixClient.ApisixV2().ApisixRoutes("default").Get(ctx, other.Name, metav1.GetOptions{}); len(other.Spec.HTTP[0].Upstreams) != 0 {
+		t.Fatalf("routes of other components should not be changed, got %+v", other.Spec.HTTP[0])
+	}
+	var stored v1alpha1.ThirdComponent
+	if err := c.Get(ctx, client.ObjectKeyFromObject(component), &stored); err != nil || len(stored.Finalizers) != 1 {
+		t.Fatalf("component should have the weighted upstream finalizer, got %v err %v", stored.Finalizers, err)
+	}
+
+	// 权重为 0 的实例不接收流量
+	source[1].Weight = util.Int(0)
+	r.applyWeightedUpstream(ctx, log, component, service, 80, source)
+	if upstream, err = upstreams.Get(ctx, name, metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range upstream.Spec.ExternalNodes {
+		if node.Name == "10.0.0.2" && *node.Weight != 0 {
+			t.Fatalf("zero weight should be kept, got %d", *node.Weight)
+		}
+	}
+
+	source[1].Weight = util.Int(60)
+	r.applyWeightedUpstream(ctx, log, component, service, 80, source)
+	if upstream, err = upstreams.Get(ctx, name, metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range upstream.Spec.ExternalNodes {
+		if node.Name == "10.0.0.2" && *node.Weight != 60 {
+			t.Fatalf("upstream weight should be updated, got %d", *node.Weight)
+		}
+	}
+
+	for _, se := range source {
+		se.Weight = nil
+	}
+	// 清除权重后上游仍被路由引用，保留并按相同的权重转发
+	r.applyWeightedUpstream(ctx, log, component, service, 80, source)
+	if upstream, err = upstreams.Get(ctx, name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("upstream referred by routes should be kept, got %v", err)
+	}
+	for _, node := range upstream.Spec.ExternalNodes {
+		if *node.Weight != v1alpha1.DefaultEndpointWeight {
+			t.Fatalf("upstream should use equal weights, got %s weight %d", node.Name, *node.Weight)
+		}
+	}
+
+	// 组件删除时路由恢复为直接指向服务端口，之后移除 finalizer
+	if err := r.releaseWeightedUpstreams(ctx, log, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if route, err = apisixClient.ApisixV2().ApisixRoutes("default").Get(ctx, route.Name, metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	rule = route.Spec.HTTP[0]
+	if len(rule.Upstreams) != 0 || len(rule.Backends) != 2 || rule.Backends[1].ServiceName != service.Name ||
+		rule.Backends[1].ServicePort.IntValue() != 80 || *rule.Backends[1].Weight != 50 {
+		t.Fatalf("route backends should be restored, got %+v", rule)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(component), &stored); err != nil || len(stored.Finalizers) != 0 {
+		t.Fatalf("finalizer should be removed, got %v err %v", stored.Finalizers, err)
+	}
+}
+
+// capability_id: rainbond.thirdcomponent.endpoint-weight-zone
+func TestCreateWeightedUpstreamPrefersGatewayZone(t *testing.T) {
+	component, service := newZoneTestComponent()
+	source := []*v1alpha1.ThirdComponentEndpointStatus{
+		{Address: "10.0.0.1:8080", Status: v1alpha1.EndpointReady, Zone: "zone-a"},
+		{Address: "10.0.0.2:8080", Status: v1alpha1.EndpointReady, Zone: "zone-b"},
+		{Address: "10.0.0.3:8080", Status: v1alpha1.EndpointUnhealthy, Zone: "zone-c"},
+	}
+	nodes := func(upstream *v2.ApisixUpstream) []string {
+		var names []string
+		for _, node := range upstream.Spec.ExternalNodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+	if names := nodes(createWeightedUpstream(component, service, 80, source, "zone-b")); len(names) != 1 || names[0] != "10.0.0.2" {
+		t.Fatalf("upstream should only use endpoints in the gateway zone, got %v", names)
+	}
+	// 网关所在可用区没有就绪实例时使用所有可用区的实例
+	if names := nodes(createWeightedUpstream(component, service, 80, source, "zone-c")); len(names) != 2 {
+		t.Fatalf("upstream should fall back to all zones, got %v", names)
+	}
+	if names := nodes(createWeightedUpstream(component, service, 80, source, "")); len(names) != 2 {
+		t.Fatalf("upstream should use all zones without gateway zone, got %v", names)
+	}
+}
@@ -30,12 +30,15 @@ import (
 	"github.com/goodrain/rainbond/db"
 	"github.com/goodrain/rainbond/db/model"
 	"github.com/goodrain/rainbond/pkg/common"
+	k8sutil "github.com/goodrain/rainbond/util/k8s"
 	"github.com/goodrain/rainbond/util/leader"
 	"github.com/goodrain/rainbond/worker/appm/store"
 	mcontroller "github.com/goodrain/rainbond/worker/master/controller"
 	"github.com/goodrain/rainbond/worker/master/controller/helmapp"
 	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent"
+	"github.com/goodrain/rainbond/worker/master/metricsadapter"
 	"github.com/goodrain/rainbond/worker/master/podevent"
+	"github.com/goodrain/rainbond/worker/master/scheduledscaling"
 	"github.com/goodrain/rainbond/worker/master/volumes/provider"
 	"github.com/goodrain/rainbond/worker/master/volumes/provider/lib/controller"
 	"github.com/goodrain/rainbond/worker/master/volumes/statistical"
@@ -64,6 +67,8 @@ type Controller struct {
 	namespaceCPULimit   *prometheus.GaugeVec
 	pc                  *controller.ProvisionController
 	helmAppController   *helmapp.Controller
+	scheduledScaling    *scheduledscaling.Controller
+	metricsAdapter      *metricsadapter.Controller
 	controllers         []mcontroller.Controller
 	isLeader            bool
 	stopCh              chan struct{}
@@ -109,6 +114,8 @@ func NewMasterController(store store.Storer) (*Controller, error) {
 	return &Controller{
 		pc:                pc,
 		helmAppController: helmAppController,
+		scheduledScaling:  scheduledscaling.New(db.GetManager(), k8s.Default().Clientset, store, k8sutil.SupportsHPAV2(k8s.Default().Clientset.Discovery())),
+		metricsAdapter:    metricsadapter.New(db.GetManager(), k8s.Default().Clientset, configs.Default().PublicConfig.RbdNamespace),
 		store:             store,
 		stopCh:            stopCh,
 		cancel:            cancel,
@@ -190,6 +197,11 @@ func (m *Controller) Start() error {
 		go m.helmAppController.Start()
 		defer m.helmAppController.Stop()
 
+		// scheduled scaling is applied by the leader only
+		go m.scheduledScaling.Start(ctx)
+		// prometheus-adapter rules of the custom autoscaler metrics
+		go m.metricsAdapter.Start(ctx)
+
 		// start controller
 		mgr, err := ctrl.NewManager(m.k8sComponent.RestConfig, ctrl.Options{
 			Scheme:           common.Scheme,
@@ -200,7 +212,7 @@ func (m *Controller) Start() error {
 			logrus.Errorf("create new manager: %v", err)
 			return
 		}
-		thirdComponentController, err := thirdcomponent.Setup(ctx, mgr)
+		thirdComponentController, err := thirdcomponent.Setup(ctx, mgr, m.workerConfig.GatewayZone)
 		if err != nil {
 			logrus.Errorf("setup third component controller: %v", err)
 			return
@@ -0,0 +1,236 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package metricsadapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigMapName the configmap holding the prometheus-adapter rules of the custom autoscaler metrics,
+	// rbd-metrics-adapter mounts its config.yaml
+	ConfigMapName = "rbd-metrics-adapter-rules"
+	// ConfigKey the key of the adapter config in the configmap
+	ConfigKey = "config.yaml"
+
+	syncInterval = time.Minute
+	// podSeries every running pod has the cadvisor series, it associates the pods metrics with the pods
+	podSeries = "container_memory_working_set_bytes"
+	// externalSeries external metrics are not associated with any object, any always present series is enough
+	externalSeries = "up"
+)
+
+// apiGroups the metrics apis the HPAs of the custom metrics read from
+var apiGroups = map[string]string{
+	model.PodsMetricsType:     "custom.metrics.k8s.io",
+	model.ObjectMetricsType:   "custom.metrics.k8s.io",
+	model.ExternalMetricsType: "external.metrics.k8s.io",
+}
+
+// Controller renders the PromQL queries of the autoscaler rules into prometheus-adapter rules,
+// so the HPAs created for pods, object and external metrics are served by the metrics adapter.
+type Controller struct {
+	dbmanager db.Manager
+	clientset kubernetes.Interface
+	namespace string
+	// warned the api groups already reported as not served
+	warned map[string]bool
+}
+
+// New creates a metrics adapter rules controller, the configmap is kept in namespace
+func New(dbmanager db.Manager, clientset kubernetes.Interface, namespace string) *Controller {
+	return &Controller{
+		dbmanager: dbmanager,
+		clientset: clientset,
+		namespace: namespace,
+		warned:    make(map[string]bool),
+	}
+}
+
+// Start syncs the adapter rules periodically until the context is done
+func (c *Controller) Start(ctx context.Context) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.sync(ctx); err != nil {
+			logrus.Warningf("sync metrics adapter rules: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Controller) sync(ctx context.Context) error {
+	metrics, err := c.dbmanager.TenantServceAutoscalerRuleMetricsDao().ListCustomOfEnableRules()
+	if err != nil {
+		return fmt.Errorf("list custom metrics: %v", err)
+	}
+	config, err := renderConfig(metrics)
+	if err != nil {
+		return err
+	}
+	c.checkAPIServed(metrics)
+
+	cm, err := c.clientset.CoreV1().ConfigMaps(c.namespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigMapName,
+				Namespace: c.namespace,
+				Labels:    map[string]string{"creator": "Rainbond"},
+			},
+			Data: map[string]string{ConfigKey: config},
+		}
+		_, err = c.clientset.CoreV1().ConfigMaps(c.namespace).Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if cm.Data[ConfigKey] == config {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[ConfigKey] = config
+	_, err = c.clientset.CoreV1().ConfigMaps(c.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// checkAPIServed warns once when no metrics adapter serves the api of the configured metrics,
+// the HPAs of these metrics report FailedGetPodsMetric until the adapter is installed.
+func (c *Controller) checkAPIServed(metrics []*model.TenantServiceAutoscalerRuleMetrics) {
+	for _, metric := range metrics {
+		group := apiGroups[metric.MetricsType]
+		if group == "" || c.warned[group] {
+			continue
+		}
+		if _, err := c.clientset.Discovery().ServerResourcesForGroupVersion(group + "/v1beta1"); err != nil {
+			logrus.Warningf("%s is not served, metric %s of rule %s will not take effect until rbd-metrics-adapter is installed: %v",
+				group, metric.MetricsName, metric.RuleID, err)
+			c.warned[group] = true
+		}
+	}
+}
+
+type adapterConfig struct {
+	Rules         []adapterRule `json:"rules"`
+	ExternalRules []adapterRule `json:"externalRules"`
+}
+
+type adapterRule struct {
+	SeriesQuery  string           `json:"seriesQuery"`
+	Resources    adapterResources `json:"resources"`
+	Name         adapterName      `json:"name"`
+	MetricsQuery string           `json:"metricsQuery"`
+}
+
+type adapterResources struct {
+	Overrides  map[string]groupResource `json:"overrides,omitempty"`
+	Namespaced *bool                    `json:"namespaced,omitempty"`
+}
+
+type groupResource struct {
+	Group    string `json:"group,omitempty"`
+	Resource string `json:"resource"`
+}
+
+type adapterName struct {
+	Matches string `json:"matches"`
+	As      string `json:"as"`
+}
+
+// renderConfig renders one adapter rule per metric name. The metric names are global in the
+// metrics apis, when several rules use the same name with different queries the first one wins.
+func renderConfig(metrics []*model.TenantServiceAutoscalerRuleMetrics) (string, error) {
+	config := adapterConfig{Rules: []adapterRule{}, ExternalRules: []adapterRule{}}
+	queries := make(map[string]string)
+	for _, metric := range metrics {
+		if !metric.IsCustom() || metric.MetricQuery == "" {
+			continue
+		}
+		key := apiGroups[metric.MetricsType] + "/" + metric.MetricsName
+		if query, ok := queries[key]; ok {
+			if query != metric.MetricQuery {
+				logrus.Warningf("metric %s of rule %s conflicts with a metric of the same name, use the query %s", metric.MetricsName, metric.RuleID, query)
+			}
+			continue
+		}
+		queries[key] = metric.MetricQuery
+		switch metric.MetricsType {
+		case model.PodsMetricsType:
+			config.Rules = append(config.Rules, adapterRule{
+				SeriesQuery: podSeries + `{namespace!="",pod!=""}`,
+				Resources: adapterResources{Overrides: map[string]groupResource{
+					"namespace": {Resource: "namespace"},
+					"pod":       {Resource: "pod"},
+				}},
+				Name:         adapterName{Matches: "^" + podSeries + "$", As: metric.MetricsName},
+				MetricsQuery: metric.MetricQuery,
+			})
+		case model.ObjectMetricsType:
+			// the query labels the series with the lower case kind of the object, eg: ingress="web"
+			label := strings.ToLower(metric.ObjectKind)
+			gv, err := schema.ParseGroupVersion(metric.ObjectAPIVersion)
+			if err != nil || label == "" {
+				logrus.Warningf("skip object metric %s of rule %s: invalid object %s %s", metric.MetricsName, metric.RuleID, metric.ObjectAPIVersion, metric.ObjectKind)
+				continue
+			}
+			config.Rules = append(config.Rules, adapterRule{
+				SeriesQuery: fmt.Sprintf(`{namespace!="",%s!=""}`, label),
+				Resources: adapterResources{Overrides: map[string]groupResource{
+					"namespace": {Resource: "namespace"},
+					label:       {Group: gv.Group, Resource: label},
+				}},
+				Name:         adapterName{Matches: "^.*$", As: metric.MetricsName},
+				MetricsQuery: metric.MetricQuery,
+			})
+		case model.ExternalMetricsType:
+			namespaced := false
+			config.ExternalRules = append(config.ExternalRules, adapterRule{
+				SeriesQuery:  externalSeries,
+				Resources:    adapterResources{Namespaced: &namespaced},
+				Name:         adapterName{Matches: "^" + externalSeries + "$", As: metric.MetricsName},
+				MetricsQuery: metric.MetricQuery,
+			})
+		}
+	}
+	body, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("marshal metrics adapter config: %v", err)
+	}
+	return string(body), nil
+}
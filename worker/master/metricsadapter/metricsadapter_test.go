@@ -0,0 +1,84 @@
+package metricsadapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/dao"
+	"github.com/goodrain/rainbond/db/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+type testManager struct {
+	db.Manager
+	metricDao dao.TenantServceAutoscalerRuleMetricsDao
+}
+
+func (m *testManager) TenantServceAutoscalerRuleMetricsDao() dao.TenantServceAutoscalerRuleMetricsDao {
+	return m.metricDao
+}
+
+type fakeMetricDao struct {
+	dao.TenantServceAutoscalerRuleMetricsDao
+	metrics []*model.TenantServiceAutoscalerRuleMetrics
+}
+
+func (f *fakeMetricDao) ListCustomOfEnableRules() ([]*model.TenantServiceAutoscalerRuleMetrics, error) {
+	return f.metrics, nil
+}
+
+// capability_id: rainbond.worker.appm.autoscaler.custom-metrics
+func TestSyncRendersAdapterRules(t *testing.T) {
+	metricDao := &fakeMetricDao{metrics: []*model.TenantServiceAutoscalerRuleMetrics{
+		{RuleID: "r1", MetricsType: model.PodsMetricsType, MetricsName: "http_requests_per_second",
+			MetricQuery: `sum(rate(http_requests_total[1m])) by (namespace, pod)`},
+		{RuleID: "r1", MetricsType: model.ObjectMetricsType, MetricsName: "ingress_hits", ObjectKind: "Ingress", ObjectName: "web",
+			ObjectAPIVersion: "networking.k8s.io/v1", MetricQuery: `sum(rate(nginx_requests[1m])) by (namespace, ingress)`},
+		{RuleID: "r2", MetricsType: model.ExternalMetricsType, MetricsName: "queue_depth",
+			MetricQuery: `sum(rabbitmq_queue_messages_ready{queue="orders"})`},
+		// the same name with another query is ignored
+		{RuleID: "r3", MetricsType: model.PodsMetricsType, MetricsName: "http_requests_per_second", MetricQuery: "up"},
+	}}
+	clientset := fake.NewSimpleClientset()
+	c := New(&testManager{metricDao: metricDao}, clientset, "rbd-system")
+	if err := c.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := clientset.CoreV1().ConfigMaps("rbd-system").Get(context.Background(), ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config adapterConfig
+	if err := yaml.Unmarshal([]byte(cm.Data[ConfigKey]), &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Rules) != 2 || len(config.ExternalRules) != 1 {
+		t.Fatalf("unexpected adapter config %s", cm.Data[ConfigKey])
+	}
+	pods := config.Rules[0]
+	if pods.Name.As != "http_requests_per_second" || !strings.Contains(pods.MetricsQuery, "http_requests_total") || pods.Resources.Overrides["pod"].Resource != "pod" {
+		t.Fatalf("unexpected pods rule %+v", pods)
+	}
+	object := config.Rules[1]
+	if object.Name.As != "ingress_hits" || object.Resources.Overrides["ingress"].Group != "networking.k8s.io" {
+		t.Fatalf("unexpected object rule %+v", object)
+	}
+	external := config.ExternalRules[0]
+	if external.Name.As != "queue_depth" || external.Resources.Namespaced == nil || *external.Resources.Namespaced {
+		t.Fatalf("unexpected external rule %+v", external)
+	}
+
+	// rules removed from the database are removed from the adapter config
+	metricDao.metrics = nil
+	if err := c.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cm, _ = clientset.CoreV1().ConfigMaps("rbd-system").Get(context.Background(), ConfigMapName, metav1.GetOptions{})
+	if err := yaml.Unmarshal([]byte(cm.Data[ConfigKey]), &config); err != nil || len(config.Rules) != 0 || len(config.ExternalRules) != 0 {
+		t.Fatalf("expected empty adapter config, got %s", cm.Data[ConfigKey])
+	}
+}
@@ -0,0 +1,246 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2024 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package scheduledscaling
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/util/cron"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// syncInterval cron expressions have a minute precision
+	syncInterval = 30 * time.Second
+	// maxCatchUp activations missed longer than it, eg: the worker was down, are not replayed
+	maxCatchUp = 24 * time.Hour
+	// recordType record type of the scaling records created by scheduled scaling
+	recordType = "scheduled"
+)
+
+// appServiceGetter is implemented by the app runtime store
+type appServiceGetter interface {
+	GetAppService(serviceID string) *v1.AppService
+}
+
+// Controller applies the scheduled scaling rules of the components. When the component
+// has enabled autoscaler rules the HPA bounds are adjusted, otherwise the replicas of
+// the component are set to min_replicas.
+type Controller struct {
+	dbmanager db.Manager
+	clientset kubernetes.Interface
+	store     appServiceGetter
+	hpaV2     bool
+	now       func() time.Time
+	// failed the activation each schedule last failed to apply, the failure is recorded once
+	// and the activation is retried until it succeeds or leaves the catch-up window
+	failed map[string]time.Time
+}
+
+// New creates a scheduled scaling controller, hpaV2 tells whether the cluster serves autoscaling/v2
+func New(dbmanager db.Manager, clientset kubernetes.Interface, store appServiceGetter, hpaV2 bool) *Controller {
+	return &Controller{
+		dbmanager: dbmanager,
+		clientset: clientset,
+		store:     store,
+		hpaV2:     hpaV2,
+		now:       time.Now,
+		failed:    make(map[string]time.Time),
+	}
+}
+
+// Start checks the schedules periodically until the context is done
+func (c *Controller) Start(ctx context.Context) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sync(ctx)
+		}
+	}
+}
+
+func (c *Controller) sync(ctx context.Context) {
+	schedules, err := c.dbmanager.TenantServiceScheduledScalingDao().ListEnableOnes()
+	if err != nil {
+		logrus.Warningf("list scheduled scaling: %v", err)
+		return
+	}
+	now := c.now()
+	for _, schedule := range schedules {
+		due, err := dueTime(schedule, now)
+		if err != nil {
+			logrus.Warningf("schedule %s of component %s: %v", schedule.ScheduleID, schedule.ServiceID, err)
+			continue
+		}
+		if due.IsZero() {
+			continue
+		}
+		if err := c.apply(ctx, schedule, due); err != nil {
+			// LastTime is kept so the activation is retried on the next sync
+			continue
+		}
+
+		schedule.LastTime = &now
+		if err := c.dbmanager.TenantServiceScheduledScalingDao().UpdateModel(schedule); err != nil {
+			logrus.Warningf("update last time of schedule %s: %v", schedule.ScheduleID, err)
+		}
+	}
+}
+
+// dueTime returns the latest activation of the schedule that has not been applied yet,
+// the zero time means nothing to do.
+func dueTime(schedule *model.TenantServiceScheduledScaling, now time.Time) (time.Time, error) {
+	sched, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc := time.UTC
+	if schedule.Timezone != "" {
+		if loc, err = time.LoadLocation(schedule.Timezone); err != nil {
+			return time.Time{}, fmt.Errorf("load timezone %s: %v", schedule.Timezone, err)
+		}
+	}
+	since := schedule.CreatedAt
+	if schedule.LastTime != nil {
+		since = *schedule.LastTime
+	}
+	// every activation sets the whole replicas range, so only the latest missed one matters
+	if since.Before(now.Add(-maxCatchUp)) {
+		since = now.Add(-maxCatchUp)
+	}
+	return sched.Prev(since.In(loc), now.In(loc)), nil
+}
+
+// apply scales the component by the schedule and saves a scaling record
+func (c *Controller) apply(ctx context.Context, schedule *model.TenantServiceScheduledScaling, due time.Time) error {
+	var desc string
+	err := func() error {
+		as := c.store.GetAppService(schedule.ServiceID)
+		if as == nil || as.IsClosed() {
+			return fmt.Errorf("component is not running")
+		}
+		rules, err := c.dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByServiceID(schedule.ServiceID)
+		if err != nil {
+			return fmt.Errorf("list autoscaler rules: %v", err)
+		}
+		if len(rules) > 0 {
+			minReplicas := schedule.MinReplicas
+			if minReplicas < 1 {
+				// the hpa does not scale to zero
+				minReplicas = 1
+			}
+			desc = fmt.Sprintf("the hpa replicas range is set to [%d, %d] by schedule %s", minReplicas, schedule.MaxReplicas, schedule.Cron)
+			return c.scaleHPAs(ctx, as, rules, minReplicas, schedule.MaxReplicas)
+		}
+		desc = fmt.Sprintf("the replicas is scaling from %d to %d by schedule %s", as.Replicas, schedule.MinReplicas, schedule.Cron)
+		return c.scaleReplicas(ctx, as, schedule.MinReplicas)
+	}()
+
+	reason := "SuccessfulScheduledRescale"
+	if err != nil {
+		logrus.Warningf("apply schedule %s of component %s: %v", schedule.ScheduleID, schedule.ServiceID, err)
+		if last, ok := c.failed[schedule.ScheduleID]; ok && last.Equal(due) {
+			return err
+		}
+		c.failed[schedule.ScheduleID] = due
+		reason = "FailedScheduledRescale"
+		desc = fmt.Sprintf("scheduled scaling %s: %v", schedule.Cron, err)
+	} else {
+		delete(c.failed, schedule.ScheduleID)
+	}
+	record := &model.TenantServiceScalingRecords{
+		ServiceID:   schedule.ServiceID,
+		RuleID:      schedule.ScheduleID,
+		EventName:   util.NewUUID(),
+		RecordType:  recordType,
+		Reason:      reason,
+		Count:       1,
+		Description: desc,
+		Operator:    "system",
+		LastTime:    due,
+	}
+	if err := c.dbmanager.TenantServiceScalingRecordsDao().AddModel(record); err != nil {
+		logrus.Warningf("save scaling record: %v", err)
+	}
+	return err
+}
+
+// scaleHPAs saves the replicas range as the scheduled override of the autoscaler rules, the
+// replicas range configured by the user is kept, and patches the running HPAs.
+func (c *Controller) scaleHPAs(ctx context.Context, as *v1.AppService, rules []*model.TenantServiceAutoscalerRules, minReplicas, maxReplicas int) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"minReplicas":%d,"maxReplicas":%d}}`, minReplicas, maxReplicas))
+	for _, rule := range rules {
+		rule.ScheduledMinReplicas = util.Int(minReplicas)
+		rule.ScheduledMaxReplicas = util.Int(maxReplicas)
+		if err := c.dbmanager.TenantServceAutoscalerRulesDao().UpdateModel(rule); err != nil {
+			return fmt.Errorf("update autoscaler rule %s: %v", rule.RuleID, err)
+		}
+
+		var err error
+		if c.hpaV2 {
+			_, err = c.clientset.AutoscalingV2().HorizontalPodAutoscalers(as.GetNamespace()).Patch(ctx, rule.RuleID, types.MergePatchType, patch, metav1.PatchOptions{})
+		} else {
+			_, err = c.clientset.AutoscalingV2beta2().HorizontalPodAutoscalers(as.GetNamespace()).Patch(ctx, rule.RuleID, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+		// the hpa is created from the rule on the next refresh
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("patch hpa %s: %v", rule.RuleID, err)
+		}
+	}
+	return nil
+}
+
+// scaleReplicas saves the replicas of the component and scales its workload
+func (c *Controller) scaleReplicas(ctx context.Context, as *v1.AppService, replicas int) error {
+	component, err := c.dbmanager.TenantServiceDao().GetServiceByID(as.ServiceID)
+	if err != nil {
+		return fmt.Errorf("get component: %v", err)
+	}
+	component.Replicas = replicas
+	if err := c.dbmanager.TenantServiceDao().UpdateModel(component); err != nil {
+		return fmt.Errorf("update component replicas: %v", err)
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	if sts := as.GetStatefulSet(); sts != nil {
+		_, err = c.clientset.AppsV1().StatefulSets(sts.Namespace).Patch(ctx, sts.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	} else if deploy := as.GetDeployment(); deploy != nil {
+		_, err = c.clientset.AppsV1().Deployments(deploy.Namespace).Patch(ctx, deploy.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	} else {
+		return fmt.Errorf("component has no deployment or statefulset")
+	}
+	if err != nil {
+		return fmt.Errorf("scale workload: %v", err)
+	}
+	return nil
+}
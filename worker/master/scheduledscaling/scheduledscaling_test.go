@@ -0,0 +1,279 @@
+package scheduledscaling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/dao"
+	"github.com/goodrain/rainbond/db/model"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeStore map[string]*v1.AppService
+
+func (f fakeStore) GetAppService(serviceID string) *v1.AppService {
+	return f[serviceID]
+}
+
+// testManager serves the daos used by the controller, other methods of db.Manager are not called
+type testManager struct {
+	db.Manager
+	scheduleDao dao.TenantServiceScheduledScalingDao
+	ruleDao     dao.TenantServceAutoscalerRulesDao
+	recordDao   dao.TenantServiceScalingRecordsDao
+	serviceDao  dao.TenantServiceDao
+}
+
+func (m *testManager) TenantServiceScheduledScalingDao() dao.TenantServiceScheduledScalingDao {
+	return m.scheduleDao
+}
+
+func (m *testManager) TenantServceAutoscalerRulesDao() dao.TenantServceAutoscalerRulesDao {
+	return m.ruleDao
+}
+
+func (m *testManager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
+	return m.recordDao
+}
+
+func (m *testManager) TenantServiceDao() dao.TenantServiceDao {
+	return m.serviceDao
+}
+
+// the generated mocks of these daos are out of date, so minimal fakes are used
+type fakeRuleDao struct {
+	dao.TenantServceAutoscalerRulesDao
+	rules   []*model.TenantServiceAutoscalerRules
+	updated int
+}
+
+func (f *fakeRuleDao) ListEnableOnesByServiceID(serviceID string) ([]*model.TenantServiceAutoscalerRules, error) {
+	return f.rules, nil
+}
+
+func (f *fakeRuleDao) UpdateModel(mo model.Interface) error {
+	f.updated++
+	return nil
+}
+
+type fakeServiceDao struct {
+	dao.TenantServiceDao
+	component *model.TenantServices
+	updated   int
+}
+
+func (f *fakeServiceDao) GetServiceByID(serviceID string) (*model.TenantServices, error) {
+	return f.component, nil
+}
+
+func (f *fakeServiceDao) UpdateModel(mo model.Interface) error {
+	f.updated++
+	return nil
+}
+
+func newAppService(serviceID string) *v1.AppService {
+	as := &v1.AppService{}
+	as.ServiceID = serviceID
+	replicas := int32(3)
+	as.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}})
+	as.SetDeployment(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceID + "-deployment", Namespace: "tenant", ResourceVersion: "1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	return as
+}
+
+// capability_id: rainbond.worker.scheduled-scaling.apply
+func TestDueTime(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load timezone: %v", err)
+	}
+	created := time.Date(2026, 3, 2, 8, 0, 0, 0, shanghai)
+	schedule := &model.TenantServiceScheduledScaling{Cron: "0 9 * * *", Timezone: "Asia/Shanghai"}
+	schedule.CreatedAt = created
+
+	now := time.Date(2026, 3, 2, 1, 0, 20, 0, time.UTC) // 09:00:20 in Shanghai
+	due, err := dueTime(schedule, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 2, 9, 0, 0, 0, shanghai); !due.Equal(want) {
+		t.Fatalf("want %s, got %s", want, due)
+	}
+
+	schedule.LastTime = &now
+	if due, _ := dueTime(schedule, now.Add(time.Minute)); !due.IsZero() {
+		t.Fatalf("applied activation must not be due again, got %s", due)
+	}
+
+	// only the latest activation of a long outage is applied
+	longAgo := now.AddDate(0, 0, -10)
+	schedule.LastTime = &longAgo
+	due, _ = dueTime(schedule, now.Add(2*time.Hour))
+	if want := time.Date(2026, 3, 2, 9, 0, 0, 0, shanghai); !due.Equal(want) {
+		t.Fatalf("want %s, got %s", want, due)
+	}
+
+	schedule.Timezone = "Mars/Olympus"
+	if _, err := dueTime(schedule, now); err == nil {
+		t.Fatal("expected unknown timezone to be rejected")
+	}
+}
+
+// capability_id: rainbond.worker.scheduled-scaling.apply
+func TestSyncAdjustsHPABounds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 3, 2, 9, 0, 10, 0, time.UTC)
+	schedule := &model.TenantServiceScheduledScaling{ScheduleID: "s1", ServiceID: "c1", Cron: "0 9 * * *", MinReplicas: 0, MaxReplicas: 8, Enable: true}
+	schedule.CreatedAt = now.Add(-time.Hour)
+	rule := &model.TenantServiceAutoscalerRules{RuleID: "r1", ServiceID: "c1", Enable: true, MinReplicas: 1, MaxReplicas: 2}
+
+	scheduleDao := dao.NewMockTenantServiceScheduledScalingDao(ctrl)
+	ruleDao := &fakeRuleDao{rules: []*model.TenantServiceAutoscalerRules{rule}}
+	recordDao := dao.NewMockTenantServiceScalingRecordsDao(ctrl)
+	dbmanager := &testManager{scheduleDao: scheduleDao, ruleDao: ruleDao, recordDao: recordDao}
+
+	scheduleDao.EXPECT().ListEnableOnes().Return([]*model.TenantServiceScheduledScaling{schedule}, nil)
+	scheduleDao.EXPECT().UpdateModel(schedule).Return(nil)
+	var record *model.TenantServiceScalingRecords
+	recordDao.EXPECT().AddModel(gomock.Any()).DoAndReturn(func(mo model.Interface) error {
+		record = mo.(*model.TenantServiceScalingRecords)
+		return nil
+	})
+
+	clientset := fake.NewSimpleClientset(&autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "tenant"},
+		Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{MaxReplicas: 2},
+	})
+	c := New(dbmanager, clientset, fakeStore{"c1": newAppService("c1")}, true)
+	c.now = func() time.Time { return now }
+	c.sync(context.Background())
+
+	hpa, err := clientset.AutoscalingV2().HorizontalPodAutoscalers("tenant").Get(context.Background(), "r1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hpa.Spec.MinReplicas == nil || *hpa.Spec.MinReplicas != 1 || hpa.Spec.MaxReplicas != 8 {
+		t.Fatalf("unexpected hpa bounds: %v-%d", hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	// the range of the rule is kept, the schedule is saved as an override
+	if minReplicas, maxReplicas := rule.ReplicasRange(); ruleDao.updated != 1 || minReplicas != 1 || maxReplicas != 8 {
+		t.Fatalf("scheduled range not saved: %d-%d", minReplicas, maxReplicas)
+	}
+	if rule.MinReplicas != 1 || rule.MaxReplicas != 2 {
+		t.Fatalf("rule range must not be changed, got %d-%d", rule.MinReplicas, rule.MaxReplicas)
+	}
+	if record == nil || record.Reason != "SuccessfulScheduledRescale" || record.RecordType != recordType || record.RuleID != "s1" {
+		t.Fatalf("unexpected scaling record: %#v", record)
+	}
+	if schedule.LastTime == nil || !schedule.LastTime.Equal(now) {
+		t.Fatalf("last time not updated: %v", schedule.LastTime)
+	}
+}
+
+// capability_id: rainbond.worker.scheduled-scaling.apply
+func TestSyncScalesReplicasWithoutHPA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 3, 2, 22, 0, 5, 0, time.UTC)
+	schedule := &model.TenantServiceScheduledScaling{ScheduleID: "s2", ServiceID: "c2", Cron: "0 22 * * *", MinReplicas: 0, MaxReplicas: 1, Enable: true}
+	schedule.CreatedAt = now.Add(-time.Hour)
+	component := &model.TenantServices{ServiceID: "c2", Replicas: 3}
+
+	scheduleDao := dao.NewMockTenantServiceScheduledScalingDao(ctrl)
+	ruleDao := &fakeRuleDao{}
+	recordDao := dao.NewMockTenantServiceScalingRecordsDao(ctrl)
+	serviceDao := &fakeServiceDao{component: component}
+	dbmanager := &testManager{scheduleDao: scheduleDao, ruleDao: ruleDao, recordDao: recordDao, serviceDao: serviceDao}
+
+	scheduleDao.EXPECT().ListEnableOnes().Return([]*model.TenantServiceScheduledScaling{schedule}, nil)
+	scheduleDao.EXPECT().UpdateModel(schedule).Return(nil)
+	var record *model.TenantServiceScalingRecords
+	recordDao.EXPECT().AddModel(gomock.Any()).DoAndReturn(func(mo model.Interface) error {
+		record = mo.(*model.TenantServiceScalingRecords)
+		return nil
+	})
+
+	replicas := int32(3)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "c2-deployment", Namespace: "tenant"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	c := New(dbmanager, clientset, fakeStore{"c2": newAppService("c2")}, true)
+	c.now = func() time.Time { return now }
+	c.sync(context.Background())
+
+	deploy, err := clientset.AppsV1().Deployments("tenant").Get(context.Background(), "c2-deployment", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deploy.Spec.Replicas == nil || *deploy.Spec.Replicas != 0 {
+		t.Fatalf("expected deployment scaled to 0, got %v", deploy.Spec.Replicas)
+	}
+	if serviceDao.updated != 1 || component.Replicas != 0 {
+		t.Fatalf("expected component replicas saved, got %d", component.Replicas)
+	}
+	if record == nil || record.Reason != "SuccessfulScheduledRescale" {
+		t.Fatalf("unexpected scaling record: %#v", record)
+	}
+}
+
+// capability_id: rainbond.worker.scheduled-scaling.apply
+func TestSyncRetriesFailedActivation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 3, 2, 9, 0, 10, 0, time.UTC)
+	schedule := &model.TenantServiceScheduledScaling{ScheduleID: "s3", ServiceID: "c3", Cron: "0 9 * * *", MinReplicas: 1, MaxReplicas: 2, Enable: true}
+	schedule.CreatedAt = now.Add(-time.Hour)
+
+	scheduleDao := dao.NewMockTenantServiceScheduledScalingDao(ctrl)
+	recordDao := dao.NewMockTenantServiceScalingRecordsDao(ctrl)
+	dbmanager := &testManager{scheduleDao: scheduleDao, ruleDao: &fakeRuleDao{}, recordDao: recordDao}
+
+	// the component is not running, the failure is recorded once and LastTime is not advanced
+	scheduleDao.EXPECT().ListEnableOnes().Return([]*model.TenantServiceScheduledScaling{schedule}, nil).Times(2)
+	recordDao.EXPECT().AddModel(gomock.Any()).DoAndReturn(func(mo model.Interface) error {
+		if reason := mo.(*model.TenantServiceScalingRecords).Reason; reason != "FailedScheduledRescale" {
+			t.Fatalf("unexpected reason %s", reason)
+		}
+		return nil
+	}).Times(1)
+
+	store := fakeStore{}
+	c := New(dbmanager, fake.NewSimpleClientset(), store, true)
+	c.now = func() time.Time { return now }
+	c.sync(context.Background())
+	c.now = func() time.Time { return now.Add(syncInterval) }
+	c.sync(context.Background())
+	if schedule.LastTime != nil {
+		t.Fatalf("last time must not advance on failure, got %v", schedule.LastTime)
+	}
+
+	// the activation is applied once the component is running
+	store["c3"] = newAppService("c3")
+	component := &model.TenantServices{ServiceID: "c3", Replicas: 3}
+	dbmanager.serviceDao = &fakeServiceDao{component: component}
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "c3-deployment", Namespace: "tenant"}})
+	c.clientset = clientset
+	scheduleDao.EXPECT().ListEnableOnes().Return([]*model.TenantServiceScheduledScaling{schedule}, nil)
+	scheduleDao.EXPECT().UpdateModel(schedule).Return(nil)
+	recordDao.EXPECT().AddModel(gomock.Any()).Return(nil)
+	c.now = func() time.Time { return now.Add(2 * syncInterval) }
+	c.sync(context.Background())
+	if schedule.LastTime == nil || component.Replicas != 1 {
+		t.Fatalf("retried activation not applied, last time %v replicas %d", schedule.LastTime, component.Replicas)
+	}
+}